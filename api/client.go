@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net"
@@ -32,6 +33,7 @@ const EnvVaultTLSServerName = "VAULT_TLS_SERVER_NAME"
 const EnvVaultWrapTTL = "VAULT_WRAP_TTL"
 const EnvVaultMaxRetries = "VAULT_MAX_RETRIES"
 const EnvVaultToken = "VAULT_TOKEN"
+const EnvVaultNamespace = "VAULT_NAMESPACE"
 
 // WrappingLookupFunc is a function that, given an HTTP verb and a path,
 // returns an optional string duration to be used for response wrapping (e.g.
@@ -40,6 +42,10 @@ const EnvVaultToken = "VAULT_TOKEN"
 // called path precisely.
 type WrappingLookupFunc func(operation, path string) string
 
+// BackoffFunc returns how long to wait before the given retry of a request,
+// starting at 1.
+type BackoffFunc func(retry int) time.Duration
+
 // Config is used to configure the creation of the client.
 type Config struct {
 	// Address is the address of the Vault server. This should be a complete
@@ -50,14 +56,25 @@ type Config struct {
 
 	// HttpClient is the HTTP client to use, which will currently always have the
 	// same values as http.DefaultClient. This is used to control redirect behavior.
+	//
+	// Its transport may be any http.RoundTripper, for example one that
+	// instruments or routes requests, but TLS settings and unix socket
+	// addresses can only be applied to an *http.Transport. The default
+	// transport sends requests through the proxy given by the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables.
 	HttpClient *http.Client
 
 	redirectSetup sync.Once
 
 	// MaxRetries controls the maximum number of times to retry when a 5xx error
-	// occurs. Set to 0 or less to disable retrying. Defaults to 0.
+	// or a connection error occurs. Set to 0 or less to disable retrying.
+	// Defaults to 2.
 	MaxRetries int
 
+	// Backoff returns how long to wait before a retry. Defaults to a linear
+	// backoff of one second per retry with a jitter of up to a third.
+	Backoff BackoffFunc
+
 	// Timeout is for setting custom timeout parameter in the HttpClient
 	Timeout time.Duration
 }
@@ -96,6 +113,8 @@ func DefaultConfig() *Config {
 	config := &Config{
 		Address:    "https://127.0.0.1:8200",
 		HttpClient: cleanhttp.DefaultClient(),
+		MaxRetries: 2,
+		Backoff:    pester.LinearJitterBackoff,
 	}
 	config.HttpClient.Timeout = time.Second * 60
 	transport := config.HttpClient.Transport.(*http.Transport)
@@ -117,6 +136,19 @@ func (c *Config) ConfigureTLS(t *TLSConfig) error {
 		c.HttpClient = DefaultConfig().HttpClient
 	}
 
+	tp, ok := c.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		if t.CACert != "" || t.CAPath != "" || t.ClientCert != "" || t.ClientKey != "" || t.Insecure || t.TLSServerName != "" {
+			return fmt.Errorf("TLS can only be configured on an *http.Transport, not %T", c.HttpClient.Transport)
+		}
+		return nil
+	}
+	if tp.TLSClientConfig == nil {
+		tp.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
 	var clientCert tls.Certificate
 	foundClientCert := false
 	if t.CACert != "" || t.CAPath != "" || t.ClientCert != "" || t.ClientKey != "" || t.Insecure {
@@ -132,7 +164,7 @@ func (c *Config) ConfigureTLS(t *TLSConfig) error {
 		}
 	}
 
-	clientTLSConfig := c.HttpClient.Transport.(*http.Transport).TLSClientConfig
+	clientTLSConfig := tp.TLSClientConfig
 	rootConfig := &rootcerts.Config{
 		CAFile: t.CACert,
 		CAPath: t.CAPath,
@@ -226,7 +258,7 @@ func (c *Config) ReadEnvironment() error {
 	}
 
 	if envMaxRetries != nil {
-		c.MaxRetries = int(*envMaxRetries) + 1
+		c.MaxRetries = int(*envMaxRetries)
 	}
 
 	if envClientTimeout != 0 {
@@ -242,6 +274,7 @@ type Client struct {
 	addr               *url.URL
 	config             *Config
 	token              string
+	namespace          string
 	wrappingLookupFunc WrappingLookupFunc
 }
 
@@ -266,10 +299,34 @@ func NewClient(c *Config) (*Client, error) {
 	if c.HttpClient == nil {
 		c.HttpClient = DefaultConfig().HttpClient
 	}
+	if c.HttpClient.Transport == nil {
+		c.HttpClient.Transport = DefaultConfig().HttpClient.Transport
+	}
 
-	tp := c.HttpClient.Transport.(*http.Transport)
-	if err := http2.ConfigureTransport(tp); err != nil {
-		return nil, err
+	// A custom transport is used as is
+	tp, ok := c.HttpClient.Transport.(*http.Transport)
+	if ok {
+		// The transport may already have been configured by a client created
+		// from the same config, such as by Clone
+		if _, ok := tp.TLSNextProto["h2"]; !ok {
+			if err := http2.ConfigureTransport(tp); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// An address of the form unix:///path/to/socket connects to a unix
+	// listener; every request is sent over the socket, never through a proxy
+	if u.Scheme == "unix" {
+		if tp == nil {
+			return nil, fmt.Errorf("unix socket addresses require an *http.Transport, not %T", c.HttpClient.Transport)
+		}
+		socket := u.Path
+		tp.Proxy = nil
+		tp.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
 	}
 
 	redirFunc := func() {
@@ -297,6 +354,10 @@ func NewClient(c *Config) (*Client, error) {
 		client.SetToken(token)
 	}
 
+	if namespace := os.Getenv(EnvVaultNamespace); namespace != "" {
+		client.SetNamespace(namespace)
+	}
+
 	return client, nil
 }
 
@@ -322,6 +383,11 @@ func (c *Client) SetMaxRetries(retries int) {
 	c.config.MaxRetries = retries
 }
 
+// SetBackoff sets the function returning how long to wait before a retry
+func (c *Client) SetBackoff(backoff BackoffFunc) {
+	c.config.Backoff = backoff
+}
+
 // SetClientTimeout sets the client request timeout
 func (c *Client) SetClientTimeout(timeout time.Duration) {
 	c.config.Timeout = timeout
@@ -350,6 +416,18 @@ func (c *Client) ClearToken() {
 	c.token = ""
 }
 
+// Namespace returns the namespace the requests of this client are made in.
+// It will return the empty string for the root namespace.
+func (c *Client) Namespace() string {
+	return c.namespace
+}
+
+// SetNamespace sets the namespace the requests of this client are made in,
+// through the X-Vault-Namespace header.
+func (c *Client) SetNamespace(namespace string) {
+	c.namespace = namespace
+}
+
 // Clone creates a copy of this client.
 func (c *Client) Clone() (*Client, error) {
 	return NewClient(c.config)
@@ -362,7 +440,14 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 	// if SRV records exist (see https://tools.ietf.org/html/draft-andrews-http-srv-02), lookup the SRV
 	// record and take the highest match; this is not designed for high-availability, just discovery
 	var host string = c.addr.Host
-	if c.addr.Port() == "" {
+	scheme := c.addr.Scheme
+	addrPath := c.addr.Path
+	if scheme == "unix" {
+		// The transport dials the socket, so the URL only needs to be valid
+		scheme = "http"
+		host = "localhost"
+		addrPath = ""
+	} else if c.addr.Port() == "" {
 		// Internet Draft specifies that the SRV record is ignored if a port is given
 		_, addrs, err := net.LookupSRV("http", "tcp", c.addr.Hostname())
 		if err == nil && len(addrs) > 0 {
@@ -374,14 +459,19 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 		Method: method,
 		URL: &url.URL{
 			User:   c.addr.User,
-			Scheme: c.addr.Scheme,
+			Scheme: scheme,
 			Host:   host,
-			Path:   path.Join(c.addr.Path, requestPath),
+			Path:   path.Join(addrPath, requestPath),
 		},
 		ClientToken: c.token,
 		Params:      make(map[string][]string),
 	}
 
+	if c.namespace != "" {
+		req.Headers = http.Header{}
+		req.Headers.Set("X-Vault-Namespace", c.namespace)
+	}
+
 	var lookupPath string
 	switch {
 	case strings.HasPrefix(requestPath, "/v1/"):
@@ -407,16 +497,34 @@ func (c *Client) NewRequest(method, requestPath string) *Request {
 // a Vault server not configured with this client. This is an advanced operation
 // that generally won't need to be called externally.
 func (c *Client) RawRequest(r *Request) (*Response, error) {
+	return c.RawRequestWithContext(context.Background(), r)
+}
+
+// RawRequestWithContext performs the raw request given, like RawRequest. The
+// request and its retries are abandoned once the context is done.
+func (c *Client) RawRequestWithContext(ctx context.Context, r *Request) (*Response, error) {
+	backoff := c.config.Backoff
+	if backoff == nil {
+		backoff = pester.LinearJitterBackoff
+	}
+
 	redirectCount := 0
 START:
 	req, err := r.ToHTTP()
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 
+	// pester counts the first attempt as well as the retries
 	client := pester.NewExtendedClient(c.config.HttpClient)
-	client.Backoff = pester.LinearJitterBackoff
-	client.MaxRetries = c.config.MaxRetries
+	client.MaxRetries = c.config.MaxRetries + 1
+	client.Backoff = func(retry int) time.Duration {
+		if ctx.Err() != nil {
+			return 0
+		}
+		return backoff(retry)
+	}
 
 	var result *Response
 	resp, err := client.Do(req)
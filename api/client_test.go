@@ -2,9 +2,15 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -47,6 +53,75 @@ func TestClientNilConfig(t *testing.T) {
 	}
 }
 
+func TestClientClone(t *testing.T) {
+	client, err := NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken("foo")
+
+	clone, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Address() != client.Address() {
+		t.Fatalf("bad: expected: %q actual: %q", client.Address(), clone.Address())
+	}
+
+	clone.SetToken("bar")
+	if client.Token() != "foo" {
+		t.Fatalf("bad: token changed on the original client: %q", client.Token())
+	}
+}
+
+type countingRoundTripper struct {
+	count int32
+	rt    http.RoundTripper
+}
+
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.count, 1)
+	return c.rt.RoundTrip(req)
+}
+
+func TestClientCustomTransport(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("test"))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	// The default transport honours the proxy environment variables
+	if config.HttpClient.Transport.(*http.Transport).Proxy == nil {
+		t.Fatal("expected the default transport to use the proxy environment variables")
+	}
+
+	rt := &countingRoundTripper{rt: config.HttpClient.Transport}
+	config.HttpClient.Transport = rt
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.RawRequest(client.NewRequest("GET", "/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if rt.count != 1 {
+		t.Fatalf("bad: %d requests through the custom transport", rt.count)
+	}
+
+	// TLS settings cannot be applied to a custom transport
+	if err := config.ConfigureTLS(&TLSConfig{Insecure: true}); err == nil {
+		t.Fatal("expected error configuring TLS on a custom transport")
+	}
+	if err := config.ConfigureTLS(&TLSConfig{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestClientSetAddress(t *testing.T) {
 	client, err := NewClient(nil)
 	if err != nil {
@@ -124,6 +199,124 @@ func TestClientRedirect(t *testing.T) {
 	}
 }
 
+func TestClientUnixSocket(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-api-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	socket := filepath.Join(td, "vault.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer ln.Close()
+
+	var path string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		w.Write([]byte("test"))
+	}
+	go http.Serve(ln, http.HandlerFunc(handler))
+
+	config := DefaultConfig()
+	config.Address = "unix://" + socket
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := client.RawRequest(client.NewRequest("GET", "/v1/sys/health"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	io.Copy(&buf, resp.Body)
+	if buf.String() != "test" {
+		t.Fatalf("Bad: %s", buf.String())
+	}
+	if path != "/v1/sys/health" {
+		t.Fatalf("bad path: %s", path)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var count int32
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.WriteHeader(500)
+			return
+		}
+		w.Write([]byte("test"))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	if config.MaxRetries != 2 {
+		t.Fatalf("bad: default retries: %d", config.MaxRetries)
+	}
+	config.Backoff = func(int) time.Duration { return 0 }
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.RawRequest(client.NewRequest("PUT", "/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if count != 3 {
+		t.Fatalf("bad: %d attempts", count)
+	}
+
+	// Without retries the first error is returned
+	atomic.StoreInt32(&count, 0)
+	client.SetMaxRetries(0)
+	resp, err = client.RawRequest(client.NewRequest("PUT", "/"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if resp == nil || resp.StatusCode != 500 {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if count != 1 {
+		t.Fatalf("bad: %d attempts", count)
+	}
+}
+
+func TestClientRawRequestWithContext(t *testing.T) {
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(500)
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.RawRequestWithContext(ctx, client.NewRequest("GET", "/")); err == nil {
+		t.Fatal("expected error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("request was not abandoned with the context, took %s", elapsed)
+	}
+}
+
 func TestClientEnvSettings(t *testing.T) {
 	cwd, _ := os.Getwd()
 	oldCACert := os.Getenv(EnvVaultCACert)
@@ -160,6 +353,9 @@ func TestClientEnvSettings(t *testing.T) {
 	if tlsConfig.InsecureSkipVerify != true {
 		t.Fatalf("bad: %v", tlsConfig.InsecureSkipVerify)
 	}
+	if config.MaxRetries != 5 {
+		t.Fatalf("bad: %d", config.MaxRetries)
+	}
 }
 
 func TestClientTimeoutSetting(t *testing.T) {
@@ -185,3 +381,46 @@ func TestClientTimeoutSetting(t *testing.T) {
 	}
 
 }
+
+func TestClientList(t *testing.T) {
+	var paths []string
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		// Lists are sent as a GET, since proxies may drop the LIST method
+		if req.Method != "GET" || req.URL.Query().Get("list") != "true" {
+			t.Errorf("bad: %s %s", req.Method, req.URL)
+		}
+		paths = append(paths, req.URL.Path)
+		w.Write([]byte(`{"data": {"keys": ["foo"]}}`))
+	}
+	config, ln := testHTTPServer(t, http.HandlerFunc(handler))
+	defer ln.Close()
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client.SetToken("foo")
+
+	if _, err := client.Logical().List("secret/"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.Auth().Token().ListAccessors(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.Sys().ListLeases("secret/"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.Sys().ListPlugins(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []string{
+		"/v1/secret",
+		"/v1/auth/token/accessors",
+		"/v1/sys/leases/lookup/secret",
+		"/v1/sys/plugins/catalog",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("bad: %#v", paths)
+	}
+}
@@ -22,8 +22,9 @@ func (r *Response) DecodeJSON(out interface{}) error {
 }
 
 // Error returns an error response if there is one. If there is an error,
-// this will fully consume the response body, but will not close it. The
-// body must still be closed manually.
+// this will fully consume the response body and replace it with a copy that
+// can be read again, but will not close it. The body must still be closed
+// manually.
 func (r *Response) Error() error {
 	// 200 to 399 are okay status codes. 429 is the code for health status of
 	// standby nodes.
@@ -37,6 +38,10 @@ func (r *Response) Error() error {
 	if _, err := io.Copy(&bodyBuf, r.Body); err != nil {
 		return err
 	}
+	r.Body = &bufferedBody{
+		Reader: bytes.NewReader(bodyBuf.Bytes()),
+		Closer: r.Body,
+	}
 
 	// Decode the error response if we can. Note that we wrap the bodyBuf
 	// in a bytes.Reader here so that the JSON decoder doesn't move the
@@ -66,6 +71,13 @@ func (r *Response) Error() error {
 	return fmt.Errorf(errBody.String())
 }
 
+// bufferedBody is a response body that has been read into memory, keeping
+// the original body so that it is still closed
+type bufferedBody struct {
+	io.Reader
+	io.Closer
+}
+
 // ErrorResponse is the raw structure of errors when they're returned by the
 // HTTP API.
 type ErrorResponse struct {
@@ -120,9 +120,9 @@ type EnableAuditOptions struct {
 }
 
 type Audit struct {
-	Path        string
-	Type        string
-	Description string
-	Options     map[string]string
-	Local       bool
+	Path        string            `json:"path" structs:"path" mapstructure:"path"`
+	Type        string            `json:"type" structs:"type" mapstructure:"type"`
+	Description string            `json:"description" structs:"description" mapstructure:"description"`
+	Options     map[string]string `json:"options" structs:"options" mapstructure:"options"`
+	Local       bool              `json:"local" structs:"local" mapstructure:"local"`
 }
@@ -18,4 +18,5 @@ type LeaderResponse struct {
 	IsSelf               bool   `json:"is_self"`
 	LeaderAddress        string `json:"leader_address"`
 	LeaderClusterAddress string `json:"leader_cluster_address"`
+	LeaderID             string `json:"leader_id"`
 }
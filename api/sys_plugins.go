@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+func (c *Sys) ListPlugins() ([]string, error) {
+	r := c.c.NewRequest("LIST", "/v1/sys/plugins/catalog")
+	// Set this for broader compatibility, but we use LIST above to be able to
+	// handle the wrapping lookup function
+	r.Method = "GET"
+	r.Params.Set("list", "true")
+	resp, err := c.c.RawRequest(r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("data from server response is empty")
+	}
+
+	var plugins []string
+	keysRaw, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return plugins, nil
+	}
+	for _, val := range keysRaw {
+		plugins = append(plugins, val.(string))
+	}
+
+	return plugins, nil
+}
+
+func (c *Sys) GetPlugin(name string) (*PluginOutput, error) {
+	r := c.c.NewRequest("GET", fmt.Sprintf("/v1/sys/plugins/catalog/%s", name))
+	resp, err := c.c.RawRequest(r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Name    string   `json:"name"`
+			Command string   `json:"command"`
+			Args    []string `json:"args"`
+			SHA256  []byte   `json:"sha256"`
+			Builtin bool     `json:"builtin"`
+		} `json:"data"`
+	}
+	if err := resp.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &PluginOutput{
+		Name:    result.Data.Name,
+		Command: result.Data.Command,
+		Args:    result.Data.Args,
+		SHA256:  hex.EncodeToString(result.Data.SHA256),
+		Builtin: result.Data.Builtin,
+	}, nil
+}
+
+func (c *Sys) RegisterPlugin(name string, input *RegisterPluginInput) error {
+	r := c.c.NewRequest("PUT", fmt.Sprintf("/v1/sys/plugins/catalog/%s", name))
+	if err := r.SetJSONBody(input); err != nil {
+		return err
+	}
+
+	resp, err := c.c.RawRequest(r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) DeregisterPlugin(name string) error {
+	r := c.c.NewRequest("DELETE", fmt.Sprintf("/v1/sys/plugins/catalog/%s", name))
+	resp, err := c.c.RawRequest(r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+func (c *Sys) ReloadPlugin(name string) error {
+	return c.reloadPlugins(map[string]interface{}{
+		"plugin": name,
+	})
+}
+
+func (c *Sys) ReloadPluginMounts(mounts []string) error {
+	return c.reloadPlugins(map[string]interface{}{
+		"mounts": mounts,
+	})
+}
+
+func (c *Sys) reloadPlugins(body map[string]interface{}) error {
+	r := c.c.NewRequest("PUT", "/v1/sys/plugins/reload/backend")
+	if err := r.SetJSONBody(body); err != nil {
+		return err
+	}
+
+	resp, err := c.c.RawRequest(r)
+	if err == nil {
+		defer resp.Body.Close()
+	}
+	return err
+}
+
+type RegisterPluginInput struct {
+	// SHA256 is the hex-encoded SHA256 sum of the plugin binary
+	SHA256  string `json:"sha256" structs:"sha256" mapstructure:"sha256"`
+	Command string `json:"command" structs:"command" mapstructure:"command"`
+}
+
+type PluginOutput struct {
+	Name    string   `json:"name" structs:"name" mapstructure:"name"`
+	Command string   `json:"command" structs:"command" mapstructure:"command"`
+	Args    []string `json:"args" structs:"args" mapstructure:"args"`
+	SHA256  string   `json:"sha256" structs:"sha256" mapstructure:"sha256"`
+	Builtin bool     `json:"builtin" structs:"builtin" mapstructure:"builtin"`
+}
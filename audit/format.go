@@ -96,12 +96,14 @@ func (f *AuditFormatter) FormatRequest(
 		if !config.HMACAccessor && req != nil && req.ClientTokenAccessor != "" {
 			clientTokenAccessor = req.ClientTokenAccessor
 		}
+		nonHMACReqData := cacheDataKeys(req.Data, config.NonHMACRequestKeys)
 		if err := Hash(salt, req); err != nil {
 			return err
 		}
 		if clientTokenAccessor != "" {
 			req.ClientTokenAccessor = clientTokenAccessor
 		}
+		restoreDataKeys(req.Data, nonHMACReqData)
 	}
 
 	// If auth is nil, make an empty one
@@ -231,28 +233,38 @@ func (f *AuditFormatter) FormatResponse(
 		if !config.HMACAccessor && req != nil && req.ClientTokenAccessor != "" {
 			clientTokenAccessor = req.ClientTokenAccessor
 		}
+		nonHMACReqData := cacheDataKeys(req.Data, config.NonHMACRequestKeys)
 		if err := Hash(salt, req); err != nil {
 			return err
 		}
 		if clientTokenAccessor != "" {
 			req.ClientTokenAccessor = clientTokenAccessor
 		}
+		restoreDataKeys(req.Data, nonHMACReqData)
 
 		// Cache and restore accessor in the response
 		if resp != nil {
-			var accessor, wrappedAccessor string
+			var accessor, wrappingAccessor, wrappedAccessor string
 			if !config.HMACAccessor && resp != nil && resp.Auth != nil && resp.Auth.Accessor != "" {
 				accessor = resp.Auth.Accessor
 			}
+			if !config.HMACAccessor && resp != nil && resp.WrapInfo != nil && resp.WrapInfo.Accessor != "" {
+				wrappingAccessor = resp.WrapInfo.Accessor
+			}
 			if !config.HMACAccessor && resp != nil && resp.WrapInfo != nil && resp.WrapInfo.WrappedAccessor != "" {
 				wrappedAccessor = resp.WrapInfo.WrappedAccessor
 			}
+			nonHMACRespData := cacheDataKeys(resp.Data, config.NonHMACResponseKeys)
 			if err := Hash(salt, resp); err != nil {
 				return err
 			}
+			restoreDataKeys(resp.Data, nonHMACRespData)
 			if accessor != "" {
 				resp.Auth.Accessor = accessor
 			}
+			if wrappingAccessor != "" {
+				resp.WrapInfo.Accessor = wrappingAccessor
+			}
 			if wrappedAccessor != "" {
 				resp.WrapInfo.WrappedAccessor = wrappedAccessor
 			}
@@ -299,6 +311,7 @@ func (f *AuditFormatter) FormatResponse(
 		respWrapInfo = &AuditResponseWrapInfo{
 			TTL:             int(resp.WrapInfo.TTL / time.Second),
 			Token:           token,
+			Accessor:        resp.WrapInfo.Accessor,
 			CreationTime:    resp.WrapInfo.CreationTime.Format(time.RFC3339Nano),
 			CreationPath:    resp.WrapInfo.CreationPath,
 			WrappedAccessor: resp.WrapInfo.WrappedAccessor,
@@ -406,6 +419,7 @@ type AuditSecret struct {
 type AuditResponseWrapInfo struct {
 	TTL             int    `json:"ttl"`
 	Token           string `json:"token"`
+	Accessor        string `json:"accessor"`
 	CreationTime    string `json:"creation_time"`
 	CreationPath    string `json:"creation_path"`
 	WrappedAccessor string `json:"wrapped_accessor,omitempty"`
@@ -435,3 +449,29 @@ func parseVaultTokenFromJWT(token string) *string {
 
 	return &result
 }
+
+// cacheDataKeys returns the values of the given keys in data so that they
+// can be restored after hashing
+func cacheDataKeys(data map[string]interface{}, keys []string) map[string]interface{} {
+	if len(data) == 0 || len(keys) == 0 {
+		return nil
+	}
+
+	cached := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if v, ok := data[key]; ok {
+			cached[key] = v
+		}
+	}
+	return cached
+}
+
+// restoreDataKeys puts values cached with cacheDataKeys back into data
+func restoreDataKeys(data map[string]interface{}, cached map[string]interface{}) {
+	if data == nil {
+		return
+	}
+	for k, v := range cached {
+		data[k] = v
+	}
+}
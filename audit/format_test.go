@@ -1,8 +1,11 @@
 package audit
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/vault/helper/salt"
@@ -61,3 +64,65 @@ func TestFormatResponseErrors(t *testing.T) {
 		t.Fatal("expected error due to nil writer")
 	}
 }
+
+func TestFormatResponse_nonHMACKeys(t *testing.T) {
+	salter, err := salt.NewSalt(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter := AuditFormatter{
+		AuditFormatWriter: &JSONFormatWriter{
+			SaltFunc: func() (*salt.Salt, error) { return salter, nil },
+		},
+	}
+	config := FormatterConfig{
+		NonHMACRequestKeys:  []string{"username"},
+		NonHMACResponseKeys: []string{"lease_duration", "missing"},
+	}
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "auth/userpass/login/foo",
+		Data: map[string]interface{}{
+			"username": "foo",
+			"password": "bar",
+		},
+	}
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"lease_duration": "1h",
+			"value":          "baz",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := formatter.FormatResponse(&buf, config, nil, req, resp, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry AuditResponseEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Request.Data["username"] != "foo" {
+		t.Fatalf("expected username to be logged unhashed: %#v", entry.Request.Data)
+	}
+	if !strings.HasPrefix(entry.Request.Data["password"].(string), "hmac-sha256:") {
+		t.Fatalf("expected password to be hashed: %#v", entry.Request.Data)
+	}
+	if entry.Response.Data["lease_duration"] != "1h" {
+		t.Fatalf("expected lease_duration to be logged unhashed: %#v", entry.Response.Data)
+	}
+	if !strings.HasPrefix(entry.Response.Data["value"].(string), "hmac-sha256:") {
+		t.Fatalf("expected value to be hashed: %#v", entry.Response.Data)
+	}
+	if _, ok := entry.Response.Data["missing"]; ok {
+		t.Fatalf("unexpected key added to response data: %#v", entry.Response.Data)
+	}
+
+	// The caller's data must not be modified
+	if req.Data["password"] != "bar" || resp.Data["value"] != "baz" {
+		t.Fatal("original data was modified")
+	}
+}
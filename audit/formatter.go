@@ -20,6 +20,11 @@ type FormatterConfig struct {
 	Raw          bool
 	HMACAccessor bool
 
+	// NonHMACRequestKeys and NonHMACResponseKeys are top-level data keys
+	// whose values are logged without being hashed
+	NonHMACRequestKeys  []string
+	NonHMACResponseKeys []string
+
 	// This should only ever be used in a testing context
 	OmitTime bool
 }
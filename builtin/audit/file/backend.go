@@ -3,12 +3,14 @@ package file
 import (
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
 	"strconv"
 	"sync"
 
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -68,14 +70,48 @@ func Factory(conf *audit.BackendConfig) (audit.Backend, error) {
 		mode = os.FileMode(m)
 	}
 
+	// Check if an owner or group is provided; -1 leaves the value unchanged
+	uid := -1
+	if owner, ok := conf.Config["owner"]; ok {
+		id, err := lookupID(owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner %q: %v", owner, err)
+		}
+		uid = id
+	}
+	gid := -1
+	if group, ok := conf.Config["group"]; ok {
+		id, err := lookupID(group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid group %q: %v", group, err)
+		}
+		gid = id
+	}
+
 	b := &Backend{
 		path:       path,
 		mode:       mode,
+		uid:        uid,
+		gid:        gid,
 		saltConfig: conf.SaltConfig,
 		saltView:   conf.SaltView,
 		formatConfig: audit.FormatterConfig{
-			Raw:          logRaw,
-			HMACAccessor: hmacAccessor,
+			Raw:                 logRaw,
+			HMACAccessor:        hmacAccessor,
+			NonHMACRequestKeys:  strutil.ParseDedupAndSortStrings(conf.Config["non_hmac_request_keys"], ","),
+			NonHMACResponseKeys: strutil.ParseDedupAndSortStrings(conf.Config["non_hmac_response_keys"], ","),
 		},
 	}
 
@@ -116,6 +152,8 @@ type Backend struct {
 	fileLock sync.RWMutex
 	f        *os.File
 	mode     os.FileMode
+	uid      int
+	gid      int
 
 	saltMutex  sync.RWMutex
 	salt       *salt.Salt
@@ -217,8 +255,8 @@ func (b *Backend) open() error {
 		return err
 	}
 
-	// Change the file mode in case the log file already existed. We special
-	// case /dev/null since we can't chmod it
+	// Change the file mode and ownership in case the log file already
+	// existed. We special case /dev/null since we can't chmod it
 	switch b.path {
 	case "/dev/null":
 	default:
@@ -226,11 +264,33 @@ func (b *Backend) open() error {
 		if err != nil {
 			return err
 		}
+		if b.uid != -1 || b.gid != -1 {
+			if err := os.Chown(b.path, b.uid, b.gid); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
 }
 
+// lookupID parses a numeric user or group ID, falling back to resolving it
+// as a name with the given lookup function
+func lookupID(value string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(value); err == nil {
+		if id < 0 {
+			return 0, fmt.Errorf("id must not be negative")
+		}
+		return id, nil
+	}
+
+	idRaw, err := lookup(value)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(idRaw)
+}
+
 func (b *Backend) Reload() error {
 	b.fileLock.Lock()
 	defer b.fileLock.Unlock()
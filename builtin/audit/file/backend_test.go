@@ -89,3 +89,86 @@ func TestAuditFile_fileModeExisting(t *testing.T) {
 		t.Fatalf("File mode does not match.")
 	}
 }
+
+func TestAuditFile_owner(t *testing.T) {
+	path, err := ioutil.TempDir("", "vault-test_audit_file-owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	file := filepath.Join(path, "auditTest.txt")
+
+	_, err = Factory(&audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config: map[string]string{
+			"path":  file,
+			"owner": strconv.Itoa(os.Getuid()),
+			"group": strconv.Itoa(os.Getgid()),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, config := range []map[string]string{
+		{"path": file, "owner": "vault-test-no-such-user"},
+		{"path": file, "group": "vault-test-no-such-group"},
+		{"path": file, "owner": "-2"},
+	} {
+		_, err = Factory(&audit.BackendConfig{
+			SaltConfig: &salt.Config{},
+			SaltView:   &logical.InmemStorage{},
+			Config:     config,
+		})
+		if err == nil {
+			t.Fatalf("expected error for config %#v", config)
+		}
+	}
+}
+
+func TestAuditFile_reloadReopens(t *testing.T) {
+	path, err := ioutil.TempDir("", "vault-test_audit_file-reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	file := filepath.Join(path, "auditTest.txt")
+
+	b, err := Factory(&audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config: map[string]string{
+			"path": file,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate logrotate moving the file out of the way
+	if err := os.Rename(file, file+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "secret/foo",
+	}
+	if err := b.LogRequest(nil, req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() == 0 {
+		t.Fatal("expected audit entry to be written to the reopened file")
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 )
 
@@ -76,8 +77,10 @@ func Factory(conf *audit.BackendConfig) (audit.Backend, error) {
 		saltConfig: conf.SaltConfig,
 		saltView:   conf.SaltView,
 		formatConfig: audit.FormatterConfig{
-			Raw:          logRaw,
-			HMACAccessor: hmacAccessor,
+			Raw:                 logRaw,
+			HMACAccessor:        hmacAccessor,
+			NonHMACRequestKeys:  strutil.ParseDedupAndSortStrings(conf.Config["non_hmac_request_keys"], ","),
+			NonHMACResponseKeys: strutil.ParseDedupAndSortStrings(conf.Config["non_hmac_response_keys"], ","),
 		},
 
 		writeDuration: writeDuration,
@@ -201,7 +204,9 @@ func (b *Backend) reconnect() error {
 		b.connection = nil
 	}
 
-	conn, err := net.Dial(b.socketType, b.address)
+	// Bound the connection attempt by the write timeout so an unreachable
+	// collector cannot stall request handling
+	conn, err := net.DialTimeout(b.socketType, b.address, b.writeDuration)
 	if err != nil {
 		return err
 	}
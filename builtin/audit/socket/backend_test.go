@@ -0,0 +1,117 @@
+package socket
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/logical"
+)
+
+func testFactory(t *testing.T, config map[string]string) *Backend {
+	b, err := Factory(&audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config:     config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.(*Backend)
+}
+
+func TestAuditSocket_config(t *testing.T) {
+	b := testFactory(t, map[string]string{
+		"address": "127.0.0.1:9090",
+	})
+	if b.socketType != "tcp" || b.writeDuration != 2*time.Second {
+		t.Fatalf("bad defaults: type %q, timeout %s", b.socketType, b.writeDuration)
+	}
+
+	for _, config := range []map[string]string{
+		{},
+		{"address": "127.0.0.1:9090", "write_timeout": "soon"},
+		{"address": "127.0.0.1:9090", "format": "xml"},
+	} {
+		_, err := Factory(&audit.BackendConfig{
+			SaltConfig: &salt.Config{},
+			SaltView:   &logical.InmemStorage{},
+			Config:     config,
+		})
+		if err == nil {
+			t.Fatalf("expected error for config %#v", config)
+		}
+	}
+}
+
+func TestAuditSocket_write(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+
+	b := testFactory(t, map[string]string{
+		"address": ln.Addr().String(),
+	})
+
+	auth := &logical.Auth{ClientToken: "foo"}
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "secret/foo",
+	}
+	if err := b.LogRequest(auth, req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case line := <-lines:
+		hashed, err := b.GetHash("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(line, hashed) || !strings.Contains(line, "secret/foo") {
+			t.Fatalf("bad entry: %s", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for audit entry")
+	}
+}
+
+func TestAuditSocket_unreachable(t *testing.T) {
+	// Grab a free port and release it so nothing is listening
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	b := testFactory(t, map[string]string{
+		"address":       addr,
+		"write_timeout": "1s",
+	})
+
+	req := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "secret/foo",
+	}
+	if err := b.LogRequest(nil, req, nil); err == nil {
+		t.Fatal("expected error logging to an unreachable socket")
+	}
+}
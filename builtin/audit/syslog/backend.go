@@ -9,9 +9,14 @@ import (
 	"github.com/hashicorp/go-syslog"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 )
 
+// newLogger creates the syslog writer used by the backend. It is a variable
+// so tests can run without a local syslog agent.
+var newLogger = gsyslog.NewLogger
+
 func Factory(conf *audit.BackendConfig) (audit.Backend, error) {
 	if conf.SaltConfig == nil {
 		return nil, fmt.Errorf("nil salt config")
@@ -63,7 +68,7 @@ func Factory(conf *audit.BackendConfig) (audit.Backend, error) {
 	}
 
 	// Get the logger
-	logger, err := gsyslog.NewLogger(gsyslog.LOG_INFO, facility, tag)
+	logger, err := newLogger(gsyslog.LOG_INFO, facility, tag)
 	if err != nil {
 		return nil, err
 	}
@@ -73,8 +78,10 @@ func Factory(conf *audit.BackendConfig) (audit.Backend, error) {
 		saltConfig: conf.SaltConfig,
 		saltView:   conf.SaltView,
 		formatConfig: audit.FormatterConfig{
-			Raw:          logRaw,
-			HMACAccessor: hmacAccessor,
+			Raw:                 logRaw,
+			HMACAccessor:        hmacAccessor,
+			NonHMACRequestKeys:  strutil.ParseDedupAndSortStrings(conf.Config["non_hmac_request_keys"], ","),
+			NonHMACResponseKeys: strutil.ParseDedupAndSortStrings(conf.Config["non_hmac_response_keys"], ","),
 		},
 	}
 
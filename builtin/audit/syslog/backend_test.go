@@ -0,0 +1,114 @@
+package syslog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-syslog"
+	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/salt"
+	"github.com/hashicorp/vault/logical"
+)
+
+type testSyslogger struct {
+	facility string
+	tag      string
+	buf      bytes.Buffer
+}
+
+func (l *testSyslogger) WriteLevel(p gsyslog.Priority, b []byte) error {
+	_, err := l.buf.Write(b)
+	return err
+}
+
+func (l *testSyslogger) Write(b []byte) (int, error) {
+	return l.buf.Write(b)
+}
+
+func (l *testSyslogger) Close() error {
+	return nil
+}
+
+func testFactory(t *testing.T, config map[string]string) (*Backend, *testSyslogger) {
+	logger := &testSyslogger{}
+	oldNewLogger := newLogger
+	newLogger = func(p gsyslog.Priority, facility, tag string) (gsyslog.Syslogger, error) {
+		logger.facility = facility
+		logger.tag = tag
+		return logger, nil
+	}
+	defer func() { newLogger = oldNewLogger }()
+
+	b, err := Factory(&audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config:     config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.(*Backend), logger
+}
+
+func TestAuditSyslog_config(t *testing.T) {
+	_, logger := testFactory(t, map[string]string{})
+	if logger.facility != "AUTH" || logger.tag != "vault" {
+		t.Fatalf("bad defaults: facility %q, tag %q", logger.facility, logger.tag)
+	}
+
+	_, logger = testFactory(t, map[string]string{
+		"facility": "LOCAL0",
+		"tag":      "vault-audit",
+	})
+	if logger.facility != "LOCAL0" || logger.tag != "vault-audit" {
+		t.Fatalf("bad config: facility %q, tag %q", logger.facility, logger.tag)
+	}
+
+	_, err := Factory(&audit.BackendConfig{
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Config:     map[string]string{"format": "xml"},
+	})
+	if err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestAuditSyslog_hashing(t *testing.T) {
+	b, logger := testFactory(t, map[string]string{
+		"prefix": "@cee: ",
+	})
+
+	auth := &logical.Auth{
+		ClientToken: "foo",
+		Accessor:    "bar",
+	}
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "secret/foo",
+		Data: map[string]interface{}{
+			"password": "hunter2",
+		},
+	}
+	if err := b.LogRequest(auth, req, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	out := logger.buf.String()
+	if !strings.HasPrefix(out, "@cee: ") {
+		t.Fatalf("missing prefix: %s", out)
+	}
+	for _, raw := range []string{"foo", "bar", "hunter2"} {
+		hashed, err := b.GetHash(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, hashed) {
+			t.Fatalf("expected %q to be hashed as %q: %s", raw, hashed, out)
+		}
+		if strings.Contains(out, `"`+raw+`"`) {
+			t.Fatalf("found unhashed value %q: %s", raw, out)
+		}
+	}
+}
@@ -47,6 +47,12 @@ func Backend(conf *logical.BackendConfig) (*backend, error) {
 				Description: "If not blank, restricts auth by this CIDR block",
 			},
 
+			"bound_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
+
 			"value": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: "App IDs that this user associates with.",
@@ -8,6 +8,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -67,12 +68,14 @@ func (b *backend) pathLogin(
 	userId := data.Get("user_id").(string)
 
 	var displayName string
-	if dispName, resp, err := b.verifyCredentials(req, appId, userId); err != nil {
+	var boundCIDRs []string
+	if dispName, cidrs, resp, err := b.verifyCredentials(req, appId, userId); err != nil {
 		return nil, err
 	} else if resp != nil {
 		return resp, nil
 	} else {
 		displayName = dispName
+		boundCIDRs = cidrs
 	}
 
 	// Get the policies associated with the app
@@ -98,6 +101,7 @@ func (b *backend) pathLogin(
 			DisplayName: displayName,
 			Policies:    policies,
 			Metadata:    metadata,
+			BoundCIDRs:  boundCIDRs,
 			LeaseOptions: logical.LeaseOptions{
 				Renewable: true,
 			},
@@ -112,7 +116,7 @@ func (b *backend) pathLoginRenew(
 
 	// Skipping CIDR verification to enable renewal from machines other than
 	// the ones encompassed by CIDR block.
-	if _, resp, err := b.verifyCredentials(req, appId, userId); err != nil {
+	if _, _, resp, err := b.verifyCredentials(req, appId, userId); err != nil {
 		return nil, err
 	} else if resp != nil {
 		return resp, nil
@@ -130,26 +134,26 @@ func (b *backend) pathLoginRenew(
 	return framework.LeaseExtend(0, 0, b.System())(req, d)
 }
 
-func (b *backend) verifyCredentials(req *logical.Request, appId, userId string) (string, *logical.Response, error) {
+func (b *backend) verifyCredentials(req *logical.Request, appId, userId string) (string, []string, *logical.Response, error) {
 	// Ensure both appId and userId are provided
 	if appId == "" || userId == "" {
-		return "", logical.ErrorResponse("missing 'app_id' or 'user_id'"), nil
+		return "", nil, logical.ErrorResponse("missing 'app_id' or 'user_id'"), nil
 	}
 
 	// Look up the apps that this user is allowed to access
 	appsMap, err := b.MapUserId.Get(req.Storage, userId)
 	if err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
 	if appsMap == nil {
-		return "", logical.ErrorResponse("invalid user ID or app ID"), nil
+		return "", nil, logical.ErrorResponse("invalid user ID or app ID"), nil
 	}
 
 	// If there is a CIDR block restriction, check that
 	if raw, ok := appsMap["cidr_block"]; ok {
 		_, cidr, err := net.ParseCIDR(raw.(string))
 		if err != nil {
-			return "", nil, fmt.Errorf("invalid restriction cidr: %s", err)
+			return "", nil, nil, fmt.Errorf("invalid restriction cidr: %s", err)
 		}
 
 		var addr string
@@ -157,7 +161,18 @@ func (b *backend) verifyCredentials(req *logical.Request, appId, userId string)
 			addr = req.Connection.RemoteAddr
 		}
 		if addr == "" || !cidr.Contains(net.ParseIP(addr)) {
-			return "", logical.ErrorResponse("unauthorized source address"), nil
+			return "", nil, logical.ErrorResponse("unauthorized source address"), nil
+		}
+	}
+
+	// The bound CIDRs restrict both the login and the resulting token
+	boundCIDRs := (&framework.FieldData{
+		Raw:    appsMap,
+		Schema: b.MapUserId.Schema,
+	}).Get("bound_cidrs").([]string)
+	if len(boundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, boundCIDRs) {
+			return "", nil, logical.ErrorResponse("unauthorized source address"), nil
 		}
 	}
 
@@ -168,7 +183,7 @@ func (b *backend) verifyCredentials(req *logical.Request, appId, userId string)
 
 	apps, ok := appsRaw.(string)
 	if !ok {
-		return "", nil, fmt.Errorf("internal error: mapping is not a string")
+		return "", nil, nil, fmt.Errorf("internal error: mapping is not a string")
 	}
 
 	// Verify that the app is in the list
@@ -182,23 +197,23 @@ func (b *backend) verifyCredentials(req *logical.Request, appId, userId string)
 		}
 	}
 	if !found {
-		return "", logical.ErrorResponse("invalid user ID or app ID"), nil
+		return "", nil, logical.ErrorResponse("invalid user ID or app ID"), nil
 	}
 
 	// Get the raw data associated with the app
 	appRaw, err := b.MapAppId.Get(req.Storage, appId)
 	if err != nil {
-		return "", nil, err
+		return "", nil, nil, err
 	}
 	if appRaw == nil {
-		return "", logical.ErrorResponse("invalid user ID or app ID"), nil
+		return "", nil, logical.ErrorResponse("invalid user ID or app ID"), nil
 	}
 	var displayName string
 	if raw, ok := appRaw["display_name"]; ok {
 		displayName = raw.(string)
 	}
 
-	return displayName, nil, nil
+	return displayName, boundCIDRs, nil, nil
 }
 
 const pathLoginSyn = `
@@ -51,6 +51,10 @@ type roleStorageEntry struct {
 	// A constraint, if set, specifies the CIDR blocks from which logins should be allowed
 	BoundCIDRList string `json:"bound_cidr_list" structs:"bound_cidr_list" mapstructure:"bound_cidr_list"`
 
+	// A constraint, if set, specifies the CIDR blocks from which the issued
+	// tokens can be used
+	TokenBoundCIDRs []string `json:"token_bound_cidrs" structs:"token_bound_cidrs" mapstructure:"token_bound_cidrs"`
+
 	// Period, if set, indicates that the token generated using this role
 	// should never expire. The token should be renewed within the duration
 	// specified by this value. The renewal duration will be fixed if the
@@ -111,6 +115,11 @@ func rolePaths(b *backend) []*framework.Path {
 					Type: framework.TypeString,
 					Description: `Comma separated list of CIDR blocks, if set, specifies blocks of IP
 addresses which can perform the login operation`,
+				},
+				"token_bound_cidrs": &framework.FieldSchema{
+					Type: framework.TypeCommaStringSlice,
+					Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can use the issued tokens.`,
 				},
 				"policies": &framework.FieldSchema{
 					Type:        framework.TypeCommaStringSlice,
@@ -767,6 +776,15 @@ func (b *backend) pathRoleCreateUpdate(req *logical.Request, data *framework.Fie
 		}
 	}
 
+	if tokenBoundCIDRsRaw, ok := data.GetOk("token_bound_cidrs"); ok {
+		role.TokenBoundCIDRs = tokenBoundCIDRsRaw.([]string)
+	}
+	if len(role.TokenBoundCIDRs) > 0 {
+		if _, err := cidrutil.ValidateCIDRListSlice(role.TokenBoundCIDRs); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid token_bound_cidrs: %v", err)), nil
+		}
+	}
+
 	if policiesRaw, ok := data.GetOk("policies"); ok {
 		role.Policies = policyutil.ParsePolicies(policiesRaw)
 	} else if req.Operation == logical.CreateOperation {
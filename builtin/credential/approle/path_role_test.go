@@ -615,6 +615,7 @@ func TestAppRole_RoleCRUD(t *testing.T) {
 		"token_max_ttl":      500,
 		"token_num_uses":     600,
 		"bound_cidr_list":    "127.0.0.1/32,127.0.0.1/16",
+		"token_bound_cidrs":  []string{},
 	}
 	var expectedStruct roleStorageEntry
 	err = mapstructure.Decode(expected, &expectedStruct)
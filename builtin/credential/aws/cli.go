@@ -116,10 +116,10 @@ environment variables (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and
 AWS_SECURITY_TOKEN), via the ~/.aws/credentials file, or via an EC2
 instance profile (in that order).
 
-  Example: vault auth -method=aws
+  Example: vault login -method=aws
 
 If you need to explicitly pass in credentials, you would do it like this:
-  Example: vault auth -method=aws aws_access_key_id=<access key> aws_secret_access_key=<secret key> aws_security_token=<token>
+  Example: vault login -method=aws aws_access_key_id=<access key> aws_secret_access_key=<secret key> aws_security_token=<token>
 
 Key/Value Pairs:
 
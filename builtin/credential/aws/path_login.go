@@ -21,6 +21,7 @@ import (
 	"github.com/fullsailor/pkcs7"
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
@@ -562,6 +563,13 @@ func (b *backend) pathLoginUpdateEc2(
 		return logical.ErrorResponse(fmt.Sprintf("entry for role %q not found", roleName)), nil
 	}
 
+	// Check for a CIDR match
+	if len(roleEntry.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, roleEntry.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
 	if roleEntry.AuthType != ec2AuthType {
 		return logical.ErrorResponse(fmt.Sprintf("auth method ec2 not allowed for role %s", roleName)), nil
 	}
@@ -745,8 +753,9 @@ func (b *backend) pathLoginUpdateEc2(
 
 	resp := &logical.Response{
 		Auth: &logical.Auth{
-			Period:   roleEntry.Period,
-			Policies: policies,
+			Period:     roleEntry.Period,
+			Policies:   policies,
+			BoundCIDRs: roleEntry.BoundCIDRs,
 			Metadata: map[string]string{
 				"instance_id":      identityDocParsed.InstanceID,
 				"region":           identityDocParsed.Region,
@@ -1145,6 +1154,13 @@ func (b *backend) pathLoginUpdateIam(
 		return logical.ErrorResponse(fmt.Sprintf("entry for role %s not found", roleName)), nil
 	}
 
+	// Check for a CIDR match
+	if len(roleEntry.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, roleEntry.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
 	if roleEntry.AuthType != iamAuthType {
 		return logical.ErrorResponse(fmt.Sprintf("auth method iam not allowed for role %s", roleName)), nil
 	}
@@ -1196,8 +1212,9 @@ func (b *backend) pathLoginUpdateIam(
 
 	resp := &logical.Response{
 		Auth: &logical.Auth{
-			Period:   roleEntry.Period,
-			Policies: policies,
+			Period:     roleEntry.Period,
+			Policies:   policies,
+			BoundCIDRs: roleEntry.BoundCIDRs,
 			Metadata: map[string]string{
 				"client_arn":           callerID.Arn,
 				"canonical_arn":        entity.canonicalArn(),
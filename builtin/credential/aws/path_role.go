@@ -7,6 +7,7 @@ import (
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -133,6 +134,11 @@ to 0, in which case the value will fallback to the system/mount defaults.`,
 				Default:     "default",
 				Description: "Policies to be set on tokens issued using this role.",
 			},
+			"bound_cidrs": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
 			"allow_instance_migration": {
 				Type:    framework.TypeBool,
 				Default: false,
@@ -629,6 +635,15 @@ func (b *backend) pathRoleCreateUpdate(
 		roleEntry.Policies = []string{"default"}
 	}
 
+	if boundCIDRsRaw, ok := data.GetOk("bound_cidrs"); ok {
+		roleEntry.BoundCIDRs = boundCIDRsRaw.([]string)
+	}
+	if len(roleEntry.BoundCIDRs) > 0 {
+		if _, err := cidrutil.ValidateCIDRListSlice(roleEntry.BoundCIDRs); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid bound_cidrs: %v", err)), nil
+		}
+	}
+
 	disallowReauthenticationBool, ok := data.GetOk("disallow_reauthentication")
 	if ok {
 		if roleEntry.AuthType != ec2AuthType {
@@ -748,6 +763,7 @@ type awsRoleEntry struct {
 	TTL                        time.Duration `json:"ttl" structs:"ttl" mapstructure:"ttl"`
 	MaxTTL                     time.Duration `json:"max_ttl" structs:"max_ttl" mapstructure:"max_ttl"`
 	Policies                   []string      `json:"policies" structs:"policies" mapstructure:"policies"`
+	BoundCIDRs                 []string      `json:"bound_cidrs" structs:"bound_cidrs" mapstructure:"bound_cidrs"`
 	DisallowReauthentication   bool          `json:"disallow_reauthentication" structs:"disallow_reauthentication" mapstructure:"disallow_reauthentication"`
 	HMACKey                    string        `json:"hmac_key" structs:"hmac_key" mapstructure:"hmac_key"`
 	Period                     time.Duration `json:"period" mapstructure:"period" structs:"period"`
@@ -559,6 +559,7 @@ func TestAwsEc2_RoleCrud(t *testing.T) {
 		"bound_iam_instance_profile_arn": "arn:aws:iam::123456789012:instance-profile/MyInstanceProfile",
 		"bound_subnet_id":                "testsubnetid",
 		"bound_vpc_id":                   "testvpcid",
+		"bound_cidrs":                    []string(nil),
 		"inferred_entity_type":           "",
 		"inferred_aws_region":            "",
 		"resolve_aws_unique_ids":         false,
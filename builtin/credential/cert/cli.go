@@ -45,7 +45,7 @@ client certificate. No other authentication materials are needed.
 Optionally, you may specify the specific certificate role to
 authenticate against with the "name" parameter.
 
-    Example: vault auth -method=cert \
+    Example: vault login -method=cert \
                         -client-cert=/path/to/cert.pem \
                         -client-key=/path/to/key.pem
                         name=cert1
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -52,7 +53,7 @@ certificate.`,
 			},
 
 			"policies": &framework.FieldSchema{
-				Type:        framework.TypeString,
+				Type:        framework.TypeCommaStringSlice,
 				Description: "Comma-seperated list of policies.",
 			},
 
@@ -67,6 +68,12 @@ seconds. Defaults to system/backend default TTL.`,
 				Description: `TTL for tokens issued by this backend.
 Defaults to system/backend default TTL time.`,
 			},
+
+			"bound_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -135,6 +142,7 @@ func (b *backend) pathCertRead(
 			"display_name": cert.DisplayName,
 			"policies":     strings.Join(cert.Policies, ","),
 			"ttl":          duration / time.Second,
+			"bound_cidrs":  cert.BoundCIDRs,
 		},
 	}, nil
 }
@@ -144,14 +152,21 @@ func (b *backend) pathCertWrite(
 	name := strings.ToLower(d.Get("name").(string))
 	certificate := d.Get("certificate").(string)
 	displayName := d.Get("display_name").(string)
-	policies := policyutil.ParsePolicies(d.Get("policies").(string))
+	policies := policyutil.ParsePolicies(d.Get("policies"))
 	allowedNames := d.Get("allowed_names").([]string)
+	boundCIDRs := d.Get("bound_cidrs").([]string)
 
 	// Default the display name to the certificate name if not given
 	if displayName == "" {
 		displayName = name
 	}
 
+	if len(boundCIDRs) > 0 {
+		if _, err := cidrutil.ValidateCIDRListSlice(boundCIDRs); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid bound_cidrs: %v", err)), nil
+		}
+	}
+
 	parsed := parsePEM([]byte(certificate))
 	if len(parsed) == 0 {
 		return logical.ErrorResponse("failed to parse certificate"), nil
@@ -177,6 +192,7 @@ func (b *backend) pathCertWrite(
 		DisplayName:  displayName,
 		Policies:     policies,
 		AllowedNames: allowedNames,
+		BoundCIDRs:   boundCIDRs,
 	}
 
 	// Parse the lease duration or default to backend/system default
@@ -210,6 +226,7 @@ type CertEntry struct {
 	Policies     []string
 	TTL          time.Duration
 	AllowedNames []string
+	BoundCIDRs   []string
 }
 
 const pathCertHelpSyn = `
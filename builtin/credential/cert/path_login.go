@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -55,6 +56,13 @@ func (b *backend) pathLogin(
 		return nil, nil
 	}
 
+	// Check for a CIDR match
+	if len(matched.Entry.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, matched.Entry.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
 	ttl := matched.Entry.TTL
 	if ttl == 0 {
 		ttl = b.System().DefaultLeaseTTL()
@@ -76,6 +84,7 @@ func (b *backend) pathLogin(
 			},
 			Policies:    matched.Entry.Policies,
 			DisplayName: matched.Entry.DisplayName,
+			BoundCIDRs:  matched.Entry.BoundCIDRs,
 			Metadata: map[string]string{
 				"cert_name":        matched.Entry.Name,
 				"common_name":      clientCerts[0].Subject.CommonName,
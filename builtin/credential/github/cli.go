@@ -44,7 +44,7 @@ To use it, specify the "token" parameter. The value should be a personal access
 token for your GitHub account. You can generate a personal access token on your
 account settings page on GitHub.
 
-    Example: vault auth -method=github token=<token>
+    Example: vault login -method=github token=<token>
 
 Key/Value Pairs:
 
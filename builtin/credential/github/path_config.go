@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/fatih/structs"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -33,6 +34,11 @@ API-compatible authentication server.`,
 				Type:        framework.TypeString,
 				Description: `Maximum duration after which authentication will be expired`,
 			},
+			"bound_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -76,11 +82,19 @@ func (b *backend) pathConfigWrite(
 		}
 	}
 
+	boundCIDRs := data.Get("bound_cidrs").([]string)
+	if len(boundCIDRs) > 0 {
+		if _, err := cidrutil.ValidateCIDRListSlice(boundCIDRs); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid bound_cidrs: %v", err)), nil
+		}
+	}
+
 	entry, err := logical.StorageEntryJSON("config", config{
 		Organization: organization,
 		BaseURL:      baseURL,
 		TTL:          ttl,
 		MaxTTL:       maxTTL,
+		BoundCIDRs:   boundCIDRs,
 	})
 
 	if err != nil {
@@ -135,4 +149,5 @@ type config struct {
 	BaseURL      string        `json:"base_url" structs:"base_url" mapstructure:"base_url"`
 	TTL          time.Duration `json:"ttl" structs:"ttl" mapstructure:"ttl"`
 	MaxTTL       time.Duration `json:"max_ttl" structs:"max_ttl" mapstructure:"max_ttl"`
+	BoundCIDRs   []string      `json:"bound_cidrs" structs:"bound_cidrs" mapstructure:"bound_cidrs"`
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/google/go-github/github"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -33,6 +34,18 @@ func (b *backend) pathLogin(
 
 	token := data.Get("token").(string)
 
+	config, err := b.Config(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for a CIDR match
+	if len(config.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, config.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
 	var verifyResp *verifyCredentialsResp
 	if verifyResponse, resp, err := b.verifyCredentials(req, token); err != nil {
 		return nil, err
@@ -42,11 +55,6 @@ func (b *backend) pathLogin(
 		verifyResp = verifyResponse
 	}
 
-	config, err := b.Config(req.Storage)
-	if err != nil {
-		return nil, err
-	}
-
 	ttl, _, err := b.SanitizeTTLStr(config.TTL.String(), config.MaxTTL.String())
 	if err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("error sanitizing TTLs: %s", err)), nil
@@ -63,10 +71,15 @@ func (b *backend) pathLogin(
 				"org":      *verifyResp.Org.Login,
 			},
 			DisplayName: *verifyResp.User.Login,
+			BoundCIDRs:  config.BoundCIDRs,
 			LeaseOptions: logical.LeaseOptions{
 				TTL:       ttl,
 				Renewable: true,
 			},
+			Persona: &logical.Persona{
+				Name: *verifyResp.User.Login,
+			},
+			GroupPersonas: logical.GroupPersonas(verifyResp.TeamNames),
 		},
 	}, nil
 }
@@ -208,14 +221,16 @@ func (b *backend) verifyCredentials(req *logical.Request, token string) (*verify
 	}
 
 	return &verifyCredentialsResp{
-		User:     user,
-		Org:      org,
-		Policies: append(groupPoliciesList, userPoliciesList...),
+		User:      user,
+		Org:       org,
+		Policies:  append(groupPoliciesList, userPoliciesList...),
+		TeamNames: teamNames,
 	}, nil, nil
 }
 
 type verifyCredentialsResp struct {
-	User     *github.User
-	Org      *github.Organization
-	Policies []string
+	User      *github.User
+	Org       *github.Organization
+	Policies  []string
+	TeamNames []string
 }
@@ -26,10 +26,10 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (string, error) {
 	}
 	password, ok := m["password"]
 	if !ok {
-		fmt.Printf("Password (will be hidden): ")
+		fmt.Fprintf(os.Stderr, "Password (will be hidden): ")
 		var err error
 		password, err = pwd.Read(os.Stdin)
-		fmt.Println()
+		fmt.Fprintln(os.Stderr)
 		if err != nil {
 			return "", err
 		}
@@ -71,7 +71,7 @@ If multi-factor authentication (MFA) is enabled, a "method" and/or "passcode"
 may be provided depending on the MFA backend enabled. To check
 which MFA backend is in use, read "auth/[mount]/mfa_config".
 
-    Example: vault auth -method=ldap username=john
+    Example: vault login -method=ldap username=john
 
     `
 
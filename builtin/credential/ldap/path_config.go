@@ -13,6 +13,7 @@ import (
 	"github.com/fatih/structs"
 	"github.com/go-ldap/ldap"
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/tlsutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -114,6 +115,11 @@ Default: cn`,
 				Default:     true,
 				Description: "Denies an unauthenticated LDAP bind request if the user's password is empty; defaults to true",
 			},
+			"bound_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -285,6 +291,13 @@ func (b *backend) newConfigEntry(d *framework.FieldData) (*ConfigEntry, error) {
 	if discoverDN {
 		cfg.DiscoverDN = discoverDN
 	}
+	boundCIDRs := d.Get("bound_cidrs").([]string)
+	if len(boundCIDRs) > 0 {
+		if _, err := cidrutil.ValidateCIDRListSlice(boundCIDRs); err != nil {
+			return nil, fmt.Errorf("invalid 'bound_cidrs': %v", err)
+		}
+		cfg.BoundCIDRs = boundCIDRs
+	}
 
 	return cfg, nil
 }
@@ -311,22 +324,23 @@ func (b *backend) pathConfigWrite(
 
 type ConfigEntry struct {
 	logger        log.Logger
-	Url           string `json:"url" structs:"url" mapstructure:"url"`
-	UserDN        string `json:"userdn" structs:"userdn" mapstructure:"userdn"`
-	GroupDN       string `json:"groupdn" structs:"groupdn" mapstructure:"groupdn"`
-	GroupFilter   string `json:"groupfilter" structs:"groupfilter" mapstructure:"groupfilter"`
-	GroupAttr     string `json:"groupattr" structs:"groupattr" mapstructure:"groupattr"`
-	UPNDomain     string `json:"upndomain" structs:"upndomain" mapstructure:"upndomain"`
-	UserAttr      string `json:"userattr" structs:"userattr" mapstructure:"userattr"`
-	Certificate   string `json:"certificate" structs:"certificate" mapstructure:"certificate"`
-	InsecureTLS   bool   `json:"insecure_tls" structs:"insecure_tls" mapstructure:"insecure_tls"`
-	StartTLS      bool   `json:"starttls" structs:"starttls" mapstructure:"starttls"`
-	BindDN        string `json:"binddn" structs:"binddn" mapstructure:"binddn"`
-	BindPassword  string `json:"bindpass" structs:"bindpass" mapstructure:"bindpass"`
-	DenyNullBind  bool   `json:"deny_null_bind" structs:"deny_null_bind" mapstructure:"deny_null_bind"`
-	DiscoverDN    bool   `json:"discoverdn" structs:"discoverdn" mapstructure:"discoverdn"`
-	TLSMinVersion string `json:"tls_min_version" structs:"tls_min_version" mapstructure:"tls_min_version"`
-	TLSMaxVersion string `json:"tls_max_version" structs:"tls_max_version" mapstructure:"tls_max_version"`
+	Url           string   `json:"url" structs:"url" mapstructure:"url"`
+	UserDN        string   `json:"userdn" structs:"userdn" mapstructure:"userdn"`
+	GroupDN       string   `json:"groupdn" structs:"groupdn" mapstructure:"groupdn"`
+	GroupFilter   string   `json:"groupfilter" structs:"groupfilter" mapstructure:"groupfilter"`
+	GroupAttr     string   `json:"groupattr" structs:"groupattr" mapstructure:"groupattr"`
+	UPNDomain     string   `json:"upndomain" structs:"upndomain" mapstructure:"upndomain"`
+	UserAttr      string   `json:"userattr" structs:"userattr" mapstructure:"userattr"`
+	Certificate   string   `json:"certificate" structs:"certificate" mapstructure:"certificate"`
+	InsecureTLS   bool     `json:"insecure_tls" structs:"insecure_tls" mapstructure:"insecure_tls"`
+	StartTLS      bool     `json:"starttls" structs:"starttls" mapstructure:"starttls"`
+	BindDN        string   `json:"binddn" structs:"binddn" mapstructure:"binddn"`
+	BindPassword  string   `json:"bindpass" structs:"bindpass" mapstructure:"bindpass"`
+	DenyNullBind  bool     `json:"deny_null_bind" structs:"deny_null_bind" mapstructure:"deny_null_bind"`
+	DiscoverDN    bool     `json:"discoverdn" structs:"discoverdn" mapstructure:"discoverdn"`
+	TLSMinVersion string   `json:"tls_min_version" structs:"tls_min_version" mapstructure:"tls_min_version"`
+	TLSMaxVersion string   `json:"tls_max_version" structs:"tls_max_version" mapstructure:"tls_max_version"`
+	BoundCIDRs    []string `json:"bound_cidrs" structs:"bound_cidrs" mapstructure:"bound_cidrs"`
 }
 
 func (c *ConfigEntry) GetTLSConfig(host string) (*tls.Config, error) {
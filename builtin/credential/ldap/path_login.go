@@ -5,6 +5,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -39,7 +40,19 @@ func (b *backend) pathLogin(
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
 
-	policies, resp, err := b.Login(req, username, password)
+	cfg, err := b.Config(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for a CIDR match
+	if len(cfg.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, cfg.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
+	policies, resp, groupNames, err := b.Login(req, username, password)
 	// Handle an internal error
 	if err != nil {
 		return nil, err
@@ -65,6 +78,11 @@ func (b *backend) pathLogin(
 			"password": password,
 		},
 		DisplayName: username,
+		BoundCIDRs:  cfg.BoundCIDRs,
+		Persona: &logical.Persona{
+			Name: username,
+		},
+		GroupPersonas: logical.GroupPersonas(groupNames),
 		LeaseOptions: logical.LeaseOptions{
 			Renewable: true,
 		},
@@ -78,7 +96,7 @@ func (b *backend) pathLoginRenew(
 	username := req.Auth.Metadata["username"]
 	password := req.Auth.InternalData["password"].(string)
 
-	loginPolicies, resp, err := b.Login(req, username, password)
+	loginPolicies, resp, _, err := b.Login(req, username, password)
 	if len(loginPolicies) == 0 {
 		return resp, err
 	}
@@ -46,27 +46,27 @@ type backend struct {
 	*framework.Backend
 }
 
-func (b *backend) Login(req *logical.Request, username string, password string) ([]string, *logical.Response, error) {
+func (b *backend) Login(req *logical.Request, username string, password string) ([]string, *logical.Response, []string, error) {
 	cfg, err := b.Config(req.Storage)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	if cfg == nil {
-		return nil, logical.ErrorResponse("Okta backend not configured"), nil
+		return nil, logical.ErrorResponse("Okta backend not configured"), nil, nil
 	}
 
 	client := cfg.OktaClient()
 	auth, err := client.Authenticate(username, password)
 	if err != nil {
-		return nil, logical.ErrorResponse(fmt.Sprintf("Okta auth failed: %v", err)), nil
+		return nil, logical.ErrorResponse(fmt.Sprintf("Okta auth failed: %v", err)), nil, nil
 	}
 	if auth == nil {
-		return nil, logical.ErrorResponse("okta auth backend unexpected failure"), nil
+		return nil, logical.ErrorResponse("okta auth backend unexpected failure"), nil, nil
 	}
 
 	oktaGroups, err := b.getOktaGroups(cfg, auth.Embedded.User.ID)
 	if err != nil {
-		return nil, logical.ErrorResponse(err.Error()), nil
+		return nil, logical.ErrorResponse(err.Error()), nil, nil
 	}
 	if b.Logger().IsDebug() {
 		b.Logger().Debug("auth/okta: Groups fetched from Okta", "num_groups", len(oktaGroups), "groups", oktaGroups)
@@ -124,10 +124,10 @@ func (b *backend) Login(req *logical.Request, username string, password string)
 		}
 
 		oktaResponse.Data["error"] = errStr
-		return nil, oktaResponse, nil
+		return nil, oktaResponse, nil, nil
 	}
 
-	return policies, oktaResponse, nil
+	return policies, oktaResponse, allGroups, nil
 }
 
 func (b *backend) getOktaGroups(cfg *ConfigEntry, userID string) ([]string, error) {
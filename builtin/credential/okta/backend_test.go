@@ -67,6 +67,62 @@ func TestBackend_Config(t *testing.T) {
 	})
 }
 
+func TestBackend_ConfigPatch(t *testing.T) {
+	b, err := Factory(&logical.BackendConfig{
+		Logger: logformat.NewVaultLogger(log.LevelTrace),
+		System: &logical.StaticSystemView{},
+	})
+	if err != nil {
+		t.Fatalf("Unable to create backend: %s", err)
+	}
+	storage := &logical.InmemStorage{}
+
+	// Patching a backend that is not configured fails
+	patch := &logical.Request{
+		Operation: logical.PatchOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"ttl": "2h",
+		},
+	}
+	if _, err := b.HandleRequest(patch); err != logical.ErrInvalidRequest {
+		t.Fatalf("bad: %v", err)
+	}
+
+	_, err = b.HandleRequest(&logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "config",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"organization": "example",
+			"token":        "secret",
+			"ttl":          "1h",
+			"max_ttl":      "4h",
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Only the fields of the patch change, and null resets a field
+	patch.Data = map[string]interface{}{
+		"ttl":     "2h",
+		"max_ttl": nil,
+	}
+	if _, err := b.HandleRequest(patch); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cfg, err := b.(*backend).Config(storage)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if cfg.Org != "example" || cfg.Token != "secret" || cfg.TTL != 2*time.Hour || cfg.MaxTTL != 0 {
+		t.Fatalf("bad: %#v", cfg)
+	}
+}
+
 func testLoginWrite(t *testing.T, username, password, reason string, expectedTTL time.Duration, policies []string) logicaltest.TestStep {
 	return logicaltest.TestStep{
 		Operation: logical.UpdateOperation,
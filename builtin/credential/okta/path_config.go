@@ -6,6 +6,7 @@ import (
 
 	"time"
 
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	"github.com/sstarcher/go-okta"
@@ -36,12 +37,18 @@ are using Okta development accounts.`,
 				Type:        framework.TypeDurationSecond,
 				Description: `Maximum duration after which authentication will be expired`,
 			},
+			"bound_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.ReadOperation:   b.pathConfigRead,
 			logical.CreateOperation: b.pathConfigWrite,
 			logical.UpdateOperation: b.pathConfigWrite,
+			logical.PatchOperation:  b.pathConfigWrite,
 		},
 
 		ExistenceCheck: b.pathConfigExistenceCheck,
@@ -87,6 +94,7 @@ func (b *backend) pathConfigRead(
 			"base_url":     cfg.BaseURL,
 			"ttl":          cfg.TTL,
 			"max_ttl":      cfg.MaxTTL,
+			"bound_cidrs":  cfg.BoundCIDRs,
 		},
 	}
 
@@ -101,8 +109,11 @@ func (b *backend) pathConfigWrite(
 	}
 
 	// Due to the existence check, entry will only be nil if it's a create
-	// operation, so just create a new one
+	// operation, so just create a new one. A patch has nothing to apply to.
 	if cfg == nil {
+		if req.Operation == logical.PatchOperation {
+			return logical.ErrorResponse("okta is not configured"), logical.ErrInvalidRequest
+		}
 		cfg = &ConfigEntry{}
 	}
 
@@ -148,6 +159,18 @@ func (b *backend) pathConfigWrite(
 		cfg.MaxTTL = time.Duration(d.Get("max_ttl").(int)) * time.Second
 	}
 
+	boundCIDRs, ok := d.GetOk("bound_cidrs")
+	if ok {
+		cfg.BoundCIDRs = boundCIDRs.([]string)
+	} else if req.Operation == logical.CreateOperation {
+		cfg.BoundCIDRs = d.Get("bound_cidrs").([]string)
+	}
+	if len(cfg.BoundCIDRs) > 0 {
+		if _, err := cidrutil.ValidateCIDRListSlice(cfg.BoundCIDRs); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid bound_cidrs: %v", err)), nil
+		}
+	}
+
 	jsonCfg, err := logical.StorageEntryJSON("config", cfg)
 	if err != nil {
 		return nil, err
@@ -190,6 +213,8 @@ type ConfigEntry struct {
 	BaseURL string        `json:"base_url"`
 	TTL     time.Duration `json:"ttl"`
 	MaxTTL  time.Duration `json:"max_ttl"`
+
+	BoundCIDRs []string `json:"bound_cidrs"`
 }
 
 const pathConfigHelp = `
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-errors/errors"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -40,7 +41,19 @@ func (b *backend) pathLogin(
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
 
-	policies, resp, err := b.Login(req, username, password)
+	cfg, err := b.getConfig(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for a CIDR match
+	if len(cfg.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, cfg.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
+	policies, resp, groupNames, err := b.Login(req, username, password)
 	// Handle an internal error
 	if err != nil {
 		return nil, err
@@ -56,11 +69,6 @@ func (b *backend) pathLogin(
 
 	sort.Strings(policies)
 
-	cfg, err := b.getConfig(req)
-	if err != nil {
-		return nil, err
-	}
-
 	resp.Auth = &logical.Auth{
 		Policies: policies,
 		Metadata: map[string]string{
@@ -71,6 +79,11 @@ func (b *backend) pathLogin(
 			"password": password,
 		},
 		DisplayName: username,
+		BoundCIDRs:  cfg.BoundCIDRs,
+		Persona: &logical.Persona{
+			Name: username,
+		},
+		GroupPersonas: logical.GroupPersonas(groupNames),
 		LeaseOptions: logical.LeaseOptions{
 			TTL:       cfg.TTL,
 			Renewable: true,
@@ -85,7 +98,7 @@ func (b *backend) pathLoginRenew(
 	username := req.Auth.Metadata["username"]
 	password := req.Auth.InternalData["password"].(string)
 
-	loginPolicies, resp, err := b.Login(req, username, password)
+	loginPolicies, resp, _, err := b.Login(req, username, password)
 	if len(loginPolicies) == 0 {
 		return resp, err
 	}
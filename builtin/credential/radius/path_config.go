@@ -1,9 +1,11 @@
 package radius
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/fatih/structs"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -46,6 +48,11 @@ func pathConfig(b *backend) *framework.Path {
 				Default:     10,
 				Description: "RADIUS NAS port field (default: 10)",
 			},
+			"bound_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
 		},
 
 		ExistenceCheck: b.configExistenceCheck,
@@ -189,6 +196,18 @@ func (b *backend) pathConfigCreateUpdate(
 		cfg.NasPort = d.Get("nas_port").(int)
 	}
 
+	boundCIDRs, ok := d.GetOk("bound_cidrs")
+	if ok {
+		cfg.BoundCIDRs = boundCIDRs.([]string)
+	} else if req.Operation == logical.CreateOperation {
+		cfg.BoundCIDRs = d.Get("bound_cidrs").([]string)
+	}
+	if len(cfg.BoundCIDRs) > 0 {
+		if _, err := cidrutil.ValidateCIDRListSlice(cfg.BoundCIDRs); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid bound_cidrs: %v", err)), nil
+		}
+	}
+
 	entry, err := logical.StorageEntryJSON("config", cfg)
 	if err != nil {
 		return nil, err
@@ -208,6 +227,7 @@ type ConfigEntry struct {
 	DialTimeout              int      `json:"dial_timeout" structs:"dial_timeout" mapstructure:"dial_timeout"`
 	ReadTimeout              int      `json:"read_timeout" structs:"read_timeout" mapstructure:"read_timeout"`
 	NasPort                  int      `json:"nas_port" structs:"nas_port" mapstructure:"nas_port"`
+	BoundCIDRs               []string `json:"bound_cidrs" structs:"bound_cidrs" mapstructure:"bound_cidrs"`
 }
 
 const pathConfigHelpSyn = `
@@ -9,6 +9,7 @@ import (
 
 	"layeh.com/radius"
 
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -59,6 +60,18 @@ func (b *backend) pathLogin(
 		return logical.ErrorResponse("password cannot be emtpy"), nil
 	}
 
+	cfg, err := b.Config(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for a CIDR match
+	if cfg != nil && len(cfg.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, cfg.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
 	policies, resp, err := b.RadiusLogin(req, username, password)
 	// Handle an internal error
 	if err != nil {
@@ -81,6 +94,7 @@ func (b *backend) pathLogin(
 			"password": password,
 		},
 		DisplayName: username,
+		BoundCIDRs:  cfg.BoundCIDRs,
 		LeaseOptions: logical.LeaseOptions{
 			Renewable: true,
 		},
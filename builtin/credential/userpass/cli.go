@@ -30,9 +30,9 @@ func (h *CLIHandler) Auth(c *api.Client, m map[string]string) (string, error) {
 		return "", fmt.Errorf("'username' must be specified")
 	}
 	if data.Password == "" {
-		fmt.Printf("Password (will be hidden): ")
+		fmt.Fprintf(os.Stderr, "Password (will be hidden): ")
 		password, err := pwd.Read(os.Stdin)
-		fmt.Println()
+		fmt.Fprintln(os.Stderr)
 		if err != nil {
 			return "", err
 		}
@@ -75,7 +75,7 @@ If multi-factor authentication (MFA) is enabled, a "method" and/or "passcode"
 may be provided depending on the MFA backend enabled. To check
 which MFA backend is in use, read "auth/[mount]/mfa_config".
 
-    Example: vault auth -method=userpass \
+    Example: vault login -method=userpass \
       username=<user> \
       password=<password>
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -66,6 +67,13 @@ func (b *backend) pathLogin(
 		}
 	}
 
+	// Check for a CIDR match
+	if len(user.BoundCIDRs) > 0 {
+		if req.Connection == nil || !cidrutil.RemoteAddrIsOk(req.Connection.RemoteAddr, user.BoundCIDRs) {
+			return nil, logical.ErrPermissionDenied
+		}
+	}
+
 	return &logical.Response{
 		Auth: &logical.Auth{
 			Policies: user.Policies,
@@ -77,6 +85,10 @@ func (b *backend) pathLogin(
 				TTL:       user.TTL,
 				Renewable: true,
 			},
+			BoundCIDRs: user.BoundCIDRs,
+			Persona: &logical.Persona{
+				Name: username,
+			},
 		},
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/policyutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -51,6 +52,11 @@ func pathUsers(b *backend) *framework.Path {
 				Default:     "",
 				Description: "Maximum duration after which login should expire",
 			},
+			"bound_cidrs": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma separated string or list of CIDR blocks. If set, specifies the blocks of
+IP addresses which can perform the login operation, and which the resulting token can be used from.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -137,9 +143,10 @@ func (b *backend) pathUserRead(
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"policies": strings.Join(user.Policies, ","),
-			"ttl":      user.TTL.Seconds(),
-			"max_ttl":  user.MaxTTL.Seconds(),
+			"policies":    strings.Join(user.Policies, ","),
+			"ttl":         user.TTL.Seconds(),
+			"max_ttl":     user.MaxTTL.Seconds(),
+			"bound_cidrs": user.BoundCIDRs,
 		},
 	}, nil
 }
@@ -169,6 +176,20 @@ func (b *backend) userCreateUpdate(req *logical.Request, d *framework.FieldData)
 		userEntry.Policies = policyutil.ParsePolicies(policiesRaw.(string))
 	}
 
+	if boundCIDRsRaw, ok := d.GetOk("bound_cidrs"); ok {
+		boundCIDRs := boundCIDRsRaw.([]string)
+		if len(boundCIDRs) > 0 {
+			valid, err := cidrutil.ValidateCIDRListSlice(boundCIDRs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to validate CIDR blocks: %v", err)
+			}
+			if !valid {
+				return logical.ErrorResponse("invalid CIDR blocks"), nil
+			}
+		}
+		userEntry.BoundCIDRs = boundCIDRs
+	}
+
 	ttlStr := userEntry.TTL.String()
 	if ttlStrRaw, ok := d.GetOk("ttl"); ok {
 		ttlStr = ttlStrRaw.(string)
@@ -212,6 +233,9 @@ type UserEntry struct {
 
 	// Maximum duration for which user can be valid
 	MaxTTL time.Duration
+
+	// If set, restricts logins and the resulting tokens to these CIDR blocks
+	BoundCIDRs []string
 }
 
 const pathUserHelpSyn = `
@@ -2,6 +2,7 @@ package aws
 
 import (
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/vault/logical"
@@ -29,6 +30,7 @@ func Backend() *backend {
 
 		Paths: []*framework.Path{
 			pathConfigRoot(),
+			pathConfigRotateRoot(&b),
 			pathConfigLease(&b),
 			pathRoles(),
 			pathListRoles(&b),
@@ -50,6 +52,9 @@ func Backend() *backend {
 
 type backend struct {
 	*framework.Backend
+
+	// rootMutex serializes rotation of the root credentials
+	rootMutex sync.Mutex
 }
 
 const backendHelp = `
@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfigRotateRoot(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/rotate-root",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathConfigRotateRootUpdate,
+		},
+
+		HelpSynopsis:    pathConfigRotateRootHelpSyn,
+		HelpDescription: pathConfigRotateRootHelpDesc,
+	}
+}
+
+func (b *backend) pathConfigRotateRootUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	b.rootMutex.Lock()
+	defer b.rootMutex.Unlock()
+
+	entry, err := req.Storage.Get("config/root")
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return logical.ErrorResponse("no root credentials are configured"), nil
+	}
+
+	var config rootConfig
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, fmt.Errorf("error reading root configuration: %s", err)
+	}
+	if config.AccessKey == "" || config.SecretKey == "" {
+		return logical.ErrorResponse("cannot rotate root credentials that are not stored in the backend"), nil
+	}
+
+	client, err := clientIAM(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look up the IAM user that owns the configured access key
+	userResp, err := client.GetUser(&iam.GetUserInput{})
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"Error getting IAM user for root credentials: %s", err)), nil
+	}
+	if userResp.User == nil || userResp.User.UserName == nil || *userResp.User.UserName == "" {
+		return logical.ErrorResponse("root credentials do not belong to an IAM user"), nil
+	}
+	username := *userResp.User.UserName
+
+	keyResp, err := client.CreateAccessKey(&iam.CreateAccessKeyInput{
+		UserName: aws.String(username),
+	})
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"Error creating access key: %s", err)), nil
+	}
+	if keyResp.AccessKey == nil || keyResp.AccessKey.AccessKeyId == nil || keyResp.AccessKey.SecretAccessKey == nil {
+		return nil, fmt.Errorf("nil response from AWS when creating access key")
+	}
+
+	oldAccessKey := config.AccessKey
+	config.AccessKey = *keyResp.AccessKey.AccessKeyId
+	config.SecretKey = *keyResp.AccessKey.SecretAccessKey
+
+	// Persist the new key before deleting the old one so that the backend
+	// is never left without working credentials
+	newEntry, err := logical.StorageEntryJSON("config/root", config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(newEntry); err != nil {
+		return nil, err
+	}
+
+	_, err = client.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+		AccessKeyId: aws.String(oldAccessKey),
+		UserName:    aws.String(username),
+	})
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf(
+			"Error deleting old access key %s: %s", oldAccessKey, err)), nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"access_key": config.AccessKey,
+		},
+	}, nil
+}
+
+const pathConfigRotateRootHelpSyn = `
+Request to rotate the AWS credentials used by Vault.
+`
+
+const pathConfigRotateRootHelpDesc = `
+This path attempts to rotate the AWS credentials used by Vault for this mount.
+A new access key is created for the IAM user that owns the configured access
+key, the new key is stored, and the old key is deleted. This is only possible
+if credentials have been stored via the config/root endpoint; credentials
+sourced from the environment or instance metadata cannot be rotated.
+
+The new access key ID is returned; the secret key is never returned.
+`
@@ -3,6 +3,7 @@ package aws
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -54,6 +55,17 @@ func (b *backend) pathSTSRead(
 			"Role '%s' not found", policyName)), nil
 	}
 	policyValue := string(policy.Value)
+
+	// STS credentials cannot be revoked, so never request them with a
+	// lifetime longer than the lease that will track them
+	maxTTL, err := b.stsMaxTTL(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > int64(maxTTL.Seconds()) {
+		ttl = int64(maxTTL.Seconds())
+	}
+
 	if strings.HasPrefix(policyValue, "arn:") {
 		if strings.Contains(policyValue, ":role/") {
 			return b.assumeRole(
@@ -75,6 +87,23 @@ func (b *backend) pathSTSRead(
 	)
 }
 
+// stsMaxTTL returns the longest lifetime that STS credentials can be requested
+// with, which is the smaller of the backend's configured maximum lease and the
+// mount's maximum lease TTL
+func (b *backend) stsMaxTTL(s logical.Storage) (time.Duration, error) {
+	maxTTL := b.System().MaxLeaseTTL()
+
+	lease, err := b.Lease(s)
+	if err != nil {
+		return 0, err
+	}
+	if lease != nil && lease.LeaseMax > 0 && lease.LeaseMax < maxTTL {
+		maxTTL = lease.LeaseMax
+	}
+
+	return maxTTL, nil
+}
+
 const pathSTSHelpSyn = `
 Generate an access key pair + security token for a specific role.
 `
@@ -87,6 +116,7 @@ then "aws/sts/deploy" would generate access keys for the "deploy" role.
 
 Note, these credentials are instantiated using the AWS STS backend.
 
-The access keys will have a lease associated with them. The access keys
-can be revoked by using the lease ID.
+The access keys will have a lease associated with them. As STS credentials
+cannot be revoked, the requested TTL is capped to the maximum lease TTL so that
+the credentials never outlive their lease.
 `
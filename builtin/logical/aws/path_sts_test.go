@@ -0,0 +1,48 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestBackend_STSMaxTTL(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b := Backend()
+	if err := b.Setup(config); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a lease configuration the mount's max lease TTL applies
+	maxTTL, err := b.stsMaxTTL(config.StorageView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxTTL != config.System.MaxLeaseTTL() {
+		t.Fatalf("bad: expected %s, got %s", config.System.MaxLeaseTTL(), maxTTL)
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/lease",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"lease":     "30m",
+			"lease_max": "1h",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	maxTTL, err = b.stsMaxTTL(config.StorageView)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maxTTL != time.Hour {
+		t.Fatalf("bad: expected %s, got %s", time.Hour, maxTTL)
+	}
+}
@@ -2,10 +2,14 @@ package consul
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -314,6 +318,182 @@ func TestBackend_role_lease(t *testing.T) {
 	})
 }
 
+func TestBackend_role_renew(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &logical.Request{
+		Storage:   config.StorageView,
+		Operation: logical.UpdateOperation,
+		Path:      "roles/test",
+		Data: map[string]interface{}{
+			"policy":  base64.StdEncoding.EncodeToString([]byte(testPolicy)),
+			"lease":   "6h",
+			"max_ttl": "8h",
+		},
+	}
+	resp, err := b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	req.Operation = logical.ReadOperation
+	resp, err = b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["max_ttl"].(int64) != 8*3600 {
+		t.Fatalf("bad: max_ttl: %#v", resp.Data["max_ttl"])
+	}
+
+	// Renewals extend by the role's lease, capped by its max TTL
+	secret := &logical.Secret{
+		InternalData: map[string]interface{}{
+			"secret_type": SecretTokenType,
+			"token":       "foo",
+			"role":        "test",
+		},
+	}
+	secret.IssueTime = time.Now()
+	secret.TTL = 6 * time.Hour
+
+	req.Operation = logical.RenewOperation
+	req.Secret = secret
+	resp, err = b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Secret.TTL != 6*time.Hour {
+		t.Fatalf("bad: expected a 6h TTL, got %s", resp.Secret.TTL)
+	}
+
+	secret.IssueTime = time.Now().Add(-4 * time.Hour)
+	resp, err = b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Secret.TTL > 4*time.Hour || resp.Secret.TTL < 4*time.Hour-time.Minute {
+		t.Fatalf("bad: expected the TTL to be capped to about 4h, got %s", resp.Secret.TTL)
+	}
+
+	// A lease longer than the max TTL is rejected
+	req.Operation = logical.UpdateOperation
+	req.Secret = nil
+	req.Data["max_ttl"] = "1h"
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected an error when lease is greater than max_ttl")
+	}
+}
+
+func TestBackend_policies_local(t *testing.T) {
+	var created aclToken
+	var deleted string
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Consul-Token") != "master" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/v1/acl/token":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			created.AccessorID = "accessor"
+			created.SecretID = "secret"
+			json.NewEncoder(w).Encode(created)
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/v1/acl/token/"):
+			deleted = strings.TrimPrefix(r.URL.Path, "/v1/acl/token/")
+			w.Write([]byte("true"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer consul.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b, err := Factory(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doReq := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(&logical.Request{
+			Storage:   config.StorageView,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+	}
+
+	resp, err := doReq(logical.UpdateOperation, "config/access", map[string]interface{}{
+		"address": strings.TrimPrefix(consul.URL, "http://"),
+		"token":   "master",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	// Local tokens need named policies
+	resp, err = doReq(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"policy": base64.StdEncoding.EncodeToString([]byte(testPolicy)),
+		"local":  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected an error for a local token without policies")
+	}
+
+	resp, err = doReq(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"policies": "foo,bar",
+		"local":    true,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	resp, err = doReq(logical.ReadOperation, "roles/test", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["policies"], []string{"foo", "bar"}) || resp.Data["local"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = doReq(logical.ReadOperation, "creds/test", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["token"] != "secret" || resp.Data["accessor"] != "accessor" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if !created.Local || len(created.Policies) != 2 || created.Policies[0].Name != "foo" || created.Policies[1].Name != "bar" {
+		t.Fatalf("bad: created token: %#v", created)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Storage:   config.StorageView,
+		Operation: logical.RevokeOperation,
+		Secret:    resp.Secret,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if deleted != "accessor" {
+		t.Fatalf("bad: deleted token %q", deleted)
+	}
+}
+
 func testAccStepConfig(
 	t *testing.T, config map[string]interface{}) logicaltest.TestStep {
 	return logicaltest.TestStep{
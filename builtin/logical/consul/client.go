@@ -2,12 +2,28 @@ package consul
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/vault/logical"
 )
 
 func client(s logical.Storage) (*api.Client, error, error) {
+	consulConf, userErr, intErr := clientConfig(s)
+	if intErr != nil {
+		return nil, nil, intErr
+	}
+	if userErr != nil {
+		return nil, userErr, nil
+	}
+
+	client, err := api.NewClient(consulConf)
+	return client, nil, err
+}
+
+func clientConfig(s logical.Storage) (*api.Config, error, error) {
 	conf, userErr, intErr := readConfigAccess(s)
 	if intErr != nil {
 		return nil, nil, intErr
@@ -24,6 +40,64 @@ func client(s logical.Storage) (*api.Client, error, error) {
 	consulConf.Scheme = conf.Scheme
 	consulConf.Token = conf.Token
 
-	client, err := api.NewClient(consulConf)
-	return client, nil, err
+	return consulConf, nil, nil
+}
+
+// aclToken is a token as handled by the ACL token endpoint of Consul 1.4 and
+// later, which supports named policies and local tokens. The vendored API
+// client predates that endpoint, so it is called directly.
+type aclToken struct {
+	AccessorID  string                `json:",omitempty"`
+	SecretID    string                `json:",omitempty"`
+	Description string                `json:",omitempty"`
+	Policies    []*aclTokenPolicyLink `json:",omitempty"`
+	Local       bool
+}
+
+type aclTokenPolicyLink struct {
+	Name string
+}
+
+func createACLToken(c *api.Client, token *aclToken) (*aclToken, error) {
+	var out aclToken
+	if _, err := c.Raw().Write("/v1/acl/token", token, &out, nil); err != nil {
+		return nil, err
+	}
+	if out.AccessorID == "" || out.SecretID == "" {
+		return nil, fmt.Errorf("consul did not return a token; ACL tokens with policies require Consul 1.4 or later")
+	}
+	return &out, nil
+}
+
+func deleteACLToken(conf *api.Config, accessorID string) error {
+	httpClient, err := api.NewHttpClient(conf.Transport, conf.TLSConfig)
+	if err != nil {
+		return err
+	}
+
+	u := url.URL{
+		Scheme: conf.Scheme,
+		Host:   conf.Address,
+		Path:   "/v1/acl/token/" + accessorID,
+	}
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if conf.Token != "" {
+		req.Header.Set("X-Consul-Token", conf.Token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The token may already have been removed from Consul
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("unexpected response code deleting token: %d (%s)", resp.StatusCode, body)
 }
@@ -31,7 +31,21 @@ func pathRoles() *framework.Path {
 			"policy": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `Policy document, base64 encoded. Required
-for 'client' tokens.`,
+for 'client' tokens unless "policies" is set.`,
+			},
+
+			"policies": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of names of Consul ACL
+policies to attach to 'client' tokens, in place of
+a policy document. Requires Consul 1.4 or later.`,
+			},
+
+			"local": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set, tokens are created local to the
+datacenter of the Consul server instead of being
+replicated globally. Requires "policies".`,
 			},
 
 			"token_type": &framework.FieldSchema{
@@ -47,6 +61,13 @@ Defaults to 'client'.`,
 				Type:        framework.TypeString,
 				Description: "Lease time of the role.",
 			},
+
+			"max_ttl": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Maximum lifetime of tokens created for the role,
+including renewals. Defaults to the mount's maximum
+lease TTL.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -92,7 +113,10 @@ func pathRolesRead(
 	resp := &logical.Response{
 		Data: map[string]interface{}{
 			"lease":      result.Lease.String(),
+			"max_ttl":    int64(result.MaxTTL.Seconds()),
 			"token_type": result.TokenType,
+			"policies":   result.Policies,
+			"local":      result.Local,
 		},
 	}
 	if result.Policy != "" {
@@ -115,9 +139,22 @@ func pathRolesWrite(
 
 	name := d.Get("name").(string)
 	policy := d.Get("policy").(string)
+	policies := d.Get("policies").([]string)
+	local := d.Get("local").(bool)
 	var policyRaw []byte
 	var err error
-	if tokenType != "management" {
+	switch {
+	case tokenType == "management" && len(policies) > 0:
+		return logical.ErrorResponse(
+			"policies cannot be set when using management tokens"), nil
+	case policy != "" && len(policies) > 0:
+		return logical.ErrorResponse(
+			"only one of policy and policies can be set"), nil
+	case local && len(policies) == 0:
+		return logical.ErrorResponse(
+			"local tokens require policies to be set"), nil
+	}
+	if tokenType != "management" && len(policies) == 0 {
 		if policy == "" {
 			return logical.ErrorResponse(
 				"policy cannot be empty when not using management tokens"), nil
@@ -139,9 +176,17 @@ func pathRolesWrite(
 		}
 	}
 
+	maxTTL := time.Duration(d.Get("max_ttl").(int)) * time.Second
+	if maxTTL > 0 && lease > maxTTL {
+		return logical.ErrorResponse("lease cannot be greater than max_ttl"), nil
+	}
+
 	entry, err := logical.StorageEntryJSON("policy/"+name, roleConfig{
 		Policy:    string(policyRaw),
+		Policies:  policies,
+		Local:     local,
 		Lease:     lease,
+		MaxTTL:    maxTTL,
 		TokenType: tokenType,
 	})
 	if err != nil {
@@ -166,6 +211,9 @@ func pathRolesDelete(
 
 type roleConfig struct {
 	Policy    string        `json:"policy"`
+	Policies  []string      `json:"policies"`
+	Local     bool          `json:"local"`
 	Lease     time.Duration `json:"lease"`
+	MaxTTL    time.Duration `json:"max_ttl"`
 	TokenType string        `json:"token_type"`
 }
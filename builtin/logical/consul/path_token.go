@@ -58,6 +58,35 @@ func (b *backend) pathTokenRead(
 	// Generate a name for the token
 	tokenName := fmt.Sprintf("Vault %s %s %d", name, req.DisplayName, time.Now().UnixNano())
 
+	// Tokens with named policies are created through the ACL token endpoint
+	if len(result.Policies) > 0 {
+		aclPolicies := make([]*aclTokenPolicyLink, 0, len(result.Policies))
+		for _, policyName := range result.Policies {
+			aclPolicies = append(aclPolicies, &aclTokenPolicyLink{Name: policyName})
+		}
+		token, err := createACLToken(c, &aclToken{
+			Description: tokenName,
+			Policies:    aclPolicies,
+			Local:       result.Local,
+		})
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		s := b.Secret(SecretTokenType).Response(map[string]interface{}{
+			"token":    token.SecretID,
+			"accessor": token.AccessorID,
+			"local":    token.Local,
+		}, map[string]interface{}{
+			"token":    token.SecretID,
+			"accessor": token.AccessorID,
+			"role":     name,
+		})
+		s.Secret.TTL = result.Lease
+
+		return s, nil
+	}
+
 	// Create it
 	token, _, err := c.ACL().Create(&api.ACLEntry{
 		Name:  tokenName,
@@ -73,6 +102,7 @@ func (b *backend) pathTokenRead(
 		"token": token,
 	}, map[string]interface{}{
 		"token": token,
+		"role":  name,
 	})
 	s.Secret.TTL = result.Lease
 
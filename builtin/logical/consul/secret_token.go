@@ -1,6 +1,8 @@
 package consul
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
@@ -27,11 +29,51 @@ func secretToken(b *backend) *framework.Secret {
 func (b *backend) secretTokenRenew(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 
-	return framework.LeaseExtend(0, 0, b.System())(req, d)
+	// Tokens created before the role was recorded in the lease are renewed
+	// using the mount defaults
+	roleRaw, ok := req.Secret.InternalData["role"]
+	if !ok {
+		return framework.LeaseExtend(0, 0, b.System())(req, d)
+	}
+	role, ok := roleRaw.(string)
+	if !ok {
+		return nil, fmt.Errorf("secret has role but value could not be understood")
+	}
+
+	entry, err := req.Storage.Get("policy/" + role)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %s", err)
+	}
+	if entry == nil {
+		return logical.ErrorResponse(fmt.Sprintf("Role '%s' not found", role)), nil
+	}
+
+	var result roleConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return framework.LeaseExtend(result.Lease, result.MaxTTL, b.System())(req, d)
 }
 
 func secretTokenRevoke(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	// Tokens created through the ACL token endpoint are deleted by accessor
+	if accessorRaw, ok := req.Secret.InternalData["accessor"]; ok {
+		accessor, ok := accessorRaw.(string)
+		if !ok {
+			return nil, fmt.Errorf("secret has accessor but value could not be understood")
+		}
+		conf, userErr, intErr := clientConfig(req.Storage)
+		if intErr != nil {
+			return nil, intErr
+		}
+		if userErr != nil {
+			return nil, userErr
+		}
+		return nil, deleteACLToken(conf, accessor)
+	}
+
 	c, userErr, intErr := client(req.Storage)
 	if intErr != nil {
 		return nil, intErr
@@ -106,6 +106,7 @@ func pathConfigurePluginConnection(b *databaseBackend) *framework.Path {
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
 			logical.UpdateOperation: b.connectionWriteHandler(),
+			logical.PatchOperation:  b.connectionPatchHandler(),
 			logical.ReadOperation:   b.connectionReadHandler(),
 			logical.DeleteOperation: b.connectionDeleteHandler(),
 		},
@@ -225,41 +226,102 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 			AllowedRoles:      allowedRoles,
 		}
 
-		db, err := dbplugin.PluginFactory(config.PluginName, b.System(), b.logger)
-		if err != nil {
-			return logical.ErrorResponse(fmt.Sprintf("error creating database object: %s", err)), nil
+		return b.saveConnection(req, name, config, verifyConnection)
+	}
+}
+
+// connectionPatchHandler returns a handler function for updating some of the
+// settings of an existing connection. Connection details that are not given
+// are kept, and the ones set to null are removed.
+func (b *databaseBackend) connectionPatchHandler() framework.OperationFunc {
+	return func(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		name := data.Get("name").(string)
+		if name == "" {
+			return logical.ErrorResponse(respErrEmptyName), nil
 		}
 
-		err = db.Initialize(config.ConnectionDetails, verifyConnection)
+		entry, err := req.Storage.Get(fmt.Sprintf("config/%s", name))
 		if err != nil {
-			db.Close()
-			return logical.ErrorResponse(fmt.Sprintf("error creating database object: %s", err)), nil
+			return nil, errors.New("failed to read connection configuration")
+		}
+		if entry == nil {
+			return logical.ErrorResponse(fmt.Sprintf("no connection configuration named %q", name)), logical.ErrInvalidRequest
 		}
 
-		// Grab the mutex lock
-		b.Lock()
-		defer b.Unlock()
+		var config DatabaseConfig
+		if err := entry.DecodeJSON(&config); err != nil {
+			return nil, err
+		}
 
-		// Close and remove the old connection
-		b.clearConnection(name)
+		if pluginName, ok := data.GetOk("plugin_name"); ok {
+			config.PluginName = pluginName.(string)
+		}
+		if config.PluginName == "" {
+			return logical.ErrorResponse(respErrEmptyPluginName), nil
+		}
 
-		// Save the new connection
-		b.connections[name] = db
+		if allowedRoles, ok := data.GetOk("allowed_roles"); ok {
+			config.AllowedRoles = allowedRoles.([]string)
+		}
 
-		// Store it
-		entry, err := logical.StorageEntryJSON(fmt.Sprintf("config/%s", name), config)
-		if err != nil {
-			return nil, err
+		verifyConnection := data.Get("verify_connection").(bool)
+
+		if config.ConnectionDetails == nil {
+			config.ConnectionDetails = make(map[string]interface{})
 		}
-		if err := req.Storage.Put(entry); err != nil {
-			return nil, err
+		for k, v := range data.Raw {
+			switch k {
+			case "name", "plugin_name", "allowed_roles", "verify_connection":
+				continue
+			}
+			if v == nil {
+				delete(config.ConnectionDetails, k)
+			} else {
+				config.ConnectionDetails[k] = v
+			}
 		}
 
-		resp := &logical.Response{}
-		resp.AddWarning("Read access to this endpoint should be controlled via ACLs as it will return the connection details as is, including passwords, if any.")
+		return b.saveConnection(req, name, &config, verifyConnection)
+	}
+}
+
+// saveConnection creates a database object for the configuration, replacing
+// the open connection of the given name, and stores the configuration.
+func (b *databaseBackend) saveConnection(req *logical.Request, name string, config *DatabaseConfig, verifyConnection bool) (*logical.Response, error) {
+	db, err := dbplugin.PluginFactory(config.PluginName, b.System(), b.logger)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("error creating database object: %s", err)), nil
+	}
 
-		return resp, nil
+	err = db.Initialize(config.ConnectionDetails, verifyConnection)
+	if err != nil {
+		db.Close()
+		return logical.ErrorResponse(fmt.Sprintf("error creating database object: %s", err)), nil
 	}
+
+	// Grab the mutex lock
+	b.Lock()
+	defer b.Unlock()
+
+	// Close and remove the old connection
+	b.clearConnection(name)
+
+	// Save the new connection
+	b.connections[name] = db
+
+	// Store it
+	entry, err := logical.StorageEntryJSON(fmt.Sprintf("config/%s", name), config)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{}
+	resp.AddWarning("Read access to this endpoint should be controlled via ACLs as it will return the connection details as is, including passwords, if any.")
+
+	return resp, nil
 }
 
 const pathConfigConnectionHelpSyn = `
@@ -282,6 +344,9 @@ accepts:
 	* "verify_connection" (default: true) - A boolean value denoting if the plugin should verify
 	   it is able to connect to the database using the provided connection
        details.
+
+A PATCH request updates only the given settings of an existing connection,
+removing the connection details that are set to null.
 `
 
 const pathResetConnectionHelpSyn = `
@@ -0,0 +1,240 @@
+package kv
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// defaultMaxVersions is the number of versions kept for a key when
+	// neither the key nor the backend configuration set a limit
+	defaultMaxVersions = 10
+
+	configPath     = "config"
+	metadataPrefix = "metadata/"
+	versionsPrefix = "versions/"
+)
+
+func Factory(conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.locks = locksutil.CreateLocks()
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		Paths: []*framework.Path{
+			pathConfig(&b),
+			pathData(&b),
+			pathDelete(&b),
+			pathUndelete(&b),
+			pathDestroy(&b),
+			pathMetadata(&b),
+		},
+
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+
+	// locks protects the metadata and versions of each key
+	locks []*locksutil.LockEntry
+}
+
+// kvConfig is the backend wide configuration
+type kvConfig struct {
+	MaxVersions uint64 `json:"max_versions"`
+	CASRequired bool   `json:"cas_required"`
+}
+
+// keyMetadata tracks the versions of a single key
+type keyMetadata struct {
+	Key            string                      `json:"key"`
+	Versions       map[uint64]*versionMetadata `json:"versions"`
+	CurrentVersion uint64                      `json:"current_version"`
+	OldestVersion  uint64                      `json:"oldest_version"`
+	MaxVersions    uint64                      `json:"max_versions"`
+	CASRequired    bool                        `json:"cas_required"`
+	CreatedTime    time.Time                   `json:"created_time"`
+	UpdatedTime    time.Time                   `json:"updated_time"`
+}
+
+// versionMetadata describes the state of a single version of a key
+type versionMetadata struct {
+	CreatedTime  time.Time `json:"created_time"`
+	DeletionTime time.Time `json:"deletion_time"`
+	Destroyed    bool      `json:"destroyed"`
+}
+
+// versionData is the stored value of a single version of a key
+type versionData struct {
+	Data        map[string]interface{} `json:"data"`
+	CreatedTime time.Time              `json:"created_time"`
+}
+
+func (b *backend) config(s logical.Storage) (*kvConfig, error) {
+	entry, err := s.Get(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &kvConfig{}
+	if entry == nil {
+		return conf, nil
+	}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, fmt.Errorf("error reading configuration: %s", err)
+	}
+
+	return conf, nil
+}
+
+func (b *backend) lockForKey(key string) *locksutil.LockEntry {
+	return locksutil.LockForKey(b.locks, key)
+}
+
+func (b *backend) metadata(s logical.Storage, key string) (*keyMetadata, error) {
+	entry, err := s.Get(metadataPrefix + key)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var meta keyMetadata
+	if err := entry.DecodeJSON(&meta); err != nil {
+		return nil, fmt.Errorf("error reading metadata for %q: %s", key, err)
+	}
+	if meta.Versions == nil {
+		meta.Versions = map[uint64]*versionMetadata{}
+	}
+
+	return &meta, nil
+}
+
+func (b *backend) writeMetadata(s logical.Storage, meta *keyMetadata) error {
+	entry, err := logical.StorageEntryJSON(metadataPrefix+meta.Key, meta)
+	if err != nil {
+		return err
+	}
+	return s.Put(entry)
+}
+
+// versionPath returns the storage path of a version of a key. Keys are
+// hashed so that versions of a key can never collide with another key.
+func versionPath(key string, version uint64) string {
+	hash := sha256.Sum256([]byte(key))
+	return versionsPrefix + hex.EncodeToString(hash[:]) + "/" + strconv.FormatUint(version, 10)
+}
+
+func (b *backend) version(s logical.Storage, key string, version uint64) (*versionData, error) {
+	entry, err := s.Get(versionPath(key, version))
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var data versionData
+	if err := entry.DecodeJSON(&data); err != nil {
+		return nil, fmt.Errorf("error reading version %d of %q: %s", version, key, err)
+	}
+
+	return &data, nil
+}
+
+// maxVersions returns the effective number of versions to keep for a key
+func (meta *keyMetadata) maxVersions(conf *kvConfig) uint64 {
+	switch {
+	case meta.MaxVersions > 0:
+		return meta.MaxVersions
+	case conf.MaxVersions > 0:
+		return conf.MaxVersions
+	default:
+		return defaultMaxVersions
+	}
+}
+
+// pruneVersions removes the oldest versions of a key until at most
+// maxVersions remain. The metadata must be persisted by the caller.
+func (b *backend) pruneVersions(s logical.Storage, meta *keyMetadata, maxVersions uint64) error {
+	if meta.CurrentVersion < maxVersions {
+		return nil
+	}
+
+	newOldest := meta.CurrentVersion - maxVersions + 1
+	for v := meta.OldestVersion; v < newOldest; v++ {
+		if err := s.Delete(versionPath(meta.Key, v)); err != nil {
+			return err
+		}
+		delete(meta.Versions, v)
+	}
+	if newOldest > meta.OldestVersion {
+		meta.OldestVersion = newOldest
+	}
+
+	return nil
+}
+
+// versionMetadataResponse formats the metadata of a version for responses
+func versionMetadataResponse(version uint64, vm *versionMetadata) map[string]interface{} {
+	deletionTime := ""
+	if !vm.DeletionTime.IsZero() {
+		deletionTime = vm.DeletionTime.Format(time.RFC3339Nano)
+	}
+	return map[string]interface{}{
+		"version":       version,
+		"created_time":  vm.CreatedTime.Format(time.RFC3339Nano),
+		"deletion_time": deletionTime,
+		"destroyed":     vm.Destroyed,
+	}
+}
+
+// parseVersions parses the versions field shared by the delete, undelete and
+// destroy endpoints
+func parseVersions(d *framework.FieldData) ([]uint64, error) {
+	raw := d.Get("versions").([]string)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no versions provided")
+	}
+
+	versions := make([]uint64, 0, len(raw))
+	for _, r := range raw {
+		v, err := strconv.ParseUint(r, 10, 64)
+		if err != nil || v == 0 {
+			return nil, fmt.Errorf("invalid version %q", r)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
+
+const backendHelp = `
+The kv backend stores versioned key/value secrets.
+
+Secrets are written to and read from the "data/" paths. Each write creates a
+new version of the secret; older versions can be read, soft deleted and
+undeleted, or permanently destroyed. The version history of each secret and
+its settings are managed via the "metadata/" paths.
+`
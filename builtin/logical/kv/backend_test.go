@@ -0,0 +1,309 @@
+package kv
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func getBackend(t *testing.T) (*backend, logical.Storage) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(config); err != nil {
+		t.Fatal(err)
+	}
+	return b, config.StorageView
+}
+
+func testRequest(t *testing.T, b *backend, s logical.Storage, op logical.Operation,
+	path string, data map[string]interface{}) *logical.Response {
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: op,
+		Path:      path,
+		Storage:   s,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatalf("%s %s: err: %s", op, path, err)
+	}
+	return resp
+}
+
+func testWrite(t *testing.T, b *backend, s logical.Storage, key string, data map[string]interface{}) *logical.Response {
+	resp := testRequest(t, b, s, logical.UpdateOperation, "data/"+key, map[string]interface{}{
+		"data": data,
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("write %s: bad: %#v", key, resp)
+	}
+	return resp
+}
+
+func TestBackend_DataVersions(t *testing.T) {
+	b, s := getBackend(t)
+
+	resp := testWrite(t, b, s, "foo", map[string]interface{}{"bar": "baz"})
+	if resp.Data["version"] != uint64(1) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = testWrite(t, b, s, "foo", map[string]interface{}{"bar": "qux"})
+	if resp.Data["version"] != uint64(2) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The latest version is returned by default
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/foo", nil)
+	if resp == nil || !reflect.DeepEqual(resp.Data["data"], map[string]interface{}{"bar": "qux"}) {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/foo", map[string]interface{}{
+		"version": 1,
+	})
+	if resp == nil || !reflect.DeepEqual(resp.Data["data"], map[string]interface{}{"bar": "baz"}) {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/foo", map[string]interface{}{
+		"version": 3,
+	})
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/missing", nil)
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestBackend_DataCAS(t *testing.T) {
+	b, s := getBackend(t)
+
+	// A cas of 0 only allows the write if the key does not exist
+	write := func(cas int) *logical.Response {
+		return testRequest(t, b, s, logical.UpdateOperation, "data/foo", map[string]interface{}{
+			"data":    map[string]interface{}{"bar": "baz"},
+			"options": map[string]interface{}{"cas": cas},
+		})
+	}
+	if resp := write(0); resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp := write(0); resp == nil || !resp.IsError() {
+		t.Fatalf("expected error: %#v", resp)
+	}
+	if resp := write(1); resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Require cas on the key
+	resp := testRequest(t, b, s, logical.UpdateOperation, "metadata/foo", map[string]interface{}{
+		"cas_required": true,
+	})
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = testRequest(t, b, s, logical.UpdateOperation, "data/foo", map[string]interface{}{
+		"data": map[string]interface{}{"bar": "baz"},
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error: %#v", resp)
+	}
+	if resp := write(2); resp == nil || resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Require cas on the whole backend
+	resp = testRequest(t, b, s, logical.UpdateOperation, "config", map[string]interface{}{
+		"cas_required": true,
+	})
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = testRequest(t, b, s, logical.UpdateOperation, "data/other", map[string]interface{}{
+		"data": map[string]interface{}{"bar": "baz"},
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error: %#v", resp)
+	}
+}
+
+func TestBackend_DeleteUndeleteDestroy(t *testing.T) {
+	b, s := getBackend(t)
+
+	testWrite(t, b, s, "foo", map[string]interface{}{"bar": "baz"})
+	testWrite(t, b, s, "foo", map[string]interface{}{"bar": "qux"})
+
+	// Deleting data/ soft deletes the latest version
+	testRequest(t, b, s, logical.DeleteOperation, "data/foo", nil)
+	resp := testRequest(t, b, s, logical.ReadOperation, "data/foo", nil)
+	if resp == nil || resp.Data["data"] != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Data["metadata"].(map[string]interface{})["deletion_time"] == "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	testRequest(t, b, s, logical.UpdateOperation, "delete/foo", map[string]interface{}{
+		"versions": "1",
+	})
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/foo", map[string]interface{}{
+		"version": 1,
+	})
+	if resp == nil || resp.Data["data"] != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	testRequest(t, b, s, logical.UpdateOperation, "undelete/foo", map[string]interface{}{
+		"versions": "1,2",
+	})
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/foo", nil)
+	if resp == nil || !reflect.DeepEqual(resp.Data["data"], map[string]interface{}{"bar": "qux"}) {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Destroyed versions cannot be undeleted
+	testRequest(t, b, s, logical.UpdateOperation, "destroy/foo", map[string]interface{}{
+		"versions": []string{"1"},
+	})
+	testRequest(t, b, s, logical.UpdateOperation, "undelete/foo", map[string]interface{}{
+		"versions": "1",
+	})
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/foo", map[string]interface{}{
+		"version": 1,
+	})
+	if resp == nil || resp.Data["data"] != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !resp.Data["metadata"].(map[string]interface{})["destroyed"].(bool) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if entry, err := s.Get(versionPath("foo", 1)); err != nil || entry != nil {
+		t.Fatalf("version data not removed: %#v, %v", entry, err)
+	}
+
+	resp = testRequest(t, b, s, logical.UpdateOperation, "delete/foo", map[string]interface{}{
+		"versions": "0",
+	})
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error: %#v", resp)
+	}
+}
+
+func TestBackend_MaxVersions(t *testing.T) {
+	b, s := getBackend(t)
+
+	resp := testRequest(t, b, s, logical.UpdateOperation, "config", map[string]interface{}{
+		"max_versions": 3,
+	})
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	for i := 0; i < 5; i++ {
+		testWrite(t, b, s, "foo", map[string]interface{}{"i": i})
+	}
+
+	resp = testRequest(t, b, s, logical.ReadOperation, "metadata/foo", nil)
+	if resp == nil || resp.Data["current_version"] != uint64(5) || resp.Data["oldest_version"] != uint64(3) {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if len(resp.Data["versions"].(map[string]interface{})) != 3 {
+		t.Fatalf("bad: %#v", resp.Data["versions"])
+	}
+	resp = testRequest(t, b, s, logical.ReadOperation, "data/foo", map[string]interface{}{
+		"version": 2,
+	})
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Lowering the key's limit prunes immediately
+	testRequest(t, b, s, logical.UpdateOperation, "metadata/foo", map[string]interface{}{
+		"max_versions": 1,
+	})
+	resp = testRequest(t, b, s, logical.ReadOperation, "metadata/foo", nil)
+	if resp.Data["oldest_version"] != uint64(5) || resp.Data["max_versions"] != uint64(1) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if _, ok := resp.Data["versions"].(map[string]interface{})["5"]; !ok {
+		t.Fatalf("bad: %#v", resp.Data["versions"])
+	}
+}
+
+func TestBackend_Metadata(t *testing.T) {
+	b, s := getBackend(t)
+
+	testWrite(t, b, s, "foo", map[string]interface{}{"bar": "baz"})
+	testWrite(t, b, s, "nested/foo", map[string]interface{}{"bar": "baz"})
+
+	resp := testRequest(t, b, s, logical.ListOperation, "metadata/", nil)
+	if resp == nil || !reflect.DeepEqual(resp.Data["keys"], []string{"foo", "nested/"}) {
+		t.Fatalf("bad: %#v", resp)
+	}
+	resp = testRequest(t, b, s, logical.ListOperation, "metadata/nested", nil)
+	if resp == nil || !reflect.DeepEqual(resp.Data["keys"], []string{"foo"}) {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	testRequest(t, b, s, logical.DeleteOperation, "metadata/foo", nil)
+	if resp := testRequest(t, b, s, logical.ReadOperation, "metadata/foo", nil); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp := testRequest(t, b, s, logical.ReadOperation, "data/foo", nil); resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if entry, err := s.Get(versionPath("foo", 1)); err != nil || entry != nil {
+		t.Fatalf("version data not removed: %#v, %v", entry, err)
+	}
+}
+
+func TestBackend_DataCASConcurrent(t *testing.T) {
+	b, s := getBackend(t)
+
+	testWrite(t, b, s, "foo", map[string]interface{}{"bar": "baz"})
+
+	// Writers racing with the same cas value must not clobber each other;
+	// exactly one of them may succeed
+	const writers = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := b.HandleRequest(&logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "data/foo",
+				Storage:   s,
+				Data: map[string]interface{}{
+					"data":    map[string]interface{}{"writer": i},
+					"options": map[string]interface{}{"cas": 1},
+				},
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if resp != nil && !resp.IsError() {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one successful write, got %d", succeeded)
+	}
+	resp := testRequest(t, b, s, logical.ReadOperation, "metadata/foo", nil)
+	if resp.Data["current_version"] != uint64(2) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
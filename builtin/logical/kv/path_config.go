@@ -0,0 +1,86 @@
+package kv
+
+import (
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfig(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config$",
+		Fields: map[string]*framework.FieldSchema{
+			"max_versions": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The number of versions to keep for each key.
+Defaults to 10. Keys can override this in their metadata.`,
+			},
+			"cas_required": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If true, all keys will require the cas
+parameter to be set on all write requests.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigRead,
+			logical.UpdateOperation: b.pathConfigWrite,
+		},
+
+		HelpSynopsis:    pathConfigHelpSyn,
+		HelpDescription: pathConfigHelpDesc,
+	}
+}
+
+func (b *backend) pathConfigRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"max_versions": conf.MaxVersions,
+			"cas_required": conf.CASRequired,
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	conf, err := b.config(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxVersionsRaw, ok := d.GetOk("max_versions"); ok {
+		maxVersions := maxVersionsRaw.(int)
+		if maxVersions < 0 {
+			return logical.ErrorResponse("max_versions cannot be negative"), nil
+		}
+		conf.MaxVersions = uint64(maxVersions)
+	}
+	if casRequiredRaw, ok := d.GetOk("cas_required"); ok {
+		conf.CASRequired = casRequiredRaw.(bool)
+	}
+
+	entry, err := logical.StorageEntryJSON(configPath, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+const pathConfigHelpSyn = `
+Configure the default settings of the backend.
+`
+
+const pathConfigHelpDesc = `
+This path configures the number of versions kept for each key and whether
+check-and-set is required for writes. Settings in the metadata of a key take
+precedence over these defaults.
+`
@@ -0,0 +1,220 @@
+package kv
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathData(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "data/(?P<path>.+)",
+		Fields: map[string]*framework.FieldSchema{
+			"path": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Location of the secret.",
+			},
+			"version": &framework.FieldSchema{
+				Type:        framework.TypeInt,
+				Description: "Version of the secret to read. Defaults to the latest version.",
+			},
+			"data": &framework.FieldSchema{
+				Type:        framework.TypeMap,
+				Description: "The secret data to write.",
+			},
+			"options": &framework.FieldSchema{
+				Type: framework.TypeMap,
+				Description: `Options for the write. Setting "cas" to a
+version number only allows the write if that is
+the current version of the secret; a value of 0
+only allows the write if the secret does not
+exist.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathDataRead,
+			logical.UpdateOperation: b.pathDataWrite,
+			logical.DeleteOperation: b.pathDataDelete,
+		},
+
+		HelpSynopsis:    pathDataHelpSyn,
+		HelpDescription: pathDataHelpDesc,
+	}
+}
+
+func (b *backend) pathDataRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key := d.Get("path").(string)
+
+	lock := b.lockForKey(key)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	meta, err := b.metadata(req.Storage, key)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil || meta.CurrentVersion == 0 {
+		return nil, nil
+	}
+
+	version := meta.CurrentVersion
+	if versionRaw, ok := d.GetOk("version"); ok && versionRaw.(int) != 0 {
+		if versionRaw.(int) < 0 {
+			return logical.ErrorResponse("version cannot be negative"), nil
+		}
+		version = uint64(versionRaw.(int))
+	}
+
+	vm, ok := meta.Versions[version]
+	if !ok {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"data":     nil,
+			"metadata": versionMetadataResponse(version, vm),
+		},
+	}
+
+	// Deleted and destroyed versions only return their metadata
+	if !vm.DeletionTime.IsZero() || vm.Destroyed {
+		return resp, nil
+	}
+
+	data, err := b.version(req.Storage, key, version)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("version %d of %q is missing", version, key)
+	}
+	resp.Data["data"] = data.Data
+
+	return resp, nil
+}
+
+func (b *backend) pathDataWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key := d.Get("path").(string)
+
+	dataRaw, ok := d.GetOk("data")
+	if !ok {
+		return logical.ErrorResponse("no data provided"), nil
+	}
+
+	conf, err := b.config(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := b.lockForKey(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	meta, err := b.metadata(req.Storage, key)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if meta == nil {
+		meta = &keyMetadata{
+			Key:         key,
+			Versions:    map[uint64]*versionMetadata{},
+			CreatedTime: now,
+		}
+	}
+
+	// Enforce check-and-set if it was requested or is required
+	cas, casSet := d.Get("options").(map[string]interface{})["cas"]
+	if !casSet && (meta.CASRequired || conf.CASRequired) {
+		return logical.ErrorResponse("check-and-set parameter required for this call"), nil
+	}
+	if casSet {
+		casVersion, err := parseutil.ParseInt(cas)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid cas value: %s", err)), nil
+		}
+		if casVersion < 0 || uint64(casVersion) != meta.CurrentVersion {
+			return logical.ErrorResponse("check-and-set parameter did not match the current version"), nil
+		}
+	}
+
+	version := meta.CurrentVersion + 1
+	entry, err := logical.StorageEntryJSON(versionPath(key, version), &versionData{
+		Data:        dataRaw.(map[string]interface{}),
+		CreatedTime: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	vm := &versionMetadata{
+		CreatedTime: now,
+	}
+	meta.Versions[version] = vm
+	meta.CurrentVersion = version
+	meta.UpdatedTime = now
+	if meta.OldestVersion == 0 {
+		meta.OldestVersion = version
+	}
+
+	if err := b.pruneVersions(req.Storage, meta, meta.maxVersions(conf)); err != nil {
+		return nil, err
+	}
+	if err := b.writeMetadata(req.Storage, meta); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: versionMetadataResponse(version, vm),
+	}, nil
+}
+
+func (b *backend) pathDataDelete(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key := d.Get("path").(string)
+
+	lock := b.lockForKey(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	meta, err := b.metadata(req.Storage, key)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil || meta.CurrentVersion == 0 {
+		return nil, nil
+	}
+
+	// Soft delete the latest version; it can be restored via undelete
+	vm, ok := meta.Versions[meta.CurrentVersion]
+	if !ok || !vm.DeletionTime.IsZero() || vm.Destroyed {
+		return nil, nil
+	}
+	vm.DeletionTime = time.Now().UTC()
+
+	return nil, b.writeMetadata(req.Storage, meta)
+}
+
+const pathDataHelpSyn = `
+Write, read, and delete versioned secrets.
+`
+
+const pathDataHelpDesc = `
+This path stores versioned secrets. Each write creates a new version of the
+secret. Reading returns the latest version, or the version given by the
+"version" parameter. Deleting soft deletes the latest version, which can be
+restored with the "undelete/" path.
+
+Writes can use check-and-set by setting the "cas" option to the current version
+of the secret, or 0 if the secret must not exist yet.
+`
@@ -0,0 +1,174 @@
+package kv
+
+import (
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathDelete(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "delete/(?P<path>.+)",
+		Fields: map[string]*framework.FieldSchema{
+			"path": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Location of the secret.",
+			},
+			"versions": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The versions to be deleted.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathDeleteWrite,
+		},
+
+		HelpSynopsis:    pathDeleteHelpSyn,
+		HelpDescription: pathDeleteHelpDesc,
+	}
+}
+
+func pathUndelete(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "undelete/(?P<path>.+)",
+		Fields: map[string]*framework.FieldSchema{
+			"path": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Location of the secret.",
+			},
+			"versions": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The versions to be undeleted.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathUndeleteWrite,
+		},
+
+		HelpSynopsis:    pathUndeleteHelpSyn,
+		HelpDescription: pathUndeleteHelpDesc,
+	}
+}
+
+func pathDestroy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "destroy/(?P<path>.+)",
+		Fields: map[string]*framework.FieldSchema{
+			"path": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Location of the secret.",
+			},
+			"versions": &framework.FieldSchema{
+				Type:        framework.TypeCommaStringSlice,
+				Description: "The versions to be destroyed.",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathDestroyWrite,
+		},
+
+		HelpSynopsis:    pathDestroyHelpSyn,
+		HelpDescription: pathDestroyHelpDesc,
+	}
+}
+
+// updateVersions applies update to the metadata of each of the given
+// versions of the key that still exist and persists the result
+func (b *backend) updateVersions(
+	req *logical.Request, d *framework.FieldData,
+	update func(key string, version uint64, vm *versionMetadata) error) (*logical.Response, error) {
+	key := d.Get("path").(string)
+
+	versions, err := parseVersions(d)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	lock := b.lockForKey(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	meta, err := b.metadata(req.Storage, key)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, nil
+	}
+
+	for _, version := range versions {
+		vm, ok := meta.Versions[version]
+		if !ok {
+			continue
+		}
+		if err := update(key, version, vm); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, b.writeMetadata(req.Storage, meta)
+}
+
+func (b *backend) pathDeleteWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	now := time.Now().UTC()
+	return b.updateVersions(req, d, func(key string, version uint64, vm *versionMetadata) error {
+		if vm.DeletionTime.IsZero() {
+			vm.DeletionTime = now
+		}
+		return nil
+	})
+}
+
+func (b *backend) pathUndeleteWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.updateVersions(req, d, func(key string, version uint64, vm *versionMetadata) error {
+		// Destroyed versions cannot be restored
+		if !vm.Destroyed {
+			vm.DeletionTime = time.Time{}
+		}
+		return nil
+	})
+}
+
+func (b *backend) pathDestroyWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	return b.updateVersions(req, d, func(key string, version uint64, vm *versionMetadata) error {
+		if err := req.Storage.Delete(versionPath(key, version)); err != nil {
+			return err
+		}
+		vm.Destroyed = true
+		return nil
+	})
+}
+
+const pathDeleteHelpSyn = `
+Soft delete versions of a secret.
+`
+
+const pathDeleteHelpDesc = `
+This path marks the given versions of a secret as deleted. Their data is no
+longer returned on reads, but can be restored with the "undelete/" path.
+`
+
+const pathUndeleteHelpSyn = `
+Restore soft deleted versions of a secret.
+`
+
+const pathUndeleteHelpDesc = `
+This path restores the given versions of a secret that were soft deleted.
+Destroyed versions cannot be restored.
+`
+
+const pathDestroyHelpSyn = `
+Permanently remove versions of a secret.
+`
+
+const pathDestroyHelpDesc = `
+This path permanently removes the data of the given versions of a secret. The
+metadata of the versions is kept and marked as destroyed.
+`
@@ -0,0 +1,186 @@
+package kv
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathMetadata(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "metadata/(?P<path>.*)",
+		Fields: map[string]*framework.FieldSchema{
+			"path": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Location of the secret.",
+			},
+			"max_versions": &framework.FieldSchema{
+				Type: framework.TypeInt,
+				Description: `The number of versions to keep. If not set,
+the backend's configured value is used.`,
+			},
+			"cas_required": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If true, the key will require the cas
+parameter to be set on all write requests.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathMetadataRead,
+			logical.UpdateOperation: b.pathMetadataWrite,
+			logical.DeleteOperation: b.pathMetadataDelete,
+			logical.ListOperation:   b.pathMetadataList,
+		},
+
+		HelpSynopsis:    pathMetadataHelpSyn,
+		HelpDescription: pathMetadataHelpDesc,
+	}
+}
+
+func (b *backend) pathMetadataList(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key := d.Get("path").(string)
+	if key != "" && !strings.HasSuffix(key, "/") {
+		key = key + "/"
+	}
+
+	keys, err := req.Storage.List(metadataPrefix + key)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(keys), nil
+}
+
+func (b *backend) pathMetadataRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key := d.Get("path").(string)
+	if key == "" {
+		return logical.ErrorResponse("missing path"), nil
+	}
+
+	lock := b.lockForKey(key)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	meta, err := b.metadata(req.Storage, key)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, nil
+	}
+
+	versions := make(map[string]interface{}, len(meta.Versions))
+	for version, vm := range meta.Versions {
+		vmResp := versionMetadataResponse(version, vm)
+		delete(vmResp, "version")
+		versions[strconv.FormatUint(version, 10)] = vmResp
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"versions":        versions,
+			"current_version": meta.CurrentVersion,
+			"oldest_version":  meta.OldestVersion,
+			"max_versions":    meta.MaxVersions,
+			"cas_required":    meta.CASRequired,
+			"created_time":    meta.CreatedTime.Format(time.RFC3339Nano),
+			"updated_time":    meta.UpdatedTime.Format(time.RFC3339Nano),
+		},
+	}, nil
+}
+
+func (b *backend) pathMetadataWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key := d.Get("path").(string)
+	if key == "" {
+		return logical.ErrorResponse("missing path"), nil
+	}
+
+	conf, err := b.config(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := b.lockForKey(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	meta, err := b.metadata(req.Storage, key)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if meta == nil {
+		meta = &keyMetadata{
+			Key:         key,
+			Versions:    map[uint64]*versionMetadata{},
+			CreatedTime: now,
+		}
+	}
+
+	if maxVersionsRaw, ok := d.GetOk("max_versions"); ok {
+		maxVersions := maxVersionsRaw.(int)
+		if maxVersions < 0 {
+			return logical.ErrorResponse("max_versions cannot be negative"), nil
+		}
+		meta.MaxVersions = uint64(maxVersions)
+	}
+	if casRequiredRaw, ok := d.GetOk("cas_required"); ok {
+		meta.CASRequired = casRequiredRaw.(bool)
+	}
+	meta.UpdatedTime = now
+
+	// Lowering the number of versions to keep takes effect immediately
+	if err := b.pruneVersions(req.Storage, meta, meta.maxVersions(conf)); err != nil {
+		return nil, err
+	}
+
+	return nil, b.writeMetadata(req.Storage, meta)
+}
+
+func (b *backend) pathMetadataDelete(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	key := d.Get("path").(string)
+	if key == "" {
+		return logical.ErrorResponse("missing path"), nil
+	}
+
+	lock := b.lockForKey(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	meta, err := b.metadata(req.Storage, key)
+	if err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, nil
+	}
+
+	// Remove all versions before the metadata so that nothing is orphaned
+	// if a delete fails part way
+	for version := range meta.Versions {
+		if err := req.Storage.Delete(versionPath(key, version)); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, req.Storage.Delete(metadataPrefix + key)
+}
+
+const pathMetadataHelpSyn = `
+Manage the metadata and settings of versioned secrets.
+`
+
+const pathMetadataHelpDesc = `
+This path reads the version history of a secret and configures the number of
+versions to keep and whether check-and-set is required for it. Deleting the
+metadata of a secret permanently removes all of its versions. Listing returns
+the secrets stored under the given path.
+`
@@ -0,0 +1,51 @@
+package nomad
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func Factory(conf *logical.BackendConfig) (logical.Backend, error) {
+	b := Backend()
+	if err := b.Setup(conf); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func Backend() *backend {
+	var b backend
+	b.Backend = &framework.Backend{
+		Help: strings.TrimSpace(backendHelp),
+
+		Paths: []*framework.Path{
+			pathConfigAccess(),
+			pathListRoles(&b),
+			pathRoles(),
+			pathCredsCreate(&b),
+		},
+
+		Secrets: []*framework.Secret{
+			secretToken(&b),
+		},
+		BackendType: logical.TypeLogical,
+	}
+
+	return &b
+}
+
+type backend struct {
+	*framework.Backend
+}
+
+const backendHelp = `
+The Nomad backend dynamically generates Nomad ACL tokens for a set of Nomad
+ACL policies. The tokens have a configurable lease set and are automatically
+deleted at the end of the lease.
+
+After mounting this backend, the address of Nomad and a management token must
+be configured with the "config/access" endpoint and roles must be written
+using the "roles/" endpoints before any tokens can be generated.
+`
@@ -0,0 +1,255 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+const testManagementToken = "management-token"
+
+// testNomadServer is a fake Nomad server implementing the ACL token endpoints
+type testNomadServer struct {
+	sync.Mutex
+	tokens map[string]*aclToken
+	count  int
+}
+
+func (s *testNomadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if r.Header.Get("X-Nomad-Token") != testManagementToken {
+		http.Error(w, "Permission denied", http.StatusForbidden)
+		return
+	}
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v1/acl/token":
+		var token aclToken
+		if err := json.NewDecoder(r.Body).Decode(&token); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.count++
+		token.AccessorID = fmt.Sprintf("accessor-%d", s.count)
+		token.SecretID = fmt.Sprintf("secret-%d", s.count)
+		s.tokens[token.AccessorID] = &token
+		json.NewEncoder(w).Encode(token)
+
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/v1/acl/token/"):
+		delete(s.tokens, strings.TrimPrefix(r.URL.Path, "/v1/acl/token/"))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func testBackendWithNomad(t *testing.T) (*backend, logical.Storage, *testNomadServer, func()) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(config); err != nil {
+		t.Fatal(err)
+	}
+
+	nomad := &testNomadServer{
+		tokens: map[string]*aclToken{},
+	}
+	ts := httptest.NewServer(nomad)
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/access",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"address": ts.URL,
+			"token":   testManagementToken,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		ts.Close()
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	return b, config.StorageView, nomad, ts.Close
+}
+
+func TestBackend_config_access(t *testing.T) {
+	b, s, _, cleanup := testBackendWithNomad(t)
+	defer cleanup()
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/access",
+		Storage:   s,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if _, ok := resp.Data["token"]; ok {
+		t.Fatal("management token returned from config/access")
+	}
+	if !strings.HasPrefix(resp.Data["address"].(string), "http://") {
+		t.Fatalf("bad: address: %#v", resp.Data["address"])
+	}
+}
+
+func TestBackend_roleCrud(t *testing.T) {
+	b, s, _, cleanup := testBackendWithNomad(t)
+	defer cleanup()
+
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/test",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"policies": "readonly,submit-job",
+			"global":   true,
+			"ttl":      "1h",
+			"max_ttl":  "2h",
+		},
+	}
+	resp, err := b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	req.Operation = logical.ReadOperation
+	resp, err = b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	expected := map[string]interface{}{
+		"policies": []string{"readonly", "submit-job"},
+		"type":     "client",
+		"global":   true,
+		"ttl":      int64(3600),
+		"max_ttl":  int64(7200),
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: expected:%#v\nactual:%#v", expected, resp.Data)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "roles/",
+		Storage:   s,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"test"}) {
+		t.Fatalf("bad: keys: %#v", resp.Data["keys"])
+	}
+
+	req.Operation = logical.DeleteOperation
+	if _, err = b.HandleRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	req.Operation = logical.ReadOperation
+	resp, err = b.HandleRequest(req)
+	if err != nil || resp != nil {
+		t.Fatalf("expected a deleted role, got err:%v resp:%#v", err, resp)
+	}
+
+	// Invalid role configurations are rejected
+	for _, data := range []map[string]interface{}{
+		{"type": "client"},
+		{"type": "management", "policies": "readonly"},
+		{"type": "invalid", "policies": "readonly"},
+		{"policies": "readonly", "ttl": "2h", "max_ttl": "1h"},
+	} {
+		resp, err = b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "roles/invalid",
+			Storage:   s,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error for role data %#v", data)
+		}
+	}
+}
+
+func TestBackend_credsRenewRevoke(t *testing.T) {
+	b, s, nomad, cleanup := testBackendWithNomad(t)
+	defer cleanup()
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/test",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"policies": "readonly",
+			"ttl":      "1h",
+			"max_ttl":  "2h",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/test",
+		Storage:   s,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Secret.TTL != time.Hour {
+		t.Fatalf("bad: expected a 1h TTL, got %s", resp.Secret.TTL)
+	}
+
+	accessorID := resp.Data["accessor_id"].(string)
+	token, ok := nomad.tokens[accessorID]
+	if !ok {
+		t.Fatalf("token %q was not created in nomad", accessorID)
+	}
+	if token.SecretID != resp.Data["secret_id"] {
+		t.Fatalf("bad: secret ID: %#v", resp.Data["secret_id"])
+	}
+	if token.Type != "client" || !reflect.DeepEqual(token.Policies, []string{"readonly"}) || token.Global {
+		t.Fatalf("bad: token: %#v", token)
+	}
+
+	secret := resp.Secret
+	secret.IssueTime = time.Now().Add(-90 * time.Minute)
+
+	// Renewals are capped by the role's max TTL
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.RenewOperation,
+		Storage:   s,
+		Secret:    secret,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Secret.TTL > 30*time.Minute || resp.Secret.TTL < 29*time.Minute {
+		t.Fatalf("bad: expected the TTL to be capped to about 30m, got %s", resp.Secret.TTL)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   s,
+		Secret:    secret,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if _, ok := nomad.tokens[accessorID]; ok {
+		t.Fatalf("token %q was not deleted from nomad", accessorID)
+	}
+}
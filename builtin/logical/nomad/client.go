@@ -0,0 +1,99 @@
+package nomad
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/logical"
+)
+
+// aclToken is the subset of a Nomad ACL token that the backend uses
+type aclToken struct {
+	AccessorID string   `json:"AccessorID,omitempty"`
+	SecretID   string   `json:"SecretID,omitempty"`
+	Name       string   `json:"Name"`
+	Type       string   `json:"Type"`
+	Policies   []string `json:"Policies"`
+	Global     bool     `json:"Global"`
+}
+
+// nomadClient is a minimal client for the Nomad ACL token HTTP API
+type nomadClient struct {
+	address    string
+	token      string
+	httpClient *http.Client
+}
+
+func client(s logical.Storage) (*nomadClient, error, error) {
+	conf, userErr, intErr := readConfigAccess(s)
+	if intErr != nil {
+		return nil, nil, intErr
+	}
+	if userErr != nil {
+		return nil, userErr, nil
+	}
+	if conf == nil {
+		return nil, nil, fmt.Errorf("no error received but no configuration found")
+	}
+
+	return &nomadClient{
+		address:    strings.TrimSuffix(conf.Address, "/"),
+		token:      conf.Token,
+		httpClient: cleanhttp.DefaultClient(),
+	}, nil, nil
+}
+
+// createToken creates a new ACL token and returns it, including its
+// accessor and secret IDs
+func (c *nomadClient) createToken(token *aclToken) (*aclToken, error) {
+	body, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var result aclToken
+	if err := c.do("POST", "/v1/acl/token", body, &result); err != nil {
+		return nil, err
+	}
+	if result.AccessorID == "" || result.SecretID == "" {
+		return nil, fmt.Errorf("nomad returned a token without an accessor or secret ID")
+	}
+
+	return &result, nil
+}
+
+// deleteToken deletes the ACL token with the given accessor ID
+func (c *nomadClient) deleteToken(accessorID string) error {
+	return c.do("DELETE", "/v1/acl/token/"+accessorID, nil, nil)
+}
+
+func (c *nomadClient) do(method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, c.address+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Nomad-Token", c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected response code from nomad: %d (%s)",
+			resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
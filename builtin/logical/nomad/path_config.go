@@ -0,0 +1,115 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathConfigAccess() *framework.Path {
+	return &framework.Path{
+		Pattern: "config/access",
+		Fields: map[string]*framework.FieldSchema{
+			"address": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `Nomad server address, including the scheme,
+e.g. "https://127.0.0.1:4646"`,
+			},
+
+			"token": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Management token for API calls",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   pathConfigAccessRead,
+			logical.UpdateOperation: pathConfigAccessWrite,
+		},
+
+		HelpSynopsis:    pathConfigAccessHelpSyn,
+		HelpDescription: pathConfigAccessHelpDesc,
+	}
+}
+
+func readConfigAccess(storage logical.Storage) (*accessConfig, error, error) {
+	entry, err := storage.Get("config/access")
+	if err != nil {
+		return nil, nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf(
+				"Access credentials for the backend itself haven't been configured. Please configure them at the '/config/access' endpoint"),
+			nil
+	}
+
+	conf := &accessConfig{}
+	if err := entry.DecodeJSON(conf); err != nil {
+		return nil, nil, fmt.Errorf("error reading nomad access configuration: %s", err)
+	}
+
+	return conf, nil, nil
+}
+
+func pathConfigAccessRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	conf, userErr, intErr := readConfigAccess(req.Storage)
+	if intErr != nil {
+		return nil, intErr
+	}
+	if userErr != nil {
+		return logical.ErrorResponse(userErr.Error()), nil
+	}
+	if conf == nil {
+		return nil, fmt.Errorf("no user error reported but nomad access configuration not found")
+	}
+
+	// The management token is never returned
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"address": conf.Address,
+		},
+	}, nil
+}
+
+func pathConfigAccessWrite(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	address := data.Get("address").(string)
+	if address == "" {
+		return logical.ErrorResponse("address cannot be empty"), nil
+	}
+	token := data.Get("token").(string)
+	if token == "" {
+		return logical.ErrorResponse("token cannot be empty"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("config/access", accessConfig{
+		Address: address,
+		Token:   token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type accessConfig struct {
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+const pathConfigAccessHelpSyn = `
+Configure the address and management token used to talk to Nomad.
+`
+
+const pathConfigAccessHelpDesc = `
+This path configures the address of the Nomad servers and the management token
+that the backend uses to create and delete ACL tokens. Reading this path
+returns the address only; the token is never returned.
+`
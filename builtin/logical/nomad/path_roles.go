@@ -0,0 +1,196 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func pathListRoles(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/?$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathRoleList,
+		},
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func pathRoles() *framework.Path {
+	return &framework.Path{
+		Pattern: "roles/" + framework.GenericNameRegex("name"),
+		Fields: map[string]*framework.FieldSchema{
+			"name": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Name of the role",
+			},
+
+			"policies": &framework.FieldSchema{
+				Type: framework.TypeCommaStringSlice,
+				Description: `Comma-separated list of Nomad ACL policies
+to attach to the token. Required for 'client' tokens.`,
+			},
+
+			"type": &framework.FieldSchema{
+				Type:    framework.TypeString,
+				Default: "client",
+				Description: `Which type of token to create: 'client'
+or 'management'. If a 'management' token,
+the "policies" parameter is not allowed.
+Defaults to 'client'.`,
+			},
+
+			"global": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Description: "If true, tokens are replicated to all Nomad regions.",
+			},
+
+			"ttl": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Lease time of tokens created for the role.
+Defaults to the mount's default lease TTL.`,
+			},
+
+			"max_ttl": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `Maximum lifetime of tokens created for the role,
+including renewals. Defaults to the mount's maximum
+lease TTL.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   pathRolesRead,
+			logical.UpdateOperation: pathRolesWrite,
+			logical.DeleteOperation: pathRolesDelete,
+		},
+
+		HelpSynopsis:    pathRolesHelpSyn,
+		HelpDescription: pathRolesHelpDesc,
+	}
+}
+
+func readRole(s logical.Storage, name string) (*roleConfig, error) {
+	entry, err := s.Get("role/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result roleConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (b *backend) pathRoleList(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List("role/")
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func pathRolesRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := readRole(req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"policies": role.Policies,
+			"type":     role.TokenType,
+			"global":   role.Global,
+			"ttl":      int64(role.TTL.Seconds()),
+			"max_ttl":  int64(role.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func pathRolesWrite(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	tokenType := d.Get("type").(string)
+	policies := d.Get("policies").([]string)
+
+	switch tokenType {
+	case "client":
+		if len(policies) == 0 {
+			return logical.ErrorResponse(
+				"policies cannot be empty when using client tokens"), nil
+		}
+	case "management":
+		if len(policies) != 0 {
+			return logical.ErrorResponse(
+				"policies cannot be specified when using management tokens"), nil
+		}
+	default:
+		return logical.ErrorResponse(
+			"type must be \"client\" or \"management\""), nil
+	}
+
+	ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+	maxTTL := time.Duration(d.Get("max_ttl").(int)) * time.Second
+	if maxTTL > 0 && ttl > maxTTL {
+		return logical.ErrorResponse("ttl cannot be greater than max_ttl"), nil
+	}
+
+	entry, err := logical.StorageEntryJSON("role/"+d.Get("name").(string), roleConfig{
+		Policies:  policies,
+		TokenType: tokenType,
+		Global:    d.Get("global").(bool),
+		TTL:       ttl,
+		MaxTTL:    maxTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, fmt.Errorf("error storing role: %s", err)
+	}
+
+	return nil, nil
+}
+
+func pathRolesDelete(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete("role/" + d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type roleConfig struct {
+	Policies  []string      `json:"policies"`
+	TokenType string        `json:"type"`
+	Global    bool          `json:"global"`
+	TTL       time.Duration `json:"ttl"`
+	MaxTTL    time.Duration `json:"max_ttl"`
+}
+
+const pathRolesHelpSyn = `
+Manage the roles that can be used to generate Nomad tokens.
+`
+
+const pathRolesHelpDesc = `
+This path lets you manage the roles used to generate Nomad ACL tokens. A role
+specifies the Nomad ACL policies attached to generated tokens, whether the
+tokens are client or management tokens, whether they are global, and the TTL
+of their leases.
+`
@@ -0,0 +1,80 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	SecretTokenType = "token"
+)
+
+func secretToken(b *backend) *framework.Secret {
+	return &framework.Secret{
+		Type: SecretTokenType,
+		Fields: map[string]*framework.FieldSchema{
+			"secret_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Nomad token secret ID",
+			},
+			"accessor_id": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: "Nomad token accessor ID",
+			},
+		},
+
+		Renew:  b.secretTokenRenew,
+		Revoke: secretTokenRevoke,
+	}
+}
+
+func (b *backend) secretTokenRenew(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	roleRaw, ok := req.Secret.InternalData["role"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing role internal data")
+	}
+	roleName, ok := roleRaw.(string)
+	if !ok {
+		return nil, fmt.Errorf("secret has role but value could not be understood")
+	}
+
+	role, err := readRole(req.Storage, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving role: %s", err)
+	}
+	if role == nil {
+		return logical.ErrorResponse(fmt.Sprintf("Role '%s' not found", roleName)), nil
+	}
+
+	return framework.LeaseExtend(role.TTL, role.MaxTTL, b.System())(req, d)
+}
+
+func secretTokenRevoke(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	c, userErr, intErr := client(req.Storage)
+	if intErr != nil {
+		return nil, intErr
+	}
+	if userErr != nil {
+		// Returning logical.ErrorResponse from revocation function is risky
+		return nil, userErr
+	}
+
+	accessorRaw, ok := req.Secret.InternalData["accessor_id"]
+	if !ok {
+		return nil, fmt.Errorf("secret is missing accessor_id internal data")
+	}
+	accessorID, ok := accessorRaw.(string)
+	if !ok {
+		return nil, fmt.Errorf("secret has accessor_id but value could not be understood")
+	}
+
+	if err := c.deleteToken(accessorID); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
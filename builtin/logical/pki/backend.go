@@ -32,6 +32,8 @@ func Backend() *backend {
 				"ca",
 				"crl/pem",
 				"crl",
+				"ocsp",
+				"ocsp/*",
 			},
 
 			LocalStorage: []string{
@@ -56,6 +58,8 @@ func Backend() *backend {
 			pathConfigCA(&b),
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
+			pathConfigTidy(&b),
+			pathConfigOCSP(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
@@ -67,17 +71,22 @@ func Backend() *backend {
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
 			pathRevoke(&b),
+			pathOCSP(&b),
+			pathOCSPGet(&b),
 			pathTidy(&b),
+			pathTidyStatus(&b),
 		},
 
 		Secrets: []*framework.Secret{
 			secretCerts(&b),
 		},
 
-		BackendType: logical.TypeLogical,
+		PeriodicFunc: b.periodicFunc,
+		BackendType:  logical.TypeLogical,
 	}
 
 	b.crlLifetime = time.Hour * 72
+	b.tidyCooldownPeriod = time.Hour
 
 	return &b
 }
@@ -87,6 +96,22 @@ type backend struct {
 
 	crlLifetime       time.Duration
 	revokeStorageLock sync.RWMutex
+
+	// Guards the tidy operation so that only one runs at a time
+	tidyCASGuard uint32
+
+	// tidyStatus holds the state of the last tidy operation and is guarded
+	// by tidyStatusLock
+	tidyStatusLock sync.RWMutex
+	tidyStatus     *tidyStatus
+
+	// Duration after which the periodic function of the backend needs to
+	// tidy the certificate store and revocation list
+	tidyCooldownPeriod time.Duration
+
+	// nextTidyTime is the time at which the periodic function should next
+	// run the tidy operation
+	nextTidyTime time.Time
 }
 
 const backendHelp = `
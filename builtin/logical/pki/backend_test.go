@@ -1,6 +1,7 @@
 package pki
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
@@ -8,12 +9,16 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"math/big"
 	mathrand "math/rand"
 	"net"
+	"net/http"
 	"os"
 	"reflect"
 	"strconv"
@@ -725,6 +730,16 @@ func generateCATestingSteps(t *testing.T, caCert, caKey, otherCaCert string, int
 		return nil
 	}
 
+	// Tidy runs in the background, so give it time to finish before the
+	// following steps look at its results
+	waitForTidy := func(resp *logical.Response) error {
+		if resp.Data[logical.HTTPStatusCode] != http.StatusAccepted {
+			return fmt.Errorf("expected tidy to be accepted, got: %#v", resp.Data)
+		}
+		time.Sleep(2 * time.Second)
+		return nil
+	}
+
 	ret := []logicaltest.TestStep{
 		logicaltest.TestStep{
 			Operation: logical.UpdateOperation,
@@ -1229,6 +1244,7 @@ func generateCATestingSteps(t *testing.T, caCert, caKey, otherCaCert string, int
 				"tidy_cert_store":      true,
 				"tidy_revocation_list": true,
 			},
+			Check: waitForTidy,
 		},
 
 		// We still expect to find these
@@ -1341,6 +1357,7 @@ func generateCATestingSteps(t *testing.T, caCert, caKey, otherCaCert string, int
 				"tidy_cert_store":      true,
 				"tidy_revocation_list": true,
 			},
+			Check: waitForTidy,
 		},
 
 		// We do *not* expect to find these
@@ -2081,6 +2098,62 @@ func TestBackend_SignVerbatim(t *testing.T) {
 	if resp.Secret != nil {
 		t.Fatal("secret is not nil")
 	}
+	block, _ := pem.Decode([]byte(resp.Data["certificate"].(string)))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.KeyUsage != 0 {
+		t.Fatalf("unexpected default key usage: %v", cert.KeyUsage)
+	}
+	if len(cert.ExtKeyUsage) != 0 {
+		t.Fatalf("unexpected default ext key usage: %v", cert.ExtKeyUsage)
+	}
+
+	// explicitly requested usages should be applied
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign-verbatim",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"csr":           string(pemCSR),
+			"key_usage":     "DigitalSignature",
+			"ext_key_usage": "ClientAuth,EmailProtection",
+		},
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("failed to sign-verbatim CSR with usages: %#v", *resp)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ = pem.Decode([]byte(resp.Data["certificate"].(string)))
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.KeyUsage != x509.KeyUsageDigitalSignature {
+		t.Fatalf("unexpected key usage: %v", cert.KeyUsage)
+	}
+	if !reflect.DeepEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageEmailProtection}) {
+		t.Fatalf("unexpected ext key usage: %v", cert.ExtKeyUsage)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "sign-verbatim",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"csr":           string(pemCSR),
+			"ext_key_usage": "TimeStamping",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected an error for an unknown extended key usage")
+	}
 
 	// create a role entry; we use this to check that sign-verbatim when used with a role is still honoring TTLs
 	roleData := map[string]interface{}{
@@ -2117,6 +2190,18 @@ func TestBackend_SignVerbatim(t *testing.T) {
 	if resp.Secret != nil {
 		t.Fatal("got a lease when we should not have")
 	}
+	// the role's default key usages apply when signing through it
+	block, _ = pem.Decode([]byte(resp.Data["certificate"].(string)))
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.KeyUsage != x509.KeyUsageDigitalSignature|x509.KeyUsageKeyAgreement|x509.KeyUsageKeyEncipherment {
+		t.Fatalf("unexpected role key usage: %v", cert.KeyUsage)
+	}
+	if !reflect.DeepEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}) {
+		t.Fatalf("unexpected role ext key usage: %v", cert.ExtKeyUsage)
+	}
 	resp, err = b.HandleRequest(&logical.Request{
 		Operation: logical.UpdateOperation,
 		Path:      "sign-verbatim/test",
@@ -2264,6 +2349,865 @@ func TestBackend_Root_Idempotentcy(t *testing.T) {
 	}
 }
 
+func TestBackend_SignIntermediate_DERChain(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	for _, path := range []string{"root", "int", "subint"} {
+		err := client.Sys().Mount(path, &api.MountInput{
+			Type: "pki",
+			Config: api.MountConfigInput{
+				DefaultLeaseTTL: "16h",
+				MaxLeaseTTL:     "60h",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := client.Logical().Write("root/root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Logical().Write("int/intermediate/generate/internal", map[string]interface{}{
+		"common_name": "int.myvault.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Logical().Write("root/root/sign-intermediate", map[string]interface{}{
+		"csr": resp.Data["csr"],
+		"ttl": "30h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("int/intermediate/set-signed", map[string]interface{}{
+		"certificate": resp.Data["certificate"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = client.Logical().Write("subint/intermediate/generate/internal", map[string]interface{}{
+		"common_name": "subint.myvault.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = client.Logical().Write("int/root/sign-intermediate", map[string]interface{}{
+		"csr":    resp.Data["csr"],
+		"ttl":    "20h",
+		"format": "der",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain, ok := resp.Data["ca_chain"].([]interface{})
+	if !ok || len(chain) == 0 {
+		t.Fatalf("expected a ca_chain in the response, got %#v", resp.Data["ca_chain"])
+	}
+	for _, entry := range chain {
+		der, err := base64.StdEncoding.DecodeString(entry.(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := x509.ParseCertificate(der); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackend_ConfigCRL_Disable(t *testing.T) {
+	config := logical.TestBackendConfig()
+	storage := &logical.InmemStorage{}
+	config.StorageView = storage
+
+	b := Backend()
+	err := b.Setup(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doReq := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s, err: %v, resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	checkCRLEntries := func(expected int) {
+		resp := doReq(logical.ReadOperation, "crl", nil)
+		crl, err := x509.ParseCRL(resp.Data[logical.HTTPRawBody].([]byte))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(crl.TBSCertList.RevokedCertificates) != expected {
+			t.Fatalf("expected %d revoked certificates, got %d", expected, len(crl.TBSCertList.RevokedCertificates))
+		}
+	}
+
+	doReq(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	doReq(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"max_ttl":          "4h",
+	})
+	resp := doReq(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+	})
+	doReq(logical.UpdateOperation, "revoke", map[string]interface{}{
+		"serial_number": resp.Data["serial_number"],
+	})
+	checkCRLEntries(1)
+
+	doReq(logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"disable": true,
+	})
+	resp = doReq(logical.ReadOperation, "config/crl", nil)
+	if resp.Data["expiry"] != "72h" || resp.Data["disable"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	checkCRLEntries(0)
+
+	doReq(logical.UpdateOperation, "config/crl", map[string]interface{}{
+		"disable": false,
+	})
+	checkCRLEntries(1)
+}
+
+func TestBackend_PeriodicTidy(t *testing.T) {
+	config := logical.TestBackendConfig()
+	storage := &logical.InmemStorage{}
+	config.StorageView = storage
+
+	b := Backend()
+	err := b.Setup(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doReq := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: op,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: path: %s, err: %v, resp: %#v", path, err, resp)
+		}
+		return resp
+	}
+
+	doReq(logical.UpdateOperation, "root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	doReq(logical.UpdateOperation, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"max_ttl":          "4h",
+	})
+	resp := doReq(logical.UpdateOperation, "issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+		"ttl":         "1s",
+	})
+	serial := normalizeSerial(resp.Data["serial_number"].(string))
+
+	countCerts := func() int {
+		resp := doReq(logical.ListOperation, "certs/", nil)
+		count := 0
+		for _, key := range resp.Data["keys"].([]string) {
+			if key == serial {
+				count++
+			}
+		}
+		return count
+	}
+
+	time.Sleep(3 * time.Second)
+
+	// Without any configuration the periodic function is a noop
+	if err := b.periodicFunc(&logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if countCerts() != 1 {
+		t.Fatal("expected certificate to still be present")
+	}
+
+	doReq(logical.UpdateOperation, "config/tidy", map[string]interface{}{
+		"tidy_cert_store": true,
+		"safety_buffer":   "1s",
+	})
+	resp = doReq(logical.ReadOperation, "config/tidy", nil)
+	if resp.Data["safety_buffer"] != 1 || resp.Data["tidy_cert_store"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The cooldown period has not yet elapsed
+	if err := b.periodicFunc(&logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	if countCerts() != 1 {
+		t.Fatal("expected certificate to still be present")
+	}
+
+	waitForTidy := func() *logical.Response {
+		for i := 0; i < 50; i++ {
+			resp := doReq(logical.ReadOperation, "tidy-status", nil)
+			if resp.Data["state"] != "Running" {
+				return resp
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Fatal("tidy operation did not finish")
+		return nil
+	}
+
+	b.nextTidyTime = time.Time{}
+	if err := b.periodicFunc(&logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	resp = waitForTidy()
+	if resp.Data["state"] != "Finished" || resp.Data["cert_store_deleted_count"] != uint(1) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if countCerts() != 0 {
+		t.Fatal("expected certificate to have been tidied")
+	}
+
+	// A manual tidy is started in the background and answered with a 202
+	resp = doReq(logical.UpdateOperation, "tidy", map[string]interface{}{
+		"tidy_cert_store":      true,
+		"tidy_revocation_list": true,
+	})
+	if resp.Data[logical.HTTPStatusCode] != http.StatusAccepted {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = waitForTidy()
+	if resp.Data["state"] != "Finished" || resp.Data["tidy_revocation_list"] != true {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// A concurrent tidy is rejected as a user error rather than failing
+	// internally
+	b.tidyCASGuard = 1
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"tidy_cert_store": true,
+		},
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err: %v, resp: %#v", err, resp)
+	}
+	b.nextTidyTime = time.Time{}
+	if err := b.periodicFunc(&logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackend_URISANs(t *testing.T) {
+	config := logical.TestBackendConfig()
+	storage := &logical.InmemStorage{}
+	config.StorageView = storage
+
+	b := Backend()
+	err := b.Setup(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doReq := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+
+	resp, err := doReq("root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+
+	for _, role := range []string{"uris", "nouris"} {
+		data := map[string]interface{}{
+			"allowed_domains":  "myvault.com",
+			"allow_subdomains": true,
+			"max_ttl":          "4h",
+		}
+		if role == "uris" {
+			data["allowed_uri_sans"] = "spiffe://myvault.com/*,urn:example:*"
+		}
+		resp, err = doReq("roles/"+role, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+		}
+	}
+
+	resp, err = doReq("issue/uris", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+		"uri_sans":    "spiffe://myvault.com/foo,urn:example:bar",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+	block, _ := pem.Decode([]byte(resp.Data["certificate"].(string)))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uris []string
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+	if !reflect.DeepEqual(uris, []string{"spiffe://myvault.com/foo", "urn:example:bar"}) {
+		t.Fatalf("bad: URI SANs: %#v", uris)
+	}
+
+	resp, err = doReq("issue/uris", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+		"uri_sans":    "spiffe://othervault.com/foo",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = doReq("issue/nouris", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+		"uri_sans":    "spiffe://myvault.com/foo",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err: %v, resp: %#v", err, resp)
+	}
+}
+
+func TestBackend_ConfigURLs(t *testing.T) {
+	config := logical.TestBackendConfig()
+	storage := &logical.InmemStorage{}
+	config.StorageView = storage
+
+	b := Backend()
+	err := b.Setup(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doReq := func(path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+	}
+
+	resp, err := doReq("root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = doReq("config/urls", map[string]interface{}{
+		"issuing_certificates":    "http://127.0.0.1:8200/v1/pki/ca, http://cdn.myvault.com/ca",
+		"crl_distribution_points": []string{"http://127.0.0.1:8200/v1/pki/crl"},
+		"ocsp_servers":            "http://127.0.0.1:8200/v1/pki/ocsp",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = doReq("roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"max_ttl":          "4h",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = doReq("issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+	block, _ := pem.Decode([]byte(resp.Data["certificate"].(string)))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cert.IssuingCertificateURL, []string{"http://127.0.0.1:8200/v1/pki/ca", "http://cdn.myvault.com/ca"}) {
+		t.Fatalf("bad: issuing certificate URLs: %#v", cert.IssuingCertificateURL)
+	}
+	if !reflect.DeepEqual(cert.CRLDistributionPoints, []string{"http://127.0.0.1:8200/v1/pki/crl"}) {
+		t.Fatalf("bad: CRL distribution points: %#v", cert.CRLDistributionPoints)
+	}
+	if !reflect.DeepEqual(cert.OCSPServer, []string{"http://127.0.0.1:8200/v1/pki/ocsp"}) {
+		t.Fatalf("bad: OCSP servers: %#v", cert.OCSPServer)
+	}
+
+	// Setting a value to an empty string should clear it
+	resp, err = doReq("config/urls", map[string]interface{}{
+		"ocsp_servers": "",
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "config/urls",
+		Storage:   storage,
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+	}
+	if len(resp.Data["ocsp_servers"].([]string)) != 0 {
+		t.Fatalf("bad: OCSP servers not cleared: %#v", resp.Data["ocsp_servers"])
+	}
+	if len(resp.Data["issuing_certificates"].([]string)) != 2 {
+		t.Fatalf("bad: issuing certificates modified: %#v", resp.Data["issuing_certificates"])
+	}
+
+	resp, err = doReq("config/urls", map[string]interface{}{
+		"crl_distribution_points": "not a url",
+	})
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got err: %v, resp: %#v", err, resp)
+	}
+}
+
+func TestBackend_ConfigCA_Import(t *testing.T) {
+	newBackend := func() (*backend, logical.Storage) {
+		config := logical.TestBackendConfig()
+		storage := &logical.InmemStorage{}
+		config.StorageView = storage
+
+		b := Backend()
+		if err := b.Setup(config); err != nil {
+			t.Fatal(err)
+		}
+		return b, storage
+	}
+
+	doReq := func(b *backend, storage logical.Storage, path string, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Storage:   storage,
+			Data:      data,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Generate the root that will be imported, along with an intermediate it
+	// signs and a leaf it issues
+	rootB, rootStorage := newBackend()
+	resp := doReq(rootB, rootStorage, "root/generate/exported", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	rootCert := resp.Data["certificate"].(string)
+	rootKey := resp.Data["private_key"].(string)
+
+	intB, intStorage := newBackend()
+	resp = doReq(intB, intStorage, "intermediate/generate/exported", map[string]interface{}{
+		"common_name": "intermediate.myvault.com",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	intKey := resp.Data["private_key"].(string)
+	resp = doReq(rootB, rootStorage, "root/sign-intermediate", map[string]interface{}{
+		"csr": resp.Data["csr"].(string),
+		"ttl": "20h",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	intCert := resp.Data["certificate"].(string)
+
+	resp = doReq(rootB, rootStorage, "roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	resp = doReq(rootB, rootStorage, "issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	leafCert := resp.Data["certificate"].(string)
+	leafKey := resp.Data["private_key"].(string)
+
+	otherB, otherStorage := newBackend()
+	resp = doReq(otherB, otherStorage, "root/generate/exported", map[string]interface{}{
+		"common_name": "othervault.com",
+	})
+	if resp == nil || resp.IsError() {
+		t.Fatalf("bad: resp: %#v", resp)
+	}
+	otherKey := resp.Data["private_key"].(string)
+
+	cases := map[string]struct {
+		bundle    string
+		expectErr string
+	}{
+		"root":         {rootKey + "\n" + rootCert, ""},
+		"intermediate": {intKey + "\n" + intCert + "\n" + rootCert, ""},
+		"mismatch":     {otherKey + "\n" + rootCert, "does not match private key"},
+		"bad chain":    {intKey + "\n" + intCert + "\n" + intCert, "certificate chain"},
+		"not a ca":     {leafKey + "\n" + leafCert, "not marked for CA use"},
+		"no key":       {rootCert, "private key not found"},
+	}
+
+	for name, tc := range cases {
+		b, storage := newBackend()
+		resp = doReq(b, storage, "config/ca", map[string]interface{}{
+			"pem_bundle": tc.bundle,
+		})
+		if tc.expectErr != "" {
+			if resp == nil || !resp.IsError() {
+				t.Fatalf("%s: expected error response, got: %#v", name, resp)
+			}
+			if !strings.Contains(resp.Data["error"].(string), tc.expectErr) {
+				t.Fatalf("%s: unexpected error: %s", name, resp.Data["error"])
+			}
+			continue
+		}
+		if resp != nil && resp.IsError() {
+			t.Fatalf("%s: bad: resp: %#v", name, resp)
+		}
+
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "cert/ca",
+			Storage:   storage,
+		})
+		if err != nil || resp == nil || resp.IsError() {
+			t.Fatalf("%s: bad: err: %v, resp: %#v", name, err, resp)
+		}
+		if resp.Data["certificate"].(string) == "" {
+			t.Fatalf("%s: CA certificate not stored", name)
+		}
+	}
+}
+
+func TestBackend_OCSP(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	err := client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"common_name": "myvault.com",
+		"ttl":         "40h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode([]byte(secret.Data["certificate"].(string)))
+	caCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Write("pki/roles/test", map[string]interface{}{
+		"allowed_domains":  "myvault.com",
+		"allow_subdomains": true,
+		"max_ttl":          "4h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func() (*x509.Certificate, string) {
+		secret, err := client.Logical().Write("pki/issue/test", map[string]interface{}{
+			"common_name": "foo.myvault.com",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := pem.Decode([]byte(secret.Data["certificate"].(string)))
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, secret.Data["serial_number"].(string)
+	}
+	goodCert, _ := issue()
+	revokedCert, revokedSerial := issue()
+
+	_, err = client.Logical().Write("pki/revoke", map[string]interface{}{
+		"serial_number": revokedSerial,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Build a request for the good cert, the revoked cert and a serial the
+	// backend has never seen
+	keyBytes, err := ocspPublicKeyBytes(caCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nameHash := crypto.SHA1.New()
+	nameHash.Write(caCert.RawSubject)
+	keyHash := crypto.SHA1.New()
+	keyHash.Write(keyBytes)
+	var reqList []ocspSingleRequest
+	for _, serial := range []*big.Int{goodCert.SerialNumber, revokedCert.SerialNumber, big.NewInt(1)} {
+		reqList = append(reqList, ocspSingleRequest{
+			Cert: ocspCertID{
+				HashAlgorithm: pkix.AlgorithmIdentifier{
+					Algorithm: asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26},
+					Parameters: asn1.RawValue{
+						Tag: asn1.TagNull,
+					},
+				},
+				NameHash:      nameHash.Sum(nil),
+				IssuerKeyHash: keyHash.Sum(nil),
+				SerialNumber:  serial,
+			},
+		})
+	}
+	reqDER, err := asn1.Marshal(ocspRequest{
+		TBSRequest: ocspTBSRequest{
+			RequestList: reqList,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkResponse := func(respDER []byte, signerCert *x509.Certificate) {
+		var resp ocspResponse
+		if _, err := asn1.Unmarshal(respDER, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Status != ocspSuccessful {
+			t.Fatalf("bad: response status: %d", resp.Status)
+		}
+		var basicResp ocspBasicResponse
+		if _, err := asn1.Unmarshal(resp.Response.Response, &basicResp); err != nil {
+			t.Fatal(err)
+		}
+		err := signerCert.CheckSignature(x509.SHA256WithRSA, basicResp.TBSResponseData.Raw, basicResp.Signature.RightAlign())
+		if err != nil {
+			t.Fatalf("bad signature: %v", err)
+		}
+		// A delegated responder's certificate is included in the response
+		switch {
+		case signerCert == caCert && len(basicResp.Certificates) != 0:
+			t.Fatalf("bad: unexpected certificates in response: %d", len(basicResp.Certificates))
+		case signerCert != caCert && (len(basicResp.Certificates) != 1 || !bytes.Equal(basicResp.Certificates[0].FullBytes, signerCert.Raw)):
+			t.Fatalf("bad: expected the responder certificate in the response")
+		}
+		responses := basicResp.TBSResponseData.Responses
+		if len(responses) != 3 {
+			t.Fatalf("bad: number of responses: %d", len(responses))
+		}
+		if !bool(responses[0].Good) {
+			t.Fatalf("expected good status: %#v", responses[0])
+		}
+		if responses[1].Revoked.RevocationTime.IsZero() {
+			t.Fatalf("expected revoked status: %#v", responses[1])
+		}
+		if !bool(responses[2].Unknown) {
+			t.Fatalf("expected unknown status: %#v", responses[2])
+		}
+		if !responses[0].NextUpdate.After(responses[0].ThisUpdate) {
+			t.Fatalf("bad: next update %v is not after this update %v", responses[0].NextUpdate, responses[0].ThisUpdate)
+		}
+	}
+
+	// POST the raw DER request, as OCSP clients do
+	httpReq := client.NewRequest("POST", "/v1/pki/ocsp")
+	httpReq.Headers = http.Header{}
+	httpReq.Headers.Set("Content-Type", "application/ocsp-request")
+	httpReq.Body = bytes.NewReader(reqDER)
+	httpResp, err := client.RawRequest(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.Header.Get("Content-Type") != "application/ocsp-response" {
+		t.Fatalf("bad: content type: %s", httpResp.Header.Get("Content-Type"))
+	}
+	respDER, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResponse(respDER, caCert)
+
+	// The GET form carries the base64-encoded request in the path
+	resp, err := cluster.Cores[0].HandleRequest(&logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "pki/ocsp/" + base64.StdEncoding.EncodeToString(reqDER),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResponse(resp.Data[logical.HTTPRawBody].([]byte), caCert)
+
+	getResponse := func() []byte {
+		resp, err := cluster.Cores[0].HandleRequest(&logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "pki/ocsp/" + base64.StdEncoding.EncodeToString(reqDER),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data[logical.HTTPRawBody].([]byte)
+	}
+
+	// A certificate without the OCSPSigning usage cannot be a responder
+	secret, err = client.Logical().Write("pki/issue/test", map[string]interface{}{
+		"common_name": "foo.myvault.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("pki/config/ocsp", map[string]interface{}{
+		"pem_bundle": secret.Data["private_key"].(string) + "\n" + secret.Data["certificate"].(string),
+	})
+	if err == nil || !strings.Contains(err.Error(), "OCSPSigning") {
+		t.Fatalf("expected OCSPSigning error, got: %v", err)
+	}
+
+	// Have the CA sign a delegated responder certificate
+	responderKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ekuValue, err := asn1.Marshal([]asn1.ObjectIdentifier{{1, 3, 6, 1, 5, 5, 7, 3, 9}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "ocsp.myvault.com"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{2, 5, 29, 37}, Value: ekuValue},
+		},
+	}, responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err = client.Logical().Write("pki/sign-verbatim", map[string]interface{}{
+		"csr": string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})),
+		"ttl": "4h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ = pem.Decode([]byte(secret.Data["certificate"].(string)))
+	responderCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(responderKey)})
+	_, err = client.Logical().Write("pki/config/ocsp", map[string]interface{}{
+		"pem_bundle": string(keyPEM) + secret.Data["certificate"].(string),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err = client.Logical().Read("pki/config/ocsp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["certificate"] != strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: responderCert.Raw}))) {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+	if _, ok := secret.Data["private_key"]; ok {
+		t.Fatal("responder private key should not be returned")
+	}
+
+	// Responses are now signed by the responder, and by the CA again once
+	// it is removed
+	checkResponse(getResponse(), responderCert)
+	if _, err := client.Logical().Delete("pki/config/ocsp"); err != nil {
+		t.Fatal(err)
+	}
+	checkResponse(getResponse(), caCert)
+
+	// Garbage results in a malformed request status rather than an error
+	resp, err = cluster.Cores[0].HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "pki/ocsp",
+		Data: map[string]interface{}{
+			"request": base64.StdEncoding.EncodeToString([]byte("not a request")),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var malformed ocspResponse
+	if _, err := asn1.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &malformed); err != nil {
+		t.Fatal(err)
+	}
+	if malformed.Status != ocspMalformedRequest {
+		t.Fatalf("bad: response status: %d", malformed.Status)
+	}
+}
+
 func TestBackend_Permitted_DNS_Domains(t *testing.T) {
 	coreConfig := &vault.CoreConfig{
 		LogicalBackends: map[string]logical.Factory{
@@ -33,6 +33,7 @@ func (b *backend) getGenerationParams(
 		AllowLocalhost:   true,
 		AllowAnyName:     true,
 		AllowIPSANs:      true,
+		AllowedURISANs:   "*",
 		EnforceHostnames: false,
 	}
 
@@ -12,6 +12,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"net"
+	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -41,6 +42,7 @@ type creationBundle struct {
 	DNSNames       []string
 	EmailAddresses []string
 	IPAddresses    []net.IP
+	URIs           []*url.URL
 	IsCA           bool
 	KeyType        string
 	KeyBits        int
@@ -249,6 +251,17 @@ func fetchCertBySerial(req *logical.Request, prefix, serial string) (*logical.St
 	return certEntry, nil
 }
 
+// validateURISAN returns true if the given URI SAN matches one of the glob
+// patterns allowed by the role
+func validateURISAN(role *roleEntry, uri string) bool {
+	for _, allowed := range strutil.ParseDedupAndSortStrings(role.AllowedURISANs, ",") {
+		if glob.Glob(allowed, uri) {
+			return true
+		}
+	}
+	return false
+}
+
 // Given a set of requested names for a certificate, verifies that all of them
 // match the various toggles set in the role for controlling issuance.
 // If one does not pass, it is returned in the string argument.
@@ -711,6 +724,49 @@ func generateCreationBundle(b *backend,
 		}
 	}
 
+	// Get and verify any URI SANs
+	uriSANs := []*url.URL{}
+	{
+		if csr != nil && role.UseCSRSANs {
+			if len(csr.URIs) > 0 {
+				if role.AllowedURISANs == "" {
+					return nil, errutil.UserError{Err: fmt.Sprintf(
+						"URI Subject Alternative Names are not allowed in this role, but were provided via CSR")}
+				}
+				for _, uri := range csr.URIs {
+					if !validateURISAN(role, uri.String()) {
+						return nil, errutil.UserError{Err: fmt.Sprintf(
+							"URI Subject Alternative Name %s not allowed by this role", uri.String())}
+					}
+				}
+				uriSANs = csr.URIs
+			}
+		} else {
+			uriAltInt, ok := data.GetOk("uri_sans")
+			if ok {
+				uriAlt := uriAltInt.(string)
+				if len(uriAlt) != 0 {
+					if role.AllowedURISANs == "" {
+						return nil, errutil.UserError{Err: fmt.Sprintf(
+							"URI Subject Alternative Names are not allowed in this role, but was provided %s", uriAlt)}
+					}
+					for _, v := range strutil.ParseDedupAndSortStrings(uriAlt, ",") {
+						if !validateURISAN(role, v) {
+							return nil, errutil.UserError{Err: fmt.Sprintf(
+								"URI Subject Alternative Name %s not allowed by this role", v)}
+						}
+						parsedURI, err := url.Parse(v)
+						if err != nil || parsedURI.Scheme == "" {
+							return nil, errutil.UserError{Err: fmt.Sprintf(
+								"the value '%s' is not a valid URI", v)}
+						}
+						uriSANs = append(uriSANs, parsedURI)
+					}
+				}
+			}
+		}
+	}
+
 	// Set OU (organizationalUnit) values if specified in the role
 	ou := []string{}
 	{
@@ -808,6 +864,7 @@ func generateCreationBundle(b *backend,
 		DNSNames:       dnsNames,
 		EmailAddresses: emailAddresses,
 		IPAddresses:    ipAddresses,
+		URIs:           uriSANs,
 		KeyType:        role.KeyType,
 		KeyBits:        role.KeyBits,
 		SigningBundle:  signingBundle,
@@ -910,6 +967,7 @@ func createCertificate(creationInfo *creationBundle) (*certutil.ParsedCertBundle
 		DNSNames:       creationInfo.DNSNames,
 		EmailAddresses: creationInfo.EmailAddresses,
 		IPAddresses:    creationInfo.IPAddresses,
+		URIs:           creationInfo.URIs,
 	}
 
 	// Add this before calling addKeyUsages
@@ -1015,6 +1073,7 @@ func createCSR(creationInfo *creationBundle) (*certutil.ParsedCSRBundle, error)
 		DNSNames:       creationInfo.DNSNames,
 		EmailAddresses: creationInfo.EmailAddresses,
 		IPAddresses:    creationInfo.IPAddresses,
+		URIs:           creationInfo.URIs,
 	}
 
 	switch creationInfo.KeyType {
@@ -1096,12 +1155,14 @@ func signCertificate(creationInfo *creationBundle,
 		certTemplate.DNSNames = csr.DNSNames
 		certTemplate.EmailAddresses = csr.EmailAddresses
 		certTemplate.IPAddresses = csr.IPAddresses
+		certTemplate.URIs = csr.URIs
 
 		certTemplate.ExtraExtensions = csr.Extensions
 	} else {
 		certTemplate.DNSNames = creationInfo.DNSNames
 		certTemplate.EmailAddresses = creationInfo.EmailAddresses
 		certTemplate.IPAddresses = creationInfo.IPAddresses
+		certTemplate.URIs = creationInfo.URIs
 	}
 
 	addKeyUsages(creationInfo, certTemplate)
@@ -120,9 +120,19 @@ func revokeCert(b *backend, req *logical.Request, serial string, fromLease bool)
 // Builds a CRL by going through the list of revoked certificates and building
 // a new CRL with the stored revocation times and serial numbers.
 func buildCRL(b *backend, req *logical.Request) error {
-	revokedSerials, err := req.Storage.List("revoked/")
+	crlInfo, err := b.CRL(req.Storage)
 	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("Error fetching list of revoked certs: %s", err)}
+		return errutil.InternalError{Err: fmt.Sprintf("Error fetching CRL config information: %s", err)}
+	}
+
+	var revokedSerials []string
+	// If the CRL is disabled, an empty (but valid) CRL is still generated so
+	// that relying parties fetching it do not fail
+	if crlInfo == nil || !crlInfo.Disable {
+		revokedSerials, err = req.Storage.List("revoked/")
+		if err != nil {
+			return errutil.InternalError{Err: fmt.Sprintf("Error fetching list of revoked certs: %s", err)}
+		}
 	}
 
 	revokedCerts := []pkix.RevokedCertificate{}
@@ -174,10 +184,6 @@ func buildCRL(b *backend, req *logical.Request) error {
 	}
 
 	crlLifetime := b.crlLifetime
-	crlInfo, err := b.CRL(req.Storage)
-	if err != nil {
-		return errutil.InternalError{Err: fmt.Sprintf("Error fetching CRL config information: %s", err)}
-	}
 	if crlInfo != nil {
 		crlDur, err := time.ParseDuration(crlInfo.Expiry)
 		if err != nil {
@@ -0,0 +1,286 @@
+package pki
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// The ASN.1 structures below follow RFC 6960. Only the subset needed to parse
+// requests and produce signed basic responses is implemented.
+
+const (
+	ocspSuccessful       asn1.Enumerated = 0
+	ocspMalformedRequest asn1.Enumerated = 1
+	ocspInternalError    asn1.Enumerated = 2
+	ocspUnauthorized     asn1.Enumerated = 6
+)
+
+// Status values for a single certificate in an OCSP response
+const (
+	ocspStatusGood = iota
+	ocspStatusRevoked
+	ocspStatusUnknown
+)
+
+var (
+	oidOCSPBasicResponse = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+
+	oidSignatureSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+
+	ocspHashOIDs = map[string]crypto.Hash{
+		asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}.String():             crypto.SHA1,
+		asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}.String(): crypto.SHA256,
+		asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}.String(): crypto.SHA384,
+		asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}.String(): crypto.SHA512,
+	}
+)
+
+type ocspCertID struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	NameHash      []byte
+	IssuerKeyHash []byte
+	SerialNumber  *big.Int
+}
+
+type ocspSingleRequest struct {
+	Cert ocspCertID
+}
+
+type ocspTBSRequest struct {
+	Version       int              `asn1:"explicit,tag:0,default:0,optional"`
+	RequestorName pkix.RDNSequence `asn1:"explicit,tag:1,optional"`
+	RequestList   []ocspSingleRequest
+}
+
+type ocspRequest struct {
+	TBSRequest ocspTBSRequest
+}
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Raw            asn1.RawContent
+	Version        int `asn1:"optional,default:0,explicit,tag:0"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []ocspSingleResponse
+}
+
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked    ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate time.Time        `asn1:"generalized"`
+	NextUpdate time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	Extensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time `asn1:"generalized"`
+}
+
+// ocspCertStatus is the status of a single certificate, as determined by the
+// backend, to be encoded into a response
+type ocspCertStatus struct {
+	CertID         ocspCertID
+	Status         int
+	RevocationTime time.Time
+}
+
+// parseOCSPRequest parses a DER-encoded OCSP request and returns the IDs of
+// the certificates whose status is being requested
+func parseOCSPRequest(der []byte) ([]ocspCertID, error) {
+	var req ocspRequest
+	rest, err := asn1.Unmarshal(der, &req)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data in OCSP request")
+	}
+	if len(req.TBSRequest.RequestList) == 0 {
+		return nil, fmt.Errorf("OCSP request contains no certificate IDs")
+	}
+
+	ids := make([]ocspCertID, 0, len(req.TBSRequest.RequestList))
+	for _, singleReq := range req.TBSRequest.RequestList {
+		ids = append(ids, singleReq.Cert)
+	}
+	return ids, nil
+}
+
+// ocspPublicKeyBytes returns the contents of the subjectPublicKey bit string
+// of the given certificate, which is what OCSP key hashes are computed over
+func ocspPublicKeyBytes(cert *x509.Certificate) ([]byte, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, err
+	}
+	return spki.PublicKey.RightAlign(), nil
+}
+
+// ocspIssuerMatches returns whether the given certificate ID refers to a
+// certificate issued by issuer
+func ocspIssuerMatches(id ocspCertID, issuer *x509.Certificate) (bool, error) {
+	hash, ok := ocspHashOIDs[id.HashAlgorithm.Algorithm.String()]
+	if !ok || !hash.Available() {
+		return false, nil
+	}
+
+	keyBytes, err := ocspPublicKeyBytes(issuer)
+	if err != nil {
+		return false, err
+	}
+
+	h := hash.New()
+	h.Write(issuer.RawSubject)
+	nameHash := h.Sum(nil)
+
+	h = hash.New()
+	h.Write(keyBytes)
+	keyHash := h.Sum(nil)
+
+	return bytes.Equal(nameHash, id.NameHash) && bytes.Equal(keyHash, id.IssuerKeyHash), nil
+}
+
+// createOCSPResponse builds a successful OCSP response containing the given
+// statuses, signed by the given responder certificate and key. The responder
+// is either the issuer itself or a delegated responder, whose certificate
+// must then be passed in certs for clients to verify the signature.
+func createOCSPResponse(responder *x509.Certificate, signer crypto.Signer, certs []*x509.Certificate, statuses []ocspCertStatus, thisUpdate, nextUpdate time.Time) ([]byte, error) {
+	var sigAlgo pkix.AlgorithmIdentifier
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		sigAlgo.Algorithm = oidSignatureSHA256WithRSA
+		sigAlgo.Parameters = asn1.RawValue{Tag: asn1.TagNull}
+	case *ecdsa.PublicKey:
+		sigAlgo.Algorithm = oidSignatureECDSAWithSHA256
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T", signer.Public())
+	}
+
+	keyBytes, err := ocspPublicKeyBytes(responder)
+	if err != nil {
+		return nil, err
+	}
+	h := crypto.SHA1.New()
+	h.Write(keyBytes)
+	keyHash, err := asn1.Marshal(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]ocspSingleResponse, 0, len(statuses))
+	for _, status := range statuses {
+		single := ocspSingleResponse{
+			CertID:     status.CertID,
+			ThisUpdate: thisUpdate.UTC(),
+			NextUpdate: nextUpdate.UTC(),
+		}
+		switch status.Status {
+		case ocspStatusGood:
+			single.Good = true
+		case ocspStatusRevoked:
+			single.Revoked = ocspRevokedInfo{
+				RevocationTime: status.RevocationTime.UTC(),
+			}
+		default:
+			single.Unknown = true
+		}
+		responses = append(responses, single)
+	}
+
+	tbsResponseData := ocspResponseData{
+		// The responder is identified by the SHA-1 hash of its public key
+		RawResponderID: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        2,
+			IsCompound: true,
+			Bytes:      keyHash,
+		},
+		ProducedAt: time.Now().UTC().Truncate(time.Second),
+		Responses:  responses,
+	}
+
+	tbsResponseDataDER, err := asn1.Marshal(tbsResponseData)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := crypto.SHA256.New()
+	digest.Write(tbsResponseDataDER)
+	signature, err := signer.Sign(rand.Reader, digest.Sum(nil), crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	basicResponse := ocspBasicResponse{
+		TBSResponseData: ocspResponseData{
+			Raw: tbsResponseDataDER,
+		},
+		SignatureAlgorithm: sigAlgo,
+		Signature: asn1.BitString{
+			Bytes:     signature,
+			BitLength: 8 * len(signature),
+		},
+	}
+	for _, cert := range certs {
+		basicResponse.Certificates = append(basicResponse.Certificates, asn1.RawValue{
+			FullBytes: cert.Raw,
+		})
+	}
+	basicResponseDER, err := asn1.Marshal(basicResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(ocspResponse{
+		Status: ocspSuccessful,
+		Response: ocspResponseBytes{
+			ResponseType: oidOCSPBasicResponse,
+			Response:     basicResponseDER,
+		},
+	})
+}
+
+// createOCSPErrorResponse builds an unsuccessful OCSP response with the given
+// status, which carries no response bytes
+func createOCSPErrorResponse(status asn1.Enumerated) []byte {
+	// Marshalling a single enumerated value cannot fail
+	der, _ := asn1.Marshal(ocspResponse{
+		Status: status,
+	})
+	return der
+}
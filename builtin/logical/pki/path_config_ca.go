@@ -1,6 +1,7 @@
 package pki
 
 import (
+	"crypto/x509"
 	"fmt"
 
 	"github.com/hashicorp/vault/helper/certutil"
@@ -16,7 +17,8 @@ func pathConfigCA(b *backend) *framework.Path {
 			"pem_bundle": &framework.FieldSchema{
 				Type: framework.TypeString,
 				Description: `PEM-format, concatenated unencrypted
-secret key and certificate.`,
+secret key and certificate, optionally followed by
+the certificates of the issuing chain.`,
 			},
 		},
 
@@ -56,6 +58,11 @@ func (b *backend) pathCAWrite(
 		return logical.ErrorResponse("the given certificate is not marked for CA use and cannot be used with this backend"), nil
 	}
 
+	if parsedBundle.Certificate.KeyUsage != 0 &&
+		parsedBundle.Certificate.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return logical.ErrorResponse("the given certificate does not allow certificate signing in its key usage and cannot be used with this backend"), nil
+	}
+
 	cb, err := parsedBundle.ToCertBundle()
 	if err != nil {
 		return nil, fmt.Errorf("error converting raw values into cert bundle: %s", err)
@@ -91,7 +98,9 @@ Set the CA certificate and private key used for generated credentials.
 const pathConfigCAHelpDesc = `
 This sets the CA information used for credentials generated by this
 by this mount. This must be a PEM-format, concatenated unencrypted
-secret key and certificate.
+secret key and certificate, optionally followed by the certificates of
+the issuing chain. The private key must match the certificate, and each
+certificate in the chain must have been signed by the one following it.
 
 For security reasons, the secret key cannot be retrieved later.
 `
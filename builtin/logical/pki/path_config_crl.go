@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
 
 // CRLConfig holds basic CRL configuration information
 type crlConfig struct {
-	Expiry string `json:"expiry" mapstructure:"expiry" structs:"expiry"`
+	Expiry  string `json:"expiry" mapstructure:"expiry" structs:"expiry"`
+	Disable bool   `json:"disable" mapstructure:"disable" structs:"disable"`
 }
 
 func pathConfigCRL(b *backend) *framework.Path {
@@ -23,6 +25,11 @@ func pathConfigCRL(b *backend) *framework.Path {
 valid; defaults to 72 hours`,
 				Default: "72h",
 			},
+			"disable": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `If set to true, disables generating the CRL
+entirely; an empty CRL will be served instead`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -64,22 +71,34 @@ func (b *backend) pathCRLRead(
 
 	return &logical.Response{
 		Data: map[string]interface{}{
-			"expiry": config.Expiry,
+			"expiry":  config.Expiry,
+			"disable": config.Disable,
 		},
 	}, nil
 }
 
 func (b *backend) pathCRLWrite(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
-	expiry := d.Get("expiry").(string)
+	config, err := b.CRL(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &crlConfig{
+			Expiry: d.Get("expiry").(string),
+		}
+	}
 
-	_, err := time.ParseDuration(expiry)
+	if expiryRaw, ok := d.GetOk("expiry"); ok {
+		config.Expiry = expiryRaw.(string)
+	}
+	_, err = time.ParseDuration(config.Expiry)
 	if err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("Given expiry could not be decoded: %s", err)), nil
 	}
 
-	config := &crlConfig{
-		Expiry: expiry,
+	if disableRaw, ok := d.GetOk("disable"); ok {
+		config.Disable = disableRaw.(bool)
 	}
 
 	entry, err := logical.StorageEntryJSON("config/crl", config)
@@ -91,6 +110,27 @@ func (b *backend) pathCRLWrite(
 		return nil, err
 	}
 
+	// Rebuild the CRL so that the new settings take effect immediately. If
+	// no CA has been configured yet there is nothing to rebuild.
+	b.revokeStorageLock.Lock()
+	defer b.revokeStorageLock.Unlock()
+
+	caEntry, err := req.Storage.Get("config/ca_bundle")
+	if err != nil {
+		return nil, err
+	}
+	if caEntry == nil {
+		return nil, nil
+	}
+
+	crlErr := buildCRL(b, req)
+	switch crlErr.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(fmt.Sprintf("Error during CRL building: %s", crlErr)), nil
+	case errutil.InternalError:
+		return nil, fmt.Errorf("Error encountered during CRL building: %s", crlErr)
+	}
+
 	return nil, nil
 }
 
@@ -99,5 +139,7 @@ Configure the CRL expiration.
 `
 
 const pathConfigCRLHelpDesc = `
-This endpoint allows configuration of the CRL lifetime.
+This endpoint allows configuration of the CRL lifetime, and allows
+CRL generation to be disabled. Changes take effect immediately, as the
+CRL is rebuilt on write.
 `
@@ -0,0 +1,193 @@
+package pki
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/helper/errutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const ocspResponderBundlePath = "config/ocsp_bundle"
+
+func pathConfigOCSP(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/ocsp",
+		Fields: map[string]*framework.FieldSchema{
+			"pem_bundle": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `PEM-format, concatenated unencrypted
+secret key and certificate of the delegated OCSP
+responder. The certificate must be issued by this
+backend's CA and carry the OCSPSigning extended
+key usage.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation:   b.pathConfigOCSPRead,
+			logical.UpdateOperation: b.pathConfigOCSPWrite,
+			logical.DeleteOperation: b.pathConfigOCSPDelete,
+		},
+
+		HelpSynopsis:    pathConfigOCSPHelpSyn,
+		HelpDescription: pathConfigOCSPHelpDesc,
+	}
+}
+
+// ocspResponder returns the configured delegated OCSP responder, or nil if
+// responses are signed by the CA
+func (b *backend) ocspResponder(s logical.Storage) (*certutil.ParsedCertBundle, error) {
+	entry, err := s.Get(ocspResponderBundlePath)
+	if err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to fetch OCSP responder certificate/key: %v", err)}
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var bundle certutil.CertBundle
+	if err := entry.DecodeJSON(&bundle); err != nil {
+		return nil, errutil.InternalError{Err: fmt.Sprintf("unable to decode OCSP responder certificate/key: %v", err)}
+	}
+
+	parsedBundle, err := bundle.ToParsedCertBundle()
+	if err != nil {
+		return nil, errutil.InternalError{Err: err.Error()}
+	}
+	if parsedBundle.Certificate == nil || parsedBundle.PrivateKey == nil {
+		return nil, errutil.InternalError{Err: "stored OCSP responder information not able to be parsed"}
+	}
+
+	return parsedBundle, nil
+}
+
+// validateOCSPResponder checks that cert may sign OCSP responses on behalf of
+// the CA certificate caCert, as described in RFC 6960 section 4.2.2.2
+func validateOCSPResponder(cert, caCert *x509.Certificate) error {
+	hasEKU := false
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageOCSPSigning {
+			hasEKU = true
+			break
+		}
+	}
+	if !hasEKU {
+		return fmt.Errorf("the given certificate does not carry the OCSPSigning extended key usage")
+	}
+
+	if err := cert.CheckSignatureFrom(caCert); err != nil {
+		return fmt.Errorf("the given certificate was not issued by this backend's CA: %s", err)
+	}
+
+	if time.Now().After(cert.NotAfter) {
+		return fmt.Errorf("the given certificate has expired")
+	}
+
+	return nil
+}
+
+func (b *backend) pathConfigOCSPRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	responder, err := b.ocspResponder(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if responder == nil {
+		return nil, nil
+	}
+
+	cb, err := responder.ToCertBundle()
+	if err != nil {
+		return nil, fmt.Errorf("error converting raw values into cert bundle: %s", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"certificate":   cb.Certificate,
+			"serial_number": cb.SerialNumber,
+			"expiration":    responder.Certificate.NotAfter.Unix(),
+		},
+	}, nil
+}
+
+func (b *backend) pathConfigOCSPWrite(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	pemBundle := data.Get("pem_bundle").(string)
+
+	parsedBundle, err := certutil.ParsePEMBundle(pemBundle)
+	if err != nil {
+		switch err.(type) {
+		case errutil.InternalError:
+			return nil, err
+		default:
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if parsedBundle.PrivateKey == nil ||
+		parsedBundle.PrivateKeyType == certutil.UnknownPrivateKey {
+		return logical.ErrorResponse("private key not found in the PEM bundle"), nil
+	}
+
+	if parsedBundle.Certificate == nil {
+		return logical.ErrorResponse("no certificate found in the PEM bundle"), nil
+	}
+
+	caInfo, err := fetchCAInfo(req)
+	switch err.(type) {
+	case errutil.UserError:
+		return logical.ErrorResponse(err.Error()), nil
+	case errutil.InternalError:
+		return nil, err
+	}
+
+	if err := validateOCSPResponder(parsedBundle.Certificate, caInfo.Certificate); err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// Only the responder itself is stored; the issuing chain is this
+	// backend's CA
+	parsedBundle.CAChain = nil
+
+	cb, err := parsedBundle.ToCertBundle()
+	if err != nil {
+		return nil, fmt.Errorf("error converting raw values into cert bundle: %s", err)
+	}
+
+	entry, err := logical.StorageEntryJSON(ocspResponderBundlePath, cb)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, req.Storage.Put(entry)
+}
+
+func (b *backend) pathConfigOCSPDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(ocspResponderBundlePath)
+}
+
+const pathConfigOCSPHelpSyn = `
+Configure a delegated responder to sign OCSP responses.
+`
+
+const pathConfigOCSPHelpDesc = `
+By default, OCSP responses are signed by this backend's CA. Writing a
+PEM-format, concatenated unencrypted secret key and certificate here makes
+the OCSP responder sign with that key instead and include the certificate in
+its responses, so that the CA key is not needed to answer OCSP requests.
+
+The certificate must be issued by this backend's CA and carry the
+OCSPSigning extended key usage; it should also carry the id-pkix-ocsp-nocheck
+extension so that clients do not check its own revocation status. While the
+certificate is expired, or once the CA has been replaced, responses are
+signed by the CA again.
+
+Reading this endpoint returns the responder certificate; for security
+reasons the secret key cannot be retrieved later. Deleting it reverts to
+signing responses with the CA.
+`
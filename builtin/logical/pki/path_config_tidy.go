@@ -0,0 +1,203 @@
+package pki
+
+import (
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	tidyConfigPath = "config/tidy"
+)
+
+// tidyConfig holds the settings used by the periodic tidy operation
+type tidyConfig struct {
+	TidyCertStore       bool `json:"tidy_cert_store" structs:"tidy_cert_store" mapstructure:"tidy_cert_store"`
+	TidyRevocationList  bool `json:"tidy_revocation_list" structs:"tidy_revocation_list" mapstructure:"tidy_revocation_list"`
+	SafetyBuffer        int  `json:"safety_buffer" structs:"safety_buffer" mapstructure:"safety_buffer"`
+	DisablePeriodicTidy bool `json:"disable_periodic_tidy" structs:"disable_periodic_tidy" mapstructure:"disable_periodic_tidy"`
+}
+
+func pathConfigTidy(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: tidyConfigPath,
+		Fields: map[string]*framework.FieldSchema{
+			"tidy_cert_store": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Set to true to enable periodic tidying up
+of the certificate store`,
+				Default: false,
+			},
+
+			"tidy_revocation_list": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Set to true to enable periodic tidying up
+of the revocation list`,
+				Default: false,
+			},
+
+			"safety_buffer": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `The amount of extra time that must have passed
+beyond certificate expiration before it is removed
+from the backend storage and/or revocation list.
+Defaults to 72 hours.`,
+				Default: 259200, //72h
+			},
+
+			"disable_periodic_tidy": &framework.FieldSchema{
+				Type:        framework.TypeBool,
+				Default:     false,
+				Description: "If set to 'true', disables the periodic tidy operation.",
+			},
+		},
+
+		ExistenceCheck: b.pathConfigTidyExistenceCheck,
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.CreateOperation: b.pathConfigTidyCreateUpdate,
+			logical.UpdateOperation: b.pathConfigTidyCreateUpdate,
+			logical.ReadOperation:   b.pathConfigTidyRead,
+			logical.DeleteOperation: b.pathConfigTidyDelete,
+		},
+
+		HelpSynopsis:    pathConfigTidyHelpSyn,
+		HelpDescription: pathConfigTidyHelpDesc,
+	}
+}
+
+func (b *backend) pathConfigTidyExistenceCheck(req *logical.Request, data *framework.FieldData) (bool, error) {
+	entry, err := b.tidyConfig(req.Storage)
+	if err != nil {
+		return false, err
+	}
+	return entry != nil, nil
+}
+
+func (b *backend) tidyConfig(s logical.Storage) (*tidyConfig, error) {
+	entry, err := s.Get(tidyConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var result tidyConfig
+	if err := entry.DecodeJSON(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *backend) pathConfigTidyCreateUpdate(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	configEntry, err := b.tidyConfig(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if configEntry == nil {
+		configEntry = &tidyConfig{}
+	}
+
+	tidyCertStoreBool, ok := data.GetOk("tidy_cert_store")
+	if ok {
+		configEntry.TidyCertStore = tidyCertStoreBool.(bool)
+	} else if req.Operation == logical.CreateOperation {
+		configEntry.TidyCertStore = data.Get("tidy_cert_store").(bool)
+	}
+
+	tidyRevocationListBool, ok := data.GetOk("tidy_revocation_list")
+	if ok {
+		configEntry.TidyRevocationList = tidyRevocationListBool.(bool)
+	} else if req.Operation == logical.CreateOperation {
+		configEntry.TidyRevocationList = data.Get("tidy_revocation_list").(bool)
+	}
+
+	safetyBufferInt, ok := data.GetOk("safety_buffer")
+	if ok {
+		configEntry.SafetyBuffer = safetyBufferInt.(int)
+	} else if req.Operation == logical.CreateOperation {
+		configEntry.SafetyBuffer = data.Get("safety_buffer").(int)
+	}
+
+	disablePeriodicTidyBool, ok := data.GetOk("disable_periodic_tidy")
+	if ok {
+		configEntry.DisablePeriodicTidy = disablePeriodicTidyBool.(bool)
+	} else if req.Operation == logical.CreateOperation {
+		configEntry.DisablePeriodicTidy = data.Get("disable_periodic_tidy").(bool)
+	}
+
+	entry, err := logical.StorageEntryJSON(tidyConfigPath, configEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Storage.Put(entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathConfigTidyRead(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	configEntry, err := b.tidyConfig(req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if configEntry == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: structs.New(configEntry).Map(),
+	}, nil
+}
+
+func (b *backend) pathConfigTidyDelete(req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return nil, req.Storage.Delete(tidyConfigPath)
+}
+
+// periodicFunc is triggered once a minute by the RollbackManager. If periodic
+// tidying has been configured via 'config/tidy', the tidy operation is started
+// in the background once every 'tidyCooldownPeriod'.
+func (b *backend) periodicFunc(req *logical.Request) error {
+	if !b.nextTidyTime.IsZero() && time.Now().Before(b.nextTidyTime) {
+		return nil
+	}
+
+	configEntry, err := b.tidyConfig(req.Storage)
+	if err != nil {
+		return err
+	}
+
+	// Update the time at which to run the tidy function again
+	b.nextTidyTime = time.Now().Add(b.tidyCooldownPeriod)
+
+	if configEntry == nil || configEntry.DisablePeriodicTidy {
+		return nil
+	}
+	if !configEntry.TidyCertStore && !configEntry.TidyRevocationList {
+		return nil
+	}
+
+	err = b.startTidy(req.Storage, configEntry.TidyCertStore, configEntry.TidyRevocationList, configEntry.SafetyBuffer)
+	if err == errTidyRunning {
+		// A manually triggered tidy is already taking care of it
+		return nil
+	}
+	return err
+}
+
+const pathConfigTidyHelpSyn = `
+Configures the periodic tidying of the certificate store and revocation list.
+`
+
+const pathConfigTidyHelpDesc = `
+If configured, the tidy operation is run periodically (once an hour) using
+the parameters set here. As with the 'tidy' endpoint, nothing is removed
+unless 'tidy_cert_store' and/or 'tidy_revocation_list' are enabled, and
+entries are only removed once 'safety_buffer' has elapsed past their
+expiration.
+`
@@ -68,7 +68,7 @@ func (b *backend) pathGenerateIntermediate(
 		switch err.(type) {
 		case errutil.UserError:
 			return logical.ErrorResponse(err.Error()), nil
-		case errutil.InternalError:
+		default:
 			return nil, err
 		}
 	}
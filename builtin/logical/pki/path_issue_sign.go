@@ -3,6 +3,7 @@ package pki
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/vault/helper/certutil"
@@ -72,6 +73,27 @@ taken verbatim from the CSR, except for
 basic constraints.`,
 	}
 
+	ret.Fields["key_usage"] = &framework.FieldSchema{
+		Type: framework.TypeString,
+		Description: `A comma-separated set of key usages (not extended
+key usages). Valid values can be found at
+https://golang.org/pkg/crypto/x509/#KeyUsage
+-- simply drop the "KeyUsage" part of the name.
+Usages present in the CSR take precedence. If a
+role is given and this is not set, the role's
+value is used; otherwise no key usages are set.`,
+	}
+
+	ret.Fields["ext_key_usage"] = &framework.FieldSchema{
+		Type:    framework.TypeCommaStringSlice,
+		Default: []string{},
+		Description: `A comma-separated set of extended key usages.
+Valid values are "ServerAuth", "ClientAuth",
+"CodeSigning" and "EmailProtection". Usages present
+in the CSR take precedence. If a role is given and
+this is not set, the role's flags are used.`,
+	}
+
 	return ret
 }
 
@@ -133,6 +155,7 @@ func (b *backend) pathSignVerbatim(
 		AllowLocalhost:   true,
 		AllowAnyName:     true,
 		AllowIPSANs:      true,
+		AllowedURISANs:   "*",
 		EnforceHostnames: false,
 		KeyType:          "any",
 		UseCSRCommonName: true,
@@ -148,6 +171,36 @@ func (b *backend) pathSignVerbatim(
 			entry.MaxTTL = role.MaxTTL
 		}
 		entry.NoStore = role.NoStore
+		entry.KeyUsage = role.KeyUsage
+		entry.ServerFlag = role.ServerFlag
+		entry.ClientFlag = role.ClientFlag
+		entry.CodeSigningFlag = role.CodeSigningFlag
+		entry.EmailProtectionFlag = role.EmailProtectionFlag
+	}
+
+	if keyUsage, ok := data.GetOk("key_usage"); ok {
+		entry.KeyUsage = keyUsage.(string)
+	}
+
+	if extKeyUsage, ok := data.GetOk("ext_key_usage"); ok {
+		entry.ServerFlag = false
+		entry.ClientFlag = false
+		entry.CodeSigningFlag = false
+		entry.EmailProtectionFlag = false
+		for _, usage := range extKeyUsage.([]string) {
+			switch strings.ToLower(strings.TrimSpace(usage)) {
+			case "serverauth":
+				entry.ServerFlag = true
+			case "clientauth":
+				entry.ClientFlag = true
+			case "codesigning":
+				entry.CodeSigningFlag = true
+			case "emailprotection":
+				entry.EmailProtectionFlag = true
+			default:
+				return logical.ErrorResponse(fmt.Sprintf("unknown extended key usage %q", usage)), nil
+			}
+		}
 	}
 
 	*entry.GenerateLease = false
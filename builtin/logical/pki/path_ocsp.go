@@ -0,0 +1,198 @@
+package pki
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/certutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const ocspResponseContentType = "application/ocsp-response"
+
+func pathOCSP(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "ocsp",
+		Fields: map[string]*framework.FieldSchema{
+			"request": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Base64-encoded DER OCSP request.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathOCSPHandler,
+		},
+
+		HelpSynopsis:    pathOCSPHelpSyn,
+		HelpDescription: pathOCSPHelpDesc,
+	}
+}
+
+func pathOCSPGet(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: `ocsp/(?P<request>.+)`,
+		Fields: map[string]*framework.FieldSchema{
+			"request": &framework.FieldSchema{
+				Type:        framework.TypeString,
+				Description: `Base64-encoded DER OCSP request.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathOCSPHandler,
+		},
+
+		HelpSynopsis:    pathOCSPHelpSyn,
+		HelpDescription: pathOCSPHelpDesc,
+	}
+}
+
+func (b *backend) pathOCSPHandler(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	respond := func(der []byte) *logical.Response {
+		return &logical.Response{
+			Data: map[string]interface{}{
+				logical.HTTPContentType: ocspResponseContentType,
+				logical.HTTPRawBody:     der,
+				logical.HTTPStatusCode:  200,
+			},
+		}
+	}
+
+	reqDER, err := base64.StdEncoding.DecodeString(data.Get("request").(string))
+	if err != nil {
+		return respond(createOCSPErrorResponse(ocspMalformedRequest)), nil
+	}
+	certIDs, err := parseOCSPRequest(reqDER)
+	if err != nil {
+		return respond(createOCSPErrorResponse(ocspMalformedRequest)), nil
+	}
+
+	caInfo, err := fetchCAInfo(req)
+	if err != nil {
+		// Without a CA there is nothing this responder is authoritative for
+		return respond(createOCSPErrorResponse(ocspUnauthorized)), nil
+	}
+
+	statuses := make([]ocspCertStatus, 0, len(certIDs))
+	for _, certID := range certIDs {
+		status, err := b.ocspCertStatus(req, caInfo, certID)
+		if err != nil {
+			b.Logger().Error("pki: error determining OCSP certificate status", "error", err)
+			return respond(createOCSPErrorResponse(ocspInternalError)), nil
+		}
+		statuses = append(statuses, *status)
+	}
+
+	// Responses are valid for as long as a CRL built now would be
+	thisUpdate := time.Now().Truncate(time.Second)
+	nextUpdate := thisUpdate.Add(b.crlLifetime)
+	crlConfig, err := b.CRL(req.Storage)
+	if err != nil {
+		return respond(createOCSPErrorResponse(ocspInternalError)), nil
+	}
+	if crlConfig != nil {
+		crlDur, err := time.ParseDuration(crlConfig.Expiry)
+		if err == nil {
+			nextUpdate = thisUpdate.Add(crlDur)
+		}
+	}
+
+	// Sign with the delegated responder if one is configured and still
+	// valid for the current CA, and with the CA itself otherwise
+	responderCert, signer := caInfo.Certificate, caInfo.PrivateKey
+	var certs []*x509.Certificate
+	responder, err := b.ocspResponder(req.Storage)
+	if err != nil {
+		b.Logger().Error("pki: error fetching OCSP responder", "error", err)
+		return respond(createOCSPErrorResponse(ocspInternalError)), nil
+	}
+	if responder != nil {
+		if err := validateOCSPResponder(responder.Certificate, caInfo.Certificate); err != nil {
+			b.Logger().Warn("pki: not using delegated OCSP responder, signing with the CA", "error", err)
+		} else {
+			responderCert, signer = responder.Certificate, responder.PrivateKey
+			certs = []*x509.Certificate{responder.Certificate}
+		}
+	}
+
+	respDER, err := createOCSPResponse(responderCert, signer, certs, statuses, thisUpdate, nextUpdate)
+	if err != nil {
+		b.Logger().Error("pki: error creating OCSP response", "error", err)
+		return respond(createOCSPErrorResponse(ocspInternalError)), nil
+	}
+
+	return respond(respDER), nil
+}
+
+// ocspCertStatus looks up the status of the certificate with the given ID.
+// Certificates that were not issued by this backend's CA, or that were never
+// stored, are reported as unknown.
+func (b *backend) ocspCertStatus(req *logical.Request, caInfo *caInfoBundle, certID ocspCertID) (*ocspCertStatus, error) {
+	status := &ocspCertStatus{
+		CertID: certID,
+		Status: ocspStatusUnknown,
+	}
+
+	issued, err := ocspIssuerMatches(certID, caInfo.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	if !issued || certID.SerialNumber == nil {
+		return status, nil
+	}
+
+	serial := certutil.GetHexFormatted(certID.SerialNumber.Bytes(), ":")
+
+	b.revokeStorageLock.RLock()
+	defer b.revokeStorageLock.RUnlock()
+
+	revokedEntry, err := fetchCertBySerial(req, "revoked/", serial)
+	if err != nil {
+		return nil, err
+	}
+	if revokedEntry != nil {
+		var revInfo revocationInfo
+		if err := revokedEntry.DecodeJSON(&revInfo); err != nil {
+			return nil, fmt.Errorf("error decoding revocation entry for serial %s: %s", serial, err)
+		}
+		status.Status = ocspStatusRevoked
+		if !revInfo.RevocationTimeUTC.IsZero() {
+			status.RevocationTime = revInfo.RevocationTimeUTC
+		} else {
+			status.RevocationTime = time.Unix(revInfo.RevocationTime, 0).UTC()
+		}
+		return status, nil
+	}
+
+	certEntry, err := fetchCertBySerial(req, "certs/", serial)
+	if err != nil {
+		return nil, err
+	}
+	if certEntry != nil {
+		status.Status = ocspStatusGood
+	}
+
+	return status, nil
+}
+
+const pathOCSPHelpSyn = `
+Query the revocation status of certificates issued by this backend via OCSP.
+`
+
+const pathOCSPHelpDesc = `
+This endpoint implements an RFC 6960 OCSP responder. Requests can be sent
+either as a POST with a DER-encoded body and a Content-Type of
+"application/ocsp-request", as a POST with the base64-encoded request in the
+"request" parameter, or as a GET with the base64-encoded request appended to
+the path.
+
+Responses are signed by the issuing CA of this backend, or by the delegated
+responder configured through "config/ocsp". Certificates that were
+not issued by this backend's CA, or that were issued by a role with "no_store"
+set, are reported as having an unknown status.
+`
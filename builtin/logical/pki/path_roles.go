@@ -114,6 +114,14 @@ CN and SANs. Defaults to true.`,
 Any valid IP is accepted.`,
 			},
 
+			"allowed_uri_sans": &framework.FieldSchema{
+				Type: framework.TypeString,
+				Description: `If set, an array of allowed URIs for URI Subject
+Alternative Names. Any valid URI is accepted; these
+values support globbing, e.g. "spiffe://example.com/*".
+If empty, URI SANs are not allowed.`,
+			},
+
 			"server_flag": &framework.FieldSchema{
 				Type:    framework.TypeBool,
 				Default: true,
@@ -392,6 +400,7 @@ func (b *backend) pathRoleCreate(
 		AllowAnyName:        data.Get("allow_any_name").(bool),
 		EnforceHostnames:    data.Get("enforce_hostnames").(bool),
 		AllowIPSANs:         data.Get("allow_ip_sans").(bool),
+		AllowedURISANs:      data.Get("allowed_uri_sans").(string),
 		ServerFlag:          data.Get("server_flag").(bool),
 		ClientFlag:          data.Get("client_flag").(bool),
 		CodeSigningFlag:     data.Get("code_signing_flag").(bool),
@@ -518,6 +527,7 @@ type roleEntry struct {
 	AllowAnyName          bool   `json:"allow_any_name" structs:"allow_any_name" mapstructure:"allow_any_name"`
 	EnforceHostnames      bool   `json:"enforce_hostnames" structs:"enforce_hostnames" mapstructure:"enforce_hostnames"`
 	AllowIPSANs           bool   `json:"allow_ip_sans" structs:"allow_ip_sans" mapstructure:"allow_ip_sans"`
+	AllowedURISANs        string `json:"allowed_uri_sans" structs:"allowed_uri_sans" mapstructure:"allowed_uri_sans"`
 	ServerFlag            bool   `json:"server_flag" structs:"server_flag" mapstructure:"server_flag"`
 	ClientFlag            bool   `json:"client_flag" structs:"client_flag" mapstructure:"client_flag"`
 	CodeSigningFlag       bool   `json:"code_signing_flag" structs:"code_signing_flag" mapstructure:"code_signing_flag"`
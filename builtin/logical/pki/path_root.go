@@ -218,6 +218,7 @@ func (b *backend) pathCASignIntermediate(
 		AllowLocalhost:   true,
 		AllowAnyName:     true,
 		AllowIPSANs:      true,
+		AllowedURISANs:   "*",
 		EnforceHostnames: false,
 		KeyType:          "any",
 	}
@@ -300,7 +301,7 @@ func (b *backend) pathCASignIntermediate(
 			caChain = append(caChain, base64.StdEncoding.EncodeToString(caCert.Bytes))
 		}
 		if caChain != nil && len(caChain) > 0 {
-			resp.Data["ca_chain"] = cb.CAChain
+			resp.Data["ca_chain"] = caChain
 		}
 	}
 
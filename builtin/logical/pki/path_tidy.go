@@ -2,13 +2,56 @@ package pki
 
 import (
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 )
 
+// errTidyRunning is returned by startTidy when another tidy operation holds
+// the guard
+var errTidyRunning = errors.New("tidy operation already running")
+
+type tidyState int
+
+const (
+	tidyStatusInactive tidyState = iota
+	tidyStatusRunning
+	tidyStatusFinished
+	tidyStatusError
+)
+
+func (s tidyState) String() string {
+	switch s {
+	case tidyStatusRunning:
+		return "Running"
+	case tidyStatusFinished:
+		return "Finished"
+	case tidyStatusError:
+		return "Error"
+	default:
+		return "Inactive"
+	}
+}
+
+// tidyStatus records the parameters and progress of the last tidy operation
+type tidyStatus struct {
+	safetyBuffer       int
+	tidyCertStore      bool
+	tidyRevocationList bool
+
+	state                   tidyState
+	err                     error
+	timeStarted             time.Time
+	timeFinished            time.Time
+	certStoreDeletedCount   uint
+	revokedCertDeletedCount uint
+}
+
 func pathTidy(b *backend) *framework.Path {
 	return &framework.Path{
 		Pattern: "tidy",
@@ -46,43 +89,177 @@ Defaults to 72 hours.`,
 	}
 }
 
+func pathTidyStatus(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "tidy-status$",
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathTidyStatusRead,
+		},
+
+		HelpSynopsis:    pathTidyStatusHelpSyn,
+		HelpDescription: pathTidyStatusHelpDesc,
+	}
+}
+
 func (b *backend) pathTidyWrite(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	safetyBuffer := d.Get("safety_buffer").(int)
 	tidyCertStore := d.Get("tidy_cert_store").(bool)
 	tidyRevocationList := d.Get("tidy_revocation_list").(bool)
 
+	if !tidyCertStore && !tidyRevocationList {
+		resp := &logical.Response{}
+		resp.AddWarning("No targets to tidy; specify 'tidy_cert_store' and/or 'tidy_revocation_list' to start a tidy operation.")
+		return resp, nil
+	}
+
+	err := b.startTidy(req.Storage, tidyCertStore, tidyRevocationList, safetyBuffer)
+	if err == errTidyRunning {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{}
+	resp.AddWarning("Tidy operation successfully started. Its progress can be read from the 'tidy-status' endpoint and any errors are also logged by the server.")
+	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+}
+
+func (b *backend) pathTidyStatusRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.tidyStatusLock.RLock()
+	defer b.tidyStatusLock.RUnlock()
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"safety_buffer":              nil,
+			"tidy_cert_store":            nil,
+			"tidy_revocation_list":       nil,
+			"state":                      tidyStatusInactive.String(),
+			"error":                      nil,
+			"time_started":               nil,
+			"time_finished":              nil,
+			"cert_store_deleted_count":   nil,
+			"revoked_cert_deleted_count": nil,
+		},
+	}
+
+	if b.tidyStatus == nil {
+		return resp, nil
+	}
+
+	resp.Data["safety_buffer"] = b.tidyStatus.safetyBuffer
+	resp.Data["tidy_cert_store"] = b.tidyStatus.tidyCertStore
+	resp.Data["tidy_revocation_list"] = b.tidyStatus.tidyRevocationList
+	resp.Data["state"] = b.tidyStatus.state.String()
+	resp.Data["time_started"] = b.tidyStatus.timeStarted
+	resp.Data["cert_store_deleted_count"] = b.tidyStatus.certStoreDeletedCount
+	resp.Data["revoked_cert_deleted_count"] = b.tidyStatus.revokedCertDeletedCount
+	if b.tidyStatus.err != nil {
+		resp.Data["error"] = b.tidyStatus.err.Error()
+	}
+	if !b.tidyStatus.timeFinished.IsZero() {
+		resp.Data["time_finished"] = b.tidyStatus.timeFinished
+	}
+
+	return resp, nil
+}
+
+// startTidy starts removing expired certificates and/or revocation entries
+// from storage in the background. It is used both by the tidy endpoint and
+// the periodic function, and returns errTidyRunning if a tidy operation is
+// already in progress.
+func (b *backend) startTidy(storage logical.Storage, tidyCertStore, tidyRevocationList bool, safetyBuffer int) error {
+	if !atomic.CompareAndSwapUint32(&b.tidyCASGuard, 0, 1) {
+		return errTidyRunning
+	}
+
+	b.tidyStatusLock.Lock()
+	b.tidyStatus = &tidyStatus{
+		safetyBuffer:       safetyBuffer,
+		tidyCertStore:      tidyCertStore,
+		tidyRevocationList: tidyRevocationList,
+		state:              tidyStatusRunning,
+		timeStarted:        time.Now(),
+	}
+	b.tidyStatusLock.Unlock()
+
+	// The tidy outlives the request or rollback tick that started it, so
+	// it gets its own request rather than holding on to the caller's
+	req := &logical.Request{
+		Storage: storage,
+	}
 	bufferDuration := time.Duration(safetyBuffer) * time.Second
 
+	go func() {
+		defer atomic.StoreUint32(&b.tidyCASGuard, 0)
+
+		err := b.tidyStorage(req, tidyCertStore, tidyRevocationList, bufferDuration)
+		if err != nil {
+			b.Logger().Error("pki: error running tidy", "error", err)
+		}
+
+		b.tidyStatusLock.Lock()
+		defer b.tidyStatusLock.Unlock()
+		b.tidyStatus.timeFinished = time.Now()
+		b.tidyStatus.err = err
+		if err != nil {
+			b.tidyStatus.state = tidyStatusError
+		} else {
+			b.tidyStatus.state = tidyStatusFinished
+		}
+	}()
+
+	return nil
+}
+
+func (b *backend) tidyStatusIncCertStoreCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+	b.tidyStatus.certStoreDeletedCount++
+}
+
+func (b *backend) tidyStatusIncRevokedCertCount() {
+	b.tidyStatusLock.Lock()
+	defer b.tidyStatusLock.Unlock()
+	b.tidyStatus.revokedCertDeletedCount++
+}
+
+// tidyStorage removes expired certificates and/or revocation entries from
+// storage. It must only be called by startTidy, which holds the guard.
+func (b *backend) tidyStorage(req *logical.Request, tidyCertStore, tidyRevocationList bool, bufferDuration time.Duration) error {
 	if tidyCertStore {
 		serials, err := req.Storage.List("certs/")
 		if err != nil {
-			return nil, fmt.Errorf("error fetching list of certs: %s", err)
+			return fmt.Errorf("error fetching list of certs: %s", err)
 		}
 
 		for _, serial := range serials {
 			certEntry, err := req.Storage.Get("certs/" + serial)
 			if err != nil {
-				return nil, fmt.Errorf("error fetching certificate %s: %s", serial, err)
+				return fmt.Errorf("error fetching certificate %s: %s", serial, err)
 			}
 
 			if certEntry == nil {
-				return nil, fmt.Errorf("certificate entry for serial %s is nil", serial)
+				return fmt.Errorf("certificate entry for serial %s is nil", serial)
 			}
 
 			if certEntry.Value == nil || len(certEntry.Value) == 0 {
-				return nil, fmt.Errorf("found entry for serial %s but actual certificate is empty", serial)
+				return fmt.Errorf("found entry for serial %s but actual certificate is empty", serial)
 			}
 
 			cert, err := x509.ParseCertificate(certEntry.Value)
 			if err != nil {
-				return nil, fmt.Errorf("unable to parse stored certificate with serial %s: %s", serial, err)
+				return fmt.Errorf("unable to parse stored certificate with serial %s: %s", serial, err)
 			}
 
 			if time.Now().After(cert.NotAfter.Add(bufferDuration)) {
 				if err := req.Storage.Delete("certs/" + serial); err != nil {
-					return nil, fmt.Errorf("error deleting serial %s from storage: %s", serial, err)
+					return fmt.Errorf("error deleting serial %s from storage: %s", serial, err)
 				}
+				b.tidyStatusIncCertStoreCount()
 			}
 		}
 	}
@@ -95,51 +272,52 @@ func (b *backend) pathTidyWrite(
 
 		revokedSerials, err := req.Storage.List("revoked/")
 		if err != nil {
-			return nil, fmt.Errorf("error fetching list of revoked certs: %s", err)
+			return fmt.Errorf("error fetching list of revoked certs: %s", err)
 		}
 
 		var revInfo revocationInfo
 		for _, serial := range revokedSerials {
 			revokedEntry, err := req.Storage.Get("revoked/" + serial)
 			if err != nil {
-				return nil, fmt.Errorf("unable to fetch revoked cert with serial %s: %s", serial, err)
+				return fmt.Errorf("unable to fetch revoked cert with serial %s: %s", serial, err)
 			}
 			if revokedEntry == nil {
-				return nil, fmt.Errorf("revoked certificate entry for serial %s is nil", serial)
+				return fmt.Errorf("revoked certificate entry for serial %s is nil", serial)
 			}
 			if revokedEntry.Value == nil || len(revokedEntry.Value) == 0 {
 				// TODO: In this case, remove it and continue? How likely is this to
 				// happen? Alternately, could skip it entirely, or could implement a
 				// delete function so that there is a way to remove these
-				return nil, fmt.Errorf("found revoked serial but actual certificate is empty")
+				return fmt.Errorf("found revoked serial but actual certificate is empty")
 			}
 
 			err = revokedEntry.DecodeJSON(&revInfo)
 			if err != nil {
-				return nil, fmt.Errorf("error decoding revocation entry for serial %s: %s", serial, err)
+				return fmt.Errorf("error decoding revocation entry for serial %s: %s", serial, err)
 			}
 
 			revokedCert, err := x509.ParseCertificate(revInfo.CertificateBytes)
 			if err != nil {
-				return nil, fmt.Errorf("unable to parse stored revoked certificate with serial %s: %s", serial, err)
+				return fmt.Errorf("unable to parse stored revoked certificate with serial %s: %s", serial, err)
 			}
 
 			if time.Now().After(revokedCert.NotAfter.Add(bufferDuration)) {
 				if err := req.Storage.Delete("revoked/" + serial); err != nil {
-					return nil, fmt.Errorf("error deleting serial %s from revoked list: %s", serial, err)
+					return fmt.Errorf("error deleting serial %s from revoked list: %s", serial, err)
 				}
+				b.tidyStatusIncRevokedCertCount()
 				tidiedRevoked = true
 			}
 		}
 
 		if tidiedRevoked {
 			if err := buildCRL(b, req); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
 
-	return nil, nil
+	return nil
 }
 
 const pathTidyHelpSyn = `
@@ -167,4 +345,23 @@ certificate/revocation information of each certificate being held in
 certificate storage or in revocation infomation will then be checked. If the
 current time, minus the value of 'safety_buffer', is greater than the
 expiration, it will be removed.
+
+The tidy operation runs in the background: this endpoint returns as soon as
+it has started, and only one tidy operation can run at a time. Its progress
+and outcome can be read from the 'tidy-status' endpoint.
+
+Tidying can also be run periodically by configuring the 'config/tidy'
+endpoint.
+`
+
+const pathTidyStatusHelpSyn = `
+Returns the status of the tidy operation.
+`
+
+const pathTidyStatusHelpDesc = `
+This is a read only endpoint that returns the parameters, state and progress
+of the most recently started tidy operation, whether it was started through
+the 'tidy' endpoint or by the periodic function. The state is one of
+'Inactive', 'Running', 'Finished' or 'Error'; when the operation failed, the
+error is returned as well.
 `
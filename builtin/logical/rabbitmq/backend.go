@@ -1,7 +1,9 @@
 package rabbitmq
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 
@@ -112,6 +114,27 @@ func (b *backend) invalidate(key string) {
 	}
 }
 
+// checkResponse closes the body of a response from the RabbitMQ management
+// API and converts unsuccessful status codes into errors, which the client
+// library does not do for requests that do not return data
+func checkResponse(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	rme := rabbithole.ErrorResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&rme); err != nil {
+		rme.Message = http.StatusText(resp.StatusCode)
+	}
+	rme.StatusCode = resp.StatusCode
+	return rme
+}
+
 // Lease returns the lease information
 func (b *backend) Lease(s logical.Storage) (*configLease, error) {
 	entry, err := s.Get("config/lease")
@@ -66,23 +66,23 @@ func (b *backend) pathCredsRead(req *logical.Request, d *framework.FieldData) (*
 	}
 
 	// Register the generated credentials in the backend, with the RabbitMQ server
-	if _, err = client.PutUser(username, rabbithole.UserSettings{
+	if err = checkResponse(client.PutUser(username, rabbithole.UserSettings{
 		Password: password,
 		Tags:     role.Tags,
-	}); err != nil {
-		return nil, fmt.Errorf("failed to create a new user with the generated credentials")
+	})); err != nil {
+		return nil, fmt.Errorf("failed to create a new user with the generated credentials: %s", err)
 	}
 
 	// If the role had vhost permissions specified, assign those permissions
 	// to the created username for respective vhosts.
 	for vhost, permission := range role.VHosts {
-		if _, err := client.UpdatePermissionsIn(vhost, username, rabbithole.Permissions{
+		if err := checkResponse(client.UpdatePermissionsIn(vhost, username, rabbithole.Permissions{
 			Configure: permission.Configure,
 			Write:     permission.Write,
 			Read:      permission.Read,
-		}); err != nil {
+		})); err != nil {
 			// Delete the user because it's in an unknown state
-			if _, rmErr := client.DeleteUser(username); rmErr != nil {
+			if rmErr := checkResponse(client.DeleteUser(username)); rmErr != nil {
 				return nil, fmt.Errorf("failed to delete user:%s, err: %s. %s", username, err, rmErr)
 			}
 			return nil, fmt.Errorf("failed to update permissions to the %s user. err:%s", username, err)
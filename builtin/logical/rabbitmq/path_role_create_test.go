@@ -0,0 +1,105 @@
+package rabbitmq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestBackend_CredsAPIErrors(t *testing.T) {
+	var lock sync.Mutex
+	statuses := map[string]int{}
+	setStatus := func(method string, status int) {
+		lock.Lock()
+		defer lock.Unlock()
+		statuses[method] = status
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		status := statuses[r.Method]
+		lock.Unlock()
+		if status >= http.StatusBadRequest {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"test","reason":"injected failure"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend()
+	if err := b.Setup(config); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "config/connection",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"connection_uri":    ts.URL,
+			"username":          "admin",
+			"password":          "admin",
+			"verify_connection": false,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/web",
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"vhosts": `{"/": {"configure": ".*", "write": ".*", "read": ".*"}}`,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	credsReq := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "creds/web",
+		Storage:   config.StorageView,
+	}
+
+	// Credentials must not be returned if the user could not be created
+	setStatus("PUT", http.StatusUnauthorized)
+	resp, err = b.HandleRequest(credsReq)
+	if err == nil {
+		t.Fatalf("expected an error when user creation fails, got %#v", resp)
+	}
+
+	setStatus("PUT", 0)
+	resp, err = b.HandleRequest(credsReq)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	revokeReq := &logical.Request{
+		Operation: logical.RevokeOperation,
+		Storage:   config.StorageView,
+		Secret:    resp.Secret,
+	}
+
+	// Revocation fails if the user could not be deleted
+	setStatus("DELETE", http.StatusInternalServerError)
+	if _, err = b.HandleRequest(revokeReq); err == nil {
+		t.Fatal("expected an error when user deletion fails")
+	}
+
+	// A user that no longer exists is considered revoked
+	setStatus("DELETE", http.StatusNotFound)
+	if _, err = b.HandleRequest(revokeReq); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,9 +2,11 @@ package rabbitmq
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/michaelklishin/rabbit-hole"
 )
 
 // SecretCredsType is the key for this backend's secrets.
@@ -59,7 +61,12 @@ func (b *backend) secretCredsRevoke(
 		return nil, err
 	}
 
-	if _, err = client.DeleteUser(username); err != nil {
+	err = checkResponse(client.DeleteUser(username))
+	if rme, ok := err.(rabbithole.ErrorResponse); ok && rme.StatusCode == http.StatusNotFound {
+		// The user is already gone, which is the desired outcome
+		err = nil
+	}
+	if err != nil {
 		return nil, fmt.Errorf("could not delete user: %s", err)
 	}
 
@@ -14,6 +14,10 @@ type backend struct {
 	view      logical.Storage
 	salt      *salt.Salt
 	saltMutex sync.RWMutex
+
+	// otpLock serializes OTP verification so that an OTP can only be
+	// consumed once, even by concurrent verify requests
+	otpLock sync.Mutex
 }
 
 func Factory(conf *logical.BackendConfig) (logical.Backend, error) {
@@ -1,7 +1,10 @@
 package ssh
 
 import (
+	"crypto/dsa"
+	"crypto/ecdsa"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
 	"errors"
 	"fmt"
@@ -14,6 +17,7 @@ import (
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -108,6 +112,11 @@ func (b *backend) pathSignCertificate(req *logical.Request, data *framework.Fiel
 		return logical.ErrorResponse(fmt.Sprintf("failed to parse public_key as SSH key: %s", err)), nil
 	}
 
+	err = b.validateSignedKeyRequirements(userPublicKey, role)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("public_key failed to meet the key requirements: %s", err)), nil
+	}
+
 	// Note that these various functions always return "user errors" so we pass
 	// them as 4xx values
 	keyId, err := b.calculateKeyId(data, req, role, userPublicKey)
@@ -193,6 +202,47 @@ func (b *backend) pathSignCertificate(req *logical.Request, data *framework.Fiel
 	return response, nil
 }
 
+func (b *backend) validateSignedKeyRequirements(publickey ssh.PublicKey, role *sshRole) error {
+	if len(role.AllowedUserKeyLengths) == 0 {
+		return nil
+	}
+
+	var kstr string
+	var kbits int
+
+	cryptoKey, ok := publickey.(ssh.CryptoPublicKey)
+	if !ok {
+		return fmt.Errorf("key type %q not supported", publickey.Type())
+	}
+
+	switch k := cryptoKey.CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		kstr = "rsa"
+		kbits = k.N.BitLen()
+	case *dsa.PublicKey:
+		kstr = "dsa"
+		kbits = k.Parameters.P.BitLen()
+	case *ecdsa.PublicKey:
+		kstr = "ecdsa"
+		kbits = k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		kstr = "ed25519"
+		kbits = 256
+	default:
+		return fmt.Errorf("key type %q not supported", publickey.Type())
+	}
+
+	minBits, ok := role.AllowedUserKeyLengths[kstr]
+	if !ok {
+		return fmt.Errorf("key type of %s is not allowed", kstr)
+	}
+	if kbits < minBits {
+		return fmt.Errorf("key is of an invalid size: %v", kbits)
+	}
+
+	return nil
+}
+
 func (b *backend) calculateValidPrincipals(data *framework.FieldData, defaultPrincipal, principalsAllowedByRole string, validatePrincipal func([]string, string) bool) ([]string, error) {
 	validPrincipals := ""
 	validPrincipalsRaw, ok := data.GetOk("valid_principals")
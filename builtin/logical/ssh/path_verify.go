@@ -63,6 +63,9 @@ func (b *backend) pathVerifyWrite(req *logical.Request, d *framework.FieldData)
 	}
 	otpSalted := salt.SaltID(otp)
 
+	b.otpLock.Lock()
+	defer b.otpLock.Unlock()
+
 	// Return nil if there is no entry found for the OTP
 	otpEntry, err := b.getOTP(req.Storage, otpSalted)
 	if err != nil {
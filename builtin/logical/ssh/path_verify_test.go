@@ -0,0 +1,84 @@
+package ssh
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestSSH_VerifyOTPOnlyOnce(t *testing.T) {
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+
+	b, err := Factory(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "roles/" + testOTPRoleName,
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"key_type":     testOTPKeyType,
+			"default_user": testUserName,
+			"cidr_list":    testCIDRList,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "creds/" + testOTPRoleName,
+		Storage:   config.StorageView,
+		Data: map[string]interface{}{
+			"username": testUserName,
+			"ip":       testIP,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	otp := resp.Data["key"].(string)
+
+	// Verify the OTP concurrently; exactly one request should succeed
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	successes := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := b.HandleRequest(&logical.Request{
+				Operation: logical.UpdateOperation,
+				Path:      "verify",
+				Storage:   config.StorageView,
+				Data: map[string]interface{}{
+					"otp": otp,
+				},
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if resp == nil || resp.IsError() {
+				return
+			}
+			if resp.Data["username"] != testUserName || resp.Data["ip"] != testIP {
+				t.Errorf("bad: verify response: %#v", resp.Data)
+				return
+			}
+			lock.Lock()
+			successes++
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if successes != 1 {
+		t.Fatalf("bad: expected the OTP to be verified exactly once, got %d", successes)
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -213,6 +215,33 @@ func convertMapToStringValue(initial map[string]interface{}) map[string]string {
 	return result
 }
 
+func convertMapToIntValue(initial map[string]interface{}) (map[string]int, error) {
+	result := map[string]int{}
+	for key, value := range initial {
+		switch v := value.(type) {
+		case int:
+			result[key] = v
+		case float64:
+			result[key] = int(v)
+		case json.Number:
+			i, err := v.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %q: %s", key, err)
+			}
+			result[key] = int(i)
+		case string:
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %q: %s", key, err)
+			}
+			result[key] = i
+		default:
+			return nil, fmt.Errorf("invalid value for %q: %v", key, value)
+		}
+	}
+	return result, nil
+}
+
 // Serve a template processor for custom format inputs
 func substQuery(tpl string, data map[string]string) string {
 	for k, v := range data {
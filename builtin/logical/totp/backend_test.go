@@ -262,6 +262,8 @@ func TestBackend_keyCrudDefaultValues(t *testing.T) {
 			// Next step should fail because it should be in the used cache
 			testAccStepValidateCode(t, "test", code, false, true),
 			testAccStepValidateCode(t, "test", invalidCode, false, false),
+			// Invalid codes are not added to the used cache
+			testAccStepValidateCode(t, "test", invalidCode, false, false),
 			testAccStepDeleteKey(t, "test"),
 			testAccStepReadKey(t, "test", nil),
 		},
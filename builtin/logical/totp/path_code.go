@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
 	otplib "github.com/pquerna/otp"
@@ -102,14 +101,20 @@ func (b *backend) pathValidateCode(
 		return logical.ErrorResponse("an error occured while validating the code"), err
 	}
 
-	// Take the key skew, add two for behind and in front, and multiple that by
-	// the period to cover the full possibility of the validity of the key
-	err = b.usedCodes.Add(usedName, nil, time.Duration(
-		int64(time.Second)*
-			int64(key.Period)*
-			int64((2+key.Skew))))
-	if err != nil {
-		return nil, errwrap.Wrapf("error adding code to used cache: {{err}}", err)
+	// Only valid codes are marked as used; otherwise anyone able to submit
+	// codes could burn codes that have yet to become valid
+	if valid {
+		// Take the key skew, add two for behind and in front, and multiple that by
+		// the period to cover the full possibility of the validity of the key.
+		// Add fails if the code is already present, which means a concurrent
+		// request consumed it first.
+		err = b.usedCodes.Add(usedName, nil, time.Duration(
+			int64(time.Second)*
+				int64(key.Period)*
+				int64((2+key.Skew))))
+		if err != nil {
+			return logical.ErrorResponse("code already used; wait until the next time period"), nil
+		}
 	}
 
 	return &logical.Response{
@@ -1,7 +1,12 @@
 package transit
 
 import (
+	"fmt"
 	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	multierror "github.com/hashicorp/go-multierror"
 
 	"github.com/hashicorp/vault/helper/keysutil"
 	"github.com/hashicorp/vault/logical"
@@ -24,6 +29,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 			// as the handler is greedy
 			b.pathConfig(),
 			b.pathRotate(),
+			b.pathTrim(),
 			b.pathRewrap(),
 			b.pathKeys(),
 			b.pathListKeys(),
@@ -36,11 +42,14 @@ func Backend(conf *logical.BackendConfig) *backend {
 			b.pathHMAC(),
 			b.pathSign(),
 			b.pathVerify(),
+			b.pathBackup(),
+			b.pathRestore(),
 		},
 
-		Secrets:     []*framework.Secret{},
-		Invalidate:  b.invalidate,
-		BackendType: logical.TypeLogical,
+		Secrets:      []*framework.Secret{},
+		Invalidate:   b.invalidate,
+		BackendType:  logical.TypeLogical,
+		PeriodicFunc: b.periodicFunc,
 	}
 
 	b.lm = keysutil.NewLockManager(conf.System.CachingDisabled())
@@ -63,3 +72,73 @@ func (b *backend) invalidate(key string) {
 		b.lm.InvalidatePolicy(name)
 	}
 }
+
+// periodicFunc is invoked by the rollback manager once a minute and rotates
+// any keys whose latest version is older than their auto-rotate interval
+func (b *backend) periodicFunc(req *logical.Request) error {
+	names, err := req.Storage.List("policy/")
+	if err != nil {
+		return err
+	}
+
+	var errs *multierror.Error
+	for _, name := range names {
+		if err := b.autoRotateKey(req.Storage, name); err != nil {
+			errs = multierror.Append(errs, errwrap.Wrapf(fmt.Sprintf("failed to auto-rotate key %q: {{err}}", name), err))
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+func (b *backend) autoRotateKey(storage logical.Storage, name string) error {
+	// Check with a shared lock first so that keys which do not need rotating
+	// do not block other requests
+	p, lock, err := b.lm.GetPolicyShared(storage, name)
+	if lock != nil {
+		lock.RUnlock()
+	}
+	if err != nil {
+		return err
+	}
+	if p == nil || !autoRotateNeeded(p) {
+		return nil
+	}
+
+	p, lock, err = b.lm.GetPolicyExclusive(storage, name)
+	if lock != nil {
+		defer lock.Unlock()
+	}
+	if err != nil {
+		return err
+	}
+	// Re-check as the key may have been rotated while unlocked
+	if p == nil || !autoRotateNeeded(p) {
+		return nil
+	}
+
+	if b.Logger().IsDebug() {
+		b.Logger().Debug("transit: auto-rotating key", "key", name)
+	}
+
+	return p.Rotate(storage)
+}
+
+// autoRotateNeeded returns whether the latest version of the key is older
+// than the key's auto-rotate interval
+func autoRotateNeeded(p *keysutil.Policy) bool {
+	if p.AutoRotateInterval <= 0 {
+		return false
+	}
+
+	latest, ok := p.Keys[p.LatestVersion]
+	if !ok {
+		return false
+	}
+	created := latest.CreationTime
+	if created.IsZero() {
+		created = time.Unix(latest.DeprecatedCreationTime, 0)
+	}
+
+	return time.Since(created) >= p.AutoRotateInterval
+}
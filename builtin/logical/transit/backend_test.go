@@ -1,6 +1,9 @@
 package transit
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"math/rand"
@@ -699,6 +702,132 @@ func TestDerivedKeyUpgrade(t *testing.T) {
 func TestConvergentEncryption(t *testing.T) {
 	testConvergentEncryptionCommon(t, 0)
 	testConvergentEncryptionCommon(t, 2)
+	testConvergentEncryptionCommon(t, 3)
+}
+
+func TestConvergentEncryption_VersionUpgrade(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	context := "pWZ6t/im3AORd0lVYE0zBdKpX6Bl3/SvFtoVTPWbdkzjG788XmMAnOlxandSdd7S"
+	decodedContext, _ := base64.StdEncoding.DecodeString(context)
+
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/testkey",
+		Data: map[string]interface{}{
+			"derived":               true,
+			"convergent_encryption": true,
+		},
+	}
+	resp, err := b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	// Make the key look like it was created with the second nonce scheme
+	p, lock, err := b.lm.GetPolicyShared(storage, "testkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.RUnlock()
+	if p.ConvergentVersion != 3 {
+		t.Fatalf("bad: expected new policy to store convergent version 3, got %d", p.ConvergentVersion)
+	}
+	if p.ConvergentVersionFor(1) != 3 {
+		t.Fatalf("bad: expected new key to use convergent version 3, got %d", p.ConvergentVersionFor(1))
+	}
+	p.ConvergentVersion = 2
+	keyEntry := p.Keys[1]
+	keyEntry.ConvergentVersion = 0
+	p.Keys[1] = keyEntry
+	if err = p.Persist(storage); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypt := func() string {
+		resp, err := b.HandleRequest(&logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "encrypt/testkey",
+			Data: map[string]interface{}{
+				"plaintext": "emlwIHphcA==", // "zip zap"
+				"context":   context,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp.Data["ciphertext"].(string)
+	}
+
+	nonceOf := func(ciphertext string) []byte {
+		decoded, err := base64.StdEncoding.DecodeString(strings.SplitN(ciphertext, ":", 3)[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded[:12]
+	}
+
+	// Version 2 nonces are an HMAC of the plaintext keyed by the context
+	nonceHmac := hmac.New(sha256.New, decodedContext)
+	nonceHmac.Write([]byte("zip zap"))
+	v2Nonce := nonceHmac.Sum(nil)[:12]
+
+	ciphertext1 := encrypt()
+	if !bytes.Equal(nonceOf(ciphertext1), v2Nonce) {
+		t.Fatal("bad: expected version 2 nonce for the original key version")
+	}
+
+	// Rotating moves new key versions to the current scheme
+	req.Path = "keys/testkey/rotate"
+	req.Data = nil
+	resp, err = b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	ciphertext2 := encrypt()
+	if !strings.HasPrefix(ciphertext2, "vault:v2:") {
+		t.Fatalf("bad: ciphertext %s", ciphertext2)
+	}
+	if ciphertext2 != encrypt() {
+		t.Fatal("expected the same ciphertext for the same plaintext")
+	}
+	if bytes.Equal(nonceOf(ciphertext2), v2Nonce) {
+		t.Fatal("bad: expected a version 3 nonce after rotation")
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Storage:   storage,
+		Operation: logical.ReadOperation,
+		Path:      "keys/testkey",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["convergent_encryption_version"] != 3 {
+		t.Fatalf("bad: convergent_encryption_version: %v", resp.Data["convergent_encryption_version"])
+	}
+
+	// Ciphertexts from both schemes must still decrypt
+	for _, ciphertext := range []string{ciphertext1, ciphertext2} {
+		resp, err = b.HandleRequest(&logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      "decrypt/testkey",
+			Data: map[string]interface{}{
+				"ciphertext": ciphertext,
+				"context":    context,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		if resp.Data["plaintext"] != "emlwIHphcA==" {
+			t.Fatalf("bad: plaintext %v", resp.Data["plaintext"])
+		}
+	}
 }
 
 func testConvergentEncryptionCommon(t *testing.T, ver int) {
@@ -747,7 +876,7 @@ func testConvergentEncryptionCommon(t *testing.T, ver int) {
 
 	// First, test using an invalid length of nonce -- this is only used for v1 convergent
 	req.Path = "encrypt/testkey"
-	if ver < 2 {
+	if ver >= 0 && ver < 2 {
 		req.Data = map[string]interface{}{
 			"plaintext": "emlwIHphcA==", // "zip zap"
 			"nonce":     "Zm9vIGJhcg==", // "foo bar"
@@ -815,7 +944,7 @@ func testConvergentEncryptionCommon(t *testing.T, ver int) {
 		"nonce":     "dHdvdGhyZWVmb3Vy", // "twothreefour"
 		"context":   "pWZ6t/im3AORd0lVYE0zBdKpX6Bl3/SvFtoVTPWbdkzjG788XmMAnOlxandSdd7S",
 	}
-	if ver < 2 {
+	if ver >= 0 && ver < 2 {
 		req.Data["nonce"] = "dHdvdGhyZWVmb3Vy" // "twothreefour"
 	} else {
 		req.Data["context"] = "pWZ6t/im3AORd0lVYE0zBdKpX6Bl3/SvFtoVTPWbdkzjG788XmMAnOldandSdd7S"
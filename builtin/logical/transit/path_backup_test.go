@@ -0,0 +1,181 @@
+package transit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_BackupRestore(t *testing.T) {
+	var resp *logical.Response
+	b, s := createBackendWithStorage(t)
+
+	doRequest := func(op logical.Operation, path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		resp, err := b.HandleRequest(&logical.Request{
+			Storage:   s,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s %s", op, path)
+			}
+			return resp
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s %s: err:%v resp:%#v", op, path, err, resp)
+		}
+		return resp
+	}
+
+	doRequest(logical.UpdateOperation, "keys/foo", nil, false)
+	doRequest(logical.UpdateOperation, "keys/foo/rotate", nil, false)
+	doRequest(logical.UpdateOperation, "keys/foo/rotate", nil, false)
+
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	var ciphertexts []string
+	for _, ver := range []int{1, 3} {
+		resp = doRequest(logical.UpdateOperation, "encrypt/foo", map[string]interface{}{
+			"plaintext":   plaintext,
+			"key_version": ver,
+		}, false)
+		ciphertexts = append(ciphertexts, resp.Data["ciphertext"].(string))
+	}
+
+	// Move the first version into the archive
+	doRequest(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 3,
+	}, false)
+
+	// Backups require both exportable and allow_plaintext_backup
+	doRequest(logical.ReadOperation, "backup/foo", nil, true)
+	doRequest(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"exportable": true,
+	}, false)
+	doRequest(logical.ReadOperation, "backup/foo", nil, true)
+	doRequest(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"allow_plaintext_backup": true,
+	}, false)
+
+	// Neither can be disabled again
+	doRequest(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"exportable":             false,
+		"allow_plaintext_backup": false,
+	}, false)
+	resp = doRequest(logical.ReadOperation, "keys/foo", nil, false)
+	if !resp.Data["exportable"].(bool) || !resp.Data["allow_plaintext_backup"].(bool) {
+		t.Fatalf("bad: exportable or allow_plaintext_backup was unset: %#v", resp.Data)
+	}
+
+	resp = doRequest(logical.ReadOperation, "backup/foo", nil, false)
+	backup := resp.Data["backup"].(string)
+	if backup == "" {
+		t.Fatal("expected a backup")
+	}
+
+	// A modified bundle is rejected before anything is written
+	decodedBackup, err := base64.StdEncoding.DecodeString(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle map[string]json.RawMessage
+	if err := json.Unmarshal(decodedBackup, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	bundle["key_data"] = bytes.Replace(bundle["key_data"], []byte(`"deletion_allowed":false`), []byte(`"deletion_allowed":true`), 1)
+	tampered, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(tampered, decodedBackup) {
+		t.Fatal("failed to modify the backup")
+	}
+	doRequest(logical.UpdateOperation, "restore/tampered", map[string]interface{}{
+		"backup": base64.StdEncoding.EncodeToString(tampered),
+	}, true)
+	resp = doRequest(logical.ReadOperation, "keys/tampered", nil, false)
+	if resp != nil {
+		t.Fatalf("bad: key written from a tampered backup: %#v", resp)
+	}
+
+	// The bundle is self-contained, so it restores into another mount
+	otherB, otherS := createBackendWithStorage(t)
+	resp, err = otherB.HandleRequest(&logical.Request{
+		Storage:   otherS,
+		Operation: logical.UpdateOperation,
+		Path:      "restore",
+		Data: map[string]interface{}{
+			"backup": backup,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("restore to another backend: err:%v resp:%#v", err, resp)
+	}
+	resp, err = otherB.HandleRequest(&logical.Request{
+		Storage:   otherS,
+		Operation: logical.UpdateOperation,
+		Path:      "decrypt/foo",
+		Data: map[string]interface{}{
+			"ciphertext": ciphertexts[1],
+		},
+	})
+	if err != nil || resp == nil || resp.Data["plaintext"] != plaintext {
+		t.Fatalf("decrypt in another backend: err:%v resp:%#v", err, resp)
+	}
+
+	// Restoring over an existing key requires force
+	doRequest(logical.UpdateOperation, "restore", map[string]interface{}{
+		"backup": backup,
+	}, true)
+	doRequest(logical.UpdateOperation, "restore", map[string]interface{}{
+		"backup": "not a backup",
+	}, true)
+
+	// Delete the key and restore it under its own name and a new one
+	doRequest(logical.UpdateOperation, "keys/foo/config", map[string]interface{}{
+		"deletion_allowed": true,
+	}, false)
+	doRequest(logical.DeleteOperation, "keys/foo", nil, false)
+	doRequest(logical.UpdateOperation, "restore", map[string]interface{}{
+		"backup": backup,
+	}, false)
+	doRequest(logical.UpdateOperation, "restore/bar", map[string]interface{}{
+		"backup": backup,
+	}, false)
+
+	for _, name := range []string{"foo", "bar"} {
+		resp = doRequest(logical.ReadOperation, "keys/"+name, nil, false)
+		if resp.Data["latest_version"].(int) != 3 {
+			t.Fatalf("bad: %s: latest_version: %v", name, resp.Data["latest_version"])
+		}
+
+		// Allow decryption with the archived version again
+		doRequest(logical.UpdateOperation, "keys/"+name+"/config", map[string]interface{}{
+			"min_decryption_version": 1,
+		}, false)
+
+		for _, ciphertext := range ciphertexts {
+			resp = doRequest(logical.UpdateOperation, "decrypt/"+name, map[string]interface{}{
+				"ciphertext": ciphertext,
+			}, false)
+			if resp.Data["plaintext"] != plaintext {
+				t.Fatalf("bad: %s: plaintext: %v", name, resp.Data["plaintext"])
+			}
+		}
+	}
+
+	// The restored key can be overwritten when forced
+	doRequest(logical.UpdateOperation, "keys/foo/rotate", nil, false)
+	doRequest(logical.UpdateOperation, "restore/foo", map[string]interface{}{
+		"backup": backup,
+		"force":  true,
+	}, false)
+	resp = doRequest(logical.ReadOperation, "keys/foo", nil, false)
+	if resp.Data["latest_version"].(int) != 3 {
+		t.Fatalf("bad: latest_version after forced restore: %v", resp.Data["latest_version"])
+	}
+}
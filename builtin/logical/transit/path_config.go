@@ -2,6 +2,7 @@ package transit
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
@@ -35,6 +36,27 @@ the latest version of the key is allowed.`,
 				Type:        framework.TypeBool,
 				Description: "Whether to allow deletion of the key",
 			},
+
+			"exportable": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables export of the key. Once set, this
+cannot be disabled.`,
+			},
+
+			"allow_plaintext_backup": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Enables backup of the key, including all of
+its versions, in plaintext. Once set, this
+cannot be disabled.`,
+			},
+
+			"auto_rotate_interval": &framework.FieldSchema{
+				Type: framework.TypeDurationSecond,
+				Description: `If set, the key is rotated automatically once
+its latest version is older than this interval.
+Must be at least one hour. Set to zero to disable
+automatic rotation.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -86,6 +108,10 @@ func (b *backend) pathConfigWrite(
 				return logical.ErrorResponse(
 					fmt.Sprintf("cannot set min decryption version of %d, latest key version is %d", minDecryptionVersion, p.LatestVersion)), nil
 			}
+			if minDecryptionVersion < p.MinAvailableVersion {
+				return logical.ErrorResponse(
+					fmt.Sprintf("cannot set min decryption version of %d, versions below %d have been trimmed", minDecryptionVersion, p.MinAvailableVersion)), nil
+			}
 			p.MinDecryptionVersion = minDecryptionVersion
 			persistNeeded = true
 		}
@@ -104,6 +130,10 @@ func (b *backend) pathConfigWrite(
 				return logical.ErrorResponse(
 					fmt.Sprintf("cannot set min encryption version of %d, latest key version is %d", minEncryptionVersion, p.LatestVersion)), nil
 			}
+			if minEncryptionVersion > 0 && minEncryptionVersion < p.MinAvailableVersion {
+				return logical.ErrorResponse(
+					fmt.Sprintf("cannot set min encryption version of %d, versions below %d have been trimmed", minEncryptionVersion, p.MinAvailableVersion)), nil
+			}
 			p.MinEncryptionVersion = minEncryptionVersion
 			persistNeeded = true
 		}
@@ -126,6 +156,39 @@ func (b *backend) pathConfigWrite(
 		}
 	}
 
+	exportableRaw, ok := d.GetOk("exportable")
+	if ok {
+		exportable := exportableRaw.(bool)
+		// Don't unset the already set value
+		if exportable && !p.Exportable {
+			p.Exportable = exportable
+			persistNeeded = true
+		}
+	}
+
+	allowPlaintextBackupRaw, ok := d.GetOk("allow_plaintext_backup")
+	if ok {
+		allowPlaintextBackup := allowPlaintextBackupRaw.(bool)
+		// Don't unset the already set value
+		if allowPlaintextBackup && !p.AllowPlaintextBackup {
+			p.AllowPlaintextBackup = allowPlaintextBackup
+			persistNeeded = true
+		}
+	}
+
+	autoRotateIntervalRaw, ok := d.GetOk("auto_rotate_interval")
+	if ok {
+		autoRotateInterval := time.Duration(autoRotateIntervalRaw.(int)) * time.Second
+		if autoRotateInterval != 0 && autoRotateInterval < minAutoRotateInterval {
+			return logical.ErrorResponse(
+				fmt.Sprintf("auto rotate interval must be zero or at least %s", minAutoRotateInterval)), nil
+		}
+		if autoRotateInterval != p.AutoRotateInterval {
+			p.AutoRotateInterval = autoRotateInterval
+			persistNeeded = true
+		}
+	}
+
 	// Add this as a guard here before persisting since we now require the min
 	// decryption version to start at 1; even if it's not explicitly set here,
 	// force the upgrade
@@ -145,10 +208,16 @@ func (b *backend) pathConfigWrite(
 	return resp, p.Persist(req.Storage)
 }
 
+// minAutoRotateInterval is the shortest interval at which keys can be
+// configured to rotate automatically
+const minAutoRotateInterval = time.Hour
+
 const pathConfigHelpSyn = `Configure a named encryption key`
 
 const pathConfigHelpDesc = `
 This path is used to configure the named key. Currently, this
 supports adjusting the minimum version of the key allowed to
-be used for decryption via the min_decryption_version paramter.
+be used for decryption via the min_decryption_version paramter,
+rotating the key automatically via the auto_rotate_interval
+parameter, and enabling export and plaintext backup of the key.
 `
@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/logical"
 )
@@ -221,3 +222,92 @@ func TestTransit_ConfigSettings(t *testing.T) {
 	testHMAC(3, true)
 	testHMAC(2, false)
 }
+
+func TestTransit_ConfigAutoRotate(t *testing.T) {
+	var b *backend
+	sysView := logical.TestSystemView()
+	storage := &logical.InmemStorage{}
+
+	b = Backend(&logical.BackendConfig{
+		StorageView: storage,
+		System:      sysView,
+	})
+
+	doReq := func(path string, op logical.Operation, data map[string]interface{}) *logical.Response {
+		resp, err := b.HandleRequest(&logical.Request{
+			Storage:   storage,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: err: %v, resp: %#v", err, resp)
+		}
+		return resp
+	}
+
+	doReq("keys/aes", logical.UpdateOperation, nil)
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/aes/config",
+		Data: map[string]interface{}{
+			"auto_rotate_interval": "30m",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatal("expected error for an auto rotate interval below the minimum")
+	}
+
+	doReq("keys/aes/config", logical.UpdateOperation, map[string]interface{}{
+		"auto_rotate_interval": "2h",
+	})
+
+	resp = doReq("keys/aes", logical.ReadOperation, nil)
+	if resp.Data["auto_rotate_interval"].(int64) != 7200 {
+		t.Fatalf("bad: auto_rotate_interval: %v", resp.Data["auto_rotate_interval"])
+	}
+
+	// A freshly created key should not be rotated
+	if err := b.periodicFunc(&logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	resp = doReq("keys/aes", logical.ReadOperation, nil)
+	if resp.Data["latest_version"].(int) != 1 {
+		t.Fatalf("bad: latest_version: %v", resp.Data["latest_version"])
+	}
+
+	// Age the latest version past the interval
+	p, lock, err := b.lm.GetPolicyExclusive(storage, "aes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := p.Keys[p.LatestVersion]
+	entry.CreationTime = time.Now().Add(-3 * time.Hour)
+	p.Keys[p.LatestVersion] = entry
+	if err := p.Persist(storage); err != nil {
+		t.Fatal(err)
+	}
+	lock.Unlock()
+
+	if err := b.periodicFunc(&logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	resp = doReq("keys/aes", logical.ReadOperation, nil)
+	if resp.Data["latest_version"].(int) != 2 {
+		t.Fatalf("bad: latest_version: %v", resp.Data["latest_version"])
+	}
+
+	// The new version is fresh, so nothing further should happen
+	if err := b.periodicFunc(&logical.Request{Storage: storage}); err != nil {
+		t.Fatal(err)
+	}
+	resp = doReq("keys/aes", logical.ReadOperation, nil)
+	if resp.Data["latest_version"].(int) != 2 {
+		t.Fatalf("bad: latest_version: %v", resp.Data["latest_version"])
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/mitchellh/mapstructure"
 )
 
 func (b *backend) pathDatakey() *framework.Path {
@@ -75,25 +76,64 @@ func (b *backend) pathDatakeyWrite(
 		return logical.ErrorResponse("Invalid path, must be 'plaintext' or 'wrapped'"), logical.ErrInvalidRequest
 	}
 
-	var err error
+	var keyLen int
+	bits := d.Get("bits").(int)
+	switch bits {
+	case 512:
+		keyLen = 64
+	case 256:
+		keyLen = 32
+	case 128:
+		keyLen = 16
+	default:
+		return logical.ErrorResponse("invalid bit length"), logical.ErrInvalidRequest
+	}
 
-	// Decode the context if any
-	contextRaw := d.Get("context").(string)
-	var context []byte
-	if len(contextRaw) != 0 {
-		context, err = base64.StdEncoding.DecodeString(contextRaw)
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []BatchRequestItem
+	var err error
+	if batchInputRaw != nil {
+		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
 		if err != nil {
-			return logical.ErrorResponse("failed to base64-decode context"), logical.ErrInvalidRequest
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse batch input: %v", err)), logical.ErrInvalidRequest
+		}
+
+		if len(batchInputItems) == 0 {
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		batchInputItems = make([]BatchRequestItem, 1)
+		batchInputItems[0] = BatchRequestItem{
+			Context:    d.Get("context").(string),
+			Nonce:      d.Get("nonce").(string),
+			KeyVersion: ver,
 		}
 	}
 
-	// Decode the nonce if any
-	nonceRaw := d.Get("nonce").(string)
-	var nonce []byte
-	if len(nonceRaw) != 0 {
-		nonce, err = base64.StdEncoding.DecodeString(nonceRaw)
-		if err != nil {
-			return logical.ErrorResponse("failed to base64-decode nonce"), logical.ErrInvalidRequest
+	batchResponseItems := make([]BatchResponseItem, len(batchInputItems))
+	contextSet := len(batchInputItems[0].Context) != 0
+
+	for i, item := range batchInputItems {
+		if (len(item.Context) == 0 && contextSet) || (len(item.Context) != 0 && !contextSet) {
+			return logical.ErrorResponse("context should be set either in all the request blocks or in none"), logical.ErrInvalidRequest
+		}
+
+		// Decode the context
+		if len(item.Context) != 0 {
+			batchInputItems[i].DecodedContext, err = base64.StdEncoding.DecodeString(item.Context)
+			if err != nil {
+				batchResponseItems[i].Error = "failed to base64-decode context"
+				continue
+			}
+		}
+
+		// Decode the nonce
+		if len(item.Nonce) != 0 {
+			batchInputItems[i].DecodedNonce, err = base64.StdEncoding.DecodeString(item.Nonce)
+			if err != nil {
+				batchResponseItems[i].Error = "failed to base64-decode nonce"
+				continue
+			}
 		}
 	}
 
@@ -109,47 +149,54 @@ func (b *backend) pathDatakeyWrite(
 		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
 	}
 
-	newKey := make([]byte, 32)
-	bits := d.Get("bits").(int)
-	switch bits {
-	case 512:
-		newKey = make([]byte, 64)
-	case 256:
-	case 128:
-		newKey = make([]byte, 16)
-	default:
-		return logical.ErrorResponse("invalid bit length"), logical.ErrInvalidRequest
-	}
-	_, err = rand.Read(newKey)
-	if err != nil {
-		return nil, err
-	}
+	for i, item := range batchInputItems {
+		if batchResponseItems[i].Error != "" {
+			continue
+		}
 
-	ciphertext, err := p.Encrypt(ver, context, nonce, base64.StdEncoding.EncodeToString(newKey))
-	if err != nil {
-		switch err.(type) {
-		case errutil.UserError:
-			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
-		case errutil.InternalError:
-			return nil, err
-		default:
+		newKey := make([]byte, keyLen)
+		_, err = rand.Read(newKey)
+		if err != nil {
 			return nil, err
 		}
-	}
 
-	if ciphertext == "" {
-		return nil, fmt.Errorf("empty ciphertext returned")
-	}
+		ciphertext, err := p.Encrypt(item.KeyVersion, item.DecodedContext, item.DecodedNonce, base64.StdEncoding.EncodeToString(newKey))
+		if err != nil {
+			switch err.(type) {
+			case errutil.UserError:
+				batchResponseItems[i].Error = err.Error()
+				continue
+			default:
+				return nil, err
+			}
+		}
 
-	// Generate the response
-	resp := &logical.Response{
-		Data: map[string]interface{}{
-			"ciphertext": ciphertext,
-		},
+		if ciphertext == "" {
+			return nil, fmt.Errorf("empty ciphertext returned for input item %d", i)
+		}
+
+		batchResponseItems[i].Ciphertext = ciphertext
+		if plaintextAllowed {
+			batchResponseItems[i].Plaintext = base64.StdEncoding.EncodeToString(newKey)
+		}
 	}
 
-	if plaintextAllowed {
-		resp.Data["plaintext"] = base64.StdEncoding.EncodeToString(newKey)
+	// Generate the response
+	resp := &logical.Response{}
+	if batchInputRaw != nil {
+		resp.Data = map[string]interface{}{
+			"batch_results": batchResponseItems,
+		}
+	} else {
+		if batchResponseItems[0].Error != "" {
+			return logical.ErrorResponse(batchResponseItems[0].Error), logical.ErrInvalidRequest
+		}
+		resp.Data = map[string]interface{}{
+			"ciphertext": batchResponseItems[0].Ciphertext,
+		}
+		if plaintextAllowed {
+			resp.Data["plaintext"] = batchResponseItems[0].Plaintext
+		}
 	}
 
 	return resp, nil
@@ -164,5 +211,6 @@ and decryption, protected by the named backend key. 128, 256,
 or 512 bits can be specified; if not specified, the default
 is 256 bits. Call with the the "wrapped" path to prevent the
 (base64-encoded) plaintext key from being returned along with
-the encrypted key, the "plaintext" path returns both.
+the encrypted key, the "plaintext" path returns both. Multiple
+data keys can be generated at once via "batch_input".
 `
@@ -0,0 +1,184 @@
+package transit
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_Datakey(t *testing.T) {
+	var resp *logical.Response
+	var err error
+	b, s := createBackendWithStorage(t)
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/existing_key",
+		Storage:   s,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	decrypt := func(ciphertext string) []byte {
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "decrypt/existing_key",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"ciphertext": ciphertext,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		plaintext, err := base64.StdEncoding.DecodeString(resp.Data["plaintext"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plaintext
+	}
+
+	for _, bits := range []int{128, 256, 512} {
+		resp, err = b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "datakey/plaintext/existing_key",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"bits": bits,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		key, err := base64.StdEncoding.DecodeString(resp.Data["plaintext"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(key) != bits/8 {
+			t.Fatalf("bad: expected %d byte key, got %d", bits/8, len(key))
+		}
+		if string(decrypt(resp.Data["ciphertext"].(string))) != string(key) {
+			t.Fatal("decrypted data key does not match plaintext data key")
+		}
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "datakey/wrapped/existing_key",
+		Storage:   s,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if _, ok := resp.Data["plaintext"]; ok {
+		t.Fatal("plaintext returned for wrapped data key")
+	}
+	if len(decrypt(resp.Data["ciphertext"].(string))) != 32 {
+		t.Fatal("bad: expected a 256 bit data key by default")
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "datakey/plaintext/existing_key",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"bits": 64,
+		},
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error for an invalid bit length")
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "datakey/invalid/existing_key",
+		Storage:   s,
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error for an invalid datakey type")
+	}
+}
+
+func TestTransit_BatchDatakey(t *testing.T) {
+	var resp *logical.Response
+	var err error
+	b, s := createBackendWithStorage(t)
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "keys/derived_key",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"derived": true,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	batchInput := []interface{}{
+		map[string]interface{}{"context": "dmlzaGFsCg=="},
+		map[string]interface{}{"context": "dGhlIHF1aWNrIGJyb3duIGZveA=="},
+		map[string]interface{}{"context": "not base64!"},
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "datakey/wrapped/derived_key",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"batch_input": batchInput,
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	batchResults := resp.Data["batch_results"].([]BatchResponseItem)
+	if len(batchResults) != 3 {
+		t.Fatalf("bad: expected 3 results, got %d", len(batchResults))
+	}
+	if batchResults[2].Error == "" {
+		t.Fatal("expected error for invalid context")
+	}
+
+	for i, item := range batchResults[:2] {
+		if item.Error != "" {
+			t.Fatalf("bad: item %d: %s", i, item.Error)
+		}
+		if item.Plaintext != "" {
+			t.Fatalf("bad: item %d: plaintext returned for wrapped data key", i)
+		}
+
+		resp, err = b.HandleRequest(&logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "decrypt/derived_key",
+			Storage:   s,
+			Data: map[string]interface{}{
+				"ciphertext": item.Ciphertext,
+				"context":    batchInput[i].(map[string]interface{})["context"],
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+	}
+
+	// Contexts must be given for all items or none
+	resp, err = b.HandleRequest(&logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "datakey/plaintext/derived_key",
+		Storage:   s,
+		Data: map[string]interface{}{
+			"batch_input": []interface{}{
+				map[string]interface{}{"context": "dmlzaGFsCg=="},
+				map[string]interface{}{},
+			},
+		},
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error for mixed contexts")
+	}
+}
@@ -147,7 +147,7 @@ func (b *backend) pathEncryptWrite(
 	if batchInputRaw != nil {
 		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse batch input: %v", err)
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse batch input: %v", err)), logical.ErrInvalidRequest
 		}
 
 		if len(batchInputItems) == 0 {
@@ -190,7 +190,7 @@ func (b *backend) pathEncryptWrite(
 		if len(item.Context) != 0 {
 			batchInputItems[i].DecodedContext, err = base64.StdEncoding.DecodeString(item.Context)
 			if err != nil {
-				batchResponseItems[i].Error = err.Error()
+				batchResponseItems[i].Error = "failed to base64-decode context"
 				continue
 			}
 		}
@@ -199,7 +199,7 @@ func (b *backend) pathEncryptWrite(
 		if len(item.Nonce) != 0 {
 			batchInputItems[i].DecodedNonce, err = base64.StdEncoding.DecodeString(item.Nonce)
 			if err != nil {
-				batchResponseItems[i].Error = err.Error()
+				batchResponseItems[i].Error = "failed to base64-decode nonce"
 				continue
 			}
 		}
@@ -515,10 +515,18 @@ func TestTransit_BatchEncryptionCase11(t *testing.T) {
 		Storage:   s,
 		Data:      batchData,
 	}
-	_, err = b.HandleRequest(batchReq)
+	resp, err := b.HandleRequest(batchReq)
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	batchResponseItems := resp.Data["batch_results"].([]BatchResponseItem)
+	if batchResponseItems[0].Error != "" {
+		t.Fatalf("bad: unexpected error for first item: %s", batchResponseItems[0].Error)
+	}
+	if batchResponseItems[1].Error != "failed to base64-decode context" {
+		t.Fatalf("bad: error for second item: %q", batchResponseItems[1].Error)
+	}
 }
 
 // Case12: Invalid batch input
@@ -540,8 +548,11 @@ func TestTransit_BatchEncryptionCase12(t *testing.T) {
 		Storage:   s,
 		Data:      batchData,
 	}
-	_, err = b.HandleRequest(batchReq)
-	if err == nil {
-		t.Fatalf("expected an error")
+	resp, err := b.HandleRequest(batchReq)
+	if err != logical.ErrInvalidRequest {
+		t.Fatalf("expected invalid request error, got %v", err)
+	}
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("expected error response, got %#v", resp)
 	}
 }
@@ -165,6 +165,16 @@ func getExportKey(policy *keysutil.Policy, key *keysutil.KeyEntry, exportType st
 
 		case keysutil.KeyType_ED25519:
 			return strings.TrimSpace(base64.StdEncoding.EncodeToString(key.Key)), nil
+
+		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA4096:
+			if key.RSAKey == nil {
+				return "", errors.New("nil RSA key provided")
+			}
+			block := pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key.RSAKey),
+			}
+			return strings.TrimSpace(string(pem.EncodeToMemory(&block))), nil
 		}
 	}
 
@@ -12,6 +12,7 @@ import (
 
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/mitchellh/mapstructure"
 )
 
 func (b *backend) pathHMAC() *framework.Path {
@@ -63,19 +64,63 @@ to the min_encryption_version configured on the key.`,
 	}
 }
 
+// batchRequestHMACItem represents a request item for batch HMAC generation
+type batchRequestHMACItem struct {
+	// Base64 encoded input data to HMAC
+	Input string `json:"input" structs:"input" mapstructure:"input"`
+
+	// DecodedInput is the base64 decoded version of Input
+	DecodedInput []byte
+}
+
+// batchResponseHMACItem represents a response item for batch HMAC generation
+type batchResponseHMACItem struct {
+	// HMAC of the input present in the corresponding batch request item
+	HMAC string `json:"hmac,omitempty" structs:"hmac" mapstructure:"hmac"`
+
+	// Error, if set represents a failure encountered while generating the
+	// HMAC for the corresponding batch request item
+	Error string `json:"error,omitempty" structs:"error" mapstructure:"error"`
+}
+
 func (b *backend) pathHMACWrite(
 	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	name := d.Get("name").(string)
 	ver := d.Get("key_version").(int)
-	inputB64 := d.Get("input").(string)
 	algorithm := d.Get("urlalgorithm").(string)
 	if algorithm == "" {
 		algorithm = d.Get("algorithm").(string)
 	}
 
-	input, err := base64.StdEncoding.DecodeString(inputB64)
+	hashFunc, err := hmacHashFunc(algorithm)
 	if err != nil {
-		return logical.ErrorResponse(fmt.Sprintf("unable to decode input as base64: %s", err)), logical.ErrInvalidRequest
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	batchInputRaw := d.Raw["batch_input"]
+	var batchInputItems []batchRequestHMACItem
+	if batchInputRaw != nil {
+		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse batch input: %v", err)), logical.ErrInvalidRequest
+		}
+
+		if len(batchInputItems) == 0 {
+			return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+		}
+	} else {
+		batchInputItems = make([]batchRequestHMACItem, 1)
+		batchInputItems[0] = batchRequestHMACItem{
+			Input: d.Get("input").(string),
+		}
+	}
+
+	batchResponseItems := make([]batchResponseHMACItem, len(batchInputItems))
+	for i, item := range batchInputItems {
+		batchInputItems[i].DecodedInput, err = base64.StdEncoding.DecodeString(item.Input)
+		if err != nil {
+			batchResponseItems[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
+		}
 	}
 
 	// Get the policy
@@ -109,30 +154,32 @@ func (b *backend) pathHMACWrite(
 		return nil, fmt.Errorf("HMAC key value could not be computed")
 	}
 
-	var hf hash.Hash
-	switch algorithm {
-	case "sha2-224":
-		hf = hmac.New(sha256.New224, key)
-	case "sha2-256":
-		hf = hmac.New(sha256.New, key)
-	case "sha2-384":
-		hf = hmac.New(sha512.New384, key)
-	case "sha2-512":
-		hf = hmac.New(sha512.New, key)
-	default:
-		return logical.ErrorResponse(fmt.Sprintf("unsupported algorithm %s", algorithm)), nil
-	}
-	hf.Write(input)
-	retBytes := hf.Sum(nil)
+	for i, item := range batchInputItems {
+		if batchResponseItems[i].Error != "" {
+			continue
+		}
+
+		hf := hmac.New(hashFunc, key)
+		hf.Write(item.DecodedInput)
+		retBytes := hf.Sum(nil)
 
-	retStr := base64.StdEncoding.EncodeToString(retBytes)
-	retStr = fmt.Sprintf("vault:v%s:%s", strconv.Itoa(ver), retStr)
+		retStr := base64.StdEncoding.EncodeToString(retBytes)
+		batchResponseItems[i].HMAC = fmt.Sprintf("vault:v%s:%s", strconv.Itoa(ver), retStr)
+	}
 
 	// Generate the response
-	resp := &logical.Response{
-		Data: map[string]interface{}{
-			"hmac": retStr,
-		},
+	resp := &logical.Response{}
+	if batchInputRaw != nil {
+		resp.Data = map[string]interface{}{
+			"batch_results": batchResponseItems,
+		}
+	} else {
+		if batchResponseItems[0].Error != "" {
+			return logical.ErrorResponse(batchResponseItems[0].Error), logical.ErrInvalidRequest
+		}
+		resp.Data = map[string]interface{}{
+			"hmac": batchResponseItems[0].HMAC,
+		}
 	}
 	return resp, nil
 }
@@ -200,19 +247,12 @@ func (b *backend) pathHMACVerify(
 		return nil, fmt.Errorf("HMAC key value could not be computed")
 	}
 
-	var hf hash.Hash
-	switch algorithm {
-	case "sha2-224":
-		hf = hmac.New(sha256.New224, key)
-	case "sha2-256":
-		hf = hmac.New(sha256.New, key)
-	case "sha2-384":
-		hf = hmac.New(sha512.New384, key)
-	case "sha2-512":
-		hf = hmac.New(sha512.New, key)
-	default:
-		return logical.ErrorResponse(fmt.Sprintf("unsupported algorithm %s", algorithm)), nil
+	hashFunc, err := hmacHashFunc(algorithm)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
 	}
+
+	hf := hmac.New(hashFunc, key)
 	hf.Write(input)
 	retBytes := hf.Sum(nil)
 
@@ -223,8 +263,25 @@ func (b *backend) pathHMACVerify(
 	}, nil
 }
 
+// hmacHashFunc returns the hash constructor for the given algorithm name
+func hmacHashFunc(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "sha2-224":
+		return sha256.New224, nil
+	case "sha2-256":
+		return sha256.New, nil
+	case "sha2-384":
+		return sha512.New384, nil
+	case "sha2-512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unsupported algorithm %s", algorithm)
+}
+
 const pathHMACHelpSyn = `Generate an HMAC for input data using the named key`
 
 const pathHMACHelpDesc = `
 Generates an HMAC sum of the given algorithm and key against the given input data.
+Multiple HMACs can be generated at once by supplying a list of items, each with
+an "input" value, via "batch_input".
 `
@@ -181,3 +181,67 @@ func TestTransit_HMAC(t *testing.T) {
 		t.Fatalf("expected invalid request error, got %v", err)
 	}
 }
+
+func TestTransit_BatchHMAC(t *testing.T) {
+	b, storage := createBackendWithStorage(t)
+
+	// First create a key
+	req := &logical.Request{
+		Storage:   storage,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+	}
+	_, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Now, change the key value to something we control
+	p, lock, err := b.lm.GetPolicyShared(storage, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// We don't care as we're the only one using this
+	lock.RUnlock()
+	keyEntry := p.Keys[p.LatestVersion]
+	keyEntry.HMACKey = []byte("01234567890123456789012345678901")
+	p.Keys[p.LatestVersion] = keyEntry
+	if err = p.Persist(storage); err != nil {
+		t.Fatal(err)
+	}
+
+	req.Path = "hmac/foo"
+	req.Data = map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": "dGhlIHF1aWNrIGJyb3duIGZveA=="},
+			map[string]interface{}{"input": "not base64!"},
+			map[string]interface{}{"input": "dGhlIHF1aWNrIGJyb3duIGZveA=="},
+		},
+	}
+	resp, err := b.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	batchResults := resp.Data["batch_results"].([]batchResponseHMACItem)
+	if len(batchResults) != 3 {
+		t.Fatalf("bad: expected 3 results, got %d", len(batchResults))
+	}
+	for _, i := range []int{0, 2} {
+		if batchResults[i].Error != "" {
+			t.Fatalf("bad: item %d: %s", i, batchResults[i].Error)
+		}
+		if batchResults[i].HMAC != "vault:v1:UcBvm5VskkukzZHlPgm3p5P/Yr/PV6xpuOGZISya3A4=" {
+			t.Fatalf("bad: item %d: mismatched hmac %s", i, batchResults[i].HMAC)
+		}
+	}
+	if batchResults[1].Error == "" || batchResults[1].HMAC != "" {
+		t.Fatalf("bad: expected error for invalid input, got %#v", batchResults[1])
+	}
+
+	req.Data["batch_input"] = []interface{}{}
+	resp, err = b.HandleRequest(req)
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error for empty batch input")
+	}
+}
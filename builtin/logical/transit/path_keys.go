@@ -41,8 +41,10 @@ func (b *backend) pathKeys() *framework.Path {
 				Type:    framework.TypeString,
 				Default: "aes256-gcm96",
 				Description: `The type of key to create. Currently,
-"aes256-gcm96" (symmetric) and "ecdsa-p256" (asymmetric), and
-'ed25519' (asymmetric) are supported. Defaults to "aes256-gcm96".`,
+"aes256-gcm96" (symmetric), "ecdsa-p256" (asymmetric),
+'ed25519' (asymmetric), 'rsa-2048' (asymmetric) and
+'rsa-4096' (asymmetric) are supported. Defaults to
+"aes256-gcm96".`,
 			},
 
 			"derived": &framework.FieldSchema{
@@ -131,6 +133,10 @@ func (b *backend) pathPolicyWrite(
 		polReq.KeyType = keysutil.KeyType_ECDSA_P256
 	case "ed25519":
 		polReq.KeyType = keysutil.KeyType_ED25519
+	case "rsa-2048":
+		polReq.KeyType = keysutil.KeyType_RSA2048
+	case "rsa-4096":
+		polReq.KeyType = keysutil.KeyType_RSA4096
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
 	}
@@ -191,6 +197,9 @@ func (b *backend) pathPolicyRead(
 			"supports_decryption":    p.Type.DecryptionSupported(),
 			"supports_signing":       p.Type.SigningSupported(),
 			"supports_derivation":    p.Type.DerivationSupported(),
+			"auto_rotate_interval":   int64(p.AutoRotateInterval.Seconds()),
+			"allow_plaintext_backup": p.AllowPlaintextBackup,
+			"min_available_version":  p.MinAvailableVersion,
 		},
 	}
 
@@ -204,7 +213,7 @@ func (b *backend) pathPolicyRead(
 		}
 		resp.Data["convergent_encryption"] = p.ConvergentEncryption
 		if p.ConvergentEncryption {
-			resp.Data["convergent_encryption_version"] = p.ConvergentVersion
+			resp.Data["convergent_encryption_version"] = p.ConvergentVersionFor(p.LatestVersion)
 		}
 	}
 
@@ -225,7 +234,7 @@ func (b *backend) pathPolicyRead(
 		}
 		resp.Data["keys"] = retKeys
 
-	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ED25519:
+	case keysutil.KeyType_ECDSA_P256, keysutil.KeyType_ED25519, keysutil.KeyType_RSA2048, keysutil.KeyType_RSA4096:
 		retKeys := map[string]map[string]interface{}{}
 		for k, v := range p.Keys {
 			key := asymKey{
@@ -253,6 +262,8 @@ func (b *backend) pathPolicyRead(
 					}
 				}
 				key.Name = "ed25519"
+			case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA4096:
+				key.Name = p.Type.String()
 			}
 
 			retKeys[strconv.Itoa(k)] = structs.New(key).Map()
@@ -59,7 +59,7 @@ func (b *backend) pathRewrapWrite(
 	if batchInputRaw != nil {
 		err = mapstructure.Decode(batchInputRaw, &batchInputItems)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse batch input: %v", err)
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse batch input: %v", err)), logical.ErrInvalidRequest
 		}
 
 		if len(batchInputItems) == 0 {
@@ -97,7 +97,7 @@ func (b *backend) pathRewrapWrite(
 		if len(item.Context) != 0 {
 			batchInputItems[i].DecodedContext, err = base64.StdEncoding.DecodeString(item.Context)
 			if err != nil {
-				batchResponseItems[i].Error = err.Error()
+				batchResponseItems[i].Error = "failed to base64-decode context"
 				continue
 			}
 		}
@@ -106,7 +106,7 @@ func (b *backend) pathRewrapWrite(
 		if len(item.Nonce) != 0 {
 			batchInputItems[i].DecodedNonce, err = base64.StdEncoding.DecodeString(item.Nonce)
 			if err != nil {
-				batchResponseItems[i].Error = err.Error()
+				batchResponseItems[i].Error = "failed to base64-decode nonce"
 				continue
 			}
 		}
@@ -46,6 +46,12 @@ Defaults to "sha2-256". Not valid for all key types,
 including ed25519.`,
 			},
 
+			"prehashed": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Set to 'true' when the input is already hashed with
+the given algorithm. Not valid for ed25519 keys.`,
+			},
+
 			"urlalgorithm": &framework.FieldSchema{
 				Type:        framework.TypeString,
 				Description: `Hash algorithm to use (POST URL parameter)`,
@@ -115,6 +121,12 @@ derivation is enabled; currently only available with ed25519 keys.`,
 
 Defaults to "sha2-256". Not valid for all key types.`,
 			},
+
+			"prehashed": &framework.FieldSchema{
+				Type: framework.TypeBool,
+				Description: `Set to 'true' when the input is already hashed with
+the given algorithm. Not valid for ed25519 keys.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -166,7 +178,12 @@ func (b *backend) pathSignWrite(
 		}
 	}
 
-	if p.Type.HashSignatureInput() {
+	prehashed := d.Get("prehashed").(bool)
+	if prehashed && !p.Type.HashSignatureInput() {
+		return logical.ErrorResponse(fmt.Sprintf("key type %v does not support prehashed input", p.Type)), logical.ErrInvalidRequest
+	}
+
+	if p.Type.HashSignatureInput() && !prehashed {
 		var hf hash.Hash
 		switch algorithm {
 		case "sha2-224":
@@ -184,9 +201,14 @@ func (b *backend) pathSignWrite(
 		input = hf.Sum(nil)
 	}
 
-	sig, err := p.Sign(ver, context, input)
+	sig, err := p.Sign(ver, context, input, algorithm)
 	if err != nil {
-		return nil, err
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		default:
+			return nil, err
+		}
 	}
 	if sig == nil {
 		return nil, fmt.Errorf("signature could not be computed")
@@ -259,7 +281,12 @@ func (b *backend) pathVerifyWrite(
 		}
 	}
 
-	if p.Type.HashSignatureInput() {
+	prehashed := d.Get("prehashed").(bool)
+	if prehashed && !p.Type.HashSignatureInput() {
+		return logical.ErrorResponse(fmt.Sprintf("key type %v does not support prehashed input", p.Type)), logical.ErrInvalidRequest
+	}
+
+	if p.Type.HashSignatureInput() && !prehashed {
 		var hf hash.Hash
 		switch algorithm {
 		case "sha2-224":
@@ -277,7 +304,7 @@ func (b *backend) pathVerifyWrite(
 		input = hf.Sum(nil)
 	}
 
-	valid, err := p.VerifySignature(context, input, sig)
+	valid, err := p.VerifySignature(context, input, sig, algorithm)
 	if err != nil {
 		switch err.(type) {
 		case errutil.UserError:
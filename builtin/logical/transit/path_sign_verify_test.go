@@ -1,6 +1,7 @@
 package transit
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
 	"strings"
 	"testing"
@@ -411,3 +412,153 @@ func TestTransit_SignVerify_ED25519(t *testing.T) {
 	verifyRequest(req, false, "bar", sig)
 	verifyRequest(req, true, "bar", v1sig)
 }
+
+func TestTransit_SignVerify_RSA(t *testing.T) {
+	var resp *logical.Response
+	var err error
+	b, s := createBackendWithStorage(t)
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Storage:   s,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/foo",
+		Data: map[string]interface{}{
+			"type": "rsa-2048",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	resp, err = b.HandleRequest(&logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "keys/foo",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["type"] != "rsa-2048" {
+		t.Fatalf("bad: key type: %v", resp.Data["type"])
+	}
+	keys := resp.Data["keys"].(map[string]map[string]interface{})
+	if len(keys) != 1 || !strings.Contains(keys["1"]["public_key"].(string), "BEGIN PUBLIC KEY") {
+		t.Fatalf("bad: expected one PEM public key, got %#v", keys)
+	}
+
+	sign := func(path string, data map[string]interface{}, errExpected bool) string {
+		resp, err := b.HandleRequest(&logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error signing with %#v", data)
+			}
+			return ""
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp.Data["signature"].(string)
+	}
+
+	verify := func(path string, data map[string]interface{}) bool {
+		resp, err := b.HandleRequest(&logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err:%v resp:%#v", err, resp)
+		}
+		return resp.Data["valid"].(bool)
+	}
+
+	input := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	for _, algorithm := range []string{"sha2-224", "sha2-256", "sha2-384", "sha2-512"} {
+		sig := sign("sign/foo/"+algorithm, map[string]interface{}{
+			"input": input,
+		}, false)
+		if !strings.HasPrefix(sig, "vault:v1:") {
+			t.Fatalf("bad: signature %q", sig)
+		}
+		if !verify("verify/foo/"+algorithm, map[string]interface{}{
+			"input":     input,
+			"signature": sig,
+		}) {
+			t.Fatalf("bad: %s signature did not verify", algorithm)
+		}
+		if verify("verify/foo/"+algorithm, map[string]interface{}{
+			"input":     "Zm9vYmFy",
+			"signature": sig,
+		}) {
+			t.Fatalf("bad: %s signature verified against the wrong input", algorithm)
+		}
+	}
+
+	// Signatures over prehashed input must match signatures over the
+	// original input
+	sum := sha256.Sum256([]byte("the quick brown fox"))
+	prehashedInput := base64.StdEncoding.EncodeToString(sum[:])
+	sig := sign("sign/foo", map[string]interface{}{
+		"input": input,
+	}, false)
+	if !verify("verify/foo", map[string]interface{}{
+		"input":     prehashedInput,
+		"signature": sig,
+		"prehashed": true,
+	}) {
+		t.Fatal("bad: signature did not verify against prehashed input")
+	}
+	sig = sign("sign/foo", map[string]interface{}{
+		"input":     prehashedInput,
+		"prehashed": true,
+	}, false)
+	if !verify("verify/foo", map[string]interface{}{
+		"input":     input,
+		"signature": sig,
+	}) {
+		t.Fatal("bad: prehashed signature did not verify against original input")
+	}
+
+	// Prehashed input must be the size of the hash
+	sign("sign/foo/sha2-512", map[string]interface{}{
+		"input":     prehashedInput,
+		"prehashed": true,
+	}, true)
+
+	// Prehashed input is not supported for ed25519 keys
+	resp, err = b.HandleRequest(&logical.Request{
+		Storage:   s,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/bar",
+		Data: map[string]interface{}{
+			"type": "ed25519",
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	sign("sign/bar", map[string]interface{}{
+		"input":     prehashedInput,
+		"prehashed": true,
+	}, true)
+
+	// RSA keys cannot be derived
+	resp, err = b.HandleRequest(&logical.Request{
+		Storage:   s,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/baz",
+		Data: map[string]interface{}{
+			"type":    "rsa-2048",
+			"derived": true,
+		},
+	})
+	if err == nil && (resp == nil || !resp.IsError()) {
+		t.Fatal("expected error creating a derived RSA key")
+	}
+}
@@ -0,0 +1,133 @@
+package transit
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestTransit_Trim(t *testing.T) {
+	var resp *logical.Response
+	b, s := createBackendWithStorage(t)
+
+	doRequest := func(path string, data map[string]interface{}, errExpected bool) *logical.Response {
+		resp, err := b.HandleRequest(&logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if errExpected {
+			if err == nil && (resp == nil || !resp.IsError()) {
+				t.Fatalf("expected error for %s with %#v", path, data)
+			}
+			return resp
+		}
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s: err:%v resp:%#v", path, err, resp)
+		}
+		return resp
+	}
+
+	checkArchive := func(expectedLen int) {
+		p, lock, err := b.lm.GetPolicyShared(s, "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer lock.RUnlock()
+		archive, err := p.LoadArchive(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive.Keys) != expectedLen {
+			t.Fatalf("bad: expected %d archived keys, got %d", expectedLen, len(archive.Keys))
+		}
+	}
+
+	// Create six versions of the key, encrypting with each
+	plaintext := "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	doRequest("keys/foo", nil, false)
+	ciphertexts := map[int]string{}
+	for ver := 1; ver <= 6; ver++ {
+		if ver > 1 {
+			doRequest("keys/foo/rotate", nil, false)
+		}
+		resp = doRequest("encrypt/foo", map[string]interface{}{
+			"plaintext": plaintext,
+		}, false)
+		ciphertexts[ver] = resp.Data["ciphertext"].(string)
+	}
+	// The untrimmed archive is indexed by version, leaving the first entry
+	// unused
+	checkArchive(7)
+
+	// Invalid requests
+	doRequest("keys/foo/trim", nil, true)
+	doRequest("keys/foo/trim", map[string]interface{}{
+		"min_available_version": 0,
+	}, true)
+	doRequest("keys/foo/trim", map[string]interface{}{
+		"min_available_version": 2,
+	}, true)
+	doRequest("keys/bar/trim", map[string]interface{}{
+		"min_available_version": 1,
+	}, true)
+
+	doRequest("keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 4,
+		"min_encryption_version": 5,
+	}, false)
+	doRequest("keys/foo/trim", map[string]interface{}{
+		"min_available_version": 5,
+	}, true)
+	doRequest("keys/foo/trim", map[string]interface{}{
+		"min_available_version": 3,
+	}, false)
+	checkArchive(4)
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "keys/foo",
+	})
+	if err != nil || resp == nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["min_available_version"].(int) != 3 {
+		t.Fatalf("bad: min_available_version: %v", resp.Data["min_available_version"])
+	}
+
+	// Trimmed versions cannot be made available again
+	doRequest("keys/foo/trim", map[string]interface{}{
+		"min_available_version": 2,
+	}, true)
+	doRequest("keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 2,
+	}, true)
+
+	// Versions kept in the archive can still be restored for decryption
+	doRequest("keys/foo/config", map[string]interface{}{
+		"min_decryption_version": 3,
+	}, false)
+	for ver, ciphertext := range ciphertexts {
+		resp = doRequest("decrypt/foo", map[string]interface{}{
+			"ciphertext": ciphertext,
+		}, ver < 3)
+		if ver >= 3 && resp.Data["plaintext"] != plaintext {
+			t.Fatalf("bad: version %d: plaintext: %v", ver, resp.Data["plaintext"])
+		}
+	}
+
+	// Rotation keeps archiving relative to the trimmed start
+	doRequest("keys/foo/rotate", nil, false)
+	checkArchive(5)
+	resp = doRequest("encrypt/foo", map[string]interface{}{
+		"plaintext": plaintext,
+	}, false)
+	resp = doRequest("decrypt/foo", map[string]interface{}{
+		"ciphertext": resp.Data["ciphertext"],
+	}, false)
+	if resp.Data["plaintext"] != plaintext {
+		t.Fatalf("bad: plaintext: %v", resp.Data["plaintext"])
+	}
+}
@@ -33,6 +33,7 @@ import (
 	physMSSQL "github.com/hashicorp/vault/physical/mssql"
 	physMySQL "github.com/hashicorp/vault/physical/mysql"
 	physPostgreSQL "github.com/hashicorp/vault/physical/postgresql"
+	physRaft "github.com/hashicorp/vault/physical/raft"
 	physS3 "github.com/hashicorp/vault/physical/s3"
 	physSwift "github.com/hashicorp/vault/physical/swift"
 	physZooKeeper "github.com/hashicorp/vault/physical/zookeeper"
@@ -41,9 +42,11 @@ import (
 	"github.com/hashicorp/vault/builtin/logical/cassandra"
 	"github.com/hashicorp/vault/builtin/logical/consul"
 	"github.com/hashicorp/vault/builtin/logical/database"
+	"github.com/hashicorp/vault/builtin/logical/kv"
 	"github.com/hashicorp/vault/builtin/logical/mongodb"
 	"github.com/hashicorp/vault/builtin/logical/mssql"
 	"github.com/hashicorp/vault/builtin/logical/mysql"
+	"github.com/hashicorp/vault/builtin/logical/nomad"
 	"github.com/hashicorp/vault/builtin/logical/pki"
 	"github.com/hashicorp/vault/builtin/logical/postgresql"
 	"github.com/hashicorp/vault/builtin/logical/rabbitmq"
@@ -59,6 +62,33 @@ import (
 	"github.com/mitchellh/cli"
 )
 
+// physicalBackends are the storage backends available to the server and
+// operator migrate commands
+var physicalBackends = map[string]physical.Factory{
+	"azure":                  physAzure.NewAzureBackend,
+	"cassandra":              physCassandra.NewCassandraBackend,
+	"cockroachdb":            physCockroachDB.NewCockroachDBBackend,
+	"consul":                 physConsul.NewConsulBackend,
+	"couchdb":                physCouchDB.NewCouchDBBackend,
+	"couchdb_transactional":  physCouchDB.NewTransactionalCouchDBBackend,
+	"dynamodb":               physDynamoDB.NewDynamoDBBackend,
+	"etcd":                   physEtcd.NewEtcdBackend,
+	"file":                   physFile.NewFileBackend,
+	"file_transactional":     physFile.NewTransactionalFileBackend,
+	"gcs":                    physGCS.NewGCSBackend,
+	"inmem":                  physInmem.NewInmem,
+	"inmem_ha":               physInmem.NewInmemHA,
+	"inmem_transactional":    physInmem.NewTransactionalInmem,
+	"inmem_transactional_ha": physInmem.NewTransactionalInmemHA,
+	"mssql":                  physMSSQL.NewMSSQLBackend,
+	"mysql":                  physMySQL.NewMySQLBackend,
+	"postgresql":             physPostgreSQL.NewPostgreSQLBackend,
+	"raft":                   physRaft.NewRaftBackend,
+	"s3":                     physS3.NewS3Backend,
+	"swift":                  physSwift.NewSwiftBackend,
+	"zookeeper":              physZooKeeper.NewZooKeeperBackend,
+}
+
 // Commands returns the mapping of CLI commands for Vault. The meta
 // parameter lets you set meta options for all commands.
 func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
@@ -75,6 +105,16 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 		}
 	}
 
+	authHandlers := map[string]command.AuthHandler{
+		"github":   &credGitHub.CLIHandler{},
+		"userpass": &credUserpass.CLIHandler{DefaultMount: "userpass"},
+		"ldap":     &credLdap.CLIHandler{},
+		"okta":     &credOkta.CLIHandler{},
+		"cert":     &credCert.CLIHandler{},
+		"aws":      &credAws.CLIHandler{},
+		"radius":   &credUserpass.CLIHandler{DefaultMount: "radius"},
+	}
+
 	return map[string]cli.CommandFactory{
 		"init": func() (cli.Command, error) {
 			return &command.InitCommand{
@@ -114,6 +154,8 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 					"mysql":      mysql.Factory,
 					"ssh":        ssh.Factory,
 					"rabbitmq":   rabbitmq.Factory,
+					"nomad":      nomad.Factory,
+					"kv":         kv.Factory,
 					"database":   database.Factory,
 					"totp":       totp.Factory,
 					"plugin":     plugin.Factory,
@@ -123,33 +165,18 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 				SighupCh:   command.MakeSighupCh(),
 			}
 
-			c.PhysicalBackends = map[string]physical.Factory{
-				"azure":                  physAzure.NewAzureBackend,
-				"cassandra":              physCassandra.NewCassandraBackend,
-				"cockroachdb":            physCockroachDB.NewCockroachDBBackend,
-				"consul":                 physConsul.NewConsulBackend,
-				"couchdb":                physCouchDB.NewCouchDBBackend,
-				"couchdb_transactional":  physCouchDB.NewTransactionalCouchDBBackend,
-				"dynamodb":               physDynamoDB.NewDynamoDBBackend,
-				"etcd":                   physEtcd.NewEtcdBackend,
-				"file":                   physFile.NewFileBackend,
-				"file_transactional":     physFile.NewTransactionalFileBackend,
-				"gcs":                    physGCS.NewGCSBackend,
-				"inmem":                  physInmem.NewInmem,
-				"inmem_ha":               physInmem.NewInmemHA,
-				"inmem_transactional":    physInmem.NewTransactionalInmem,
-				"inmem_transactional_ha": physInmem.NewTransactionalInmemHA,
-				"mssql":                  physMSSQL.NewMSSQLBackend,
-				"mysql":                  physMySQL.NewMySQLBackend,
-				"postgresql":             physPostgreSQL.NewPostgreSQLBackend,
-				"s3":                     physS3.NewS3Backend,
-				"swift":                  physSwift.NewSwiftBackend,
-				"zookeeper":              physZooKeeper.NewZooKeeperBackend,
-			}
+			c.PhysicalBackends = physicalBackends
 
 			return c, nil
 		},
 
+		"agent": func() (cli.Command, error) {
+			return &command.AgentCommand{
+				Meta:       *metaPtr,
+				ShutdownCh: command.MakeShutdownCh(),
+			}, nil
+		},
+
 		"ssh": func() (cli.Command, error) {
 			return &command.SSHCommand{
 				Meta: *metaPtr,
@@ -164,15 +191,16 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 
 		"auth": func() (cli.Command, error) {
 			return &command.AuthCommand{
-				Meta: *metaPtr,
-				Handlers: map[string]command.AuthHandler{
-					"github":   &credGitHub.CLIHandler{},
-					"userpass": &credUserpass.CLIHandler{DefaultMount: "userpass"},
-					"ldap":     &credLdap.CLIHandler{},
-					"okta":     &credOkta.CLIHandler{},
-					"cert":     &credCert.CLIHandler{},
-					"aws":      &credAws.CLIHandler{},
-					"radius":   &credUserpass.CLIHandler{DefaultMount: "radius"},
+				Meta:     *metaPtr,
+				Handlers: authHandlers,
+			}, nil
+		},
+
+		"login": func() (cli.Command, error) {
+			return &command.LoginCommand{
+				AuthCommand: command.AuthCommand{
+					Meta:     *metaPtr,
+					Handlers: authHandlers,
 				},
 			}, nil
 		},
@@ -261,6 +289,62 @@ func Commands(metaPtr *meta.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"kv": func() (cli.Command, error) {
+			return &command.KVCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv get": func() (cli.Command, error) {
+			return &command.KVGetCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv put": func() (cli.Command, error) {
+			return &command.KVPutCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv patch": func() (cli.Command, error) {
+			return &command.KVPatchCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv delete": func() (cli.Command, error) {
+			return &command.KVDeleteCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"kv list": func() (cli.Command, error) {
+			return &command.KVListCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"operator": func() (cli.Command, error) {
+			return &command.OperatorCommand{
+				Meta: *metaPtr,
+			}, nil
+		},
+
+		"operator debug": func() (cli.Command, error) {
+			return &command.OperatorDebugCommand{
+				Meta:       *metaPtr,
+				ShutdownCh: command.MakeShutdownCh(),
+			}, nil
+		},
+
+		"operator migrate": func() (cli.Command, error) {
+			return &command.OperatorMigrateCommand{
+				Meta:             *metaPtr,
+				PhysicalBackends: physicalBackends,
+			}, nil
+		},
+
 		"rekey": func() (cli.Command, error) {
 			return &command.RekeyCommand{
 				Meta: *metaPtr,
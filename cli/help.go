@@ -13,6 +13,7 @@ import (
 func HelpFunc(commands map[string]cli.CommandFactory) string {
 	commonNames := map[string]struct{}{
 		"delete":    struct{}{},
+		"login":     struct{}{},
 		"path-help": struct{}{},
 		"read":      struct{}{},
 		"renew":     struct{}{},
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/hashicorp/vault/meta"
 	"github.com/mitchellh/cli"
 )
 
@@ -36,11 +37,12 @@ func RunCustom(args []string, commands map[string]cli.CommandFactory) int {
 	}
 
 	cli := &cli.CLI{
-		Args:         args,
-		Commands:     commands,
-		Name:         "vault",
-		Autocomplete: true,
-		HelpFunc:     cli.FilteredHelpFunc(commandsInclude, HelpFunc),
+		Args:                    args,
+		Commands:                commands,
+		Name:                    "vault",
+		Autocomplete:            true,
+		AutocompleteGlobalFlags: meta.GeneralFlagsAutocomplete(),
+		HelpFunc:                cli.FilteredHelpFunc(commandsInclude, HelpFunc),
 	}
 
 	exitCode, err := cli.Run()
@@ -0,0 +1,326 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	colorable "github.com/mattn/go-colorable"
+	log "github.com/mgutz/logxi/v1"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/command/agent/auth/approle"
+	"github.com/hashicorp/vault/command/agent/auth/aws"
+	"github.com/hashicorp/vault/command/agent/auth/kubernetes"
+	"github.com/hashicorp/vault/command/agent/cache"
+	agentConfig "github.com/hashicorp/vault/command/agent/config"
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/command/agent/sink/file"
+	"github.com/hashicorp/vault/command/agent/sink/inmem"
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/meta"
+)
+
+// AgentCommand is a Command that runs the Vault agent, a daemon that
+// authenticates to Vault with an auth method and keeps a token for it
+// renewed and written to the configured sinks.
+type AgentCommand struct {
+	meta.Meta
+
+	ShutdownCh chan struct{}
+
+	logger log.Logger
+}
+
+func (c *AgentCommand) Run(args []string) int {
+	var configPath, logLevel string
+	flags := c.Meta.FlagSet("agent", meta.FlagSetDefault)
+	flags.StringVar(&configPath, "config", "", "")
+	flags.StringVar(&logLevel, "log-level", "info", "")
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if configPath == "" {
+		c.Ui.Error("At least one config path must be specified with -config")
+		flags.Usage()
+		return 1
+	}
+
+	level, err := parseLogLevel(strings.ToLower(strings.TrimSpace(logLevel)))
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	logWriter := colorable.NewColorable(os.Stderr)
+	c.logger = logformat.NewVaultLoggerWithWriter(logWriter, level)
+
+	config, err := agentConfig.LoadConfig(configPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error loading configuration from %s: %s", configPath, err))
+		return 1
+	}
+	if config.AutoAuth == nil && config.Cache == nil {
+		c.Ui.Error("No auto_auth or cache block found in config file")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	// The agent only ever uses the tokens it obtains itself or that are
+	// given in proxied requests
+	client.SetWrappingLookupFunc(nil)
+	client.ClearToken()
+
+	var method auth.AuthMethod
+	var sinks []*sink.SinkConfig
+	var inmemSink sink.SinkReader
+	if config.AutoAuth != nil {
+		method, err = newAgentAuthMethod(c.logger, config.AutoAuth.Method)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error creating %s auth method: %s", config.AutoAuth.Method.Type, err))
+			return 1
+		}
+
+		for _, sc := range config.AutoAuth.Sinks {
+			s, err := newAgentSink(c.logger, sc)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf(
+					"Error creating %s sink: %s", sc.Type, err))
+				return 1
+			}
+			sinks = append(sinks, s)
+		}
+
+		// The cache uses the auto-auth token through an in-memory sink
+		if config.Cache != nil && config.Cache.UseAutoAuthToken {
+			sc := &sink.SinkConfig{
+				Logger: c.logger,
+			}
+			if inmemSink, err = inmem.New(sc); err != nil {
+				c.Ui.Error(fmt.Sprintf(
+					"Error creating in-memory sink: %s", err))
+				return 1
+			}
+			sc.Sink = inmemSink
+			sinks = append(sinks, sc)
+		}
+	}
+
+	if config.PidFile != "" {
+		if err := ioutil.WriteFile(config.PidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error writing pid file: %s", err))
+			return 1
+		}
+		defer os.Remove(config.PidFile)
+	}
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+
+	if config.Cache != nil {
+		proxyClient, err := client.Clone()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing client: %s", err))
+			return 2
+		}
+
+		leaseCache, err := cache.NewLeaseCache(&cache.LeaseCacheConfig{
+			Proxier: cache.NewAPIProxy(&cache.APIProxyConfig{
+				Client: proxyClient,
+				Logger: c.logger,
+			}),
+			Client:      proxyClient,
+			BaseContext: ctx,
+			Logger:      c.logger,
+		})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error creating lease cache: %s", err))
+			return 1
+		}
+
+		handlerConfig := &cache.HandlerConfig{
+			Logger: c.logger,
+			Cache:  leaseCache,
+		}
+		if inmemSink != nil {
+			handlerConfig.TokenFunc = inmemSink.Token
+		}
+		handler := cache.Handler(handlerConfig)
+
+		for _, lnConfig := range config.Listeners {
+			ln, props, _, err := server.NewListener(lnConfig.Type, lnConfig.Config, logWriter)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf(
+					"Error initializing listener of type %s: %s", lnConfig.Type, err))
+				return 1
+			}
+			defer ln.Close()
+
+			c.Ui.Output(fmt.Sprintf(
+				"==> Vault agent cache listening on %s (%s)", props["addr"], lnConfig.Type))
+
+			srv := &http.Server{
+				Handler: handler,
+			}
+			go srv.Serve(ln)
+		}
+	}
+
+	var ah *auth.AuthHandler
+	var ss *sink.SinkServer
+	var sinkDoneCh chan struct{}
+	if method != nil {
+		authClient, err := client.Clone()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing client: %s", err))
+			return 2
+		}
+		sinkClient, err := client.Clone()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error initializing client: %s", err))
+			return 2
+		}
+
+		ah = auth.NewAuthHandler(&auth.AuthHandlerConfig{
+			Logger: c.logger,
+			Client: authClient,
+		})
+		ss = sink.NewSinkServer(&sink.SinkServerConfig{
+			Logger:        c.logger,
+			Client:        sinkClient,
+			ExitAfterAuth: config.ExitAfterAuth,
+		})
+		sinkDoneCh = ss.DoneCh
+
+		go ah.Run(ctx, method)
+		go ss.Run(ctx, ah.OutputCh, sinks)
+	}
+
+	c.Ui.Output("==> Vault agent started! Log data will stream in below:\n")
+
+	select {
+	case <-c.ShutdownCh:
+		c.Ui.Output("==> Vault agent shutdown triggered")
+	case <-sinkDoneCh:
+	}
+
+	cancelFunc()
+	if ah != nil {
+		<-ah.DoneCh
+		<-ss.DoneCh
+	}
+
+	return 0
+}
+
+// newAgentAuthMethod returns the auth method described by the configuration
+func newAgentAuthMethod(logger log.Logger, m *agentConfig.Method) (auth.AuthMethod, error) {
+	authConfig := &auth.AuthConfig{
+		Logger:    logger,
+		MountPath: m.MountPath,
+		Config:    m.Config,
+	}
+
+	switch m.Type {
+	case "approle":
+		return approle.NewApproleAuthMethod(authConfig)
+	case "aws":
+		return aws.NewAWSAuthMethod(authConfig)
+	case "kubernetes":
+		return kubernetes.NewKubernetesAuthMethod(authConfig)
+	default:
+		return nil, fmt.Errorf("unknown auth method %q", m.Type)
+	}
+}
+
+// newAgentSink returns the sink described by the configuration
+func newAgentSink(logger log.Logger, sc *agentConfig.Sink) (*sink.SinkConfig, error) {
+	config := &sink.SinkConfig{
+		Logger:  logger,
+		Config:  sc.Config,
+		WrapTTL: sc.WrapTTL,
+		DHType:  sc.DHType,
+		DHPath:  sc.DHPath,
+		AAD:     sc.AAD,
+	}
+
+	var err error
+	switch sc.Type {
+	case "file":
+		config.Sink, err = file.NewFileSink(config)
+	default:
+		err = fmt.Errorf("unknown sink type %q", sc.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (c *AgentCommand) Synopsis() string {
+	return "Start a Vault agent"
+}
+
+func (c *AgentCommand) Help() string {
+	helpText := `
+Usage: vault agent [options]
+
+  Start a Vault agent.
+
+  The agent authenticates to Vault with the auth method in its configuration
+  file, writes the resulting token to each configured sink and keeps the
+  token renewed. When the token can no longer be renewed, the agent
+  authenticates again and writes the new token to the sinks.
+
+  Supported auth methods are "approle", "aws" and "kubernetes". Tokens can
+  be response-wrapped and encrypted before they are written to a sink.
+
+  With a cache block, the agent also serves the Vault API on its listeners,
+  proxying requests to Vault. Responses that carry a lease or a token are
+  cached and kept renewed, and identical requests are answered from the
+  cache.
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Agent Options:
+
+  -config=<path>          Path to the agent configuration file.
+
+  -log-level=info         Log verbosity. Defaults to "info", will be output to
+                          stderr. Supported values: "trace", "debug", "info",
+                          "warn", "err"
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AgentCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *AgentCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-config":    complete.PredictOr(complete.PredictFiles("*.hcl"), complete.PredictFiles("*.json")),
+		"-log-level": complete.PredictSet("trace", "debug", "info", "warn", "err"),
+	}
+}
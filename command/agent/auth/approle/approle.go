@@ -0,0 +1,135 @@
+package approle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+	"github.com/hashicorp/vault/helper/parseutil"
+	log "github.com/mgutz/logxi/v1"
+)
+
+type approleMethod struct {
+	logger    log.Logger
+	mountPath string
+
+	roleIDFilePath                 string
+	secretIDFilePath               string
+	removeSecretIDFileAfterReading bool
+
+	// The secret ID file may be removed after it is read, so the last values
+	// read are kept to authenticate again
+	l              sync.Mutex
+	cachedRoleID   string
+	cachedSecretID string
+}
+
+// NewApproleAuthMethod returns an auth method that logs in with a role ID and
+// a secret ID read from files
+func NewApproleAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	a := &approleMethod{
+		logger:                         conf.Logger,
+		mountPath:                      conf.MountPath,
+		removeSecretIDFileAfterReading: true,
+	}
+
+	roleIDFilePathRaw, ok := conf.Config["role_id_file_path"]
+	if !ok {
+		return nil, errors.New("missing 'role_id_file_path' value")
+	}
+	a.roleIDFilePath, ok = roleIDFilePathRaw.(string)
+	if !ok || a.roleIDFilePath == "" {
+		return nil, errors.New("could not convert 'role_id_file_path' config value to string")
+	}
+
+	if secretIDFilePathRaw, ok := conf.Config["secret_id_file_path"]; ok {
+		a.secretIDFilePath, ok = secretIDFilePathRaw.(string)
+		if !ok {
+			return nil, errors.New("could not convert 'secret_id_file_path' config value to string")
+		}
+	}
+
+	if removeRaw, ok := conf.Config["remove_secret_id_file_after_reading"]; ok {
+		remove, err := parseutil.ParseBool(removeRaw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'remove_secret_id_file_after_reading' value: %s", err)
+		}
+		a.removeSecretIDFileAfterReading = remove
+	}
+
+	return a, nil
+}
+
+func (a *approleMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	a.l.Lock()
+	defer a.l.Unlock()
+
+	roleID, err := readFile(a.roleIDFilePath)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading role ID file: %s", err)
+	}
+	if roleID != "" {
+		a.cachedRoleID = roleID
+	}
+	if a.cachedRoleID == "" {
+		return "", nil, errors.New("no known role ID")
+	}
+
+	data := map[string]interface{}{
+		"role_id": a.cachedRoleID,
+	}
+
+	if a.secretIDFilePath != "" {
+		secretID, err := readFile(a.secretIDFilePath)
+		if err != nil {
+			return "", nil, fmt.Errorf("error reading secret ID file: %s", err)
+		}
+		if secretID != "" {
+			a.cachedSecretID = secretID
+			if a.removeSecretIDFileAfterReading {
+				if err := os.Remove(a.secretIDFilePath); err != nil {
+					a.logger.Error("auth.approle: error removing secret ID file after reading", "error", err)
+				}
+			}
+		}
+		if a.cachedSecretID == "" {
+			return "", nil, errors.New("no known secret ID")
+		}
+		data["secret_id"] = a.cachedSecretID
+	}
+
+	return fmt.Sprintf("%s/login", a.mountPath), data, nil
+}
+
+func (a *approleMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (a *approleMethod) Shutdown() {
+}
+
+// readFile returns the trimmed contents of the file, or an empty string if
+// the file does not exist
+func readFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+const (
+	initialBackoff = 1 * time.Second
+	maxBackoff     = 5 * time.Minute
+)
+
+var errNoToken = errors.New("login response did not contain a token")
+
+// AuthMethod is the interface implemented by the auth methods the agent can
+// use to obtain a token
+type AuthMethod interface {
+	// Authenticate returns the path and the data of the login request
+	Authenticate(context.Context, *api.Client) (string, map[string]interface{}, error)
+
+	// NewCreds returns a channel that receives a value whenever the method
+	// has new credentials and the agent should authenticate again. It may
+	// return nil if the method never rotates its credentials.
+	NewCreds() chan struct{}
+
+	// Shutdown releases any resources held by the method
+	Shutdown()
+}
+
+// AuthConfig is the configuration passed to the factories of auth methods
+type AuthConfig struct {
+	Logger    log.Logger
+	MountPath string
+	Config    map[string]interface{}
+}
+
+// AuthHandler logs in with an auth method, hands the resulting token to
+// OutputCh and keeps it renewed, authenticating again whenever the token
+// can no longer be renewed or the method has new credentials.
+type AuthHandler struct {
+	DoneCh   chan struct{}
+	OutputCh chan string
+
+	logger log.Logger
+	client *api.Client
+}
+
+// AuthHandlerConfig is the configuration of an AuthHandler
+type AuthHandlerConfig struct {
+	Logger log.Logger
+	Client *api.Client
+}
+
+// NewAuthHandler returns a new AuthHandler
+func NewAuthHandler(conf *AuthHandlerConfig) *AuthHandler {
+	return &AuthHandler{
+		DoneCh:   make(chan struct{}),
+		OutputCh: make(chan string),
+		logger:   conf.Logger,
+		client:   conf.Client,
+	}
+}
+
+// Run runs the handler until the context is cancelled
+func (ah *AuthHandler) Run(ctx context.Context, am AuthMethod) {
+	defer close(ah.DoneCh)
+	defer am.Shutdown()
+
+	credCh := am.NewCreds()
+	if credCh == nil {
+		credCh = make(chan struct{})
+	}
+
+	backoff := initialBackoff
+	retry := func(msg string, err error) bool {
+		ah.logger.Error("auth.handler: "+msg, "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(jitter(backoff)):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// Log in without the previous token
+		client := ah.client
+		client.ClearToken()
+
+		path, data, err := am.Authenticate(ctx, client)
+		if err != nil {
+			if !retry("error getting path or data from method", err) {
+				return
+			}
+			continue
+		}
+
+		secret, err := client.Logical().Write(path, data)
+		if err == nil && (secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "") {
+			err = errNoToken
+		}
+		if err != nil {
+			if !retry("error authenticating", err) {
+				return
+			}
+			continue
+		}
+
+		backoff = initialBackoff
+		ah.logger.Info("auth.handler: authentication successful, sending token to sinks")
+
+		select {
+		case <-ctx.Done():
+			return
+		case ah.OutputCh <- secret.Auth.ClientToken:
+		}
+
+		if !secret.Auth.Renewable {
+			ah.logger.Info("auth.handler: token is not renewable, waiting for new credentials")
+			select {
+			case <-ctx.Done():
+				return
+			case <-credCh:
+				ah.logger.Info("auth.handler: auth method found new credentials, re-authenticating")
+			}
+			continue
+		}
+
+		renewer, err := client.NewRenewer(&api.RenewerInput{
+			Secret: secret,
+		})
+		if err != nil {
+			if !retry("error creating renewer", err) {
+				return
+			}
+			continue
+		}
+		go renewer.Renew()
+
+	RenewerLoop:
+		for {
+			select {
+			case <-ctx.Done():
+				renewer.Stop()
+				return
+
+			case <-credCh:
+				ah.logger.Info("auth.handler: auth method found new credentials, re-authenticating")
+				renewer.Stop()
+				break RenewerLoop
+
+			case err := <-renewer.DoneCh():
+				if err != nil {
+					ah.logger.Error("auth.handler: error renewing token", "error", err)
+				} else {
+					ah.logger.Info("auth.handler: token can no longer be renewed, re-authenticating")
+				}
+				break RenewerLoop
+
+			case <-renewer.RenewCh():
+				ah.logger.Info("auth.handler: renewed auth token")
+			}
+		}
+	}
+}
+
+// jitter returns a random duration between 75% and 100% of the given one
+func jitter(d time.Duration) time.Duration {
+	return d - time.Duration(rand.Int63n(int64(d)/4+1))
+}
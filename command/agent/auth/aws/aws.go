@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/api"
+	awsauth "github.com/hashicorp/vault/builtin/credential/aws"
+	"github.com/hashicorp/vault/command/agent/auth"
+	log "github.com/mgutz/logxi/v1"
+)
+
+const (
+	typeEC2 = "ec2"
+	typeIAM = "iam"
+)
+
+type awsMethod struct {
+	logger    log.Logger
+	authType  string
+	mountPath string
+	role      string
+
+	// iam
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	headerValue  string
+
+	// ec2; the nonce is generated once so that the agent can authenticate
+	// again from the same instance
+	nonce string
+}
+
+// NewAWSAuthMethod returns an auth method that logs in with either the IAM
+// credentials or the EC2 instance identity of the host the agent runs on
+func NewAWSAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	a := &awsMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+	}
+
+	getString := func(key string) (string, error) {
+		raw, ok := conf.Config[key]
+		if !ok {
+			return "", nil
+		}
+		v, ok := raw.(string)
+		if !ok {
+			return "", fmt.Errorf("could not convert '%s' config value to string", key)
+		}
+		return v, nil
+	}
+
+	var err error
+	if a.authType, err = getString("type"); err != nil {
+		return nil, err
+	}
+	switch a.authType {
+	case typeEC2, typeIAM:
+	case "":
+		return nil, errors.New("missing 'type' value")
+	default:
+		return nil, fmt.Errorf("invalid 'type' value %q, must be %q or %q", a.authType, typeEC2, typeIAM)
+	}
+
+	if a.role, err = getString("role"); err != nil {
+		return nil, err
+	}
+	if a.role == "" {
+		return nil, errors.New("missing 'role' value")
+	}
+
+	if a.accessKey, err = getString("access_key"); err != nil {
+		return nil, err
+	}
+	if a.secretKey, err = getString("secret_key"); err != nil {
+		return nil, err
+	}
+	if a.sessionToken, err = getString("session_token"); err != nil {
+		return nil, err
+	}
+	if a.headerValue, err = getString("header_value"); err != nil {
+		return nil, err
+	}
+
+	if a.authType == typeEC2 {
+		if a.nonce, err = uuid.GenerateUUID(); err != nil {
+			return nil, fmt.Errorf("error generating nonce: %s", err)
+		}
+	}
+
+	return a, nil
+}
+
+func (a *awsMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	var data map[string]interface{}
+
+	switch a.authType {
+	case typeEC2:
+		sess, err := session.NewSession()
+		if err != nil {
+			return "", nil, fmt.Errorf("error creating session to probe EC2 metadata: %s", err)
+		}
+		metadataSvc := ec2metadata.New(sess)
+		if !metadataSvc.Available() {
+			return "", nil, errors.New("session available, but metadata service is not")
+		}
+
+		pkcs7, err := metadataSvc.GetDynamicData("/instance-identity/pkcs7")
+		if err != nil {
+			return "", nil, fmt.Errorf("error fetching PKCS #7 signature: %s", err)
+		}
+
+		data = map[string]interface{}{
+			"pkcs7": strings.Replace(strings.TrimSpace(pkcs7), "\n", "", -1),
+			"nonce": a.nonce,
+		}
+
+	default:
+		var err error
+		data, err = awsauth.GenerateLoginData(a.accessKey, a.secretKey, a.sessionToken, a.headerValue)
+		if err != nil {
+			return "", nil, fmt.Errorf("error creating login data: %s", err)
+		}
+	}
+
+	data["role"] = a.role
+
+	return fmt.Sprintf("%s/login", a.mountPath), data, nil
+}
+
+func (a *awsMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (a *awsMethod) Shutdown() {
+}
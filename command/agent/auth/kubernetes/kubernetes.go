@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/command/agent/auth"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// serviceAccountFile is the path of the service account token mounted into
+// every pod by default
+const serviceAccountFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+type kubernetesMethod struct {
+	logger    log.Logger
+	mountPath string
+
+	role      string
+	tokenPath string
+}
+
+// NewKubernetesAuthMethod returns an auth method that logs in with the
+// service account token of the pod the agent runs in
+func NewKubernetesAuthMethod(conf *auth.AuthConfig) (auth.AuthMethod, error) {
+	if conf == nil {
+		return nil, errors.New("empty config")
+	}
+	if conf.Config == nil {
+		return nil, errors.New("empty config data")
+	}
+
+	k := &kubernetesMethod{
+		logger:    conf.Logger,
+		mountPath: conf.MountPath,
+		tokenPath: serviceAccountFile,
+	}
+
+	roleRaw, ok := conf.Config["role"]
+	if !ok {
+		return nil, errors.New("missing 'role' value")
+	}
+	k.role, ok = roleRaw.(string)
+	if !ok || k.role == "" {
+		return nil, errors.New("could not convert 'role' config value to string")
+	}
+
+	if tokenPathRaw, ok := conf.Config["token_path"]; ok {
+		k.tokenPath, ok = tokenPathRaw.(string)
+		if !ok || k.tokenPath == "" {
+			return nil, errors.New("could not convert 'token_path' config value to string")
+		}
+	}
+
+	return k, nil
+}
+
+func (k *kubernetesMethod) Authenticate(ctx context.Context, client *api.Client) (string, map[string]interface{}, error) {
+	// The token is read on every login since it may be rotated
+	jwt, err := ioutil.ReadFile(k.tokenPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("error reading service account token: %s", err)
+	}
+
+	return fmt.Sprintf("%s/login", k.mountPath), map[string]interface{}{
+		"role": k.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}, nil
+}
+
+func (k *kubernetesMethod) NewCreds() chan struct{} {
+	return nil
+}
+
+func (k *kubernetesMethod) Shutdown() {
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// skippedHeaders are the headers of incoming requests that are not passed on
+// to Vault, either because the token is sent separately or because they
+// only apply to the connection to the agent
+var skippedHeaders = map[string]struct{}{
+	"Accept-Encoding": struct{}{},
+	"Authorization":   struct{}{},
+	"Connection":      struct{}{},
+	"Content-Length":  struct{}{},
+	"X-Vault-Token":   struct{}{},
+}
+
+// APIProxy is a Proxier that sends requests to Vault with the API client
+type APIProxy struct {
+	client *api.Client
+	logger log.Logger
+}
+
+// APIProxyConfig is the configuration of an APIProxy
+type APIProxyConfig struct {
+	Client *api.Client
+	Logger log.Logger
+}
+
+// NewAPIProxy returns a new APIProxy
+func NewAPIProxy(config *APIProxyConfig) Proxier {
+	return &APIProxy{
+		client: config.Client,
+		logger: config.Logger,
+	}
+}
+
+func (ap *APIProxy) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	fwReq := ap.client.NewRequest(req.Request.Method, req.Request.URL.Path)
+	fwReq.ClientToken = req.Token
+	fwReq.WrapTTL = ""
+	fwReq.Params = req.Request.URL.Query()
+	fwReq.Body = bytes.NewReader(req.RequestBody)
+
+	if fwReq.Headers == nil {
+		fwReq.Headers = make(http.Header)
+	}
+	for k, v := range req.Request.Header {
+		if _, ok := skippedHeaders[http.CanonicalHeaderKey(k)]; ok {
+			continue
+		}
+		fwReq.Headers[k] = v
+	}
+
+	// Error responses from Vault are not an error of the proxy; they are
+	// passed on to the client
+	resp, err := ap.client.RawRequestWithContext(ctx, fwReq)
+	if resp == nil {
+		return nil, err
+	}
+
+	return newSendResponse(resp, nil)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// maxRequestSize is the maximum size of the body of a request to the agent
+const maxRequestSize = 32 * 1024 * 1024
+
+// HandlerConfig is the configuration of the handler of the agent listeners
+type HandlerConfig struct {
+	Logger log.Logger
+	Cache  *LeaseCache
+
+	// TokenFunc, if set, returns the token used for requests that do not
+	// carry one
+	TokenFunc func() string
+}
+
+// Handler returns the handler of the agent listeners. Requests to
+// /agent/v1/cache-clear clear the cache; every other request is proxied to
+// Vault through the cache.
+func Handler(conf *HandlerConfig) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/agent/v1/cache-clear", handleCacheClear(conf))
+	mux.Handle("/", handleProxy(conf))
+	return mux
+}
+
+func handleProxy(conf *HandlerConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := requestToken(r)
+		if token == "" && conf.TokenFunc != nil {
+			token = conf.TokenFunc()
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to read request body: %s", err))
+			return
+		}
+
+		resp, err := conf.Cache.Send(r.Context(), &SendRequest{
+			Token:       token,
+			Request:     r,
+			RequestBody: body,
+		})
+		if err != nil {
+			conf.Logger.Error("cache: error proxying request", "path", r.URL.Path, "error", err)
+			respondError(w, http.StatusBadGateway, fmt.Errorf("failed to get the response: %s", err))
+			return
+		}
+
+		for k, v := range resp.Response.Header {
+			if k == "Content-Length" {
+				continue
+			}
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.Response.StatusCode)
+		w.Write(resp.ResponseBody)
+	})
+}
+
+func handleCacheClear(conf *HandlerConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" && r.Method != "POST" {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		var req struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, fmt.Errorf("failed to parse JSON input: %s", err))
+			return
+		}
+
+		if _, err := conf.Cache.Clear(req.Type, req.Value); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// requestToken returns the token of the request, given either as the
+// X-Vault-Token header or as a bearer token
+func requestToken(r *http.Request) string {
+	if token := r.Header.Get("X-Vault-Token"); token != "" {
+		return token
+	}
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+
+	return ""
+}
+
+func respondError(w http.ResponseWriter, status int, err error) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	resp := &api.ErrorResponse{Errors: make([]string, 0, 1)}
+	if err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
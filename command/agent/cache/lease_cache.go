@@ -0,0 +1,384 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// LeaseCache is a Proxier that caches the responses that carry a lease or a
+// token, keeps them renewed and answers identical requests from the cache.
+// Concurrent identical requests are only sent to Vault once. A cached
+// response is evicted when its lease or token can no longer be renewed, when
+// it is revoked through the agent, or when the cache is cleared.
+type LeaseCache struct {
+	proxier Proxier
+	client  *api.Client
+	logger  log.Logger
+	baseCtx context.Context
+
+	l        sync.Mutex
+	entries  map[string]*cacheEntry
+	inflight map[string]chan struct{}
+}
+
+// LeaseCacheConfig is the configuration of a LeaseCache
+type LeaseCacheConfig struct {
+	// Proxier sends the requests that are not answered from the cache
+	Proxier Proxier
+
+	// Client is used to renew the cached leases and tokens
+	Client *api.Client
+
+	// BaseContext stops the renewal of every cached lease and token when it
+	// is cancelled
+	BaseContext context.Context
+
+	Logger log.Logger
+}
+
+type cacheEntry struct {
+	key string
+
+	// token is the token the request was made with
+	token string
+
+	// leaseID and authToken are the lease and the token returned in the
+	// response, if any
+	leaseID   string
+	authToken string
+
+	statusCode int
+	header     http.Header
+	body       []byte
+
+	cancel context.CancelFunc
+}
+
+// NewLeaseCache returns a new LeaseCache
+func NewLeaseCache(conf *LeaseCacheConfig) (*LeaseCache, error) {
+	if conf.Proxier == nil {
+		return nil, errors.New("nil proxier provided")
+	}
+	if conf.Client == nil {
+		return nil, errors.New("nil client provided")
+	}
+
+	baseCtx := conf.BaseContext
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+
+	return &LeaseCache{
+		proxier:  conf.Proxier,
+		client:   conf.Client,
+		logger:   conf.Logger,
+		baseCtx:  baseCtx,
+		entries:  make(map[string]*cacheEntry),
+		inflight: make(map[string]chan struct{}),
+	}, nil
+}
+
+// Send returns the cached response to the request if there is one, and
+// otherwise sends the request to Vault, caching the response if it carries a
+// lease or a token
+func (c *LeaseCache) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	key := computeKey(req)
+
+	for {
+		c.l.Lock()
+		if entry, ok := c.entries[key]; ok {
+			c.l.Unlock()
+			c.logger.Debug("cache: returning cached response", "path", req.Request.URL.Path)
+			return entry.sendResponse()
+		}
+
+		// Wait for an identical request in flight, then look again
+		doneCh, ok := c.inflight[key]
+		if !ok {
+			doneCh = make(chan struct{})
+			c.inflight[key] = doneCh
+			c.l.Unlock()
+			break
+		}
+		c.l.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-doneCh:
+		}
+	}
+
+	defer func() {
+		c.l.Lock()
+		close(c.inflight[key])
+		delete(c.inflight, key)
+		c.l.Unlock()
+	}()
+
+	resp, err := c.proxier.Send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Response.StatusCode >= 200 && resp.Response.StatusCode < 300 {
+		c.handleRevocation(req)
+	}
+
+	secret, err := parseCacheableSecret(resp)
+	if err != nil {
+		c.logger.Warn("cache: error parsing response, not caching it", "path", req.Request.URL.Path, "error", err)
+		return resp, nil
+	}
+	if secret == nil {
+		return resp, nil
+	}
+
+	entry := &cacheEntry{
+		key:        key,
+		token:      req.Token,
+		leaseID:    secret.LeaseID,
+		statusCode: resp.Response.StatusCode,
+		header:     resp.Response.Header,
+		body:       resp.ResponseBody,
+	}
+	if secret.Auth != nil {
+		entry.authToken = secret.Auth.ClientToken
+	}
+
+	renewCtx, cancel := context.WithCancel(c.baseCtx)
+	entry.cancel = cancel
+
+	c.l.Lock()
+	c.entries[key] = entry
+	c.l.Unlock()
+
+	c.logger.Debug("cache: caching response", "path", req.Request.URL.Path)
+	go c.refresh(renewCtx, entry, secret)
+
+	return resp, nil
+}
+
+// refresh keeps the lease or token of the entry renewed, evicting the entry
+// once it can no longer be renewed
+func (c *LeaseCache) refresh(ctx context.Context, entry *cacheEntry, secret *api.Secret) {
+	defer c.evict(entry)
+
+	renewable := secret.Renewable
+	leaseDuration := secret.LeaseDuration
+	if secret.Auth != nil {
+		renewable = secret.Auth.Renewable
+		leaseDuration = secret.Auth.LeaseDuration
+	}
+
+	if !renewable {
+		// Keep the response until it expires
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(leaseDuration) * time.Second):
+		}
+		return
+	}
+
+	client, err := c.client.Clone()
+	if err != nil {
+		c.logger.Error("cache: error creating client to renew cached response", "error", err)
+		return
+	}
+	client.SetToken(entry.token)
+
+	renewer, err := client.NewRenewer(&api.RenewerInput{
+		Secret: secret,
+	})
+	if err != nil {
+		c.logger.Error("cache: error creating renewer for cached response", "error", err)
+		return
+	}
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-renewer.DoneCh():
+			if err != nil {
+				c.logger.Debug("cache: error renewing cached response, evicting it", "error", err)
+			}
+			return
+		case <-renewer.RenewCh():
+			c.logger.Trace("cache: renewed cached response")
+		}
+	}
+}
+
+// evict removes the entry from the cache if it is still there
+func (c *LeaseCache) evict(entry *cacheEntry) {
+	c.l.Lock()
+	if c.entries[entry.key] == entry {
+		delete(c.entries, entry.key)
+	}
+	c.l.Unlock()
+
+	entry.cancel()
+}
+
+// evictMatching evicts the entries the function returns true for
+func (c *LeaseCache) evictMatching(f func(*cacheEntry) bool) int {
+	var matched []*cacheEntry
+
+	c.l.Lock()
+	for key, entry := range c.entries {
+		if f(entry) {
+			delete(c.entries, key)
+			matched = append(matched, entry)
+		}
+	}
+	c.l.Unlock()
+
+	for _, entry := range matched {
+		entry.cancel()
+	}
+	return len(matched)
+}
+
+// handleRevocation evicts the entries made obsolete by a successful request
+// revoking a lease or a token
+func (c *LeaseCache) handleRevocation(req *SendRequest) {
+	path := strings.TrimPrefix(req.Request.URL.Path, "/v1/")
+
+	switch {
+	case path == "auth/token/revoke-self":
+		c.evictToken(req.Token)
+
+	case path == "auth/token/revoke":
+		var data struct {
+			Token string `json:"token"`
+		}
+		if err := jsonutil.DecodeJSON(req.RequestBody, &data); err == nil && data.Token != "" {
+			c.evictToken(data.Token)
+		}
+
+	case path == "sys/leases/revoke":
+		var data struct {
+			LeaseID string `json:"lease_id"`
+		}
+		if err := jsonutil.DecodeJSON(req.RequestBody, &data); err == nil && data.LeaseID != "" {
+			c.evictLease(data.LeaseID)
+		}
+
+	case strings.HasPrefix(path, "sys/leases/revoke/"):
+		c.evictLease(strings.TrimPrefix(path, "sys/leases/revoke/"))
+
+	case strings.HasPrefix(path, "sys/revoke/"):
+		c.evictLease(strings.TrimPrefix(path, "sys/revoke/"))
+	}
+}
+
+// evictToken evicts the response that returned the token and every response
+// to a request made with it
+func (c *LeaseCache) evictToken(token string) int {
+	return c.evictMatching(func(e *cacheEntry) bool {
+		return e.token == token || e.authToken == token
+	})
+}
+
+// evictLease evicts the response that returned the lease
+func (c *LeaseCache) evictLease(leaseID string) int {
+	return c.evictMatching(func(e *cacheEntry) bool {
+		return e.leaseID == leaseID
+	})
+}
+
+// Clear evicts entries from the cache. The type is one of "all", "lease" or
+// "token", in which case value is the lease ID or the token.
+func (c *LeaseCache) Clear(clearType, value string) (int, error) {
+	switch clearType {
+	case "all":
+		return c.evictMatching(func(*cacheEntry) bool { return true }), nil
+	case "lease":
+		if value == "" {
+			return 0, errors.New("missing lease ID")
+		}
+		return c.evictLease(value), nil
+	case "token":
+		if value == "" {
+			return 0, errors.New("missing token")
+		}
+		return c.evictToken(value), nil
+	default:
+		return 0, errors.New("type must be one of \"all\", \"lease\" or \"token\"")
+	}
+}
+
+// sendResponse returns a copy of the cached response
+func (e *cacheEntry) sendResponse() (*SendResponse, error) {
+	header := make(http.Header, len(e.header))
+	for k, v := range e.header {
+		header[k] = v
+	}
+
+	return newSendResponse(&api.Response{
+		Response: &http.Response{
+			StatusCode: e.statusCode,
+			Header:     header,
+			Body:       ioutil.NopCloser(bytes.NewReader(e.body)),
+		},
+	}, e.body)
+}
+
+// parseCacheableSecret returns the secret in the response if it carries a
+// lease or a token, and nil otherwise
+func parseCacheableSecret(resp *SendResponse) (*api.Secret, error) {
+	if resp.Response.StatusCode != http.StatusOK || len(resp.ResponseBody) == 0 {
+		return nil, nil
+	}
+
+	secret, err := api.ParseSecret(bytes.NewReader(resp.ResponseBody))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case secret == nil, secret.WrapInfo != nil:
+		return nil, nil
+	case secret.LeaseID != "":
+		return secret, nil
+	case secret.Auth != nil && secret.Auth.ClientToken != "":
+		return secret, nil
+	default:
+		return nil, nil
+	}
+}
+
+// computeKey returns the key of a request in the cache. Requests are only
+// identical if they are made with the same token.
+func computeKey(req *SendRequest) string {
+	h := sha256.New()
+	h.Write([]byte(req.Request.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.Header.Get("X-Vault-Namespace")))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Request.Header.Get("X-Vault-Wrap-TTL")))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Token))
+	h.Write([]byte{0})
+	h.Write(req.RequestBody)
+	return hex.EncodeToString(h.Sum(nil))
+}
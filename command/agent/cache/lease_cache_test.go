@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// mockProxier returns a response with a new lease ID for every request to a
+// path under "leased/", and a response without a lease otherwise
+type mockProxier struct {
+	count int32
+	delay time.Duration
+}
+
+func (p *mockProxier) Send(ctx context.Context, req *SendRequest) (*SendResponse, error) {
+	n := atomic.AddInt32(&p.count, 1)
+	time.Sleep(p.delay)
+
+	var body string
+	if strings.HasPrefix(req.Request.URL.Path, "/v1/leased/") {
+		body = fmt.Sprintf(`{"lease_id": "leased/foo/%d", "lease_duration": 3600, "renewable": false, "data": {"value": "bar"}}`, n)
+	} else {
+		body = fmt.Sprintf(`{"data": {"count": %d}}`, n)
+	}
+
+	return newSendResponse(&api.Response{
+		Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+		},
+	}, []byte(body))
+}
+
+func testLeaseCache(t *testing.T, proxier Proxier) *LeaseCache {
+	client, err := api.NewClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lc, err := NewLeaseCache(&LeaseCacheConfig{
+		Proxier: proxier,
+		Client:  client,
+		Logger:  logformat.NewVaultLogger(log.LevelTrace),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lc
+}
+
+func testSend(t *testing.T, lc *LeaseCache, method, path, token string) string {
+	resp, err := lc.Send(context.Background(), &SendRequest{
+		Token:   token,
+		Request: httptest.NewRequest(method, path, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(resp.ResponseBody)
+}
+
+func TestLeaseCache_cachesLeases(t *testing.T) {
+	proxier := &mockProxier{}
+	lc := testLeaseCache(t, proxier)
+
+	first := testSend(t, lc, "GET", "/v1/leased/foo", "token1")
+	if second := testSend(t, lc, "GET", "/v1/leased/foo", "token1"); second != first {
+		t.Fatalf("expected cached response, got:\n%s\n%s", first, second)
+	}
+
+	// Requests made with another token are not answered from the cache
+	if other := testSend(t, lc, "GET", "/v1/leased/foo", "token2"); other == first {
+		t.Fatal("expected a new response for another token")
+	}
+
+	// Responses without a lease are not cached
+	first = testSend(t, lc, "GET", "/v1/secret/foo", "token1")
+	if second := testSend(t, lc, "GET", "/v1/secret/foo", "token1"); second == first {
+		t.Fatal("expected response without a lease not to be cached")
+	}
+
+	if proxier.count != 4 {
+		t.Fatalf("bad: %d requests sent", proxier.count)
+	}
+}
+
+func TestLeaseCache_deduplicates(t *testing.T) {
+	proxier := &mockProxier{delay: 100 * time.Millisecond}
+	lc := testLeaseCache(t, proxier)
+
+	var wg sync.WaitGroup
+	responses := make([]string, 10)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = testSend(t, lc, "GET", "/v1/leased/foo", "token1")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, resp := range responses {
+		if resp != responses[0] {
+			t.Fatalf("bad: %#v", responses)
+		}
+	}
+	if proxier.count != 1 {
+		t.Fatalf("bad: %d requests sent", proxier.count)
+	}
+}
+
+func TestLeaseCache_evictions(t *testing.T) {
+	proxier := &mockProxier{}
+	lc := testLeaseCache(t, proxier)
+
+	first := testSend(t, lc, "GET", "/v1/leased/foo", "token1")
+
+	// Revoking the lease through the agent evicts it
+	testSend(t, lc, "PUT", "/v1/sys/leases/revoke/leased/foo/1", "token1")
+	second := testSend(t, lc, "GET", "/v1/leased/foo", "token1")
+	if second == first {
+		t.Fatal("expected revoked lease to be evicted")
+	}
+
+	// Revoking the token evicts the responses to its requests
+	testSend(t, lc, "PUT", "/v1/auth/token/revoke-self", "token1")
+	third := testSend(t, lc, "GET", "/v1/leased/foo", "token1")
+	if third == second {
+		t.Fatal("expected responses to requests of a revoked token to be evicted")
+	}
+
+	// Clearing the cache evicts everything
+	if n, err := lc.Clear("all", ""); err != nil || n != 1 {
+		t.Fatalf("bad: %d, %v", n, err)
+	}
+	if fourth := testSend(t, lc, "GET", "/v1/leased/foo", "token1"); fourth == third {
+		t.Fatal("expected cleared response to be evicted")
+	}
+
+	if _, err := lc.Clear("foo", ""); err == nil {
+		t.Fatal("expected error clearing an invalid type")
+	}
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// SendRequest is a request received by the agent that is to be sent to
+// Vault
+type SendRequest struct {
+	Token       string
+	Request     *http.Request
+	RequestBody []byte
+}
+
+// SendResponse is the response of Vault to a SendRequest, with its body read
+// into memory
+type SendResponse struct {
+	Response     *api.Response
+	ResponseBody []byte
+}
+
+// Proxier sends requests to Vault on behalf of the clients of the agent
+type Proxier interface {
+	Send(context.Context, *SendRequest) (*SendResponse, error)
+}
+
+// newSendResponse builds a SendResponse out of a response, reading its body
+// into memory. Error responses are returned as is so that they can be
+// passed on to the client.
+func newSendResponse(resp *api.Response, body []byte) (*SendResponse, error) {
+	if body == nil && resp.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return &SendResponse{
+		Response:     resp,
+		ResponseBody: body,
+	}, nil
+}
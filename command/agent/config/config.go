@@ -0,0 +1,352 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+// Config is the configuration for the vault agent
+type Config struct {
+	AutoAuth      *AutoAuth   `hcl:"-"`
+	ExitAfterAuth bool        `hcl:"exit_after_auth"`
+	PidFile       string      `hcl:"pid_file"`
+	Cache         *Cache      `hcl:"-"`
+	Listeners     []*Listener `hcl:"-"`
+}
+
+// Cache enables the caching proxy of the agent, which serves requests on the
+// listeners
+type Cache struct {
+	UseAutoAuthToken bool `hcl:"use_auto_auth_token"`
+}
+
+// Listener is a listener the caching proxy serves requests on
+type Listener struct {
+	Type   string
+	Config map[string]interface{}
+}
+
+// AutoAuth holds the auth method the agent authenticates with and the sinks
+// the resulting token is written to
+type AutoAuth struct {
+	Method *Method `hcl:"-"`
+	Sinks  []*Sink `hcl:"-"`
+}
+
+// Method is the auth method used by the agent
+type Method struct {
+	Type      string                 `hcl:"-"`
+	MountPath string                 `hcl:"mount_path"`
+	Config    map[string]interface{} `hcl:"config"`
+}
+
+// Sink is a destination for the token obtained by the agent
+type Sink struct {
+	Type       string                 `hcl:"-"`
+	WrapTTLRaw interface{}            `hcl:"wrap_ttl"`
+	WrapTTL    time.Duration          `hcl:"-"`
+	DHType     string                 `hcl:"dh_type"`
+	DHPath     string                 `hcl:"dh_path"`
+	AAD        string                 `hcl:"aad"`
+	Config     map[string]interface{} `hcl:"config"`
+}
+
+// LoadConfig loads the configuration at the given path
+func LoadConfig(path string) (*Config, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(string(d))
+}
+
+// ParseConfig parses the given HCL configuration
+func ParseConfig(d string) (*Config, error) {
+	obj, err := hcl.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Config
+	if err := hcl.DecodeObject(&result, obj); err != nil {
+		return nil, err
+	}
+
+	list, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
+	}
+
+	valid := []string{
+		"auto_auth",
+		"cache",
+		"exit_after_auth",
+		"listener",
+		"pid_file",
+	}
+	if err := checkHCLKeys(list, valid); err != nil {
+		return nil, err
+	}
+
+	if o := list.Filter("auto_auth"); len(o.Items) > 0 {
+		if err := parseAutoAuth(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'auto_auth': %s", err)
+		}
+	}
+
+	if o := list.Filter("cache"); len(o.Items) > 0 {
+		if err := parseCache(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'cache': %s", err)
+		}
+	}
+
+	if o := list.Filter("listener"); len(o.Items) > 0 {
+		if err := parseListeners(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'listener': %s", err)
+		}
+	}
+
+	if result.Cache != nil {
+		if len(result.Listeners) == 0 {
+			return nil, fmt.Errorf("at least one 'listener' block is required when caching is enabled")
+		}
+		if result.Cache.UseAutoAuthToken && result.AutoAuth == nil {
+			return nil, fmt.Errorf("'use_auto_auth_token' requires an 'auto_auth' block")
+		}
+	} else if len(result.Listeners) > 0 {
+		return nil, fmt.Errorf("'listener' blocks require a 'cache' block")
+	}
+
+	return &result, nil
+}
+
+func parseCache(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'cache' block is permitted")
+	}
+
+	// Get our one item
+	item := list.Items[0]
+
+	valid := []string{
+		"use_auto_auth_token",
+	}
+	if err := checkHCLKeys(item.Val, valid); err != nil {
+		return multierror.Prefix(err, "cache:")
+	}
+
+	var c Cache
+	if err := hcl.DecodeObject(&c, item.Val); err != nil {
+		return multierror.Prefix(err, "cache:")
+	}
+
+	result.Cache = &c
+	return nil
+}
+
+func parseListeners(result *Config, list *ast.ObjectList) error {
+	listeners := make([]*Listener, 0, len(list.Items))
+	for _, item := range list.Items {
+		key := "listener"
+		if len(item.Keys) > 0 {
+			key = item.Keys[0].Token.Value().(string)
+		}
+
+		valid := []string{
+			"address",
+			"socket_mode",
+			"socket_user",
+			"socket_group",
+			"tls_disable",
+			"tls_cert_file",
+			"tls_key_file",
+			"tls_min_version",
+			"tls_cipher_suites",
+			"tls_prefer_server_cipher_suites",
+			"tls_require_and_verify_client_cert",
+			"tls_client_ca_file",
+		}
+		if err := checkHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("listeners.%s:", key))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("listeners.%s:", key))
+		}
+
+		listeners = append(listeners, &Listener{
+			Type:   strings.ToLower(key),
+			Config: m,
+		})
+	}
+
+	result.Listeners = listeners
+	return nil
+}
+
+func parseAutoAuth(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'auto_auth' block is permitted")
+	}
+
+	// Get our one item
+	item := list.Items[0]
+
+	valid := []string{
+		"method",
+		"sink",
+	}
+	if err := checkHCLKeys(item.Val, valid); err != nil {
+		return err
+	}
+
+	var subList *ast.ObjectList
+	if ot, ok := item.Val.(*ast.ObjectType); ok {
+		subList = ot.List
+	} else {
+		return fmt.Errorf("could not parse 'auto_auth' as an object")
+	}
+
+	result.AutoAuth = &AutoAuth{}
+
+	if err := parseMethod(result, subList.Filter("method")); err != nil {
+		return fmt.Errorf("error parsing 'method': %s", err)
+	}
+
+	if err := parseSinks(result, subList.Filter("sink")); err != nil {
+		return fmt.Errorf("error parsing 'sink': %s", err)
+	}
+
+	return nil
+}
+
+func parseMethod(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) != 1 {
+		return fmt.Errorf("exactly one 'method' block is required")
+	}
+
+	// Get our one item
+	item := list.Items[0]
+	if len(item.Keys) == 0 {
+		return fmt.Errorf("'method' block must have a type")
+	}
+	key := item.Keys[0].Token.Value().(string)
+
+	valid := []string{
+		"mount_path",
+		"config",
+	}
+	if err := checkHCLKeys(item.Val, valid); err != nil {
+		return multierror.Prefix(err, fmt.Sprintf("method.%s:", key))
+	}
+
+	var m Method
+	if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		return multierror.Prefix(err, fmt.Sprintf("method.%s:", key))
+	}
+
+	m.Type = strings.ToLower(key)
+	if m.MountPath == "" {
+		m.MountPath = "auth/" + m.Type
+	}
+	m.MountPath = strings.TrimSuffix(m.MountPath, "/")
+
+	result.AutoAuth.Method = &m
+	return nil
+}
+
+func parseSinks(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) == 0 {
+		return fmt.Errorf("at least one 'sink' block is required")
+	}
+
+	sinks := make([]*Sink, 0, len(list.Items))
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			return fmt.Errorf("'sink' block must have a type")
+		}
+		key := item.Keys[0].Token.Value().(string)
+
+		valid := []string{
+			"wrap_ttl",
+			"dh_type",
+			"dh_path",
+			"aad",
+			"config",
+		}
+		if err := checkHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("sink.%s:", key))
+		}
+
+		var s Sink
+		if err := hcl.DecodeObject(&s, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("sink.%s:", key))
+		}
+
+		s.Type = strings.ToLower(key)
+
+		if s.WrapTTLRaw != nil {
+			var err error
+			if s.WrapTTL, err = parseutil.ParseDurationSecond(s.WrapTTLRaw); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("sink.%s:", key))
+			}
+		}
+
+		switch s.DHType {
+		case "":
+			if s.DHPath != "" {
+				return fmt.Errorf("sink.%s: 'dh_path' specified but 'dh_type' is empty", key)
+			}
+			if s.AAD != "" {
+				return fmt.Errorf("sink.%s: 'aad' specified but 'dh_type' is empty", key)
+			}
+		case "curve25519":
+			if s.DHPath == "" {
+				return fmt.Errorf("sink.%s: 'dh_type' specified but 'dh_path' is empty", key)
+			}
+		default:
+			return fmt.Errorf("sink.%s: invalid 'dh_type' %q", key, s.DHType)
+		}
+
+		sinks = append(sinks, &s)
+	}
+
+	result.AutoAuth.Sinks = sinks
+	return nil
+}
+
+func checkHCLKeys(node ast.Node, valid []string) error {
+	var list *ast.ObjectList
+	switch n := node.(type) {
+	case *ast.ObjectList:
+		list = n
+	case *ast.ObjectType:
+		list = n.List
+	default:
+		return fmt.Errorf("cannot check HCL keys of type %T", n)
+	}
+
+	validMap := make(map[string]struct{}, len(valid))
+	for _, v := range valid {
+		validMap[v] = struct{}{}
+	}
+
+	var result error
+	for _, item := range list.Items {
+		key := item.Keys[0].Token.Value().(string)
+		if _, ok := validMap[key]; !ok {
+			result = multierror.Append(result, fmt.Errorf(
+				"invalid key '%s' on line %d", key, item.Assign.Line))
+		}
+	}
+
+	return result
+}
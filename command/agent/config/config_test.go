@@ -0,0 +1,169 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "aws",
+				MountPath: "auth/aws",
+				Config: map[string]interface{}{
+					"type": "iam",
+					"role": "foobar",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-foo",
+					},
+				},
+				&Sink{
+					Type:       "file",
+					WrapTTLRaw: "5m",
+					WrapTTL:    5 * time.Minute,
+					DHType:     "curve25519",
+					DHPath:     "/tmp/file-foo-dhpath",
+					AAD:        "foobar",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-bar",
+					},
+				},
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
+	}
+}
+
+func TestLoadConfigFile_cache(t *testing.T) {
+	config, err := LoadConfig("./test-fixtures/config-cache.hcl")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Config{
+		AutoAuth: &AutoAuth{
+			Method: &Method{
+				Type:      "approle",
+				MountPath: "auth/approle",
+				Config: map[string]interface{}{
+					"role_id_file_path": "/tmp/role-id",
+				},
+			},
+			Sinks: []*Sink{
+				&Sink{
+					Type: "file",
+					Config: map[string]interface{}{
+						"path": "/tmp/file-foo",
+					},
+				},
+			},
+		},
+		Cache: &Cache{
+			UseAutoAuthToken: true,
+		},
+		Listeners: []*Listener{
+			&Listener{
+				Type: "unix",
+				Config: map[string]interface{}{
+					"address":     "/path/to/socket",
+					"tls_disable": true,
+				},
+			},
+			&Listener{
+				Type: "tcp",
+				Config: map[string]interface{}{
+					"address":     "127.0.0.1:8300",
+					"tls_disable": true,
+				},
+			},
+		},
+		PidFile: "./pidfile",
+	}
+
+	if !reflect.DeepEqual(config, expected) {
+		t.Fatalf("expected \n\n%#v\n\n to be \n\n%#v\n\n", config, expected)
+	}
+}
+
+func TestParseConfig_errors(t *testing.T) {
+	cases := map[string]string{
+		"no method": `
+auto_auth {
+	sink "file" {
+		config = { path = "/tmp/foo" }
+	}
+}`,
+		"no sink": `
+auto_auth {
+	method "approle" {
+		config = { role_id_file_path = "/tmp/role" }
+	}
+}`,
+		"dh path without type": `
+auto_auth {
+	method "approle" {}
+	sink "file" {
+		dh_path = "/tmp/dh"
+		config = { path = "/tmp/foo" }
+	}
+}`,
+		"cache without listener": `
+cache {}`,
+		"listener without cache": `
+listener "tcp" {
+	address = "127.0.0.1:8300"
+}`,
+		"auto auth token without auto auth": `
+cache {
+	use_auto_auth_token = true
+}
+listener "tcp" {
+	address = "127.0.0.1:8300"
+}`,
+		"invalid key": `
+auto_auth {
+	method "approle" {
+		foo = "bar"
+	}
+	sink "file" {}
+}`,
+	}
+
+	for name, raw := range cases {
+		if _, err := ParseConfig(raw); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseConfig_defaultMountPath(t *testing.T) {
+	config, err := ParseConfig(`
+auto_auth {
+	method "approle" {}
+	sink "file" {
+		config = { path = "/tmp/foo" }
+	}
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.AutoAuth.Method.MountPath != "auth/approle" {
+		t.Fatalf("bad: %s", config.AutoAuth.Method.MountPath)
+	}
+}
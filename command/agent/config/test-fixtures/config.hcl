@@ -0,0 +1,27 @@
+pid_file = "./pidfile"
+
+auto_auth {
+	method "aws" {
+		mount_path = "auth/aws"
+		config = {
+			type = "iam"
+			role = "foobar"
+		}
+	}
+
+	sink "file" {
+		config = {
+			path = "/tmp/file-foo"
+		}
+	}
+
+	sink "file" {
+		wrap_ttl = "5m"
+		dh_type = "curve25519"
+		dh_path = "/tmp/file-foo-dhpath"
+		aad = "foobar"
+		config = {
+			path = "/tmp/file-bar"
+		}
+	}
+}
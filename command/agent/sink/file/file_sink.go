@@ -0,0 +1,91 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hashicorp/vault/command/agent/sink"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// defaultMode is the mode of the token file unless configured otherwise
+const defaultMode os.FileMode = 0640
+
+// fileSink writes tokens to a file
+type fileSink struct {
+	logger log.Logger
+	path   string
+	mode   os.FileMode
+}
+
+// NewFileSink returns a sink that writes tokens to the file at the configured
+// path, replacing its contents atomically on every write
+func NewFileSink(conf *sink.SinkConfig) (sink.Sink, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+
+	f := &fileSink{
+		logger: conf.Logger,
+		mode:   defaultMode,
+	}
+
+	pathRaw, ok := conf.Config["path"]
+	if !ok {
+		return nil, errors.New("'path' not specified for file sink")
+	}
+	f.path, ok = pathRaw.(string)
+	if !ok || f.path == "" {
+		return nil, errors.New("could not parse 'path' as string")
+	}
+
+	if modeRaw, ok := conf.Config["mode"]; ok {
+		modeStr, ok := modeRaw.(string)
+		if !ok {
+			return nil, errors.New("could not parse 'mode' as string")
+		}
+		mode, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse 'mode': %s", err)
+		}
+		f.mode = os.FileMode(mode)
+	}
+
+	return f, nil
+}
+
+// WriteToken writes the token to a temporary file next to the configured
+// path and renames it over the path, so that readers never see a partially
+// written token
+func (f *fileSink) WriteToken(token string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp.")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %s", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.WriteString(token); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("error writing token to temporary file: %s", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error closing temporary file: %s", err)
+	}
+	if err := os.Chmod(tmpPath, f.mode); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error setting mode of temporary file: %s", err)
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error moving temporary file to %q: %s", f.path, err)
+	}
+
+	f.logger.Info("sink.file: token written", "path", f.path)
+	return nil
+}
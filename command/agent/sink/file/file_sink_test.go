@@ -0,0 +1,72 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/vault/command/agent/sink"
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault-agent-file-sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	fs, err := NewFileSink(&sink.SinkConfig{
+		Logger: logformat.NewVaultLogger(log.LevelTrace),
+		Config: map[string]interface{}{
+			"path": path,
+			"mode": "0600",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"foo", "bar"} {
+		if err := fs.WriteToken(token); err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != token {
+			t.Fatalf("bad: %s", raw)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("bad: %v", info.Mode())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected only the token file, got %d files", len(files))
+	}
+}
+
+func TestFileSink_noPath(t *testing.T) {
+	_, err := NewFileSink(&sink.SinkConfig{
+		Logger: logformat.NewVaultLogger(log.LevelTrace),
+		Config: map[string]interface{}{},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
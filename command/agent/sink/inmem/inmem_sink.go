@@ -0,0 +1,39 @@
+package inmem
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/hashicorp/vault/command/agent/sink"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// inmemSink keeps the latest token in memory, so that the agent itself can
+// use it
+type inmemSink struct {
+	logger log.Logger
+	token  atomic.Value
+}
+
+// New returns a sink that keeps the latest token in memory
+func New(conf *sink.SinkConfig) (sink.SinkReader, error) {
+	if conf.Logger == nil {
+		return nil, errors.New("nil logger provided")
+	}
+
+	s := &inmemSink{
+		logger: conf.Logger,
+	}
+	s.token.Store("")
+
+	return s, nil
+}
+
+func (s *inmemSink) WriteToken(token string) error {
+	s.token.Store(token)
+	return nil
+}
+
+func (s *inmemSink) Token() string {
+	return s.token.Load().(string)
+}
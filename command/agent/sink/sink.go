@@ -0,0 +1,220 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/dhutil"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// retryInterval is how long the server waits before writing a token again
+// to the sinks that failed
+const retryInterval = 5 * time.Second
+
+// Sink is a destination the agent writes tokens to
+type Sink interface {
+	WriteToken(string) error
+}
+
+// SinkReader is a Sink that the latest token can be read back from
+type SinkReader interface {
+	Sink
+	Token() string
+}
+
+// SinkConfig is the configuration of a sink. The token is response-wrapped
+// when WrapTTL is set and encrypted with a key derived from the public key at
+// DHPath when DHType is set, in that order, before being written.
+type SinkConfig struct {
+	Sink
+	Logger  log.Logger
+	Config  map[string]interface{}
+	Client  *api.Client
+	WrapTTL time.Duration
+	DHType  string
+	DHPath  string
+	AAD     string
+}
+
+// SinkServer writes the tokens it receives to its sinks
+type SinkServer struct {
+	DoneCh chan struct{}
+
+	logger        log.Logger
+	client        *api.Client
+	exitAfterAuth bool
+}
+
+// SinkServerConfig is the configuration of a SinkServer
+type SinkServerConfig struct {
+	Logger        log.Logger
+	Client        *api.Client
+	ExitAfterAuth bool
+}
+
+// NewSinkServer returns a new SinkServer
+func NewSinkServer(conf *SinkServerConfig) *SinkServer {
+	return &SinkServer{
+		DoneCh:        make(chan struct{}),
+		logger:        conf.Logger,
+		client:        conf.Client,
+		exitAfterAuth: conf.ExitAfterAuth,
+	}
+}
+
+// Run writes every token received on incoming to all sinks until the context
+// is cancelled. Writes that fail are retried until they succeed or a new
+// token is received. If the server was configured to exit after auth, Run
+// returns once the first token has been written to every sink.
+func (ss *SinkServer) Run(ctx context.Context, incoming chan string, sinks []*SinkConfig) {
+	defer close(ss.DoneCh)
+
+	if incoming == nil {
+		ss.logger.Error("sink.server: incoming channel is nil")
+		return
+	}
+
+	var token string
+	var pending []*SinkConfig
+	var retryCh <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case token = <-incoming:
+			ss.logger.Info("sink.server: received new token")
+			pending = sinks
+
+		case <-retryCh:
+		}
+
+		var failed []*SinkConfig
+		for _, sc := range pending {
+			if err := ss.writeToken(sc, token); err != nil {
+				ss.logger.Error("sink.server: error writing token to sink", "error", err)
+				failed = append(failed, sc)
+			}
+		}
+		pending = failed
+
+		if len(pending) > 0 {
+			retryCh = time.After(retryInterval)
+			continue
+		}
+		retryCh = nil
+
+		if ss.exitAfterAuth {
+			ss.logger.Info("sink.server: token written to all sinks, exiting")
+			return
+		}
+	}
+}
+
+func (ss *SinkServer) writeToken(sc *SinkConfig, token string) error {
+	var err error
+
+	if sc.WrapTTL > 0 {
+		if token, err = ss.wrapToken(sc, token); err != nil {
+			return err
+		}
+	}
+
+	if sc.DHType != "" {
+		if token, err = encryptToken(sc, token); err != nil {
+			return err
+		}
+	}
+
+	return sc.WriteToken(token)
+}
+
+// wrapToken returns the JSON-encoded wrapping information of a response
+// wrapping the token
+func (ss *SinkServer) wrapToken(sc *SinkConfig, token string) (string, error) {
+	client := sc.Client
+	if client == nil {
+		client = ss.client
+	}
+	if client == nil {
+		return "", errors.New("no client available to wrap the token")
+	}
+
+	wrapTTL := sc.WrapTTL.String()
+	client.SetToken(token)
+	client.SetWrappingLookupFunc(func(string, string) string {
+		return wrapTTL
+	})
+	defer client.SetWrappingLookupFunc(nil)
+	defer client.ClearToken()
+
+	secret, err := client.Logical().Write("sys/wrapping/wrap", map[string]interface{}{
+		"token": token,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error wrapping token: %s", err)
+	}
+	if secret == nil || secret.WrapInfo == nil {
+		return "", errors.New("nil wrap info returned when wrapping token")
+	}
+
+	m, err := json.Marshal(secret.WrapInfo)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling token wrap info: %s", err)
+	}
+
+	return string(m), nil
+}
+
+// encryptToken returns the JSON-encoded envelope of the token encrypted with
+// a key shared with the owner of the public key at the sink's DH path
+func encryptToken(sc *SinkConfig, token string) (string, error) {
+	switch sc.DHType {
+	case "curve25519":
+	default:
+		return "", fmt.Errorf("unsupported DH type %q", sc.DHType)
+	}
+
+	raw, err := ioutil.ReadFile(sc.DHPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading DH public key file: %s", err)
+	}
+
+	var pkInfo dhutil.PublicKeyInfo
+	if err := json.Unmarshal(raw, &pkInfo); err != nil {
+		return "", fmt.Errorf("error decoding DH public key file: %s", err)
+	}
+
+	public, private, err := dhutil.GeneratePublicPrivateKey()
+	if err != nil {
+		return "", fmt.Errorf("error generating DH key pair: %s", err)
+	}
+
+	key, err := dhutil.GenerateSharedKey(private, pkInfo.Curve25519PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("error deriving shared key: %s", err)
+	}
+
+	ciphertext, nonce, err := dhutil.EncryptAES(key, []byte(token), []byte(sc.AAD))
+	if err != nil {
+		return "", fmt.Errorf("error encrypting token: %s", err)
+	}
+
+	m, err := json.Marshal(&dhutil.Envelope{
+		Curve25519PublicKey: public,
+		Nonce:               nonce,
+		EncryptedPayload:    ciphertext,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error marshaling encrypted token: %s", err)
+	}
+
+	return string(m), nil
+}
@@ -0,0 +1,104 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/dhutil"
+	"github.com/hashicorp/vault/helper/logformat"
+	log "github.com/mgutz/logxi/v1"
+)
+
+type testSink struct {
+	failures int
+	tokens   []string
+}
+
+func (s *testSink) WriteToken(token string) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("failed")
+	}
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+func TestSinkServer_exitAfterAuth(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	ss := NewSinkServer(&SinkServerConfig{
+		Logger:        logger,
+		ExitAfterAuth: true,
+	})
+
+	s1 := &testSink{}
+	s2 := &testSink{failures: 1}
+	incoming := make(chan string)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ss.Run(ctx, incoming, []*SinkConfig{
+		&SinkConfig{Sink: s1, Logger: logger},
+		&SinkConfig{Sink: s2, Logger: logger},
+	})
+
+	incoming <- "foobar"
+
+	select {
+	case <-ss.DoneCh:
+	case <-time.After(2 * retryInterval):
+		t.Fatal("sink server did not exit")
+	}
+
+	for _, s := range []*testSink{s1, s2} {
+		if len(s.tokens) != 1 || s.tokens[0] != "foobar" {
+			t.Fatalf("bad: %#v", s.tokens)
+		}
+	}
+}
+
+func TestSinkServer_encryptToken(t *testing.T) {
+	pub, pri, err := dhutil.GeneratePublicPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ioutil.TempFile("", "vault-agent-dh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err := json.NewEncoder(f).Encode(&dhutil.PublicKeyInfo{Curve25519PublicKey: pub}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	out, err := encryptToken(&SinkConfig{
+		DHType: "curve25519",
+		DHPath: f.Name(),
+		AAD:    "foobar",
+	}, "s.token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var env dhutil.Envelope
+	if err := json.Unmarshal([]byte(out), &env); err != nil {
+		t.Fatal(err)
+	}
+	key, err := dhutil.GenerateSharedKey(pri, env.Curve25519PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := dhutil.DecryptAES(key, env.EncryptedPayload, env.Nonce, []byte("foobar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pt) != "s.token" {
+		t.Fatalf("bad: %s", pt)
+	}
+}
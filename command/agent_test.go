@@ -0,0 +1,289 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	credAppRole "github.com/hashicorp/vault/builtin/credential/approle"
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestAgent_approle(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"approle": credAppRole.Factory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: http.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+	client := cluster.Cores[0].Client
+	client.SetToken(cluster.RootToken)
+
+	if err := client.Sys().EnableAuth("approle", "approle", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("auth/approle/role/test1", map[string]interface{}{
+		"bind_secret_id": "true",
+		"token_ttl":      "3h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := client.Logical().Read("auth/approle/role/test1/role-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleID := secret.Data["role_id"].(string)
+	secret, err = client.Logical().Write("auth/approle/role/test1/secret-id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secretID := secret.Data["secret_id"].(string)
+
+	dir, err := ioutil.TempDir("", "vault-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	roleIDPath := filepath.Join(dir, "role-id")
+	secretIDPath := filepath.Join(dir, "secret-id")
+	sinkPath := filepath.Join(dir, "token")
+	wrappedSinkPath := filepath.Join(dir, "wrapped-token")
+	configPath := filepath.Join(dir, "agent.hcl")
+
+	if err := ioutil.WriteFile(roleIDPath, []byte(roleID+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(secretIDPath, []byte(secretID+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := fmt.Sprintf(`
+exit_after_auth = true
+
+auto_auth {
+	method "approle" {
+		config = {
+			role_id_file_path = "%s"
+			secret_id_file_path = "%s"
+		}
+	}
+
+	sink "file" {
+		config = {
+			path = "%s"
+		}
+	}
+
+	sink "file" {
+		wrap_ttl = "5m"
+		config = {
+			path = "%s"
+		}
+	}
+}
+`, roleIDPath, secretIDPath, sinkPath, wrappedSinkPath)
+	if err := ioutil.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &AgentCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+
+	args := []string{
+		"-address", client.Address(),
+		"-ca-cert", cluster.CACertPEMFile,
+		"-config", configPath,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	// The secret ID file is removed once it has been read
+	if _, err := os.Stat(secretIDPath); !os.IsNotExist(err) {
+		t.Fatalf("expected secret ID file to be removed, got: %v", err)
+	}
+
+	raw, err := ioutil.ReadFile(sinkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := string(raw)
+
+	client.SetToken(token)
+	secret, err = client.Auth().Token().LookupSelf()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta, ok := secret.Data["meta"].(map[string]interface{}); !ok || meta["role_name"] != "test1" {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+
+	raw, err = ioutil.ReadFile(wrappedSinkPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrapInfo api.SecretWrapInfo
+	if err := json.Unmarshal(raw, &wrapInfo); err != nil {
+		t.Fatal(err)
+	}
+	if wrapInfo.TTL != 300 {
+		t.Fatalf("bad: %#v", wrapInfo)
+	}
+
+	client.SetToken(cluster.RootToken)
+	secret, err = client.Logical().Unwrap(wrapInfo.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["token"] != token {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+}
+
+func TestAgent_noConfig(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &AgentCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "-config") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
+
+func TestAgent_cache(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"leased-kv": vault.LeasedPassthroughBackendFactory,
+		},
+	}, &vault.TestClusterOptions{
+		HandlerFunc: http.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+	client := cluster.Cores[0].Client
+	client.SetToken(cluster.RootToken)
+
+	if err := client.Sys().Mount("leased", &api.MountInput{
+		Type: "leased-kv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("leased/foo", map[string]interface{}{
+		"value": "bar",
+		"ttl":   "1h",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "vault-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "agent.sock")
+	configPath := filepath.Join(dir, "agent.hcl")
+
+	config := fmt.Sprintf(`
+cache {}
+
+listener "unix" {
+	address = "%s"
+	tls_disable = true
+}
+`, socketPath)
+	if err := ioutil.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &AgentCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+		ShutdownCh: make(chan struct{}),
+	}
+
+	args := []string{
+		"-address", client.Address(),
+		"-ca-cert", cluster.CACertPEMFile,
+		"-config", configPath,
+	}
+	codeCh := make(chan int)
+	go func() {
+		codeCh <- c.Run(args)
+	}()
+	defer func() {
+		close(c.ShutdownCh)
+		if code := <-codeCh; code != 0 {
+			t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+		}
+	}()
+
+	agentClient := testClient(t, "unix://"+socketPath, cluster.RootToken)
+
+	var first *api.Secret
+	for i := 0; i < 50; i++ {
+		if first, err = agentClient.Logical().Read("leased/foo"); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.LeaseID == "" || first.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", first)
+	}
+
+	// The same request through the agent is answered from the cache
+	second, err := agentClient.Logical().Read("leased/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.LeaseID != first.LeaseID {
+		t.Fatalf("expected cached lease %q, got %q", first.LeaseID, second.LeaseID)
+	}
+
+	// Revoking the lease through the agent evicts it from the cache
+	if err := agentClient.Sys().Revoke(first.LeaseID); err != nil {
+		t.Fatal(err)
+	}
+	third, err := agentClient.Logical().Read("leased/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.LeaseID == first.LeaseID {
+		t.Fatal("expected a new lease after revocation")
+	}
+}
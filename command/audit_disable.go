@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // AuditDisableCommand is a Command that mounts a new mount.
@@ -69,3 +70,11 @@ General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *AuditDisableCommand) AutocompleteArgs() complete.Predictor {
+	return predictAuditPaths(&c.Meta)
+}
+
+func (c *AuditDisableCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 	"github.com/ryanuber/columnize"
 )
 
@@ -15,7 +16,9 @@ type AuditListCommand struct {
 }
 
 func (c *AuditListCommand) Run(args []string) int {
+	var format string
 	flags := c.Meta.FlagSet("audit-list", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -35,6 +38,10 @@ func (c *AuditListCommand) Run(args []string) int {
 		return 2
 	}
 
+	if !isTableFormat(format) {
+		return OutputData(c.Ui, format, audits)
+	}
+
 	if len(audits) == 0 {
 		c.Ui.Error(fmt.Sprintf(
 			"No audit backends are enabled. Use `vault audit-enable` to\n" +
@@ -82,6 +89,23 @@ Usage: vault audit-list [options]
   only a root Vault user can view this.
 
 General Options:
-` + meta.GeneralOptionsUsage()
+` + meta.GeneralOptionsUsage() + `
+Audit List Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
+`
 	return strings.TrimSpace(helpText)
 }
+
+func (c *AuditListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *AuditListCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format": predictFormat,
+	}
+}
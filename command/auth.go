@@ -37,9 +37,15 @@ type AuthCommand struct {
 }
 
 func (c *AuthCommand) Run(args []string) int {
+	return c.run("auth", args)
+}
+
+// run authenticates and stores the token. It is shared by the auth and
+// login commands; name is the command that is run.
+func (c *AuthCommand) run(name string, args []string) int {
 	var method, authPath string
 	var methods, methodHelp, noVerify, noStore, tokenOnly bool
-	flags := c.Meta.FlagSet("auth", meta.FlagSetDefault)
+	flags := c.Meta.FlagSet(name, meta.FlagSetDefault)
 	flags.BoolVar(&methods, "methods", false, "")
 	flags.BoolVar(&methodHelp, "method-help", false, "")
 	flags.BoolVar(&noVerify, "no-verify", false, "")
@@ -58,6 +64,10 @@ func (c *AuthCommand) Run(args []string) int {
 
 	args = flags.Args()
 
+	// The path can be given with or without the auth/ prefix of the mount
+	authPath = strings.Trim(authPath, "/")
+	authPath = strings.Trim(strings.TrimPrefix(authPath, "auth/"), "/")
+
 	tokenHelper, err := c.TokenHelper()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
@@ -94,11 +104,11 @@ func (c *AuthCommand) Run(args []string) int {
 			token = args[0]
 		}
 
-		handler = &tokenAuthHandler{Token: token}
+		handler = &tokenAuthHandler{Token: token, Command: name}
 		args = nil
 
 		switch authPath {
-		case "", "auth/token":
+		case "", "token":
 		default:
 			c.Ui.Error("Token authentication does not support custom paths")
 			return 1
@@ -134,7 +144,7 @@ func (c *AuthCommand) Run(args []string) int {
 	if os.Getenv("VAULT_TOKEN") != "" && !tokenOnly {
 		c.Ui.Output("==> WARNING: VAULT_TOKEN environment variable set!\n")
 		c.Ui.Output("  The environment variable takes precedence over the value")
-		c.Ui.Output("  set by the auth command. Either update the value of the")
+		c.Ui.Output(fmt.Sprintf("  set by the %s command. Either update the value of the", name))
 		c.Ui.Output("  environment variable or unset it to use the new token.\n")
 	}
 
@@ -288,7 +298,7 @@ func (c *AuthCommand) Run(args []string) int {
 	}
 	if method != "" {
 		output += "\nThe token below is already saved in the session. You do not"
-		output += "\nneed to \"vault auth\" again with the token."
+		output += fmt.Sprintf("\nneed to \"vault %s\" again with the token.", name)
 	}
 	output += fmt.Sprintf("\ntoken: %s", secret.Data["id"])
 	output += fmt.Sprintf("\ntoken_duration: %s", secret.Data["ttl"].(json.Number).String())
@@ -330,7 +340,7 @@ func (c *AuthCommand) listMethods() int {
 	}
 	sort.Strings(paths)
 
-	columns := []string{"Path | Type | Accessor | Default TTL | Max TTL | Replication Behavior | Description"}
+	columns := []string{"Path | Type | Accessor | Default TTL | Max TTL | Replication Behavior | Seal Wrap | Description"}
 	for _, path := range paths {
 		auth := auth[path]
 		defTTL := "system"
@@ -346,7 +356,7 @@ func (c *AuthCommand) listMethods() int {
 			replicatedBehavior = "local"
 		}
 		columns = append(columns, fmt.Sprintf(
-			"%s | %s | %s | %s | %s | %s | %s", path, auth.Type, auth.Accessor, defTTL, maxTTL, replicatedBehavior, auth.Description))
+			"%s | %s | %s | %s | %s | %s | %v | %s", path, auth.Type, auth.Accessor, defTTL, maxTTL, replicatedBehavior, auth.SealWrap, auth.Description))
 	}
 
 	c.Ui.Output(columnize.SimpleFormat(columns))
@@ -420,6 +430,9 @@ Auth Options:
 // tokenAuthHandler handles retrieving the token from the command-line.
 type tokenAuthHandler struct {
 	Token string
+
+	// Command is the name of the command the handler is used by
+	Command string
 }
 
 func (h *tokenAuthHandler) Auth(*api.Client, map[string]string) (string, error) {
@@ -428,41 +441,48 @@ func (h *tokenAuthHandler) Auth(*api.Client, map[string]string) (string, error)
 		var err error
 
 		// No arguments given, read the token from user input
-		fmt.Printf("Token (will be hidden): ")
+		fmt.Fprintf(os.Stderr, "Token (will be hidden): ")
 		token, err = password.Read(os.Stdin)
-		fmt.Printf("\n")
+		fmt.Fprintf(os.Stderr, "\n")
 		if err != nil {
 			return "", fmt.Errorf(
 				"Error attempting to ask for token. The raw error message\n"+
 					"is shown below, but the most common reason for this error is\n"+
-					"that you attempted to pipe a value into auth. If you want to\n"+
+					"that you attempted to pipe a value into %s. If you want to\n"+
 					"pipe the token, please pass '-' as the token argument.\n\n"+
-					"Raw error: %s", err)
+					"Raw error: %s", h.command(), err)
 		}
 	}
 
 	if token == "" {
 		return "", fmt.Errorf(
-			"A token must be passed to auth. Please view the help\n" +
-				"for more information.")
+			"A token must be passed to %s. Please view the help\n"+
+				"for more information.", h.command())
 	}
 
 	return token, nil
 }
 
+func (h *tokenAuthHandler) command() string {
+	if h.Command == "" {
+		return "auth"
+	}
+	return h.Command
+}
+
 func (h *tokenAuthHandler) Help() string {
-	help := `
-No method selected with the "-method" flag, so the "auth" command assumes
+	help := fmt.Sprintf(`
+No method selected with the "-method" flag, so the "%[1]s" command assumes
 you'll be using raw token authentication. For this, specify the token to
-authenticate as the parameter to "vault auth". Example:
+authenticate as the parameter to "vault %[1]s". Example:
 
-    vault auth 123456
+    vault %[1]s 123456
 
 The token used to authenticate must come from some other source. A root
 token is created when Vault is first initialized. After that, subsequent
 tokens are created via the API or command line interface (with the
 "token"-prefixed commands).
-`
+`, h.command())
 
 	return strings.TrimSpace(help)
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // AuthDisableCommand is a Command that enables a new endpoint.
@@ -67,3 +68,11 @@ General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *AuthDisableCommand) AutocompleteArgs() complete.Predictor {
+	return predictAuthMounts(&c.Meta)
+}
+
+func (c *AuthDisableCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
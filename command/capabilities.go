@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // CapabilitiesCommand is a Command that enables a new endpoint.
@@ -85,3 +86,11 @@ General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *CapabilitiesCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *CapabilitiesCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
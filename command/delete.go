@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // DeleteCommand is a Command that puts data into the Vault.
@@ -65,3 +66,11 @@ General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *DeleteCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *DeleteCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -18,8 +19,27 @@ import (
 	"github.com/ryanuber/columnize"
 )
 
+// EnvVaultFormat is the environment variable that sets the default output
+// format of the commands that accept the -format flag.
+const EnvVaultFormat = "VAULT_FORMAT"
+
 var predictFormat complete.Predictor = complete.PredictSet("json", "yaml")
 
+// defaultFormat returns the value of VAULT_FORMAT, falling back to a table
+// when it is unset.
+func defaultFormat() string {
+	if format := os.Getenv(EnvVaultFormat); format != "" {
+		return format
+	}
+	return "table"
+}
+
+// isTableFormat reports whether the format selects the human-readable table
+// output, which commands that format their own output render themselves.
+func isTableFormat(format string) bool {
+	return strings.ToLower(format) == "table"
+}
+
 func OutputSecret(ui cli.Ui, format string, secret *api.Secret) int {
 	return outputWithFormat(ui, format, secret, secret)
 }
@@ -28,6 +48,12 @@ func OutputList(ui cli.Ui, format string, secret *api.Secret) int {
 	return outputWithFormat(ui, format, secret, secret.Data["keys"])
 }
 
+// OutputData outputs arbitrary data, such as the response of a sys endpoint,
+// in the given format.
+func OutputData(ui cli.Ui, format string, data interface{}) int {
+	return outputWithFormat(ui, format, nil, data)
+}
+
 func outputWithFormat(ui cli.Ui, format string, secret *api.Secret, data interface{}) int {
 	formatter, ok := Formatters[strings.ToLower(format)]
 	if !ok {
@@ -182,6 +208,7 @@ func (t TableFormatter) OutputSecret(ui cli.Ui, secret, s *api.Secret) error {
 	if s.WrapInfo != nil {
 		onceHeader.Do(headerFunc)
 		input = append(input, fmt.Sprintf("wrapping_token: %s %s", config.Delim, s.WrapInfo.Token))
+		input = append(input, fmt.Sprintf("wrapping_accessor: %s %s", config.Delim, s.WrapInfo.Accessor))
 		input = append(input, fmt.Sprintf("wrapping_token_ttl: %s %s", config.Delim, (time.Second*time.Duration(s.WrapInfo.TTL)).String()))
 		input = append(input, fmt.Sprintf("wrapping_token_creation_time: %s %s", config.Delim, s.WrapInfo.CreationTime.String()))
 		input = append(input, fmt.Sprintf("wrapping_token_creation_path: %s %s", config.Delim, s.WrapInfo.CreationPath))
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // KeyStatusCommand is a Command that provides information about the key status
@@ -35,6 +36,7 @@ func (c *KeyStatusCommand) Run(args []string) int {
 
 	c.Ui.Output(fmt.Sprintf("Key Term: %d", status.Term))
 	c.Ui.Output(fmt.Sprintf("Installation Time: %v", status.InstallTime))
+	c.Ui.Output(fmt.Sprintf("Encryptions: %d", status.Encryptions))
 	return 0
 }
 
@@ -47,9 +49,18 @@ func (c *KeyStatusCommand) Help() string {
 Usage: vault key-status [options]
 
   Provides information about the active encryption key. Specifically,
-  the current key term and the key installation time.
+  the current key term, the key installation time and the number of
+  encryptions performed with the key since this server was unsealed.
 
 General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *KeyStatusCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *KeyStatusCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
@@ -0,0 +1,189 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/kv-builder"
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+// kvMountTypeVersioned is the type of the versioned key/value backend
+const kvMountTypeVersioned = "kv"
+
+// KVCommand is the parent of the kv subcommands, which read and write
+// secrets in the key/value backends.
+type KVCommand struct {
+	meta.Meta
+}
+
+func (c *KVCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *KVCommand) Synopsis() string {
+	return "Interact with key/value secrets"
+}
+
+func (c *KVCommand) Help() string {
+	helpText := `
+Usage: vault kv <subcommand> [options] [args]
+
+  Interact with the secrets stored in the generic and versioned key/value
+  backends. Paths are given the same way for both backends; for a versioned
+  ("kv") mount the data/ and metadata/ paths of its API are used
+  automatically.
+
+  Read a secret:
+
+      $ vault kv get secret/foo
+
+  Write a secret, replacing any existing data:
+
+      $ vault kv put secret/foo bar=baz
+
+  Update some of the fields of a secret:
+
+      $ vault kv patch secret/foo bar=qux
+
+  The type of a mount is read from the mounts the token can access, or from
+  sys/mounts on older servers. If it cannot be read, the mount is treated as
+  an unversioned generic backend.
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// kvMount describes the mount a kv path is under
+type kvMount struct {
+	// Path is the mount path, with a trailing slash, or empty if the mount
+	// could not be determined
+	Path string
+
+	// Versioned is true for a versioned ("kv") mount
+	Versioned bool
+}
+
+// kvPreflight returns the mount that the path is under. If the mounts cannot
+// be read, such as on an older server when the token may not read
+// sys/mounts, the path is treated as being under an unversioned mount.
+func kvPreflight(client *api.Client, p string) *kvMount {
+	var mounts map[string]*api.MountOutput
+	if uiMounts, err := client.Sys().ListUIMounts(); err == nil {
+		mounts = uiMounts.Secret
+	} else if mounts, err = client.Sys().ListMounts(); err != nil {
+		// Servers without sys/internal/ui/mounts need read on sys/mounts
+		return &kvMount{}
+	}
+
+	var result kvMount
+	var mountType string
+	for mountPath, mount := range mounts {
+		if !strings.HasPrefix(p+"/", mountPath) {
+			continue
+		}
+		if len(mountPath) > len(result.Path) {
+			result.Path = mountPath
+			mountType = mount.Type
+		}
+	}
+	result.Versioned = mountType == kvMountTypeVersioned
+
+	return &result
+}
+
+// apiPath returns the path of the API of the mount to use for the secret
+// path. Versioned mounts serve secrets under a prefix, such as data/ or
+// metadata/; unversioned mounts serve them at the path itself.
+func (m *kvMount) apiPath(p, prefix string) string {
+	if !m.Versioned {
+		return p
+	}
+
+	return path.Join(m.Path, prefix, strings.TrimPrefix(p, strings.TrimSuffix(m.Path, "/")))
+}
+
+// kvSanitizePath removes any leading slash from a secret path
+func kvSanitizePath(p string) string {
+	return strings.TrimPrefix(p, "/")
+}
+
+// kvParseData parses the key=value arguments of a write
+func kvParseData(stdin io.Reader, args []string) (map[string]interface{}, error) {
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	builder := &kvbuilder.Builder{Stdin: stdin}
+	if err := builder.Add(args...); err != nil {
+		return nil, err
+	}
+
+	return builder.Map(), nil
+}
+
+// kvReadData reads the data of a secret. For a versioned mount the data is
+// unwrapped from the response, and the version metadata is returned
+// separately; version selects a version other than the latest if non-zero.
+func kvReadData(client *api.Client, mount *kvMount, p string, version int) (*api.Secret, map[string]interface{}, error) {
+	if !mount.Versioned {
+		secret, err := client.Logical().Read(p)
+		return secret, nil, err
+	}
+
+	var params map[string][]string
+	if version > 0 {
+		params = map[string][]string{
+			"version": []string{fmt.Sprintf("%d", version)},
+		}
+	}
+
+	secret, err := client.Logical().ReadWithData(mount.apiPath(p, "data"), params)
+	if err != nil || secret == nil {
+		return secret, nil, err
+	}
+
+	metadata, _ := secret.Data["metadata"].(map[string]interface{})
+	data, _ := secret.Data["data"].(map[string]interface{})
+	if data == nil {
+		// Deleted and destroyed versions only have metadata
+		return nil, metadata, nil
+	}
+	secret.Data = data
+
+	return secret, metadata, nil
+}
+
+// kvWriteData writes the data of a secret. For a versioned mount, cas sets
+// the check-and-set version of the write if it is not negative.
+func kvWriteData(client *api.Client, mount *kvMount, p string, data map[string]interface{}, cas int) (*api.Secret, error) {
+	if !mount.Versioned {
+		return client.Logical().Write(p, data)
+	}
+
+	body := map[string]interface{}{
+		"data": data,
+	}
+	if cas >= 0 {
+		body["options"] = map[string]interface{}{
+			"cas": cas,
+		}
+	}
+
+	return client.Logical().Write(mount.apiPath(p, "data"), body)
+}
+
+func (c *KVCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *KVCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
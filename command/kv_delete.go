@@ -0,0 +1,97 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
+)
+
+// KVDeleteCommand is a Command that deletes a key/value secret.
+type KVDeleteCommand struct {
+	meta.Meta
+}
+
+func (c *KVDeleteCommand) Run(args []string) int {
+	var versions string
+	flags := c.Meta.FlagSet("kv delete", meta.FlagSetDefault)
+	flags.StringVar(&versions, "versions", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 || len(args[0]) == 0 {
+		c.Ui.Error("kv delete expects one argument")
+		flags.Usage()
+		return 1
+	}
+	path := kvSanitizePath(args[0])
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	mount := kvPreflight(client, path)
+	switch {
+	case versions != "" && !mount.Versioned:
+		c.Ui.Error("-versions is only supported for versioned kv mounts")
+		return 1
+
+	case versions != "":
+		_, err = client.Logical().Write(mount.apiPath(path, "delete"), map[string]interface{}{
+			"versions": versions,
+		})
+
+	default:
+		_, err = client.Logical().Delete(mount.apiPath(path, "data"))
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error deleting '%s': %s", path, err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Success! Deleted '%s' if it existed.", path))
+	return 0
+}
+
+func (c *KVDeleteCommand) Synopsis() string {
+	return "Delete a key/value secret"
+}
+
+func (c *KVDeleteCommand) Help() string {
+	helpText := `
+Usage: vault kv delete [options] path
+
+  Delete a secret in a key/value backend. For a versioned mount the latest
+  version is deleted, or the versions given with -versions; deleted versions
+  can be restored through the undelete/ path of the mount.
+
+      $ vault kv delete secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Delete Options:
+
+  -versions=""            A comma-separated list of the versions to delete, for
+                          versioned mounts. By default the latest version is
+                          deleted.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVDeleteCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *KVDeleteCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-versions": complete.PredictNothing,
+	}
+}
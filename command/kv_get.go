@@ -0,0 +1,139 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
+)
+
+// KVGetCommand is a Command that reads a key/value secret.
+type KVGetCommand struct {
+	meta.Meta
+}
+
+func (c *KVGetCommand) Run(args []string) int {
+	var format, field string
+	var version int
+	flags := c.Meta.FlagSet("kv get", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", defaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.IntVar(&version, "version", 0, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 || len(args[0]) == 0 {
+		c.Ui.Error("kv get expects one argument")
+		flags.Usage()
+		return 1
+	}
+	path := kvSanitizePath(args[0])
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	mount := kvPreflight(client, path)
+	if version != 0 && !mount.Versioned {
+		c.Ui.Error("-version is only supported for versioned kv mounts")
+		return 1
+	}
+
+	secret, metadata, err := kvReadData(client, mount, path, version)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", path, err))
+		return 1
+	}
+	if secret == nil {
+		if metadata != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Version of %s has been deleted or destroyed", path))
+			return 1
+		}
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s", path))
+		return 1
+	}
+
+	// Handle single field output
+	if field != "" {
+		return PrintRawField(c.Ui, secret, field)
+	}
+
+	if metadata == nil {
+		return OutputSecret(c.Ui, format, secret)
+	}
+
+	// The structured formats keep the shape of the versioned API so that
+	// the metadata is available to scripts
+	if !isTableFormat(format) {
+		secret.Data = map[string]interface{}{
+			"data":     secret.Data,
+			"metadata": metadata,
+		}
+		return OutputSecret(c.Ui, format, secret)
+	}
+
+	c.Ui.Output("====== Metadata ======")
+	if code := OutputSecret(c.Ui, format, &api.Secret{Data: metadata}); code != 0 {
+		return code
+	}
+	c.Ui.Output("\n====== Data ======")
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *KVGetCommand) Synopsis() string {
+	return "Read a key/value secret"
+}
+
+func (c *KVGetCommand) Help() string {
+	helpText := `
+Usage: vault kv get [options] path
+
+  Read the data of a secret in a key/value backend. For a versioned mount,
+  the metadata of the version is output along with its data.
+
+      $ vault kv get secret/foo
+
+  Read an older version of a secret in a versioned mount:
+
+      $ vault kv get -version=1 secret/foo
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Get Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
+
+  -field=field            If included, the raw value of the specified field
+                          of the data will be output raw to stdout.
+
+  -version=0              The version of the secret to read, for versioned
+                          mounts. By default the latest version is read.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVGetCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *KVGetCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format":  predictFormat,
+		"-field":   complete.PredictNothing,
+		"-version": complete.PredictNothing,
+	}
+}
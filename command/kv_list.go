@@ -0,0 +1,98 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
+)
+
+// KVListCommand is a Command that lists the key/value secrets under a path.
+type KVListCommand struct {
+	meta.Meta
+}
+
+func (c *KVListCommand) Run(args []string) int {
+	var format string
+	flags := c.Meta.FlagSet("kv list", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", defaultFormat(), "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 || len(args[0]) == 0 {
+		c.Ui.Error("kv list expects one argument")
+		flags.Usage()
+		return 1
+	}
+	path := kvSanitizePath(args[0])
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	mount := kvPreflight(client, path)
+	listPath := mount.apiPath(path, "metadata")
+	if !strings.HasSuffix(listPath, "/") {
+		listPath = listPath + "/"
+	}
+
+	secret, err := client.Logical().List(listPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error listing %s: %s", path, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s", path))
+		return 1
+	}
+	if secret.Data["keys"] == nil {
+		c.Ui.Error("No entries found")
+		return 0
+	}
+
+	return OutputList(c.Ui, format, secret)
+}
+
+func (c *KVListCommand) Synopsis() string {
+	return "List the key/value secrets under a path"
+}
+
+func (c *KVListCommand) Help() string {
+	helpText := `
+Usage: vault kv list [options] path
+
+  List the names of the secrets under a path of a key/value backend. Names
+  ending in a slash are folders that can be listed in turn.
+
+      $ vault kv list secret/
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV List Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVListCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *KVListCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format": predictFormat,
+	}
+}
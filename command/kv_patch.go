@@ -0,0 +1,153 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
+)
+
+// KVPatchCommand is a Command that updates some of the fields of a
+// key/value secret.
+type KVPatchCommand struct {
+	meta.Meta
+
+	// The fields below can be overwritten for tests
+	testStdin io.Reader
+}
+
+func (c *KVPatchCommand) Run(args []string) int {
+	var format, field string
+	flags := c.Meta.FlagSet("kv patch", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", defaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) < 2 || len(args[0]) == 0 {
+		c.Ui.Error("kv patch expects a path and at least one key=value pair")
+		flags.Usage()
+		return 1
+	}
+	path := kvSanitizePath(args[0])
+
+	newData, err := kvParseData(c.testStdin, args[1:])
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error loading data: %s", err))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	mount := kvPreflight(client, path)
+	secret, metadata, err := kvReadData(client, mount, path, 0)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error reading %s: %s", path, err))
+		return 1
+	}
+	if secret == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"No value found at %s; use \"vault kv put\" to create it", path))
+		return 1
+	}
+
+	data := secret.Data
+	if data == nil {
+		data = make(map[string]interface{}, len(newData))
+	}
+	for k, v := range newData {
+		data[k] = v
+	}
+
+	// On a versioned mount the write is only made against the version that
+	// was read, so that concurrent changes to other fields are not lost
+	cas := -1
+	if metadata != nil {
+		version, err := parseutil.ParseInt(metadata["version"])
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error reading the version of %s: %s", path, err))
+			return 1
+		}
+		cas = int(version)
+	}
+
+	secret, err = kvWriteData(client, mount, path, data, cas)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing data to %s: %s", path, err))
+		return 1
+	}
+
+	if secret == nil {
+		// Don't output anything if people aren't using the "human" output
+		if isTableFormat(format) {
+			c.Ui.Output(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return 0
+	}
+
+	// Handle single field output
+	if field != "" {
+		return PrintRawField(c.Ui, secret, field)
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *KVPatchCommand) Synopsis() string {
+	return "Update some of the fields of a key/value secret"
+}
+
+func (c *KVPatchCommand) Help() string {
+	helpText := `
+Usage: vault kv patch [options] path key=value [key=value...]
+
+  Update the given fields of an existing secret in a key/value backend,
+  keeping its other fields. The secret is read, merged with the new data and
+  written back. For a versioned mount the write uses check-and-set against
+  the version that was read, so it fails rather than overwrite a concurrent
+  change.
+
+      $ vault kv patch secret/foo bar=qux
+
+  Data is given in "key=value" pairs, in the same way as for "vault kv put".
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Patch Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
+
+  -field=field            If included, the raw value of the specified field
+                          will be output raw to stdout.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVPatchCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *KVPatchCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format": predictFormat,
+		"-field":  complete.PredictNothing,
+	}
+}
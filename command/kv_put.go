@@ -0,0 +1,132 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
+)
+
+// KVPutCommand is a Command that writes a key/value secret.
+type KVPutCommand struct {
+	meta.Meta
+
+	// The fields below can be overwritten for tests
+	testStdin io.Reader
+}
+
+func (c *KVPutCommand) Run(args []string) int {
+	var format, field string
+	var cas int
+	flags := c.Meta.FlagSet("kv put", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", defaultFormat(), "")
+	flags.StringVar(&field, "field", "", "")
+	flags.IntVar(&cas, "cas", -1, "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) < 2 || len(args[0]) == 0 {
+		c.Ui.Error("kv put expects a path and at least one key=value pair")
+		flags.Usage()
+		return 1
+	}
+	path := kvSanitizePath(args[0])
+
+	data, err := kvParseData(c.testStdin, args[1:])
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error loading data: %s", err))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+
+	mount := kvPreflight(client, path)
+	if cas >= 0 && !mount.Versioned {
+		c.Ui.Error("-cas is only supported for versioned kv mounts")
+		return 1
+	}
+
+	secret, err := kvWriteData(client, mount, path, data, cas)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing data to %s: %s", path, err))
+		return 1
+	}
+
+	if secret == nil {
+		// Don't output anything if people aren't using the "human" output
+		if isTableFormat(format) {
+			c.Ui.Output(fmt.Sprintf("Success! Data written to: %s", path))
+		}
+		return 0
+	}
+
+	// Handle single field output
+	if field != "" {
+		return PrintRawField(c.Ui, secret, field)
+	}
+
+	return OutputSecret(c.Ui, format, secret)
+}
+
+func (c *KVPutCommand) Synopsis() string {
+	return "Write a key/value secret, replacing any existing data"
+}
+
+func (c *KVPutCommand) Help() string {
+	helpText := `
+Usage: vault kv put [options] path key=value [key=value...]
+
+  Write the data of a secret in a key/value backend. Any existing data of the
+  secret is replaced; use "vault kv patch" to update only some fields. For a
+  versioned mount a new version is created, and its metadata is output.
+
+      $ vault kv put secret/foo bar=baz
+
+  Data is given in "key=value" pairs. If value begins with an "@", then it is
+  loaded from a file. If the only data argument is "-", it is read as JSON
+  from stdin.
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+KV Put Options:
+
+  -cas=-1                 The check-and-set version of the write, for versioned
+                          mounts. The write only succeeds if this is the
+                          current version of the secret; 0 only allows the
+                          write if the secret does not exist. By default no
+                          check is made, unless the mount requires one.
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
+
+  -field=field            If included, the raw value of the specified field
+                          will be output raw to stdout.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *KVPutCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *KVPutCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-cas":    complete.PredictNothing,
+		"-format": predictFormat,
+		"-field":  complete.PredictNothing,
+	}
+}
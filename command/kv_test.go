@@ -0,0 +1,162 @@
+package command
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/builtin/logical/kv"
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func testKVClient(t *testing.T, addr, token string) *api.Client {
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client.SetToken(token)
+	return client
+}
+
+func testKVMeta(ui cli.Ui, token string) meta.Meta {
+	return meta.Meta{
+		ClientToken: token,
+		Ui:          ui,
+	}
+}
+
+func TestKV_generic(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	put := &KVPutCommand{Meta: testKVMeta(ui, token)}
+	if code := put.Run([]string{"-address", addr, "secret/foo", "a=1", "b=2"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	patch := &KVPatchCommand{Meta: testKVMeta(ui, token)}
+	if code := patch.Run([]string{"-address", addr, "secret/foo", "b=3"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	client := testKVClient(t, addr, token)
+	secret, err := client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if secret.Data["a"] != "1" || secret.Data["b"] != "3" {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+
+	ui = new(cli.MockUi)
+	get := &KVGetCommand{Meta: testKVMeta(ui, token)}
+	if code := get.Run([]string{"-address", addr, "-version", "1", "secret/foo"}); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+
+	del := &KVDeleteCommand{Meta: testKVMeta(ui, token)}
+	if code := del.Run([]string{"-address", addr, "secret/foo"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	secret, err = client.Logical().Read("secret/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if secret != nil {
+		t.Fatalf("bad: %#v", secret)
+	}
+}
+
+func TestKV_versioned(t *testing.T) {
+	if err := vault.AddTestLogicalBackend("kv", kv.Factory); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	client := testKVClient(t, addr, token)
+	if err := client.Sys().Mount("versioned", &api.MountInput{Type: "kv"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	put := &KVPutCommand{Meta: testKVMeta(ui, token)}
+	if code := put.Run([]string{"-address", addr, "versioned/foo", "a=1", "b=2"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	// A check-and-set write against an old version fails
+	if code := put.Run([]string{"-address", addr, "-cas", "0", "versioned/foo", "a=2"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+
+	patch := &KVPatchCommand{Meta: testKVMeta(ui, token)}
+	if code := patch.Run([]string{"-address", addr, "versioned/foo", "b=3"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	secret, err := client.Logical().Read("versioned/data/foo")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	data := secret.Data["data"].(map[string]interface{})
+	if data["a"] != "1" || data["b"] != "3" {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+
+	ui = new(cli.MockUi)
+	get := &KVGetCommand{Meta: testKVMeta(ui, token)}
+	if code := get.Run([]string{"-address", addr, "-format", "json", "-version", "1", "versioned/foo"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	var out struct {
+		Data struct {
+			Data     map[string]interface{} `json:"data"`
+			Metadata map[string]interface{} `json:"metadata"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &out); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.Data.Data["b"] != "2" || out.Data.Metadata["version"] != float64(1) {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	ui = new(cli.MockUi)
+	get = &KVGetCommand{Meta: testKVMeta(ui, token)}
+	if code := get.Run([]string{"-address", addr, "-field", "b", "versioned/foo"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	ui = new(cli.MockUi)
+	list := &KVListCommand{Meta: testKVMeta(ui, token)}
+	if code := list.Run([]string{"-address", addr, "-format", "json", "versioned/"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), `"foo"`) {
+		t.Fatalf("bad: %s", ui.OutputWriter.String())
+	}
+
+	del := &KVDeleteCommand{Meta: testKVMeta(ui, token)}
+	if code := del.Run([]string{"-address", addr, "versioned/foo"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	ui = new(cli.MockUi)
+	get = &KVGetCommand{Meta: testKVMeta(ui, token)}
+	if code := get.Run([]string{"-address", addr, "versioned/foo"}); code != 1 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "deleted") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
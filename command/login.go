@@ -0,0 +1,80 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+)
+
+// LoginCommand is a Command that authenticates with Vault and stores the
+// resulting token with the token helper. It shares its behavior with the
+// auth command.
+type LoginCommand struct {
+	AuthCommand
+}
+
+func (c *LoginCommand) Run(args []string) int {
+	return c.run("login", args)
+}
+
+func (c *LoginCommand) Synopsis() string {
+	return "Authenticate with Vault and store the token"
+}
+
+func (c *LoginCommand) Help() string {
+	helpText := `
+Usage: vault login [options] [auth-information]
+
+  Authenticate with Vault using a token or any supported auth method, and
+  store the resulting token with the configured token helper so that later
+  commands use it.
+
+  By default, a token is expected. If it is not given on the command line, it
+  is prompted for without echoing it. If the authentication information is
+  "-", it is read from stdin.
+
+      $ vault login
+
+  The -method option selects another auth method, such as okta, ldap, github
+  or userpass. Its values are given as "key=value" pairs. A password that is
+  not given is prompted for without echoing it:
+
+      $ vault login -method=ldap username=john
+
+  Use "-method-help" to get help for a specific method.
+
+  If an auth method is enabled at a different path, the "-method" flag
+  should still point to its type, and "-path" gives the path it is enabled
+  at, with or without the "auth/" prefix:
+
+      $ vault login -method=github -path=github-private
+
+  The token is stored by the token helper set by "token_helper" in the
+  Vault CLI configuration file, which can be an external program. By default
+  it is stored in ~/.vault-token.
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Login Options:
+
+  -method=name      The auth method to use. Defaults to token.
+
+  -method-help      If set, the help for the selected method will be shown.
+
+  -methods          List the auth methods enabled on the server.
+
+  -no-verify        Do not verify the token after creation; avoids a use count
+                    decrement.
+
+  -no-store         Do not store the token after creation; it will only be
+                    displayed in the command output.
+
+  -token-only       Output only the token to stdout. This implies -no-verify
+                    and -no-store.
+
+  -path             The path at which the auth method is enabled. If an auth
+                    method is enabled at multiple paths, this option can be
+                    used to authenticate against specific paths.
+`
+	return strings.TrimSpace(helpText)
+}
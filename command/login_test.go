@@ -0,0 +1,99 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestLogin_token(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	testAuthInit(t)
+
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		AuthCommand: AuthCommand{
+			Meta: meta.Meta{
+				Ui:          ui,
+				TokenHelper: DefaultTokenHelper,
+			},
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		token,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	helper, err := c.TokenHelper()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	actual, err := helper.Get()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if actual != token {
+		t.Fatalf("bad: %s", actual)
+	}
+}
+
+func TestLogin_methodPath(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	testAuthInit(t)
+
+	handler := &testMountAuthHandler{Token: token}
+	ui := new(cli.MockUi)
+	c := &LoginCommand{
+		AuthCommand: AuthCommand{
+			Handlers: map[string]AuthHandler{
+				"test": handler,
+			},
+			Meta: meta.Meta{
+				Ui:          ui,
+				TokenHelper: DefaultTokenHelper,
+			},
+		},
+	}
+
+	// The path is passed to the handler without the auth/ prefix
+	for _, path := range []string{"test-path", "auth/test-path/", "/auth/test-path"} {
+		args := []string{
+			"-address", addr,
+			"-method=test",
+			"-path=" + path,
+		}
+		if code := c.Run(args); code != 0 {
+			t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+		}
+		if handler.Mount != "test-path" {
+			t.Fatalf("bad: %q for %q", handler.Mount, path)
+		}
+	}
+}
+
+// testMountAuthHandler records the mount it is called with
+type testMountAuthHandler struct {
+	Token string
+	Mount string
+}
+
+func (h *testMountAuthHandler) Auth(c *api.Client, m map[string]string) (string, error) {
+	h.Mount = m["mount"]
+	return h.Token, nil
+}
+
+func (h *testMountAuthHandler) Help() string { return "" }
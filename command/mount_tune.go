@@ -1,11 +1,13 @@
 package command
 
 import (
+	"flag"
 	"fmt"
 	"strings"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // MountTuneCommand is a Command that remounts a mounted secret backend
@@ -15,10 +17,11 @@ type MountTuneCommand struct {
 }
 
 func (c *MountTuneCommand) Run(args []string) int {
-	var defaultLeaseTTL, maxLeaseTTL string
+	var defaultLeaseTTL, maxLeaseTTL, description string
 	flags := c.Meta.FlagSet("mount-tune", meta.FlagSetDefault)
 	flags.StringVar(&defaultLeaseTTL, "default-lease-ttl", "", "")
 	flags.StringVar(&maxLeaseTTL, "max-lease-ttl", "", "")
+	flags.StringVar(&description, "description", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -39,6 +42,14 @@ func (c *MountTuneCommand) Run(args []string) int {
 		MaxLeaseTTL:     maxLeaseTTL,
 	}
 
+	// Only send the description if the flag was explicitly given, so that an
+	// empty value can be used to clear it
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "description" {
+			mountConfig.Description = &description
+		}
+	})
+
 	client, err := c.Client()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
@@ -84,6 +95,21 @@ Mount Options:
                                  the previously set value. Set to 'system' to
                                  explicitly set it to use the system default.
 
+  -description=<desc>            Human-friendly description of the mount. Set
+                                 to an empty string to clear the description.
+
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *MountTuneCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *MountTuneCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-default-lease-ttl": complete.PredictNothing,
+		"-max-lease-ttl":     complete.PredictNothing,
+		"-description":       complete.PredictNothing,
+	}
+}
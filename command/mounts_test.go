@@ -1,6 +1,8 @@
 package command
 
 import (
+	"encoding/json"
+	"os"
 	"testing"
 
 	"github.com/hashicorp/vault/http"
@@ -29,3 +31,36 @@ func TestMounts(t *testing.T) {
 		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
 }
+
+func TestMounts_envFormat(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	oldFormat := os.Getenv(EnvVaultFormat)
+	os.Setenv(EnvVaultFormat, "json")
+	defer os.Setenv(EnvVaultFormat, oldFormat)
+
+	ui := new(cli.MockUi)
+	c := &MountsCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var mounts map[string]interface{}
+	if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &mounts); err != nil {
+		t.Fatalf("err: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	if _, ok := mounts["secret/"]; !ok {
+		t.Fatalf("bad: %#v", mounts)
+	}
+}
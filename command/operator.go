@@ -0,0 +1,50 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/meta"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+// OperatorCommand is the parent of the operator subcommands, which help the
+// operators of a Vault cluster.
+type OperatorCommand struct {
+	meta.Meta
+}
+
+func (c *OperatorCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *OperatorCommand) Synopsis() string {
+	return "Perform operator-specific tasks"
+}
+
+func (c *OperatorCommand) Help() string {
+	helpText := `
+Usage: vault operator <subcommand> [options] [args]
+
+  Perform tasks for the operators of a Vault cluster.
+
+  Capture a bundle of debugging information from a server:
+
+      $ vault operator debug -duration=5m
+
+  Copy all data from one storage backend to another while Vault is offline:
+
+      $ vault operator migrate -config=migrate.hcl
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
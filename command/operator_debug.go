@@ -0,0 +1,435 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/hashicorp/vault/version"
+	"github.com/posener/complete"
+)
+
+const (
+	// debugDefaultDuration and debugDefaultInterval are the defaults for how
+	// long a debug bundle is captured for and how often the status of the
+	// server is captured in that time
+	debugDefaultDuration = 2 * time.Minute
+	debugDefaultInterval = 30 * time.Second
+
+	// debugRequestPadding is added to the duration for the client timeout,
+	// since the profile and trace requests take the whole duration
+	debugRequestPadding = 30 * time.Second
+
+	// debugTimeFormat names the directories of the interval captures and the
+	// default output file. It has no colons, so that the bundle can be
+	// extracted anywhere.
+	debugTimeFormat = "2006-01-02T15-04-05Z"
+
+	debugIndexFile = "index.json"
+)
+
+// debugTarget is a file of the debug bundle and the API path it is captured
+// from
+type debugTarget struct {
+	File   string
+	Path   string
+	Params url.Values
+}
+
+var (
+	// debugStaticTargets are captured once, at the start of the capture
+	debugStaticTargets = []*debugTarget{
+		{File: "config.json", Path: "/v1/sys/config/state/sanitized"},
+	}
+
+	// debugIntervalTargets are captured at every interval, into a directory
+	// named for the time of the capture
+	debugIntervalTargets = []*debugTarget{
+		{File: "metrics.json", Path: "/v1/sys/metrics"},
+		{File: "health.json", Path: "/v1/sys/health", Params: url.Values{
+			// Sealed, uninitialized and standby servers are still captured
+			"standbyok":  {"true"},
+			"sealedcode": {"299"},
+			"uninitcode": {"299"},
+		}},
+		{File: "leader.json", Path: "/v1/sys/leader"},
+		{File: "seal-status.json", Path: "/v1/sys/seal-status"},
+		{File: "replication-status.json", Path: "/v1/sys/replication/status"},
+		{File: "goroutine.prof", Path: "/v1/sys/pprof/goroutine"},
+		{File: "heap.prof", Path: "/v1/sys/pprof/heap"},
+	}
+)
+
+// debugDurationTargets are captured over the whole duration of the capture,
+// up to the longest duration the server profiles for
+func debugDurationTargets(duration time.Duration) []*debugTarget {
+	seconds := int(duration.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	if seconds > vault.PprofMaxSeconds {
+		seconds = vault.PprofMaxSeconds
+	}
+	params := url.Values{"seconds": {strconv.Itoa(seconds)}}
+
+	return []*debugTarget{
+		{File: "profile.prof", Path: "/v1/sys/pprof/profile", Params: params},
+		{File: "trace.out", Path: "/v1/sys/pprof/trace", Params: params},
+	}
+}
+
+// debugIndex is written to the root of the debug bundle to describe it
+type debugIndex struct {
+	VaultAddress  string        `json:"vault_address"`
+	ClientVersion string        `json:"client_version"`
+	Timestamp     time.Time     `json:"timestamp"`
+	Duration      string        `json:"duration"`
+	Interval      string        `json:"interval"`
+	Errors        []*debugError `json:"errors"`
+}
+
+// debugError records a capture that failed
+type debugError struct {
+	Target    string    `json:"target"`
+	Timestamp time.Time `json:"timestamp"`
+	Error     string    `json:"error"`
+}
+
+// OperatorDebugCommand is a Command that captures the state of a server over
+// a period of time into a bundle, for debugging it.
+type OperatorDebugCommand struct {
+	meta.Meta
+
+	ShutdownCh chan struct{}
+
+	errorsLock sync.Mutex
+	errors     []*debugError
+}
+
+func (c *OperatorDebugCommand) Run(args []string) int {
+	var duration, interval time.Duration
+	var output string
+	flags := c.Meta.FlagSet("operator debug", meta.FlagSetDefault)
+	flags.DurationVar(&duration, "duration", debugDefaultDuration, "")
+	flags.DurationVar(&interval, "interval", debugDefaultInterval, "")
+	flags.StringVar(&output, "output", "", "")
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(flags.Args()) != 0 {
+		c.Ui.Error("operator debug expects no arguments")
+		flags.Usage()
+		return 1
+	}
+	if duration <= 0 || interval <= 0 {
+		c.Ui.Error("-duration and -interval must be positive")
+		return 1
+	}
+	if interval > duration {
+		interval = duration
+	}
+
+	start := time.Now().UTC()
+	if output == "" {
+		output = fmt.Sprintf("vault-debug-%s.tar.gz", start.Format(debugTimeFormat))
+	}
+	if _, err := os.Stat(output); err == nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Output file %s already exists", output))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error initializing client: %s", err))
+		return 2
+	}
+	client.SetClientTimeout(duration + debugRequestPadding)
+
+	tempDir, err := ioutil.TempDir("", "vault-debug")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error creating temporary directory: %s", err))
+		return 1
+	}
+	defer os.RemoveAll(tempDir)
+
+	// The bundle extracts into a directory named for the output file
+	bundleName := strings.TrimSuffix(filepath.Base(output), ".tar.gz")
+	bundleDir := filepath.Join(tempDir, bundleName)
+	if err := os.Mkdir(bundleDir, 0700); err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error creating temporary directory: %s", err))
+		return 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.ShutdownCh:
+			c.Ui.Output("==> Interrupt received, writing what was captured so far...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	c.Ui.Output(fmt.Sprintf(
+		"==> Capturing debug information from %s for %s, every %s",
+		client.Address(), duration, interval))
+
+	var wg sync.WaitGroup
+	for _, target := range debugDurationTargets(duration) {
+		wg.Add(1)
+		go func(target *debugTarget) {
+			defer wg.Done()
+			c.capture(ctx, client, bundleDir, target)
+		}(target)
+	}
+
+	for _, target := range debugStaticTargets {
+		c.capture(ctx, client, bundleDir, target)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+CAPTURE:
+	for {
+		c.captureInterval(ctx, client, bundleDir)
+		if time.Since(start) >= duration {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			break CAPTURE
+		case <-ticker.C:
+		}
+	}
+	wg.Wait()
+
+	index := &debugIndex{
+		VaultAddress:  client.Address(),
+		ClientVersion: version.GetVersion().VersionNumber(),
+		Timestamp:     start,
+		Duration:      duration.String(),
+		Interval:      interval.String(),
+		Errors:        c.errors,
+	}
+	if err := writeDebugIndex(bundleDir, index); err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing the bundle index: %s", err))
+		return 1
+	}
+
+	if err := writeDebugArchive(output, tempDir); err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error writing the bundle to %s: %s", output, err))
+		return 1
+	}
+
+	if len(c.errors) > 0 {
+		c.Ui.Warn(fmt.Sprintf(
+			"%d captures failed; their errors are listed in %s in the bundle",
+			len(c.errors), debugIndexFile))
+	}
+	c.Ui.Output(fmt.Sprintf("Success! Debug bundle written to: %s", output))
+	return 0
+}
+
+// captureInterval captures the interval targets into a new directory
+func (c *OperatorDebugCommand) captureInterval(ctx context.Context, client *api.Client, bundleDir string) {
+	dir := filepath.Join(bundleDir, time.Now().UTC().Format(debugTimeFormat))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		c.addError(dir, err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range debugIntervalTargets {
+		wg.Add(1)
+		go func(target *debugTarget) {
+			defer wg.Done()
+			c.capture(ctx, client, dir, target)
+		}(target)
+	}
+	wg.Wait()
+}
+
+// capture writes the response of the target into its file in dir. A
+// failure is recorded in the index instead of stopping the capture, since
+// some paths may not be available to the token or on the server.
+func (c *OperatorDebugCommand) capture(ctx context.Context, client *api.Client, dir string, target *debugTarget) {
+	r := client.NewRequest("GET", target.Path)
+	for k, v := range target.Params {
+		r.Params[k] = v
+	}
+
+	resp, err := client.RawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		c.addError(target.Path, err)
+		return
+	}
+
+	f, err := os.Create(filepath.Join(dir, target.File))
+	if err != nil {
+		c.addError(target.Path, err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		c.addError(target.Path, err)
+	}
+}
+
+func (c *OperatorDebugCommand) addError(target string, err error) {
+	c.errorsLock.Lock()
+	defer c.errorsLock.Unlock()
+
+	c.errors = append(c.errors, &debugError{
+		Target:    target,
+		Timestamp: time.Now().UTC(),
+		Error:     err.Error(),
+	})
+}
+
+func writeDebugIndex(dir string, index *debugIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, debugIndexFile), data, 0600)
+}
+
+// writeDebugArchive writes the contents of dir to a gzipped tarball at
+// output
+func writeDebugArchive(output, dir string) error {
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gzw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gzw)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gzw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (c *OperatorDebugCommand) Synopsis() string {
+	return "Capture debugging information from a server into a bundle"
+}
+
+func (c *OperatorDebugCommand) Help() string {
+	helpText := `
+Usage: vault operator debug [options]
+
+  Capture the state of a Vault server over a period of time into a gzipped
+  tarball, which can be attached to a support escalation.
+
+  The sanitized server configuration is captured once. The metrics, health,
+  leader, seal and replication status, and the goroutine and heap profiles
+  are captured at every interval, into a directory named for the time of the
+  capture. A CPU profile and an execution trace are captured over the whole
+  duration, or over the first minute of it.
+
+  Most of these paths require a root or sudo token. A capture that fails is
+  skipped and its error is recorded in index.json in the bundle, so that
+  the rest of the bundle is still written. Interrupting the command writes
+  what was captured so far.
+
+      $ vault operator debug -duration=5m -interval=1m
+
+General Options:
+` + meta.GeneralOptionsUsage() + `
+Debug Options:
+
+  -duration=2m            How long to capture for.
+
+  -interval=30s           How often to capture the status of the server.
+                          Clamped to the duration.
+
+  -output=path            The file to write the bundle to. Defaults to
+                          vault-debug-<timestamp>.tar.gz in the current
+                          directory. An existing file is not overwritten.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorDebugCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorDebugCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-duration": complete.PredictAnything,
+		"-interval": complete.PredictAnything,
+		"-output":   complete.PredictFiles("*.tar.gz"),
+	}
+}
@@ -0,0 +1,106 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorDebug(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	dir, err := ioutil.TempDir("", "vault-debug-test")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "bundle.tar.gz")
+
+	ui := new(cli.MockUi)
+	c := &OperatorDebugCommand{
+		Meta: meta.Meta{
+			ClientToken: token,
+			Ui:          ui,
+		},
+	}
+
+	args := []string{
+		"-address", addr,
+		"-duration", "1s",
+		"-interval", "1s",
+		"-output", output,
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	tr := tar.NewReader(gzr)
+
+	files := make(map[string]bool)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		files[filepath.Base(header.Name)] = true
+		if !strings.HasPrefix(header.Name, "bundle/") {
+			t.Fatalf("bad: %s", header.Name)
+		}
+	}
+
+	// The test core has no configuration or metrics, so only the status
+	// paths and the profiles are captured
+	for _, name := range []string{
+		"index.json", "health.json", "leader.json", "seal-status.json",
+		"heap.prof", "goroutine.prof", "profile.prof", "trace.out",
+	} {
+		if !files[name] {
+			t.Fatalf("missing %s: %#v", name, files)
+		}
+	}
+
+	// The bundle is never overwritten
+	if code := c.Run(args); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+}
+
+func TestOperatorDebug_durationTargets(t *testing.T) {
+	cases := map[time.Duration]string{
+		500 * time.Millisecond: "1",
+		30 * time.Second:       "30",
+		2 * time.Minute:        "60",
+	}
+	for duration, expected := range cases {
+		for _, target := range debugDurationTargets(duration) {
+			if seconds := target.Params.Get("seconds"); seconds != expected {
+				t.Fatalf("%s: %s: expected %s seconds, got %s", duration, target.Path, expected, seconds)
+			}
+		}
+	}
+}
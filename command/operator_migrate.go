@@ -0,0 +1,215 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/command/server"
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/physical"
+	log "github.com/mgutz/logxi/v1"
+	"github.com/posener/complete"
+)
+
+// storageMigrateProgressInterval is the number of keys copied between
+// progress updates
+const storageMigrateProgressInterval = 1000
+
+// storageMigrateExcludedKeys are keys that are never copied, since they
+// hold state belonging to the running source storage rather than Vault data
+var storageMigrateExcludedKeys = []string{
+	"core/lock",
+}
+
+// OperatorMigrateCommand is a Command that copies all data from one physical
+// backend to another while Vault is offline.
+type OperatorMigrateCommand struct {
+	meta.Meta
+
+	PhysicalBackends map[string]physical.Factory
+}
+
+func (c *OperatorMigrateCommand) Run(args []string) int {
+	var configPath, start string
+	flags := c.Meta.FlagSet("operator migrate", meta.FlagSetNone)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	flags.StringVar(&configPath, "config", "", "")
+	flags.StringVar(&start, "start", "", "")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if configPath == "" {
+		c.Ui.Error("A configuration file must be specified with -config")
+		flags.Usage()
+		return 1
+	}
+
+	config, err := server.LoadMigrationConfigFile(configPath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error loading configuration from %s: %s", configPath, err))
+		return 1
+	}
+
+	logger := logformat.NewVaultLoggerWithWriter(os.Stderr, log.LevelError)
+
+	from, err := c.newBackend(config.StorageSource, logger)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing source storage: %s", err))
+		return 1
+	}
+	to, err := c.newBackend(config.StorageDestination, logger)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing destination storage: %s", err))
+		return 1
+	}
+
+	if start == "" {
+		keys, err := to.List("")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error checking destination storage: %s", err))
+			return 1
+		}
+		if len(keys) > 0 {
+			c.Ui.Error("Destination storage is not empty. To resume a previous " +
+				"migration, specify the key to continue from with -start.")
+			return 1
+		}
+	}
+
+	c.Ui.Output(fmt.Sprintf("==> Migrating from %q to %q storage",
+		config.StorageSource.Type, config.StorageDestination.Type))
+
+	copied, lastKey, err := c.migrate(from, to, start)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error migrating storage after copying %d keys: %s", copied, err))
+		if lastKey != "" {
+			c.Ui.Error(fmt.Sprintf("To resume the migration, run again with -start=%q", lastKey))
+		}
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Success! Migrated %d keys.", copied))
+	return 0
+}
+
+func (c *OperatorMigrateCommand) newBackend(storage *server.Storage, logger log.Logger) (physical.Backend, error) {
+	factory, ok := c.PhysicalBackends[storage.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown storage type %s", storage.Type)
+	}
+	return factory(storage.Config, logger)
+}
+
+// migrate copies every key, in lexical order, from one backend to another.
+// Keys that sort before start are skipped. It returns the number of keys
+// copied and the key at which to resume if an error occurs.
+func (c *OperatorMigrateCommand) migrate(from, to physical.Backend, start string) (int, string, error) {
+	var copied int
+	var current string
+	err := walkStorage(from, "", func(key string) error {
+		if key < start {
+			return nil
+		}
+		for _, excluded := range storageMigrateExcludedKeys {
+			if key == excluded {
+				return nil
+			}
+		}
+
+		current = key
+		entry, err := from.Get(key)
+		if err != nil {
+			return fmt.Errorf("error reading key %q: %v", key, err)
+		}
+		if entry == nil {
+			// Removed since it was listed
+			return nil
+		}
+		if err := to.Put(entry); err != nil {
+			return fmt.Errorf("error writing key %q: %v", key, err)
+		}
+
+		copied++
+		if copied%storageMigrateProgressInterval == 0 {
+			c.Ui.Output(fmt.Sprintf("Copied %d keys (last: %s)", copied, key))
+		}
+		return nil
+	})
+	return copied, current, err
+}
+
+// walkStorage calls fn for every key under the given prefix in lexical order
+func walkStorage(b physical.Backend, prefix string, fn func(string) error) error {
+	keys, err := b.List(prefix)
+	if err != nil {
+		return fmt.Errorf("error listing %q: %v", prefix, err)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		full := prefix + key
+		if strings.HasSuffix(key, "/") {
+			if err := walkStorage(b, full, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(full); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *OperatorMigrateCommand) Synopsis() string {
+	return "Copy all data between storage backends"
+}
+
+func (c *OperatorMigrateCommand) Help() string {
+	helpText := `
+Usage: vault operator migrate [options]
+
+  Copy all data from one storage backend to another.
+
+  This command operates directly on the storage backends and must only be run
+  while all Vault servers using the source storage are stopped. The
+  configuration file specifies the source and destination storage using the
+  same parameters as the server configuration:
+
+      storage_source "file" {
+        path = "/var/lib/vault"
+      }
+
+      storage_destination "consul" {
+        address = "127.0.0.1:8500"
+        path    = "vault/"
+      }
+
+  The destination storage must be empty unless resuming a previous migration
+  with -start. Keys are copied in lexical order, and if the migration fails
+  the key to resume from is reported.
+
+Operator Migrate Options:
+
+  -config=path            Path to the migration configuration file. Required.
+
+  -start=key              Only copy keys that sort at or after the given key.
+                          This is used to resume an interrupted migration.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorMigrateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorMigrateCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-config": complete.PredictOr(complete.PredictFiles("*.hcl"), complete.PredictFiles("*.json")),
+		"-start":  complete.PredictNothing,
+	}
+}
@@ -0,0 +1,170 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/physical"
+	physFile "github.com/hashicorp/vault/physical/file"
+	log "github.com/mgutz/logxi/v1"
+	"github.com/mitchellh/cli"
+)
+
+func testOperatorMigrate(t *testing.T) (string, physical.Backend, physical.Backend, func()) {
+	dir, err := ioutil.TempDir("", "vault-operator-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+	backends := make([]physical.Backend, 2)
+	for i, name := range []string{"source", "destination"} {
+		backends[i], err = physFile.NewFileBackend(map[string]string{
+			"path": filepath.Join(dir, name),
+		}, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configPath := filepath.Join(dir, "migrate.hcl")
+	config := fmt.Sprintf(`
+storage_source "file" {
+	path = %q
+}
+
+storage_destination "file" {
+	path = %q
+}`, filepath.Join(dir, "source"), filepath.Join(dir, "destination"))
+	if err := ioutil.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return configPath, backends[0], backends[1], func() { os.RemoveAll(dir) }
+}
+
+func testOperatorMigrateCommand() (*cli.MockUi, *OperatorMigrateCommand) {
+	ui := new(cli.MockUi)
+	return ui, &OperatorMigrateCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+		PhysicalBackends: map[string]physical.Factory{
+			"file": physFile.NewFileBackend,
+		},
+	}
+}
+
+func TestOperatorMigrate(t *testing.T) {
+	configPath, from, to, cleanup := testOperatorMigrate(t)
+	defer cleanup()
+
+	keys := []string{"core/keyring", "core/lock", "logical/abc/foo", "logical/abc/nested/bar", "sys/token/id/baz"}
+	for _, key := range keys {
+		if err := from.Put(&physical.Entry{Key: key, Value: []byte("value-" + key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ui, c := testOperatorMigrateCommand()
+	if code := c.Run([]string{"-config", configPath}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	for _, key := range keys {
+		entry, err := to.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key == "core/lock" {
+			if entry != nil {
+				t.Fatalf("lock should not be migrated")
+			}
+			continue
+		}
+		if entry == nil || string(entry.Value) != "value-"+key {
+			t.Fatalf("bad entry for %s: %#v", key, entry)
+		}
+	}
+
+	// A second run must refuse to write into the non-empty destination
+	ui, c = testOperatorMigrateCommand()
+	if code := c.Run([]string{"-config", configPath}); code != 1 {
+		t.Fatalf("expected failure for non-empty destination: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "-start") {
+		t.Fatalf("expected resume hint: %s", ui.ErrorWriter.String())
+	}
+}
+
+func TestOperatorMigrate_start(t *testing.T) {
+	configPath, from, to, cleanup := testOperatorMigrate(t)
+	defer cleanup()
+
+	for _, key := range []string{"a/1", "a/2", "b/1", "c"} {
+		if err := from.Put(&physical.Entry{Key: key, Value: []byte(key)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Simulate an interrupted migration that stopped at a/2
+	if err := to.Put(&physical.Entry{Key: "a/1", Value: []byte("a/1")}); err != nil {
+		t.Fatal(err)
+	}
+
+	ui, c := testOperatorMigrateCommand()
+	if code := c.Run([]string{"-config", configPath, "-start", "a/2"}); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "Migrated 3 keys") {
+		t.Fatalf("bad output: %s", ui.OutputWriter.String())
+	}
+
+	var migrated []string
+	if err := walkStorage(to, "", func(key string) error {
+		migrated = append(migrated, key)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a/1", "a/2", "b/1", "c"}
+	if !reflect.DeepEqual(migrated, expected) {
+		t.Fatalf("bad keys: expected %v, got %v", expected, migrated)
+	}
+}
+
+func TestOperatorMigrate_config(t *testing.T) {
+	ui, c := testOperatorMigrateCommand()
+	if code := c.Run(nil); code != 1 {
+		t.Fatalf("expected failure without config: %d", code)
+	}
+
+	dir, err := ioutil.TempDir("", "vault-operator-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "migrate.hcl")
+	if err := ioutil.WriteFile(configPath, []byte(`
+storage_source "unknown" {}
+storage_destination "file" {
+	path = "/tmp"
+}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ui, c = testOperatorMigrateCommand()
+	if code := c.Run([]string{"-config", configPath}); code != 1 {
+		t.Fatalf("expected failure for unknown storage: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "unknown storage type") {
+		t.Fatalf("bad error: %s", ui.ErrorWriter.String())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // PathHelpCommand is a Command that lists the mounts.
@@ -74,3 +75,11 @@ General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *PathHelpCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *PathHelpCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
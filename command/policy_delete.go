@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // PolicyDeleteCommand is a Command that enables a new endpoint.
@@ -63,3 +64,11 @@ General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *PolicyDeleteCommand) AutocompleteArgs() complete.Predictor {
+	return predictPolicies(&c.Meta)
+}
+
+func (c *PolicyDeleteCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // PolicyListCommand is a Command that enables a new endpoint.
@@ -13,7 +14,9 @@ type PolicyListCommand struct {
 }
 
 func (c *PolicyListCommand) Run(args []string) int {
+	var format string
 	flags := c.Meta.FlagSet("policy-list", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -21,9 +24,9 @@ func (c *PolicyListCommand) Run(args []string) int {
 
 	args = flags.Args()
 	if len(args) == 1 {
-		return c.read(args[0])
+		return c.read(args[0], format)
 	} else if len(args) == 0 {
-		return c.list()
+		return c.list(format)
 	} else {
 		flags.Usage()
 		c.Ui.Error(fmt.Sprintf(
@@ -32,7 +35,7 @@ func (c *PolicyListCommand) Run(args []string) int {
 	}
 }
 
-func (c *PolicyListCommand) list() int {
+func (c *PolicyListCommand) list(format string) int {
 	client, err := c.Client()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
@@ -47,6 +50,10 @@ func (c *PolicyListCommand) list() int {
 		return 1
 	}
 
+	if !isTableFormat(format) {
+		return OutputData(c.Ui, format, policies)
+	}
+
 	for _, p := range policies {
 		c.Ui.Output(p)
 	}
@@ -54,7 +61,7 @@ func (c *PolicyListCommand) list() int {
 	return 0
 }
 
-func (c *PolicyListCommand) read(n string) int {
+func (c *PolicyListCommand) read(n, format string) int {
 	client, err := c.Client()
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf(
@@ -69,6 +76,13 @@ func (c *PolicyListCommand) read(n string) int {
 		return 1
 	}
 
+	if !isTableFormat(format) {
+		return OutputData(c.Ui, format, map[string]string{
+			"name":  n,
+			"rules": rules,
+		})
+	}
+
 	c.Ui.Output(rules)
 	return 0
 }
@@ -87,6 +101,23 @@ Usage: vault policies [options] [name]
   If a name of a policy is specified, that policy is outputted.
 
 General Options:
-` + meta.GeneralOptionsUsage()
+` + meta.GeneralOptionsUsage() + `
+Policies Options:
+
+  -format=table           The format for output. By default it is a list of
+                          names or the raw policy rules. This can also be json
+                          or yaml. The default can be set with the VAULT_FORMAT
+                          environment variable.
+`
 	return strings.TrimSpace(helpText)
 }
+
+func (c *PolicyListCommand) AutocompleteArgs() complete.Predictor {
+	return predictPolicies(&c.Meta)
+}
+
+func (c *PolicyListCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format": predictFormat,
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // PolicyWriteCommand is a Command that enables a new endpoint.
@@ -88,3 +89,11 @@ General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *PolicyWriteCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(predictPolicies(&c.Meta), complete.PredictFiles("*.hcl"))
+}
+
+func (c *PolicyWriteCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
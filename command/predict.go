@@ -0,0 +1,117 @@
+package command
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
+)
+
+// predictTimeout bounds the requests made to predict arguments, since
+// completion runs while the user is typing
+const predictTimeout = 2 * time.Second
+
+// predictClient returns a client for predicting arguments. It does not retry
+// and gives up quickly, so that an unreachable server does not hang the
+// shell.
+func predictClient(m *meta.Meta) (*api.Client, error) {
+	client, err := m.Client()
+	if err != nil {
+		return nil, err
+	}
+	client.SetMaxRetries(0)
+	client.SetClientTimeout(predictTimeout)
+	return client, nil
+}
+
+// predictMounts predicts the paths of the secret backend mounts. Reading the
+// mount table requires a token that may read sys/mounts; without one
+// nothing is predicted.
+func predictMounts(m *meta.Meta) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := predictClient(m)
+		if err != nil {
+			return nil
+		}
+		mounts, err := client.Sys().ListMounts()
+		if err != nil {
+			return nil
+		}
+
+		paths := make([]string, 0, len(mounts))
+		for path := range mounts {
+			paths = append(paths, path)
+		}
+		return filterPredictions(paths, a.Last)
+	})
+}
+
+// predictAuthMounts predicts the paths of the auth backend mounts
+func predictAuthMounts(m *meta.Meta) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := predictClient(m)
+		if err != nil {
+			return nil
+		}
+		auths, err := client.Sys().ListAuth()
+		if err != nil {
+			return nil
+		}
+
+		paths := make([]string, 0, len(auths))
+		for path := range auths {
+			paths = append(paths, path)
+		}
+		return filterPredictions(paths, a.Last)
+	})
+}
+
+// predictAuditPaths predicts the paths of the enabled audit backends
+func predictAuditPaths(m *meta.Meta) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := predictClient(m)
+		if err != nil {
+			return nil
+		}
+		audits, err := client.Sys().ListAudit()
+		if err != nil {
+			return nil
+		}
+
+		paths := make([]string, 0, len(audits))
+		for path := range audits {
+			paths = append(paths, path)
+		}
+		return filterPredictions(paths, a.Last)
+	})
+}
+
+// predictPolicies predicts the names of the policies
+func predictPolicies(m *meta.Meta) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := predictClient(m)
+		if err != nil {
+			return nil
+		}
+		policies, err := client.Sys().ListPolicies()
+		if err != nil {
+			return nil
+		}
+		return filterPredictions(policies, a.Last)
+	})
+}
+
+// filterPredictions returns the sorted options that start with prefix
+func filterPredictions(options []string, prefix string) []string {
+	result := make([]string, 0, len(options))
+	for _, option := range options {
+		if strings.HasPrefix(option, prefix) {
+			result = append(result, option)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/meta"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+func TestPredictMounts(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	m := &meta.Meta{
+		ClientToken:  token,
+		ForceAddress: addr,
+		Ui:           new(cli.MockUi),
+	}
+
+	actual := predictMounts(m).Predict(complete.Args{Last: "s"})
+	expected := []string{"secret/", "sys/"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	actual = predictPolicies(m).Predict(complete.Args{Last: "r"})
+	expected = []string{"root"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
+func TestPredictMounts_noServer(t *testing.T) {
+	m := &meta.Meta{
+		ClientToken:  "foo",
+		ForceAddress: "http://127.0.0.1:0",
+		Ui:           new(cli.MockUi),
+	}
+
+	if actual := predictMounts(m).Predict(complete.Args{}); len(actual) != 0 {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
@@ -22,7 +22,7 @@ func (c *ReadCommand) Run(args []string) int {
 	var secret *api.Secret
 	var flags *flag.FlagSet
 	flags = c.Meta.FlagSet("read", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.StringVar(&field, "field", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
@@ -88,7 +88,9 @@ General Options:
 Read Options:
 
   -format=table           The format for output. By default it is a whitespace-
-                          delimited table. This can also be json or yaml.
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
 
   -field=field            If included, the raw value of the specified field
                           will be output raw to stdout.
@@ -98,7 +100,7 @@ Read Options:
 }
 
 func (c *ReadCommand) AutocompleteArgs() complete.Predictor {
-	return complete.PredictNothing
+	return predictMounts(&c.Meta)
 }
 
 func (c *ReadCommand) AutocompleteFlags() complete.Flags {
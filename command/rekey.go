@@ -29,7 +29,7 @@ type RekeyCommand struct {
 }
 
 func (c *RekeyCommand) Run(args []string) int {
-	var init, cancel, status, delete, retrieve, backup, recoveryKey bool
+	var init, cancel, status, delete, retrieve, backup, recoveryKey, verify, requireVerification bool
 	var shares, threshold int
 	var nonce string
 	var pgpKeys pgpkeys.PubKeyFilesFlag
@@ -41,6 +41,8 @@ func (c *RekeyCommand) Run(args []string) int {
 	flags.BoolVar(&retrieve, "retrieve", false, "")
 	flags.BoolVar(&backup, "backup", false, "")
 	flags.BoolVar(&recoveryKey, "recovery-key", c.RecoveryKey, "")
+	flags.BoolVar(&verify, "verify", false, "")
+	flags.BoolVar(&requireVerification, "require-verification", false, "")
 	flags.IntVar(&shares, "key-shares", 5, "")
 	flags.IntVar(&threshold, "key-threshold", 3, "")
 	flags.StringVar(&nonce, "nonce", "", "")
@@ -64,7 +66,9 @@ func (c *RekeyCommand) Run(args []string) int {
 	// Check if we are running doing any restricted variants
 	switch {
 	case init:
-		return c.initRekey(client, shares, threshold, pgpKeys, backup, recoveryKey)
+		return c.initRekey(client, shares, threshold, pgpKeys, backup, requireVerification, recoveryKey)
+	case cancel && verify:
+		return c.restartRekeyVerify(client, recoveryKey)
 	case cancel:
 		return c.cancelRekey(client, recoveryKey)
 	case status:
@@ -73,6 +77,8 @@ func (c *RekeyCommand) Run(args []string) int {
 		return c.rekeyRetrieveStored(client, recoveryKey)
 	case delete:
 		return c.rekeyDeleteStored(client, recoveryKey)
+	case verify:
+		return c.rekeyVerify(client, flags.Args(), recoveryKey)
 	}
 
 	// Check if the rekey is started
@@ -95,6 +101,8 @@ func (c *RekeyCommand) Run(args []string) int {
 				SecretThreshold: threshold,
 				PGPKeys:         pgpKeys,
 				Backup:          backup,
+
+				RequireVerification: requireVerification,
 			})
 		} else {
 			rekeyStatus, err = client.Sys().RekeyInit(&api.RekeyInitRequest{
@@ -102,6 +110,8 @@ func (c *RekeyCommand) Run(args []string) int {
 				SecretThreshold: threshold,
 				PGPKeys:         pgpKeys,
 				Backup:          backup,
+
+				RequireVerification: requireVerification,
 			})
 		}
 		if err != nil {
@@ -183,6 +193,19 @@ func (c *RekeyCommand) Run(args []string) int {
 
 	c.Ui.Output(fmt.Sprintf("\nOperation nonce: %s", result.Nonce))
 
+	if result.VerificationRequired {
+		c.Ui.Output(fmt.Sprintf(
+			"\n"+
+				"The new keys are not yet in effect. At least %d of them must be\n"+
+				"provided with 'vault rekey -verify' to verify that they were received\n"+
+				"before Vault is rekeyed. Until then, the current keys still unseal it.\n\n"+
+				"Verification nonce: %s",
+			threshold,
+			result.VerificationNonce,
+		))
+		return 0
+	}
+
 	if len(result.PGPFingerprints) > 0 && result.Backup {
 		c.Ui.Output(fmt.Sprintf(
 			"\n" +
@@ -213,13 +236,15 @@ func (c *RekeyCommand) Run(args []string) int {
 func (c *RekeyCommand) initRekey(client *api.Client,
 	shares, threshold int,
 	pgpKeys pgpkeys.PubKeyFilesFlag,
-	backup, recoveryKey bool) int {
+	backup, requireVerification, recoveryKey bool) int {
 	// Start the rekey
 	request := &api.RekeyInitRequest{
 		SecretShares:    shares,
 		SecretThreshold: threshold,
 		PGPKeys:         pgpKeys,
 		Backup:          backup,
+
+		RequireVerification: requireVerification,
 	}
 	var status *api.RekeyStatusResponse
 	var err error
@@ -311,10 +336,128 @@ func (c *RekeyCommand) dumpRekeyStatus(status *api.RekeyStatusResponse) int {
 		statString = fmt.Sprintf("%s\nPGP Key Fingerprints: %s", statString, status.PGPFingerprints)
 		statString = fmt.Sprintf("%s\nBackup Storage: %t", statString, status.Backup)
 	}
+	if status.VerificationRequired {
+		statString = fmt.Sprintf("%s\nVerification Required: %t", statString, status.VerificationRequired)
+	}
+	if status.VerificationNonce != "" {
+		statString = fmt.Sprintf("%s\nVerification Nonce: %s", statString, status.VerificationNonce)
+	}
 	c.Ui.Output(statString)
 	return 0
 }
 
+// rekeyVerify is used to provide one of the new keys to verify the rekey
+func (c *RekeyCommand) rekeyVerify(client *api.Client, args []string, recovery bool) int {
+	var status *api.RekeyVerificationStatusResponse
+	var err error
+	if recovery {
+		status, err = client.Sys().RekeyRecoveryKeyVerificationStatus()
+	} else {
+		status, err = client.Sys().RekeyVerificationStatus()
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading rekey verification status: %s", err))
+		return 1
+	}
+
+	nonce := c.Nonce
+	if nonce == "" {
+		nonce = status.Nonce
+	}
+
+	// Get the new key
+	key := c.Key
+	if len(args) > 0 {
+		key = args[0]
+	}
+	if key == "" {
+		fmt.Printf("Verification nonce: %s\n", nonce)
+		fmt.Printf("New key (will be hidden): ")
+		key, err = password.Read(os.Stdin)
+		fmt.Printf("\n")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf(
+				"Error attempting to ask for password. The raw error message\n"+
+					"is shown below, but the most common reason for this error is\n"+
+					"that you attempted to pipe a value into rekey or you're\n"+
+					"executing `vault rekey -verify` from outside of a terminal.\n\n"+
+					"Raw error: %s", err))
+			return 1
+		}
+	}
+
+	// Provide the key, this may potentially complete the verification
+	var result *api.RekeyVerificationUpdateResponse
+	if recovery {
+		result, err = client.Sys().RekeyRecoveryKeyVerificationUpdate(strings.TrimSpace(key), nonce)
+	} else {
+		result, err = client.Sys().RekeyVerificationUpdate(strings.TrimSpace(key), nonce)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error attempting rekey verification: %s", err))
+		return 1
+	}
+
+	if !result.Complete {
+		return c.rekeyVerifyStatus(client, recovery)
+	}
+
+	c.Ui.Output(fmt.Sprintf(
+		"\n"+
+			"Rekey verified. Vault now uses the new keys, and the previous keys\n"+
+			"no longer unseal it.\n\n"+
+			"Verification nonce: %s",
+		result.Nonce,
+	))
+	return 0
+}
+
+// restartRekeyVerify is used to discard the new keys provided so far to
+// verify the rekey
+func (c *RekeyCommand) restartRekeyVerify(client *api.Client, recovery bool) int {
+	var err error
+	if recovery {
+		err = client.Sys().RekeyRecoveryKeyVerificationCancel()
+	} else {
+		err = client.Sys().RekeyVerificationCancel()
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to restart rekey verification: %s", err))
+		return 1
+	}
+	c.Ui.Output("Rekey verification restarted.")
+	return c.rekeyVerifyStatus(client, recovery)
+}
+
+// rekeyVerifyStatus is used to fetch and dump the verification status
+func (c *RekeyCommand) rekeyVerifyStatus(client *api.Client, recovery bool) int {
+	var status *api.RekeyVerificationStatusResponse
+	var err error
+	if recovery {
+		status, err = client.Sys().RekeyRecoveryKeyVerificationStatus()
+	} else {
+		status, err = client.Sys().RekeyVerificationStatus()
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading rekey verification status: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf(
+		"Verification Nonce: %s\n"+
+			"Started: %t\n"+
+			"New Key Shares: %d\n"+
+			"New Key Threshold: %d\n"+
+			"Verification Progress: %d",
+		status.Nonce,
+		status.Started,
+		status.N,
+		status.T,
+		status.Progress,
+	))
+	return 0
+}
+
 func (c *RekeyCommand) rekeyRetrieveStored(client *api.Client, recovery bool) int {
 	var storedKeys *api.RekeyRetrieveResponse
 	var err error
@@ -414,6 +557,17 @@ Rekey Options:
                           storage. You can retrieve or delete them via the
                           'sys/rekey/backup' endpoint.
 
+  -require-verification=false
+                          If true, the new keys are not put in effect until a
+                          threshold of them is provided back with '-verify'.
+                          This ensures the new keys were received before the
+                          current keys stop working.
+
+  -verify                 Provide one of the new keys to verify a rekey started
+                          with '-require-verification'. With '-cancel', discard
+                          the new keys provided so far and restart the
+                          verification.
+
   -recovery-key=false     Whether to rekey the recovery key instead of the
                           barrier key. Only used with Vault HSM.
 `
@@ -437,5 +591,8 @@ func (c *RekeyCommand) AutocompleteFlags() complete.Flags {
 		"-pgp-keys":      complete.PredictNothing,
 		"-backup":        complete.PredictNothing,
 		"-recovery-key":  complete.PredictNothing,
+
+		"-verify":               complete.PredictNothing,
+		"-require-verification": complete.PredictNothing,
 	}
 }
@@ -3,6 +3,7 @@ package command
 import (
 	"encoding/hex"
 	"os"
+	"regexp"
 	"sort"
 	"strings"
 	"testing"
@@ -54,6 +55,78 @@ func TestRekey(t *testing.T) {
 	}
 }
 
+func TestRekey_verify(t *testing.T) {
+	core, keys, _ := vault.TestCoreUnsealed(t)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	ui := new(cli.MockUi)
+	c := &RekeyCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+	args := []string{"-address", addr, "-init", "-require-verification"}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	conf, err := core.RekeyConfig(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		c = &RekeyCommand{
+			Key:   hex.EncodeToString(key),
+			Nonce: conf.Nonce,
+			Meta: meta.Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run([]string{"-address", addr}); code != 0 {
+			t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+		}
+	}
+
+	var newKeys []string
+	keyRe := regexp.MustCompile(`(?m)^Key \d+: (.+)$`)
+	for _, match := range keyRe.FindAllStringSubmatch(ui.OutputWriter.String(), -1) {
+		newKeys = append(newKeys, match[1])
+	}
+	if len(newKeys) != 5 {
+		t.Fatalf("bad: %#v", newKeys)
+	}
+
+	// The new keys are not in effect until verified
+	config, err := core.SealAccess().BarrierConfig()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if config.SecretShares == 5 {
+		t.Fatal("should not rekey before verification")
+	}
+
+	for _, key := range newKeys[:3] {
+		c = &RekeyCommand{
+			Key: key,
+			Meta: meta.Meta{
+				Ui: ui,
+			},
+		}
+		if code := c.Run([]string{"-address", addr, "-verify"}); code != 0 {
+			t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+		}
+	}
+
+	config, err = core.SealAccess().BarrierConfig()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if config.SecretShares != 5 {
+		t.Fatal("should rekey")
+	}
+}
+
 func TestRekey_arg(t *testing.T) {
 	core, keys, _ := vault.TestCoreUnsealed(t)
 	ln, addr := http.TestServer(t, core)
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // RemountCommand is a Command that remounts a mounted secret backend
@@ -72,3 +73,11 @@ General Options:
 
 	return strings.TrimSpace(helpText)
 }
+
+func (c *RemountCommand) AutocompleteArgs() complete.Predictor {
+	return predictMounts(&c.Meta)
+}
+
+func (c *RemountCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // RevokeCommand is a Command that mounts a new mount.
@@ -93,3 +94,14 @@ Revoke Options:
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *RevokeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RevokeCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-prefix": complete.PredictNothing,
+		"-force":  complete.PredictNothing,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // RotateCommand is a Command that rotates the encryption key being used
@@ -44,6 +45,7 @@ func (c *RotateCommand) Run(args []string) int {
 
 	c.Ui.Output(fmt.Sprintf("Key Term: %d", status.Term))
 	c.Ui.Output(fmt.Sprintf("Installation Time: %v", status.InstallTime))
+	c.Ui.Output(fmt.Sprintf("Encryptions: %d", status.Encryptions))
 	return 0
 }
 
@@ -61,7 +63,18 @@ Usage: vault rotate [options]
   secrets written previously. This is an online operation and is not
   disruptive.
 
+  The key is also rotated automatically after a number of encryptions,
+  which can be configured at sys/rotate/config.
+
 General Options:
 ` + meta.GeneralOptionsUsage()
 	return strings.TrimSpace(helpText)
 }
+
+func (c *RotateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RotateCommand) AutocompleteFlags() complete.Flags {
+	return nil
+}
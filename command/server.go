@@ -2,6 +2,7 @@ package command
 
 import (
 	"encoding/base64"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -37,6 +38,7 @@ import (
 	"github.com/hashicorp/vault/helper/flag-slice"
 	"github.com/hashicorp/vault/helper/gated-writer"
 	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/mlock"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/reload"
@@ -95,23 +97,10 @@ func (c *ServerCommand) Run(args []string) int {
 	// Create a logger. We wrap it in a gated writer so that it doesn't
 	// start logging too early.
 	c.logGate = &gatedwriter.Writer{Writer: colorable.NewColorable(os.Stderr)}
-	var level int
 	logLevel = strings.ToLower(strings.TrimSpace(logLevel))
-	switch logLevel {
-	case "trace":
-		level = log.LevelTrace
-	case "debug":
-		level = log.LevelDebug
-	case "info":
-		level = log.LevelInfo
-	case "notice":
-		level = log.LevelNotice
-	case "warn":
-		level = log.LevelWarn
-	case "err":
-		level = log.LevelError
-	default:
-		c.Ui.Output(fmt.Sprintf("Unknown log level %s", logLevel))
+	level, err := parseLogLevel(logLevel)
+	if err != nil {
+		c.Ui.Output(err.Error())
 		return 1
 	}
 
@@ -191,6 +180,24 @@ func (c *ServerCommand) Run(args []string) int {
 		return 1
 	}
 
+	// The log level of the configuration applies unless it was given as a
+	// flag
+	logLevelFlagSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "log-level" {
+			logLevelFlagSet = true
+		}
+	})
+	if config.LogLevel != "" && !logLevelFlagSet {
+		logLevel = strings.ToLower(strings.TrimSpace(config.LogLevel))
+		level, err := parseLogLevel(logLevel)
+		if err != nil {
+			c.Ui.Output(err.Error())
+			return 1
+		}
+		c.logger.SetLevel(level)
+	}
+
 	// If mlockall(2) isn't supported, show a warning.  We disable this
 	// in dev because it is quite scary to see when first using Vault.
 	if !dev && !mlock.Supported() {
@@ -200,7 +207,8 @@ func (c *ServerCommand) Run(args []string) int {
 		c.Ui.Output("  Vault on an mlockall(2) enabled system is much more secure.\n")
 	}
 
-	if err := c.setupTelemetry(config); err != nil {
+	metricSink, err := c.setupTelemetry(config)
+	if err != nil {
 		c.Ui.Output(fmt.Sprintf("Error initializing telemetry: %s", err))
 		return 1
 	}
@@ -224,7 +232,73 @@ func (c *ServerCommand) Run(args []string) int {
 	infoKeys := make([]string, 0, 10)
 	info := make(map[string]string)
 
+	if config.HSM != nil && config.Seal != nil {
+		c.Ui.Output("Only one of 'hsm' and 'seal' may be configured")
+		return 1
+	}
+
 	var seal vault.Seal = &vault.DefaultSeal{}
+	var migrationSeal vault.Seal
+	if config.HSM != nil {
+		switch config.HSM.Type {
+		case "pkcs11":
+			pkcs11Seal, err := vault.NewPKCS11Seal(config.HSM.Config)
+			if err != nil {
+				c.Ui.Output(fmt.Sprintf(
+					"Error initializing HSM of type %s: %s",
+					config.HSM.Type, err))
+				return 1
+			}
+			seal = pkcs11Seal
+		default:
+			c.Ui.Output(fmt.Sprintf(
+				"Unknown HSM type %s", config.HSM.Type))
+			return 1
+		}
+
+		info["seal"] = fmt.Sprintf("hsm (%s)", config.HSM.Type)
+		infoKeys = append(infoKeys, "seal")
+	}
+	if config.Seal != nil {
+		var configuredSeal vault.Seal
+		switch config.Seal.Type {
+		case "awskms":
+			awsKMSSeal, err := vault.NewAWSKMSSeal(config.Seal.Config)
+			if err != nil {
+				c.Ui.Output(fmt.Sprintf(
+					"Error initializing seal of type %s: %s",
+					config.Seal.Type, err))
+				return 1
+			}
+			configuredSeal = awsKMSSeal
+		default:
+			c.Ui.Output(fmt.Sprintf(
+				"Unknown seal type %s", config.Seal.Type))
+			return 1
+		}
+
+		// A disabled seal is only used to migrate back to unseal keys;
+		// otherwise migration from unseal keys to the seal is possible
+		var disabled bool
+		if disabledRaw, ok := config.Seal.Config["disabled"]; ok {
+			disabled, err = parseutil.ParseBool(disabledRaw)
+			if err != nil {
+				c.Ui.Output(fmt.Sprintf(
+					"Error parsing 'disabled' for seal of type %s: %s",
+					config.Seal.Type, err))
+				return 1
+			}
+		}
+		if disabled {
+			migrationSeal = configuredSeal
+			info["seal"] = fmt.Sprintf("shamir (migrating from %s)", config.Seal.Type)
+		} else {
+			seal = configuredSeal
+			migrationSeal = &vault.DefaultSeal{}
+			info["seal"] = config.Seal.Type
+		}
+		infoKeys = append(infoKeys, "seal")
+	}
 
 	// Ensure that the seal finalizer is called, even if using verify-only
 	defer func() {
@@ -246,6 +320,7 @@ func (c *ServerCommand) Run(args []string) int {
 		RedirectAddr:       config.Storage.RedirectAddr,
 		HAPhysical:         nil,
 		Seal:               seal,
+		MigrationSeal:      migrationSeal,
 		AuditBackends:      c.AuditBackends,
 		CredentialBackends: c.CredentialBackends,
 		LogicalBackends:    c.LogicalBackends,
@@ -257,6 +332,13 @@ func (c *ServerCommand) Run(args []string) int {
 		ClusterName:        config.ClusterName,
 		CacheSize:          config.CacheSize,
 		PluginDirectory:    config.PluginDirectory,
+		MetricSink:         metricSink,
+		SanitizedConfig:    config.Sanitized(),
+	}
+	if !dev {
+		coreConfig.ReloadConfigFunc = func() error {
+			return c.Reload(c.reloadFuncsLock, c.reloadFuncs, configPath)
+		}
 	}
 	if dev {
 		coreConfig.DevToken = devRootTokenID
@@ -278,6 +360,13 @@ func (c *ServerCommand) Run(args []string) int {
 	// Initialize the separate HA storage backend, if it exists
 	var ok bool
 	if config.HAStorage != nil {
+		// Raft storage replicates its own data between the nodes of the
+		// cluster and elects the active node along the way
+		if config.Storage.Type == "raft" || config.HAStorage.Type == "raft" {
+			c.Ui.Output("Raft storage cannot be used with a separate HA storage")
+			return 1
+		}
+
 		factory, exists := c.PhysicalBackends[config.HAStorage.Type]
 		if !exists {
 			c.Ui.Output(fmt.Sprintf(
@@ -450,7 +539,24 @@ CLUSTER_SYNTHESIS_COMPLETE:
 	// Initialize the listeners
 	c.reloadFuncsLock.Lock()
 	lns := make([]net.Listener, 0, len(config.Listeners))
+	lnPurposes := make([]string, 0, len(config.Listeners))
+	lnUIs := make([]bool, 0, len(config.Listeners))
 	for i, lnConfig := range config.Listeners {
+		purpose, err := listenerPurpose(lnConfig.Config)
+		if err != nil {
+			c.Ui.Output(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+		ui, err := listenerUI(lnConfig.Config, purpose)
+		if err != nil {
+			c.Ui.Output(fmt.Sprintf(
+				"Error initializing listener of type %s: %s",
+				lnConfig.Type, err))
+			return 1
+		}
+
 		ln, props, reloadFunc, err := server.NewListener(lnConfig.Type, lnConfig.Config, c.logGate)
 		if err != nil {
 			c.Ui.Output(fmt.Sprintf(
@@ -460,6 +566,12 @@ CLUSTER_SYNTHESIS_COMPLETE:
 		}
 
 		lns = append(lns, ln)
+		lnPurposes = append(lnPurposes, purpose)
+		lnUIs = append(lnUIs, ui)
+		props["purpose"] = purpose
+		if ui {
+			props["ui"] = "enabled"
+		}
 
 		if reloadFunc != nil {
 			relSlice := (*c.reloadFuncs)["listener|"+lnConfig.Type]
@@ -467,7 +579,9 @@ CLUSTER_SYNTHESIS_COMPLETE:
 			(*c.reloadFuncs)["listener|"+lnConfig.Type] = relSlice
 		}
 
-		if !disableClustering && lnConfig.Type == "tcp" {
+		// Listeners only serving monitoring endpoints do not take part in
+		// request forwarding
+		if !disableClustering && lnConfig.Type == "tcp" && purpose == listenerPurposeAPI {
 			var addrRaw interface{}
 			var addr string
 			var ok bool
@@ -627,14 +741,22 @@ CLUSTER_SYNTHESIS_COMPLETE:
 		))
 	}
 
-	// Initialize the HTTP server
-	server := &http.Server{}
-	if err := http2.ConfigureServer(server, nil); err != nil {
-		c.Ui.Output(fmt.Sprintf("Error configuring server for HTTP/2: %s", err))
-		return 1
-	}
-	server.Handler = handler
-	for _, ln := range lns {
+	// Initialize the HTTP servers, one for each purpose of the listeners
+	monitoringHandler := vaulthttp.MonitoringHandler(handler)
+	uiHandler := vaulthttp.UIHandler(handler)
+	for i, ln := range lns {
+		server := &http.Server{}
+		if err := http2.ConfigureServer(server, nil); err != nil {
+			c.Ui.Output(fmt.Sprintf("Error configuring server for HTTP/2: %s", err))
+			return 1
+		}
+		server.Handler = handler
+		switch {
+		case lnPurposes[i] == listenerPurposeMonitoring:
+			server.Handler = monitoringHandler
+		case lnUIs[i]:
+			server.Handler = uiHandler
+		}
 		go server.Serve(ln)
 	}
 
@@ -1022,7 +1144,7 @@ func (c *ServerCommand) detectRedirect(detect physical.RedirectDetect,
 }
 
 // setupTelemetry is used to setup the telemetry sub-systems
-func (c *ServerCommand) setupTelemetry(config *server.Config) error {
+func (c *ServerCommand) setupTelemetry(config *server.Config) (*metricsutil.PrometheusSink, error) {
 	/* Setup telemetry
 	Aggregate on 10 second intervals for 1 minute. Expose the
 	metrics over stderr when there is a SIGUSR1 received.
@@ -1045,7 +1167,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	if telConfig.StatsiteAddr != "" {
 		sink, err := metrics.NewStatsiteSink(telConfig.StatsiteAddr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -1054,7 +1176,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 	if telConfig.StatsdAddr != "" {
 		sink, err := metrics.NewStatsdSink(telConfig.StatsdAddr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		fanout = append(fanout, sink)
 	}
@@ -1090,7 +1212,7 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 		sink, err := circonus.NewCirconusSink(cfg)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		sink.Start()
 		fanout = append(fanout, sink)
@@ -1105,21 +1227,24 @@ func (c *ServerCommand) setupTelemetry(config *server.Config) error {
 
 		sink, err := datadog.NewDogStatsdSink(telConfig.DogStatsDAddr, metricsConf.HostName)
 		if err != nil {
-			return fmt.Errorf("failed to start DogStatsD sink. Got: %s", err)
+			return nil, fmt.Errorf("failed to start DogStatsD sink. Got: %s", err)
 		}
 		sink.SetTags(tags)
 		fanout = append(fanout, sink)
 	}
 
+	// Keep the cumulative values for sys/metrics
+	prom := metricsutil.NewPrometheusSink()
+
 	// Initialize the global sink
 	if len(fanout) > 0 {
-		fanout = append(fanout, inm)
+		fanout = append(fanout, inm, prom)
 		metrics.NewGlobal(metricsConf, fanout)
 	} else {
 		metricsConf.EnableHostname = false
-		metrics.NewGlobal(metricsConf, inm)
+		metrics.NewGlobal(metricsConf, metrics.FanoutSink{inm, prom})
 	}
-	return nil
+	return prom, nil
 }
 
 func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]reload.ReloadFunc, configPath []string) error {
@@ -1128,12 +1253,55 @@ func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]rel
 
 	var reloadErrors *multierror.Error
 
+	// Re-read the configuration so that changed settings are picked up. If
+	// it cannot be loaded, reload using the settings already in use.
+	var config *server.Config
+	for _, path := range configPath {
+		current, err := server.LoadConfig(path, c.logger)
+		if err != nil {
+			reloadErrors = multierror.Append(reloadErrors, fmt.Errorf("Error loading configuration from %s: %v", path, err))
+			config = nil
+			break
+		}
+
+		if config == nil {
+			config = current
+		} else {
+			config = config.Merge(current)
+		}
+	}
+
+	if config != nil && config.LogLevel != "" {
+		level, err := parseLogLevel(strings.ToLower(strings.TrimSpace(config.LogLevel)))
+		if err != nil {
+			reloadErrors = multierror.Append(reloadErrors, err)
+		} else {
+			c.logger.SetLevel(level)
+		}
+	}
+
+	// Listener reload functions are registered in the order the listeners
+	// are configured, so each one is given the new configuration of the
+	// listener at the same position, if the listeners have not changed
+	listenerConfigs := make(map[string][]map[string]interface{})
+	if config != nil {
+		for _, lnConfig := range config.Listeners {
+			key := "listener|" + lnConfig.Type
+			listenerConfigs[key] = append(listenerConfigs[key], lnConfig.Config)
+		}
+	}
+
 	for k, relFuncs := range *reloadFuncs {
 		switch {
 		case strings.HasPrefix(k, "listener|"):
-			for _, relFunc := range relFuncs {
+			lnConfigs := listenerConfigs[k]
+			for i, relFunc := range relFuncs {
 				if relFunc != nil {
-					if err := relFunc(nil); err != nil {
+					var lnConfig map[string]interface{}
+					if len(lnConfigs) == len(relFuncs) {
+						lnConfig = lnConfigs[i]
+					}
+					if err := relFunc(lnConfig); err != nil {
 						reloadErrors = multierror.Append(reloadErrors, fmt.Errorf("Error encountered reloading listener: %v", err))
 					}
 				}
@@ -1153,6 +1321,76 @@ func (c *ServerCommand) Reload(lock *sync.RWMutex, reloadFuncs *map[string][]rel
 	return reloadErrors.ErrorOrNil()
 }
 
+const (
+	// listenerPurposeAPI is the default purpose of a listener, serving the
+	// whole API
+	listenerPurposeAPI = "api"
+
+	// listenerPurposeMonitoring restricts a listener to the endpoints
+	// reporting the status of the node
+	listenerPurposeMonitoring = "monitoring"
+)
+
+// listenerPurpose returns the purpose set in the configuration of a listener
+func listenerPurpose(config map[string]interface{}) (string, error) {
+	purposeRaw, ok := config["purpose"]
+	if !ok {
+		return listenerPurposeAPI, nil
+	}
+
+	purpose, ok := purposeRaw.(string)
+	if !ok {
+		return "", fmt.Errorf("failed parsing purpose value: not a string")
+	}
+
+	switch purpose = strings.ToLower(strings.TrimSpace(purpose)); purpose {
+	case "", listenerPurposeAPI:
+		return listenerPurposeAPI, nil
+	case listenerPurposeMonitoring:
+		return listenerPurposeMonitoring, nil
+	default:
+		return "", fmt.Errorf("invalid purpose %q; must be %q or %q", purpose, listenerPurposeAPI, listenerPurposeMonitoring)
+	}
+}
+
+// listenerUI returns whether the web UI is enabled in the configuration of a
+// listener. Only listeners serving the whole API can serve the UI.
+func listenerUI(config map[string]interface{}, purpose string) (bool, error) {
+	uiRaw, ok := config["ui"]
+	if !ok {
+		return false, nil
+	}
+
+	ui, err := parseutil.ParseBool(uiRaw)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for 'ui': %v", err)
+	}
+	if ui && purpose != listenerPurposeAPI {
+		return false, fmt.Errorf("the UI can only be enabled on listeners with the %q purpose", listenerPurposeAPI)
+	}
+	return ui, nil
+}
+
+// parseLogLevel returns the logxi level of a log level name
+func parseLogLevel(logLevel string) (int, error) {
+	switch logLevel {
+	case "trace":
+		return log.LevelTrace, nil
+	case "debug":
+		return log.LevelDebug, nil
+	case "info":
+		return log.LevelInfo, nil
+	case "notice":
+		return log.LevelNotice, nil
+	case "warn":
+		return log.LevelWarn, nil
+	case "err":
+		return log.LevelError, nil
+	default:
+		return 0, fmt.Errorf("Unknown log level %s", logLevel)
+	}
+}
+
 func (c *ServerCommand) Synopsis() string {
 	return "Start a Vault server"
 }
@@ -24,7 +24,8 @@ type Config struct {
 	Storage   *Storage    `hcl:"-"`
 	HAStorage *Storage    `hcl:"-"`
 
-	HSM *HSM `hcl:"-"`
+	HSM  *HSM  `hcl:"-"`
+	Seal *Seal `hcl:"-"`
 
 	CacheSize       int         `hcl:"cache_size"`
 	DisableCache    bool        `hcl:"-"`
@@ -44,6 +45,8 @@ type Config struct {
 
 	ClusterName     string `hcl:"cluster_name"`
 	PluginDirectory string `hcl:"plugin_directory"`
+
+	LogLevel string `hcl:"log_level"`
 }
 
 // DevConfig is a Config that is used for dev mode of Vault.
@@ -118,6 +121,16 @@ func (h *HSM) GoString() string {
 	return fmt.Sprintf("*%#v", *h)
 }
 
+// Seal contains the seal configuration for the server
+type Seal struct {
+	Type   string
+	Config map[string]string
+}
+
+func (s *Seal) GoString() string {
+	return fmt.Sprintf("*%#v", *s)
+}
+
 // Telemetry is the telemetry configuration for the server
 type Telemetry struct {
 	StatsiteAddr string `hcl:"statsite_address"`
@@ -239,6 +252,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.HSM = c2.HSM
 	}
 
+	result.Seal = c.Seal
+	if c2.Seal != nil {
+		result.Seal = c2.Seal
+	}
+
 	result.Telemetry = c.Telemetry
 	if c2.Telemetry != nil {
 		result.Telemetry = c2.Telemetry
@@ -286,9 +304,123 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.PluginDirectory = c2.PluginDirectory
 	}
 
+	result.LogLevel = c.LogLevel
+	if c2.LogLevel != "" {
+		result.LogLevel = c2.LogLevel
+	}
+
+	return result
+}
+
+// sensitiveConfigWords are the words of a storage, HA storage, HSM, seal or
+// listener configuration key that mark its value as a secret
+var sensitiveConfigWords = map[string]bool{
+	"credentials": true,
+	"key":         true,
+	"password":    true,
+	"pin":         true,
+	"secret":      true,
+	"token":       true,
+}
+
+// Sanitized returns a copy of the configuration that is safe to expose, with
+// every value that may be a secret, such as seal credentials, TLS keys or
+// storage tokens, removed.
+func (c *Config) Sanitized() map[string]interface{} {
+	result := map[string]interface{}{
+		"cache_size":        c.CacheSize,
+		"disable_cache":     c.DisableCache,
+		"disable_mlock":     c.DisableMlock,
+		"ui":                c.EnableUI,
+		"max_lease_ttl":     int64(c.MaxLeaseTTL.Seconds()),
+		"default_lease_ttl": int64(c.DefaultLeaseTTL.Seconds()),
+		"cluster_name":      c.ClusterName,
+		"plugin_directory":  c.PluginDirectory,
+		"log_level":         c.LogLevel,
+	}
+
+	var listeners []interface{}
+	for _, l := range c.Listeners {
+		config := make(map[string]interface{}, len(l.Config))
+		for k, v := range l.Config {
+			if !isSensitiveConfigKey(k) {
+				config[k] = v
+			}
+		}
+		listeners = append(listeners, map[string]interface{}{
+			"type":   l.Type,
+			"config": config,
+		})
+	}
+	result["listeners"] = listeners
+
+	if c.Storage != nil {
+		result["storage"] = c.Storage.sanitized()
+	}
+	if c.HAStorage != nil {
+		result["ha_storage"] = c.HAStorage.sanitized()
+	}
+	if c.HSM != nil {
+		result["hsm"] = map[string]interface{}{
+			"type":   c.HSM.Type,
+			"config": sanitizeConfigMap(c.HSM.Config),
+		}
+	}
+	if c.Seal != nil {
+		result["seal"] = map[string]interface{}{
+			"type":   c.Seal.Type,
+			"config": sanitizeConfigMap(c.Seal.Config),
+		}
+	}
+
+	if c.Telemetry != nil {
+		result["telemetry"] = map[string]interface{}{
+			"statsite_address":   c.Telemetry.StatsiteAddr,
+			"statsd_address":     c.Telemetry.StatsdAddr,
+			"disable_hostname":   c.Telemetry.DisableHostname,
+			"circonus_api_app":   c.Telemetry.CirconusAPIApp,
+			"circonus_api_url":   c.Telemetry.CirconusAPIURL,
+			"circonus_check_id":  c.Telemetry.CirconusCheckID,
+			"circonus_broker_id": c.Telemetry.CirconusBrokerID,
+			"dogstatsd_addr":     c.Telemetry.DogStatsDAddr,
+			"dogstatsd_tags":     c.Telemetry.DogStatsDTags,
+		}
+	}
+
+	return result
+}
+
+func (b *Storage) sanitized() map[string]interface{} {
+	return map[string]interface{}{
+		"type":               b.Type,
+		"redirect_addr":      b.RedirectAddr,
+		"cluster_addr":       b.ClusterAddr,
+		"disable_clustering": b.DisableClustering,
+		"config":             sanitizeConfigMap(b.Config),
+	}
+}
+
+func sanitizeConfigMap(m map[string]string) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		if !isSensitiveConfigKey(k) {
+			result[k] = v
+		}
+	}
 	return result
 }
 
+// isSensitiveConfigKey returns whether any underscore-separated word of the
+// key marks its value as a secret
+func isSensitiveConfigKey(key string) bool {
+	for _, word := range strings.Split(strings.ToLower(key), "_") {
+		if sensitiveConfigWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadConfig loads the configuration at the given path, regardless if
 // its a file or directory.
 func LoadConfig(path string, logger log.Logger) (*Config, error) {
@@ -367,6 +499,7 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		"backend",
 		"ha_backend",
 		"hsm",
+		"seal",
 		"listener",
 		"cache_size",
 		"disable_cache",
@@ -377,6 +510,7 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		"max_lease_ttl",
 		"cluster_name",
 		"plugin_directory",
+		"log_level",
 	}
 	if err := checkHCLKeys(list, valid); err != nil {
 		return nil, err
@@ -413,6 +547,12 @@ func ParseConfig(d string, logger log.Logger) (*Config, error) {
 		}
 	}
 
+	if o := list.Filter("seal"); len(o.Items) > 0 {
+		if err := parseSeal(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'seal': %s", err)
+		}
+	}
+
 	if o := list.Filter("listener"); len(o.Items) > 0 {
 		if err := parseListeners(&result, o); err != nil {
 			return nil, fmt.Errorf("error parsing 'listener': %s", err)
@@ -654,6 +794,51 @@ func parseHSMs(result *Config, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseSeal(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'seal' block is permitted")
+	}
+
+	// Get our item
+	item := list.Items[0]
+
+	key := "seal"
+	if len(item.Keys) > 0 {
+		key = item.Keys[0].Token.Value().(string)
+	}
+
+	var valid []string
+	switch strings.ToLower(key) {
+	case "awskms":
+		valid = []string{
+			"region",
+			"access_key",
+			"secret_key",
+			"session_token",
+			"endpoint",
+			"kms_key_id",
+			"disabled",
+		}
+	default:
+		return fmt.Errorf("invalid seal type %q", key)
+	}
+	if err := checkHCLKeys(item.Val, valid); err != nil {
+		return multierror.Prefix(err, fmt.Sprintf("seal.%s:", key))
+	}
+
+	var m map[string]string
+	if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		return multierror.Prefix(err, fmt.Sprintf("seal.%s:", key))
+	}
+
+	result.Seal = &Seal{
+		Type:   strings.ToLower(key),
+		Config: m,
+	}
+
+	return nil
+}
+
 func parseListeners(result *Config, list *ast.ObjectList) error {
 	listeners := make([]*Listener, 0, len(list.Items))
 	for _, item := range list.Items {
@@ -670,6 +855,10 @@ func parseListeners(result *Config, list *ast.ObjectList) error {
 			"node_id",
 			"proxy_protocol_behavior",
 			"proxy_protocol_authorized_addrs",
+			"purpose",
+			"socket_mode",
+			"socket_user",
+			"socket_group",
 			"tls_disable",
 			"tls_cert_file",
 			"tls_key_file",
@@ -679,6 +868,7 @@ func parseListeners(result *Config, list *ast.ObjectList) error {
 			"tls_require_and_verify_client_cert",
 			"tls_client_ca_file",
 			"token",
+			"ui",
 		}
 		if err := checkHCLKeys(item.Val, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("listeners.%s:", key))
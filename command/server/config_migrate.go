@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// MigrationConfig is the configuration for migrating data between storage
+// backends
+type MigrationConfig struct {
+	StorageSource      *Storage
+	StorageDestination *Storage
+}
+
+// LoadMigrationConfigFile loads the storage migration configuration from
+// a single file
+func LoadMigrationConfigFile(path string) (*MigrationConfig, error) {
+	d, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMigrationConfig(string(d))
+}
+
+// ParseMigrationConfig parses a storage migration configuration, which
+// must contain exactly one storage_source and one storage_destination block
+func ParseMigrationConfig(d string) (*MigrationConfig, error) {
+	obj, err := hcl.Parse(d)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := obj.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("error parsing: file doesn't contain a root object")
+	}
+
+	valid := []string{
+		"storage_source",
+		"storage_destination",
+	}
+	if err := checkHCLKeys(list, valid); err != nil {
+		return nil, err
+	}
+
+	var result MigrationConfig
+	for _, name := range valid {
+		o := list.Filter(name)
+		if len(o.Items) == 0 {
+			return nil, fmt.Errorf("missing %q block", name)
+		}
+
+		var parsed Config
+		if err := parseStorage(&parsed, o, name); err != nil {
+			return nil, fmt.Errorf("error parsing %q: %s", name, err)
+		}
+
+		switch name {
+		case "storage_source":
+			result.StorageSource = parsed.Storage
+		case "storage_destination":
+			result.StorageDestination = parsed.Storage
+		}
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/vault/helper/reload"
+)
+
+func unixListenerFactory(config map[string]interface{}, _ io.Writer) (net.Listener, map[string]string, reload.ReloadFunc, error) {
+	addrRaw, ok := config["address"]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("'address' must be set to the path of the socket")
+	}
+	addr, ok := addrRaw.(string)
+	if !ok || addr == "" {
+		return nil, nil, nil, fmt.Errorf("'address' must be set to the path of the socket")
+	}
+
+	mode, uid, gid, err := unixSocketPermissions(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Remove a socket left behind by a previous run; anything else at the
+	// path is left alone and makes listening fail
+	if fi, err := os.Lstat(addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(addr); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to remove existing socket: %v", err)
+		}
+	}
+
+	// The socket is created in a private directory next to its path and
+	// only moved into place once its owner and mode are set, so that it is
+	// never reachable by others before then. The umask is left alone, as it
+	// is process-wide and would also apply to files created concurrently.
+	dir, err := ioutil.TempDir(filepath.Dir(addr), ".vault-sock")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create a directory for the socket: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpAddr := filepath.Join(dir, "s")
+	ul, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpAddr, Net: "unix"})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// The socket is removed from its final path on close instead
+	ul.SetUnlinkOnClose(false)
+
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(tmpAddr, uid, gid); err != nil {
+			ul.Close()
+			return nil, nil, nil, fmt.Errorf("failed to set the owner of the socket: %v", err)
+		}
+	}
+	if mode == 0 {
+		mode = 0600
+	}
+	if err := os.Chmod(tmpAddr, mode); err != nil {
+		ul.Close()
+		return nil, nil, nil, fmt.Errorf("failed to set the mode of the socket: %v", err)
+	}
+	if err := os.Rename(tmpAddr, addr); err != nil {
+		ul.Close()
+		return nil, nil, nil, fmt.Errorf("failed to move the socket into place: %v", err)
+	}
+
+	ln := &unixSocketListener{Listener: ul, path: addr}
+	props := map[string]string{"addr": addr}
+	return listenerWrapTLS(ln, props, config)
+}
+
+// unixSocketListener removes the socket from its path when closed
+type unixSocketListener struct {
+	net.Listener
+	path string
+
+	closeOnce sync.Once
+}
+
+func (l *unixSocketListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() {
+		os.Remove(l.path)
+	})
+	return err
+}
+
+// unixSocketPermissions parses the socket_mode, socket_user and socket_group
+// of a unix listener. A mode of zero means that the socket is only accessible
+// to its owner, and IDs of -1 that the owner is left as created.
+func unixSocketPermissions(config map[string]interface{}) (os.FileMode, int, int, error) {
+	var mode os.FileMode
+	if v, ok := config["socket_mode"]; ok {
+		modeStr, ok := v.(string)
+		if !ok {
+			return 0, 0, 0, fmt.Errorf("invalid value for 'socket_mode': not a string")
+		}
+		parsed, err := strconv.ParseUint(modeStr, 8, 32)
+		if err != nil || parsed > 0777 {
+			return 0, 0, 0, fmt.Errorf("invalid value for 'socket_mode': %q is not an octal permission", modeStr)
+		}
+		mode = os.FileMode(parsed)
+	}
+
+	uid := -1
+	if v, ok := config["socket_user"]; ok {
+		name, ok := v.(string)
+		if !ok {
+			return 0, 0, 0, fmt.Errorf("invalid value for 'socket_user': not a string")
+		}
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return 0, 0, 0, fmt.Errorf("invalid value for 'socket_user': %v", err)
+			}
+			if id, err = strconv.Atoi(u.Uid); err != nil {
+				return 0, 0, 0, fmt.Errorf("invalid value for 'socket_user': non-numeric uid %q", u.Uid)
+			}
+		}
+		uid = id
+	}
+
+	gid := -1
+	if v, ok := config["socket_group"]; ok {
+		name, ok := v.(string)
+		if !ok {
+			return 0, 0, 0, fmt.Errorf("invalid value for 'socket_group': not a string")
+		}
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return 0, 0, 0, fmt.Errorf("invalid value for 'socket_group': %v", err)
+			}
+			if id, err = strconv.Atoi(g.Gid); err != nil {
+				return 0, 0, 0, fmt.Errorf("invalid value for 'socket_group': non-numeric gid %q", g.Gid)
+			}
+		}
+		gid = id
+	}
+
+	return mode, uid, gid, nil
+}
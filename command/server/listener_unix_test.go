@@ -0,0 +1,83 @@
+package server
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestUnixListener(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-test-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "vault.sock")
+	ln, props, _, err := unixListenerFactory(map[string]interface{}{
+		"address":      path,
+		"socket_mode":  "0600",
+		"socket_user":  strconv.Itoa(os.Getuid()),
+		"socket_group": strconv.Itoa(os.Getgid()),
+		"tls_disable":  "1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if props["addr"] != path {
+		t.Fatalf("bad: %#v", props)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if fi.Mode()&os.ModePerm != 0600 {
+		t.Fatalf("bad mode: %v", fi.Mode())
+	}
+
+	connFn := func(lnReal net.Listener) (net.Conn, error) {
+		return net.Dial("unix", path)
+	}
+
+	testListenerImpl(t, ln, connFn, "")
+
+	// A socket left behind is replaced, and without a mode it is only
+	// accessible to its owner
+	ln, _, _, err = unixListenerFactory(map[string]interface{}{
+		"address":     path,
+		"tls_disable": "1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	fi, err = os.Stat(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if fi.Mode()&0077 != 0 {
+		t.Fatalf("bad mode: %v", fi.Mode())
+	}
+	ln.Close()
+
+	// Closing the listener removes the socket, and nothing else is left in
+	// its directory
+	entries, err := ioutil.ReadDir(td)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("bad: %d entries left", len(entries))
+	}
+
+	if _, _, _, err := unixListenerFactory(map[string]interface{}{
+		"address":     path,
+		"socket_mode": "999",
+		"tls_disable": "1",
+	}, nil); err == nil {
+		t.Fatal("expected error for an invalid mode")
+	}
+}
@@ -67,6 +67,128 @@ listener "tcp" {
 `
 )
 
+func TestServer_HSMConfig(t *testing.T) {
+	td, err := ioutil.TempDir("", "vault-test-hsm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(td)
+
+	hsmhcl := `
+hsm "pkcs11" {
+    lib = "` + td + `/libmissing.so"
+    slot = "0"
+    pin = "1234"
+    key_label = "vault"
+}
+`
+	cases := map[string]struct {
+		config   string
+		expected string
+	}{
+		"missing library": {
+			hsmhcl,
+			"Error initializing HSM of type pkcs11",
+		},
+		"regenerate key": {
+			strings.Replace(hsmhcl, `key_label = "vault"`, `key_label = "vault"
+    regenerate_key = "true"`, 1),
+			"'regenerate_key' is not supported",
+		},
+		"unknown type": {
+			strings.Replace(hsmhcl, `hsm "pkcs11"`, `hsm "other"`, 1),
+			"Unknown HSM type other",
+		},
+		"hsm and seal": {
+			hsmhcl + `
+seal "awskms" {
+    kms_key_id = "alias/vault"
+}
+`,
+			"Only one of 'hsm' and 'seal' may be configured",
+		},
+	}
+
+	for name, tc := range cases {
+		config := basehcl + `
+backend "file" {
+    path = "` + td + `/data"
+}
+` + tc.config
+		if err := ioutil.WriteFile(td+"/hsm.hcl", []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui := new(cli.MockUi)
+		c := &ServerCommand{
+			Meta: meta.Meta{
+				Ui: ui,
+			},
+			ShutdownCh: MakeShutdownCh(),
+			SighupCh:   MakeSighupCh(),
+			PhysicalBackends: map[string]physical.Factory{
+				"file": physFile.NewFileBackend,
+			},
+		}
+
+		if code := c.Run([]string{"-config", td + "/hsm.hcl"}); code != 1 {
+			t.Fatalf("%s: expected exit code 1, got %d", name, code)
+		}
+		if !strings.Contains(ui.OutputWriter.String(), tc.expected) {
+			t.Fatalf("%s: bad output: %s", name, ui.OutputWriter.String())
+		}
+	}
+}
+
+func TestServer_listenerPurpose(t *testing.T) {
+	cases := []struct {
+		config   map[string]interface{}
+		expected string
+		err      bool
+	}{
+		{map[string]interface{}{}, "api", false},
+		{map[string]interface{}{"purpose": "api"}, "api", false},
+		{map[string]interface{}{"purpose": "Monitoring"}, "monitoring", false},
+		{map[string]interface{}{"purpose": "bogus"}, "", true},
+		{map[string]interface{}{"purpose": 1}, "", true},
+	}
+
+	for _, tc := range cases {
+		purpose, err := listenerPurpose(tc.config)
+		if (err != nil) != tc.err {
+			t.Fatalf("bad: %#v: err: %v", tc.config, err)
+		}
+		if purpose != tc.expected {
+			t.Fatalf("bad: %#v: expected %q, got %q", tc.config, tc.expected, purpose)
+		}
+	}
+}
+
+func TestServer_listenerUI(t *testing.T) {
+	cases := []struct {
+		config   map[string]interface{}
+		purpose  string
+		expected bool
+		err      bool
+	}{
+		{map[string]interface{}{}, "api", false, false},
+		{map[string]interface{}{"ui": true}, "api", true, false},
+		{map[string]interface{}{"ui": "false"}, "api", false, false},
+		{map[string]interface{}{"ui": "bogus"}, "api", false, true},
+		{map[string]interface{}{"ui": true}, "monitoring", false, true},
+	}
+
+	for _, tc := range cases {
+		ui, err := listenerUI(tc.config, tc.purpose)
+		if (err != nil) != tc.err {
+			t.Fatalf("bad: %#v: err: %v", tc.config, err)
+		}
+		if ui != tc.expected {
+			t.Fatalf("bad: %#v: expected %t, got %t", tc.config, tc.expected, ui)
+		}
+	}
+}
+
 // The following tests have a go-metrics/exp manager race condition
 func TestServer_ReloadListener(t *testing.T) {
 	wd, _ := os.Getwd()
@@ -173,6 +295,20 @@ func TestServer_ReloadListener(t *testing.T) {
 		t.Fatalf("certificate name didn't check out: %s", err)
 	}
 
+	// Point the listener at different files
+	relhcl = strings.Replace(reloadhcl, "TMPDIR/reload_cert.pem", wd+"reload_foo.pem", -1)
+	relhcl = strings.Replace(relhcl, "TMPDIR/reload_key.pem", wd+"reload_foo.key", -1)
+	ioutil.WriteFile(td+"/reload.hcl", []byte(relhcl), 0777)
+
+	c.SighupCh <- struct{}{}
+	checkFinished()
+	time.Sleep(2 * time.Second)
+	checkFinished()
+
+	if err := testCertificateName("foo.example.com"); err != nil {
+		t.Fatalf("certificate name didn't check out: %s", err)
+	}
+
 	c.ShutdownCh <- struct{}{}
 
 	wg.Wait()
@@ -16,6 +16,7 @@ import (
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
+	"github.com/posener/complete"
 )
 
 // SSHCommand is a Command that establishes a SSH connection with target by
@@ -79,7 +80,7 @@ func (c *SSHCommand) Run(args []string) int {
 	// Common options
 	flags.StringVar(&c.mode, "mode", "", "")
 	flags.BoolVar(&c.noExec, "no-exec", false, "")
-	flags.StringVar(&c.format, "format", "table", "")
+	flags.StringVar(&c.format, "format", defaultFormat(), "")
 	flags.StringVar(&c.mountPoint, "mount-point", "ssh", "")
 	flags.StringVar(&c.role, "role", "", "")
 
@@ -679,3 +680,23 @@ CA Mode Options:
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *SSHCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SSHCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-mode":                     complete.PredictSet("ca", "dynamic", "otp"),
+		"-role":                     complete.PredictNothing,
+		"-no-exec":                  complete.PredictNothing,
+		"-format":                   predictFormat,
+		"-mount-point":              complete.PredictNothing,
+		"-strict-host-key-checking": complete.PredictSet("yes", "no", "ask"),
+		"-user-known-hosts-file":    complete.PredictFiles("*"),
+		"-public-key-path":          complete.PredictFiles("*"),
+		"-private-key-path":         complete.PredictFiles("*"),
+		"-host-key-mount-point":     complete.PredictNothing,
+		"-host-key-hostnames":       complete.PredictNothing,
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // StatusCommand is a Command that outputs the status of whether
@@ -15,7 +16,9 @@ type StatusCommand struct {
 }
 
 func (c *StatusCommand) Run(args []string) int {
+	var format string
 	flags := c.Meta.FlagSet("status", meta.FlagSetDefault)
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -35,6 +38,34 @@ func (c *StatusCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Mask the 'Vault is sealed' error, since this means HA is enabled,
+	// but that we cannot query for the leader since we are sealed.
+	leaderStatus, err := client.Sys().Leader()
+	if err != nil && strings.Contains(err.Error(), "Vault is sealed") {
+		leaderStatus = &api.LeaderResponse{HAEnabled: true}
+		err = nil
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf(
+			"Error checking leader status: %s", err))
+		return 1
+	}
+
+	sealedCode := 0
+	if sealStatus.Sealed {
+		sealedCode = 2
+	}
+
+	if !isTableFormat(format) {
+		if code := OutputData(c.Ui, format, &statusOutput{
+			SealStatusResponse: sealStatus,
+			LeaderResponse:     leaderStatus,
+		}); code != 0 {
+			return code
+		}
+		return sealedCode
+	}
+
 	outStr := fmt.Sprintf(
 		"Sealed: %v\n"+
 			"Key Shares: %d\n"+
@@ -55,19 +86,6 @@ func (c *StatusCommand) Run(args []string) int {
 
 	c.Ui.Output(outStr)
 
-	// Mask the 'Vault is sealed' error, since this means HA is enabled,
-	// but that we cannot query for the leader since we are sealed.
-	leaderStatus, err := client.Sys().Leader()
-	if err != nil && strings.Contains(err.Error(), "Vault is sealed") {
-		leaderStatus = &api.LeaderResponse{HAEnabled: true}
-		err = nil
-	}
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf(
-			"Error checking leader status: %s", err))
-		return 1
-	}
-
 	// Output if HA is enabled
 	c.Ui.Output("")
 	c.Ui.Output(fmt.Sprintf("High-Availability Enabled: %v", leaderStatus.HAEnabled))
@@ -87,15 +105,23 @@ func (c *StatusCommand) Run(args []string) int {
 			if leaderStatus.LeaderClusterAddress == "" {
 				leaderStatus.LeaderClusterAddress = "<none>"
 			}
+			if leaderStatus.LeaderID == "" {
+				leaderStatus.LeaderID = "<none>"
+			}
+			c.Ui.Output(fmt.Sprintf("\tLeader: %s", leaderStatus.LeaderAddress))
 			c.Ui.Output(fmt.Sprintf("\tLeader Cluster Address: %s", leaderStatus.LeaderClusterAddress))
+			c.Ui.Output(fmt.Sprintf("\tLeader ID: %s", leaderStatus.LeaderID))
 		}
 	}
 
-	if sealStatus.Sealed {
-		return 2
-	} else {
-		return 0
-	}
+	return sealedCode
+}
+
+// statusOutput is the seal and HA status as output in the json and yaml
+// formats
+type statusOutput struct {
+	*api.SealStatusResponse
+	*api.LeaderResponse
 }
 
 func (c *StatusCommand) Synopsis() string {
@@ -112,6 +138,23 @@ Usage: vault status [options]
   code also reflects the seal status (0 unsealed, 2 sealed, 1 error).
 
 General Options:
-` + meta.GeneralOptionsUsage()
+` + meta.GeneralOptionsUsage() + `
+Status Options:
+
+  -format=table           The format for output. By default it is a whitespace-
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
+`
 	return strings.TrimSpace(helpText)
 }
+
+func (c *StatusCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *StatusCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format": predictFormat,
+	}
+}
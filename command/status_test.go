@@ -1,6 +1,7 @@
 package command
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/hashicorp/vault/http"
@@ -37,3 +38,33 @@ func TestStatus(t *testing.T) {
 		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
 	}
 }
+
+func TestStatus_json(t *testing.T) {
+	ui := new(cli.MockUi)
+	c := &StatusCommand{
+		Meta: meta.Meta{
+			Ui: ui,
+		},
+	}
+
+	core := vault.TestCore(t)
+	vault.TestCoreInit(t, core)
+	ln, addr := http.TestServer(t, core)
+	defer ln.Close()
+
+	args := []string{"-address", addr, "-format", "json"}
+	if code := c.Run(args); code != 2 {
+		t.Fatalf("bad: %d\n\n%s", code, ui.ErrorWriter.String())
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &out); err != nil {
+		t.Fatalf("err: %s\n\n%s", err, ui.OutputWriter.String())
+	}
+	if out["sealed"] != true {
+		t.Fatalf("bad: %#v", out)
+	}
+	if _, ok := out["ha_enabled"]; !ok {
+		t.Fatalf("bad: %#v", out)
+	}
+}
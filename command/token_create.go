@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/vault/helper/flag-kv"
 	"github.com/hashicorp/vault/helper/flag-slice"
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // TokenCreateCommand is a Command that mounts a new mount.
@@ -23,7 +24,7 @@ func (c *TokenCreateCommand) Run(args []string) int {
 	var numUses int
 	var policies []string
 	flags := c.Meta.FlagSet("mount", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.StringVar(&displayName, "display-name", "", "")
 	flags.StringVar(&id, "id", "", "")
 	flags.StringVar(&lease, "lease", "", "")
@@ -165,7 +166,9 @@ Token Options:
                           it is automatically revoked.
 
   -format=table           The format for output. By default it is a whitespace-
-                          delimited table. This can also be json or yaml.
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
 
   -role=name              If set, the token will be created against the named
                           role. The role may override other parameters. This
@@ -174,3 +177,26 @@ Token Options:
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *TokenCreateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *TokenCreateCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format":            predictFormat,
+		"-display-name":      complete.PredictNothing,
+		"-id":                complete.PredictNothing,
+		"-lease":             complete.PredictNothing,
+		"-ttl":               complete.PredictNothing,
+		"-explicit-max-ttl":  complete.PredictNothing,
+		"-period":            complete.PredictNothing,
+		"-role":              complete.PredictNothing,
+		"-orphan":            complete.PredictNothing,
+		"-renewable":         complete.PredictSet("true", "false"),
+		"-no-default-policy": complete.PredictNothing,
+		"-use-limit":         complete.PredictNothing,
+		"-metadata":          complete.PredictNothing,
+		"-policy":            predictPolicies(&c.Meta),
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // TokenLookupCommand is a Command that outputs details about the
@@ -19,7 +20,7 @@ func (c *TokenLookupCommand) Run(args []string) int {
 	var accessor bool
 	flags := c.Meta.FlagSet("token-lookup", meta.FlagSetDefault)
 	flags.BoolVar(&accessor, "accessor", false, "")
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -93,8 +94,21 @@ Token Lookup Options:
                           (and for revocation via '/auth/token/revoke-accessor/<accessor>' endpoint).
 
   -format=table           The format for output. By default it is a whitespace-
-                          delimited table. This can also be json or yaml.
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
 
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *TokenLookupCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *TokenLookupCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-accessor": complete.PredictNothing,
+		"-format":   predictFormat,
+	}
+}
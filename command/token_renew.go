@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // TokenRenewCommand is a Command that mounts a new mount.
@@ -18,7 +19,7 @@ type TokenRenewCommand struct {
 func (c *TokenRenewCommand) Run(args []string) int {
 	var format, increment string
 	flags := c.Meta.FlagSet("token-renew", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.StringVar(&increment, "increment", "", "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
@@ -108,8 +109,21 @@ Token Renew Options:
                           of seconds or a string duration (e.g. "72h").
 
   -format=table           The format for output. By default it is a whitespace-
-                          delimited table. This can also be json or yaml.
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
 
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *TokenRenewCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *TokenRenewCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-format":    predictFormat,
+		"-increment": complete.PredictNothing,
+	}
+}
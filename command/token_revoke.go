@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // TokenRevokeCommand is a Command that mounts a new mount.
@@ -136,3 +137,15 @@ Token Options:
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *TokenRevokeCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *TokenRevokeCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-accessor": complete.PredictNothing,
+		"-self":     complete.PredictNothing,
+		"-mode":     complete.PredictSet("orphan", "path"),
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/hashicorp/vault/helper/password"
 	"github.com/hashicorp/vault/meta"
+	"github.com/posener/complete"
 )
 
 // UnsealCommand is a Command that unseals the vault.
@@ -19,9 +20,10 @@ type UnsealCommand struct {
 }
 
 func (c *UnsealCommand) Run(args []string) int {
-	var reset bool
+	var reset, migrate bool
 	flags := c.Meta.FlagSet("unseal", meta.FlagSetDefault)
 	flags.BoolVar(&reset, "reset", false, "")
+	flags.BoolVar(&migrate, "migrate", false, "")
 	flags.Usage = func() { c.Ui.Error(c.Help()) }
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -71,7 +73,11 @@ func (c *UnsealCommand) Run(args []string) int {
 				return 1
 			}
 		}
-		sealStatus, err = client.Sys().Unseal(strings.TrimSpace(value))
+		if migrate {
+			sealStatus, err = client.Sys().UnsealMigrate(strings.TrimSpace(value))
+		} else {
+			sealStatus, err = client.Sys().Unseal(strings.TrimSpace(value))
+		}
 	}
 
 	if err != nil {
@@ -123,6 +129,23 @@ Unseal Options:
   -reset                  Reset the unsealing process by throwing away
                           prior keys in process to unseal the vault.
 
+  -migrate                Migrate the vault from its previous seal to the
+                          seal it is currently configured with. Provide
+                          unseal keys when migrating to a seal storing the
+                          master key, and recovery keys when migrating
+                          away from one.
+
 `
 	return strings.TrimSpace(helpText)
 }
+
+func (c *UnsealCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *UnsealCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-reset":   complete.PredictNothing,
+		"-migrate": complete.PredictNothing,
+	}
+}
@@ -23,7 +23,7 @@ func (c *WriteCommand) Run(args []string) int {
 	var field, format string
 	var force bool
 	flags := c.Meta.FlagSet("write", meta.FlagSetDefault)
-	flags.StringVar(&format, "format", "table", "")
+	flags.StringVar(&format, "format", defaultFormat(), "")
 	flags.StringVar(&field, "field", "", "")
 	flags.BoolVar(&force, "force", false, "")
 	flags.BoolVar(&force, "f", false, "")
@@ -132,7 +132,9 @@ Write Options:
                           need or expect any fields to be specified.
 
   -format=table           The format for output. By default it is a whitespace-
-                          delimited table. This can also be json or yaml.
+                          delimited table. This can also be json or yaml. The
+                          default can be set with the VAULT_FORMAT environment
+                          variable.
 
   -field=field            If included, the raw value of the specified field
                           will be output raw to stdout.
@@ -142,7 +144,7 @@ Write Options:
 }
 
 func (c *WriteCommand) AutocompleteArgs() complete.Predictor {
-	return complete.PredictNothing
+	return predictMounts(&c.Meta)
 }
 
 func (c *WriteCommand) AutocompleteFlags() complete.Flags {
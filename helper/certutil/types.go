@@ -287,7 +287,8 @@ func (p *ParsedCertBundle) ToCertBundle() (*CertBundle, error) {
 
 // Verify checks if the parsed bundle is valid.  It validates the public
 // key of the certificate to the private key and checks the certificate trust
-// chain for path issues.
+// chain for path issues, including that each certificate was signed by the
+// next one in the chain.
 func (p *ParsedCertBundle) Verify() error {
 	// If private key exists, check if it matches the public key of cert
 	if p.PrivateKey != nil && p.Certificate != nil {
@@ -310,6 +311,10 @@ func (p *ParsedCertBundle) Verify() error {
 				return fmt.Errorf("certificate %d of certificate chain ca trust path is incorrect (%s/%s)",
 					i+1, certPath[i].Certificate.Subject.CommonName, caCert.Certificate.Subject.CommonName)
 			}
+			if err := certPath[i].Certificate.CheckSignatureFrom(caCert.Certificate); err != nil {
+				return fmt.Errorf("certificate %d of certificate chain was not signed by certificate %d (%s/%s): %s",
+					i, i+1, certPath[i].Certificate.Subject.CommonName, caCert.Certificate.Subject.CommonName, err)
+			}
 		}
 	}
 
@@ -83,6 +83,18 @@ func IPBelongsToCIDRBlocksSlice(ipAddr string, cidrs []string) (bool, error) {
 	return false, nil
 }
 
+// RemoteAddrIsOk checks if the given remote address may be used with the
+// given CIDR blocks: either no blocks are given, or the address belongs to
+// one of them. An empty or unparseable address is never in any block.
+func RemoteAddrIsOk(remoteAddr string, boundCIDRs []string) bool {
+	if len(boundCIDRs) == 0 {
+		return true
+	}
+
+	belongs, err := IPBelongsToCIDRBlocksSlice(remoteAddr, boundCIDRs)
+	return err == nil && belongs
+}
+
 // ValidateCIDRListString checks if the list of CIDR blocks are valid, given
 // that the input is a string composed by joining all the CIDR blocks using a
 // separator. The input is separated based on the given separator and validity
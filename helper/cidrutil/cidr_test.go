@@ -238,3 +238,25 @@ func TestCIDRUtil_SubsetBlocks(t *testing.T) {
 		t.Fatalf("expected CIDR blocks %q to not be a subset of CIDR blocks %q", cidrBlocks2, cidrBlocks1)
 	}
 }
+
+func TestCIDRUtil_RemoteAddrIsOk(t *testing.T) {
+	cases := []struct {
+		remoteAddr string
+		boundCIDRs []string
+		expected   bool
+	}{
+		{"127.0.0.1", nil, true},
+		{"", nil, true},
+		{"127.0.0.1", []string{"127.0.0.0/8"}, true},
+		{"10.0.0.1", []string{"127.0.0.0/8", "10.0.0.0/24"}, true},
+		{"10.0.1.1", []string{"127.0.0.0/8", "10.0.0.0/24"}, false},
+		{"", []string{"127.0.0.0/8"}, false},
+		{"foo", []string{"127.0.0.0/8"}, false},
+	}
+
+	for _, tc := range cases {
+		if actual := RemoteAddrIsOk(tc.remoteAddr, tc.boundCIDRs); actual != tc.expected {
+			t.Fatalf("%q in %q: expected %t, got %t", tc.remoteAddr, tc.boundCIDRs, tc.expected, actual)
+		}
+	}
+}
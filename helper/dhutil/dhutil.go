@@ -0,0 +1,104 @@
+package dhutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// PublicKeyInfo is the JSON representation of a curve25519 public key, as
+// read by a sink from a file supplied by the consumer of the token
+type PublicKeyInfo struct {
+	Curve25519PublicKey []byte `json:"curve25519_public_key"`
+}
+
+// Envelope is the JSON representation of a payload encrypted with a shared
+// key. It carries the public key of the sender so that the receiver can
+// derive the same shared key.
+type Envelope struct {
+	Curve25519PublicKey []byte `json:"curve25519_public_key"`
+	Nonce               []byte `json:"nonce"`
+	EncryptedPayload    []byte `json:"encrypted_payload"`
+}
+
+// GeneratePublicPrivateKey generates a new curve25519 key pair
+func GeneratePublicPrivateKey() ([]byte, []byte, error) {
+	var scalar, public [32]byte
+
+	if _, err := io.ReadFull(rand.Reader, scalar[:]); err != nil {
+		return nil, nil, err
+	}
+
+	curve25519.ScalarBaseMult(&public, &scalar)
+	return public[:], scalar[:], nil
+}
+
+// GenerateSharedKey uses the private key and the other party's public key to
+// derive a shared key suitable for use with AES-256
+func GenerateSharedKey(ourPrivate, theirPublic []byte) ([]byte, error) {
+	if len(ourPrivate) != 32 {
+		return nil, fmt.Errorf("invalid private key length: %d", len(ourPrivate))
+	}
+	if len(theirPublic) != 32 {
+		return nil, fmt.Errorf("invalid public key length: %d", len(theirPublic))
+	}
+
+	var scalar, pub, secret [32]byte
+	copy(scalar[:], ourPrivate)
+	copy(pub[:], theirPublic)
+
+	curve25519.ScalarMult(&secret, &scalar, &pub)
+
+	key := sha256.Sum256(secret[:])
+	return key[:], nil
+}
+
+// EncryptAES encrypts the plaintext with AES-GCM using the given key and
+// additional data, returning the ciphertext and the generated nonce
+func EncryptAES(key, plaintext, aad []byte) ([]byte, []byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+
+	return aead.Seal(nil, nonce, plaintext, aad), nonce, nil
+}
+
+// DecryptAES decrypts the ciphertext with AES-GCM using the given key, nonce
+// and additional data
+func DecryptAES(key, ciphertext, nonce, aad []byte) ([]byte, error) {
+	if len(nonce) == 0 {
+		return nil, errors.New("empty nonce")
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, errors.New("empty key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
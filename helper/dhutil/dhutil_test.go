@@ -0,0 +1,47 @@
+package dhutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDHUtil_RoundTrip(t *testing.T) {
+	pub1, pri1, err := GeneratePublicPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, pri2, err := GeneratePublicPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key1, err := GenerateSharedKey(pri1, pub2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := GenerateSharedKey(pri2, pub1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key1, key2) {
+		t.Fatal("shared keys do not match")
+	}
+
+	aad := []byte("foobar")
+	ct, nonce, err := EncryptAES(key1, []byte("s.token"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pt, err := DecryptAES(key2, ct, nonce, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pt) != "s.token" {
+		t.Fatalf("bad: %s", pt)
+	}
+
+	if _, err := DecryptAES(key2, ct, nonce, []byte("other")); err == nil {
+		t.Fatal("expected error decrypting with the wrong additional data")
+	}
+}
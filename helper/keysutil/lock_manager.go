@@ -1,10 +1,15 @@
 package keysutil
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
 
+	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/logical"
 )
@@ -57,6 +62,20 @@ type LockManager struct {
 	cacheMutex sync.RWMutex
 }
 
+// backupBundle is the decoded form of a backup: the encoded KeyData and an
+// HMAC of it, computed with the HMAC key of the latest key version
+type backupBundle struct {
+	KeyData json.RawMessage `json:"key_data"`
+	HMAC    []byte          `json:"hmac"`
+}
+
+// backupHMAC computes the HMAC of encoded key data for a backup bundle
+func backupHMAC(hmacKey, keyData []byte) []byte {
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(keyData)
+	return mac.Sum(nil)
+}
+
 func NewLockManager(cacheDisabled bool) *LockManager {
 	lm := &LockManager{
 		locks: map[string]*sync.RWMutex{},
@@ -247,7 +266,7 @@ func (lm *LockManager) getPolicyCommon(req PolicyRequest, lockType bool) (*Polic
 				return nil, nil, false, fmt.Errorf("convergent encryption requires derivation to be enabled")
 			}
 
-		case KeyType_ECDSA_P256:
+		case KeyType_ECDSA_P256, KeyType_RSA2048, KeyType_RSA4096:
 			if req.Derived || req.Convergent {
 				lm.UnlockPolicy(lock, lockType)
 				return nil, nil, false, fmt.Errorf("key derivation and convergent encryption not supported for keys of type %v", req.KeyType)
@@ -273,7 +292,9 @@ func (lm *LockManager) getPolicyCommon(req PolicyRequest, lockType bool) (*Polic
 		if req.Derived {
 			p.KDF = Kdf_hkdf_sha256
 			p.ConvergentEncryption = req.Convergent
-			p.ConvergentVersion = 2
+			if p.ConvergentEncryption {
+				p.ConvergentVersion = currentConvergentVersion
+			}
 		}
 
 		err = p.Rotate(req.Storage)
@@ -377,6 +398,141 @@ func (lm *LockManager) DeletePolicy(storage logical.Storage, name string) error
 	return nil
 }
 
+// BackupPolicy returns a base64-encoded bundle of the named policy, including
+// all archived key versions, suitable for passing to RestorePolicy. The bundle
+// carries an HMAC made with the key's own HMAC key, so it is self-contained and
+// can be restored to any backend, while modifications to it are detected.
+func (lm *LockManager) BackupPolicy(storage logical.Storage, name string) (string, error) {
+	p, lock, err := lm.GetPolicyShared(storage, name)
+	if lock != nil {
+		defer lock.RUnlock()
+	}
+	if err != nil {
+		return "", err
+	}
+	if p == nil {
+		return "", errutil.UserError{Err: fmt.Sprintf("key %q not found", name)}
+	}
+
+	if !p.Exportable {
+		return "", errutil.UserError{Err: fmt.Sprintf("key %q is not exportable", name)}
+	}
+	if !p.AllowPlaintextBackup {
+		return "", errutil.UserError{Err: fmt.Sprintf("plaintext backup is not allowed for key %q", name)}
+	}
+
+	archive, err := p.LoadArchive(storage)
+	if err != nil {
+		return "", err
+	}
+
+	keyData := &KeyData{
+		Policy:       p,
+		ArchivedKeys: archive,
+	}
+
+	encodedKeyData, err := json.Marshal(keyData)
+	if err != nil {
+		return "", err
+	}
+
+	hmacKey, err := p.HMACKey(p.LatestVersion)
+	if err != nil {
+		return "", err
+	}
+
+	bundle := &backupBundle{
+		KeyData: encodedKeyData,
+		HMAC:    backupHMAC(hmacKey, encodedKeyData),
+	}
+
+	encodedBackup, err := json.Marshal(bundle)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(encodedBackup), nil
+}
+
+// RestorePolicy restores a policy from a bundle created by BackupPolicy. The
+// bundle is rejected unless its HMAC verifies with the HMAC key of the latest
+// key version it contains. If name is empty, the name stored in the bundle is used. An existing policy of
+// the same name is only overwritten if force is set.
+func (lm *LockManager) RestorePolicy(storage logical.Storage, name, backup string, force bool) error {
+	backupBytes, err := base64.StdEncoding.DecodeString(backup)
+	if err != nil {
+		return errutil.UserError{Err: "failed to base64-decode backup"}
+	}
+
+	var bundle backupBundle
+	if err := jsonutil.DecodeJSON(backupBytes, &bundle); err != nil {
+		return errutil.UserError{Err: fmt.Sprintf("failed to decode backup: %v", err)}
+	}
+
+	keyData := KeyData{
+		Policy: &Policy{
+			Keys: keyEntryMap{},
+		},
+	}
+	if err := jsonutil.DecodeJSON(bundle.KeyData, &keyData); err != nil {
+		return errutil.UserError{Err: fmt.Sprintf("failed to decode backup: %v", err)}
+	}
+
+	p := keyData.Policy
+	switch {
+	case p == nil:
+		return errutil.UserError{Err: "backup does not contain a key"}
+	case len(p.Keys) == 0 || p.LatestVersion < 1:
+		return errutil.UserError{Err: "backup does not contain any key versions"}
+	case keyData.ArchivedKeys == nil || len(keyData.ArchivedKeys.Keys) < keyData.ArchivedKeys.index(p.ArchiveVersion)+1:
+		return errutil.UserError{Err: "backup does not contain the key archive"}
+	}
+
+	// Nothing in the bundle is written until its HMAC verifies
+	hmacKey, err := p.HMACKey(p.LatestVersion)
+	if err != nil || !hmac.Equal(bundle.HMAC, backupHMAC(hmacKey, bundle.KeyData)) {
+		return errutil.UserError{Err: "backup failed verification"}
+	}
+
+	if name == "" {
+		name = p.Name
+	}
+	if name == "" {
+		return errutil.UserError{Err: "missing name of the key to restore"}
+	}
+	p.Name = name
+
+	lm.cacheMutex.Lock()
+	lock := lm.policyLock(name, exclusive)
+	defer lock.Unlock()
+	defer lm.cacheMutex.Unlock()
+
+	if !force {
+		existing, err := lm.getStoredPolicy(storage, name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errutil.UserError{Err: fmt.Sprintf("key %q already exists", name)}
+		}
+	}
+
+	// Write the archive first so that the policy never refers to archived
+	// keys that are not in storage
+	if err := p.storeArchive(keyData.ArchivedKeys, storage); err != nil {
+		return err
+	}
+	if err := p.Persist(storage); err != nil {
+		return err
+	}
+
+	if lm.CacheActive() {
+		delete(lm.cache, name)
+	}
+
+	return nil
+}
+
 func (lm *LockManager) getStoredPolicy(storage logical.Storage, name string) (*Policy, error) {
 	// Check if the policy already exists
 	raw, err := storage.Get("policy/" + name)
@@ -9,7 +9,9 @@ import (
 	"crypto/elliptic"
 	"crypto/hmac"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha256"
+	_ "crypto/sha512"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/base64"
@@ -44,8 +46,16 @@ const (
 	KeyType_AES256_GCM96 = iota
 	KeyType_ECDSA_P256
 	KeyType_ED25519
+	KeyType_RSA2048
+	KeyType_RSA4096
 )
 
+// The version of the convergent nonce scheme used for newly created key
+// versions. Version 1 requires the nonce to be supplied by the caller, version
+// 2 derives it from an HMAC of the plaintext keyed by the context, and version
+// 3 keys that HMAC with a secret derived from the key version's HMAC key.
+const currentConvergentVersion = 3
+
 const ErrTooOld = "ciphertext or signature version is disallowed by policy (too old)"
 
 type SigningResult struct {
@@ -77,7 +87,7 @@ func (kt KeyType) DecryptionSupported() bool {
 
 func (kt KeyType) SigningSupported() bool {
 	switch kt {
-	case KeyType_ECDSA_P256, KeyType_ED25519:
+	case KeyType_ECDSA_P256, KeyType_ED25519, KeyType_RSA2048, KeyType_RSA4096:
 		return true
 	}
 	return false
@@ -85,7 +95,7 @@ func (kt KeyType) SigningSupported() bool {
 
 func (kt KeyType) HashSignatureInput() bool {
 	switch kt {
-	case KeyType_ECDSA_P256:
+	case KeyType_ECDSA_P256, KeyType_RSA2048, KeyType_RSA4096:
 		return true
 	}
 	return false
@@ -107,6 +117,10 @@ func (kt KeyType) String() string {
 		return "ecdsa-p256"
 	case KeyType_ED25519:
 		return "ed25519"
+	case KeyType_RSA2048:
+		return "rsa-2048"
+	case KeyType_RSA4096:
+		return "rsa-4096"
 	}
 
 	return "[unknown]"
@@ -127,6 +141,12 @@ type KeyEntry struct {
 	EC_Y *big.Int `json:"ec_y"`
 	EC_D *big.Int `json:"ec_d"`
 
+	RSAKey *rsa.PrivateKey `json:"rsa_key"`
+
+	// The version of the convergent nonce scheme used with this key version;
+	// if zero, the policy-wide version applies
+	ConvergentVersion int `json:"convergent_version"`
+
 	// The public key in an appropriate format for the type of key
 	FormattedPublicKey string `json:"public_key"`
 
@@ -196,17 +216,46 @@ type Policy struct {
 	// Whether the key is allowed to be deleted
 	DeletionAllowed bool `json:"deletion_allowed"`
 
-	// The version of the convergent nonce to use
+	// The version of the convergent nonce to use. From version 3 on, the
+	// version is also tracked per key version.
 	ConvergentVersion int `json:"convergent_version"`
 
 	// The type of key
 	Type KeyType `json:"type"`
+
+	// The minimum version of the key still stored; older versions have been
+	// trimmed and are permanently unavailable
+	MinAvailableVersion int `json:"min_available_version"`
+
+	// Whether the key, along with all its versions, can be backed up in
+	// plaintext
+	AllowPlaintextBackup bool `json:"allow_plaintext_backup"`
+
+	// The interval after which the key is rotated automatically; zero
+	// disables automatic rotation
+	AutoRotateInterval time.Duration `json:"auto_rotate_interval"`
+}
+
+// KeyData is the bundle of a policy and its archived keys produced by a
+// backup
+type KeyData struct {
+	Policy       *Policy       `json:"policy"`
+	ArchivedKeys *archivedKeys `json:"archived_keys"`
 }
 
 // ArchivedKeys stores old keys. This is used to keep the key loading time sane
 // when there are huge numbers of rotations.
 type archivedKeys struct {
 	Keys []KeyEntry `json:"keys"`
+
+	// The key version stored at the start of Keys. Zero means the archive
+	// has never been trimmed, in which case Keys is indexed by version.
+	MinVersion int `json:"min_version"`
+}
+
+// index returns the position of the given key version in the archive
+func (a *archivedKeys) index(ver int) int {
+	return ver - a.MinVersion
 }
 
 func (p *Policy) LoadArchive(storage logical.Storage) (*archivedKeys, error) {
@@ -277,6 +326,9 @@ func (p *Policy) handleArchiving(storage logical.Storage) error {
 	case p.MinDecryptionVersion > p.LatestVersion:
 		return fmt.Errorf("minimum decryption version of %d is greater than the latest version %d",
 			p.MinDecryptionVersion, p.LatestVersion)
+	case p.MinAvailableVersion > p.MinDecryptionVersion:
+		return fmt.Errorf("minimum available version of %d is greater than minimum decryption version %d",
+			p.MinAvailableVersion, p.MinDecryptionVersion)
 	}
 
 	archive, err := p.LoadArchive(storage)
@@ -288,7 +340,7 @@ func (p *Policy) handleArchiving(storage logical.Storage) error {
 		// Need to move keys *from* archive
 
 		for i := p.MinDecryptionVersion; i <= p.LatestVersion; i++ {
-			p.Keys[i] = archive.Keys[i]
+			p.Keys[i] = archive.Keys[archive.index(i)]
 		}
 
 		return nil
@@ -299,9 +351,9 @@ func (p *Policy) handleArchiving(storage logical.Storage) error {
 	// We need a size that is equivalent to the latest version (number of keys)
 	// but adding one since slice numbering starts at 0 and we're indexing by
 	// key version
-	if len(archive.Keys) < p.LatestVersion+1 {
+	if len(archive.Keys) < archive.index(p.LatestVersion)+1 {
 		// Increase the size of the archive slice
-		newKeys := make([]KeyEntry, p.LatestVersion+1)
+		newKeys := make([]KeyEntry, archive.index(p.LatestVersion)+1)
 		copy(newKeys, archive.Keys)
 		archive.Keys = newKeys
 	}
@@ -309,7 +361,7 @@ func (p *Policy) handleArchiving(storage logical.Storage) error {
 	// We are storing all keys in the archive, so we ensure that it is up to
 	// date up to p.LatestVersion
 	for i := p.ArchiveVersion + 1; i <= p.LatestVersion; i++ {
-		archive.Keys[i] = p.Keys[i]
+		archive.Keys[archive.index(i)] = p.Keys[i]
 		p.ArchiveVersion = i
 	}
 
@@ -327,6 +379,59 @@ func (p *Policy) handleArchiving(storage logical.Storage) error {
 	return nil
 }
 
+// Trim permanently deletes all key versions below minAvailableVersion from
+// the policy and its archive. The version cannot be greater than the minimum
+// decryption or encryption versions, nor lower than a previously trimmed
+// version.
+func (p *Policy) Trim(storage logical.Storage, minAvailableVersion int) error {
+	switch {
+	case minAvailableVersion < 1:
+		return errutil.UserError{Err: "minimum available version must be at least 1"}
+	case minAvailableVersion < p.MinAvailableVersion:
+		return errutil.UserError{Err: fmt.Sprintf("minimum available version cannot be lowered from %d, as older versions have already been trimmed", p.MinAvailableVersion)}
+	case minAvailableVersion > p.MinDecryptionVersion:
+		return errutil.UserError{Err: fmt.Sprintf("minimum available version cannot be greater than the minimum decryption version of %d", p.MinDecryptionVersion)}
+	case p.MinEncryptionVersion > 0 && minAvailableVersion > p.MinEncryptionVersion:
+		return errutil.UserError{Err: fmt.Sprintf("minimum available version cannot be greater than the minimum encryption version of %d", p.MinEncryptionVersion)}
+	}
+
+	// Make sure the archive holds every version before trimming it
+	if err := p.Persist(storage); err != nil {
+		return err
+	}
+
+	archive, err := p.LoadArchive(storage)
+	if err != nil {
+		return err
+	}
+
+	if minAvailableVersion > archive.MinVersion {
+		start := archive.index(minAvailableVersion)
+		if start > len(archive.Keys) {
+			start = len(archive.Keys)
+		}
+		trimmed := make([]KeyEntry, len(archive.Keys)-start)
+		copy(trimmed, archive.Keys[start:])
+		archive.Keys = trimmed
+		archive.MinVersion = minAvailableVersion
+
+		// The archive is the source of truth for its own layout, so it is
+		// safe to write it before the policy
+		if err := p.storeArchive(archive, storage); err != nil {
+			return err
+		}
+	}
+
+	for ver := range p.Keys {
+		if ver < minAvailableVersion {
+			delete(p.Keys, ver)
+		}
+	}
+	p.MinAvailableVersion = minAvailableVersion
+
+	return p.Persist(storage)
+}
+
 func (p *Policy) Persist(storage logical.Storage) error {
 	err := p.handleArchiving(storage)
 	if err != nil {
@@ -514,6 +619,24 @@ func (p *Policy) DeriveKey(context []byte, ver int) ([]byte, error) {
 	}
 }
 
+// ConvergentVersionFor returns the version of the convergent nonce scheme used
+// by the given key version, or zero if convergent encryption is not enabled
+func (p *Policy) ConvergentVersionFor(ver int) int {
+	if !p.ConvergentEncryption {
+		return 0
+	}
+	if keyConvergentVersion := p.Keys[ver].ConvergentVersion; keyConvergentVersion != 0 {
+		return keyConvergentVersion
+	}
+	switch p.ConvergentVersion {
+	case 0:
+		// Policies that predate versioning use the first scheme
+		return 1
+	default:
+		return p.ConvergentVersion
+	}
+}
+
 func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string, error) {
 	if !p.Type.EncryptionSupported() {
 		return "", errutil.UserError{Err: fmt.Sprintf("message encryption not supported for key type %v", p.Type)}
@@ -568,17 +691,34 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 		return "", errutil.InternalError{Err: err.Error()}
 	}
 
+	convergentVersion := p.ConvergentVersionFor(ver)
 	if p.ConvergentEncryption {
-		switch p.ConvergentVersion {
+		switch convergentVersion {
 		case 1:
 			if len(nonce) != gcm.NonceSize() {
 				return "", errutil.UserError{Err: fmt.Sprintf("base64-decoded nonce must be %d bytes long when using convergent encryption with this key", gcm.NonceSize())}
 			}
-		default:
+		case 2:
 			nonceHmac := hmac.New(sha256.New, context)
 			nonceHmac.Write(plaintext)
 			nonceSum := nonceHmac.Sum(nil)
 			nonce = nonceSum[:gcm.NonceSize()]
+		case 3:
+			// Key the nonce HMAC with a secret so that the nonce cannot be
+			// used to confirm guesses of the plaintext by anyone knowing the
+			// context
+			hmacKey := p.Keys[ver].HMACKey
+			if len(hmacKey) == 0 {
+				return "", errutil.InternalError{Err: fmt.Sprintf("no HMAC key found for version %d", ver)}
+			}
+			nonceKeyHmac := hmac.New(sha256.New, hmacKey)
+			nonceKeyHmac.Write(context)
+			nonceHmac := hmac.New(sha256.New, nonceKeyHmac.Sum(nil))
+			nonceHmac.Write(plaintext)
+			nonceSum := nonceHmac.Sum(nil)
+			nonce = nonceSum[:gcm.NonceSize()]
+		default:
+			return "", errutil.InternalError{Err: fmt.Sprintf("unknown convergent version %d", convergentVersion)}
 		}
 	} else {
 		// Compute random nonce
@@ -593,7 +733,7 @@ func (p *Policy) Encrypt(ver int, context, nonce []byte, value string) (string,
 
 	// Place the encrypted data after the nonce
 	full := out
-	if !p.ConvergentEncryption || p.ConvergentVersion > 1 {
+	if convergentVersion != 1 {
 		full = append(nonce, out...)
 	}
 
@@ -677,7 +817,7 @@ func (p *Policy) Decrypt(context, nonce []byte, value string) (string, error) {
 
 	// Extract the nonce and ciphertext
 	var ciphertext []byte
-	if p.ConvergentEncryption && p.ConvergentVersion < 2 {
+	if p.ConvergentVersionFor(ver) == 1 {
 		ciphertext = decoded
 	} else {
 		nonce = decoded[:gcm.NonceSize()]
@@ -708,7 +848,25 @@ func (p *Policy) HMACKey(version int) ([]byte, error) {
 	return p.Keys[version].HMACKey, nil
 }
 
-func (p *Policy) Sign(ver int, context, input []byte) (*SigningResult, error) {
+// SignatureHash returns the hash function corresponding to the given
+// algorithm name, as accepted by the sign and verify endpoints
+func SignatureHash(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case "sha2-224":
+		return crypto.SHA224, nil
+	case "sha2-256":
+		return crypto.SHA256, nil
+	case "sha2-384":
+		return crypto.SHA384, nil
+	case "sha2-512":
+		return crypto.SHA512, nil
+	}
+	return crypto.Hash(0), errutil.UserError{Err: fmt.Sprintf("unsupported algorithm %s", algorithm)}
+}
+
+// Sign signs the input with the given version of the key. For key types that
+// hash their input, the input must already be hashed with the given algorithm.
+func (p *Policy) Sign(ver int, context, input []byte, algorithm string) (*SigningResult, error) {
 	if !p.Type.SigningSupported() {
 		return nil, fmt.Errorf("message signing not supported for key type %v", p.Type)
 	}
@@ -773,6 +931,26 @@ func (p *Policy) Sign(ver int, context, input []byte) (*SigningResult, error) {
 			return nil, err
 		}
 
+	case KeyType_RSA2048, KeyType_RSA4096:
+		hashType, err := SignatureHash(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if len(input) != hashType.Size() {
+			return nil, errutil.UserError{Err: fmt.Sprintf("input length of %d does not match the %s hash size of %d", len(input), algorithm, hashType.Size())}
+		}
+
+		key := p.Keys[ver].RSAKey
+		if key == nil {
+			return nil, errutil.InternalError{Err: fmt.Sprintf("no RSA key found for version %d", ver)}
+		}
+		key.Precompute()
+
+		sig, err = rsa.SignPSS(rand.Reader, key, hashType, input, nil)
+		if err != nil {
+			return nil, err
+		}
+
 	default:
 		return nil, fmt.Errorf("unsupported key type %v", p.Type)
 	}
@@ -788,7 +966,9 @@ func (p *Policy) Sign(ver int, context, input []byte) (*SigningResult, error) {
 	return res, nil
 }
 
-func (p *Policy) VerifySignature(context, input []byte, sig string) (bool, error) {
+// VerifySignature verifies the signature over the input. For key types that
+// hash their input, the input must already be hashed with the given algorithm.
+func (p *Policy) VerifySignature(context, input []byte, sig, algorithm string) (bool, error) {
 	if !p.Type.SigningSupported() {
 		return false, errutil.UserError{Err: fmt.Sprintf("message verification not supported for key type %v", p.Type)}
 	}
@@ -857,6 +1037,20 @@ func (p *Policy) VerifySignature(context, input []byte, sig string) (bool, error
 
 		return ed25519.Verify(key.Public().(ed25519.PublicKey), input, sigBytes), nil
 
+	case KeyType_RSA2048, KeyType_RSA4096:
+		hashType, err := SignatureHash(algorithm)
+		if err != nil {
+			return false, err
+		}
+
+		key := p.Keys[ver].RSAKey
+		if key == nil {
+			return false, errutil.InternalError{Err: fmt.Sprintf("no RSA key found for version %d", ver)}
+		}
+
+		err = rsa.VerifyPSS(&key.PublicKey, hashType, input, sigBytes, nil)
+		return err == nil, nil
+
 	default:
 		return false, errutil.InternalError{Err: fmt.Sprintf("unsupported key type %v", p.Type)}
 	}
@@ -885,6 +1079,15 @@ func (p *Policy) Rotate(storage logical.Storage) error {
 	}
 	entry.HMACKey = hmacKey
 
+	// Policies using a derived nonce move new key versions to the current
+	// scheme; existing ciphertexts keep using the scheme of their key version
+	if p.ConvergentEncryption {
+		switch p.ConvergentVersion {
+		case 2, 3:
+			entry.ConvergentVersion = currentConvergentVersion
+		}
+	}
+
 	switch p.Type {
 	case KeyType_AES256_GCM96:
 		// Generate a 256bit key
@@ -923,6 +1126,30 @@ func (p *Policy) Rotate(storage logical.Storage) error {
 		}
 		entry.Key = pri
 		entry.FormattedPublicKey = base64.StdEncoding.EncodeToString(pub)
+
+	case KeyType_RSA2048, KeyType_RSA4096:
+		bitSize := 2048
+		if p.Type == KeyType_RSA4096 {
+			bitSize = 4096
+		}
+
+		privKey, err := rsa.GenerateKey(rand.Reader, bitSize)
+		if err != nil {
+			return err
+		}
+		entry.RSAKey = privKey
+		derBytes, err := x509.MarshalPKIXPublicKey(privKey.Public())
+		if err != nil {
+			return fmt.Errorf("error marshaling public key: %s", err)
+		}
+		pemBytes := pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: derBytes,
+		})
+		if pemBytes == nil || len(pemBytes) == 0 {
+			return fmt.Errorf("error PEM-encoding public key")
+		}
+		entry.FormattedPublicKey = string(pemBytes)
 	}
 
 	p.Keys[p.LatestVersion] = entry
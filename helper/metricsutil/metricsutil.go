@@ -0,0 +1,166 @@
+package metricsutil
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// PrometheusFormat is the format name that selects the Prometheus text
+	// exposition format
+	PrometheusFormat = "prometheus"
+
+	// PrometheusContentType is the content type of the Prometheus text
+	// exposition format
+	PrometheusContentType = "text/plain; version=0.0.4"
+)
+
+// invalidNameChars matches the characters that may not appear in a
+// Prometheus metric name
+var invalidNameChars = regexp.MustCompile("[^a-zA-Z0-9_:]")
+
+// PrometheusSink is a metrics.MetricSink that keeps the cumulative value of
+// every metric it receives, so that they can be scraped in the Prometheus
+// text format. Counters are summed, samples are kept as a count and a sum,
+// and gauges keep their last value.
+type PrometheusSink struct {
+	lock     sync.RWMutex
+	gauges   map[string]float64
+	counters map[string]float64
+	samples  map[string]*Summary
+}
+
+// Summary is the cumulative count and sum of the values of a sample
+type Summary struct {
+	Count uint64  `json:"count"`
+	Sum   float64 `json:"sum"`
+}
+
+// Snapshot is a point-in-time copy of the metrics of a PrometheusSink,
+// keyed by metric name
+type Snapshot struct {
+	Gauges   map[string]float64  `json:"gauges"`
+	Counters map[string]float64  `json:"counters"`
+	Samples  map[string]*Summary `json:"samples"`
+}
+
+// NewPrometheusSink returns an empty PrometheusSink
+func NewPrometheusSink() *PrometheusSink {
+	return &PrometheusSink{
+		gauges:   make(map[string]float64),
+		counters: make(map[string]float64),
+		samples:  make(map[string]*Summary),
+	}
+}
+
+// SetGauge sets the value of a gauge
+func (p *PrometheusSink) SetGauge(key []string, val float32) {
+	name := flattenKey(key)
+
+	p.lock.Lock()
+	p.gauges[name] = float64(val)
+	p.lock.Unlock()
+}
+
+// EmitKey records a value for the key, which is handled like a sample
+func (p *PrometheusSink) EmitKey(key []string, val float32) {
+	p.AddSample(key, val)
+}
+
+// IncrCounter adds the value to a counter
+func (p *PrometheusSink) IncrCounter(key []string, val float32) {
+	name := flattenKey(key)
+
+	p.lock.Lock()
+	p.counters[name] += float64(val)
+	p.lock.Unlock()
+}
+
+// AddSample records a value of a sample, such as a timing
+func (p *PrometheusSink) AddSample(key []string, val float32) {
+	name := flattenKey(key)
+
+	p.lock.Lock()
+	s, ok := p.samples[name]
+	if !ok {
+		s = &Summary{}
+		p.samples[name] = s
+	}
+	s.Count++
+	s.Sum += float64(val)
+	p.lock.Unlock()
+}
+
+// Snapshot returns a copy of the current metrics
+func (p *PrometheusSink) Snapshot() *Snapshot {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	snap := &Snapshot{
+		Gauges:   make(map[string]float64, len(p.gauges)),
+		Counters: make(map[string]float64, len(p.counters)),
+		Samples:  make(map[string]*Summary, len(p.samples)),
+	}
+	for name, val := range p.gauges {
+		snap.Gauges[name] = val
+	}
+	for name, val := range p.counters {
+		snap.Counters[name] = val
+	}
+	for name, s := range p.samples {
+		snap.Samples[name] = &Summary{
+			Count: s.Count,
+			Sum:   s.Sum,
+		}
+	}
+
+	return snap
+}
+
+// Prometheus renders the current metrics in the Prometheus text exposition
+// format. Samples are rendered as summaries without quantiles.
+func (p *PrometheusSink) Prometheus() []byte {
+	snap := p.Snapshot()
+
+	var buf bytes.Buffer
+	for _, name := range sortedKeys(snap.Gauges) {
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		fmt.Fprintf(&buf, "%s %v\n", name, snap.Gauges[name])
+	}
+	for _, name := range sortedKeys(snap.Counters) {
+		fmt.Fprintf(&buf, "# TYPE %s counter\n", name)
+		fmt.Fprintf(&buf, "%s %v\n", name, snap.Counters[name])
+	}
+
+	names := make([]string, 0, len(snap.Samples))
+	for name := range snap.Samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := snap.Samples[name]
+		fmt.Fprintf(&buf, "# TYPE %s summary\n", name)
+		fmt.Fprintf(&buf, "%s_sum %v\n", name, s.Sum)
+		fmt.Fprintf(&buf, "%s_count %d\n", name, s.Count)
+	}
+
+	return buf.Bytes()
+}
+
+// flattenKey joins the parts of a key into a valid Prometheus metric name
+func flattenKey(parts []string) string {
+	return invalidNameChars.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package metricsutil
+
+import (
+	"testing"
+)
+
+func TestPrometheusSink(t *testing.T) {
+	sink := NewPrometheusSink()
+
+	sink.SetGauge([]string{"vault", "expire", "num_leases"}, 3)
+	sink.SetGauge([]string{"vault", "expire", "num_leases"}, 5)
+	sink.IncrCounter([]string{"vault", "audit", "log_request_failure"}, 1)
+	sink.IncrCounter([]string{"vault", "audit", "log_request_failure"}, 2)
+	sink.AddSample([]string{"vault", "route", "read", "secret-"}, 1.5)
+	sink.AddSample([]string{"vault", "route", "read", "secret-"}, 2.5)
+
+	snap := sink.Snapshot()
+	if snap.Gauges["vault_expire_num_leases"] != 5 {
+		t.Fatalf("bad: gauges: %#v", snap.Gauges)
+	}
+	if snap.Counters["vault_audit_log_request_failure"] != 3 {
+		t.Fatalf("bad: counters: %#v", snap.Counters)
+	}
+	s := snap.Samples["vault_route_read_secret_"]
+	if s == nil || s.Count != 2 || s.Sum != 4 {
+		t.Fatalf("bad: samples: %#v", snap.Samples)
+	}
+
+	expected := `# TYPE vault_expire_num_leases gauge
+vault_expire_num_leases 5
+# TYPE vault_audit_log_request_failure counter
+vault_audit_log_request_failure 3
+# TYPE vault_route_read_secret_ summary
+vault_route_read_secret__sum 4
+vault_route_read_secret__count 2
+`
+	if actual := string(sink.Prometheus()); actual != expected {
+		t.Fatalf("bad: expected:\n%s\nactual:\n%s", expected, actual)
+	}
+}
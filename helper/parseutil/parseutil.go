@@ -60,3 +60,11 @@ func ParseBool(in interface{}) (bool, error) {
 	}
 	return result, nil
 }
+
+func ParseInt(in interface{}) (int64, error) {
+	var result int64
+	if err := mapstructure.WeakDecode(in, &result); err != nil {
+		return 0, err
+	}
+	return result, nil
+}
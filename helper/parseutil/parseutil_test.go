@@ -53,3 +53,18 @@ func Test_ParseBool(t *testing.T) {
 		t.Fatal("wrong output")
 	}
 }
+
+func Test_ParseInt(t *testing.T) {
+	for _, in := range []interface{}{"42", 42, float64(42), json.Number("42")} {
+		outp, err := ParseInt(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outp != 42 {
+			t.Fatalf("wrong output for %#v: %d", in, outp)
+		}
+	}
+	if _, err := ParseInt("not a number"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
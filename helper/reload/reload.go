@@ -10,8 +10,9 @@ import (
 type ReloadFunc func(map[string]interface{}) error
 
 // CertificateGetter satisfies ReloadFunc and its GetCertificate method
-// satisfies the tls.GetCertificate function signature.  Currently it does not
-// allow changing paths after the fact.
+// satisfies the tls.GetCertificate function signature. If the listener
+// configuration given to Reload sets tls_cert_file and tls_key_file, the
+// certificate is loaded from those paths from then on.
 type CertificateGetter struct {
 	sync.RWMutex
 
@@ -28,8 +29,20 @@ func NewCertificateGetter(certFile, keyFile string) *CertificateGetter {
 	}
 }
 
-func (cg *CertificateGetter) Reload(_ map[string]interface{}) error {
-	cert, err := tls.LoadX509KeyPair(cg.certFile, cg.keyFile)
+func (cg *CertificateGetter) Reload(config map[string]interface{}) error {
+	cg.RLock()
+	certFile, keyFile := cg.certFile, cg.keyFile
+	cg.RUnlock()
+
+	if config != nil {
+		newCertFile, _ := config["tls_cert_file"].(string)
+		newKeyFile, _ := config["tls_key_file"].(string)
+		if newCertFile != "" && newKeyFile != "" {
+			certFile, keyFile = newCertFile, newKeyFile
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
 		return err
 	}
@@ -38,6 +51,8 @@ func (cg *CertificateGetter) Reload(_ map[string]interface{}) error {
 	defer cg.Unlock()
 
 	cg.cert = &cert
+	cg.certFile = certFile
+	cg.keyFile = keyFile
 
 	return nil
 }
@@ -13,6 +13,7 @@ var allowedMethods = []string{
 	http.MethodDelete,
 	http.MethodGet,
 	http.MethodOptions,
+	http.MethodPatch,
 	http.MethodPost,
 	http.MethodPut,
 	"LIST", // LIST is not an official HTTP method, but Vault supports it.
@@ -50,7 +51,10 @@ func wrapCORSHandler(h http.Handler, core *vault.Core) http.Handler {
 		// apply headers for preflight requests
 		if req.Method == http.MethodOptions {
 			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowedMethods, ","))
-			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsConf.AllowedHeaders, ","))
+			corsConf.RLock()
+			allowedHeaders := strings.Join(corsConf.AllowedHeaders, ",")
+			corsConf.RUnlock()
+			w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
 			w.Header().Set("Access-Control-Max-Age", "300")
 
 			return
@@ -32,6 +32,10 @@ const (
 	// not to use request forwarding
 	NoRequestForwardingHeaderName = "X-Vault-No-Request-Forwarding"
 
+	// NamespaceHeaderName is the name of the header containing the path of
+	// the namespace the request is made in
+	NamespaceHeaderName = "X-Vault-Namespace"
+
 	// MaxRequestSize is the maximum accepted request size. This is to prevent
 	// a denial of service attack where no Content-Length is provided and the server
 	// is fed ever more data until it exhausts memory.
@@ -57,11 +61,21 @@ func Handler(core *vault.Core) http.Handler {
 	mux.Handle("/v1/sys/generate-root/update", handleRequestForwarding(core, handleSysGenerateRootUpdate(core)))
 	mux.Handle("/v1/sys/rekey/init", handleRequestForwarding(core, handleSysRekeyInit(core, false)))
 	mux.Handle("/v1/sys/rekey/update", handleRequestForwarding(core, handleSysRekeyUpdate(core, false)))
+	mux.Handle("/v1/sys/rekey/verify", handleRequestForwarding(core, handleSysRekeyVerify(core, false)))
 	mux.Handle("/v1/sys/rekey-recovery-key/init", handleRequestForwarding(core, handleSysRekeyInit(core, true)))
 	mux.Handle("/v1/sys/rekey-recovery-key/update", handleRequestForwarding(core, handleSysRekeyUpdate(core, true)))
+	mux.Handle("/v1/sys/rekey-recovery-key/verify", handleRequestForwarding(core, handleSysRekeyVerify(core, true)))
 	mux.Handle("/v1/sys/wrapping/lookup", handleRequestForwarding(core, handleLogical(core, false, wrappingVerificationFunc)))
 	mux.Handle("/v1/sys/wrapping/rewrap", handleRequestForwarding(core, handleLogical(core, false, wrappingVerificationFunc)))
 	mux.Handle("/v1/sys/wrapping/unwrap", handleRequestForwarding(core, handleLogical(core, false, wrappingVerificationFunc)))
+	mux.Handle("/v1/sys/replication/dr/status", handleSysDRReplicationStatus(core))
+	mux.Handle("/v1/sys/replication/dr/primary/stream", handleRequestForwarding(core, handleSysDRPrimaryStream(core)))
+	mux.Handle("/v1/sys/replication/dr/secondary/promote", handleSysDRSecondaryPromote(core))
+	mux.Handle("/v1/sys/replication/dr/secondary/update-primary", handleSysDRSecondaryUpdatePrimary(core))
+	mux.Handle("/v1/sys/metrics", handleRequestForwarding(core, handleSysMetrics(core)))
+	mux.Handle("/v1/sys/storage/raft/snapshot", handleRequestForwarding(core, handleSysRaftSnapshot(core)))
+	mux.Handle("/v1/sys/pprof/profile", handleRequestForwarding(core, handleSysPprofProfile(core)))
+	mux.Handle("/v1/sys/pprof/trace", handleRequestForwarding(core, handleSysPprofTrace(core)))
 	mux.Handle("/v1/sys/capabilities-self", handleRequestForwarding(core, handleLogical(core, true, nil)))
 	mux.Handle("/v1/sys/", handleRequestForwarding(core, handleLogical(core, true, nil)))
 	mux.Handle("/v1/", handleRequestForwarding(core, handleLogical(core, false, nil)))
@@ -77,6 +91,28 @@ func Handler(core *vault.Core) http.Handler {
 	return genericWrappedHandler
 }
 
+// monitoringPaths are the paths served by a MonitoringHandler
+var monitoringPaths = map[string]bool{
+	"/v1/sys/health":      true,
+	"/v1/sys/leader":      true,
+	"/v1/sys/metrics":     true,
+	"/v1/sys/seal-status": true,
+}
+
+// MonitoringHandler returns an http.Handler that only passes requests to the
+// endpoints reporting the status of the node, such as sys/health, on to the
+// given API handler. It is used for listeners that are exposed to monitoring
+// systems rather than to clients.
+func MonitoringHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !monitoringPaths[r.URL.Path] {
+			respondError(w, http.StatusNotFound, nil)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 // wrapGenericHandler wraps the handler with an extra layer of handler where
 // tasks that should be commonly handled for all the requests and/or responses
 // are performed.
@@ -193,6 +229,9 @@ func handleRequestForwarding(core *vault.Core, handler http.Handler) http.Handle
 
 		if header != nil {
 			for k, v := range header {
+				// Replace rather than append to headers already set by
+				// this node, such as Cache-Control
+				w.Header().Del(k)
 				for _, j := range v {
 					w.Header().Add(k, j)
 				}
@@ -265,8 +304,16 @@ func respondStandby(core *vault.Core, w http.ResponseWriter, reqURL *url.URL) {
 
 // requestAuth adds the token to the logical.Request if it exists.
 func requestAuth(core *vault.Core, r *http.Request, req *logical.Request) *logical.Request {
-	// Attach the header value if we have it
-	if v := r.Header.Get(AuthHeaderName); v != "" {
+	// Attach the header value if we have it, falling back to a bearer token
+	// in the Authorization header for proxies and clients such as Prometheus
+	// that can only set standard headers
+	v := r.Header.Get(AuthHeaderName)
+	if v == "" {
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			v = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+		}
+	}
+	if v != "" {
 		req.ClientToken = v
 
 		// Also attach the accessor if we have it. This doesn't fail if it
@@ -1,7 +1,9 @@
 package http
 
 import (
+	"encoding/base64"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"strconv"
@@ -16,6 +18,20 @@ import (
 
 type PrepareRequestFunc func(*vault.Core, *logical.Request) error
 
+// ocspRequestContentType is the content type of DER-encoded OCSP requests
+// sent via POST, as defined in RFC 6960
+const ocspRequestContentType = "application/ocsp-request"
+
+// isOCSPRequest returns whether the request is a DER-encoded OCSP request
+// POSTed to the ocsp endpoint of a PKI mount
+func isOCSPRequest(core *vault.Core, r *http.Request, path string) bool {
+	if r.Header.Get("Content-Type") != ocspRequestContentType {
+		return false
+	}
+	mount, mountType := core.MatchingMount(path)
+	return mountType == "pki" && path == mount+"ocsp"
+}
+
 func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Request) (*logical.Request, int, error) {
 	// Determine the path...
 	if !strings.HasPrefix(r.URL.Path, "/v1/") {
@@ -26,6 +42,11 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		return nil, http.StatusNotFound, nil
 	}
 
+	// Requests within a namespace are made under its path
+	if ns := strings.Trim(strings.TrimSpace(r.Header.Get(NamespaceHeaderName)), "/"); ns != "" {
+		path = ns + "/" + path
+	}
+
 	// Determine the operation
 	var op logical.Operation
 	switch r.Method {
@@ -39,7 +60,7 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		if listStr != "" {
 			list, err := strconv.ParseBool(listStr)
 			if err != nil {
-				return nil, http.StatusBadRequest, nil
+				return nil, http.StatusBadRequest, errwrap.Wrapf("error parsing list parameter: {{err}}", err)
 			}
 			if list {
 				op = logical.ListOperation
@@ -47,6 +68,8 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		}
 	case "POST", "PUT":
 		op = logical.UpdateOperation
+	case "PATCH":
+		op = logical.PatchOperation
 	case "LIST":
 		op = logical.ListOperation
 	case "OPTIONS":
@@ -62,7 +85,17 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 
 	// Parse the request if we can
 	var data map[string]interface{}
-	if op == logical.UpdateOperation {
+	if op == logical.UpdateOperation && isOCSPRequest(core, r, path) {
+		// OCSP clients POST the DER-encoded request as-is; hand it to the
+		// backend base64-encoded, the same way it arrives via GET
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxRequestSize))
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		data = map[string]interface{}{
+			"request": base64.StdEncoding.EncodeToString(body),
+		}
+	} else if op == logical.UpdateOperation || op == logical.PatchOperation {
 		err := parseRequest(r, w, &data)
 		if err == io.EOF {
 			data = nil
@@ -71,6 +104,19 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 		if err != nil {
 			return nil, http.StatusBadRequest, err
 		}
+	} else if op == logical.ReadOperation {
+		// Query parameters are passed to the backend, so that reads can take
+		// arguments such as the version of a secret
+		for k, v := range r.URL.Query() {
+			if data == nil {
+				data = make(map[string]interface{})
+			}
+			if len(v) == 1 {
+				data[k] = v[0]
+			} else {
+				data[k] = v
+			}
+		}
 	}
 
 	var err error
@@ -151,6 +197,7 @@ func respondLogical(w http.ResponseWriter, r *http.Request, req *logical.Request
 			httpResp = &logical.HTTPResponse{
 				WrapInfo: &logical.HTTPWrapInfo{
 					Token:           resp.WrapInfo.Token,
+					Accessor:        resp.WrapInfo.Accessor,
 					TTL:             int(resp.WrapInfo.TTL.Seconds()),
 					CreationTime:    resp.WrapInfo.CreationTime.Format(time.RFC3339Nano),
 					CreationPath:    resp.WrapInfo.CreationPath,
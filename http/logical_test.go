@@ -13,7 +13,9 @@ import (
 
 	log "github.com/mgutz/logxi/v1"
 
+	"github.com/hashicorp/vault/builtin/logical/pki"
 	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/physical/inmem"
 	"github.com/hashicorp/vault/vault"
@@ -297,4 +299,111 @@ func TestLogical_ListSuffix(t *testing.T) {
 	if !strings.HasSuffix(lreq.Path, "/") {
 		t.Fatal("trailing slash not found on path")
 	}
+
+	req, _ = http.NewRequest("GET", "http://127.0.0.1:8200/v1/secret/foo?list=yes", nil)
+	_, status, err = buildLogicalRequest(core, nil, req)
+	if err == nil || status != http.StatusBadRequest {
+		t.Fatalf("got status %d, err %v", status, err)
+	}
+}
+
+func TestLogical_ReadQueryParams(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:8200/v1/secret/foo?version=2&a=1&a=2", nil)
+	lreq, status, err := buildLogicalRequest(core, nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != 0 {
+		t.Fatalf("got status %d", status)
+	}
+	expected := map[string]interface{}{
+		"version": "2",
+		"a":       []string{"1", "2"},
+	}
+	if !reflect.DeepEqual(lreq.Data, expected) {
+		t.Fatalf("bad: %#v", lreq.Data)
+	}
+}
+
+func TestLogical_Patch(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	body := strings.NewReader(`{"ttl": "2h", "max_ttl": null}`)
+	req, _ := http.NewRequest("PATCH", "http://127.0.0.1:8200/v1/auth/okta/config", body)
+	lreq, status, err := buildLogicalRequest(core, nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != 0 {
+		t.Fatalf("got status %d", status)
+	}
+	if lreq.Operation != logical.PatchOperation {
+		t.Fatalf("bad: %s", lreq.Operation)
+	}
+	expected := map[string]interface{}{
+		"ttl":     "2h",
+		"max_ttl": nil,
+	}
+	if !reflect.DeepEqual(lreq.Data, expected) {
+		t.Fatalf("bad: %#v", lreq.Data)
+	}
+}
+
+func TestLogical_Namespace(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	for _, ns := range []string{"team1", "/team1/", " team1/child "} {
+		req, _ := http.NewRequest("GET", "http://127.0.0.1:8200/v1/secret/foo", nil)
+		req.Header.Set(NamespaceHeaderName, ns)
+		lreq, status, err := buildLogicalRequest(core, nil, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != 0 {
+			t.Fatalf("got status %d", status)
+		}
+		expected := strings.Trim(strings.TrimSpace(ns), "/") + "/secret/foo"
+		if lreq.Path != expected {
+			t.Fatalf("%q: expected path %q, got %q", ns, expected, lreq.Path)
+		}
+	}
+}
+
+func TestLogical_OCSPRequest(t *testing.T) {
+	if err := vault.AddTestLogicalBackend("pki", pki.Factory); err != nil {
+		t.Fatal(err)
+	}
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPost(t, token, addr+"/v1/sys/mounts/pki", map[string]interface{}{
+		"type": "pki",
+	})
+	testResponseStatus(t, resp, 204)
+
+	// The DER-encoded body of a POST to the OCSP endpoint of a PKI mount is
+	// handed to the backend as-is
+	req, _ := http.NewRequest("POST", "http://127.0.0.1:8200/v1/pki/ocsp", strings.NewReader("der"))
+	req.Header.Set("Content-Type", ocspRequestContentType)
+	lreq, status, err := buildLogicalRequest(core, nil, req)
+	if err != nil || status != 0 {
+		t.Fatalf("got status %d, err %v", status, err)
+	}
+	if !reflect.DeepEqual(lreq.Data, map[string]interface{}{"request": "ZGVy"}) {
+		t.Fatalf("bad: %#v", lreq.Data)
+	}
+
+	// Any other path parses the body as usual regardless of the content type
+	for _, path := range []string{"secret/ocsp", "pki/roles/ocsp"} {
+		req, _ = http.NewRequest("POST", "http://127.0.0.1:8200/v1/"+path, strings.NewReader(`{"foo": "bar"}`))
+		req.Header.Set("Content-Type", ocspRequestContentType)
+		lreq, status, err = buildLogicalRequest(core, nil, req)
+		if err != nil || status != 0 {
+			t.Fatalf("%s: got status %d, err %v", path, status, err)
+		}
+		if !reflect.DeepEqual(lreq.Data, map[string]interface{}{"foo": "bar"}) {
+			t.Fatalf("%s: bad: %#v", path, lreq.Data)
+		}
+	}
 }
@@ -32,7 +32,8 @@ func TestSysAuth(t *testing.T) {
 					"default_lease_ttl": json.Number("0"),
 					"max_lease_ttl":     json.Number("0"),
 				},
-				"local": false,
+				"local":     false,
+				"seal_wrap": false,
 			},
 		},
 		"token/": map[string]interface{}{
@@ -42,7 +43,8 @@ func TestSysAuth(t *testing.T) {
 				"default_lease_ttl": json.Number("0"),
 				"max_lease_ttl":     json.Number("0"),
 			},
-			"local": false,
+			"local":     false,
+			"seal_wrap": false,
 		},
 	}
 	testResponseStatus(t, resp, 200)
@@ -92,7 +94,8 @@ func TestSysEnableAuth(t *testing.T) {
 					"default_lease_ttl": json.Number("0"),
 					"max_lease_ttl":     json.Number("0"),
 				},
-				"local": false,
+				"local":     false,
+				"seal_wrap": false,
 			},
 			"token/": map[string]interface{}{
 				"description": "token based credentials",
@@ -101,7 +104,8 @@ func TestSysEnableAuth(t *testing.T) {
 					"default_lease_ttl": json.Number("0"),
 					"max_lease_ttl":     json.Number("0"),
 				},
-				"local": false,
+				"local":     false,
+				"seal_wrap": false,
 			},
 		},
 		"foo/": map[string]interface{}{
@@ -111,7 +115,8 @@ func TestSysEnableAuth(t *testing.T) {
 				"default_lease_ttl": json.Number("0"),
 				"max_lease_ttl":     json.Number("0"),
 			},
-			"local": false,
+			"local":     false,
+			"seal_wrap": false,
 		},
 		"token/": map[string]interface{}{
 			"description": "token based credentials",
@@ -120,7 +125,8 @@ func TestSysEnableAuth(t *testing.T) {
 				"default_lease_ttl": json.Number("0"),
 				"max_lease_ttl":     json.Number("0"),
 			},
-			"local": false,
+			"local":     false,
+			"seal_wrap": false,
 		},
 	}
 	testResponseStatus(t, resp, 200)
@@ -174,6 +180,7 @@ func TestSysDisableAuth(t *testing.T) {
 				"description": "token based credentials",
 				"type":        "token",
 				"local":       false,
+				"seal_wrap":   false,
 			},
 		},
 		"token/": map[string]interface{}{
@@ -184,6 +191,7 @@ func TestSysDisableAuth(t *testing.T) {
 			"description": "token based credentials",
 			"type":        "token",
 			"local":       false,
+			"seal_wrap":   false,
 		},
 	}
 	testResponseStatus(t, resp, 200)
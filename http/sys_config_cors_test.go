@@ -75,4 +75,19 @@ func TestSysConfigCors(t *testing.T) {
 		t.Fatalf("bad: expected: %#v\nactual: %#v", expected, actual)
 	}
 
+	// Reconfiguring CORS replaces the allowed headers rather than adding to
+	// them
+	resp = testHttpPut(t, token, addr+"/v1/sys/config/cors", map[string]interface{}{
+		"allowed_origins": addr,
+		"allowed_headers": "X-Other-Header",
+	})
+	testResponseStatus(t, resp, 204)
+
+	corsConf.RLock()
+	allowedHeaders := corsConf.AllowedHeaders
+	corsConf.RUnlock()
+	expectedAllowedHeaders := append(append([]string{}, vault.StdAllowedHeaders...), "X-Other-Header")
+	if !reflect.DeepEqual(allowedHeaders, expectedAllowedHeaders) {
+		t.Fatalf("bad: expected: %#v\nactual: %#v", expectedAllowedHeaders, allowedHeaders)
+	}
 }
@@ -70,8 +70,16 @@ func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Reques
 }
 
 func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, error) {
-	// Check if being a standby is allowed for the purpose of a 200 OK
-	_, standbyOK := r.URL.Query()["standbyok"]
+	// Check if being a standby is allowed for the purpose of a 200 OK. A bare
+	// standbyok parameter without a value counts as true.
+	standbyOKStr, standbyOK := r.URL.Query()["standbyok"]
+	if standbyOK && standbyOKStr[0] != "" {
+		var err error
+		standbyOK, err = strconv.ParseBool(standbyOKStr[0])
+		if err != nil {
+			return http.StatusBadRequest, nil, nil
+		}
+	}
 
 	uninitCode := http.StatusNotImplemented
 	if code, found, ok := fetchStatusCode(r, "uninitcode"); !ok {
@@ -216,6 +216,29 @@ func TestSysHealth_customcodes(t *testing.T) {
 	}
 }
 
+func TestSysHealth_standbyok(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	queries := map[string]int{
+		"standbyok":       200,
+		"standbyok=true":  200,
+		"standbyok=false": 200,
+		"standbyok=maybe": 400,
+	}
+	for query, code := range queries {
+		resp, err := http.Get(addr + "/v1/sys/health?" + query)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != code {
+			t.Fatalf("%s: expected status %d, got %d", query, code, resp.StatusCode)
+		}
+	}
+}
+
 func TestSysHealth_head(t *testing.T) {
 	core, _, _ := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
@@ -30,11 +30,17 @@ func handleSysLeaderGet(core *vault.Core, w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	var leaderID string
+	if haEnabled && address != "" {
+		leaderID = core.LeaderID()
+	}
+
 	respondOk(w, &LeaderResponse{
 		HAEnabled:            haEnabled,
 		IsSelf:               isLeader,
 		LeaderAddress:        address,
 		LeaderClusterAddress: clusterAddr,
+		LeaderID:             leaderID,
 	})
 }
 
@@ -43,4 +49,5 @@ type LeaderResponse struct {
 	IsSelf               bool   `json:"is_self"`
 	LeaderAddress        string `json:"leader_address"`
 	LeaderClusterAddress string `json:"leader_cluster_address"`
+	LeaderID             string `json:"leader_id"`
 }
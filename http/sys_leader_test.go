@@ -24,6 +24,7 @@ func TestSysLeader_get(t *testing.T) {
 		"is_self":                false,
 		"leader_address":         "",
 		"leader_cluster_address": "",
+		"leader_id":              "",
 	}
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
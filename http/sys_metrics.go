@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+// handleSysMetrics serves sys/metrics. Unlike other logical paths it accepts
+// its format as a query parameter, since Prometheus can only be configured
+// with a URL to scrape.
+func handleSysMetrics(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		req, statusCode, err := buildLogicalRequest(core, w, r)
+		if err != nil || statusCode != 0 {
+			respondError(w, statusCode, err)
+			return
+		}
+		if format := r.URL.Query().Get("format"); format != "" {
+			req.Data = map[string]interface{}{
+				"format": format,
+			}
+		}
+
+		resp, ok := request(core, w, r, req)
+		if !ok {
+			return
+		}
+
+		respondLogical(w, r, req, false, resp)
+	})
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// handleSysPprofProfile serves sys/pprof/profile, which collects a CPU
+// profile for the given number of seconds, 30 by default
+func handleSysPprofProfile(core *vault.Core) http.Handler {
+	return handleSysPprofCapture(core, "sys/pprof/profile", 30, core.PprofProfile)
+}
+
+// handleSysPprofTrace serves sys/pprof/trace, which collects an execution
+// trace for the given number of seconds, 1 by default
+func handleSysPprofTrace(core *vault.Core) http.Handler {
+	return handleSysPprofCapture(core, "sys/pprof/trace", 1, core.PprofTrace)
+}
+
+// handleSysPprofCapture serves a profile that is collected over a duration.
+// These do not go through the logical request path, so that the state lock
+// is not held while collecting and so that the collection stops when the
+// client goes away.
+func handleSysPprofCapture(core *vault.Core, path string, defaultSeconds int,
+	capture func(*logical.Request, int, <-chan struct{}) ([]byte, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		seconds := defaultSeconds
+		if raw := r.URL.Query().Get("seconds"); raw != "" {
+			var err error
+			seconds, err = strconv.Atoi(raw)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Errorf("error parsing seconds: %v", err))
+				return
+			}
+		}
+
+		requestID, err := uuid.GenerateUUID()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, errwrap.Wrapf("failed to generate identifier for the request: {{err}}", err))
+			return
+		}
+		req := requestAuth(core, r, &logical.Request{
+			ID:         requestID,
+			Operation:  logical.ReadOperation,
+			Path:       path,
+			Connection: getConnection(r),
+			Headers:    r.Header,
+		})
+
+		profile, err := capture(req, seconds, r.Context().Done())
+		if err != nil {
+			if errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+				respondError(w, http.StatusForbidden, err)
+				return
+			}
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write(profile)
+	})
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysPprof(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	for _, path := range []string{"/v1/sys/pprof/profile", "/v1/sys/pprof/trace"} {
+		resp := testHttpGet(t, token, addr+path+"?seconds=1")
+		testResponseStatus(t, resp, 200)
+		if ct := resp.Header.Get("Content-Type"); ct != "application/octet-stream" {
+			t.Fatalf("%s: bad content type: %s", path, ct)
+		}
+		profile, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: err: %s", path, err)
+		}
+		if len(profile) == 0 {
+			t.Fatalf("%s: empty profile", path)
+		}
+
+		for _, seconds := range []string{"0", "61", "bogus"} {
+			resp = testHttpGet(t, token, addr+path+"?seconds="+seconds)
+			testResponseStatus(t, resp, 400)
+		}
+	}
+
+	// Profiles require root privileges
+	resp := testHttpPost(t, token, addr+"/v1/auth/token/create", map[string]interface{}{
+		"policies": []string{"default"},
+	})
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	childToken := actual["auth"].(map[string]interface{})["client_token"].(string)
+	resp = testHttpGet(t, childToken, addr+"/v1/sys/pprof/profile?seconds=1")
+	testResponseStatus(t, resp, 403)
+}
+
+func TestSysPprof_clientDisconnect(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	req, err := http.NewRequest("GET", addr+"/v1/sys/pprof/profile?seconds=60", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	if _, err := http.DefaultClient.Do(req.WithContext(ctx)); err == nil {
+		t.Fatal("expected the request to time out")
+	}
+
+	// The abandoned profile stops, so that another one can be taken well
+	// before its duration would have expired
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp := testHttpGet(t, token, addr+"/v1/sys/pprof/profile?seconds=1")
+		resp.Body.Close()
+		if resp.StatusCode == 200 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("profile still running, got status %d", resp.StatusCode)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
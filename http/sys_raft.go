@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// handleSysRaftSnapshot serves sys/storage/raft/snapshot. Snapshots are
+// streamed to and from the client as-is rather than as JSON, so this does
+// not go through the logical request path.
+func handleSysRaftSnapshot(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var op logical.Operation
+		switch r.Method {
+		case "GET":
+			op = logical.ReadOperation
+		case "PUT", "POST":
+			op = logical.UpdateOperation
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		requestID, err := uuid.GenerateUUID()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, errwrap.Wrapf("failed to generate identifier for the request: {{err}}", err))
+			return
+		}
+		req := requestAuth(core, r, &logical.Request{
+			ID:         requestID,
+			Operation:  op,
+			Path:       "sys/storage/raft/snapshot",
+			Connection: getConnection(r),
+			Headers:    r.Header,
+		})
+
+		if op == logical.UpdateOperation {
+			if err := core.RaftSnapshotRestore(req, r.Body); err != nil {
+				respondRaftSnapshotError(w, err)
+				return
+			}
+			respondOk(w, nil)
+			return
+		}
+
+		sw := &snapshotWriter{w: w}
+		if err := core.RaftSnapshot(req, sw); err != nil {
+			// Once the snapshot has started streaming the status can no
+			// longer be changed; the client sees a truncated archive
+			if !sw.started {
+				respondRaftSnapshotError(w, err)
+			}
+			return
+		}
+	})
+}
+
+func respondRaftSnapshotError(w http.ResponseWriter, err error) {
+	switch {
+	case errwrap.Contains(err, logical.ErrPermissionDenied.Error()):
+		respondError(w, http.StatusForbidden, err)
+	case errwrap.Contains(err, vault.ErrRaftStorageNotInUse.Error()):
+		respondError(w, http.StatusBadRequest, err)
+	default:
+		respondError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// snapshotWriter sets the content type of a snapshot response once the
+// snapshot starts being written
+type snapshotWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (s *snapshotWriter) Write(p []byte) (int, error) {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/gzip")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	return s.w.Write(p)
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	physRaft "github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/vault"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func TestSysRaftSnapshot(t *testing.T) {
+	path, err := ioutil.TempDir("", "vault-raft")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(path)
+
+	backend, err := physRaft.NewRaftBackend(map[string]string{
+		"path":        path,
+		"node_id":     "node1",
+		"address":     "127.0.0.1:0",
+		"bootstrap":   "true",
+		"tls_disable": "true",
+	}, logformat.NewVaultLogger(log.LevelTrace))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer backend.(*physRaft.RaftBackend).Close()
+
+	core, _, token := vault.TestCoreUnsealedBackend(t, backend)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{
+		"data": "bar",
+	})
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/storage/raft/configuration")
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	servers := actual["data"].(map[string]interface{})["servers"].([]interface{})
+	if len(servers) != 1 || servers[0].(map[string]interface{})["node_id"] != "node1" {
+		t.Fatalf("bad: %#v", actual)
+	}
+
+	// Snapshots require root privileges
+	resp = testHttpPost(t, token, addr+"/v1/auth/token/create", map[string]interface{}{
+		"policies": []string{"default"},
+	})
+	actual = nil
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	childToken := actual["auth"].(map[string]interface{})["client_token"].(string)
+	resp = testHttpGet(t, childToken, addr+"/v1/sys/storage/raft/snapshot")
+	testResponseStatus(t, resp, 403)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/storage/raft/snapshot")
+	testResponseStatus(t, resp, 200)
+	if ct := resp.Header.Get("Content-Type"); ct != "application/gzip" {
+		t.Fatalf("bad content type: %s", ct)
+	}
+	snapshot, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp = testHttpPut(t, token, addr+"/v1/secret/foo", map[string]interface{}{
+		"data": "baz",
+	})
+	testResponseStatus(t, resp, 204)
+
+	req, err := http.NewRequest("PUT", addr+"/v1/sys/storage/raft/snapshot", bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	req.Header.Set(AuthHeaderName, token)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, resp, 204)
+
+	// The data written after the snapshot was taken is gone
+	resp = testHttpGet(t, token, addr+"/v1/secret/foo")
+	actual = nil
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	if actual["data"].(map[string]interface{})["data"] != "bar" {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
+
+func TestSysRaftSnapshot_notInUse(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	// Without raft storage there is nothing to snapshot
+	resp := testHttpGet(t, token, addr+"/v1/sys/storage/raft/snapshot")
+	testResponseStatus(t, resp, 400)
+	resp = testHttpGet(t, token, addr+"/v1/sys/storage/raft/configuration")
+	testResponseStatus(t, resp, 400)
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/vault"
+)
+
+// These disaster recovery replication endpoints are handled outside of the
+// system backend, as secondaries are always sealed.
+
+func handleSysDRReplicationStatus(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		respondOk(w, core.DRReplicationStatus())
+	})
+}
+
+func handleSysDRPrimaryStream(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+		case "POST":
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		var req vault.DRStreamRequest
+		if err := parseRequest(r, w, &req); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		resp, err := core.DRStream(&req)
+		if err != nil {
+			respondDRError(w, err)
+			return
+		}
+		respondOk(w, resp)
+	})
+}
+
+func handleSysDRSecondaryPromote(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+		case "POST":
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		var req DRSecondaryPromoteRequest
+		if err := parseRequest(r, w, &req); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		if !req.Reset && req.Key == "" {
+			respondError(
+				w, http.StatusBadRequest,
+				errors.New("'key' must be specified in request body as JSON, or 'reset' set to true"))
+			return
+		}
+
+		complete := false
+		if req.Reset {
+			core.ResetUnsealProcess()
+		} else {
+			key, err := decodeDRKey(core, req.Key)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, err)
+				return
+			}
+			complete, err = core.PromoteDRSecondary(key)
+			if err != nil {
+				respondDRError(w, err)
+				return
+			}
+		}
+
+		respondDRKeyProgress(core, w, complete)
+	})
+}
+
+func handleSysDRSecondaryUpdatePrimary(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+		case "POST":
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		var req DRSecondaryUpdatePrimaryRequest
+		if err := parseRequest(r, w, &req); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		if !req.Reset && (req.Key == "" || req.Token == "") {
+			respondError(
+				w, http.StatusBadRequest,
+				errors.New("'key' and 'token' must be specified in request body as JSON, or 'reset' set to true"))
+			return
+		}
+
+		complete := false
+		if req.Reset {
+			core.ResetUnsealProcess()
+		} else {
+			key, err := decodeDRKey(core, req.Key)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, err)
+				return
+			}
+			complete, err = core.UpdateDRPrimary(req.Token, req.PrimaryAPIAddr, req.CAFile, req.CAPath, key)
+			if err != nil {
+				respondDRError(w, err)
+				return
+			}
+		}
+
+		respondDRKeyProgress(core, w, complete)
+	})
+}
+
+// decodeDRKey decodes a key share, which is base64 or hex encoded
+func decodeDRKey(core *vault.Core, encoded string) ([]byte, error) {
+	min, max := core.BarrierKeyLength()
+	key, err := hex.DecodeString(encoded)
+	// We check min and max here to ensure that a string that is base64
+	// encoded but also valid hex will not be valid and we instead base64
+	// decode it
+	if err != nil || len(key) < min || len(key) > max {
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("'key' must be a valid hex or base64 string")
+		}
+	}
+	return key, nil
+}
+
+// respondDRError responds with the status code of the error if it carries
+// one, such as for invalid requests or credentials, and a server error
+// otherwise
+func respondDRError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if coded, ok := err.(logical.HTTPCodedError); ok {
+		status = coded.Code()
+	}
+	respondError(w, status, err)
+}
+
+func respondDRKeyProgress(core *vault.Core, w http.ResponseWriter, complete bool) {
+	progress, required, err := core.DRKeyProgress()
+	if err != nil {
+		respondDRError(w, err)
+		return
+	}
+
+	respondOk(w, &DRKeyProgressResponse{
+		Progress: progress,
+		Required: required,
+		Complete: complete,
+	})
+}
+
+type DRSecondaryPromoteRequest struct {
+	Key   string `json:"key"`
+	Reset bool   `json:"reset"`
+}
+
+type DRSecondaryUpdatePrimaryRequest struct {
+	Key            string `json:"key"`
+	Reset          bool   `json:"reset"`
+	Token          string `json:"token"`
+	PrimaryAPIAddr string `json:"primary_api_addr"`
+	CAFile         string `json:"ca_file"`
+	CAPath         string `json:"ca_path"`
+}
+
+type DRKeyProgressResponse struct {
+	Progress int  `json:"progress"`
+	Required int  `json:"required"`
+	Complete bool `json:"complete"`
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysDRReplication(t *testing.T) {
+	primary, keys, root := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, primary)
+	defer ln.Close()
+	secondary, _, secondaryRoot := vault.TestCoreUnsealed(t)
+	ln2, addr2 := TestServer(t, secondary)
+	defer ln2.Close()
+
+	resp := testHttpPut(t, root, addr+"/v1/secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+	testResponseStatus(t, resp, 204)
+	resp = testHttpPut(t, root, addr+"/v1/sys/replication/dr/primary/enable", nil)
+	testResponseStatus(t, resp, 204)
+
+	resp = testHttpPut(t, root, addr+"/v1/sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": "dr1",
+	})
+	var actual map[string]interface{}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	token := actual["data"].(map[string]interface{})["token"].(string)
+
+	// Streaming requires the credentials of a secondary
+	resp = testHttpPut(t, "", addr+"/v1/sys/replication/dr/primary/stream", map[string]interface{}{
+		"id":     "dr1",
+		"secret": "bad",
+	})
+	testResponseStatus(t, resp, 403)
+
+	// The primary is not a secondary, and invalid activation tokens are
+	// rejected
+	resp = testHttpPut(t, "", addr+"/v1/sys/replication/dr/secondary/promote", map[string]interface{}{
+		"key": hex.EncodeToString(keys[0]),
+	})
+	testResponseStatus(t, resp, 400)
+	resp = testHttpPut(t, "", addr2+"/v1/sys/replication/dr/secondary/update-primary", map[string]interface{}{
+		"key":   hex.EncodeToString(keys[0]),
+		"token": "bad",
+	})
+	testResponseStatus(t, resp, 400)
+
+	resp = testHttpPut(t, secondaryRoot, addr2+"/v1/sys/replication/dr/secondary/enable", map[string]interface{}{
+		"token":            token,
+		"primary_api_addr": addr,
+	})
+	testResponseStatus(t, resp, 204)
+
+	// The secondary is sealed and streams the storage of the primary
+	var status map[string]interface{}
+	for i := 0; i < 50; i++ {
+		resp = testHttpGet(t, "", addr2+"/v1/sys/replication/dr/status")
+		testResponseStatus(t, resp, 200)
+		status = nil
+		testResponseBody(t, resp, &status)
+		if status["last_sync"] != "" {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if status["mode"] != "secondary" || status["primary_api_addr"] != addr || status["last_sync"] == "" {
+		t.Fatalf("bad: %#v", status)
+	}
+	if sealed, _ := secondary.Sealed(); !sealed {
+		t.Fatal("expected the secondary to be sealed")
+	}
+
+	resp = testHttpPut(t, "", addr2+"/v1/sys/replication/dr/secondary/promote", map[string]interface{}{
+		"key": "bad",
+	})
+	testResponseStatus(t, resp, 400)
+	for i, key := range keys {
+		resp = testHttpPut(t, "", addr2+"/v1/sys/replication/dr/secondary/promote", map[string]interface{}{
+			"key": hex.EncodeToString(key),
+		})
+		actual = nil
+		testResponseStatus(t, resp, 200)
+		testResponseBody(t, resp, &actual)
+		expected := map[string]interface{}{
+			"progress": json.Number(strconv.Itoa(i + 1)),
+			"required": json.Number(strconv.Itoa(len(keys))),
+			"complete": false,
+		}
+		if i == len(keys)-1 {
+			expected["progress"] = json.Number("0")
+			expected["complete"] = true
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("bad: %#v", actual)
+		}
+	}
+
+	resp = testHttpGet(t, root, addr2+"/v1/secret/foo")
+	testResponseStatus(t, resp, 200)
+	actual = nil
+	testResponseBody(t, resp, &actual)
+	if actual["data"].(map[string]interface{})["value"] != "bar" {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
@@ -120,14 +120,19 @@ func handleSysUnseal(core *vault.Core) http.Handler {
 				}
 			}
 
-			// Attempt the unseal
-			if _, err := core.Unseal(key); err != nil {
+			// Attempt the unseal, migrating seals if requested
+			unseal := core.Unseal
+			if req.Migrate {
+				unseal = core.UnsealMigrate
+			}
+			if _, err := unseal(key); err != nil {
 				switch {
 				case errwrap.ContainsType(err, new(vault.ErrInvalidKey)):
 				case errwrap.Contains(err, vault.ErrBarrierInvalidKey.Error()):
 				case errwrap.Contains(err, vault.ErrBarrierNotInit.Error()):
 				case errwrap.Contains(err, vault.ErrBarrierSealed.Error()):
 				case errwrap.Contains(err, consts.ErrStandby.Error()):
+				case errwrap.Contains(err, vault.ErrNoSealMigration.Error()):
 				default:
 					respondError(w, http.StatusInternalServerError, err)
 					return
@@ -213,6 +218,7 @@ type SealStatusResponse struct {
 }
 
 type UnsealRequest struct {
-	Key   string
-	Reset bool
+	Key     string
+	Reset   bool
+	Migrate bool
 }
@@ -163,6 +163,19 @@ func TestSysUnseal_badKey(t *testing.T) {
 	testResponseStatus(t, resp, 400)
 }
 
+func TestSysUnseal_migrateNotConfigured(t *testing.T) {
+	core := vault.TestCore(t)
+	keys, _ := vault.TestCoreInit(t, core)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	resp := testHttpPut(t, "", addr+"/v1/sys/unseal", map[string]interface{}{
+		"key":     hex.EncodeToString(keys[0]),
+		"migrate": true,
+	})
+	testResponseStatus(t, resp, 400)
+}
+
 func TestSysUnseal_Reset(t *testing.T) {
 	core := vault.TestCore(t)
 	ln, addr := TestServer(t, core)
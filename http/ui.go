@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// uiPrefix is the path the web UI is served under
+const uiPrefix = "/ui/"
+
+// uiAsset is a file of the web UI
+type uiAsset struct {
+	ContentType string
+	Body        string
+}
+
+// uiAssets are the files of the web UI, by their path under uiPrefix. The
+// UI is a single page that only talks to the API, so it needs no build step
+// and is compiled into the binary.
+var uiAssets = map[string]*uiAsset{
+	"":        &uiAsset{ContentType: "text/html; charset=utf-8", Body: uiIndexHTML},
+	"app.js":  &uiAsset{ContentType: "application/javascript; charset=utf-8", Body: uiAppJS},
+	"app.css": &uiAsset{ContentType: "text/css; charset=utf-8", Body: uiAppCSS},
+}
+
+// UIHandler returns an http.Handler that serves the web UI under /ui/ and
+// passes any other request on to the given API handler. The root path is
+// redirected to the UI.
+func UIHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/" || r.URL.Path == strings.TrimSuffix(uiPrefix, "/"):
+			http.Redirect(w, r, uiPrefix, http.StatusTemporaryRedirect)
+		case strings.HasPrefix(r.URL.Path, uiPrefix):
+			handleUIAsset(w, r)
+		default:
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+func handleUIAsset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		respondError(w, http.StatusMethodNotAllowed, nil)
+		return
+	}
+
+	asset, ok := uiAssets[strings.TrimPrefix(r.URL.Path, uiPrefix)]
+	if !ok {
+		respondError(w, http.StatusNotFound, nil)
+		return
+	}
+
+	// The UI only loads its own scripts and styles and talks to the API of
+	// this server, and is never framed, so that the token it holds cannot
+	// be reached by other origins
+	w.Header().Set("Content-Type", asset.ContentType)
+	w.Header().Set("Content-Security-Policy", "default-src 'self'")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == "GET" {
+		w.Write([]byte(asset.Body))
+	}
+}
@@ -0,0 +1,638 @@
+package http
+
+// uiIndexHTML is the page of the web UI. It only loads the script and the
+// stylesheet; the views are rendered by the script.
+const uiIndexHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Vault</title>
+  <link rel="stylesheet" href="/ui/app.css">
+</head>
+<body>
+  <header>
+    <h1>Vault</h1>
+    <nav id="nav"></nav>
+  </header>
+  <main id="app"><p>Loading...</p></main>
+  <script src="/ui/app.js"></script>
+</body>
+</html>
+`
+
+const uiAppCSS = `body {
+  margin: 0;
+  font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif;
+  font-size: 15px;
+  color: #222;
+  background: #f5f5f5;
+}
+
+header {
+  display: flex;
+  align-items: center;
+  justify-content: space-between;
+  padding: 0 24px;
+  background: #000;
+  color: #fff;
+}
+
+header h1 {
+  margin: 12px 0;
+  font-size: 20px;
+}
+
+nav a, nav button {
+  margin-left: 16px;
+  color: #fff;
+}
+
+main {
+  max-width: 960px;
+  margin: 24px auto;
+  padding: 24px;
+  background: #fff;
+  border: 1px solid #ddd;
+}
+
+h2 {
+  margin-top: 0;
+  font-size: 18px;
+}
+
+table {
+  width: 100%;
+  border-collapse: collapse;
+  margin-bottom: 16px;
+}
+
+th, td {
+  padding: 6px 8px;
+  border-bottom: 1px solid #eee;
+  text-align: left;
+  vertical-align: top;
+}
+
+td.value {
+  font-family: Menlo, Consolas, monospace;
+  white-space: pre-wrap;
+  word-break: break-all;
+}
+
+form {
+  margin-bottom: 16px;
+}
+
+label {
+  display: block;
+  margin: 8px 0 4px;
+  font-weight: bold;
+}
+
+input, select {
+  width: 320px;
+  padding: 4px;
+}
+
+textarea {
+  width: 100%;
+  height: 320px;
+  font-family: Menlo, Consolas, monospace;
+}
+
+button {
+  margin: 8px 8px 0 0;
+  padding: 4px 12px;
+  cursor: pointer;
+}
+
+nav button {
+  background: none;
+  border: none;
+  padding: 0;
+  font-size: inherit;
+  text-decoration: underline;
+}
+
+.error {
+  padding: 8px;
+  border: 1px solid #c73445;
+  background: #fbeaec;
+  color: #c73445;
+}
+
+.breadcrumbs a {
+  margin-right: 4px;
+}
+`
+
+// uiAppJS renders the views of the web UI: unsealing, logging in with the
+// enabled auth methods, browsing and editing secrets, and managing policies.
+// The token is kept in the session storage of the browser, and every request
+// goes to the API of the server the UI was loaded from.
+const uiAppJS = `(function () {
+  "use strict";
+
+  var tokenKey = "vault-token";
+  var app = document.getElementById("app");
+  var nav = document.getElementById("nav");
+  var mounts = null;
+
+  // Creates an element. Text is always set as text, never parsed as HTML.
+  function el(tag, attrs, children) {
+    var node = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (key) {
+      var value = attrs[key];
+      if (key === "text") {
+        node.textContent = value;
+      } else if (key.indexOf("on") === 0) {
+        node.addEventListener(key.slice(2), value);
+      } else {
+        node.setAttribute(key, value);
+      }
+    });
+    (children || []).forEach(function (child) {
+      node.appendChild(typeof child === "string" ? document.createTextNode(child) : child);
+    });
+    return node;
+  }
+
+  function render(title, children) {
+    app.textContent = "";
+    app.appendChild(el("h2", { text: title }));
+    children.forEach(function (child) {
+      app.appendChild(child);
+    });
+  }
+
+  function errorBox(err) {
+    return el("p", { "class": "error", text: err.message || String(err) });
+  }
+
+  function renderError(err) {
+    if (err.status === 503) {
+      // The server was sealed
+      start();
+      return;
+    }
+    app.appendChild(errorBox(err));
+  }
+
+  function encodePath(path) {
+    return path.split("/").map(encodeURIComponent).join("/");
+  }
+
+  function decodePath(path) {
+    return path.split("/").map(decodeURIComponent).join("/");
+  }
+
+  function api(method, path, body, query) {
+    var headers = {};
+    var token = sessionStorage.getItem(tokenKey);
+    if (token) {
+      headers["X-Vault-Token"] = token;
+    }
+    var options = { method: method, headers: headers, credentials: "same-origin" };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+      options.body = JSON.stringify(body);
+    }
+
+    var url = "/v1/" + encodePath(path) + (query ? "?" + query : "");
+    return fetch(url, options).then(function (resp) {
+      if (resp.status === 204) {
+        return null;
+      }
+      return resp.json().catch(function () {
+        return {};
+      }).then(function (data) {
+        if (!resp.ok) {
+          var message = data.errors && data.errors.length ?
+            data.errors.join("; ") : "Request failed with status " + resp.status;
+          var err = new Error(message);
+          err.status = resp.status;
+          throw err;
+        }
+        return data;
+      });
+    });
+  }
+
+  function list(path) {
+    return api("GET", path, undefined, "list=true").then(function (resp) {
+      return resp.data.keys || [];
+    }, function (err) {
+      if (err.status === 404) {
+        return [];
+      }
+      throw err;
+    });
+  }
+
+  function renderNav() {
+    nav.textContent = "";
+    if (!sessionStorage.getItem(tokenKey)) {
+      return;
+    }
+    nav.appendChild(el("a", { href: "#/secrets", text: "Secrets" }));
+    nav.appendChild(el("a", { href: "#/policies", text: "Policies" }));
+    nav.appendChild(el("button", { text: "Log out", onclick: function () {
+      sessionStorage.removeItem(tokenKey);
+      mounts = null;
+      start();
+    } }));
+  }
+
+  // start shows the view for the state of the server: unsealing, logging in
+  // or the page in the location
+  function start() {
+    api("GET", "sys/seal-status").then(function (status) {
+      if (!status.initialized) {
+        nav.textContent = "";
+        render("Not initialized", [el("p", {
+          text: "This Vault server is not initialized. Initialize it with \"vault init\" and reload this page."
+        })]);
+      } else if (status.sealed) {
+        nav.textContent = "";
+        renderUnseal(status);
+      } else if (!sessionStorage.getItem(tokenKey)) {
+        nav.textContent = "";
+        renderLogin();
+      } else {
+        renderNav();
+        route();
+      }
+    }, function (err) {
+      render("Error", [errorBox(err)]);
+    });
+  }
+
+  function renderUnseal(status) {
+    var key = el("input", { type: "password", autocomplete: "off", id: "unseal-key" });
+    var form = el("form", { onsubmit: function (e) {
+      e.preventDefault();
+      api("PUT", "sys/unseal", { key: key.value }).then(start, function (err) {
+        form.appendChild(errorBox(err));
+      });
+    } }, [
+      el("label", { "for": "unseal-key", text: "Unseal key" }),
+      key,
+      el("div", {}, [
+        el("button", { type: "submit", text: "Unseal" }),
+        el("button", { type: "button", text: "Reset", onclick: function () {
+          api("PUT", "sys/unseal", { reset: true }).then(start);
+        } })
+      ])
+    ]);
+
+    render("Vault is sealed", [
+      el("p", { text: "Unseal progress: " + status.progress + " of " + status.t + " keys." }),
+      form
+    ]);
+  }
+
+  var loginMethods = {
+    token: ["token"],
+    userpass: ["username", "password"],
+    ldap: ["username", "password"],
+    okta: ["username", "password"],
+    github: ["token"]
+  };
+
+  function renderLogin() {
+    var method = el("select", { id: "login-method" }, Object.keys(loginMethods).map(function (name) {
+      return el("option", { value: name, text: name });
+    }));
+    var path = el("input", { id: "login-path", placeholder: "token" });
+    var fields = el("div");
+    var inputs = {};
+
+    function renderFields() {
+      fields.textContent = "";
+      inputs = {};
+      path.placeholder = method.value;
+      loginMethods[method.value].forEach(function (name) {
+        var input = el("input", {
+          id: "login-" + name,
+          type: name === "username" ? "text" : "password",
+          autocomplete: "off"
+        });
+        inputs[name] = input;
+        fields.appendChild(el("label", { "for": input.id, text: name }));
+        fields.appendChild(input);
+      });
+    }
+    method.addEventListener("change", renderFields);
+    renderFields();
+
+    var form = el("form", { onsubmit: function (e) {
+      e.preventDefault();
+      var mountPath = (path.value || method.value).replace(/^\/+|\/+$/g, "").replace(/^auth\//, "");
+      login(method.value, mountPath, inputs).then(function (token) {
+        sessionStorage.setItem(tokenKey, token);
+        location.hash = "#/secrets";
+        start();
+      }, function (err) {
+        sessionStorage.removeItem(tokenKey);
+        form.appendChild(errorBox(err));
+      });
+    } }, [
+      el("label", { "for": "login-method", text: "Method" }),
+      method,
+      el("label", { "for": "login-path", text: "Path" }),
+      path,
+      fields,
+      el("div", {}, [el("button", { type: "submit", text: "Log in" })])
+    ]);
+
+    render("Log in", [form]);
+  }
+
+  // login authenticates with the given method and returns the token
+  function login(method, path, inputs) {
+    switch (method) {
+    case "token":
+      // Check the token before keeping it
+      sessionStorage.setItem(tokenKey, inputs.token.value);
+      return api("GET", "auth/token/lookup-self").then(function () {
+        return inputs.token.value;
+      });
+    case "github":
+      return api("PUT", "auth/" + path + "/login", { token: inputs.token.value }).then(function (resp) {
+        return resp.auth.client_token;
+      });
+    default:
+      return api("PUT", "auth/" + path + "/login/" + inputs.username.value, {
+        password: inputs.password.value
+      }).then(function (resp) {
+        return resp.auth.client_token;
+      });
+    }
+  }
+
+  function route() {
+    var hash = location.hash.replace(/^#\/?/, "");
+    var parts = hash.split("/");
+    var rest = decodePath(parts.slice(1).join("/"));
+
+    switch (parts[0]) {
+    case "policies":
+      if (rest) {
+        renderPolicy(rest);
+      } else {
+        renderPolicies();
+      }
+      break;
+    case "secrets":
+      if (rest) {
+        renderSecretPath(rest);
+      } else {
+        renderMounts();
+      }
+      break;
+    default:
+      location.hash = "#/secrets";
+    }
+  }
+
+  function loadMounts() {
+    if (mounts) {
+      return Promise.resolve(mounts);
+    }
+    // Only the mounts the token can access are listed, which does not
+    // require read on sys/mounts
+    return api("GET", "sys/internal/ui/mounts").then(function (resp) {
+      mounts = resp.data.secret;
+      return mounts;
+    });
+  }
+
+  function renderMounts() {
+    render("Secrets", []);
+    loadMounts().then(function (mounts) {
+      var rows = Object.keys(mounts).sort().filter(function (path) {
+        return mounts[path].type !== "system" && mounts[path].type !== "identity";
+      }).map(function (path) {
+        return el("tr", {}, [
+          el("td", {}, [el("a", { href: "#/secrets/" + encodePath(path), text: path })]),
+          el("td", { text: mounts[path].type }),
+          el("td", { text: mounts[path].description || "" })
+        ]);
+      });
+      app.appendChild(el("table", {}, [
+        el("tr", {}, [el("th", { text: "Path" }), el("th", { text: "Type" }), el("th", { text: "Description" })])
+      ].concat(rows)));
+    }, renderError);
+  }
+
+  // kvPaths returns the API paths to list, read and write a secret path. The
+  // versioned kv backend keeps its data under data/ and metadata/.
+  function kvPaths(path) {
+    var mount = "";
+    Object.keys(mounts || {}).forEach(function (m) {
+      if (path.indexOf(m) === 0 && m.length > mount.length) {
+        mount = m;
+      }
+    });
+    if (!mount || mounts[mount].type !== "kv") {
+      return { list: path, data: path, versioned: false };
+    }
+    var rest = path.slice(mount.length);
+    return { list: mount + "metadata/" + rest, data: mount + "data/" + rest, versioned: true };
+  }
+
+  function breadcrumbs(path) {
+    var crumbs = el("p", { "class": "breadcrumbs" }, [el("a", { href: "#/secrets", text: "secrets" })]);
+    var prefix = "";
+    path.split("/").forEach(function (part, i, parts) {
+      if (!part) {
+        return;
+      }
+      prefix += part + (i < parts.length - 1 ? "/" : "");
+      crumbs.appendChild(document.createTextNode("/ "));
+      crumbs.appendChild(el("a", { href: "#/secrets/" + encodePath(prefix), text: part }));
+    });
+    return crumbs;
+  }
+
+  function renderSecretPath(path) {
+    // If the mounts cannot be read every mount is treated as unversioned
+    loadMounts().catch(function () {
+      return {};
+    }).then(function () {
+      if (path.charAt(path.length - 1) === "/") {
+        renderSecretList(path);
+      } else {
+        renderSecret(path);
+      }
+    });
+  }
+
+  function renderSecretList(path) {
+    var name = el("input", { id: "secret-name" });
+    render(path, [
+      breadcrumbs(path),
+      el("form", { onsubmit: function (e) {
+        e.preventDefault();
+        if (name.value) {
+          location.hash = "#/secrets/" + encodePath(path + name.value);
+        }
+      } }, [
+        el("label", { "for": "secret-name", text: "Create a secret" }),
+        name,
+        el("button", { type: "submit", text: "Create" })
+      ])
+    ]);
+
+    list(kvPaths(path).list).then(function (keys) {
+      if (!keys.length) {
+        app.appendChild(el("p", { text: "No secrets under this path." }));
+        return;
+      }
+      app.appendChild(el("table", {}, keys.map(function (key) {
+        return el("tr", {}, [el("td", {}, [
+          el("a", { href: "#/secrets/" + encodePath(path + key), text: key })
+        ])]);
+      })));
+    }, renderError);
+  }
+
+  function renderSecret(path) {
+    var paths = kvPaths(path);
+    api("GET", paths.data).then(function (resp) {
+      var data = paths.versioned ? resp.data.data : resp.data;
+      showSecret(path, paths, data || {}, paths.versioned ? resp.data.metadata : null);
+    }, function (err) {
+      if (err.status === 404) {
+        editSecret(path, paths, {});
+        return;
+      }
+      render(path, [breadcrumbs(path)]);
+      renderError(err);
+    });
+  }
+
+  function showSecret(path, paths, data, metadata) {
+    var rows = Object.keys(data).sort().map(function (key) {
+      var value = data[key];
+      return el("tr", {}, [
+        el("td", { text: key }),
+        el("td", { "class": "value", text: typeof value === "string" ? value : JSON.stringify(value, null, 2) })
+      ]);
+    });
+    var children = [breadcrumbs(path), el("table", {}, rows)];
+    if (metadata) {
+      children.push(el("p", { text: "Version " + metadata.version + ", created " + metadata.created_time }));
+    }
+    children.push(el("div", {}, [
+      el("button", { text: "Edit", onclick: function () {
+        editSecret(path, paths, data);
+      } }),
+      el("button", { text: "Delete", onclick: function () {
+        if (!confirm("Delete " + path + "?")) {
+          return;
+        }
+        api("DELETE", paths.data).then(function () {
+          location.hash = "#/secrets/" + encodePath(path.slice(0, path.lastIndexOf("/") + 1));
+        }, renderError);
+      } })
+    ]));
+    render(path, children);
+  }
+
+  function editSecret(path, paths, data) {
+    var text = el("textarea", { id: "secret-data" });
+    text.value = JSON.stringify(data, null, 2);
+    var form = el("form", { onsubmit: function (e) {
+      e.preventDefault();
+      var value;
+      try {
+        value = JSON.parse(text.value);
+      } catch (err) {
+        form.appendChild(errorBox(new Error("The data is not valid JSON: " + err.message)));
+        return;
+      }
+      api("PUT", paths.data, paths.versioned ? { data: value } : value).then(function () {
+        renderSecret(path);
+      }, function (err) {
+        form.appendChild(errorBox(err));
+      });
+    } }, [
+      el("label", { "for": "secret-data", text: "Data (JSON)" }),
+      text,
+      el("button", { type: "submit", text: "Save" })
+    ]);
+    render(path, [breadcrumbs(path), form]);
+  }
+
+  function renderPolicies() {
+    var name = el("input", { id: "policy-name" });
+    render("Policies", [el("form", { onsubmit: function (e) {
+      e.preventDefault();
+      if (name.value) {
+        location.hash = "#/policies/" + encodeURIComponent(name.value);
+      }
+    } }, [
+      el("label", { "for": "policy-name", text: "Create a policy" }),
+      name,
+      el("button", { type: "submit", text: "Create" })
+    ])]);
+
+    api("GET", "sys/policy").then(function (resp) {
+      var policies = (resp.data && resp.data.policies) || resp.policies || [];
+      app.appendChild(el("table", {}, policies.map(function (policy) {
+        return el("tr", {}, [el("td", {}, [
+          el("a", { href: "#/policies/" + encodeURIComponent(policy), text: policy })
+        ])]);
+      })));
+    }, renderError);
+  }
+
+  function renderPolicy(name) {
+    api("GET", "sys/policy/" + name).then(function (resp) {
+      editPolicy(name, (resp.data && resp.data.rules) || resp.rules || "");
+    }, function (err) {
+      if (err.status === 404) {
+        editPolicy(name, "");
+        return;
+      }
+      render(name, []);
+      renderError(err);
+    });
+  }
+
+  function editPolicy(name, rules) {
+    var text = el("textarea", { id: "policy-rules" });
+    text.value = rules;
+    var form = el("form", { onsubmit: function (e) {
+      e.preventDefault();
+      api("PUT", "sys/policy/" + name, { rules: text.value }).then(function () {
+        location.hash = "#/policies";
+      }, function (err) {
+        form.appendChild(errorBox(err));
+      });
+    } }, [
+      el("label", { "for": "policy-rules", text: "Rules" }),
+      text,
+      el("div", {}, [
+        el("button", { type: "submit", text: "Save" }),
+        el("button", { type: "button", text: "Delete", onclick: function () {
+          if (!confirm("Delete the policy " + name + "?")) {
+            return;
+          }
+          api("DELETE", "sys/policy/" + name).then(function () {
+            location.hash = "#/policies";
+          }, function (err) {
+            form.appendChild(errorBox(err));
+          });
+        } })
+      ])
+    ]);
+    render("Policy " + name, [form]);
+  }
+
+  window.addEventListener("hashchange", start);
+  start();
+})();
+`
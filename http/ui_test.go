@@ -0,0 +1,51 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestUIHandler(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+
+	server := &http.Server{
+		Handler: UIHandler(Handler(core)),
+	}
+	go server.Serve(ln)
+
+	// The root path leads to the UI
+	resp := testHttpGet(t, "", addr+"/")
+	testResponseStatus(t, resp, 200)
+	if resp.Request.URL.Path != "/ui/" {
+		t.Fatalf("bad: %s", resp.Request.URL.Path)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(body), `<script src="/ui/app.js">`) {
+		t.Fatalf("bad: %s", body)
+	}
+	if resp.Header.Get("X-Frame-Options") != "DENY" {
+		t.Fatalf("bad: %#v", resp.Header)
+	}
+
+	resp = testHttpGet(t, "", addr+"/ui/app.js")
+	testResponseStatus(t, resp, 200)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/javascript") {
+		t.Fatalf("bad: %#v", resp.Header)
+	}
+
+	resp = testHttpGet(t, "", addr+"/ui/bogus")
+	testResponseStatus(t, resp, 404)
+
+	// The API is still served
+	resp = testHttpGet(t, token, addr+"/v1/sys/mounts")
+	testResponseStatus(t, resp, 200)
+}
@@ -52,9 +52,19 @@ type Auth struct {
 	// Number of allowed uses of the issued token
 	NumUses int `json:"num_uses" mapstructure:"num_uses" structs:"num_uses"`
 
+	// BoundCIDRs is the set of CIDR blocks the issued token is restricted
+	// to. Requests made with the token from any other source address will
+	// be denied.
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
+
 	// Persona is the information about the authenticated client returned by
 	// the auth backend
 	Persona *Persona `json:"persona" structs:"persona" mapstructure:"persona"`
+
+	// GroupPersonas are the groups the authenticated client is a member of
+	// in the auth backend. They are used to manage the membership of
+	// external identity groups.
+	GroupPersonas []*Persona `json:"group_personas" structs:"group_personas" mapstructure:"group_personas"`
 }
 
 func (a *Auth) GoString() string {
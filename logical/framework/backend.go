@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"reflect"
 	"regexp"
 	"sort"
 	"strings"
@@ -200,6 +201,16 @@ func (b *Backend) HandleRequest(req *logical.Request) (*logical.Response, error)
 		raw[k] = v
 	}
 
+	// A patch sets a field to null to reset it, so hand the callback the
+	// default of the field as if it had been given
+	if req.Operation == logical.PatchOperation {
+		for k, v := range raw {
+			if schema, ok := path.Fields[k]; ok && v == nil {
+				raw[k] = schema.DefaultOrZero()
+			}
+		}
+	}
+
 	// Look up the callback for this operation
 	var callback OperationFunc
 	var ok bool
@@ -221,8 +232,14 @@ func (b *Backend) HandleRequest(req *logical.Request) (*logical.Response, error)
 		Schema: path.Fields}
 
 	if req.Operation != logical.HelpOperation {
-		err := fd.Validate()
-		if err != nil {
+		// Required fields only apply to writes, as reads and deletes of
+		// the same path have no need for them
+		required := req.Operation == logical.CreateOperation ||
+			req.Operation == logical.UpdateOperation
+		if err := fd.validate(required); err != nil {
+			if _, ok := err.(*ValidationError); ok {
+				return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+			}
 			return nil, err
 		}
 	}
@@ -429,7 +446,20 @@ func (b *Backend) handleRootHelp() (*logical.Response, error) {
 		return nil, err
 	}
 
-	return logical.HelpResponse(help, nil), nil
+	// The OpenAPI document is passed as generic data, so that it can be
+	// returned by plugins as well
+	docJSON, err := json.Marshal(b.OpenAPI())
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(docJSON, &doc); err != nil {
+		return nil, err
+	}
+
+	resp := logical.HelpResponse(help, nil)
+	resp.Data["openapi"] = doc
+	return resp, nil
 }
 
 func (b *Backend) handleRevokeRenew(
@@ -472,13 +502,21 @@ func (b *Backend) handleRevokeRenew(
 func (b *Backend) handleRollback(
 	req *logical.Request) (*logical.Response, error) {
 	// Response is not expected from the periodic operation.
+	var periodicErr error
 	if b.PeriodicFunc != nil {
-		if err := b.PeriodicFunc(req); err != nil {
-			return nil, err
-		}
+		periodicErr = b.PeriodicFunc(req)
 	}
 
-	return b.handleWALRollback(req)
+	// A failing periodic function must not keep the WAL entries from being
+	// rolled back, so its error is only returned after the rollback.
+	resp, err := b.handleWALRollback(req)
+	if periodicErr == nil {
+		return resp, err
+	}
+	if err == logical.ErrUnsupportedOperation {
+		err = nil
+	}
+	return resp, multierror.Append(err, periodicErr)
 }
 
 func (b *Backend) handleAuthRenew(req *logical.Request) (*logical.Response, error) {
@@ -556,6 +594,54 @@ type FieldSchema struct {
 	Type        FieldType
 	Default     interface{}
 	Description string
+
+	// Required, if set, rejects create and update requests that do not set
+	// the field.
+	Required bool
+
+	// AllowedValues, if set, rejects requests that set the field to any
+	// other value. The values are compared to the parsed value of the
+	// field, and for the string slice types to each of its elements.
+	AllowedValues []interface{}
+
+	// ValidateFunc, if set, is called with the parsed value of the field
+	// when a request sets it, rejecting the request if it returns an error.
+	ValidateFunc func(interface{}) error
+}
+
+// validateValue checks a parsed value of the field against its allowed
+// values and validation function.
+func (s *FieldSchema) validateValue(value interface{}) error {
+	if len(s.AllowedValues) > 0 {
+		values := []interface{}{value}
+		if slice, ok := value.([]string); ok {
+			values = make([]interface{}, len(slice))
+			for i, v := range slice {
+				values[i] = v
+			}
+		}
+
+		for _, v := range values {
+			if !s.allowedValue(v) {
+				return fmt.Errorf("%v is not one of the allowed values %v", v, s.AllowedValues)
+			}
+		}
+	}
+
+	if s.ValidateFunc != nil {
+		return s.ValidateFunc(value)
+	}
+
+	return nil
+}
+
+func (s *FieldSchema) allowedValue(value interface{}) bool {
+	for _, allowed := range s.AllowedValues {
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
 }
 
 // DefaultOrZero returns the default value if it is set, or otherwise
@@ -618,6 +704,8 @@ func (t FieldType) Zero() interface{} {
 		return []interface{}{}
 	case TypeStringSlice, TypeCommaStringSlice:
 		return []string{}
+	case TypeKVPairs:
+		return map[string]string{}
 	default:
 		panic("unknown type: " + t.String())
 	}
@@ -1,7 +1,9 @@
 package framework
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -112,6 +114,160 @@ func TestBackendHandleRequest_badwrite(t *testing.T) {
 
 }
 
+func TestBackendHandleRequest_validation(t *testing.T) {
+	var called bool
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		called = true
+		return nil, nil
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo/bar",
+				Fields: map[string]*FieldSchema{
+					"name": &FieldSchema{
+						Type:     TypeString,
+						Required: true,
+					},
+					"mode": &FieldSchema{
+						Type:          TypeString,
+						AllowedValues: []interface{}{"a", "b"},
+					},
+					"modes": &FieldSchema{
+						Type:          TypeCommaStringSlice,
+						AllowedValues: []interface{}{"a", "b"},
+					},
+					"count": &FieldSchema{
+						Type: TypeInt,
+						ValidateFunc: func(v interface{}) error {
+							if v.(int) < 1 {
+								return fmt.Errorf("must be positive")
+							}
+							return nil
+						},
+					},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation:   callback,
+					logical.UpdateOperation: callback,
+				},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		Operation logical.Operation
+		Data      map[string]interface{}
+		Error     string
+	}{
+		"valid": {
+			logical.UpdateOperation,
+			map[string]interface{}{"name": "foo", "mode": "a", "modes": "a,b", "count": 1},
+			"",
+		},
+		"required field not needed on read": {
+			logical.ReadOperation,
+			map[string]interface{}{},
+			"",
+		},
+		"missing required field": {
+			logical.UpdateOperation,
+			map[string]interface{}{"mode": "a"},
+			"missing required field name",
+		},
+		"not allowed value": {
+			logical.UpdateOperation,
+			map[string]interface{}{"name": "foo", "mode": "c"},
+			"invalid value for field mode: c is not one of the allowed values [a b]",
+		},
+		"not allowed slice element": {
+			logical.UpdateOperation,
+			map[string]interface{}{"name": "foo", "modes": "a,c"},
+			"invalid value for field modes: c is not one of the allowed values [a b]",
+		},
+		"validate func": {
+			logical.UpdateOperation,
+			map[string]interface{}{"name": "foo", "count": 0},
+			"invalid value for field count: must be positive",
+		},
+		"all invalid fields": {
+			logical.UpdateOperation,
+			map[string]interface{}{"mode": "c", "count": 0},
+			"invalid value for field count: must be positive; " +
+				"invalid value for field mode: c is not one of the allowed values [a b]; " +
+				"missing required field name",
+		},
+	}
+
+	for name, tc := range cases {
+		called = false
+		resp, err := b.HandleRequest(&logical.Request{
+			Operation: tc.Operation,
+			Path:      "foo/bar",
+			Data:      tc.Data,
+		})
+
+		if tc.Error == "" {
+			if err != nil || !called {
+				t.Fatalf("%s: bad: %#v %v", name, resp, err)
+			}
+			continue
+		}
+
+		if err != logical.ErrInvalidRequest {
+			t.Fatalf("%s: bad: %v", name, err)
+		}
+		if called {
+			t.Fatalf("%s: callback should not be called", name)
+		}
+		if resp == nil || !resp.IsError() || resp.Data["error"] != tc.Error {
+			t.Fatalf("%s: bad: %#v", name, resp)
+		}
+	}
+}
+
+func TestBackendHandleRequest_patch(t *testing.T) {
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		resp := &logical.Response{Data: map[string]interface{}{}}
+		for _, k := range []string{"value", "name"} {
+			if v, ok := data.GetOk(k); ok {
+				resp.Data[k] = v
+			}
+		}
+		return resp, nil
+	}
+
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo/bar",
+				Fields: map[string]*FieldSchema{
+					"value": &FieldSchema{Type: TypeInt, Default: 42},
+					"name":  &FieldSchema{Type: TypeString, Required: true},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.PatchOperation: callback,
+				},
+			},
+		},
+	}
+
+	// Required fields do not apply, and null resets a field to its default
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.PatchOperation,
+		Path:      "foo/bar",
+		Data:      map[string]interface{}{"value": nil},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := map[string]interface{}{"value": 42}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
 func TestBackendHandleRequest_404(t *testing.T) {
 	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
 		return &logical.Response{
@@ -338,6 +494,44 @@ func TestBackendHandleRequest_rollback(t *testing.T) {
 	}
 }
 
+func TestBackendHandleRequest_rollbackPeriodicError(t *testing.T) {
+	var called uint32
+	callback := func(req *logical.Request, kind string, data interface{}) error {
+		if data == "foo" {
+			atomic.AddUint32(&called, 1)
+		}
+
+		return nil
+	}
+
+	b := &Backend{
+		PeriodicFunc: func(req *logical.Request) error {
+			return fmt.Errorf("periodic failure")
+		},
+		WALRollback:       callback,
+		WALRollbackMinAge: 1 * time.Millisecond,
+	}
+
+	storage := new(logical.InmemStorage)
+	if _, err := PutWAL(storage, "kind", "foo"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	_, err := b.HandleRequest(&logical.Request{
+		Operation: logical.RollbackOperation,
+		Path:      "",
+		Storage:   storage,
+	})
+	if err == nil || !strings.Contains(err.Error(), "periodic failure") {
+		t.Fatalf("bad: %v", err)
+	}
+	if v := atomic.LoadUint32(&called); v != 1 {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
 func TestBackendHandleRequest_rollbackMinAge(t *testing.T) {
 	var called uint32
 	callback := func(req *logical.Request, kind string, data interface{}) error {
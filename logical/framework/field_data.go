@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/strutil"
@@ -23,8 +25,18 @@ type FieldData struct {
 // Validate cycles through raw data and validate conversions in
 // the schema, so we don't get an error/panic later when
 // trying to get data out.  Data not in the schema is not
-// an error at this point, so we don't worry about it.
+// an error at this point, so we don't worry about it. Values
+// that are set are also checked against the allowed values and
+// validation function of their schema.
 func (d *FieldData) Validate() error {
+	return d.validate(false)
+}
+
+// validate is Validate, additionally checking that the required fields are
+// set if required is true. All invalid fields are reported in a single
+// ValidationError.
+func (d *FieldData) validate(required bool) error {
+	fieldErrors := make(map[string]string)
 	for field, value := range d.Raw {
 
 		schema, ok := d.Schema[field]
@@ -34,10 +46,18 @@ func (d *FieldData) Validate() error {
 
 		switch schema.Type {
 		case TypeBool, TypeInt, TypeMap, TypeDurationSecond, TypeString,
-			TypeNameString, TypeSlice, TypeStringSlice, TypeCommaStringSlice:
-			_, _, err := d.getPrimitive(field, schema)
+			TypeNameString, TypeSlice, TypeStringSlice, TypeCommaStringSlice,
+			TypeKVPairs:
+			result, ok, err := d.getPrimitive(field, schema)
 			if err != nil {
-				return fmt.Errorf("Error converting input %v for field %s: %s", value, field, err)
+				fieldErrors[field] = fmt.Sprintf("Error converting input %v for field %s: %s", value, field, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			if err := schema.validateValue(result); err != nil {
+				fieldErrors[field] = fmt.Sprintf("invalid value for field %s: %s", field, err)
 			}
 		default:
 			return fmt.Errorf("unknown field type %s for field %s",
@@ -45,7 +65,41 @@ func (d *FieldData) Validate() error {
 		}
 	}
 
-	return nil
+	if required {
+		for field, schema := range d.Schema {
+			if !schema.Required {
+				continue
+			}
+			if value, ok := d.Raw[field]; !ok || value == nil {
+				fieldErrors[field] = fmt.Sprintf("missing required field %s", field)
+			}
+		}
+	}
+
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fieldErrors}
+}
+
+// ValidationError is returned when the data of a request does not match the
+// schema of its path. It holds the error of each invalid field.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	errs := make([]string, len(fields))
+	for i, field := range fields {
+		errs[i] = e.Fields[field]
+	}
+	return strings.Join(errs, "; ")
 }
 
 // Get gets the value for the given field. If the key is an invalid field,
@@ -110,7 +164,8 @@ func (d *FieldData) GetOkErr(k string) (interface{}, bool, error) {
 
 	switch schema.Type {
 	case TypeBool, TypeInt, TypeMap, TypeDurationSecond, TypeString,
-		TypeNameString, TypeSlice, TypeStringSlice, TypeCommaStringSlice:
+		TypeNameString, TypeSlice, TypeStringSlice, TypeCommaStringSlice,
+		TypeKVPairs:
 		return d.getPrimitive(k, schema)
 	default:
 		return nil, false,
@@ -236,6 +291,38 @@ func (d *FieldData) getPrimitive(
 		}
 		return strutil.TrimStrings(result), true, nil
 
+	case TypeKVPairs:
+		// First try to parse this as a map
+		var mapResult map[string]string
+		if err := mapstructure.WeakDecode(raw, &mapResult); err == nil {
+			return mapResult, true, nil
+		}
+
+		// If map parse fails, parse as a string list of = delimited pairs
+		var listResult []string
+		config := &mapstructure.DecoderConfig{
+			Result:           &listResult,
+			WeaklyTypedInput: true,
+			DecodeHook:       mapstructure.StringToSliceHookFunc(","),
+		}
+		decoder, err := mapstructure.NewDecoder(config)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := decoder.Decode(raw); err != nil {
+			return nil, false, err
+		}
+
+		result := make(map[string]string, len(listResult))
+		for _, keyPair := range strutil.TrimStrings(listResult) {
+			keyPairSlice := strings.SplitN(keyPair, "=", 2)
+			if len(keyPairSlice) != 2 || strings.TrimSpace(keyPairSlice[0]) == "" {
+				return nil, false, fmt.Errorf("invalid key pair %q", keyPair)
+			}
+			result[strings.TrimSpace(keyPairSlice[0])] = strings.TrimSpace(keyPairSlice[1])
+		}
+		return result, true, nil
+
 	default:
 		panic(fmt.Sprintf("Unknown type: %s", schema.Type))
 	}
@@ -257,6 +257,51 @@ func TestFieldDataGet(t *testing.T) {
 			[]string{},
 		},
 
+		"kv pair type, map value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": map[string]interface{}{
+					"key1": "value1",
+					"key2": 2,
+				},
+			},
+			"foo",
+			map[string]string{"key1": "value1", "key2": "2"},
+		},
+
+		"kv pair type, comma string value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": "key1=value1, key2 = value2,key3=a=b",
+			},
+			"foo",
+			map[string]string{"key1": "value1", "key2": "value2", "key3": "a=b"},
+		},
+
+		"kv pair type, string slice value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": []interface{}{"key1=value1", "key2="},
+			},
+			"foo",
+			map[string]string{"key1": "value1", "key2": ""},
+		},
+
+		"kv pair type, unset value": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{},
+			"foo",
+			map[string]string{},
+		},
+
 		"name string type, valid string": {
 			map[string]*FieldSchema{
 				"foo": &FieldSchema{Type: TypeNameString},
@@ -332,6 +377,24 @@ func TestFieldDataGet_Error(t *testing.T) {
 			},
 			"foo",
 		},
+		"kv pair type, missing equal sign": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": "key1=value1,key2",
+			},
+			"foo",
+		},
+		"kv pair type, empty key": {
+			map[string]*FieldSchema{
+				"foo": &FieldSchema{Type: TypeKVPairs},
+			},
+			map[string]interface{}{
+				"foo": []interface{}{"=value1"},
+			},
+			"foo",
+		},
 		"name string type, empty string": {
 			map[string]*FieldSchema{
 				"foo": &FieldSchema{Type: TypeNameString},
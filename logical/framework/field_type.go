@@ -28,6 +28,10 @@ const (
 	// rules.  These rules include start and end with an alphanumeric
 	// character and characters in the middle can be alphanumeric or . or -.
 	TypeNameString
+
+	// TypeKVPairs allows you to represent the data as a map or a list of
+	// equal sign delimited key pairs
+	TypeKVPairs
 )
 
 func (t FieldType) String() string {
@@ -40,7 +44,7 @@ func (t FieldType) String() string {
 		return "int"
 	case TypeBool:
 		return "bool"
-	case TypeMap:
+	case TypeMap, TypeKVPairs:
 		return "map"
 	case TypeDurationSecond:
 		return "duration (sec)"
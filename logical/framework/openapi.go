@@ -0,0 +1,394 @@
+package framework
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+// OASVersion is the version of the OpenAPI specification that the documents
+// generated from paths follow.
+const OASVersion = "3.0.2"
+
+// OASDocument is an OpenAPI document describing the paths of one or more
+// backends.
+type OASDocument struct {
+	Version string                  `json:"openapi" mapstructure:"openapi"`
+	Info    OASInfo                 `json:"info"`
+	Servers []OASServer             `json:"servers,omitempty"`
+	Paths   map[string]*OASPathItem `json:"paths"`
+}
+
+type OASInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OASServer struct {
+	URL string `json:"url"`
+}
+
+type OASPathItem struct {
+	Summary     string         `json:"summary,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Parameters  []OASParameter `json:"parameters,omitempty"`
+
+	Get    *OASOperation `json:"get,omitempty"`
+	Post   *OASOperation `json:"post,omitempty"`
+	Patch  *OASOperation `json:"patch,omitempty"`
+	Delete *OASOperation `json:"delete,omitempty"`
+}
+
+type OASOperation struct {
+	Summary     string                  `json:"summary,omitempty"`
+	Parameters  []OASParameter          `json:"parameters,omitempty"`
+	RequestBody *OASRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OASResponse `json:"responses"`
+}
+
+type OASParameter struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	In          string     `json:"in"`
+	Required    bool       `json:"required,omitempty"`
+	Schema      *OASSchema `json:"schema,omitempty"`
+}
+
+type OASRequestBody struct {
+	Required bool                     `json:"required,omitempty"`
+	Content  map[string]*OASMediaType `json:"content"`
+}
+
+type OASMediaType struct {
+	Schema *OASSchema `json:"schema"`
+}
+
+type OASSchema struct {
+	Type                 string                `json:"type,omitempty"`
+	Format               string                `json:"format,omitempty"`
+	Description          string                `json:"description,omitempty"`
+	Properties           map[string]*OASSchema `json:"properties,omitempty"`
+	AdditionalProperties *OASSchema            `json:"additionalProperties,omitempty"`
+	Items                *OASSchema            `json:"items,omitempty"`
+	Required             []string              `json:"required,omitempty"`
+	Enum                 []interface{}         `json:"enum,omitempty"`
+	Default              interface{}           `json:"default,omitempty"`
+}
+
+type OASResponse struct {
+	Description string `json:"description"`
+}
+
+// NewOASDocument returns an OpenAPI document without any paths.
+func NewOASDocument(version string) *OASDocument {
+	return &OASDocument{
+		Version: OASVersion,
+		Info: OASInfo{
+			Title:   "HashiCorp Vault API",
+			Version: version,
+		},
+		Paths: make(map[string]*OASPathItem),
+	}
+}
+
+// AddPaths adds the paths of another document to this one, under the given
+// prefix, such as the path of the mount the paths are served from. Paths
+// already in the document are kept.
+func (d *OASDocument) AddPaths(prefix string, other *OASDocument) {
+	prefix = strings.Trim(prefix, "/")
+	for path, item := range other.Paths {
+		if prefix != "" {
+			path = "/" + prefix + path
+		}
+		if _, ok := d.Paths[path]; !ok {
+			d.Paths[path] = item
+		}
+	}
+}
+
+// OpenAPI returns an OpenAPI document describing the paths of the backend,
+// relative to the path the backend is mounted at. Paths whose patterns cannot
+// be expressed as OpenAPI paths are left out.
+func (b *Backend) OpenAPI() *OASDocument {
+	b.once.Do(b.init)
+
+	doc := NewOASDocument("")
+	doc.Info.Description = strings.TrimSpace(b.Help)
+	for _, p := range b.Paths {
+		for _, path := range expandPattern(p.Pattern) {
+			if _, ok := doc.Paths[path]; !ok {
+				doc.Paths[path] = documentPath(p, path)
+			}
+		}
+	}
+	return doc
+}
+
+var (
+	// oasPathParamRe matches the path parameters of an expanded pattern
+	oasPathParamRe = regexp.MustCompile(`\{(\w+)\}`)
+
+	// oasUnescapeRe matches the characters escaped in a pattern that stand
+	// for themselves in a path
+	oasUnescapeRe = regexp.MustCompile(`\\([./-])`)
+)
+
+// expandPattern returns the OpenAPI paths matched by a path pattern. Named
+// captures become path parameters, and groups with alternatives, or that
+// are optional, are expanded into one path for each of their alternatives.
+// Nothing is returned for patterns that match more than such paths.
+func expandPattern(pattern string) []string {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+	pattern = strings.TrimSuffix(pattern, "/?")
+	pattern = replaceNamedCaptures(pattern)
+
+	paths := []string{pattern}
+	for {
+		var expanded []string
+		done := true
+		for _, path := range paths {
+			alternatives, ok := expandGroup(path)
+			if ok {
+				done = false
+			}
+			expanded = append(expanded, alternatives...)
+		}
+		paths = expanded
+		if done {
+			break
+		}
+	}
+
+	result := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		path = oasUnescapeRe.ReplaceAllString(path, "$1")
+		if strings.ContainsAny(path, `()[]|*+?\^$`) {
+			continue
+		}
+		path = "/" + strings.TrimPrefix(path, "/")
+		if !seen[path] {
+			seen[path] = true
+			result = append(result, path)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// replaceNamedCaptures replaces the named captures of a pattern with path
+// parameters. An optional capture is wrapped in a group, which is then
+// expanded like any other.
+func replaceNamedCaptures(pattern string) string {
+	for {
+		start := strings.Index(pattern, "(?P<")
+		if start == -1 {
+			return pattern
+		}
+		nameEnd := strings.Index(pattern[start:], ">")
+		end := matchingParen(pattern, start)
+		if nameEnd == -1 || end == -1 {
+			return pattern
+		}
+
+		param := "{" + pattern[start+len("(?P<"):start+nameEnd] + "}"
+		if end+1 < len(pattern) && pattern[end+1] == '?' {
+			param = "(" + param + ")"
+		}
+		pattern = pattern[:start] + param + pattern[end+1:]
+	}
+}
+
+// matchingParen returns the index of the parenthesis closing the group that
+// starts at the given index, skipping escaped characters and character
+// classes, or -1 if there is none.
+func matchingParen(pattern string, start int) int {
+	depth := 0
+	inClass := false
+	for i := start; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expandGroup expands the first innermost group of a path into a path for
+// each of its alternatives, and for none of them if the group is optional.
+// It returns false if the path has no groups.
+func expandGroup(path string) ([]string, bool) {
+	end := strings.Index(path, ")")
+	if end == -1 {
+		return []string{path}, false
+	}
+	start := strings.LastIndex(path[:end], "(")
+	if start == -1 || (start > 0 && path[start-1] == '\\') {
+		return []string{path}, false
+	}
+
+	alternatives := strings.Split(strings.TrimPrefix(path[start+1:end], "?:"), "|")
+	suffix := path[end+1:]
+	if strings.HasPrefix(suffix, "?") {
+		suffix = suffix[1:]
+		alternatives = append(alternatives, "")
+	}
+
+	paths := make([]string, len(alternatives))
+	for i, alternative := range alternatives {
+		paths[i] = path[:start] + alternative + suffix
+	}
+	return paths, true
+}
+
+// documentPath returns the OpenAPI description of the given expansion of a
+// path.
+func documentPath(p *Path, path string) *OASPathItem {
+	item := &OASPathItem{
+		Summary:     strings.TrimSpace(p.HelpSynopsis),
+		Description: strings.TrimSpace(p.HelpDescription),
+	}
+
+	pathParams := make(map[string]bool)
+	for _, match := range oasPathParamRe.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		pathParams[name] = true
+
+		param := OASParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &OASSchema{Type: "string"},
+		}
+		if schema, ok := p.Fields[name]; ok {
+			param.Description = strings.TrimSpace(schema.Description)
+			param.Schema = documentField(schema)
+		}
+		item.Parameters = append(item.Parameters, param)
+	}
+
+	// The fields that are not path parameters make up the request body of
+	// writes
+	body := &OASSchema{
+		Type:       "object",
+		Properties: make(map[string]*OASSchema),
+	}
+	for name, schema := range p.Fields {
+		if pathParams[name] {
+			continue
+		}
+		body.Properties[name] = documentField(schema)
+		if schema.Required {
+			body.Required = append(body.Required, name)
+		}
+	}
+	sort.Strings(body.Required)
+
+	operation := func(withBody bool) *OASOperation {
+		op := &OASOperation{
+			Summary: item.Summary,
+			Responses: map[string]*OASResponse{
+				"200": &OASResponse{Description: "OK"},
+			},
+		}
+		if withBody && len(body.Properties) > 0 {
+			op.RequestBody = &OASRequestBody{
+				Content: map[string]*OASMediaType{
+					"application/json": &OASMediaType{Schema: body},
+				},
+			}
+		}
+		return op
+	}
+
+	_, read := p.Callbacks[logical.ReadOperation]
+	_, list := p.Callbacks[logical.ListOperation]
+	if read || list {
+		item.Get = operation(false)
+		if list {
+			// Lists are reads with the list query parameter set
+			item.Get.Parameters = append(item.Get.Parameters, OASParameter{
+				Name:        "list",
+				Description: "Return a list of the keys under the path",
+				In:          "query",
+				Required:    !read,
+				Schema:      &OASSchema{Type: "string", Enum: []interface{}{"true"}},
+			})
+		}
+	}
+
+	_, create := p.Callbacks[logical.CreateOperation]
+	_, update := p.Callbacks[logical.UpdateOperation]
+	if create || update {
+		item.Post = operation(true)
+	}
+
+	if _, ok := p.Callbacks[logical.PatchOperation]; ok {
+		item.Patch = operation(true)
+		if item.Patch.RequestBody != nil {
+			// Required fields do not apply to a patch
+			patchBody := *body
+			patchBody.Required = nil
+			item.Patch.RequestBody.Content["application/json"].Schema = &patchBody
+		}
+	}
+
+	if _, ok := p.Callbacks[logical.DeleteOperation]; ok {
+		item.Delete = operation(false)
+	}
+
+	return item
+}
+
+// documentField returns the OpenAPI schema of a field.
+func documentField(s *FieldSchema) *OASSchema {
+	schema := &OASSchema{
+		Description: strings.TrimSpace(s.Description),
+		Default:     s.Default,
+		Enum:        s.AllowedValues,
+	}
+
+	switch s.Type {
+	case TypeString, TypeNameString:
+		schema.Type = "string"
+	case TypeInt:
+		schema.Type = "integer"
+	case TypeBool:
+		schema.Type = "boolean"
+	case TypeDurationSecond:
+		// Durations are given as a number of seconds or a duration string
+		schema.Type = "string"
+		schema.Format = "duration"
+	case TypeMap:
+		schema.Type = "object"
+	case TypeKVPairs:
+		schema.Type = "object"
+		schema.AdditionalProperties = &OASSchema{Type: "string"}
+	case TypeSlice:
+		schema.Type = "array"
+		schema.Items = &OASSchema{}
+	case TypeStringSlice, TypeCommaStringSlice:
+		schema.Type = "array"
+		schema.Items = &OASSchema{Type: "string"}
+		if len(s.AllowedValues) > 0 {
+			schema.Items.Enum = s.AllowedValues
+			schema.Enum = nil
+		}
+	}
+
+	return schema
+}
@@ -0,0 +1,169 @@
+package framework
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestExpandPattern(t *testing.T) {
+	cases := map[string][]string{
+		"config$":                           []string{"/config"},
+		"roles/?$":                          []string{"/roles"},
+		"creds/" + GenericNameRegex("name"): []string{"/creds/{name}"},
+		"tools/random" + OptionalParamRegex("urlbytes"): []string{
+			"/tools/random",
+			"/tools/random/{urlbytes}",
+		},
+		"pprof/(?P<name>heap|goroutine)$": []string{"/pprof/{name}"},
+		"(export|keys)/(?P<name>.+)$":     []string{"/export/{name}", "/keys/{name}"},
+		"cert/(?P<serial>[0-9A-Fa-f-:]+)": []string{"/cert/{serial}"},
+		"login(/(?P<role>.+))?$":          []string{"/login", "/login/{role}"},
+		`ca(/pem)?`:                       []string{"/ca", "/ca/pem"},
+		`crl\.pem`:                        []string{"/crl.pem"},
+		"keys/.*":                         []string{},
+		"[^/]+/foo":                       []string{},
+	}
+
+	for pattern, expected := range cases {
+		actual := expandPattern(pattern)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("%s: expected %#v, got %#v", pattern, expected, actual)
+		}
+	}
+}
+
+func TestBackendOpenAPI(t *testing.T) {
+	callback := func(req *logical.Request, data *FieldData) (*logical.Response, error) {
+		return nil, nil
+	}
+
+	b := &Backend{
+		Help: "Test backend.",
+		Paths: []*Path{
+			&Path{
+				Pattern: "roles/" + GenericNameRegex("name"),
+				Fields: map[string]*FieldSchema{
+					"name": &FieldSchema{
+						Type:        TypeString,
+						Description: "Name of the role.",
+					},
+					"ttl": &FieldSchema{
+						Type:     TypeDurationSecond,
+						Required: true,
+					},
+					"mode": &FieldSchema{
+						Type:          TypeString,
+						Default:       "a",
+						AllowedValues: []interface{}{"a", "b"},
+					},
+				},
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation:   callback,
+					logical.UpdateOperation: callback,
+					logical.PatchOperation:  callback,
+					logical.DeleteOperation: callback,
+				},
+				HelpSynopsis: "Manage roles.",
+			},
+			&Path{
+				Pattern: "roles/?$",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ListOperation: callback,
+				},
+			},
+			&Path{
+				Pattern: "passthrough/.*",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation: callback,
+				},
+			},
+		},
+	}
+
+	doc := b.OpenAPI()
+	if doc.Version != OASVersion || doc.Info.Description != "Test backend." {
+		t.Fatalf("bad: %#v", doc)
+	}
+	if len(doc.Paths) != 2 {
+		t.Fatalf("bad: %#v", doc.Paths)
+	}
+
+	item := doc.Paths["/roles/{name}"]
+	if item == nil {
+		t.Fatalf("bad: %#v", doc.Paths)
+	}
+	if item.Summary != "Manage roles." {
+		t.Fatalf("bad: %#v", item)
+	}
+	if len(item.Parameters) != 1 || item.Parameters[0].Name != "name" ||
+		item.Parameters[0].In != "path" || !item.Parameters[0].Required {
+		t.Fatalf("bad: %#v", item.Parameters)
+	}
+	if item.Get == nil || item.Post == nil || item.Patch == nil || item.Delete == nil {
+		t.Fatalf("bad: %#v", item)
+	}
+	if item.Get.RequestBody != nil || item.Delete.RequestBody != nil {
+		t.Fatalf("bad: %#v", item)
+	}
+
+	body := item.Post.RequestBody.Content["application/json"].Schema
+	expected := &OASSchema{
+		Type: "object",
+		Properties: map[string]*OASSchema{
+			"ttl": &OASSchema{Type: "string", Format: "duration"},
+			"mode": &OASSchema{
+				Type:    "string",
+				Default: "a",
+				Enum:    []interface{}{"a", "b"},
+			},
+		},
+		Required: []string{"ttl"},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Fatalf("bad: %#v", body)
+	}
+	if patchBody := item.Patch.RequestBody.Content["application/json"].Schema; patchBody.Required != nil {
+		t.Fatalf("bad: %#v", patchBody)
+	}
+
+	item = doc.Paths["/roles"]
+	if item == nil || item.Get == nil || item.Post != nil {
+		t.Fatalf("bad: %#v", doc.Paths)
+	}
+	if len(item.Get.Parameters) != 1 || item.Get.Parameters[0].Name != "list" ||
+		!item.Get.Parameters[0].Required {
+		t.Fatalf("bad: %#v", item.Get.Parameters)
+	}
+}
+
+func TestBackendHandleRequest_helpOpenAPI(t *testing.T) {
+	b := &Backend{
+		Paths: []*Path{
+			&Path{
+				Pattern: "foo/bar",
+				Callbacks: map[logical.Operation]OperationFunc{
+					logical.ReadOperation: nil,
+				},
+			},
+		},
+	}
+
+	resp, err := b.HandleRequest(&logical.Request{
+		Operation: logical.HelpOperation,
+		Path:      "",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	doc, ok := resp.Data["openapi"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	paths := doc["paths"].(map[string]interface{})
+	if _, ok := paths["/foo/bar"]; !ok {
+		t.Fatalf("bad: %#v", doc)
+	}
+}
@@ -116,6 +116,7 @@ func (p *Path) helpCallback(
 			Key:         k,
 			Type:        schema.Type.String(),
 			Description: description,
+			Required:    schema.Required,
 		}
 	}
 
@@ -139,6 +140,7 @@ type pathTemplateFieldData struct {
 	Key         string
 	Type        string
 	Description string
+	Required    bool
 	URL         bool
 }
 
@@ -151,7 +153,7 @@ Matching Route: {{.RoutePattern}}
 {{ if .Fields -}}
 ## PARAMETERS
 {{range .Fields}}
-{{indent 4 .Key}} ({{.Type}})
+{{indent 4 .Key}} ({{.Type}}{{if .Required}}, required{{end}})
 {{indent 8 .Description}}
 {{end}}{{end}}
 ## DESCRIPTION
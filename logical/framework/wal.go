@@ -26,14 +26,14 @@ type WALEntry struct {
 // multiple kinds of WAL data and to easily disambiguate what data they're
 // expecting.
 //
-// Data within the WAL that is uncommitted (CommitWAL hasn't be called)
+// Data within the WAL that is uncommitted (DeleteWAL hasn't been called)
 // will be given to the rollback callback when an rollback operation is
 // received, allowing the backend to clean up some partial states.
 //
 // The data must be JSON encodable.
 //
 // This returns a unique ID that can be used to reference this WAL data.
-// WAL data cannot be modified. You can only add to the WAL and commit existing
+// WAL data cannot be modified. You can only add to the WAL and delete existing
 // WAL entries.
 func PutWAL(s logical.Storage, kind string, data interface{}) (string, error) {
 	value, err := json.Marshal(&WALEntry{
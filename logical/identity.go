@@ -4,12 +4,9 @@ package logical
 // Implicit entities get created when a client authenticates successfully from
 // any of the authentication backends (except token backend).
 //
-// This is applicable to enterprise binaries only. Persona should be set in the
-// Auth response returned by the credential backends. This structure is placed
-// in the open source repository only to enable custom authetication plugins to
-// be used along with enterprise binary. The custom auth plugins should make
-// use of this and fill out the Persona information in the authentication
-// response.
+// Persona should be set in the Auth response returned by the credential
+// backends. Only the Name needs to be filled out; core sets the mount type
+// and accessor of the backend the login came through.
 type Persona struct {
 	// MountType is the backend mount's type to which this identity belongs
 	// to.
@@ -24,3 +21,15 @@ type Persona struct {
 	// authentication source.
 	Name string `json:"name" structs:"name" mapstructure:"name"`
 }
+
+// GroupPersonas returns the personas of the groups with the given names, for
+// use as the GroupPersonas of an Auth response
+func GroupPersonas(groupNames []string) []*Persona {
+	personas := make([]*Persona, 0, len(groupNames))
+	for _, name := range groupNames {
+		personas = append(personas, &Persona{
+			Name: name,
+		})
+	}
+	return personas
+}
@@ -19,6 +19,11 @@ import (
 func init() {
 	gob.Register(rsa.PublicKey{})
 	gob.Register(ecdsa.PublicKey{})
+
+	// Generic JSON data, such as the OpenAPI document of help responses,
+	// is passed in responses
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
 }
 
 // BackendPluginClient is a wrapper around backendPluginClient
@@ -181,6 +181,11 @@ const (
 	ListOperation             = "list"
 	HelpOperation             = "help"
 
+	// PatchOperation updates some of the fields of an existing resource,
+	// following JSON merge patch semantics: fields not in the request are
+	// left unchanged, and fields set to null are reset.
+	PatchOperation = "patch"
+
 	// The operations below are called globally, the path is less relevant.
 	RevokeOperation   Operation = "revoke"
 	RenewOperation              = "renew"
@@ -1,6 +1,7 @@
 package logical
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/errutil"
 )
 
 // RespondErrorCommon pulls most of the functionality from http's
@@ -72,6 +74,8 @@ func RespondErrorCommon(req *Request, resp *Response, err error) (int, error) {
 		switch {
 		case errwrap.ContainsType(err, new(StatusBadRequest)):
 			statusCode = http.StatusBadRequest
+		case errwrap.ContainsType(err, errutil.UserError{}):
+			statusCode = http.StatusBadRequest
 		case errwrap.Contains(err, ErrPermissionDenied.Error()):
 			statusCode = http.StatusForbidden
 		case errwrap.Contains(err, ErrUnsupportedOperation.Error()):
@@ -109,3 +113,29 @@ func AdjustErrorStatusCode(status *int, err error) {
 		*status = t.Code()
 	}
 }
+
+// RespondWithStatusCode turns resp into a raw JSON response that is sent
+// with the given HTTP status code instead of the default one.
+func RespondWithStatusCode(resp *Response, req *Request, code int) (*Response, error) {
+	ret := &Response{
+		Data: map[string]interface{}{
+			HTTPContentType: "application/json",
+			HTTPStatusCode:  code,
+		},
+	}
+
+	if resp != nil {
+		httpResp := LogicalResponseToHTTPResponse(resp)
+		if req != nil {
+			httpResp.RequestID = req.ID
+		}
+
+		body, err := json.Marshal(httpResp)
+		if err != nil {
+			return nil, err
+		}
+		ret.Data[HTTPRawBody] = body
+	}
+
+	return ret, nil
+}
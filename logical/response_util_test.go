@@ -0,0 +1,64 @@
+package logical
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/errutil"
+)
+
+func TestRespondErrorCommon(t *testing.T) {
+	cases := map[string]struct {
+		Resp  *Response
+		Err   error
+		Code  int
+		Error string
+	}{
+		"internal error": {
+			nil,
+			errutil.InternalError{Err: "boom"},
+			http.StatusInternalServerError,
+			"boom",
+		},
+		"user error": {
+			nil,
+			errutil.UserError{Err: "bad input"},
+			http.StatusBadRequest,
+			"bad input",
+		},
+		"wrapped user error": {
+			nil,
+			multierror.Append(errors.New("request failed"), errutil.UserError{Err: "bad input"}),
+			http.StatusBadRequest,
+			"",
+		},
+		"bad request": {
+			nil,
+			&StatusBadRequest{Err: "bad input"},
+			http.StatusBadRequest,
+			"bad input",
+		},
+		"error response": {
+			ErrorResponse("bad input"),
+			ErrInvalidRequest,
+			http.StatusBadRequest,
+			"bad input",
+		},
+	}
+
+	for name, tc := range cases {
+		req := &Request{Operation: UpdateOperation}
+		code, err := RespondErrorCommon(req, tc.Resp, tc.Err)
+		if code != tc.Code {
+			t.Fatalf("%s: bad code: %d", name, code)
+		}
+		if err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+		if tc.Error != "" && err.Error() != tc.Error {
+			t.Fatalf("%s: bad error: %s", name, err)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/token"
 	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
 )
 
 // FlagSetFlags is an enum to define what flags are present in the
@@ -172,6 +173,20 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 	return f
 }
 
+// GeneralFlagsAutocomplete returns the autocompletion of the flags that
+// FlagSetServer adds to a command.
+func GeneralFlagsAutocomplete() complete.Flags {
+	return complete.Flags{
+		"-address":         complete.PredictAnything,
+		"-ca-cert":         complete.PredictFiles("*"),
+		"-ca-path":         complete.PredictDirs("*"),
+		"-client-cert":     complete.PredictFiles("*"),
+		"-client-key":      complete.PredictFiles("*"),
+		"-tls-skip-verify": complete.PredictNothing,
+		"-wrap-ttl":        complete.PredictAnything,
+	}
+}
+
 // GeneralOptionsUsage returns the usage documentation for commonly
 // available options
 func GeneralOptionsUsage() string {
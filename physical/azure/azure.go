@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"sort"
 	"strconv"
@@ -54,24 +56,23 @@ func NewAzureBackend(conf map[string]string, logger log.Logger) (physical.Backen
 	accountKey := os.Getenv("AZURE_ACCOUNT_KEY")
 	if accountKey == "" {
 		accountKey = conf["accountKey"]
-		if accountKey == "" {
-			return nil, fmt.Errorf("'accountKey' must be set")
-		}
 	}
 
-	client, err := storage.NewBasicClient(accountName, accountKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Azure client: %v", err)
+	sasToken := os.Getenv("AZURE_SAS_TOKEN")
+	if sasToken == "" {
+		sasToken = conf["sasToken"]
 	}
-	client.HTTPClient = cleanhttp.DefaultPooledClient()
 
-	blobClient := client.GetBlobService()
-	container := blobClient.GetContainerReference(name)
-	_, err = container.CreateIfNotExists(&storage.CreateContainerOptions{
-		Access: storage.ContainerAccessTypePrivate,
-	})
+	switch {
+	case accountKey != "" && sasToken != "":
+		return nil, fmt.Errorf("only one of 'accountKey' and 'sasToken' may be set")
+	case accountKey == "" && sasToken == "":
+		return nil, fmt.Errorf("'accountKey' or 'sasToken' must be set")
+	}
+
+	container, err := newAzureContainer(name, accountName, accountKey, sasToken, cleanhttp.DefaultPooledClient())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create %q container: %v", name, err)
+		return nil, err
 	}
 
 	maxParStr, ok := conf["max_parallel"]
@@ -94,6 +95,71 @@ func NewAzureBackend(conf map[string]string, logger log.Logger) (physical.Backen
 	return a, nil
 }
 
+// newAzureContainer returns a reference to the named container, authenticating
+// with either the account key or a SAS token. With an account key the
+// container is created if it does not exist; a SAS token is usually scoped
+// to an existing container, so it must already exist.
+func newAzureContainer(name, accountName, accountKey, sasToken string, httpClient *http.Client) (*storage.Container, error) {
+	var sasValues url.Values
+	if sasToken != "" {
+		var err error
+		sasValues, err = url.ParseQuery(strings.TrimPrefix(sasToken, "?"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SAS token: %v", err)
+		}
+
+		// The client requires an account key even though requests will be
+		// authorized by the SAS token instead
+		accountKey = base64.StdEncoding.EncodeToString([]byte("sas"))
+	}
+
+	client, err := storage.NewBasicClient(accountName, accountKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure client: %v", err)
+	}
+	client.HTTPClient = httpClient
+	if sasValues != nil {
+		client.Sender = &sasSender{
+			sasValues: sasValues,
+			sender:    client.Sender,
+		}
+	}
+
+	blobClient := client.GetBlobService()
+	container := blobClient.GetContainerReference(name)
+	if sasValues != nil {
+		return container, nil
+	}
+
+	_, err = container.CreateIfNotExists(&storage.CreateContainerOptions{
+		Access: storage.ContainerAccessTypePrivate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %q container: %v", name, err)
+	}
+
+	return container, nil
+}
+
+// sasSender authorizes requests with a shared access signature in place of
+// the shared key signature added by the storage client.
+type sasSender struct {
+	sasValues url.Values
+	sender    storage.Sender
+}
+
+func (s *sasSender) Send(c *storage.Client, req *http.Request) (*http.Response, error) {
+	req.Header.Del("Authorization")
+
+	query := req.URL.Query()
+	for k, v := range s.sasValues {
+		query[k] = v
+	}
+	req.URL.RawQuery = query.Encode()
+
+	return s.sender.Send(c, req)
+}
+
 // Put is used to insert or update an entry
 func (a *AzureBackend) Put(entry *physical.Entry) error {
 	defer metrics.MeasureSince([]string{"azure", "put"}, time.Now())
@@ -175,23 +241,30 @@ func (a *AzureBackend) Delete(key string) error {
 func (a *AzureBackend) List(prefix string) ([]string, error) {
 	defer metrics.MeasureSince([]string{"azure", "list"}, time.Now())
 
-	a.permitPool.Acquire()
-	list, err := a.container.ListBlobs(storage.ListBlobsParameters{Prefix: prefix})
-	if err != nil {
-		// Break early.
+	keys := []string{}
+	params := storage.ListBlobsParameters{Prefix: prefix}
+	for {
+		a.permitPool.Acquire()
+		list, err := a.container.ListBlobs(params)
 		a.permitPool.Release()
-		return nil, err
-	}
-	a.permitPool.Release()
+		if err != nil {
+			return nil, err
+		}
 
-	keys := []string{}
-	for _, blob := range list.Blobs {
-		key := strings.TrimPrefix(blob.Name, prefix)
-		if i := strings.Index(key, "/"); i == -1 {
-			keys = append(keys, key)
-		} else {
-			keys = strutil.AppendIfMissing(keys, key[:i+1])
+		for _, blob := range list.Blobs {
+			key := strings.TrimPrefix(blob.Name, prefix)
+			if i := strings.Index(key, "/"); i == -1 {
+				keys = append(keys, key)
+			} else {
+				keys = strutil.AppendIfMissing(keys, key[:i+1])
+			}
+		}
+
+		// Results are returned in pages; continue until there are no more
+		if list.NextMarker == "" {
+			break
 		}
+		params.Marker = list.NextMarker
 	}
 
 	sort.Strings(keys)
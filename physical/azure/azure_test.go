@@ -2,7 +2,11 @@ package azure
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -50,3 +54,79 @@ func TestAzureBackend(t *testing.T) {
 	physical.ExerciseBackend(t, backend)
 	physical.ExerciseBackend_ListPrefix(t, backend)
 }
+
+// redirectTransport sends all requests to a test server
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestAzureBackend_SASList(t *testing.T) {
+	pages := map[string]string{
+		"":      `<EnumerationResults><Blobs><Blob><Name>foo/a</Name></Blob><Blob><Name>foo/b/c</Name></Blob></Blobs><NextMarker>page2</NextMarker></EnumerationResults>`,
+		"page2": `<EnumerationResults><Blobs><Blob><Name>foo/b/d</Name></Blob><Blob><Name>foo/e</Name></Blob></Blobs><NextMarker></NextMarker></EnumerationResults>`,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected shared key authorization")
+		}
+		query := r.URL.Query()
+		if query.Get("sig") != "secret" || query.Get("sv") != "2016-05-31" {
+			t.Errorf("missing SAS token: %s", r.URL.RawQuery)
+		}
+		if query.Get("comp") != "list" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		page, ok := pages[query.Get("marker")]
+		if !ok {
+			http.Error(w, "unknown marker", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	container, err := newAzureContainer("vault", "vaulttest", "", "?sv=2016-05-31&sig=secret", &http.Client{
+		Transport: &redirectTransport{target: target},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &AzureBackend{
+		container:  container,
+		logger:     logformat.NewVaultLogger(log.LevelTrace),
+		permitPool: physical.NewPermitPool(0),
+	}
+
+	keys, err := b.List("foo/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a", "b/", "e"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("bad keys: expected %v, got %v", expected, keys)
+	}
+}
+
+func TestAzureBackend_credentials(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	for _, conf := range []map[string]string{
+		{"container": "vault", "accountName": "vaulttest"},
+		{"container": "vault", "accountName": "vaulttest", "accountKey": "a2V5", "sasToken": "sig=secret"},
+	} {
+		if _, err := NewAzureBackend(conf, logger); err == nil {
+			t.Fatalf("expected error for config %#v", conf)
+		}
+	}
+}
@@ -127,6 +127,12 @@ func NewCassandraBackend(conf map[string]string, logger log.Logger) (physical.Ba
 		cluster.Timeout = time.Duration(connectionTimeout) * time.Second
 	}
 
+	// Only connect to hosts in the local datacenter, so that LOCAL_*
+	// consistency levels are relative to it in multi-datacenter clusters
+	if dc, ok := conf["local_datacenter"]; ok && dc != "" {
+		cluster.HostFilter = gocql.DataCentreHostFilter(dc)
+	}
+
 	if err := setupCassandraTLS(conf, cluster); err != nil {
 		return nil, err
 	}
@@ -250,8 +256,10 @@ func (c *CassandraBackend) Put(entry *physical.Entry) error {
 
 	// Execute inserts to each key prefix simultaneously
 	stmt := fmt.Sprintf(`INSERT INTO "%s" (bucket, key, value) VALUES (?, ?, ?)`, c.table)
-	results := make(chan error)
 	buckets := c.buckets(entry.Key)
+	// Buffer all results so that no goroutine is left blocked if we return
+	// early on an error
+	results := make(chan error, len(buckets))
 	for _, _bucket := range buckets {
 		go func(bucket string) {
 			results <- c.sess.Query(stmt, bucket, entry.Key, entry.Value).Exec()
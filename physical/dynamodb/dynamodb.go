@@ -151,6 +151,30 @@ func NewDynamoDBBackend(conf map[string]string, logger log.Logger) (physical.Bac
 		writeCapacity = DefaultDynamoDBWriteCapacity
 	}
 
+	haEnabled := os.Getenv("DYNAMODB_HA_ENABLED")
+	if haEnabled == "" {
+		haEnabled = conf["ha_enabled"]
+	}
+	if haEnabled == "" {
+		haEnabled = "false"
+	}
+	haEnabledBool, err := strconv.ParseBool(haEnabled)
+	if err != nil {
+		return nil, fmt.Errorf("value [%v] of 'ha_enabled' could not be understood", haEnabled)
+	}
+
+	recoveryMode := os.Getenv("RECOVERY_MODE")
+	if recoveryMode == "" {
+		recoveryMode = conf["recovery_mode"]
+	}
+	if recoveryMode == "" {
+		recoveryMode = "false"
+	}
+	recoveryModeBool, err := strconv.ParseBool(recoveryMode)
+	if err != nil {
+		return nil, fmt.Errorf("value [%v] of 'recovery_mode' could not be understood", recoveryMode)
+	}
+
 	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
 	if accessKey == "" {
 		accessKey = conf["access_key"]
@@ -205,18 +229,6 @@ func NewDynamoDBBackend(conf map[string]string, logger log.Logger) (physical.Bac
 		return nil, err
 	}
 
-	haEnabled := os.Getenv("DYNAMODB_HA_ENABLED")
-	if haEnabled == "" {
-		haEnabled = conf["ha_enabled"]
-	}
-	haEnabledBool, _ := strconv.ParseBool(haEnabled)
-
-	recoveryMode := os.Getenv("RECOVERY_MODE")
-	if recoveryMode == "" {
-		recoveryMode = conf["recovery_mode"]
-	}
-	recoveryModeBool, _ := strconv.ParseBool(recoveryMode)
-
 	maxParStr, ok := conf["max_parallel"]
 	var maxParInt int
 	if ok {
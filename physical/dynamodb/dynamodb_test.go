@@ -222,6 +222,22 @@ func testDynamoDBLockTTL(t *testing.T, ha physical.HABackend) {
 	lock2.Unlock()
 }
 
+func TestDynamoDBBackend_invalidConfig(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	for _, conf := range []map[string]string{
+		{"read_capacity": "many"},
+		{"write_capacity": "many"},
+		{"ha_enabled": "yes please"},
+		{"recovery_mode": "maybe"},
+	} {
+		// These are rejected before any request is made to DynamoDB
+		if _, err := NewDynamoDBBackend(conf, logger); err == nil {
+			t.Fatalf("expected error for config %#v", conf)
+		}
+	}
+}
+
 func prepareDynamoDBTestContainer(t *testing.T) (cleanup func(), retAddress string, creds *credentials.Credentials) {
 	// If environment variable is set, assume caller wants to target a real
 	// DynamoDB.
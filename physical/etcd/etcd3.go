@@ -14,6 +14,7 @@ import (
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
 	"github.com/coreos/etcd/pkg/transport"
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/physical"
 	log "github.com/mgutz/logxi/v1"
@@ -78,6 +79,9 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 	cert, hasCert := conf["tls_cert_file"]
 	key, hasKey := conf["tls_key_file"]
 	ca, hasCa := conf["tls_ca_file"]
+	if hasCert != hasKey {
+		return nil, fmt.Errorf("both 'tls_cert_file' and 'tls_key_file' must be set for TLS client authentication")
+	}
 	if (hasCert && hasKey) || hasCa {
 		tls := transport.TLSInfo{
 			CAFile:   ca,
@@ -108,6 +112,18 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 		cfg.Password = password
 	}
 
+	maxParStr, ok := conf["max_parallel"]
+	var maxParInt int
+	if ok {
+		maxParInt, err = strconv.Atoi(maxParStr)
+		if err != nil {
+			return nil, errwrap.Wrapf("failed parsing max_parallel parameter: {{err}}", err)
+		}
+		if logger.IsDebug() {
+			logger.Debug("physical/etcd: max_parallel set", "max_parallel", maxParInt)
+		}
+	}
+
 	etcd, err := clientv3.New(cfg)
 	if err != nil {
 		return nil, err
@@ -134,7 +150,7 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 	return &EtcdBackend{
 		path:       path,
 		etcd:       etcd,
-		permitPool: physical.NewPermitPool(physical.DefaultParallelOperations),
+		permitPool: physical.NewPermitPool(maxParInt),
 		logger:     logger,
 		haEnabled:  haEnabledBool,
 	}, nil
@@ -36,3 +36,18 @@ func TestEtcd3Backend(t *testing.T) {
 	}
 	physical.ExerciseHABackend(t, ha, ha)
 }
+
+func TestEtcd3Backend_invalidConfig(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	for _, conf := range []map[string]string{
+		{"address": "http://127.0.0.1:2379", "tls_cert_file": "cert.pem"},
+		{"address": "http://127.0.0.1:2379", "tls_key_file": "key.pem"},
+		{"address": "http://127.0.0.1:2379", "max_parallel": "lots"},
+	} {
+		// These are rejected before a client is created
+		if _, err := newEtcd3Backend(conf, logger); err == nil {
+			t.Fatalf("expected error for config %#v", conf)
+		}
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/armon/go-metrics"
 	log "github.com/mgutz/logxi/v1"
 
 	"github.com/hashicorp/vault/helper/consts"
@@ -65,6 +67,8 @@ func NewTransactionalFileBackend(conf map[string]string, logger log.Logger) (phy
 }
 
 func (b *FileBackend) Delete(path string) error {
+	defer metrics.MeasureSince([]string{"file", "delete"}, time.Now())
+
 	b.permitPool.Acquire()
 	defer b.permitPool.Release()
 
@@ -134,6 +138,8 @@ func (b *FileBackend) cleanupLogicalPath(path string) error {
 }
 
 func (b *FileBackend) Get(k string) (*physical.Entry, error) {
+	defer metrics.MeasureSince([]string{"file", "get"}, time.Now())
+
 	b.permitPool.Acquire()
 	defer b.permitPool.Release()
 
@@ -172,6 +178,8 @@ func (b *FileBackend) GetInternal(k string) (*physical.Entry, error) {
 }
 
 func (b *FileBackend) Put(entry *physical.Entry) error {
+	defer metrics.MeasureSince([]string{"file", "put"}, time.Now())
+
 	b.permitPool.Acquire()
 	defer b.permitPool.Release()
 
@@ -209,6 +217,8 @@ func (b *FileBackend) PutInternal(entry *physical.Entry) error {
 }
 
 func (b *FileBackend) List(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"file", "list"}, time.Now())
+
 	b.permitPool.Acquire()
 	defer b.permitPool.Release()
 
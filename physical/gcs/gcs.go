@@ -42,19 +42,18 @@ func NewGCSBackend(conf map[string]string, logger log.Logger) (physical.Backend,
 		}
 	}
 
-	// path to service account JSON file
+	// path to service account JSON file; if unset, application default
+	// credentials such as the GCE metadata service are used
+	var opts []option.ClientOption
 	credentialsFile := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
 	if credentialsFile == "" {
 		credentialsFile = conf["credentials_file"]
-		if credentialsFile == "" {
-			return nil, fmt.Errorf("env var GOOGLE_APPLICATION_CREDENTIALS or configuration parameter 'credentials_file' must be set")
-		}
+	}
+	if credentialsFile != "" {
+		opts = append(opts, option.WithServiceAccountFile(credentialsFile))
 	}
 
-	client, err := storage.NewClient(
-		context.Background(),
-		option.WithServiceAccountFile(credentialsFile),
-	)
+	client, err := storage.NewClient(context.Background(), opts...)
 
 	if err != nil {
 		return nil, fmt.Errorf("error establishing storage client: '%v'", err)
@@ -98,10 +97,18 @@ func (g *GCSBackend) Put(entry *physical.Entry) error {
 	g.permitPool.Acquire()
 	defer g.permitPool.Release()
 
-	defer writer.Close()
-	_, err := writer.Write(entry.Value)
+	if _, err := writer.Write(entry.Value); err != nil {
+		writer.Close()
+		return fmt.Errorf("error writing object '%v': '%v'", entry.Key, err)
+	}
 
-	return err
+	// The object is only committed once the writer is closed, so upload
+	// failures are reported here
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error writing object '%v': '%v'", entry.Key, err)
+	}
+
+	return nil
 }
 
 // Get is used to fetch an entry
@@ -3,6 +3,8 @@ package gcs
 import (
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -86,3 +88,31 @@ func TestGCSBackend(t *testing.T) {
 	physical.ExerciseBackend_ListPrefix(t, b)
 
 }
+
+func TestGCSBackend_putError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	client, err := storage.NewClient(
+		context.Background(),
+		option.WithEndpoint(srv.URL),
+		option.WithHTTPClient(http.DefaultClient),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &GCSBackend{
+		bucketName: "vault-test",
+		client:     client,
+		permitPool: physical.NewPermitPool(0),
+		logger:     logformat.NewVaultLogger(log.LevelTrace),
+	}
+
+	err = b.Put(&physical.Entry{Key: "foo", Value: []byte("bar")})
+	if err == nil {
+		t.Fatal("expected error from failed upload")
+	}
+}
@@ -3,10 +3,12 @@ package inmem
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/physical"
 	log "github.com/mgutz/logxi/v1"
 
+	"github.com/armon/go-metrics"
 	"github.com/armon/go-radix"
 )
 
@@ -49,6 +51,8 @@ func NewTransactionalInmem(_ map[string]string, logger log.Logger) (physical.Bac
 
 // Put is used to insert or update an entry
 func (i *InmemBackend) Put(entry *physical.Entry) error {
+	defer metrics.MeasureSince([]string{"inmem", "put"}, time.Now())
+
 	i.permitPool.Acquire()
 	defer i.permitPool.Release()
 
@@ -65,6 +69,8 @@ func (i *InmemBackend) PutInternal(entry *physical.Entry) error {
 
 // Get is used to fetch an entry
 func (i *InmemBackend) Get(key string) (*physical.Entry, error) {
+	defer metrics.MeasureSince([]string{"inmem", "get"}, time.Now())
+
 	i.permitPool.Acquire()
 	defer i.permitPool.Release()
 
@@ -83,6 +89,8 @@ func (i *InmemBackend) GetInternal(key string) (*physical.Entry, error) {
 
 // Delete is used to permanently delete an entry
 func (i *InmemBackend) Delete(key string) error {
+	defer metrics.MeasureSince([]string{"inmem", "delete"}, time.Now())
+
 	i.permitPool.Acquire()
 	defer i.permitPool.Release()
 
@@ -100,6 +108,8 @@ func (i *InmemBackend) DeleteInternal(key string) error {
 // List is used ot list all the keys under a given
 // prefix, up to the next prefix.
 func (i *InmemBackend) List(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"inmem", "list"}, time.Now())
+
 	i.permitPool.Acquire()
 	defer i.permitPool.Release()
 
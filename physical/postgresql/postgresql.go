@@ -3,18 +3,31 @@ package postgresql
 import (
 	"database/sql"
 	"fmt"
+	"hash/fnv"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/physical"
 	log "github.com/mgutz/logxi/v1"
+	"golang.org/x/net/context"
 
 	"github.com/armon/go-metrics"
 	"github.com/lib/pq"
 )
 
+const (
+	// PostgreSQLLockRetryInterval is the amount of time to wait
+	// if a lock fails before trying again.
+	PostgreSQLLockRetryInterval = time.Second
+
+	// PostgreSQLLockCheckInterval is the amount of time to wait between
+	// checks that the connection holding a lock is still alive.
+	PostgreSQLLockCheckInterval = 5 * time.Second
+)
+
 // PostgreSQL Backend is a physical backend that stores data
 // within a PostgreSQL database.
 type PostgreSQLBackend struct {
@@ -26,6 +39,22 @@ type PostgreSQLBackend struct {
 	list_query   string
 	logger       log.Logger
 	permitPool   *physical.PermitPool
+	haEnabled    bool
+}
+
+// PostgreSQLLock implements a lock using a PostgreSQL session-level
+// advisory lock. The lock is held for as long as the dedicated connection
+// that acquired it stays open; the lock value is stored under the lock key.
+type PostgreSQLLock struct {
+	backend *PostgreSQLBackend
+	key     string
+	value   string
+	lockID  int64
+
+	lock   sync.Mutex
+	held   bool
+	conn   *sql.Conn
+	stopCh chan struct{}
 }
 
 // NewPostgreSQLBackend constructs a PostgreSQL backend using the given
@@ -58,12 +87,27 @@ func NewPostgreSQLBackend(conf map[string]string, logger log.Logger) (physical.B
 		maxParInt = physical.DefaultParallelOperations
 	}
 
+	haEnabled, ok := conf["ha_enabled"]
+	if !ok || haEnabled == "" {
+		haEnabled = "false"
+	}
+	haEnabledBool, err := strconv.ParseBool(haEnabled)
+	if err != nil {
+		return nil, fmt.Errorf("value [%v] of 'ha_enabled' could not be understood", haEnabled)
+	}
+
 	// Create PostgreSQL handle for the database.
 	db, err := sql.Open("postgres", connURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to postgres: %v", err)
 	}
-	db.SetMaxOpenConns(maxParInt)
+	if haEnabledBool {
+		// The HA lock holds a dedicated connection, so reserve one for it to
+		// avoid starving regular requests
+		db.SetMaxOpenConns(maxParInt + 1)
+	} else {
+		db.SetMaxOpenConns(maxParInt)
+	}
 
 	// Determine if we should use an upsert function (versions < 9.5)
 	var upsert_required bool
@@ -91,10 +135,11 @@ func NewPostgreSQLBackend(conf map[string]string, logger log.Logger) (physical.B
 		get_query:    "SELECT value FROM " + quoted_table + " WHERE path = $1 AND key = $2",
 		delete_query: "DELETE FROM " + quoted_table + " WHERE path = $1 AND key = $2",
 		list_query: "SELECT key FROM " + quoted_table + " WHERE path = $1" +
-			"UNION SELECT DISTINCT substring(substr(path, length($1)+1) from '^.*?/') FROM " +
+			" UNION SELECT DISTINCT substring(substr(path, length($1)+1) from '^.*?/') FROM " +
 			quoted_table + " WHERE parent_path LIKE $1 || '%'",
 		logger:     logger,
 		permitPool: physical.NewPermitPool(maxParInt),
+		haEnabled:  haEnabledBool,
 	}
 
 	return m, nil
@@ -159,7 +204,7 @@ func (m *PostgreSQLBackend) Get(fullPath string) (*physical.Entry, error) {
 	}
 
 	ent := &physical.Entry{
-		Key:   key,
+		Key:   fullPath,
 		Value: result,
 	}
 	return ent, nil
@@ -205,6 +250,151 @@ func (m *PostgreSQLBackend) List(prefix string) ([]string, error) {
 
 		keys = append(keys, key)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %v", err)
+	}
 
 	return keys, nil
 }
+
+// LockWith is used for mutual exclusion based on the given key.
+func (m *PostgreSQLBackend) LockWith(key, value string) (physical.Lock, error) {
+	// Advisory locks are identified by a 64-bit integer, so derive one from
+	// the table and key to keep locks of different Vault clusters apart
+	h := fnv.New64a()
+	h.Write([]byte(m.table + "/" + key))
+
+	return &PostgreSQLLock{
+		backend: m,
+		key:     key,
+		value:   value,
+		lockID:  int64(h.Sum64()),
+	}, nil
+}
+
+func (m *PostgreSQLBackend) HAEnabled() bool {
+	return m.haEnabled
+}
+
+// Lock tries to acquire the advisory lock on a dedicated connection. It will
+// block until either the stop channel is closed or the lock is acquired.
+// The returned channel is closed if the connection holding the lock fails.
+func (l *PostgreSQLLock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.held {
+		return nil, fmt.Errorf("lock already held")
+	}
+
+	conn, err := l.backend.client.Conn(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for lock: %v", err)
+	}
+
+	for {
+		var acquired bool
+		err := conn.QueryRowContext(context.Background(), "SELECT pg_try_advisory_lock($1)", l.lockID).Scan(&acquired)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to acquire lock: %v", err)
+		}
+		if acquired {
+			break
+		}
+
+		select {
+		case <-stopCh:
+			conn.Close()
+			return nil, nil
+		case <-time.After(PostgreSQLLockRetryInterval):
+		}
+	}
+
+	if err := l.backend.Put(&physical.Entry{
+		Key:   l.key,
+		Value: []byte(l.value),
+	}); err != nil {
+		// Closing the session releases the advisory lock
+		conn.Close()
+		return nil, fmt.Errorf("failed to write lock value: %v", err)
+	}
+
+	l.held = true
+	l.conn = conn
+	l.stopCh = make(chan struct{})
+
+	leaderCh := make(chan struct{})
+	go l.monitor(conn, l.stopCh, leaderCh)
+
+	return leaderCh, nil
+}
+
+// monitor closes the leader channel once the connection holding the lock
+// stops responding, since the advisory lock is released along with it.
+func (l *PostgreSQLLock) monitor(conn *sql.Conn, stopCh, leaderCh chan struct{}) {
+	defer close(leaderCh)
+
+	ticker := time.NewTicker(PostgreSQLLockCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			if err := conn.PingContext(context.Background()); err != nil {
+				l.backend.logger.Error("postgres: lost connection holding lock", "key", l.key, "error", err)
+				return
+			}
+		}
+	}
+}
+
+// Unlock releases the lock and closes its dedicated connection.
+func (l *PostgreSQLLock) Unlock() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if !l.held {
+		return nil
+	}
+
+	l.held = false
+	close(l.stopCh)
+	defer l.conn.Close()
+
+	if err := l.backend.Delete(l.key); err != nil {
+		return err
+	}
+
+	_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.lockID)
+	return err
+}
+
+// Value checks whether or not the lock is held by any session, including
+// this one, and returns the current value.
+func (l *PostgreSQLLock) Value() (bool, string, error) {
+	// A bigint advisory lock is reported in pg_locks with the high and low
+	// 32 bits of its ID in classid and objid respectively
+	var held bool
+	err := l.backend.client.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND granted"+
+			" AND database = (SELECT oid FROM pg_database WHERE datname = current_database())"+
+			" AND classid::bigint = $1 AND objid::bigint = $2 AND objsubid = 1)",
+		int64(uint64(l.lockID)>>32), int64(uint32(l.lockID))).Scan(&held)
+	if err != nil {
+		return false, "", err
+	}
+	if !held {
+		return false, "", nil
+	}
+
+	entry, err := l.backend.Get(l.key)
+	if err != nil {
+		return false, "", err
+	}
+	if entry == nil {
+		return true, "", nil
+	}
+
+	return true, string(entry.Value), nil
+}
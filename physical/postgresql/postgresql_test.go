@@ -44,3 +44,48 @@ func TestPostgreSQLBackend(t *testing.T) {
 	physical.ExerciseBackend(t, b)
 	physical.ExerciseBackend_ListPrefix(t, b)
 }
+
+func TestPostgreSQLBackend_HA(t *testing.T) {
+	connURL := os.Getenv("PGURL")
+	if connURL == "" {
+		t.SkipNow()
+	}
+
+	table := os.Getenv("PGTABLE")
+	if table == "" {
+		table = "vault_kv_store"
+	}
+
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	conf := map[string]string{
+		"connection_url": connURL,
+		"table":          table,
+		"ha_enabled":     "true",
+	}
+	b, err := NewPostgreSQLBackend(conf, logger)
+	if err != nil {
+		t.Fatalf("Failed to create new backend: %v", err)
+	}
+	b2, err := NewPostgreSQLBackend(conf, logger)
+	if err != nil {
+		t.Fatalf("Failed to create new backend: %v", err)
+	}
+
+	defer func() {
+		pg := b.(*PostgreSQLBackend)
+		_, err := pg.client.Exec("TRUNCATE TABLE " + pg.table)
+		if err != nil {
+			t.Fatalf("Failed to drop table: %v", err)
+		}
+	}()
+
+	ha, ok := b.(physical.HABackend)
+	if !ok {
+		t.Fatalf("PostgreSQL does not implement HABackend")
+	}
+	if !ha.HAEnabled() {
+		t.Fatalf("expected HA to be enabled")
+	}
+	physical.ExerciseHABackend(t, ha, b2.(physical.HABackend))
+}
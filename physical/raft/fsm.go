@@ -0,0 +1,269 @@
+package raft
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/physical"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// dataBucket holds the entries of the backend and lockBucket the values
+	// of the HA locks
+	dataBucket = []byte("data")
+	lockBucket = []byte("locks")
+)
+
+const (
+	putOp    = "put"
+	deleteOp = "delete"
+	lockOp   = "lock"
+)
+
+// command is the data of an entry of the Raft log. Its operations are
+// applied atomically.
+type command struct {
+	Ops []*operation `json:"ops"`
+}
+
+// operation sets or deletes an entry, or sets the value of a lock; an empty
+// lock value releases the lock
+type operation struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+// fsm applies the commands committed to the Raft log to a BoltDB database.
+// Reads are served from the database of the local node.
+type fsm struct {
+	path string
+
+	// l guards db, which is replaced when a snapshot is restored
+	l  sync.RWMutex
+	db *bolt.DB
+}
+
+var _ raft.FSM = (*fsm)(nil)
+
+func newFSM(path string) (*fsm, error) {
+	f := &fsm{
+		path: path,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *fsm) open() error {
+	db, err := bolt.Open(f.path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("error opening %q: %v", f.path, err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{dataBucket, lockBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return err
+	}
+
+	f.db = db
+	return nil
+}
+
+func (f *fsm) close() error {
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if f.db == nil {
+		return nil
+	}
+	err := f.db.Close()
+	f.db = nil
+	return err
+}
+
+// view runs fn in a read-only transaction
+func (f *fsm) view(fn func(*bolt.Tx) error) error {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	if f.db == nil {
+		return fmt.Errorf("raft storage is closed")
+	}
+	return f.db.View(fn)
+}
+
+func (f *fsm) get(key string) (*physical.Entry, error) {
+	var entry *physical.Entry
+	err := f.view(func(tx *bolt.Tx) error {
+		value := tx.Bucket(dataBucket).Get([]byte(key))
+		if value == nil {
+			return nil
+		}
+		entry = &physical.Entry{
+			Key:   key,
+			Value: append([]byte(nil), value...),
+		}
+		return nil
+	})
+	return entry, err
+}
+
+func (f *fsm) list(prefix string) ([]string, error) {
+	var keys []string
+	err := f.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(dataBucket).Cursor()
+		p := []byte(prefix)
+		for k, _ := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, _ = c.Next() {
+			key := string(k[len(p):])
+			if i := strings.Index(key, "/"); i != -1 {
+				// Add truncated 'folder' paths; keys are sorted, so the
+				// entries of a folder are adjacent
+				key = key[:i+1]
+				if n := len(keys); n > 0 && keys[n-1] == key {
+					continue
+				}
+			}
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (f *fsm) lockValue(key string) (string, error) {
+	var value string
+	err := f.view(func(tx *bolt.Tx) error {
+		value = string(tx.Bucket(lockBucket).Get([]byte(key)))
+		return nil
+	})
+	return value, err
+}
+
+// Apply implements raft.FSM. It returns an error if the command could not be
+// applied.
+func (f *fsm) Apply(log *raft.Log) interface{} {
+	var cmd command
+	if err := jsonutil.DecodeJSON(log.Data, &cmd); err != nil {
+		return fmt.Errorf("error decoding command: %v", err)
+	}
+
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	if f.db == nil {
+		return fmt.Errorf("raft storage is closed")
+	}
+	return f.db.Update(func(tx *bolt.Tx) error {
+		data := tx.Bucket(dataBucket)
+		locks := tx.Bucket(lockBucket)
+		for _, op := range cmd.Ops {
+			var err error
+			switch op.Op {
+			case putOp:
+				err = data.Put([]byte(op.Key), op.Value)
+			case deleteOp:
+				err = data.Delete([]byte(op.Key))
+			case lockOp:
+				if len(op.Value) == 0 {
+					err = locks.Delete([]byte(op.Key))
+				} else {
+					err = locks.Put([]byte(op.Key), op.Value)
+				}
+			default:
+				err = fmt.Errorf("unknown operation %q", op.Op)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Snapshot implements raft.FSM. The snapshot is a copy of the database as
+// of the last applied command, written by a read-only transaction that is
+// started here.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	if f.db == nil {
+		return nil, fmt.Errorf("raft storage is closed")
+	}
+	tx, err := f.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &fsmSnapshot{tx: tx}, nil
+}
+
+// Restore implements raft.FSM, replacing the database with the snapshot
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	restorePath := f.path + ".restore"
+	file, err := os.OpenFile(restorePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, rc)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(restorePath)
+		return fmt.Errorf("error writing snapshot: %v", err)
+	}
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	if f.db != nil {
+		if err := f.db.Close(); err != nil {
+			return err
+		}
+		f.db = nil
+	}
+	if err := os.Rename(restorePath, f.path); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+type fsmSnapshot struct {
+	tx *bolt.Tx
+}
+
+// Persist implements raft.FSMSnapshot
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := s.tx.WriteTo(sink); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+// Release implements raft.FSMSnapshot
+func (s *fsmSnapshot) Release() {
+	s.tx.Rollback()
+}
@@ -0,0 +1,170 @@
+package raft
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// logsBucket holds the Raft log, keyed by index, and stableBucket the
+	// Raft state that must survive restarts, such as the current term
+	logsBucket   = []byte("logs")
+	stableBucket = []byte("stable")
+
+	// errKeyNotFound is returned for missing stable keys; Raft relies on the
+	// message to tell a new node from a failure
+	errKeyNotFound = errors.New("not found")
+)
+
+// logStore keeps the Raft log and stable state in a BoltDB database
+type logStore struct {
+	db *bolt.DB
+}
+
+var _ raft.LogStore = (*logStore)(nil)
+var _ raft.StableStore = (*logStore)(nil)
+
+func newLogStore(path string) (*logStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening %q: %v", path, err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{logsBucket, stableBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &logStore{
+		db: db,
+	}, nil
+}
+
+func (s *logStore) Close() error {
+	return s.db.Close()
+}
+
+func uint64ToBytes(u uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, u)
+	return buf
+}
+
+// FirstIndex implements raft.LogStore
+func (s *logStore) FirstIndex() (uint64, error) {
+	var index uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(logsBucket).Cursor().First(); k != nil {
+			index = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return index, err
+}
+
+// LastIndex implements raft.LogStore
+func (s *logStore) LastIndex() (uint64, error) {
+	var index uint64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket(logsBucket).Cursor().Last(); k != nil {
+			index = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return index, err
+}
+
+// GetLog implements raft.LogStore
+func (s *logStore) GetLog(index uint64, log *raft.Log) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(logsBucket).Get(uint64ToBytes(index))
+		if value == nil {
+			return raft.ErrLogNotFound
+		}
+		return jsonutil.DecodeJSON(value, log)
+	})
+}
+
+// StoreLog implements raft.LogStore
+func (s *logStore) StoreLog(log *raft.Log) error {
+	return s.StoreLogs([]*raft.Log{log})
+}
+
+// StoreLogs implements raft.LogStore
+func (s *logStore) StoreLogs(logs []*raft.Log) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(logsBucket)
+		for _, log := range logs {
+			value, err := jsonutil.EncodeJSON(log)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(uint64ToBytes(log.Index), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteRange implements raft.LogStore, deleting the logs from min to max
+// inclusive
+func (s *logStore) DeleteRange(min, max uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(logsBucket).Cursor()
+		for k, _ := c.Seek(uint64ToBytes(min)); k != nil && binary.BigEndian.Uint64(k) <= max; k, _ = c.Next() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Set implements raft.StableStore
+func (s *logStore) Set(key []byte, value []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(stableBucket).Put(key, value)
+	})
+}
+
+// Get implements raft.StableStore
+func (s *logStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(stableBucket).Get(key)
+		if v == nil {
+			return errKeyNotFound
+		}
+		value = append([]byte(nil), v...)
+		return nil
+	})
+	return value, err
+}
+
+// SetUint64 implements raft.StableStore
+func (s *logStore) SetUint64(key []byte, value uint64) error {
+	return s.Set(key, uint64ToBytes(value))
+}
+
+// GetUint64 implements raft.StableStore
+func (s *logStore) GetUint64(key []byte) (uint64, error) {
+	value, err := s.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
@@ -0,0 +1,733 @@
+package raft
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	golog "log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	log "github.com/mgutz/logxi/v1"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/parseutil"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// DefaultAddress is the address Raft listens on if none is configured
+	DefaultAddress = "127.0.0.1:8202"
+
+	// applyTimeout bounds the time to wait for a command to be committed
+	applyTimeout = 10 * time.Second
+
+	// bootstrapTimeout bounds the time a newly bootstrapped node waits to be
+	// elected leader before starting
+	bootstrapTimeout = 30 * time.Second
+
+	// snapshotMetaFile and snapshotStateFile are the entries of the archive
+	// written by Snapshot
+	snapshotMetaFile  = "meta.json"
+	snapshotStateFile = "state.bin"
+)
+
+// Verify RaftBackend satisfies the correct interfaces
+var _ physical.Backend = (*RaftBackend)(nil)
+var _ physical.HABackend = (*RaftBackend)(nil)
+var _ physical.Transactional = (*RaftBackend)(nil)
+
+// RaftBackend is a physical backend that replicates its entries among the
+// Vault nodes of a cluster using the Raft consensus protocol, so that no
+// external storage is needed. Writes are only accepted by the Raft leader,
+// which is also the node holding the HA lock and thus the active node.
+type RaftBackend struct {
+	logger log.Logger
+	nodeID string
+
+	raft      *raft.Raft
+	fsm       *fsm
+	logStore  *logStore
+	snaps     raft.SnapshotStore
+	transport *raft.NetworkTransport
+
+	// l guards the fields below. changeCh is closed and replaced whenever
+	// the leadership of this node changes or a lock held by this node is
+	// released, waking up lock acquisitions.
+	l         sync.Mutex
+	changeCh  chan struct{}
+	heldLocks map[string]bool
+
+	shutdownCh chan struct{}
+}
+
+// Peer is a server of the Raft cluster
+type Peer struct {
+	NodeID  string `json:"node_id"`
+	Address string `json:"address"`
+	Leader  bool   `json:"leader"`
+	Voter   bool   `json:"voter"`
+}
+
+// NewRaftBackend constructs a RaftBackend storing its data in the configured
+// directory
+func NewRaftBackend(conf map[string]string, logger log.Logger) (physical.Backend, error) {
+	path, ok := conf["path"]
+	if !ok {
+		return nil, fmt.Errorf("'path' must be set")
+	}
+
+	nodeID := os.Getenv("VAULT_RAFT_NODE_ID")
+	if nodeID == "" {
+		nodeID = conf["node_id"]
+	}
+	if nodeID == "" {
+		return nil, fmt.Errorf("'node_id' must be set")
+	}
+
+	address := conf["address"]
+	if address == "" {
+		address = DefaultAddress
+	}
+
+	var advertise net.Addr
+	if advertiseAddress := conf["advertise_address"]; advertiseAddress != "" {
+		addr, err := net.ResolveTCPAddr("tcp", advertiseAddress)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'advertise_address': %v", err)
+		}
+		advertise = addr
+	}
+
+	var bootstrap bool
+	if bootstrapRaw, ok := conf["bootstrap"]; ok {
+		var err error
+		bootstrap, err = parseutil.ParseBool(bootstrapRaw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'bootstrap': %v", err)
+		}
+	}
+
+	var tlsDisable bool
+	if tlsDisableRaw, ok := conf["tls_disable"]; ok {
+		var err error
+		tlsDisable, err = parseutil.ParseBool(tlsDisableRaw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'tls_disable': %v", err)
+		}
+	}
+	var tlsConfig *tls.Config
+	if !tlsDisable {
+		var err error
+		tlsConfig, err = loadTLSConfig(conf["tls_cert_file"], conf["tls_key_file"], conf["tls_ca_file"])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+
+	b := &RaftBackend{
+		logger:     logger,
+		nodeID:     nodeID,
+		changeCh:   make(chan struct{}),
+		heldLocks:  make(map[string]bool),
+		shutdownCh: make(chan struct{}),
+	}
+	if err := b.setup(path, address, advertise, tlsConfig, bootstrap); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *RaftBackend) setup(path, address string, advertise net.Addr, tlsConfig *tls.Config, bootstrap bool) error {
+	logOutput := &logWriter{logger: b.logger}
+	hclogger := hclog.New(&hclog.LoggerOptions{
+		Name:   "raft",
+		Level:  hclog.Trace,
+		Output: logOutput,
+	})
+
+	var err error
+	b.fsm, err = newFSM(filepath.Join(path, "vault.db"))
+	if err != nil {
+		return err
+	}
+
+	b.logStore, err = newLogStore(filepath.Join(path, "raft.db"))
+	if err != nil {
+		return err
+	}
+	logs, err := raft.NewLogCache(512, b.logStore)
+	if err != nil {
+		return err
+	}
+
+	b.snaps, err = raft.NewFileSnapshotStore(path, 2, logOutput)
+	if err != nil {
+		return err
+	}
+
+	stream, err := newStreamLayer(address, advertise, tlsConfig)
+	if err != nil {
+		return err
+	}
+	b.transport = raft.NewNetworkTransportWithConfig(&raft.NetworkTransportConfig{
+		Stream:  stream,
+		MaxPool: 3,
+		Timeout: 10 * time.Second,
+		Logger:  golog.New(logOutput, "", 0),
+	})
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(b.nodeID)
+	config.Logger = hclogger
+	notifyCh := make(chan bool, 1)
+	config.NotifyCh = notifyCh
+
+	bootstrapped := false
+	if bootstrap {
+		hasState, err := raft.HasExistingState(logs, b.logStore, b.snaps)
+		if err != nil {
+			return err
+		}
+		if !hasState {
+			if err := raft.BootstrapCluster(config, logs, b.logStore, b.snaps, b.transport, raft.Configuration{
+				Servers: []raft.Server{
+					{
+						Suffrage: raft.Voter,
+						ID:       config.LocalID,
+						Address:  b.transport.LocalAddr(),
+					},
+				},
+			}); err != nil {
+				return fmt.Errorf("error bootstrapping cluster: %v", err)
+			}
+			bootstrapped = true
+		}
+	}
+
+	b.raft, err = raft.NewRaft(config, b.fsm, logs, b.logStore, b.snaps, b.transport)
+	if err != nil {
+		return err
+	}
+	go b.watchLeadership(notifyCh)
+
+	// Wait for the election so that a new cluster can be initialized straight
+	// away
+	if bootstrapped {
+		if err := b.waitForLeader(bootstrapTimeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close shuts down Raft and closes the stores
+func (b *RaftBackend) Close() error {
+	b.l.Lock()
+	select {
+	case <-b.shutdownCh:
+		b.l.Unlock()
+		return nil
+	default:
+		close(b.shutdownCh)
+	}
+	b.l.Unlock()
+
+	var err error
+	if b.raft != nil {
+		err = b.raft.Shutdown().Error()
+	} else if b.transport != nil {
+		err = b.transport.Close()
+	}
+	if b.logStore != nil {
+		if closeErr := b.logStore.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if b.fsm != nil {
+		if closeErr := b.fsm.close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// watchLeadership wakes up lock acquisitions when the leadership of this
+// node changes
+func (b *RaftBackend) watchLeadership(notifyCh <-chan bool) {
+	for {
+		select {
+		case isLeader := <-notifyCh:
+			if isLeader {
+				b.logger.Info("physical/raft: acquired leadership")
+			} else {
+				b.logger.Info("physical/raft: lost leadership")
+			}
+			b.notifyChange()
+		case <-b.shutdownCh:
+			return
+		}
+	}
+}
+
+func (b *RaftBackend) notifyChange() {
+	b.l.Lock()
+	close(b.changeCh)
+	b.changeCh = make(chan struct{})
+	b.l.Unlock()
+}
+
+// leadership returns whether this node is the leader, and a channel closed on
+// the next change
+func (b *RaftBackend) leadership() (bool, <-chan struct{}) {
+	b.l.Lock()
+	defer b.l.Unlock()
+	return b.raft.State() == raft.Leader, b.changeCh
+}
+
+func (b *RaftBackend) waitForLeader(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		isLeader, changeCh := b.leadership()
+		if isLeader {
+			return nil
+		}
+		select {
+		case <-changeCh:
+		case <-timer.C:
+			return fmt.Errorf("timed out waiting for leadership")
+		}
+	}
+}
+
+// apply commits the operations to the Raft log and waits for them to be
+// applied on this node
+func (b *RaftBackend) apply(ops ...*operation) error {
+	cmd, err := jsonutil.EncodeJSON(&command{Ops: ops})
+	if err != nil {
+		return err
+	}
+
+	f := b.raft.Apply(cmd, applyTimeout)
+	if err := f.Error(); err != nil {
+		return err
+	}
+	if err, ok := f.Response().(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Put is used to insert or update an entry
+func (b *RaftBackend) Put(entry *physical.Entry) error {
+	defer metrics.MeasureSince([]string{"raft_storage", "put"}, time.Now())
+
+	return b.apply(&operation{
+		Op:    putOp,
+		Key:   entry.Key,
+		Value: entry.Value,
+	})
+}
+
+// Get is used to fetch an entry
+func (b *RaftBackend) Get(key string) (*physical.Entry, error) {
+	defer metrics.MeasureSince([]string{"raft_storage", "get"}, time.Now())
+
+	return b.fsm.get(key)
+}
+
+// Delete is used to permanently delete an entry
+func (b *RaftBackend) Delete(key string) error {
+	defer metrics.MeasureSince([]string{"raft_storage", "delete"}, time.Now())
+
+	return b.apply(&operation{
+		Op:  deleteOp,
+		Key: key,
+	})
+}
+
+// List is used to list all the keys under a given prefix, up to the next
+// prefix
+func (b *RaftBackend) List(prefix string) ([]string, error) {
+	defer metrics.MeasureSince([]string{"raft_storage", "list"}, time.Now())
+
+	return b.fsm.list(prefix)
+}
+
+// Transaction applies the operations in a single Raft log entry, so that
+// either all or none of them are applied
+func (b *RaftBackend) Transaction(txns []physical.TxnEntry) error {
+	defer metrics.MeasureSince([]string{"raft_storage", "transaction"}, time.Now())
+
+	ops := make([]*operation, 0, len(txns))
+	for _, txn := range txns {
+		switch txn.Operation {
+		case physical.PutOperation:
+			ops = append(ops, &operation{
+				Op:    putOp,
+				Key:   txn.Entry.Key,
+				Value: txn.Entry.Value,
+			})
+		case physical.DeleteOperation:
+			ops = append(ops, &operation{
+				Op:  deleteOp,
+				Key: txn.Entry.Key,
+			})
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", txn.Operation)
+		}
+	}
+	return b.apply(ops...)
+}
+
+// Peers returns the servers of the Raft cluster
+func (b *RaftBackend) Peers() ([]*Peer, error) {
+	f := b.raft.GetConfiguration()
+	if err := f.Error(); err != nil {
+		return nil, err
+	}
+
+	leader := b.raft.Leader()
+	var peers []*Peer
+	for _, server := range f.Configuration().Servers {
+		peers = append(peers, &Peer{
+			NodeID:  string(server.ID),
+			Address: string(server.Address),
+			Leader:  server.Address == leader,
+			Voter:   server.Suffrage == raft.Voter,
+		})
+	}
+	return peers, nil
+}
+
+// AddPeer adds a node to the Raft cluster as a voter. The node must have
+// been started without bootstrapping a cluster of its own.
+func (b *RaftBackend) AddPeer(nodeID, address string) error {
+	b.logger.Info("physical/raft: adding peer", "node_id", nodeID, "address", address)
+	return b.raft.AddVoter(raft.ServerID(nodeID), raft.ServerAddress(address), 0, 0).Error()
+}
+
+// RemovePeer removes a node from the Raft cluster
+func (b *RaftBackend) RemovePeer(nodeID string) error {
+	b.logger.Info("physical/raft: removing peer", "node_id", nodeID)
+	return b.raft.RemoveServer(raft.ServerID(nodeID), 0, 0).Error()
+}
+
+// Snapshot writes a snapshot of the data of the cluster to w, as a gzipped
+// tar archive holding the Raft snapshot metadata and the state
+func (b *RaftBackend) Snapshot(w io.Writer) error {
+	meta, rc, err := b.openSnapshot()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    snapshotMetaFile,
+		Mode:    0600,
+		Size:    int64(len(metaJSON)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(metaJSON); err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    snapshotStateFile,
+		Mode:    0600,
+		Size:    meta.Size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, rc); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// openSnapshot takes a snapshot, or opens the latest one if nothing has been
+// committed since it was taken
+func (b *RaftBackend) openSnapshot() (*raft.SnapshotMeta, io.ReadCloser, error) {
+	f := b.raft.Snapshot()
+	err := f.Error()
+	if err == nil {
+		return f.Open()
+	}
+	if !strings.Contains(err.Error(), raft.ErrNothingNewToSnapshot.Error()) {
+		return nil, nil, err
+	}
+
+	snapshots, err := b.snaps.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, nil, fmt.Errorf("no snapshot available")
+	}
+	return b.snaps.Open(snapshots[0].ID)
+}
+
+// Restore replaces the data of the cluster with a snapshot written by
+// Snapshot. It can only be run on the leader.
+func (b *RaftBackend) Restore(r io.Reader) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("error reading snapshot: %v", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	var meta *raft.SnapshotMeta
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fmt.Errorf("snapshot is missing %q", snapshotStateFile)
+		}
+		if err != nil {
+			return fmt.Errorf("error reading snapshot: %v", err)
+		}
+
+		switch header.Name {
+		case snapshotMetaFile:
+			meta = new(raft.SnapshotMeta)
+			if err := jsonutil.DecodeJSONFromReader(tr, meta); err != nil {
+				return fmt.Errorf("error decoding snapshot metadata: %v", err)
+			}
+		case snapshotStateFile:
+			if meta == nil {
+				return fmt.Errorf("snapshot is missing %q", snapshotMetaFile)
+			}
+			if header.Size != meta.Size {
+				return fmt.Errorf("snapshot state is %d bytes, expected %d", header.Size, meta.Size)
+			}
+			b.logger.Info("physical/raft: restoring snapshot", "index", meta.Index)
+			return b.raft.Restore(meta, tr, 0)
+		default:
+			return fmt.Errorf("unexpected file %q in snapshot", header.Name)
+		}
+	}
+}
+
+// HAEnabled indicates whether the HA functionality should be exposed.
+// Currently always returns true.
+func (b *RaftBackend) HAEnabled() bool {
+	return true
+}
+
+// LockWith is used for mutual exclusion based on the given key. A lock can
+// only be held by the leader.
+func (b *RaftBackend) LockWith(key, value string) (physical.Lock, error) {
+	return &RaftLock{
+		b:     b,
+		key:   key,
+		value: value,
+	}, nil
+}
+
+// tryLocalLock takes the lock on key for this node if it is the leader and
+// the lock is not already held here. If it is not taken, it returns a
+// channel closed when that may have changed.
+func (b *RaftBackend) tryLocalLock(key string) (bool, <-chan struct{}) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if b.raft.State() != raft.Leader || b.heldLocks[key] {
+		return false, b.changeCh
+	}
+	b.heldLocks[key] = true
+	return true, nil
+}
+
+func (b *RaftBackend) releaseLocalLock(key string) {
+	b.l.Lock()
+	delete(b.heldLocks, key)
+	b.l.Unlock()
+	b.notifyChange()
+}
+
+// RaftLock is a Lock held by the Raft leader. Its value is replicated so that
+// the other nodes can find the active node.
+type RaftLock struct {
+	b     *RaftBackend
+	key   string
+	value string
+
+	l        sync.Mutex
+	held     bool
+	leaderCh chan struct{}
+	stopCh   chan struct{}
+}
+
+// Lock blocks until this node is the leader and has written the value of
+// the lock. The returned channel is closed when leadership is lost.
+func (l *RaftLock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	l.l.Lock()
+	defer l.l.Unlock()
+	if l.held {
+		return nil, fmt.Errorf("lock already held")
+	}
+
+	for {
+		acquired, changeCh := l.b.tryLocalLock(l.key)
+		if !acquired {
+			select {
+			case <-changeCh:
+				continue
+			case <-stopCh:
+				return nil, nil
+			case <-l.b.shutdownCh:
+				return nil, fmt.Errorf("raft storage is shut down")
+			}
+		}
+
+		// Make sure everything committed by a previous leader has been
+		// applied before the active node reads it
+		err := l.b.raft.Barrier(applyTimeout).Error()
+		if err == nil {
+			err = l.b.apply(&operation{
+				Op:    lockOp,
+				Key:   l.key,
+				Value: []byte(l.value),
+			})
+		}
+		if err == nil {
+			break
+		}
+
+		l.b.releaseLocalLock(l.key)
+		if err != raft.ErrNotLeader && err != raft.ErrLeadershipLost {
+			return nil, err
+		}
+	}
+
+	l.held = true
+	l.leaderCh = make(chan struct{})
+	l.stopCh = make(chan struct{})
+	go l.monitorLeadership(l.leaderCh, l.stopCh)
+	return l.leaderCh, nil
+}
+
+// monitorLeadership closes leaderCh when leadership is lost or stopCh is
+// closed
+func (l *RaftLock) monitorLeadership(leaderCh, stopCh chan struct{}) {
+	defer close(leaderCh)
+	for {
+		isLeader, changeCh := l.b.leadership()
+		if !isLeader {
+			return
+		}
+		select {
+		case <-changeCh:
+		case <-stopCh:
+			return
+		case <-l.b.shutdownCh:
+			return
+		}
+	}
+}
+
+// Unlock releases the lock. If this node is still the leader, it clears the
+// value of the lock and hands leadership over to another node, so that it
+// can become active.
+func (l *RaftLock) Unlock() error {
+	l.l.Lock()
+	defer l.l.Unlock()
+	if !l.held {
+		return nil
+	}
+
+	close(l.stopCh)
+	<-l.leaderCh
+	l.held = false
+	defer l.b.releaseLocalLock(l.key)
+
+	if l.b.raft.State() != raft.Leader {
+		return nil
+	}
+	if err := l.b.apply(&operation{
+		Op:  lockOp,
+		Key: l.key,
+	}); err != nil {
+		return err
+	}
+
+	peers, err := l.b.Peers()
+	if err != nil {
+		return err
+	}
+	if len(peers) > 1 {
+		if err := l.b.raft.LeadershipTransfer().Error(); err != nil {
+			l.b.logger.Warn("physical/raft: failed to transfer leadership", "error", err)
+		}
+	}
+	return nil
+}
+
+// Value returns the value of the lock and if it is held, as last replicated
+// to this node
+func (l *RaftLock) Value() (bool, string, error) {
+	value, err := l.b.fsm.lockValue(l.key)
+	if err != nil {
+		return false, "", err
+	}
+	return value != "", value, nil
+}
+
+// logWriter forwards the output of the Raft library to Vault's logger
+type logWriter struct {
+	logger log.Logger
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSpace(string(p)), "\n") {
+		level, msg := "", line
+		if start := strings.Index(line, "["); start != -1 {
+			if end := strings.Index(line[start:], "]"); end != -1 {
+				level = line[start+1 : start+end]
+				msg = strings.TrimSpace(line[start+end+1:])
+			}
+		}
+		msg = "physical/raft: " + strings.TrimPrefix(msg, "raft: ")
+
+		switch level {
+		case "ERROR", "ERR":
+			w.logger.Error(msg)
+		case "WARN":
+			w.logger.Warn(msg)
+		case "INFO":
+			w.logger.Info(msg)
+		default:
+			w.logger.Debug(msg)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,301 @@
+package raft
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/physical"
+	log "github.com/mgutz/logxi/v1"
+)
+
+func testRaftBackend(t *testing.T, nodeID string, bootstrap bool) (*RaftBackend, func()) {
+	path, err := ioutil.TempDir("", "vault-raft")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	b, err := NewRaftBackend(map[string]string{
+		"path":        path,
+		"node_id":     nodeID,
+		"address":     "127.0.0.1:0",
+		"bootstrap":   fmt.Sprintf("%t", bootstrap),
+		"tls_disable": "true",
+	}, logger)
+	if err != nil {
+		os.RemoveAll(path)
+		t.Fatalf("err: %s", err)
+	}
+
+	rb := b.(*RaftBackend)
+	return rb, func() {
+		rb.Close()
+		os.RemoveAll(path)
+	}
+}
+
+// waitFor polls f until it returns true or the timeout expires
+func waitFor(t *testing.T, what string, f func() bool) {
+	deadline := time.Now().Add(30 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRaftBackend(t *testing.T) {
+	b, cleanup := testRaftBackend(t, "node1", true)
+	defer cleanup()
+
+	physical.ExerciseBackend(t, b)
+	physical.ExerciseBackend_ListPrefix(t, b)
+}
+
+func TestRaftBackend_Transactional(t *testing.T) {
+	b, cleanup := testRaftBackend(t, "node1", true)
+	defer cleanup()
+
+	physical.ExerciseTransactionalBackend(t, b)
+}
+
+func TestRaftBackend_Config(t *testing.T) {
+	logger := logformat.NewVaultLogger(log.LevelTrace)
+
+	cases := map[string]map[string]string{
+		"'path' must be set": {
+			"node_id": "node1",
+		},
+		"'node_id' must be set": {
+			"path": "/tmp/vault-raft",
+		},
+		"'tls_cert_file', 'tls_key_file' and 'tls_ca_file' must be set": {
+			"path":    "/tmp/vault-raft",
+			"node_id": "node1",
+		},
+		"error parsing 'bootstrap'": {
+			"path":      "/tmp/vault-raft",
+			"node_id":   "node1",
+			"bootstrap": "maybe",
+		},
+	}
+	for expected, conf := range cases {
+		_, err := NewRaftBackend(conf, logger)
+		if err == nil || !bytes.Contains([]byte(err.Error()), []byte(expected)) {
+			t.Fatalf("expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestRaftBackend_Cluster(t *testing.T) {
+	b1, cleanup1 := testRaftBackend(t, "node1", true)
+	defer cleanup1()
+	b2, cleanup2 := testRaftBackend(t, "node2", false)
+	defer cleanup2()
+	b3, cleanup3 := testRaftBackend(t, "node3", false)
+	defer cleanup3()
+
+	// Writes are only accepted by the leader
+	if err := b2.Put(&physical.Entry{Key: "foo", Value: []byte("bar")}); err == nil {
+		t.Fatal("expected error writing to a node outside of the cluster")
+	}
+
+	for _, b := range []*RaftBackend{b2, b3} {
+		if err := b1.AddPeer(b.nodeID, string(b.transport.LocalAddr())); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	peers, err := b1.Peers()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(peers) != 3 {
+		t.Fatalf("bad: %#v", peers)
+	}
+	for _, peer := range peers {
+		if peer.Leader != (peer.NodeID == "node1") || !peer.Voter {
+			t.Fatalf("bad: %#v", peer)
+		}
+	}
+
+	// Entries are replicated to the followers
+	if err := b1.Put(&physical.Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, b := range []*RaftBackend{b2, b3} {
+		waitFor(t, "replication", func() bool {
+			entry, err := b.Get("foo")
+			return err == nil && entry != nil && string(entry.Value) == "bar"
+		})
+	}
+
+	// The leader holds the lock
+	lock1, _ := b1.LockWith("core/lock", "node1")
+	leaderCh1, err := lock1.Lock(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if leaderCh1 == nil {
+		t.Fatal("failed to get leader ch")
+	}
+
+	// A follower cannot acquire it
+	lock2, _ := b2.LockWith("core/lock", "node2")
+	stopCh := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() {
+		close(stopCh)
+	})
+	leaderCh2, err := lock2.Lock(stopCh)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if leaderCh2 != nil {
+		t.Fatal("should not get leader ch")
+	}
+
+	// Nor can the leader take it a second time
+	lock1b, _ := b1.LockWith("core/lock", "node1b")
+	stopCh = make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() {
+		close(stopCh)
+	})
+	if leaderCh, err := lock1b.Lock(stopCh); err != nil || leaderCh != nil {
+		t.Fatalf("bad: %v, %v", leaderCh, err)
+	}
+
+	held, value, err := lock2.Value()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !held || value != "node1" {
+		t.Fatalf("bad: %t, %q", held, value)
+	}
+
+	// Releasing the lock hands leadership over to another node, which can
+	// then acquire it
+	type lockResult struct {
+		value    string
+		leaderCh <-chan struct{}
+		err      error
+	}
+	results := make(chan lockResult, 2)
+	stopCh = make(chan struct{})
+	defer close(stopCh)
+	for _, b := range []*RaftBackend{b2, b3} {
+		lock, _ := b.LockWith("core/lock", b.nodeID)
+		go func(value string) {
+			leaderCh, err := lock.Lock(stopCh)
+			results <- lockResult{value, leaderCh, err}
+		}(b.nodeID)
+	}
+
+	if err := lock1.Unlock(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	select {
+	case <-leaderCh1:
+	default:
+		t.Fatal("leader ch should be closed")
+	}
+
+	var result lockResult
+	select {
+	case result = <-results:
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for the lock")
+	}
+	if result.err != nil || result.leaderCh == nil {
+		t.Fatalf("bad: %#v", result)
+	}
+	waitFor(t, "lock value", func() bool {
+		held, value, err := lock1.Value()
+		return err == nil && held && value == result.value
+	})
+
+	// The former leader can be removed by the new one
+	newLeader := b2
+	if result.value == "node3" {
+		newLeader = b3
+	}
+	if err := newLeader.RemovePeer("node1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	peers, err = newLeader.Peers()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(peers) != 2 {
+		t.Fatalf("bad: %#v", peers)
+	}
+}
+
+func TestRaftBackend_Snapshot(t *testing.T) {
+	b, cleanup := testRaftBackend(t, "node1", true)
+	defer cleanup()
+
+	if err := b.Put(&physical.Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var snapshot bytes.Buffer
+	if err := b.Snapshot(&snapshot); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Taking another snapshot with nothing new reuses the last one
+	var snapshot2 bytes.Buffer
+	if err := b.Snapshot(&snapshot2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := b.Delete("foo"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := b.Put(&physical.Entry{Key: "zip", Value: []byte("zap")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := b.Restore(bytes.NewReader([]byte("not a snapshot"))); err == nil {
+		t.Fatal("expected error restoring an invalid snapshot")
+	}
+
+	if err := b.Restore(&snapshot); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	entry, err := b.Get("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry == nil || string(entry.Value) != "bar" {
+		t.Fatalf("bad: %#v", entry)
+	}
+	entry, err = b.Get("zip")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry != nil {
+		t.Fatalf("bad: %#v", entry)
+	}
+
+	// Writes still work after the restore
+	if err := b.Put(&physical.Entry{Key: "zip", Value: []byte("zap")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A restored cluster can be restored again from the second snapshot
+	if err := b.Restore(&snapshot2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if entry, err := b.Get("zip"); err != nil || entry != nil {
+		t.Fatalf("bad: %#v, %v", entry, err)
+	}
+}
@@ -0,0 +1,89 @@
+package raft
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// streamLayer carries the Raft traffic between nodes over TCP, secured with
+// mutually authenticated TLS unless TLS is disabled
+type streamLayer struct {
+	net.Listener
+	advertise net.Addr
+	tlsConfig *tls.Config
+}
+
+func newStreamLayer(address string, advertise net.Addr, tlsConfig *tls.Config) (*streamLayer, error) {
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	if advertise == nil {
+		advertise = ln.Addr()
+	}
+	if tcpAddr, ok := advertise.(*net.TCPAddr); !ok || tcpAddr.IP.IsUnspecified() {
+		ln.Close()
+		return nil, fmt.Errorf("address %q cannot be advertised to other nodes; set 'advertise_address'", advertise)
+	}
+
+	return &streamLayer{
+		Listener:  ln,
+		advertise: advertise,
+		tlsConfig: tlsConfig,
+	}, nil
+}
+
+// Dial implements raft.StreamLayer
+func (s *streamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if s.tlsConfig == nil {
+		return dialer.Dial("tcp", string(address))
+	}
+	return tls.DialWithDialer(dialer, "tcp", string(address), s.tlsConfig)
+}
+
+// Addr returns the address advertised to the other nodes
+func (s *streamLayer) Addr() net.Addr {
+	return s.advertise
+}
+
+// loadTLSConfig builds the TLS configuration used both to serve and to dial
+// other nodes. Every node must present a certificate issued by the CA in
+// caFile that is valid for the address it advertises.
+func loadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, fmt.Errorf("'tls_cert_file', 'tls_key_file' and 'tls_ca_file' must be set unless 'tls_disable' is set")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading TLS certificate: %v", err)
+	}
+
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading TLS CA file: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %q", caFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -43,6 +43,11 @@ func NewACL(policies []*Policy) (*ACL, error) {
 			a.root = true
 		}
 		for _, pc := range policy.Paths {
+			// Templated paths only apply once resolved against an entity
+			if pc.Templated {
+				continue
+			}
+
 			// Check which tree to use
 			tree := a.exactRules
 			if pc.Glob {
@@ -74,6 +79,8 @@ func NewACL(policies []*Policy) (*ACL, error) {
 				existingPerms.CapabilitiesBitmap = DenyCapabilityInt
 				existingPerms.AllowedParameters = nil
 				existingPerms.DeniedParameters = nil
+				existingPerms.RequiredParameters = nil
+				existingPerms.ControlGroup = nil
 				goto INSERT
 
 			default:
@@ -140,6 +147,18 @@ func NewACL(policies []*Policy) (*ACL, error) {
 				}
 			}
 
+			// Required parameters are the union of those in each policy
+			for _, parameter := range pc.Permissions.RequiredParameters {
+				if !strutil.StrListContains(existingPerms.RequiredParameters, parameter) {
+					existingPerms.RequiredParameters = append(existingPerms.RequiredParameters, parameter)
+				}
+			}
+
+			// A control group on the path in any policy applies
+			if existingPerms.ControlGroup == nil {
+				existingPerms.ControlGroup = pc.Permissions.ControlGroup
+			}
+
 		INSERT:
 			tree.Insert(pc.Prefix, existingPerms)
 
@@ -201,6 +220,55 @@ CHECK:
 	return
 }
 
+// ControlGroup returns the control group that read and list requests to the
+// path are subject to, or nil if there is none. Root tokens are not subject
+// to control groups.
+func (a *ACL) ControlGroup(path string) *ControlGroup {
+	if a.root {
+		return nil
+	}
+
+	// Find an exact matching rule, look for glob if no match
+	raw, ok := a.exactRules.Get(path)
+	if !ok {
+		_, raw, ok = a.globRules.LongestPrefix(path)
+		if !ok {
+			return nil
+		}
+	}
+	return raw.(*Permissions).ControlGroup
+}
+
+// hasMountAccess returns whether the ACL grants any capability on the mount
+// at the given path or on any path under it, so that the mount is worth
+// showing to the token.
+func (a *ACL) hasMountAccess(path string) bool {
+	if a.root {
+		return true
+	}
+
+	// A rule covering the mount path itself, such as a glob on a parent path
+	if !strutil.StrListContains(a.Capabilities(path), DenyCapability) {
+		return true
+	}
+
+	// Any rule under the mount path that is not a deny
+	var allowed bool
+	walkFn := func(s string, v interface{}) bool {
+		perms := v.(*Permissions)
+		if perms.CapabilitiesBitmap&DenyCapabilityInt == 0 && perms.CapabilitiesBitmap != 0 {
+			allowed = true
+			return true
+		}
+		return false
+	}
+	a.exactRules.WalkPrefix(path, walkFn)
+	if !allowed {
+		a.globRules.WalkPrefix(path, walkFn)
+	}
+	return allowed
+}
+
 // AllowOperation is used to check if the given operation is permitted. The
 // first bool indicates if an op is allowed, the second whether sudo priviliges
 // exist for that op and path.
@@ -248,7 +316,7 @@ CHECK:
 		operationAllowed = capabilities&ReadCapabilityInt > 0
 	case logical.ListOperation:
 		operationAllowed = capabilities&ListCapabilityInt > 0
-	case logical.UpdateOperation:
+	case logical.UpdateOperation, logical.PatchOperation:
 		operationAllowed = capabilities&UpdateCapabilityInt > 0
 	case logical.DeleteOperation:
 		operationAllowed = capabilities&DeleteCapabilityInt > 0
@@ -288,7 +356,21 @@ CHECK:
 
 	// Only check parameter permissions for operations that can modify
 	// parameters.
-	if op == logical.UpdateOperation || op == logical.CreateOperation {
+	if op == logical.UpdateOperation || op == logical.CreateOperation ||
+		op == logical.PatchOperation {
+		// Check that all required parameters have been provided
+		if len(permissions.RequiredParameters) > 0 {
+			provided := make(map[string]struct{}, len(req.Data))
+			for parameter := range req.Data {
+				provided[strings.ToLower(parameter)] = struct{}{}
+			}
+			for _, parameter := range permissions.RequiredParameters {
+				if _, ok := provided[parameter]; !ok {
+					return false, sudo
+				}
+			}
+		}
+
 		// If there are no data fields, allow
 		if len(req.Data) == 0 {
 			return true, sudo
@@ -106,6 +106,7 @@ func TestACL_Single(t *testing.T) {
 
 		{logical.ReadOperation, "dev/foo", true, true},
 		{logical.UpdateOperation, "dev/foo", true, true},
+		{logical.PatchOperation, "dev/foo", true, true},
 
 		{logical.DeleteOperation, "stage/foo", true, false},
 		{logical.ListOperation, "stage/aws/foo", true, true},
@@ -114,6 +115,7 @@ func TestACL_Single(t *testing.T) {
 
 		{logical.DeleteOperation, "prod/foo", false, false},
 		{logical.UpdateOperation, "prod/foo", false, false},
+		{logical.PatchOperation, "prod/foo", false, false},
 		{logical.ReadOperation, "prod/foo", true, false},
 		{logical.ListOperation, "prod/foo", true, false},
 		{logical.ReadOperation, "prod/aws/foo", false, false},
@@ -284,6 +286,7 @@ func TestACL_AllowOperation(t *testing.T) {
 	toperations := []logical.Operation{
 		logical.UpdateOperation,
 		logical.CreateOperation,
+		logical.PatchOperation,
 	}
 	type tcase struct {
 		path        string
@@ -341,6 +344,63 @@ func TestACL_AllowOperation(t *testing.T) {
 	}
 }
 
+func TestACL_RequiredParameters(t *testing.T) {
+	policy, err := Parse(requiredParametersPolicy)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	acl, err := NewACL([]*Policy{policy})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	toperations := []logical.Operation{
+		logical.UpdateOperation,
+		logical.CreateOperation,
+	}
+	type tcase struct {
+		path       string
+		parameters []string
+		allowed    bool
+	}
+
+	tcases := []tcase{
+		{"auth/approle/role/foo", []string{"policies", "bind_secret_id"}, true},
+		{"auth/approle/role/foo", []string{"Policies", "bind_secret_id"}, true},
+		{"auth/approle/role/foo", []string{"policies", "bind_secret_id", "period"}, true},
+		{"auth/approle/role/foo", []string{"policies"}, false},
+		{"auth/approle/role/foo", []string{"bind_secret_id"}, false},
+		{"auth/approle/role/foo", []string{}, false},
+		{"secret/foo", []string{"ttl"}, true},
+		{"secret/foo", []string{"value"}, false},
+		{"secret/foo", []string{}, false},
+		{"secret/bar", []string{}, true},
+	}
+
+	for _, tc := range tcases {
+		request := logical.Request{Path: tc.path, Data: make(map[string]interface{})}
+		for _, parameter := range tc.parameters {
+			request.Data[parameter] = ""
+		}
+		for _, op := range toperations {
+			request.Operation = op
+			allowed, _ := acl.AllowOperation(&request)
+			if allowed != tc.allowed {
+				t.Fatalf("bad: case %#v: %v", tc, allowed)
+			}
+		}
+	}
+
+	// Required parameters only apply to operations that can modify data
+	request := logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "secret/foo",
+	}
+	if allowed, _ := acl.AllowOperation(&request); !allowed {
+		t.Fatal("expected read to be allowed")
+	}
+}
+
 func TestACL_ValuePermissions(t *testing.T) {
 	policy, err := Parse(valuePermissionsPolicy)
 	if err != nil {
@@ -763,3 +823,108 @@ path "test/star" {
 	}
 }
 `
+
+//test required parameters
+var requiredParametersPolicy = `
+name = "required"
+path "auth/approle/role/*" {
+	capabilities = ["create", "update"]
+	required_parameters = ["policies"]
+}
+path "auth/approle/role/*" {
+	capabilities = ["create", "update"]
+	required_parameters = ["bind_secret_id", "policies"]
+}
+path "secret/foo" {
+	capabilities = ["create", "read", "update"]
+	required_parameters = ["ttl"]
+}
+path "secret/bar" {
+	capabilities = ["create", "update"]
+}
+`
+
+func TestACL_Templated(t *testing.T) {
+	policy, err := Parse(templatedPolicy)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	entity := &Entity{
+		ID:       "abcd",
+		Name:     "armon",
+		Metadata: map[string]string{"team": "eng"},
+		Aliases: []*Alias{
+			&Alias{MountAccessor: "auth_userpass_1234", Name: "armon-userpass"},
+		},
+	}
+
+	type tcase struct {
+		path    string
+		allowed bool
+	}
+	cases := map[*Entity][]tcase{
+		entity: []tcase{
+			{"secret/abcd/foo", true},
+			{"secret/armon", true},
+			{"secret/teams/eng/foo", true},
+			{"secret/users/armon-userpass", true},
+			{"secret/users/armon", false},
+			{"secret/{{identity.entity.name}}", false},
+			{"secret/static", true},
+		},
+		// Paths with a parameter the entity has no value for are dropped
+		&Entity{ID: "efgh"}: []tcase{
+			{"secret/efgh/foo", true},
+			{"secret/", false},
+			{"secret/teams//foo", false},
+			{"secret/users/", false},
+			{"secret/static", true},
+		},
+		// Without an entity only the static paths apply
+		nil: []tcase{
+			{"secret//foo", false},
+			{"secret/{{identity.entity.id}}/foo", false},
+			{"secret/static", true},
+		},
+	}
+
+	for e, tcases := range cases {
+		acl, err := NewACL(resolveTemplatedPolicies([]*Policy{policy}, e))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, tc := range tcases {
+			request := new(logical.Request)
+			request.Operation = logical.ReadOperation
+			request.Path = tc.path
+			allowed, _ := acl.AllowOperation(request)
+			if allowed != tc.allowed {
+				t.Fatalf("bad: path %q, allowed %v", tc.path, allowed)
+			}
+		}
+	}
+
+	// The parsed policy itself is left untouched
+	if !policy.Paths[0].Templated || policy.Paths[0].Prefix != "secret/{{identity.entity.id}}/" {
+		t.Fatalf("bad: %#v", policy.Paths[0])
+	}
+}
+
+var templatedPolicy = `
+path "secret/{{identity.entity.id}}/*" {
+	capabilities = ["read"]
+}
+path "secret/{{identity.entity.name}}" {
+	capabilities = ["read"]
+}
+path "secret/teams/{{identity.entity.metadata.team}}/*" {
+	capabilities = ["read"]
+}
+path "secret/users/{{identity.entity.aliases.auth_userpass_1234.name}}" {
+	capabilities = ["read"]
+}
+path "secret/static" {
+	capabilities = ["read"]
+}
+`
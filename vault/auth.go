@@ -92,8 +92,12 @@ func (c *Core) enableCredential(entry *MountEntry) error {
 		}
 		entry.Accessor = accessor
 	}
+	barrier, err := c.mountBarrier(entry)
+	if err != nil {
+		return logical.CodedError(400, err.Error())
+	}
 	viewPath := credentialBarrierPrefix + entry.UUID + "/"
-	view := NewBarrierView(c.barrier, viewPath)
+	view := NewBarrierView(barrier, viewPath)
 	sysView := c.mountEntrySysView(entry)
 	conf := make(map[string]string)
 	if entry.Config.PluginName != "" {
@@ -174,13 +178,7 @@ func (c *Core) disableCredential(path string) error {
 		return err
 	}
 
-	// Call cleanup function if it exists
-	backend := c.router.MatchingBackend(fullPath)
-	if backend != nil {
-		backend.Cleanup()
-	}
-
-	// Unmount the backend
+	// Unmount the backend, which calls its cleanup function
 	if err := c.router.Unmount(fullPath); err != nil {
 		return err
 	}
@@ -398,6 +396,7 @@ func (c *Core) persistAuth(table *MountTable, localOnly bool) error {
 // initialize the credential backends and setup the router
 func (c *Core) setupCredentials() error {
 	var backend logical.Backend
+	var barrier BarrierStorage
 	var view *BarrierView
 	var err error
 	var persistNeeded bool
@@ -413,8 +412,13 @@ func (c *Core) setupCredentials() error {
 		}
 
 		// Create a barrier view using the UUID
+		barrier, err = c.mountBarrier(entry)
+		if err != nil {
+			c.logger.Error("core: failed to create credential entry", "path", entry.Path, "error", err)
+			return errLoadAuthFailed
+		}
 		viewPath := credentialBarrierPrefix + entry.UUID + "/"
-		view = NewBarrierView(c.barrier, viewPath)
+		view = NewBarrierView(barrier, viewPath)
 		sysView := c.mountEntrySysView(entry)
 		conf := make(map[string]string)
 		if entry.Config.PluginName != "" {
@@ -329,6 +329,11 @@ func TestCore_DisableCredential_Cleanup(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
+	// The backend cleanup should be called once
+	if noop.Cleanups != 1 {
+		t.Fatalf("bad: %d", noop.Cleanups)
+	}
+
 	// Token should be revoked
 	te, err := c.tokenStore.Lookup(resp.Auth.ClientToken)
 	if err != nil {
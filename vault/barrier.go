@@ -121,6 +121,11 @@ type SecurityBarrier interface {
 	// ActiveKeyInfo is used to inform details about the active key
 	ActiveKeyInfo() (*KeyInfo, error)
 
+	// AddEncryptions adds to the number of encryptions performed with the
+	// key of the given term, if it is still the active key. This carries
+	// over the encryptions performed before the barrier was unsealed.
+	AddEncryptions(term uint32, encryptions uint64) error
+
 	// Rekey is used to change the master key used to protect the keyring
 	Rekey([]byte) error
 
@@ -176,4 +181,9 @@ func (e *Entry) Logical() *logical.StorageEntry {
 type KeyInfo struct {
 	Term        int
 	InstallTime time.Time
+
+	// Encryptions is the number of encryptions performed with the key
+	// since the barrier was unsealed, plus any carried over with
+	// AddEncryptions
+	Encryptions uint64
 }
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -43,6 +44,11 @@ type barrierInit struct {
 // bit. AES-GCM is high performance, and provides both confidentiality
 // and integrity.
 type AESGCMBarrier struct {
+	// encryptions is the number of encryptions performed with the active
+	// key since the barrier was unsealed or the key was rotated. It is
+	// accessed atomically and kept first for 64-bit alignment.
+	encryptions uint64
+
 	backend physical.Backend
 
 	l      sync.RWMutex
@@ -421,6 +427,7 @@ func (b *AESGCMBarrier) Seal() error {
 	b.keyring.Zeroize(true)
 	b.keyring = nil
 	b.sealed = true
+	atomic.StoreUint64(&b.encryptions, 0)
 	return nil
 }
 
@@ -460,6 +467,7 @@ func (b *AESGCMBarrier) Rotate() (uint32, error) {
 
 	// Swap the keyrings
 	b.keyring = newKeyring
+	atomic.StoreUint64(&b.encryptions, 0)
 	return newTerm, nil
 }
 
@@ -566,10 +574,26 @@ func (b *AESGCMBarrier) ActiveKeyInfo() (*KeyInfo, error) {
 	info := &KeyInfo{
 		Term:        int(term),
 		InstallTime: key.InstallTime,
+		Encryptions: atomic.LoadUint64(&b.encryptions),
 	}
 	return info, nil
 }
 
+// AddEncryptions adds to the number of encryptions performed with the key
+// of the given term, if it is still the active key
+func (b *AESGCMBarrier) AddEncryptions(term uint32, encryptions uint64) error {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return ErrBarrierSealed
+	}
+
+	if b.keyring.ActiveTerm() == term {
+		atomic.AddUint64(&b.encryptions, encryptions)
+	}
+	return nil
+}
+
 // Rekey is used to change the master key used to protect the keyring
 func (b *AESGCMBarrier) Rekey(key []byte) error {
 	b.l.Lock()
@@ -645,6 +669,7 @@ func (b *AESGCMBarrier) Put(entry *Entry) error {
 		Key:   entry.Key,
 		Value: b.encrypt(entry.Key, term, primary, entry.Value),
 	}
+	atomic.AddUint64(&b.encryptions, 1)
 	return b.backend.Put(pe)
 }
 
@@ -856,6 +881,7 @@ func (b *AESGCMBarrier) Encrypt(key string, plaintext []byte) ([]byte, error) {
 	}
 
 	ciphertext := b.encrypt(key, term, primary, plaintext)
+	atomic.AddUint64(&b.encryptions, 1)
 	return ciphertext, nil
 }
 
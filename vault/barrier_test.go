@@ -268,6 +268,15 @@ func testBarrier_Rotate(t *testing.T, b SecurityBarrier) {
 		t.Fatalf("err: %v", err)
 	}
 
+	// The encryption should be counted
+	info, err = b.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Encryptions != 1 {
+		t.Fatalf("Bad encryptions: %d", info.Encryptions)
+	}
+
 	// Rotate the encryption key
 	newTerm, err := b.Rotate()
 	if err != nil {
@@ -288,6 +297,9 @@ func testBarrier_Rotate(t *testing.T, b SecurityBarrier) {
 	if !info.InstallTime.After(first) {
 		t.Fatalf("Bad install: %v", info.InstallTime)
 	}
+	if info.Encryptions != 0 {
+		t.Fatalf("Bad encryptions: %d", info.Encryptions)
+	}
 
 	// Write another key
 	e2 := &Entry{Key: "foo", Value: []byte("test")}
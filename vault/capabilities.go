@@ -2,11 +2,13 @@ package vault
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/hashicorp/vault/logical"
 )
 
-// Capabilities is used to fetch the capabilities of the given token on the given path
+// Capabilities is used to fetch the capabilities of the given token on the given path.
+// The path includes the path of the namespace it is in, if any.
 func (c *Core) Capabilities(token, path string) ([]string, error) {
 	if path == "" {
 		return nil, &logical.StatusBadRequest{Err: "missing path"}
@@ -24,13 +26,22 @@ func (c *Core) Capabilities(token, path string) ([]string, error) {
 		return nil, &logical.StatusBadRequest{Err: "invalid token"}
 	}
 
-	if te.Policies == nil {
+	// Policies are written relative to the namespace of the token, which
+	// has no capabilities outside of it
+	ns := c.namespaceByID(te.NamespaceID)
+	if ns == nil || !strings.HasPrefix(path, ns.Path) {
+		return []string{DenyCapability}, nil
+	}
+	path = strings.TrimPrefix(path, ns.Path)
+
+	tePolicies := c.tokenPolicies(te)
+	if tePolicies == nil {
 		return []string{DenyCapability}, nil
 	}
 
 	var policies []*Policy
-	for _, tePolicy := range te.Policies {
-		policy, err := c.policyStore.GetPolicy(tePolicy)
+	for _, tePolicy := range tePolicies {
+		policy, err := c.namespacePolicyStore(ns).GetPolicy(tePolicy)
 		if err != nil {
 			return nil, err
 		}
@@ -41,7 +52,7 @@ func (c *Core) Capabilities(token, path string) ([]string, error) {
 		return []string{DenyCapability}, nil
 	}
 
-	acl, err := NewACL(policies)
+	acl, err := NewACL(resolveTemplatedPolicies(policies, c.tokenEntity(te)))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,212 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// defaultControlGroupTTL is the TTL of the wrapping tokens of requests
+	// subject to a control group that does not set one
+	defaultControlGroupTTL = 24 * time.Hour
+
+	// controlGroupCubbyholePath is where the control group request is stored
+	// in the cubbyhole of the wrapping token, along with the wrapped response
+	controlGroupCubbyholePath = "cubbyhole/controlgroup"
+)
+
+var (
+	// errControlGroupPending is returned when unwrapping a response whose
+	// control group request has not been authorized yet
+	errControlGroupPending = errors.New("request needs further approval")
+)
+
+// controlGroupRequest is a request subject to a control group. It is stored
+// in the cubbyhole of the token wrapping the response, so it goes away
+// along with the token.
+type controlGroupRequest struct {
+	// Accessor is the accessor of the wrapping token, which is used by
+	// authorizers to refer to the request
+	Accessor string `json:"accessor"`
+
+	// RequestPath is the path of the request
+	RequestPath string `json:"request_path"`
+
+	// RequestEntityID is the ID of the entity of the requesting token, if any
+	RequestEntityID string `json:"request_entity_id"`
+
+	// Factors are the factors of the control group that must be satisfied
+	Factors []*ControlGroupFactor `json:"factors"`
+
+	// AuthorizationEntityIDs are the IDs of the entities that authorized the
+	// request
+	AuthorizationEntityIDs []string `json:"authorization_entity_ids"`
+}
+
+// storeControlGroupRequest stores the control group request in the cubbyhole
+// of the wrapping token
+func (c *Core) storeControlGroupRequest(token string, cgReq *controlGroupRequest) error {
+	marshaled, err := json.Marshal(cgReq)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.router.Route(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        controlGroupCubbyholePath,
+		ClientToken: token,
+		Data: map[string]interface{}{
+			"request": string(marshaled),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if resp != nil && resp.IsError() {
+		return fmt.Errorf("%v", resp.Data["error"])
+	}
+	return nil
+}
+
+// controlGroupRequestByToken returns the control group request of the
+// wrapping token, or nil if the token does not wrap a response subject to a
+// control group
+func (c *Core) controlGroupRequestByToken(token string) (*controlGroupRequest, error) {
+	resp, err := c.router.Route(&logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        controlGroupCubbyholePath,
+		ClientToken: token,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Data == nil {
+		return nil, nil
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("%v", resp.Data["error"])
+	}
+
+	raw, ok := resp.Data["request"].(string)
+	if !ok {
+		return nil, fmt.Errorf("could not decode control group request")
+	}
+	cgReq := new(controlGroupRequest)
+	if err := jsonutil.DecodeJSON([]byte(raw), cgReq); err != nil {
+		return nil, errwrap.Wrapf("failed to decode control group request: {{err}}", err)
+	}
+	return cgReq, nil
+}
+
+// controlGroupRequestByAccessor returns the control group request of the
+// wrapping token with the given accessor, along with the token
+func (c *Core) controlGroupRequestByAccessor(accessor string) (string, *controlGroupRequest, error) {
+	aEntry, err := c.tokenStore.lookupByAccessor(accessor, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if aEntry.TokenID == "" {
+		return "", nil, nil
+	}
+
+	cgReq, err := c.controlGroupRequestByToken(aEntry.TokenID)
+	if err != nil {
+		return "", nil, err
+	}
+	return aEntry.TokenID, cgReq, nil
+}
+
+// controlGroupApproved returns whether each factor of the control group
+// request has been authorized by enough members of its groups
+func (c *Core) controlGroupApproved(cgReq *controlGroupRequest) bool {
+	for _, factor := range cgReq.Factors {
+		approvals := 0
+		for _, entityID := range cgReq.AuthorizationEntityIDs {
+			if c.identityStore.entityInGroupNames(entityID, factor.GroupNames) {
+				approvals++
+			}
+		}
+		if approvals < factor.Approvals {
+			return false
+		}
+	}
+	return true
+}
+
+// authorizeControlGroupRequest records the authorization of the control
+// group request of the wrapping token with the given accessor by the entity.
+// It returns whether the request is now approved.
+func (c *Core) authorizeControlGroupRequest(accessor, entityID string) (bool, error) {
+	c.controlGroupLock.Lock()
+	defer c.controlGroupLock.Unlock()
+
+	token, cgReq, err := c.controlGroupRequestByAccessor(accessor)
+	if err != nil {
+		return false, err
+	}
+	if cgReq == nil {
+		return false, logical.CodedError(400, "no control group request found for the accessor")
+	}
+
+	if cgReq.RequestEntityID != "" && cgReq.RequestEntityID == entityID {
+		return false, logical.CodedError(403, "requesters cannot authorize their own request")
+	}
+
+	authorizer := false
+	for _, factor := range cgReq.Factors {
+		if c.identityStore.entityInGroupNames(entityID, factor.GroupNames) {
+			authorizer = true
+			break
+		}
+	}
+	if !authorizer {
+		return false, logical.CodedError(403, "entity is not an authorizer of the request")
+	}
+
+	if !strutil.StrListContains(cgReq.AuthorizationEntityIDs, entityID) {
+		cgReq.AuthorizationEntityIDs = append(cgReq.AuthorizationEntityIDs, entityID)
+		if err := c.storeControlGroupRequest(token, cgReq); err != nil {
+			return false, errwrap.Wrapf("failed to store control group request: {{err}}", err)
+		}
+	}
+
+	return c.controlGroupApproved(cgReq), nil
+}
+
+// checkControlGroupUnwrap ensures that a request does not read the response
+// wrapped by a token subject to a control group before the control group
+// request is approved. This is checked before the wrapping token is used,
+// so that it stays valid until then.
+func (c *Core) checkControlGroupUnwrap(req *logical.Request, te *TokenEntry) error {
+	path := c.namespaceRelativePath(req.Path)
+
+	var tokens []string
+	if te != nil && path != "sys/wrapping/lookup" &&
+		len(te.Policies) == 1 && te.Policies[0] == responseWrappingPolicyName {
+		tokens = append(tokens, te.ID)
+	}
+	if path == "sys/wrapping/unwrap" || path == "sys/wrapping/rewrap" {
+		if token, ok := req.Data["token"].(string); ok && token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, token := range tokens {
+		cgReq, err := c.controlGroupRequestByToken(token)
+		if err != nil {
+			c.logger.Error("core: failed to look up control group request", "error", err)
+			return ErrInternalError
+		}
+		if cgReq != nil && !c.controlGroupApproved(cgReq) {
+			return errControlGroupPending
+		}
+	}
+	return nil
+}
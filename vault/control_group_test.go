@@ -0,0 +1,179 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+// testControlGroupToken creates a token with the given policies tied to the
+// entity
+func testControlGroupToken(t *testing.T, c *Core, entityID string, policies ...string) string {
+	te := &TokenEntry{
+		Path:     "auth/token/create",
+		Policies: policies,
+		EntityID: entityID,
+	}
+	if err := c.tokenStore.create(te); err != nil {
+		t.Fatal(err)
+	}
+	return te.ID
+}
+
+func TestControlGroup_Approval(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/policy/requester", map[string]interface{}{
+		"rules": `
+path "secret/foo" {
+	capabilities = ["read"]
+	control_group = {
+		ttl = "1h"
+		factor "managers" {
+			identity {
+				group_names = ["managers"]
+				approvals = 2
+			}
+		}
+	}
+}`,
+	})
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/policy/approver", map[string]interface{}{
+		"rules": `path "sys/control-group/authorize" { capabilities = ["update"] }`,
+	})
+
+	requesterID := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+	manager1ID := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+	manager2ID := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+	otherID := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "group", map[string]interface{}{
+		"name":              "managers",
+		"member_entity_ids": []string{requesterID, manager1ID, manager2ID},
+	})
+
+	requester := testControlGroupToken(t, c, requesterID, "default", "requester", "approver")
+	manager1 := testControlGroupToken(t, c, manager1ID, "default", "approver")
+	manager2 := testControlGroupToken(t, c, manager2ID, "default", "approver")
+	other := testControlGroupToken(t, c, otherID, "default", "approver")
+
+	// The response is wrapped even though wrapping was not requested
+	resp := testNamespaceRequest(t, c, requester, logical.ReadOperation, "secret/foo", nil)
+	if resp.WrapInfo == nil || resp.WrapInfo.Token == "" || resp.WrapInfo.Accessor == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.Data != nil {
+		t.Fatalf("expected no data in the wrapped response, got: %#v", resp.Data)
+	}
+	wrappingToken, accessor := resp.WrapInfo.Token, resp.WrapInfo.Accessor
+
+	// The response cannot be unwrapped, and the token is not used up trying
+	unwrap := func() (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/wrapping/unwrap")
+		req.ClientToken = wrappingToken
+		return c.HandleRequest(req)
+	}
+	testPending := func() {
+		resp, err := unwrap()
+		if err == nil || resp == nil || resp.Data["error"] != errControlGroupPending.Error() {
+			t.Fatalf("expected the request to be pending, got: %v, resp: %#v", err, resp)
+		}
+	}
+	testPending()
+	testPending()
+
+	// Only other members of the groups can authorize the request
+	authorize := func(token string) (*logical.Response, error) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/control-group/authorize")
+		req.ClientToken = token
+		req.Data["accessor"] = accessor
+		return c.HandleRequest(req)
+	}
+	for _, token := range []string{requester, other} {
+		resp, err := authorize(token)
+		if err == nil || resp == nil || !resp.IsError() {
+			t.Fatalf("expected an error response, got: %v, resp: %#v", err, resp)
+		}
+	}
+
+	resp, err := authorize(manager1)
+	if err != nil || resp.Data["approved"] != false {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	// Authorizing twice counts once
+	resp, err = authorize(manager1)
+	if err != nil || resp.Data["approved"] != false {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	testPending()
+
+	resp = testNamespaceRequest(t, c, requester, logical.UpdateOperation, "sys/control-group/request", map[string]interface{}{
+		"accessor": accessor,
+	})
+	if resp.Data["approved"] != false || resp.Data["request_path"] != "secret/foo" ||
+		resp.Data["request_entity"].(map[string]interface{})["id"] != requesterID ||
+		len(resp.Data["authorizations"].([]map[string]interface{})) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err = authorize(manager2)
+	if err != nil || resp.Data["approved"] != true {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+
+	resp, err = unwrap()
+	if err != nil || resp == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	var unwrapped logical.HTTPResponse
+	if err := jsonutil.DecodeJSON(resp.Data[logical.HTTPRawBody].([]byte), &unwrapped); err != nil {
+		t.Fatal(err)
+	}
+	if unwrapped.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", unwrapped)
+	}
+}
+
+func TestControlGroup_Root(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/policy/requester", map[string]interface{}{
+		"rules": `
+path "secret/foo" {
+	capabilities = ["read"]
+	control_group = {
+		factor "managers" {
+			identity {
+				group_names = ["managers"]
+			}
+		}
+	}
+}`,
+	})
+
+	// Root tokens are not subject to control groups
+	resp := testNamespaceRequest(t, c, root, logical.ReadOperation, "secret/foo", nil)
+	if resp.WrapInfo != nil || resp.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The control group defaults to a single approval and the default TTL
+	p, err := c.policyStore.GetPolicy("requester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	acl, err := NewACL([]*Policy{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg := acl.ControlGroup("secret/foo")
+	if cg == nil || cg.TTL != defaultControlGroupTTL || len(cg.Factors) != 1 || cg.Factors[0].Approvals != 1 {
+		t.Fatalf("bad: %#v", cg)
+	}
+}
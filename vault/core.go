@@ -24,12 +24,15 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/audit"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/errutil"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/mlock"
 	"github.com/hashicorp/vault/helper/reload"
+	"github.com/hashicorp/vault/helper/strutil"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/physical"
 	"github.com/hashicorp/vault/shamir"
@@ -162,9 +165,16 @@ type Core struct {
 	// physical backend is the un-trusted backend with durable data
 	physical physical.Backend
 
+	// underlyingPhysical is the configured physical backend, before it is
+	// wrapped by the cache and the disaster recovery log
+	underlyingPhysical physical.Backend
+
 	// Our Seal, for seal configuration information
 	seal Seal
 
+	// migrationSeal is the seal being migrated away from, if any
+	migrationSeal Seal
+
 	// barrier is the security barrier wrapping the physical backend
 	barrier SecurityBarrier
 
@@ -255,9 +265,38 @@ type Core struct {
 	// token store is used to manage authentication tokens
 	tokenStore *TokenStore
 
+	// identityStore is used to manage client entities
+	identityStore *IdentityStore
+
+	// namespaces holds the namespaces other than the root namespace, keyed
+	// by path
+	namespaces     map[string]*namespace
+	namespacesLock sync.RWMutex
+
+	// controlGroupLock serializes the authorizations of control group
+	// requests
+	controlGroupLock sync.Mutex
+
 	// metricsCh is used to stop the metrics streaming
 	metricsCh chan struct{}
 
+	// keyRotationCh is used to stop the automatic rotation of the barrier
+	// encryption key
+	keyRotationCh chan struct{}
+
+	// keyRotationConfig is the configuration of the automatic rotation of
+	// the barrier encryption key
+	keyRotationConfig     *KeyRotationConfig
+	keyRotationConfigLock sync.RWMutex
+
+	// keyRotationLock serializes rotations of the barrier encryption key, and
+	// checkpoints of the number of encryptions performed with it
+	keyRotationLock sync.Mutex
+
+	// keyEncryptionCountPersisted is the number of encryptions last
+	// checkpointed, protected by the key rotation lock
+	keyEncryptionCountPersisted uint64
+
 	// metricsMutex is used to prevent a race condition between
 	// metrics emission and sealing leading to a nil pointer
 	metricsMutex sync.Mutex
@@ -276,6 +315,9 @@ type Core struct {
 	// reloadFuncsLock controls access to the funcs
 	reloadFuncsLock sync.RWMutex
 
+	// reloadConfigFunc reloads the server configuration
+	reloadConfigFunc func() error
+
 	// wrappingJWTKey is the key used for generating JWTs containing response
 	// wrapping information
 	wrappingJWTKey *ecdsa.PrivateKey
@@ -312,6 +354,9 @@ type Core struct {
 	// Most recent leader UUID. Used to avoid repeatedly JSON parsing the same
 	// values.
 	clusterLeaderUUID string
+	// UUID of the HA lock held while this node is active, guarded by
+	// stateLock
+	activeLeaderUUID string
 	// Most recent leader redirect addr
 	clusterLeaderRedirectAddr string
 	// Most recent leader cluster addr
@@ -338,6 +383,13 @@ type Core struct {
 	// lookup
 	replicationState consts.ReplicationState
 
+	// drLog wraps the physical backend and logs the writes of a disaster
+	// recovery primary
+	drLog *drLogBackend
+
+	// drReplication holds the disaster recovery replication state
+	drReplication *drReplication
+
 	// uiEnabled indicates whether Vault Web UI is enabled or not
 	uiEnabled bool
 
@@ -347,6 +399,13 @@ type Core struct {
 	// pluginCatalog is used to manage plugin configurations
 	pluginCatalog *PluginCatalog
 
+	// sanitizedConfig is the server configuration with its secrets removed
+	sanitizedConfig map[string]interface{}
+
+	// metricSink keeps the telemetry of this node so that it can be served
+	// from sys/metrics. May be nil.
+	metricSink *metricsutil.PrometheusSink
+
 	enableMlock bool
 
 	// This can be used to trigger operations to stop running when Vault is
@@ -372,6 +431,10 @@ type CoreConfig struct {
 
 	Seal Seal `json:"seal" structs:"seal" mapstructure:"seal"`
 
+	// MigrationSeal is the seal being migrated away from. If set, unseal
+	// keys for it can be provided to UnsealMigrate to move to Seal.
+	MigrationSeal Seal `json:"migration_seal" structs:"migration_seal" mapstructure:"migration_seal"`
+
 	Logger log.Logger `json:"logger" structs:"logger" mapstructure:"logger"`
 
 	// Disables the LRU cache on the physical backend
@@ -399,8 +462,19 @@ type CoreConfig struct {
 
 	PluginDirectory string `json:"plugin_directory" structs:"plugin_directory" mapstructure:"plugin_directory"`
 
+	// SanitizedConfig is the server configuration with its secrets removed,
+	// which is returned by sys/config/state/sanitized
+	SanitizedConfig map[string]interface{} `json:"sanitized_config" structs:"sanitized_config" mapstructure:"sanitized_config"`
+
+	// May be nil, which disables the sys/metrics endpoint
+	MetricSink *metricsutil.PrometheusSink `json:"metric_sink" structs:"metric_sink" mapstructure:"metric_sink"`
+
 	ReloadFuncs     *map[string][]reload.ReloadFunc
 	ReloadFuncsLock *sync.RWMutex
+
+	// ReloadConfigFunc reloads the server configuration, the same way as on
+	// SIGHUP. May be nil, which disables the sys/config/reload endpoint.
+	ReloadConfigFunc func() error
 }
 
 // NewCore is used to construct a new core
@@ -442,9 +516,11 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	c := &Core{
 		devToken:                         conf.DevToken,
 		physical:                         conf.Physical,
+		underlyingPhysical:               conf.Physical,
 		redirectAddr:                     conf.RedirectAddr,
 		clusterAddr:                      conf.ClusterAddr,
 		seal:                             conf.Seal,
+		migrationSeal:                    conf.MigrationSeal,
 		router:                           NewRouter(),
 		sealed:                           true,
 		standby:                          true,
@@ -457,6 +533,9 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		clusterListenerShutdownSuccessCh: make(chan struct{}),
 		clusterPeerClusterAddrsCache:     cache.New(3*heartbeatInterval, time.Second),
 		enableMlock:                      !conf.DisableMlock,
+		metricSink:                       conf.MetricSink,
+		sanitizedConfig:                  conf.SanitizedConfig,
+		reloadConfigFunc:                 conf.ReloadConfigFunc,
 	}
 
 	c.corsConfig = &CORSConfig{core: c}
@@ -472,6 +551,11 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		}
 	}
 
+	// Wrap the physical backend in the write-ahead log of disaster recovery
+	// replication, which is only kept while this is a primary
+	c.drLog = newDRLogBackend(c.physical)
+	c.physical = c.drLog
+
 	if !conf.DisableMlock {
 		// Ensure our memory usage is locked into physical RAM
 		if err := mlock.LockMemory(); err != nil {
@@ -531,6 +615,9 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		}
 		return b, nil
 	}
+	logicalBackends["identity"] = func(config *logical.BackendConfig) (logical.Backend, error) {
+		return NewIdentityStore(c, config)
+	}
 	c.logicalBackends = logicalBackends
 
 	credentialBackends := make(map[string]logical.Factory)
@@ -552,6 +639,11 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 		c.seal = &DefaultSeal{}
 	}
 	c.seal.SetCore(c)
+	if c.migrationSeal != nil {
+		c.migrationSeal.SetCore(c)
+	}
+
+	c.setupDRReplication()
 
 	// Attempt unsealing with stored keys; if there are no stored keys this
 	// returns nil, otherwise returns nil or an error
@@ -572,6 +664,11 @@ func (c *Core) Shutdown() error {
 	}
 	c.stateLock.RUnlock()
 
+	// Stop replicating if this is a disaster recovery secondary
+	c.drReplication.opLock.Lock()
+	c.stopDRSecondary()
+	c.drReplication.opLock.Unlock()
+
 	// Seal the Vault, causes a leader stepdown
 	retChan := make(chan error)
 	go func() {
@@ -639,22 +736,90 @@ func (c *Core) fetchACLandTokenEntry(req *logical.Request) (*ACL, *TokenEntry, e
 		return nil, nil, logical.ErrPermissionDenied
 	}
 
+	// CIDR checks bind all tokens except non-expiring root tokens
+	if te.TTL != 0 && len(te.BoundCIDRs) > 0 {
+		if req.Connection == nil || req.Connection.RemoteAddr == "" {
+			return nil, nil, logical.ErrPermissionDenied
+		}
+		valid, err := cidrutil.IPBelongsToCIDRBlocksSlice(req.Connection.RemoteAddr, te.BoundCIDRs)
+		if err != nil {
+			if c.logger.IsDebug() {
+				c.logger.Debug("core: failed to check token bound CIDRs", "remote_addr", req.Connection.RemoteAddr, "error", err)
+			}
+			return nil, nil, logical.ErrPermissionDenied
+		}
+		if !valid {
+			return nil, nil, logical.ErrPermissionDenied
+		}
+	}
+
 	// Construct the corresponding ACL object
-	acl, err := c.policyStore.ACL(te.Policies...)
+	acl, _, err := c.tokenACL(te)
 	if err != nil {
 		c.logger.Error("core: failed to construct ACL", "error", err)
 		return nil, nil, ErrInternalError
 	}
 
+	// The namespace of the token no longer exists
+	if acl == nil {
+		return nil, nil, logical.ErrPermissionDenied
+	}
+
 	return acl, te, nil
 }
 
-func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, error) {
+// tokenPolicies returns the policies of the token, along with those attached
+// to its entity directly and through groups
+func (c *Core) tokenPolicies(te *TokenEntry) []string {
+	if te.EntityID == "" || c.identityStore == nil {
+		return te.Policies
+	}
+
+	policies := make([]string, 0, len(te.Policies))
+	policies = append(policies, te.Policies...)
+	policies = append(policies, c.identityStore.policiesByEntityID(te.EntityID)...)
+	return strutil.RemoveDuplicates(policies, false)
+}
+
+// tokenEntity returns the entity the token is tied to, against which
+// templated policy paths are resolved. Nil is returned if there is none.
+func (c *Core) tokenEntity(te *TokenEntry) *Entity {
+	if te.EntityID == "" || c.identityStore == nil {
+		return nil
+	}
+	return c.identityStore.entityByID(te.EntityID)
+}
+
+// useToken counts a use of the token by a request to an unauthenticated path
+// that still acts on the token, which is not counted by the normal request
+// handling. The token is revoked on its last use.
+func (c *Core) useToken(te *TokenEntry) error {
+	te, err := c.tokenStore.UseToken(te)
+	if err != nil {
+		c.logger.Error("core: failed to use token", "error", err)
+		return ErrInternalError
+	}
+	if te == nil {
+		// Token has been revoked by this point
+		return logical.ErrPermissionDenied
+	}
+	if te.NumUses == -1 {
+		if err := c.tokenStore.Revoke(te.ID); err != nil {
+			c.logger.Error("core: failed to revoke token", "error", err)
+			return ErrInternalError
+		}
+	}
+	return nil
+}
+
+// checkToken validates the token of the request against the ACL. If the
+// request is subject to a control group, it is returned as well.
+func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, *ControlGroup, error) {
 	defer metrics.MeasureSince([]string{"core", "check_token"}, time.Now())
 
 	acl, te, err := c.fetchACLandTokenEntry(req)
 	if err != nil {
-		return nil, te, err
+		return nil, te, nil, err
 	}
 
 	// Check if this is a root protected path
@@ -676,9 +841,9 @@ func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, err
 		default:
 			c.logger.Error("core: failed to run existence check", "error", err)
 			if _, ok := err.(errutil.UserError); ok {
-				return nil, nil, err
+				return nil, nil, nil, err
 			} else {
-				return nil, nil, ErrInternalError
+				return nil, nil, nil, ErrInternalError
 			}
 		}
 
@@ -707,17 +872,25 @@ func (c *Core) checkToken(req *logical.Request) (*logical.Auth, *TokenEntry, err
 
 	// Check the standard non-root ACLs. Return the token entry if it's not
 	// allowed so we can decrement the use count.
-	allowed, rootPrivs := acl.AllowOperation(req)
+	allowed, rootPrivs := c.allowOperation(acl, te, req)
 	if !allowed {
 		// Return auth for audit logging even if not allowed
-		return auth, te, logical.ErrPermissionDenied
+		return auth, te, nil, logical.ErrPermissionDenied
 	}
 	if rootPath && !rootPrivs {
 		// Return auth for audit logging even if not allowed
-		return auth, te, logical.ErrPermissionDenied
+		return auth, te, nil, logical.ErrPermissionDenied
+	}
+
+	// Control groups only gate the responses of reads and lists
+	var controlGroup *ControlGroup
+	if req.Operation == logical.ReadOperation || req.Operation == logical.ListOperation {
+		if path, ok := c.namespaceACLPath(te, req.Path); ok {
+			controlGroup = acl.ControlGroup(path)
+		}
 	}
 
-	return auth, te, nil
+	return auth, te, controlGroup, nil
 }
 
 // Sealed checks if the Vault is current sealed
@@ -786,9 +959,11 @@ func (c *Core) Leader() (isLeader bool, leaderAddr, clusterAddr string, err erro
 	c.clusterLeaderParamsLock.Lock()
 	defer c.clusterLeaderParamsLock.Unlock()
 
-	// Validate base conditions again
+	// Validate base conditions again; another request may have refreshed the
+	// connection while we waited on the lock, in which case the values read
+	// above are stale
 	if leaderUUID == c.clusterLeaderUUID && c.clusterLeaderRedirectAddr != "" {
-		return false, localRedirAddr, localClusterAddr, nil
+		return false, c.clusterLeaderRedirectAddr, c.clusterLeaderClusterAddr, nil
 	}
 
 	key := coreLeaderPrefix + leaderUUID
@@ -837,6 +1012,26 @@ func (c *Core) Leader() (isLeader bool, leaderAddr, clusterAddr string, err erro
 	return false, adv.RedirectAddr, adv.ClusterAddr, nil
 }
 
+// LeaderID returns the UUID of the HA lock held by the active node, which
+// changes every time a node becomes active. It is empty if the node is sealed,
+// HA is not enabled, or the active node is not known yet; call Leader first to
+// refresh the active node information on a standby.
+func (c *Core) LeaderID() string {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+
+	if c.sealed || c.ha == nil {
+		return ""
+	}
+	if !c.standby {
+		return c.activeLeaderUUID
+	}
+
+	c.clusterLeaderParamsLock.RLock()
+	defer c.clusterLeaderParamsLock.RUnlock()
+	return c.clusterLeaderUUID
+}
+
 // SecretProgress returns the number of keys provided so far
 func (c *Core) SecretProgress() (int, string) {
 	c.stateLock.RLock()
@@ -868,14 +1063,8 @@ func (c *Core) ResetUnsealProcess() {
 func (c *Core) Unseal(key []byte) (bool, error) {
 	defer metrics.MeasureSince([]string{"core", "unseal"}, time.Now())
 
-	// Verify the key length
-	min, max := c.barrier.KeyLength()
-	max += shamir.ShareOverhead
-	if len(key) < min {
-		return false, &ErrInvalidKey{fmt.Sprintf("key is shorter than minimum %d bytes", min)}
-	}
-	if len(key) > max {
-		return false, &ErrInvalidKey{fmt.Sprintf("key is longer than maximum %d bytes", max)}
+	if err := c.checkUnsealKeyLength(key); err != nil {
+		return false, err
 	}
 
 	// Get the seal configuration
@@ -897,6 +1086,13 @@ func (c *Core) Unseal(key []byte) (bool, error) {
 		return true, nil
 	}
 
+	if err := c.ensureDRReplicationLoaded(); err != nil {
+		return false, err
+	}
+	if c.drSecondary() {
+		return false, ErrDRSecondary
+	}
+
 	masterKey, err := c.unsealPart(config, key)
 	if err != nil {
 		return false, err
@@ -908,6 +1104,19 @@ func (c *Core) Unseal(key []byte) (bool, error) {
 	return false, nil
 }
 
+// checkUnsealKeyLength verifies that a key part has a valid length
+func (c *Core) checkUnsealKeyLength(key []byte) error {
+	min, max := c.barrier.KeyLength()
+	max += shamir.ShareOverhead
+	if len(key) < min {
+		return &ErrInvalidKey{fmt.Sprintf("key is shorter than minimum %d bytes", min)}
+	}
+	if len(key) > max {
+		return &ErrInvalidKey{fmt.Sprintf("key is longer than maximum %d bytes", max)}
+	}
+	return nil
+}
+
 func (c *Core) unsealPart(config *SealConfig, key []byte) ([]byte, error) {
 	// Check if we already have this piece
 	if c.unlockInfo != nil {
@@ -965,6 +1174,10 @@ func (c *Core) unsealPart(config *SealConfig, key []byte) ([]byte, error) {
 func (c *Core) unsealInternal(masterKey []byte) (bool, error) {
 	defer memzero(masterKey)
 
+	if c.drSecondary() {
+		return false, ErrDRSecondary
+	}
+
 	// Attempt to unlock
 	if err := c.barrier.Unseal(masterKey); err != nil {
 		return false, err
@@ -1128,7 +1341,7 @@ func (c *Core) sealInitCommon(req *logical.Request) (retErr error) {
 	}
 
 	// Verify that this operation is allowed
-	allowed, rootPrivs := acl.AllowOperation(req)
+	allowed, rootPrivs := c.allowOperation(acl, te, req)
 	if !allowed {
 		retErr = multierror.Append(retErr, logical.ErrPermissionDenied)
 		c.stateLock.RUnlock()
@@ -1230,7 +1443,7 @@ func (c *Core) StepDown(req *logical.Request) (retErr error) {
 	}
 
 	// Verify that this operation is allowed
-	allowed, rootPrivs := acl.AllowOperation(req)
+	allowed, rootPrivs := c.allowOperation(acl, te, req)
 	if !allowed {
 		retErr = multierror.Append(retErr, logical.ErrPermissionDenied)
 		return retErr
@@ -1251,6 +1464,58 @@ func (c *Core) StepDown(req *logical.Request) (retErr error) {
 	return retErr
 }
 
+// checkRootRequest authenticates and audit-logs a request that is served
+// outside of the logical request path, and checks that its token has root
+// privileges on the request path. The state lock must be held for reading and
+// Vault must be unsealed.
+func (c *Core) checkRootRequest(req *logical.Request) (retErr error) {
+	acl, te, err := c.fetchACLandTokenEntry(req)
+	if err != nil {
+		return err
+	}
+
+	// Audit-log the request before going any further
+	auth := &logical.Auth{
+		ClientToken: req.ClientToken,
+		Policies:    te.Policies,
+		Metadata:    te.Meta,
+		DisplayName: te.DisplayName,
+	}
+
+	if err := c.auditBroker.LogRequest(auth, req, c.auditedHeaders, nil); err != nil {
+		c.logger.Error("core: failed to audit request", "request_path", req.Path, "error", err)
+		return errors.New("failed to audit request, cannot continue")
+	}
+
+	// Attempt to use the token (decrement num_uses)
+	te, err = c.tokenStore.UseToken(te)
+	if err != nil {
+		c.logger.Error("core: failed to use token", "error", err)
+		return ErrInternalError
+	}
+	if te == nil {
+		// Token has been revoked
+		return logical.ErrPermissionDenied
+	}
+	if te.NumUses == -1 {
+		// Token needs to be revoked
+		defer func(id string) {
+			if err := c.tokenStore.Revoke(id); err != nil {
+				c.logger.Error("core: token needed revocation after use but failed to revoke", "request_path", req.Path, "error", err)
+				retErr = multierror.Append(retErr, ErrInternalError)
+			}
+		}(te.ID)
+	}
+
+	// We always require root privileges for these operations
+	allowed, rootPrivs := c.allowOperation(acl, te, req)
+	if !allowed || !rootPrivs {
+		return logical.ErrPermissionDenied
+	}
+
+	return nil
+}
+
 // sealInternal is an internal method used to seal the vault.  It does not do
 // any authorization checking. The stateLock must be held prior to calling.
 func (c *Core) sealInternal() error {
@@ -1365,12 +1630,21 @@ func (c *Core) postUnseal() (retErr error) {
 	if err := c.loadCORSConfig(); err != nil {
 		return err
 	}
+	if err := c.loadKeyRotationConfig(); err != nil {
+		return err
+	}
+	if err := c.loadKeyEncryptionCount(); err != nil {
+		return err
+	}
 	if err := c.loadCredentials(); err != nil {
 		return err
 	}
 	if err := c.setupCredentials(); err != nil {
 		return err
 	}
+	if err := c.setupNamespaces(); err != nil {
+		return err
+	}
 	if err := c.setupExpiration(); err != nil {
 		return err
 	}
@@ -1391,6 +1665,8 @@ func (c *Core) postUnseal() (retErr error) {
 	}
 	c.metricsCh = make(chan struct{})
 	go c.emitMetrics(c.metricsCh)
+	c.keyRotationCh = make(chan struct{})
+	go c.autoRotateKeys(c.keyRotationCh)
 	c.logger.Info("core: post-unseal setup complete")
 	return nil
 }
@@ -1411,6 +1687,16 @@ func (c *Core) preSeal() error {
 		close(c.metricsCh)
 		c.metricsCh = nil
 	}
+	if c.keyRotationCh != nil {
+		close(c.keyRotationCh)
+		c.keyRotationCh = nil
+
+		if c.ReplicationState() != consts.ReplicationSecondary {
+			if err := c.persistKeyEncryptionCount(); err != nil {
+				c.logger.Error("core: failed to checkpoint encryption count", "error", err)
+			}
+		}
+	}
 	var result error
 
 	c.stopClusterListener()
@@ -1424,6 +1710,9 @@ func (c *Core) preSeal() error {
 	if err := c.teardownCredentials(); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error tearing down credentials: {{err}}", err))
 	}
+	if err := c.teardownNamespaces(); err != nil {
+		result = multierror.Append(result, errwrap.Wrapf("error tearing down namespaces: {{err}}", err))
+	}
 	if err := c.teardownPolicyStore(); err != nil {
 		result = multierror.Append(result, errwrap.Wrapf("error tearing down policy store: {{err}}", err))
 	}
@@ -1573,6 +1862,7 @@ func (c *Core) runStandby(doneCh, stopCh, manualStepDownCh chan struct{}) {
 		err = c.postUnseal()
 		if err == nil {
 			c.standby = false
+			c.activeLeaderUUID = uuid
 		}
 		c.stateLock.Unlock()
 
@@ -1611,6 +1901,7 @@ func (c *Core) runStandby(doneCh, stopCh, manualStepDownCh chan struct{}) {
 		// Attempt the pre-seal process
 		c.stateLock.Lock()
 		c.standby = true
+		c.activeLeaderUUID = ""
 		preSealErr := c.preSeal()
 		c.stateLock.Unlock()
 
@@ -1077,6 +1077,15 @@ func TestCore_Standby_Seal(t *testing.T) {
 		t.Fatalf("Bad advertise: %v, orig is %v", advertise, redirectOriginal)
 	}
 
+	// Both cores should agree on the identity of the leader
+	leaderID := core.LeaderID()
+	if leaderID == "" {
+		t.Fatalf("missing leader ID")
+	}
+	if leaderID2 := core2.LeaderID(); leaderID2 != leaderID {
+		t.Fatalf("bad leader ID: %v, expected %v", leaderID2, leaderID)
+	}
+
 	// Seal the standby core with the correct token. Shouldn't go down
 	err = core2.Seal(root)
 	if err == nil {
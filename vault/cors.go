@@ -16,10 +16,12 @@ const (
 )
 
 var StdAllowedHeaders = []string{
+	"Authorization",
 	"Content-Type",
 	"X-Requested-With",
 	"X-Vault-AWS-IAM-Server-ID",
 	"X-Vault-MFA",
+	"X-Vault-Namespace",
 	"X-Vault-No-Request-Forwarding",
 	"X-Vault-Token",
 	"X-Vault-Wrap-Format",
@@ -97,8 +99,9 @@ func (c *CORSConfig) Enable(urls []string, headers []string) error {
 	c.Lock()
 	c.AllowedOrigins = urls
 
-	// Start with the standard headers to Vault accepts.
-	c.AllowedHeaders = append(c.AllowedHeaders, StdAllowedHeaders...)
+	// Start with the standard headers to Vault accepts, replacing any
+	// headers allowed by an earlier configuration.
+	c.AllowedHeaders = append([]string(nil), StdAllowedHeaders...)
 
 	// Allow the user to add additional headers to the list of
 	// headers allowed on cross-origin requests.
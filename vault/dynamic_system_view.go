@@ -40,11 +40,14 @@ func (d dynamicSystemView) SudoPrivilege(path string, token string) bool {
 	}
 
 	// Construct the corresponding ACL object
-	acl, err := d.core.policyStore.ACL(te.Policies...)
+	acl, _, err := d.core.tokenACL(te)
 	if err != nil {
 		d.core.logger.Error("failed to retrieve ACL for token's policies", "token_policies", te.Policies, "error", err)
 		return false
 	}
+	if acl == nil {
+		return false
+	}
 
 	// The operation type isn't important here as this is run from a path the
 	// user has already been given access to; we only care about whether they
@@ -52,7 +55,7 @@ func (d dynamicSystemView) SudoPrivilege(path string, token string) bool {
 	req := new(logical.Request)
 	req.Operation = logical.ReadOperation
 	req.Path = path
-	_, rootPrivs := acl.AllowOperation(req)
+	_, rootPrivs := d.core.allowOperation(acl, te, req)
 	return rootPrivs
 }
 
@@ -110,7 +113,7 @@ func (d dynamicSystemView) ResponseWrapData(data map[string]interface{}, ttl tim
 		resp.WrapInfo.Format = "jwt"
 	}
 
-	_, err := d.core.wrapInCubbyhole(req, resp)
+	_, err := d.core.wrapInCubbyhole(req, resp, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -62,9 +62,31 @@ type ExpirationManager struct {
 	pending     map[string]*time.Timer
 	pendingLock sync.Mutex
 
+	// revokeQueue feeds expired leases to the revocation workers, which run
+	// until revokeQuit is closed. Both are guarded by pendingLock, and
+	// revokeQuit is nil while the workers are stopped.
+	revokeQueue chan revokeJob
+	revokeQuit  chan struct{}
+
+	// restoreMode is 1 while leases are being restored in the background.
+	// Until then, a lease that is used before it has been restored is
+	// loaded on demand by loadEntry, which sets up its timer. restoreLocks
+	// serialize the loading of each lease, and restoreLoaded records the
+	// leases that have been loaded. Both are guarded by restoreModeLock.
+	restoreMode     int32
+	restoreModeLock sync.RWMutex
+	restoreLocks    []*locksutil.LockEntry
+	restoreLoaded   *sync.Map
+
 	tidyLock int64
 }
 
+// revokeJob is a queued revocation of an expired lease
+type revokeJob struct {
+	leaseID string
+	attempt uint
+}
+
 // NewExpirationManager creates a new ExpirationManager that is backed
 // using a given view, and uses the provided router for revocation.
 func NewExpirationManager(router *Router, view *BarrierView, ts *TokenStore, logger log.Logger) *ExpirationManager {
@@ -80,6 +102,7 @@ func NewExpirationManager(router *Router, view *BarrierView, ts *TokenStore, log
 		logger:     logger,
 		pending:    make(map[string]*time.Timer),
 	}
+	exp.startRevokeWorkers()
 	return exp
 }
 
@@ -98,11 +121,19 @@ func (c *Core) setupExpiration() error {
 	// Link the token store to this
 	c.tokenStore.SetExpirationManager(mgr)
 
-	// Restore the existing state
+	// Restore the existing state in the background, so that unsealing does
+	// not wait for every lease to be loaded. If restoring fails, the core is
+	// shut down, as leases would otherwise never be revoked.
 	c.logger.Info("expiration: restoring leases")
-	if err := c.expiration.Restore(); err != nil {
-		return fmt.Errorf("expiration state restore failed: %v", err)
+	errorFunc := func() {
+		c.logger.Error("expiration: shutting down")
+		if err := c.Shutdown(); err != nil {
+			c.logger.Error("expiration: error shutting down core", "error", err)
+		}
 	}
+	mgr.enterRestoreMode()
+	go mgr.restore(errorFunc)
+
 	return nil
 }
 
@@ -231,17 +262,55 @@ func (m *ExpirationManager) Tidy() error {
 	return tidyErrors.ErrorOrNil()
 }
 
-// Restore is used to recover the lease states when starting.
-// This is used after starting the vault.
+// Restore is used to recover the lease states when starting, restarting the
+// revocation workers if the manager was stopped. Unlike the restore done on
+// unseal, it returns once all the leases are loaded.
 func (m *ExpirationManager) Restore() error {
 	m.pendingLock.Lock()
-	defer m.pendingLock.Unlock()
+	if m.revokeQuit == nil {
+		m.startRevokeWorkers()
+	}
+	m.pendingLock.Unlock()
+
+	m.enterRestoreMode()
+	return m.restore(nil)
+}
+
+// restore loads all the leases, setting up their revocation timers. It must
+// be called in restore mode, which it leaves when done. It stops early when
+// the manager is stopped. On any other error, errorFunc is called if given.
+func (m *ExpirationManager) restore(errorFunc func()) (retErr error) {
+	defer func() {
+		m.exitRestoreMode()
+
+		switch {
+		case retErr == nil:
+		case errwrap.Contains(retErr, ErrBarrierSealed.Error()):
+			// The core is already sealing, so there is nothing to shut down
+			m.logger.Warn("expiration: barrier sealed while restoring leases, stopping lease loading")
+			retErr = nil
+		default:
+			m.logger.Error("expiration: error restoring leases", "error", retErr)
+			if errorFunc != nil {
+				errorFunc()
+			}
+		}
+	}()
+
+	m.pendingLock.Lock()
+	stopCh := m.revokeQuit
+	m.pendingLock.Unlock()
+
+	// The manager was stopped before restoring started
+	if stopCh == nil {
+		return nil
+	}
 
 	// Accumulate existing leases
 	m.logger.Debug("expiration: collecting leases")
 	existing, err := logical.CollectKeys(m.idView)
 	if err != nil {
-		return fmt.Errorf("failed to scan for leases: %v", err)
+		return errwrap.Wrapf("failed to scan for leases: {{err}}", err)
 	}
 	m.logger.Debug("expiration: leases collected", "num_existing", len(existing))
 
@@ -269,6 +338,8 @@ func (m *ExpirationManager) Restore() error {
 						return
 					}
 
+					// Loading the entry in restore mode sets up its timer,
+					// unless it was already loaded on demand
 					le, err := m.loadEntry(leaseID)
 					if err != nil {
 						errs <- err
@@ -299,8 +370,7 @@ func (m *ExpirationManager) Restore() error {
 			case <-quit:
 				return
 
-			default:
-				broker <- leaseID
+			case broker <- leaseID:
 			}
 		}
 
@@ -308,7 +378,8 @@ func (m *ExpirationManager) Restore() error {
 		close(broker)
 	}()
 
-	// Restore each key by pulling from the result chan
+	// Count the restored leases by pulling from the result chan
+	restored := 0
 	for i := 0; i < len(existing); i++ {
 		select {
 		case err := <-errs:
@@ -317,46 +388,74 @@ func (m *ExpirationManager) Restore() error {
 
 			return err
 
-		case le := <-result:
+		case <-stopCh:
+			// The manager is being stopped
+			close(quit)
+			wg.Wait()
 
-			// If there is no entry, nothing to restore
-			if le == nil {
-				continue
-			}
+			return nil
 
-			// If there is no expiry time, don't do anything
-			if le.ExpireTime.IsZero() {
-				continue
-			}
-
-			// Determine the remaining time to expiration
-			expires := le.ExpireTime.Sub(time.Now())
-			if expires <= 0 {
-				expires = minRevokeDelay
+		case le := <-result:
+			if le != nil {
+				restored++
 			}
-
-			// Setup revocation timer
-			m.pending[le.LeaseID] = time.AfterFunc(expires, func() {
-				m.expireID(le.LeaseID)
-			})
 		}
 	}
 
 	// Let all go routines finish
 	wg.Wait()
 
-	if len(m.pending) > 0 {
+	if restored > 0 {
 		if m.logger.IsInfo() {
-			m.logger.Info("expire: leases restored", "restored_lease_count", len(m.pending))
+			m.logger.Info("expire: leases restored", "restored_lease_count", restored)
 		}
 	}
 
 	return nil
 }
 
+// enterRestoreMode makes leases load on demand until restore finishes
+func (m *ExpirationManager) enterRestoreMode() {
+	m.restoreModeLock.Lock()
+	defer m.restoreModeLock.Unlock()
+
+	m.restoreLocks = locksutil.CreateLocks()
+	m.restoreLoaded = new(sync.Map)
+	atomic.StoreInt32(&m.restoreMode, 1)
+}
+
+// exitRestoreMode is called once all the leases are restored
+func (m *ExpirationManager) exitRestoreMode() {
+	m.restoreModeLock.Lock()
+	defer m.restoreModeLock.Unlock()
+
+	atomic.StoreInt32(&m.restoreMode, 0)
+	m.restoreLocks = nil
+	m.restoreLoaded = nil
+}
+
+// inRestoreMode returns whether leases are still being restored
+func (m *ExpirationManager) inRestoreMode() bool {
+	return atomic.LoadInt32(&m.restoreMode) == 1
+}
+
 // Stop is used to prevent further automatic revocations.
 // This must be called before sealing the view.
 func (m *ExpirationManager) Stop() error {
+	// Stop the revocation workers, which also stops a restore in progress
+	m.pendingLock.Lock()
+	if m.revokeQuit != nil {
+		close(m.revokeQuit)
+		m.revokeQuit = nil
+		m.revokeQueue = nil
+	}
+	m.pendingLock.Unlock()
+
+	// Wait for the restore to finish, so that it sets up no more timers
+	for m.inRestoreMode() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
 	// Stop all the pending expiration timers
 	m.pendingLock.Lock()
 	for _, timer := range m.pending {
@@ -367,6 +466,44 @@ func (m *ExpirationManager) Stop() error {
 	return nil
 }
 
+// startRevokeWorkers starts the pool of workers that revoke expired leases.
+// It must be called with pendingLock held, or before the manager is in use.
+func (m *ExpirationManager) startRevokeWorkers() {
+	queue := make(chan revokeJob, consts.ExpirationRevokeWorkerCount)
+	quit := make(chan struct{})
+	m.revokeQueue = queue
+	m.revokeQuit = quit
+
+	for i := 0; i < consts.ExpirationRevokeWorkerCount; i++ {
+		go func() {
+			for {
+				select {
+				case job := <-queue:
+					m.expireID(job)
+				case <-quit:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// queueRevoke hands an expired lease to the revocation workers, blocking
+// until one is free. It is dropped if the workers are stopped meanwhile.
+func (m *ExpirationManager) queueRevoke(job revokeJob) {
+	m.pendingLock.Lock()
+	queue, quit := m.revokeQueue, m.revokeQuit
+	m.pendingLock.Unlock()
+	if quit == nil {
+		return
+	}
+
+	select {
+	case queue <- job:
+	case <-quit:
+	}
+}
+
 // Revoke is used to revoke a secret named by the given LeaseID
 func (m *ExpirationManager) Revoke(leaseID string) error {
 	defer metrics.MeasureSince([]string{"expire", "revoke"}, time.Now())
@@ -803,7 +940,7 @@ func (m *ExpirationManager) updatePending(le *leaseEntry, leaseTotal time.Durati
 	// Create entry if it does not exist
 	if !ok && leaseTotal > 0 {
 		timer := time.AfterFunc(leaseTotal, func() {
-			m.expireID(le.LeaseID)
+			m.queueRevoke(revokeJob{leaseID: le.LeaseID})
 		})
 		m.pending[le.LeaseID] = timer
 		return
@@ -822,25 +959,47 @@ func (m *ExpirationManager) updatePending(le *leaseEntry, leaseTotal time.Durati
 	}
 }
 
-// expireID is invoked when a given ID is expired
-func (m *ExpirationManager) expireID(leaseID string) {
+// expireID is invoked by a revocation worker when a given ID is expired. A
+// failed revocation is queued again after an exponential backoff, without
+// holding up the worker.
+func (m *ExpirationManager) expireID(job revokeJob) {
+	leaseID := job.leaseID
+
 	// Clear from the pending expiration
 	m.pendingLock.Lock()
 	delete(m.pending, leaseID)
 	m.pendingLock.Unlock()
 
-	for attempt := uint(0); attempt < maxRevokeAttempts; attempt++ {
-		err := m.Revoke(leaseID)
-		if err == nil {
-			if m.logger.IsInfo() {
-				m.logger.Info("expire: revoked lease", "lease_id", leaseID)
-			}
-			return
+	err := m.Revoke(leaseID)
+	if err == nil {
+		if m.logger.IsInfo() {
+			m.logger.Info("expire: revoked lease", "lease_id", leaseID)
 		}
-		m.logger.Error("expire: failed to revoke lease", "lease_id", leaseID, "error", err)
-		time.Sleep((1 << attempt) * revokeRetryBase)
+		return
+	}
+	m.logger.Error("expire: failed to revoke lease", "lease_id", leaseID, "error", err)
+
+	if job.attempt+1 >= maxRevokeAttempts {
+		m.logger.Error("expire: maximum revoke attempts reached", "lease_id", leaseID)
+		return
+	}
+
+	m.pendingLock.Lock()
+	defer m.pendingLock.Unlock()
+
+	// Don't retry if the workers were stopped, or if the lease was renewed
+	// in the meantime and has a new timer
+	if m.revokeQuit == nil {
+		return
+	}
+	if _, ok := m.pending[leaseID]; ok {
+		return
 	}
-	m.logger.Error("expire: maximum revoke attempts reached", "lease_id", leaseID)
+
+	retry := revokeJob{leaseID: leaseID, attempt: job.attempt + 1}
+	m.pending[leaseID] = time.AfterFunc((1<<job.attempt)*revokeRetryBase, func() {
+		m.queueRevoke(retry)
+	})
 }
 
 // revokeEntry is used to attempt revocation of an internal entry
@@ -900,18 +1059,63 @@ func (m *ExpirationManager) renewAuthEntry(req *logical.Request, le *leaseEntry,
 	return resp, nil
 }
 
-// loadEntry is used to read a lease entry
+// loadEntry is used to read a lease entry. While leases are being restored,
+// it also restores the lease if that has not been done yet.
 func (m *ExpirationManager) loadEntry(leaseID string) (*leaseEntry, error) {
+	if !m.inRestoreMode() {
+		return m.loadEntryInternal(leaseID)
+	}
+
+	m.restoreModeLock.RLock()
+	defer m.restoreModeLock.RUnlock()
+
+	// Restore may have finished while waiting for the lock
+	if !m.inRestoreMode() {
+		return m.loadEntryInternal(leaseID)
+	}
+
+	lock := locksutil.LockForKey(m.restoreLocks, leaseID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	le, err := m.loadEntryInternal(leaseID)
+	if err != nil || le == nil {
+		return le, err
+	}
+
+	if _, loaded := m.restoreLoaded.LoadOrStore(le.LeaseID, struct{}{}); loaded {
+		return le, nil
+	}
+
+	// If there is no expiry time, there is no timer to set up
+	if le.ExpireTime.IsZero() {
+		return le, nil
+	}
+
+	// Determine the remaining time to expiration
+	expires := le.ExpireTime.Sub(time.Now())
+	if expires <= 0 {
+		expires = minRevokeDelay
+	}
+
+	// Setup revocation timer
+	m.updatePending(le, expires)
+
+	return le, nil
+}
+
+// loadEntryInternal reads and decodes a lease entry from storage
+func (m *ExpirationManager) loadEntryInternal(leaseID string) (*leaseEntry, error) {
 	out, err := m.idView.Get(leaseID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read lease entry: %v", err)
+		return nil, errwrap.Wrapf("failed to read lease entry: {{err}}", err)
 	}
 	if out == nil {
 		return nil, nil
 	}
 	le, err := decodeLeaseEntry(out.Value)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode lease entry: %v", err)
+		return nil, errwrap.Wrapf("failed to decode lease entry: {{err}}", err)
 	}
 	return le, nil
 }
@@ -1037,8 +1241,15 @@ func (m *ExpirationManager) lookupByToken(token string) ([]string, error) {
 func (m *ExpirationManager) emitMetrics() {
 	m.pendingLock.Lock()
 	num := len(m.pending)
+	var numTokens int
+	for leaseID := range m.pending {
+		if strings.HasPrefix(leaseID, "auth/") {
+			numTokens++
+		}
+	}
 	m.pendingLock.Unlock()
 	metrics.SetGauge([]string{"expire", "num_leases"}, float32(num))
+	metrics.SetGauge([]string{"expire", "num_token_leases"}, float32(numTokens))
 }
 
 // leaseEntry is used to structure the values the expiration
@@ -420,6 +420,80 @@ func TestExpiration_Restore(t *testing.T) {
 	}
 }
 
+func TestExpiration_Restore_Lazy(t *testing.T) {
+	exp := mockExpiration(t)
+	noop := &NoopBackend{}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exp.router.Mount(noop, "prod/aws/", &MountEntry{Path: "prod/aws/", Type: "noop", UUID: meUUID, Accessor: "noop-accessor"}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "prod/aws/foo",
+		ClientToken: "foobar",
+	}
+	resp := &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: 200 * time.Millisecond,
+			},
+		},
+		Data: map[string]interface{}{
+			"access_key": "xyz",
+			"secret_key": "abcd",
+		},
+	}
+	id, err := exp.Register(req, resp)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Stop everything
+	err = exp.Stop()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Pretend a restore is running in the background but has not reached
+	// the lease yet
+	exp.pendingLock.Lock()
+	exp.startRevokeWorkers()
+	exp.pendingLock.Unlock()
+	exp.enterRestoreMode()
+	defer exp.exitRestoreMode()
+
+	// Using the lease restores it
+	if _, err := exp.FetchLeaseTimes(id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure it is reaped
+	start := time.Now()
+	for time.Now().Sub(start) < 2*time.Second {
+		noop.Lock()
+		less := len(noop.Requests) < 1
+		noop.Unlock()
+
+		if less {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		break
+	}
+	noop.Lock()
+	defer noop.Unlock()
+	if len(noop.Requests) != 1 || noop.Requests[0].Operation != logical.RevokeOperation {
+		t.Fatalf("bad: %#v", noop.Requests)
+	}
+}
+
 func TestExpiration_Register(t *testing.T) {
 	exp := mockExpiration(t)
 	req := &logical.Request{
@@ -619,6 +693,105 @@ func TestExpiration_RevokeOnExpire(t *testing.T) {
 	}
 }
 
+// blockingRevokeBackend holds up revocations until release is closed, after
+// announcing each of them on inFlight
+type blockingRevokeBackend struct {
+	*NoopBackend
+	inFlight chan struct{}
+	release  chan struct{}
+}
+
+func (b *blockingRevokeBackend) HandleRequest(req *logical.Request) (*logical.Response, error) {
+	if req.Operation == logical.RevokeOperation {
+		b.inFlight <- struct{}{}
+		<-b.release
+	}
+	return b.NoopBackend.HandleRequest(req)
+}
+
+func TestExpiration_RevokeOnExpire_Parallel(t *testing.T) {
+	exp := mockExpiration(t)
+	noop := &NoopBackend{}
+	backend := &blockingRevokeBackend{
+		NoopBackend: noop,
+		inFlight:    make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exp.router.Mount(backend, "prod/aws/", &MountEntry{Path: "prod/aws/", Type: "noop", UUID: meUUID, Accessor: "noop-accessor"}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{
+		"prod/aws/foo",
+		"prod/aws/sub/bar",
+		"prod/aws/zip",
+	}
+	for _, path := range paths {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: "foobar",
+		}
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: 20 * time.Millisecond,
+				},
+			},
+			Data: map[string]interface{}{
+				"access_key": "xyz",
+				"secret_key": "abcd",
+			},
+		}
+		_, err := exp.Register(req, resp)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// All the revocations must be in flight at once, as none of them
+	// completes until they are released
+	for i := range paths {
+		select {
+		case <-backend.inFlight:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d revocations started", i, len(paths))
+		}
+	}
+	close(backend.release)
+
+	start := time.Now()
+	for time.Now().Sub(start) < time.Second {
+		noop.Lock()
+		less := len(noop.Requests) < len(paths)
+		noop.Unlock()
+
+		if less {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		break
+	}
+
+	noop.Lock()
+	defer noop.Unlock()
+	if len(noop.Requests) != len(paths) {
+		t.Fatalf("bad: %d revocations", len(noop.Requests))
+	}
+	for _, req := range noop.Requests {
+		if req.Operation != logical.RevokeOperation {
+			t.Fatalf("Bad: %v", req)
+		}
+	}
+}
+
 func TestExpiration_RevokePrefix(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
@@ -0,0 +1,505 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// identityEntityPrefix is the storage prefix of entities, which are
+	// stored along with their aliases
+	identityEntityPrefix = "entity/"
+
+	// identityGroupPrefix is the storage prefix of groups, which are stored
+	// along with their alias
+	identityGroupPrefix = "group/"
+)
+
+// IdentityStore is the backend mounted at identity/. It maps the logins from
+// different authentication backends onto entities, and attaches policies to
+// entities directly and through groups.
+//
+// All entities and groups are held in memory and indexed when the backend is
+// initialized; writes go to storage before the indexes are updated.
+type IdentityStore struct {
+	*framework.Backend
+
+	core *Core
+	view logical.Storage
+
+	// lock protects everything below it
+	lock sync.RWMutex
+
+	entities               map[string]*Entity
+	entityIDsByName        map[string]string
+	aliases                map[string]*Alias
+	aliasIDsByFactors      map[string]string
+	groups                 map[string]*Group
+	groupIDsByName         map[string]string
+	groupAliases           map[string]*Alias
+	groupAliasIDsByFactors map[string]string
+}
+
+// NewIdentityStore creates the identity store backend
+func NewIdentityStore(core *Core, config *logical.BackendConfig) (*IdentityStore, error) {
+	if config == nil {
+		return nil, fmt.Errorf("configuration passed into backend is nil")
+	}
+
+	i := &IdentityStore{
+		core: core,
+		view: config.StorageView,
+	}
+	i.resetIndexes()
+
+	i.Backend = &framework.Backend{
+		Help:        strings.TrimSpace(identityStoreHelp),
+		BackendType: logical.TypeLogical,
+		Init:        i.initialize,
+	}
+	i.Backend.Paths = append(i.Backend.Paths, entityPaths(i)...)
+	i.Backend.Paths = append(i.Backend.Paths, aliasPaths(i)...)
+	i.Backend.Paths = append(i.Backend.Paths, groupPaths(i)...)
+
+	i.Backend.Setup(config)
+
+	return i, nil
+}
+
+func (i *IdentityStore) resetIndexes() {
+	i.entities = make(map[string]*Entity)
+	i.entityIDsByName = make(map[string]string)
+	i.aliases = make(map[string]*Alias)
+	i.aliasIDsByFactors = make(map[string]string)
+	i.groups = make(map[string]*Group)
+	i.groupIDsByName = make(map[string]string)
+	i.groupAliases = make(map[string]*Alias)
+	i.groupAliasIDsByFactors = make(map[string]string)
+}
+
+// initialize loads all entities and groups from storage and indexes them
+func (i *IdentityStore) initialize() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.resetIndexes()
+
+	entityIDs, err := i.view.List(identityEntityPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list entities: {{err}}", err)
+	}
+	for _, id := range entityIDs {
+		raw, err := i.view.Get(identityEntityPrefix + id)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to read entity %q: {{err}}", id), err)
+		}
+		if raw == nil {
+			continue
+		}
+		var entity Entity
+		if err := jsonutil.DecodeJSON(raw.Value, &entity); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to decode entity %q: {{err}}", id), err)
+		}
+		i.indexEntity(&entity)
+	}
+
+	groupIDs, err := i.view.List(identityGroupPrefix)
+	if err != nil {
+		return errwrap.Wrapf("failed to list groups: {{err}}", err)
+	}
+	for _, id := range groupIDs {
+		raw, err := i.view.Get(identityGroupPrefix + id)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to read group %q: {{err}}", id), err)
+		}
+		if raw == nil {
+			continue
+		}
+		var group Group
+		if err := jsonutil.DecodeJSON(raw.Value, &group); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("failed to decode group %q: {{err}}", id), err)
+		}
+		i.indexGroup(&group)
+	}
+
+	return nil
+}
+
+// indexEntity adds the entity and its aliases to the indexes. The lock must
+// be held.
+func (i *IdentityStore) indexEntity(entity *Entity) {
+	i.entities[entity.ID] = entity
+	i.entityIDsByName[entity.Name] = entity.ID
+	for _, alias := range entity.Aliases {
+		i.aliases[alias.ID] = alias
+		i.aliasIDsByFactors[aliasFactors(alias.MountAccessor, alias.Name)] = alias.ID
+	}
+}
+
+// unindexEntity removes the entity and its aliases from the indexes. The lock
+// must be held.
+func (i *IdentityStore) unindexEntity(entity *Entity) {
+	delete(i.entities, entity.ID)
+	delete(i.entityIDsByName, entity.Name)
+	for _, alias := range entity.Aliases {
+		delete(i.aliases, alias.ID)
+		delete(i.aliasIDsByFactors, aliasFactors(alias.MountAccessor, alias.Name))
+	}
+}
+
+// indexGroup adds the group and its alias to the indexes. The lock must be
+// held.
+func (i *IdentityStore) indexGroup(group *Group) {
+	i.groups[group.ID] = group
+	i.groupIDsByName[group.Name] = group.ID
+	if group.Alias != nil {
+		i.groupAliases[group.Alias.ID] = group.Alias
+		i.groupAliasIDsByFactors[aliasFactors(group.Alias.MountAccessor, group.Alias.Name)] = group.Alias.ID
+	}
+}
+
+// unindexGroup removes the group and its alias from the indexes. The lock
+// must be held.
+func (i *IdentityStore) unindexGroup(group *Group) {
+	delete(i.groups, group.ID)
+	delete(i.groupIDsByName, group.Name)
+	if group.Alias != nil {
+		delete(i.groupAliases, group.Alias.ID)
+		delete(i.groupAliasIDsByFactors, aliasFactors(group.Alias.MountAccessor, group.Alias.Name))
+	}
+}
+
+// upsertEntity persists the entity and replaces any previous version of it in
+// the indexes. The lock must be held.
+func (i *IdentityStore) upsertEntity(entity *Entity) error {
+	entity.LastUpdateTime = time.Now()
+
+	entry, err := logical.StorageEntryJSON(identityEntityPrefix+entity.ID, entity)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode entity: {{err}}", err)
+	}
+	if err := i.view.Put(entry); err != nil {
+		return errwrap.Wrapf("failed to persist entity: {{err}}", err)
+	}
+
+	if existing, ok := i.entities[entity.ID]; ok {
+		i.unindexEntity(existing)
+	}
+	i.indexEntity(entity)
+	return nil
+}
+
+// upsertGroup persists the group and replaces any previous version of it in
+// the indexes. The lock must be held.
+func (i *IdentityStore) upsertGroup(group *Group) error {
+	group.LastUpdateTime = time.Now()
+
+	entry, err := logical.StorageEntryJSON(identityGroupPrefix+group.ID, group)
+	if err != nil {
+		return errwrap.Wrapf("failed to encode group: {{err}}", err)
+	}
+	if err := i.view.Put(entry); err != nil {
+		return errwrap.Wrapf("failed to persist group: {{err}}", err)
+	}
+
+	if existing, ok := i.groups[group.ID]; ok {
+		i.unindexGroup(existing)
+	}
+	i.indexGroup(group)
+	return nil
+}
+
+// deleteEntity removes the entity from storage, the indexes and the groups it
+// is a member of. The lock must be held.
+func (i *IdentityStore) deleteEntity(entity *Entity) error {
+	for _, group := range i.groups {
+		if !strutil.StrListContains(group.MemberEntityIDs, entity.ID) {
+			continue
+		}
+		group = group.Clone()
+		group.MemberEntityIDs = strutil.StrListDelete(group.MemberEntityIDs, entity.ID)
+		if err := i.upsertGroup(group); err != nil {
+			return err
+		}
+	}
+
+	if err := i.view.Delete(identityEntityPrefix + entity.ID); err != nil {
+		return errwrap.Wrapf("failed to delete entity: {{err}}", err)
+	}
+	i.unindexEntity(entity)
+	return nil
+}
+
+// deleteGroup removes the group from storage and the indexes. The lock must be
+// held.
+func (i *IdentityStore) deleteGroup(group *Group) error {
+	if err := i.view.Delete(identityGroupPrefix + group.ID); err != nil {
+		return errwrap.Wrapf("failed to delete group: {{err}}", err)
+	}
+	i.unindexGroup(group)
+	return nil
+}
+
+// CreateOrFetchEntity returns the entity that the given login persona is an
+// alias of. If there is none, a new entity is created for it. The persona's
+// mount accessor and type must be set.
+func (i *IdentityStore) CreateOrFetchEntity(persona *logical.Persona) (*Entity, error) {
+	if persona == nil {
+		return nil, fmt.Errorf("missing persona")
+	}
+	if persona.Name == "" {
+		return nil, fmt.Errorf("missing persona name")
+	}
+	if persona.MountAccessor == "" {
+		return nil, fmt.Errorf("missing persona mount accessor")
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if aliasID, ok := i.aliasIDsByFactors[aliasFactors(persona.MountAccessor, persona.Name)]; ok {
+		entity, ok := i.entities[i.aliases[aliasID].CanonicalID]
+		if !ok {
+			return nil, fmt.Errorf("entity of alias %q not found", aliasID)
+		}
+		return entity.Clone(), nil
+	}
+
+	entity, err := i.newEntity("")
+	if err != nil {
+		return nil, err
+	}
+	alias, err := newAlias(entity.ID, persona.MountAccessor, persona.MountType, persona.Name)
+	if err != nil {
+		return nil, err
+	}
+	entity.Aliases = append(entity.Aliases, alias)
+
+	if err := i.upsertEntity(entity); err != nil {
+		return nil, err
+	}
+
+	return entity.Clone(), nil
+}
+
+// refreshExternalGroupMemberships updates the membership of the entity in
+// the external groups tied to the given mount, so that it is a member of
+// those matching the group personas returned on login and of no others.
+func (i *IdentityStore) refreshExternalGroupMemberships(mountAccessor, entityID string, groupPersonas []*logical.Persona) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	wanted := make(map[string]struct{}, len(groupPersonas))
+	for _, persona := range groupPersonas {
+		if persona == nil {
+			continue
+		}
+		if aliasID, ok := i.groupAliasIDsByFactors[aliasFactors(mountAccessor, persona.Name)]; ok {
+			wanted[i.groupAliases[aliasID].CanonicalID] = struct{}{}
+		}
+	}
+
+	for _, group := range i.groups {
+		if group.Type != groupTypeExternal || group.Alias == nil || group.Alias.MountAccessor != mountAccessor {
+			continue
+		}
+
+		_, shouldBeMember := wanted[group.ID]
+		isMember := strutil.StrListContains(group.MemberEntityIDs, entityID)
+		if shouldBeMember == isMember {
+			continue
+		}
+
+		group = group.Clone()
+		if shouldBeMember {
+			group.MemberEntityIDs = append(group.MemberEntityIDs, entityID)
+		} else {
+			group.MemberEntityIDs = strutil.StrListDelete(group.MemberEntityIDs, entityID)
+		}
+		if err := i.upsertGroup(group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// policiesByEntityID returns the policies attached to the entity, directly
+// and through the groups it is a member of
+func (i *IdentityStore) policiesByEntityID(entityID string) []string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	entity, ok := i.entities[entityID]
+	if !ok {
+		return nil
+	}
+
+	var policies []string
+	policies = append(policies, entity.Policies...)
+	for _, group := range i.groups {
+		if strutil.StrListContains(group.MemberEntityIDs, entityID) {
+			policies = append(policies, group.Policies...)
+		}
+	}
+
+	return strutil.RemoveDuplicates(policies, false)
+}
+
+// entityInGroupNames returns whether the entity is a member of any of the
+// groups with the given names
+func (i *IdentityStore) entityInGroupNames(entityID string, groupNames []string) bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	for _, name := range groupNames {
+		group, ok := i.groups[i.groupIDsByName[name]]
+		if ok && strutil.StrListContains(group.MemberEntityIDs, entityID) {
+			return true
+		}
+	}
+	return false
+}
+
+// entityName returns the name of the entity, or an empty string if it does
+// not exist
+func (i *IdentityStore) entityName(entityID string) string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if entity, ok := i.entities[entityID]; ok {
+		return entity.Name
+	}
+	return ""
+}
+
+// entityByID returns a copy of the entity with the given ID, or nil if there
+// is no such entity
+func (i *IdentityStore) entityByID(entityID string) *Entity {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if entity, ok := i.entities[entityID]; ok {
+		return entity.Clone()
+	}
+	return nil
+}
+
+// groupIDsByEntityID returns the IDs of the groups the entity is a member of.
+// The lock must be held.
+func (i *IdentityStore) groupIDsByEntityID(entityID string) []string {
+	groupIDs := []string{}
+	for _, group := range i.groups {
+		if strutil.StrListContains(group.MemberEntityIDs, entityID) {
+			groupIDs = append(groupIDs, group.ID)
+		}
+	}
+	return strutil.RemoveDuplicates(groupIDs, false)
+}
+
+// newEntity returns a new entity with the given name, or a generated one if
+// the name is empty. It is not persisted. The lock must be held.
+func (i *IdentityStore) newEntity(name string) (*Entity, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate entity ID: {{err}}", err)
+	}
+	if name == "" {
+		name = "entity_" + id[:8]
+	}
+	if _, ok := i.entityIDsByName[name]; ok {
+		return nil, fmt.Errorf("entity name %q is already in use", name)
+	}
+
+	now := time.Now()
+	return &Entity{
+		ID:             id,
+		Name:           name,
+		CreationTime:   now,
+		LastUpdateTime: now,
+	}, nil
+}
+
+func newAlias(canonicalID, mountAccessor, mountType, name string) (*Alias, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to generate alias ID: {{err}}", err)
+	}
+
+	now := time.Now()
+	return &Alias{
+		ID:             id,
+		CanonicalID:    canonicalID,
+		MountAccessor:  mountAccessor,
+		MountType:      mountType,
+		Name:           name,
+		CreationTime:   now,
+		LastUpdateTime: now,
+	}, nil
+}
+
+// validateAuthMountAccessor returns the type of the authentication backend
+// mounted with the given accessor
+func (i *IdentityStore) validateAuthMountAccessor(mountAccessor string) (string, error) {
+	entry := i.core.router.MatchingMountByAccessor(mountAccessor)
+	if entry == nil || entry.Accessor != mountAccessor || entry.Table != credentialTableType {
+		return "", fmt.Errorf("invalid mount accessor %q", mountAccessor)
+	}
+	if entry.Type == "token" {
+		return "", fmt.Errorf("aliases cannot be tied to the token store")
+	}
+	return entry.Type, nil
+}
+
+// parseIdentityMetadata converts the raw metadata of a request into a string
+// map
+func parseIdentityMetadata(raw map[string]interface{}) (map[string]string, error) {
+	metadata := make(map[string]string, len(raw))
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of metadata key %q must be a string", k)
+		}
+		metadata[k] = s
+	}
+	return metadata, nil
+}
+
+// validateIdentityPolicies ensures the policies can be attached to entities
+// and groups
+func validateIdentityPolicies(policies []string) error {
+	for _, policy := range policies {
+		if policy == "root" || strutil.StrListContains(nonAssignablePolicies, policy) {
+			return fmt.Errorf("cannot assign policy %q", policy)
+		}
+	}
+	return nil
+}
+
+func aliasResponseData(alias *Alias) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               alias.ID,
+		"canonical_id":     alias.CanonicalID,
+		"mount_accessor":   alias.MountAccessor,
+		"mount_type":       alias.MountType,
+		"name":             alias.Name,
+		"metadata":         alias.Metadata,
+		"creation_time":    alias.CreationTime,
+		"last_update_time": alias.LastUpdateTime,
+	}
+}
+
+const identityStoreHelp = `
+The identity store maps the logins of a client through different
+authentication backends onto a single entity. Policies can be attached to
+entities directly, and to groups of entities.
+`
@@ -0,0 +1,241 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func aliasPathFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the alias. If set, updates the corresponding existing alias.",
+		},
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the alias, as reported by the authentication backend on login, such as the username.",
+		},
+		"mount_accessor": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Accessor of the authentication backend mount the alias belongs to.",
+		},
+		"canonical_id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the entity the alias belongs to.",
+		},
+		"metadata": &framework.FieldSchema{
+			Type:        framework.TypeMap,
+			Description: "Metadata to be associated with the alias, as string key-value pairs.",
+		},
+	}
+}
+
+func aliasPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "entity-alias$",
+			Fields:  aliasPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathAliasRegister,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(aliasHelp["alias"][0]),
+			HelpDescription: strings.TrimSpace(aliasHelp["alias"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity-alias/id/" + framework.GenericNameRegex("id"),
+			Fields:  aliasPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathAliasRegister,
+				logical.ReadOperation:   i.pathAliasIDRead,
+				logical.DeleteOperation: i.pathAliasIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(aliasHelp["alias-id"][0]),
+			HelpDescription: strings.TrimSpace(aliasHelp["alias-id"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity-alias/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathAliasIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(aliasHelp["alias-id-list"][0]),
+			HelpDescription: strings.TrimSpace(aliasHelp["alias-id-list"][1]),
+		},
+	}
+}
+
+// pathAliasRegister creates a new entity alias, or updates the existing one
+// if an ID is given. Updating the canonical ID moves the alias to another
+// entity.
+func (i *IdentityStore) pathAliasRegister(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	var alias *Alias
+	if id := d.Get("id").(string); id != "" {
+		existing, ok := i.aliases[id]
+		if !ok {
+			return logical.ErrorResponse(fmt.Sprintf("alias %q not found", id)), nil
+		}
+		alias = existing.Clone()
+	} else {
+		for _, field := range []string{"name", "mount_accessor", "canonical_id"} {
+			if d.Get(field).(string) == "" {
+				return logical.ErrorResponse(fmt.Sprintf("missing %s", field)), nil
+			}
+		}
+
+		var err error
+		alias, err = newAlias("", "", "", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	previousEntityID := alias.CanonicalID
+
+	if name := d.Get("name").(string); name != "" {
+		alias.Name = name
+	}
+	if mountAccessor := d.Get("mount_accessor").(string); mountAccessor != "" {
+		mountType, err := i.validateAuthMountAccessor(mountAccessor)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		alias.MountAccessor = mountAccessor
+		alias.MountType = mountType
+	}
+	if canonicalID := d.Get("canonical_id").(string); canonicalID != "" {
+		alias.CanonicalID = canonicalID
+	}
+	if metadataRaw, ok := d.GetOk("metadata"); ok {
+		metadata, err := parseIdentityMetadata(metadataRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		alias.Metadata = metadata
+	}
+
+	if otherID, ok := i.aliasIDsByFactors[aliasFactors(alias.MountAccessor, alias.Name)]; ok && otherID != alias.ID {
+		return logical.ErrorResponse(fmt.Sprintf("an alias named %q already exists for mount accessor %q", alias.Name, alias.MountAccessor)), nil
+	}
+
+	existingEntity, ok := i.entities[alias.CanonicalID]
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("entity %q not found", alias.CanonicalID)), nil
+	}
+	entity := existingEntity.Clone()
+
+	// Detach the alias from the entity it previously belonged to
+	if previousEntityID != "" && previousEntityID != entity.ID {
+		if previous, ok := i.entities[previousEntityID]; ok {
+			previous = previous.Clone()
+			previous.Aliases = removeAlias(previous.Aliases, alias.ID)
+			if err := i.upsertEntity(previous); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	entity.Aliases = append(removeAlias(entity.Aliases, alias.ID), alias)
+	if err := i.upsertEntity(entity); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":           alias.ID,
+			"canonical_id": alias.CanonicalID,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathAliasIDRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	alias, ok := i.aliases[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: aliasResponseData(alias),
+	}, nil
+}
+
+func (i *IdentityStore) pathAliasIDDelete(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	alias, ok := i.aliases[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	existingEntity, ok := i.entities[alias.CanonicalID]
+	if !ok {
+		return nil, fmt.Errorf("entity of alias %q not found", alias.ID)
+	}
+	entity := existingEntity.Clone()
+	entity.Aliases = removeAlias(entity.Aliases, alias.ID)
+
+	return nil, i.upsertEntity(entity)
+}
+
+func (i *IdentityStore) pathAliasIDList(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	ids := make([]string, 0, len(i.aliases))
+	for id := range i.aliases {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return logical.ListResponse(ids), nil
+}
+
+// removeAlias returns the aliases without the one with the given ID
+func removeAlias(aliases []*Alias, id string) []*Alias {
+	ret := make([]*Alias, 0, len(aliases))
+	for _, alias := range aliases {
+		if alias.ID != id {
+			ret = append(ret, alias)
+		}
+	}
+	return ret
+}
+
+var aliasHelp = map[string][2]string{
+	"alias": {
+		"Create a new entity alias, or update an existing one.",
+		`
+An alias ties the identity of a client in an authentication backend, given by
+the accessor of the backend's mount and the name the backend reports on
+login, to an entity. Aliases are created automatically on the first login
+through a backend that reports the client's identity; creating them ahead of
+time maps logins from several backends onto the same entity.
+
+If an ID is given, the existing alias is updated; setting canonical_id moves
+the alias to another entity.
+`,
+	},
+	"alias-id": {
+		"Read, update or delete an entity alias by its ID.",
+		"",
+	},
+	"alias-id-list": {
+		"List the IDs of all entity aliases.",
+		"",
+	},
+}
@@ -0,0 +1,210 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func entityPathFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the entity. If set, updates the corresponding existing entity.",
+		},
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the entity. Generated if not set on creation.",
+		},
+		"metadata": &framework.FieldSchema{
+			Type:        framework.TypeMap,
+			Description: "Metadata to be associated with the entity, as string key-value pairs.",
+		},
+		"policies": &framework.FieldSchema{
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Policies to be tied to the entity.",
+		},
+	}
+}
+
+func entityPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "entity$",
+			Fields:  entityPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathEntityRegister,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity/id/" + framework.GenericNameRegex("id"),
+			Fields:  entityPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathEntityRegister,
+				logical.ReadOperation:   i.pathEntityIDRead,
+				logical.DeleteOperation: i.pathEntityIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-id"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-id"][1]),
+		},
+		&framework.Path{
+			Pattern: "entity/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathEntityIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(entityHelp["entity-id-list"][0]),
+			HelpDescription: strings.TrimSpace(entityHelp["entity-id-list"][1]),
+		},
+	}
+}
+
+// pathEntityRegister creates a new entity, or updates the existing one if an
+// ID is given
+func (i *IdentityStore) pathEntityRegister(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	var entity *Entity
+	if id := d.Get("id").(string); id != "" {
+		existing, ok := i.entities[id]
+		if !ok {
+			return logical.ErrorResponse(fmt.Sprintf("entity %q not found", id)), nil
+		}
+		entity = existing.Clone()
+
+		if nameRaw, ok := d.GetOk("name"); ok {
+			name := nameRaw.(string)
+			if otherID, ok := i.entityIDsByName[name]; ok && otherID != entity.ID {
+				return logical.ErrorResponse(fmt.Sprintf("entity name %q is already in use", name)), nil
+			}
+			if name != "" {
+				entity.Name = name
+			}
+		}
+	} else {
+		var err error
+		entity, err = i.newEntity(d.Get("name").(string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
+	if metadataRaw, ok := d.GetOk("metadata"); ok {
+		metadata, err := parseIdentityMetadata(metadataRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		entity.Metadata = metadata
+	}
+
+	if policiesRaw, ok := d.GetOk("policies"); ok {
+		policies := policiesRaw.([]string)
+		if err := validateIdentityPolicies(policies); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		entity.Policies = policies
+	}
+
+	if err := i.upsertEntity(entity); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   entity.ID,
+			"name": entity.Name,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathEntityIDRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	entity, ok := i.entities[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	aliases := make([]interface{}, 0, len(entity.Aliases))
+	for _, alias := range entity.Aliases {
+		aliases = append(aliases, aliasResponseData(alias))
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":               entity.ID,
+			"name":             entity.Name,
+			"metadata":         entity.Metadata,
+			"policies":         entity.Policies,
+			"aliases":          aliases,
+			"group_ids":        i.groupIDsByEntityID(entity.ID),
+			"creation_time":    entity.CreationTime,
+			"last_update_time": entity.LastUpdateTime,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathEntityIDDelete(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	entity, ok := i.entities[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	return nil, i.deleteEntity(entity)
+}
+
+func (i *IdentityStore) pathEntityIDList(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	ids := make([]string, 0, len(i.entities))
+	for id := range i.entities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return logical.ListResponse(ids), nil
+}
+
+var entityHelp = map[string][2]string{
+	"entity": {
+		"Create a new entity, or update an existing one.",
+		`
+Entities represent the clients of Vault. Logins from different authentication
+backends are mapped onto an entity through its aliases. The policies of an
+entity, and of the groups it is a member of, are added to those of the tokens
+issued on login.
+
+If an ID is given, the existing entity is updated. Otherwise a new entity is
+created, with a generated name if none is given.
+`,
+	},
+	"entity-id": {
+		"Read, update or delete an entity by its ID.",
+		`
+Reading an entity returns its aliases and the IDs of the groups it is a
+member of. Deleting an entity also deletes its aliases and removes it from
+its groups.
+`,
+	},
+	"entity-id-list": {
+		"List the IDs of all entities.",
+		"",
+	},
+}
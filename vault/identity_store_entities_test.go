@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestIdentityStore_EntityCRUD(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	resp := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", map[string]interface{}{
+		"name":     "armon",
+		"policies": "foo,bar",
+		"metadata": map[string]interface{}{
+			"team": "core",
+		},
+	})
+	id := resp.Data["id"].(string)
+	if resp.Data["name"] != "armon" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity/id/"+id, nil)
+	if resp.Data["name"] != "armon" ||
+		!reflect.DeepEqual(resp.Data["policies"], []string{"foo", "bar"}) ||
+		!reflect.DeepEqual(resp.Data["metadata"], map[string]string{"team": "core"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Names are unique
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity", map[string]interface{}{
+		"name": "armon",
+	})
+
+	// Entities can be updated by ID, leaving unset fields alone
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "entity/id/"+id, map[string]interface{}{
+		"policies": "baz",
+	})
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity/id/"+id, nil)
+	if resp.Data["name"] != "armon" || !reflect.DeepEqual(resp.Data["policies"], []string{"baz"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// A name is generated if none is given
+	resp = testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil)
+	if resp.Data["name"] == "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = testIdentityRequest(t, c, root, logical.ListOperation, "entity/id/", nil)
+	if len(resp.Data["keys"].([]string)) != 2 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity/id/"+id, map[string]interface{}{
+		"policies": "root",
+	})
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity/id/missing", map[string]interface{}{
+		"policies": "foo",
+	})
+
+	testIdentityRequest(t, c, root, logical.DeleteOperation, "entity/id/"+id, nil)
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity/id/"+id, nil)
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The name is free again
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", map[string]interface{}{
+		"name": "armon",
+	})
+}
+
+func TestIdentityStore_EntityAliases(t *testing.T) {
+	noop := &NoopBackend{}
+	c, root, accessor := testIdentityCoreWithNoopAuth(t, noop)
+
+	entity1 := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+	entity2 := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+
+	resp := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity-alias", map[string]interface{}{
+		"name":           "armon",
+		"mount_accessor": accessor,
+		"canonical_id":   entity1,
+	})
+	aliasID := resp.Data["id"].(string)
+
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity-alias/id/"+aliasID, nil)
+	if resp.Data["canonical_id"] != entity1 || resp.Data["mount_type"] != "noop" || resp.Data["name"] != "armon" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Logins through the alias map onto the entity
+	entity, err := c.identityStore.CreateOrFetchEntity(&logical.Persona{
+		MountAccessor: accessor,
+		Name:          "armon",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.ID != entity1 {
+		t.Fatalf("expected entity %q, got %q", entity1, entity.ID)
+	}
+
+	// Aliases are unique per mount
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity-alias", map[string]interface{}{
+		"name":           "armon",
+		"mount_accessor": accessor,
+		"canonical_id":   entity2,
+	})
+
+	// Aliases must belong to an auth mount and an existing entity
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity-alias", map[string]interface{}{
+		"name":           "jeff",
+		"mount_accessor": "auth_noop_missing",
+		"canonical_id":   entity1,
+	})
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity-alias", map[string]interface{}{
+		"name":           "jeff",
+		"mount_accessor": c.router.MatchingMountEntry("secret/").Accessor,
+		"canonical_id":   entity1,
+	})
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity-alias", map[string]interface{}{
+		"name":           "jeff",
+		"mount_accessor": accessor,
+		"canonical_id":   "missing",
+	})
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "entity-alias", map[string]interface{}{
+		"name":           "jeff",
+		"mount_accessor": accessor,
+	})
+
+	// Moving an alias moves it between entities
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "entity-alias/id/"+aliasID, map[string]interface{}{
+		"canonical_id": entity2,
+	})
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity/id/"+entity1, nil)
+	if len(resp.Data["aliases"].([]interface{})) != 0 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity/id/"+entity2, nil)
+	if len(resp.Data["aliases"].([]interface{})) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = testIdentityRequest(t, c, root, logical.ListOperation, "entity-alias/id/", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{aliasID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Deleting an entity deletes its aliases
+	testIdentityRequest(t, c, root, logical.DeleteOperation, "entity/id/"+entity2, nil)
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity-alias/id/"+aliasID, nil)
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
@@ -0,0 +1,453 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+func groupPathFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the group. If set, updates the corresponding existing group.",
+		},
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the group. Generated if not set on creation.",
+		},
+		"type": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Type of the group, 'internal' or 'external'. Defaults to 'internal'.",
+		},
+		"metadata": &framework.FieldSchema{
+			Type:        framework.TypeMap,
+			Description: "Metadata to be associated with the group, as string key-value pairs.",
+		},
+		"policies": &framework.FieldSchema{
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Policies to be tied to the group.",
+		},
+		"member_entity_ids": &framework.FieldSchema{
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Entity IDs to be assigned as group members. Not allowed on external groups.",
+		},
+	}
+}
+
+func groupAliasPathFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the group alias. If set, updates the corresponding existing group alias.",
+		},
+		"name": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Name of the group alias, as reported by the authentication backend on login.",
+		},
+		"mount_accessor": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "Accessor of the authentication backend mount the group alias belongs to.",
+		},
+		"canonical_id": &framework.FieldSchema{
+			Type:        framework.TypeString,
+			Description: "ID of the external group the alias belongs to.",
+		},
+	}
+}
+
+func groupPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "group$",
+			Fields:  groupPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathGroupRegister,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group"][1]),
+		},
+		&framework.Path{
+			Pattern: "group/id/" + framework.GenericNameRegex("id"),
+			Fields:  groupPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathGroupRegister,
+				logical.ReadOperation:   i.pathGroupIDRead,
+				logical.DeleteOperation: i.pathGroupIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-id"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-id"][1]),
+		},
+		&framework.Path{
+			Pattern: "group/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathGroupIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-id-list"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-id-list"][1]),
+		},
+		&framework.Path{
+			Pattern: "group-alias$",
+			Fields:  groupAliasPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathGroupAliasRegister,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-alias"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-alias"][1]),
+		},
+		&framework.Path{
+			Pattern: "group-alias/id/" + framework.GenericNameRegex("id"),
+			Fields:  groupAliasPathFields(),
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: i.pathGroupAliasRegister,
+				logical.ReadOperation:   i.pathGroupAliasIDRead,
+				logical.DeleteOperation: i.pathGroupAliasIDDelete,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-alias-id"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-alias-id"][1]),
+		},
+		&framework.Path{
+			Pattern: "group-alias/id/?$",
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ListOperation: i.pathGroupAliasIDList,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupHelp["group-alias-id-list"][0]),
+			HelpDescription: strings.TrimSpace(groupHelp["group-alias-id-list"][1]),
+		},
+	}
+}
+
+// pathGroupRegister creates a new group, or updates the existing one if an ID
+// is given
+func (i *IdentityStore) pathGroupRegister(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	var group *Group
+	if id := d.Get("id").(string); id != "" {
+		existing, ok := i.groups[id]
+		if !ok {
+			return logical.ErrorResponse(fmt.Sprintf("group %q not found", id)), nil
+		}
+		group = existing.Clone()
+
+		if groupType := d.Get("type").(string); groupType != "" && groupType != group.Type {
+			return logical.ErrorResponse("group type cannot be changed"), nil
+		}
+	} else {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+
+		groupType := d.Get("type").(string)
+		switch groupType {
+		case "":
+			groupType = groupTypeInternal
+		case groupTypeInternal, groupTypeExternal:
+		default:
+			return logical.ErrorResponse(fmt.Sprintf("invalid group type %q", groupType)), nil
+		}
+
+		now := time.Now()
+		group = &Group{
+			ID:             id,
+			Name:           "group_" + id[:8],
+			Type:           groupType,
+			CreationTime:   now,
+			LastUpdateTime: now,
+		}
+	}
+
+	if name := d.Get("name").(string); name != "" {
+		if otherID, ok := i.groupIDsByName[name]; ok && otherID != group.ID {
+			return logical.ErrorResponse(fmt.Sprintf("group name %q is already in use", name)), nil
+		}
+		group.Name = name
+	}
+
+	if metadataRaw, ok := d.GetOk("metadata"); ok {
+		metadata, err := parseIdentityMetadata(metadataRaw.(map[string]interface{}))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		group.Metadata = metadata
+	}
+
+	if policiesRaw, ok := d.GetOk("policies"); ok {
+		policies := policiesRaw.([]string)
+		if err := validateIdentityPolicies(policies); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		group.Policies = policies
+	}
+
+	if memberEntityIDsRaw, ok := d.GetOk("member_entity_ids"); ok {
+		if group.Type == groupTypeExternal {
+			return logical.ErrorResponse("members of external groups are managed by their authentication backend"), nil
+		}
+		memberEntityIDs := strutil.RemoveDuplicates(memberEntityIDsRaw.([]string), false)
+		for _, entityID := range memberEntityIDs {
+			if _, ok := i.entities[entityID]; !ok {
+				return logical.ErrorResponse(fmt.Sprintf("entity %q not found", entityID)), nil
+			}
+		}
+		group.MemberEntityIDs = memberEntityIDs
+	}
+
+	if err := i.upsertGroup(group); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   group.ID,
+			"name": group.Name,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathGroupIDRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	group, ok := i.groups[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"id":                group.ID,
+			"name":              group.Name,
+			"type":              group.Type,
+			"metadata":          group.Metadata,
+			"policies":          group.Policies,
+			"member_entity_ids": group.MemberEntityIDs,
+			"alias":             map[string]interface{}{},
+			"creation_time":     group.CreationTime,
+			"last_update_time":  group.LastUpdateTime,
+		},
+	}
+	if group.Alias != nil {
+		resp.Data["alias"] = aliasResponseData(group.Alias)
+	}
+
+	return resp, nil
+}
+
+func (i *IdentityStore) pathGroupIDDelete(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	group, ok := i.groups[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	return nil, i.deleteGroup(group)
+}
+
+func (i *IdentityStore) pathGroupIDList(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	ids := make([]string, 0, len(i.groups))
+	for id := range i.groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return logical.ListResponse(ids), nil
+}
+
+// pathGroupAliasRegister creates the alias of an external group, or updates
+// the existing one if an ID is given
+func (i *IdentityStore) pathGroupAliasRegister(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	var alias *Alias
+	if id := d.Get("id").(string); id != "" {
+		existing, ok := i.groupAliases[id]
+		if !ok {
+			return logical.ErrorResponse(fmt.Sprintf("group alias %q not found", id)), nil
+		}
+		alias = existing.Clone()
+	} else {
+		for _, field := range []string{"name", "mount_accessor", "canonical_id"} {
+			if d.Get(field).(string) == "" {
+				return logical.ErrorResponse(fmt.Sprintf("missing %s", field)), nil
+			}
+		}
+
+		var err error
+		alias, err = newAlias("", "", "", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	previousGroupID := alias.CanonicalID
+
+	if name := d.Get("name").(string); name != "" {
+		alias.Name = name
+	}
+	if mountAccessor := d.Get("mount_accessor").(string); mountAccessor != "" {
+		mountType, err := i.validateAuthMountAccessor(mountAccessor)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		alias.MountAccessor = mountAccessor
+		alias.MountType = mountType
+	}
+	if canonicalID := d.Get("canonical_id").(string); canonicalID != "" {
+		alias.CanonicalID = canonicalID
+	}
+
+	if otherID, ok := i.groupAliasIDsByFactors[aliasFactors(alias.MountAccessor, alias.Name)]; ok && otherID != alias.ID {
+		return logical.ErrorResponse(fmt.Sprintf("a group alias named %q already exists for mount accessor %q", alias.Name, alias.MountAccessor)), nil
+	}
+
+	existingGroup, ok := i.groups[alias.CanonicalID]
+	if !ok {
+		return logical.ErrorResponse(fmt.Sprintf("group %q not found", alias.CanonicalID)), nil
+	}
+	if existingGroup.Type != groupTypeExternal {
+		return logical.ErrorResponse("aliases can only be tied to external groups"), nil
+	}
+	if existingGroup.Alias != nil && existingGroup.Alias.ID != alias.ID {
+		return logical.ErrorResponse(fmt.Sprintf("group %q already has an alias", existingGroup.ID)), nil
+	}
+	group := existingGroup.Clone()
+
+	// Detach the alias from the group it previously belonged to. Its members
+	// came from the alias, so they go with it.
+	if previousGroupID != "" && previousGroupID != group.ID {
+		if previous, ok := i.groups[previousGroupID]; ok {
+			previous = previous.Clone()
+			previous.Alias = nil
+			previous.MemberEntityIDs = nil
+			if err := i.upsertGroup(previous); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	group.Alias = alias
+	if err := i.upsertGroup(group); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":           alias.ID,
+			"canonical_id": alias.CanonicalID,
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathGroupAliasIDRead(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	alias, ok := i.groupAliases[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: aliasResponseData(alias),
+	}, nil
+}
+
+func (i *IdentityStore) pathGroupAliasIDDelete(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	alias, ok := i.groupAliases[d.Get("id").(string)]
+	if !ok {
+		return nil, nil
+	}
+
+	existingGroup, ok := i.groups[alias.CanonicalID]
+	if !ok {
+		return nil, fmt.Errorf("group of alias %q not found", alias.ID)
+	}
+	group := existingGroup.Clone()
+	group.Alias = nil
+	group.MemberEntityIDs = nil
+
+	return nil, i.upsertGroup(group)
+}
+
+func (i *IdentityStore) pathGroupAliasIDList(
+	req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	ids := make([]string, 0, len(i.groupAliases))
+	for id := range i.groupAliases {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return logical.ListResponse(ids), nil
+}
+
+var groupHelp = map[string][2]string{
+	"group": {
+		"Create a new group, or update an existing one.",
+		`
+Groups attach policies to a set of entities. The members of an internal group
+are set through member_entity_ids. The members of an external group are the
+entities that logged in through the authentication backend of the group's
+alias, and whose login reported membership of the group the alias names; their
+membership is refreshed on every such login.
+
+If an ID is given, the existing group is updated.
+`,
+	},
+	"group-id": {
+		"Read, update or delete a group by its ID.",
+		"",
+	},
+	"group-id-list": {
+		"List the IDs of all groups.",
+		"",
+	},
+	"group-alias": {
+		"Create the alias of an external group, or update an existing one.",
+		`
+A group alias ties a group in an authentication backend, given by the
+accessor of the backend's mount and the group's name in the backend, to an
+external group. Each external group has at most one alias.
+`,
+	},
+	"group-alias-id": {
+		"Read, update or delete a group alias by its ID.",
+		"",
+	},
+	"group-alias-id-list": {
+		"List the IDs of all group aliases.",
+		"",
+	},
+}
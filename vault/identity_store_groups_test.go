@@ -0,0 +1,142 @@
+package vault
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestIdentityStore_GroupCRUD(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	entityID := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+
+	resp := testIdentityRequest(t, c, root, logical.UpdateOperation, "group", map[string]interface{}{
+		"name":              "engineering",
+		"policies":          "foo",
+		"member_entity_ids": entityID,
+	})
+	groupID := resp.Data["id"].(string)
+
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group/id/"+groupID, nil)
+	if resp.Data["name"] != "engineering" || resp.Data["type"] != "internal" ||
+		!reflect.DeepEqual(resp.Data["policies"], []string{"foo"}) ||
+		!reflect.DeepEqual(resp.Data["member_entity_ids"], []string{entityID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	if policies := c.identityStore.policiesByEntityID(entityID); !reflect.DeepEqual(policies, []string{"foo"}) {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "entity/id/"+entityID, nil)
+	if !reflect.DeepEqual(resp.Data["group_ids"], []string{groupID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Names are unique, members must exist and the type is fixed
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "group", map[string]interface{}{
+		"name": "engineering",
+	})
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "group/id/"+groupID, map[string]interface{}{
+		"member_entity_ids": "missing",
+	})
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "group/id/"+groupID, map[string]interface{}{
+		"type": "external",
+	})
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "group", map[string]interface{}{
+		"type": "other",
+	})
+
+	resp = testIdentityRequest(t, c, root, logical.ListOperation, "group/id/", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{groupID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Deleting an entity removes it from its groups
+	testIdentityRequest(t, c, root, logical.DeleteOperation, "entity/id/"+entityID, nil)
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group/id/"+groupID, nil)
+	if len(resp.Data["member_entity_ids"].([]string)) != 0 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	testIdentityRequest(t, c, root, logical.DeleteOperation, "group/id/"+groupID, nil)
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group/id/"+groupID, nil)
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestIdentityStore_GroupAliases(t *testing.T) {
+	noop := &NoopBackend{}
+	c, root, accessor := testIdentityCoreWithNoopAuth(t, noop)
+
+	internalID := testIdentityRequest(t, c, root, logical.UpdateOperation, "group", nil).Data["id"].(string)
+	externalID := testIdentityRequest(t, c, root, logical.UpdateOperation, "group", map[string]interface{}{
+		"type": "external",
+	}).Data["id"].(string)
+
+	// Members of external groups are not managed directly
+	entityID := testIdentityRequest(t, c, root, logical.UpdateOperation, "entity", nil).Data["id"].(string)
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "group/id/"+externalID, map[string]interface{}{
+		"member_entity_ids": entityID,
+	})
+
+	// Only external groups have aliases
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "group-alias", map[string]interface{}{
+		"name":           "engineering",
+		"mount_accessor": accessor,
+		"canonical_id":   internalID,
+	})
+
+	resp := testIdentityRequest(t, c, root, logical.UpdateOperation, "group-alias", map[string]interface{}{
+		"name":           "engineering",
+		"mount_accessor": accessor,
+		"canonical_id":   externalID,
+	})
+	aliasID := resp.Data["id"].(string)
+
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group/id/"+externalID, nil)
+	if resp.Data["alias"].(map[string]interface{})["id"] != aliasID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Each external group has a single alias
+	testIdentityRequestError(t, c, root, logical.UpdateOperation, "group-alias", map[string]interface{}{
+		"name":           "ops",
+		"mount_accessor": accessor,
+		"canonical_id":   externalID,
+	})
+
+	if err := c.identityStore.refreshExternalGroupMemberships(accessor, entityID, []*logical.Persona{
+		&logical.Persona{Name: "engineering"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group/id/"+externalID, nil)
+	if !reflect.DeepEqual(resp.Data["member_entity_ids"], []string{entityID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Renaming the alias keeps it on the group
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "group-alias/id/"+aliasID, map[string]interface{}{
+		"name": "ops",
+	})
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group-alias/id/"+aliasID, nil)
+	if resp.Data["name"] != "ops" || resp.Data["canonical_id"] != externalID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp = testIdentityRequest(t, c, root, logical.ListOperation, "group-alias/id/", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{aliasID}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Deleting the alias drops the members it brought in
+	testIdentityRequest(t, c, root, logical.DeleteOperation, "group-alias/id/"+aliasID, nil)
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group/id/"+externalID, nil)
+	if len(resp.Data["member_entity_ids"].([]string)) != 0 || len(resp.Data["alias"].(map[string]interface{})) != 0 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
@@ -0,0 +1,147 @@
+package vault
+
+import "time"
+
+const (
+	// groupTypeInternal groups have their members managed in Vault
+	groupTypeInternal = "internal"
+
+	// groupTypeExternal groups have their members managed by an
+	// authentication backend, via the group's alias
+	groupTypeExternal = "external"
+)
+
+// Entity represents a single client of Vault, which may authenticate through
+// any number of authentication backends. Each login path that maps to the
+// entity is represented by an alias.
+type Entity struct {
+	// ID is the unique identifier of the entity
+	ID string `json:"id"`
+
+	// Name is a unique, human-friendly identifier of the entity
+	Name string `json:"name"`
+
+	// Metadata is arbitrary operator-provided information about the entity
+	Metadata map[string]string `json:"metadata"`
+
+	// Policies are attached to every token issued to the entity
+	Policies []string `json:"policies"`
+
+	// Aliases are the authentication backend identities of the entity
+	Aliases []*Alias `json:"aliases"`
+
+	CreationTime   time.Time `json:"creation_time"`
+	LastUpdateTime time.Time `json:"last_update_time"`
+}
+
+// Alias ties an identity in an authentication backend, given by the mount
+// accessor of the backend and the name of the identity within it, to an
+// entity or, for external groups, to a group.
+type Alias struct {
+	// ID is the unique identifier of the alias
+	ID string `json:"id"`
+
+	// CanonicalID is the ID of the entity or group the alias belongs to
+	CanonicalID string `json:"canonical_id"`
+
+	// MountType is the type of the authentication backend
+	MountType string `json:"mount_type"`
+
+	// MountAccessor is the accessor of the authentication backend's mount
+	MountAccessor string `json:"mount_accessor"`
+
+	// Name is the identifier of the alias within the authentication backend,
+	// such as a username or group name
+	Name string `json:"name"`
+
+	// Metadata is arbitrary information about the alias
+	Metadata map[string]string `json:"metadata"`
+
+	CreationTime   time.Time `json:"creation_time"`
+	LastUpdateTime time.Time `json:"last_update_time"`
+}
+
+// Group attaches policies to a set of entities. The members of an internal
+// group are managed in Vault; the members of an external group are the
+// entities that logged in through a backend that reported membership of the
+// group's alias.
+type Group struct {
+	// ID is the unique identifier of the group
+	ID string `json:"id"`
+
+	// Name is a unique, human-friendly identifier of the group
+	Name string `json:"name"`
+
+	// Type is either internal or external
+	Type string `json:"type"`
+
+	// Metadata is arbitrary operator-provided information about the group
+	Metadata map[string]string `json:"metadata"`
+
+	// Policies are attached to every token issued to the group's members
+	Policies []string `json:"policies"`
+
+	// MemberEntityIDs are the IDs of the entities that belong to the group
+	MemberEntityIDs []string `json:"member_entity_ids"`
+
+	// Alias is the group in an authentication backend that an external
+	// group's membership is taken from
+	Alias *Alias `json:"alias"`
+
+	CreationTime   time.Time `json:"creation_time"`
+	LastUpdateTime time.Time `json:"last_update_time"`
+}
+
+func (e *Entity) Clone() *Entity {
+	ret := *e
+	ret.Metadata = cloneMetadata(e.Metadata)
+	ret.Policies = cloneStrings(e.Policies)
+	ret.Aliases = make([]*Alias, 0, len(e.Aliases))
+	for _, alias := range e.Aliases {
+		ret.Aliases = append(ret.Aliases, alias.Clone())
+	}
+	return &ret
+}
+
+func (a *Alias) Clone() *Alias {
+	ret := *a
+	ret.Metadata = cloneMetadata(a.Metadata)
+	return &ret
+}
+
+func (g *Group) Clone() *Group {
+	ret := *g
+	ret.Metadata = cloneMetadata(g.Metadata)
+	ret.Policies = cloneStrings(g.Policies)
+	ret.MemberEntityIDs = cloneStrings(g.MemberEntityIDs)
+	if g.Alias != nil {
+		ret.Alias = g.Alias.Clone()
+	}
+	return &ret
+}
+
+func cloneMetadata(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	ret := make([]string, len(s))
+	copy(ret, s)
+	return ret
+}
+
+// aliasFactors returns the key that uniquely identifies an alias within the
+// aliases of one kind
+func aliasFactors(mountAccessor, name string) string {
+	return mountAccessor + "/" + name
+}
@@ -0,0 +1,341 @@
+package vault
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+// testIdentityRequest makes a request to the identity store as root and
+// fails the test on any error
+func testIdentityRequest(t *testing.T, c *Core, root string, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	req := logical.TestRequest(t, op, "identity/"+path)
+	req.ClientToken = root
+	if data != nil {
+		req.Data = data
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("%s identity/%s: err: %v, resp: %#v", op, path, err, resp)
+	}
+	return resp
+}
+
+// testIdentityRequestError makes a request to the identity store as root and
+// fails the test unless it returns an error response
+func testIdentityRequestError(t *testing.T, c *Core, root string, op logical.Operation, path string, data map[string]interface{}) {
+	req := logical.TestRequest(t, op, "identity/"+path)
+	req.ClientToken = root
+	req.Data = data
+	resp, _ := c.HandleRequest(req)
+	if resp == nil || !resp.IsError() {
+		t.Fatalf("%s identity/%s: expected an error response, got: %#v", op, path, resp)
+	}
+}
+
+// testIdentityCoreWithNoopAuth returns an unsealed core with a noop auth
+// backend mounted at auth/foo, along with the accessor of its mount
+func testIdentityCoreWithNoopAuth(t *testing.T, noop *NoopBackend) (*Core, string, string) {
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(conf *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/auth/foo")
+	req.Data["type"] = "noop"
+	req.ClientToken = root
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	return c, root, c.router.MatchingMountEntry("auth/foo/login").Accessor
+}
+
+func TestIdentityStore_Mounted(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	if c.identityStore == nil {
+		t.Fatal("identity store not set up")
+	}
+	if c.router.MatchingBackend("identity/") != c.identityStore {
+		t.Fatal("identity store not mounted at identity/")
+	}
+
+	if err := c.unmount("identity/"); err == nil {
+		t.Fatal("expected error unmounting the identity store")
+	}
+}
+
+func TestIdentityStore_CreateOrFetchEntity(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	i := c.identityStore
+
+	persona := &logical.Persona{
+		MountAccessor: "auth_userpass_1234",
+		MountType:     "userpass",
+		Name:          "armon",
+	}
+
+	entity, err := i.CreateOrFetchEntity(persona)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entity.ID == "" || entity.Name == "" {
+		t.Fatalf("bad: %#v", entity)
+	}
+	if len(entity.Aliases) != 1 {
+		t.Fatalf("bad: %#v", entity.Aliases)
+	}
+	alias := entity.Aliases[0]
+	if alias.CanonicalID != entity.ID || alias.MountAccessor != "auth_userpass_1234" ||
+		alias.MountType != "userpass" || alias.Name != "armon" {
+		t.Fatalf("bad: %#v", alias)
+	}
+
+	// The same persona maps onto the same entity
+	fetched, err := i.CreateOrFetchEntity(persona)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ID != entity.ID {
+		t.Fatalf("expected entity %q, got %q", entity.ID, fetched.ID)
+	}
+
+	// The same name in another mount maps onto another entity
+	other, err := i.CreateOrFetchEntity(&logical.Persona{
+		MountAccessor: "auth_ldap_1234",
+		MountType:     "ldap",
+		Name:          "armon",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == entity.ID {
+		t.Fatal("expected a new entity")
+	}
+
+	// Entities survive reloading from storage
+	if err := i.initialize(); err != nil {
+		t.Fatal(err)
+	}
+	fetched, err = i.CreateOrFetchEntity(persona)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ID != entity.ID || fetched.Aliases[0].ID != alias.ID {
+		t.Fatalf("bad: %#v", fetched)
+	}
+	if len(i.entities) != 2 {
+		t.Fatalf("expected 2 entities, got %d", len(i.entities))
+	}
+
+	if _, err := i.CreateOrFetchEntity(&logical.Persona{Name: "armon"}); err == nil {
+		t.Fatal("expected error without a mount accessor")
+	}
+}
+
+func TestIdentityStore_Login(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies:    []string{"foo"},
+				DisplayName: "armon",
+				Persona: &logical.Persona{
+					Name: "armon",
+				},
+				GroupPersonas: []*logical.Persona{
+					&logical.Persona{Name: "engineering"},
+				},
+			},
+		},
+	}
+	c, root, accessor := testIdentityCoreWithNoopAuth(t, noop)
+
+	login := func() *TokenEntry {
+		resp, err := c.HandleRequest(&logical.Request{
+			Path: "auth/foo/login",
+		})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		te, err := c.tokenStore.Lookup(resp.Auth.ClientToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return te
+	}
+
+	te := login()
+	if te.EntityID == "" {
+		t.Fatal("expected the token to be tied to an entity")
+	}
+	if !reflect.DeepEqual(c.tokenPolicies(te), []string{"default", "foo"}) {
+		t.Fatalf("bad: %#v", c.tokenPolicies(te))
+	}
+
+	// The login created an entity with an alias for the mount
+	resp := testIdentityRequest(t, c, root, logical.ReadOperation, "entity/id/"+te.EntityID, nil)
+	aliases := resp.Data["aliases"].([]interface{})
+	if len(aliases) != 1 {
+		t.Fatalf("bad: %#v", aliases)
+	}
+	aliasData := aliases[0].(map[string]interface{})
+	if aliasData["mount_accessor"] != accessor || aliasData["mount_type"] != "noop" || aliasData["name"] != "armon" {
+		t.Fatalf("bad: %#v", aliasData)
+	}
+
+	// Policies attached to the entity apply to its tokens
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "entity/id/"+te.EntityID, map[string]interface{}{
+		"policies": "bar",
+	})
+
+	// An external group tied to the group reported on login picks up the
+	// entity as a member
+	resp = testIdentityRequest(t, c, root, logical.UpdateOperation, "group", map[string]interface{}{
+		"type":     "external",
+		"policies": "baz",
+	})
+	groupID := resp.Data["id"].(string)
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "group-alias", map[string]interface{}{
+		"name":           "engineering",
+		"mount_accessor": accessor,
+		"canonical_id":   groupID,
+	})
+
+	te2 := login()
+	if te2.EntityID != te.EntityID {
+		t.Fatalf("expected entity %q, got %q", te.EntityID, te2.EntityID)
+	}
+	policies := c.tokenPolicies(te)
+	sort.Strings(policies)
+	if !reflect.DeepEqual(policies, []string{"bar", "baz", "default", "foo"}) {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	// Once the backend stops reporting the group, the entity is removed from
+	// it on the next login
+	noop.Response.Auth.GroupPersonas = nil
+	login()
+	resp = testIdentityRequest(t, c, root, logical.ReadOperation, "group/id/"+groupID, nil)
+	if len(resp.Data["member_entity_ids"].([]string)) != 0 {
+		t.Fatalf("bad: %#v", resp.Data["member_entity_ids"])
+	}
+	policies = c.tokenPolicies(te)
+	sort.Strings(policies)
+	if !reflect.DeepEqual(policies, []string{"bar", "default", "foo"}) {
+		t.Fatalf("bad: %#v", policies)
+	}
+
+	// Entity policies show up in the token's capabilities
+	capabilities, err := c.Capabilities(te.ID, "secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(capabilities, []string{"deny"}) {
+		t.Fatalf("bad: %#v", capabilities)
+	}
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/policy/bar")
+	req.ClientToken = root
+	req.Data["rules"] = `path "secret/foo" { capabilities = ["read"] }`
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	capabilities, err = c.Capabilities(te.ID, "secret/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(capabilities, []string{"read"}) {
+		t.Fatalf("bad: %#v", capabilities)
+	}
+
+	// The token lookup reports the entity
+	req = logical.TestRequest(t, logical.ReadOperation, "auth/token/lookup-self")
+	req.ClientToken = te.ID
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["entity_id"] != te.EntityID {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestIdentityStore_TemplatedPolicy(t *testing.T) {
+	noop := &NoopBackend{
+		Login: []string{"login"},
+		Response: &logical.Response{
+			Auth: &logical.Auth{
+				Policies:    []string{"templated"},
+				DisplayName: "armon",
+				Persona: &logical.Persona{
+					Name: "armon",
+				},
+			},
+		},
+	}
+	c, root, accessor := testIdentityCoreWithNoopAuth(t, noop)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/policy/templated")
+	req.ClientToken = root
+	req.Data["rules"] = `
+path "secret/{{identity.entity.name}}/*" { capabilities = ["read"] }
+path "secret/teams/{{identity.entity.metadata.team}}" { capabilities = ["update"] }
+path "secret/users/{{identity.entity.aliases.` + accessor + `.name}}" { capabilities = ["list"] }
+`
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := c.HandleRequest(&logical.Request{
+		Path: "auth/foo/login",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	te, err := c.tokenStore.Lookup(resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entityName := c.identityStore.entityName(te.EntityID)
+
+	checkCapabilities := func(path string, expected []string) {
+		t.Helper()
+		capabilities, err := c.Capabilities(te.ID, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(capabilities, expected) {
+			t.Fatalf("%s: bad: %#v", path, capabilities)
+		}
+	}
+
+	checkCapabilities("secret/"+entityName+"/foo", []string{"read"})
+	checkCapabilities("secret/someone-else/foo", []string{"deny"})
+	checkCapabilities("secret/users/armon", []string{"list"})
+
+	// The raw template never matches literally
+	checkCapabilities("secret/{{identity.entity.name}}/foo", []string{"deny"})
+
+	// Without the metadata key the path grants nothing, until it is set
+	checkCapabilities("secret/teams/", []string{"deny"})
+	testIdentityRequest(t, c, root, logical.UpdateOperation, "entity/id/"+te.EntityID, map[string]interface{}{
+		"metadata": map[string]interface{}{"team": "eng"},
+	})
+	checkCapabilities("secret/teams/eng", []string{"update"})
+
+	// The request path is checked against the resolved policy as well
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/"+entityName+"/foo")
+	req.ClientToken = te.ID
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	req = logical.TestRequest(t, logical.ReadOperation, "secret/someone-else/foo")
+	req.ClientToken = te.ID
+	if _, err := c.HandleRequest(req); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+}
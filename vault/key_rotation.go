@@ -0,0 +1,263 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+)
+
+const (
+	// keyRotationConfigKey is where the automatic rotation configuration of
+	// the barrier encryption key is stored, in the system config view
+	keyRotationConfigKey = "rotate"
+
+	// keyEncryptionCountPath is where the number of encryptions performed
+	// with the barrier encryption key is checkpointed, so that it survives
+	// restarts and leadership changes
+	keyEncryptionCountPath = "core/encryption-count"
+
+	// keyRotationAutoCheckInterval is how often the active node checks
+	// whether the barrier encryption key must be rotated
+	keyRotationAutoCheckInterval = 10 * time.Second
+
+	// With random nonces, AES-GCM keys should not be used for more than
+	// 2^32 encryptions. Keys are rotated before 90% of that is reached by
+	// default, and this cannot be raised.
+	defaultKeyRotationMaxOperations uint64 = 3865470566
+	minKeyRotationMaxOperations     uint64 = 1000000
+)
+
+// KeyRotationConfig is the configuration of the automatic rotation of the
+// barrier encryption key
+type KeyRotationConfig struct {
+	// Enabled turns on automatic rotation
+	Enabled bool `json:"enabled"`
+
+	// MaxOperations is the number of encryptions after which the key is
+	// rotated
+	MaxOperations uint64 `json:"max_operations"`
+}
+
+// defaultKeyRotationConfig returns the configuration in use when none has
+// been saved
+func defaultKeyRotationConfig() *KeyRotationConfig {
+	return &KeyRotationConfig{
+		Enabled:       true,
+		MaxOperations: defaultKeyRotationMaxOperations,
+	}
+}
+
+// This should only be called with the core state lock held for writing
+func (c *Core) loadKeyRotationConfig() error {
+	view := c.systemBarrierView.SubView("config/")
+
+	out, err := view.Get(keyRotationConfigKey)
+	if err != nil {
+		return fmt.Errorf("failed to read key rotation config: %v", err)
+	}
+
+	config := defaultKeyRotationConfig()
+	if out != nil {
+		if err := out.DecodeJSON(config); err != nil {
+			return err
+		}
+	}
+
+	c.keyRotationConfigLock.Lock()
+	c.keyRotationConfig = config
+	c.keyRotationConfigLock.Unlock()
+	return nil
+}
+
+// saveKeyRotationConfig persists the configuration and makes it current
+func (c *Core) saveKeyRotationConfig(config *KeyRotationConfig) error {
+	if config.MaxOperations < minKeyRotationMaxOperations || config.MaxOperations > defaultKeyRotationMaxOperations {
+		return logical.CodedError(400, fmt.Sprintf("max_operations must be between %d and %d", minKeyRotationMaxOperations, defaultKeyRotationMaxOperations))
+	}
+
+	view := c.systemBarrierView.SubView("config/")
+	entry, err := logical.StorageEntryJSON(keyRotationConfigKey, config)
+	if err != nil {
+		return fmt.Errorf("failed to create key rotation config entry: %v", err)
+	}
+	if err := view.Put(entry); err != nil {
+		return fmt.Errorf("failed to save key rotation config: %v", err)
+	}
+
+	c.keyRotationConfigLock.Lock()
+	c.keyRotationConfig = config
+	c.keyRotationConfigLock.Unlock()
+	return nil
+}
+
+// keyEncryptionCount is the checkpointed number of encryptions performed
+// with the barrier encryption key of a term
+type keyEncryptionCount struct {
+	Term        uint32 `json:"term"`
+	Encryptions uint64 `json:"encryptions"`
+}
+
+// loadKeyEncryptionCount carries the checkpointed number of encryptions over
+// to the barrier, if the key it was counted for is still the active key.
+// This should only be called with the core state lock held for writing.
+func (c *Core) loadKeyEncryptionCount() error {
+	entry, err := c.barrier.Get(keyEncryptionCountPath)
+	if err != nil {
+		return fmt.Errorf("failed to read encryption count: %v", err)
+	}
+	if entry == nil {
+		return nil
+	}
+
+	count := new(keyEncryptionCount)
+	if err := jsonutil.DecodeJSON(entry.Value, count); err != nil {
+		return fmt.Errorf("failed to decode encryption count: %v", err)
+	}
+	if err := c.barrier.AddEncryptions(count.Term, count.Encryptions); err != nil {
+		return err
+	}
+
+	c.keyRotationLock.Lock()
+	c.keyEncryptionCountPersisted = 0
+	c.keyRotationLock.Unlock()
+	return nil
+}
+
+// persistKeyEncryptionCount checkpoints the number of encryptions performed
+// with the active barrier encryption key, if it changed since the last
+// checkpoint. The checkpoint itself is one more encryption, which is counted.
+func (c *Core) persistKeyEncryptionCount() error {
+	c.keyRotationLock.Lock()
+	defer c.keyRotationLock.Unlock()
+
+	info, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		return err
+	}
+	if info.Encryptions == c.keyEncryptionCountPersisted {
+		return nil
+	}
+
+	count := &keyEncryptionCount{
+		Term:        uint32(info.Term),
+		Encryptions: info.Encryptions + 1,
+	}
+	buf, err := jsonutil.EncodeJSON(count)
+	if err != nil {
+		return fmt.Errorf("failed to encode encryption count: %v", err)
+	}
+	if err := c.barrier.Put(&Entry{
+		Key:   keyEncryptionCountPath,
+		Value: buf,
+	}); err != nil {
+		return fmt.Errorf("failed to persist encryption count: %v", err)
+	}
+	c.keyEncryptionCountPersisted = count.Encryptions
+	return nil
+}
+
+// KeyRotationConfig returns the current automatic rotation configuration
+func (c *Core) KeyRotationConfig() *KeyRotationConfig {
+	c.keyRotationConfigLock.RLock()
+	defer c.keyRotationConfigLock.RUnlock()
+
+	if c.keyRotationConfig == nil {
+		return defaultKeyRotationConfig()
+	}
+	config := *c.keyRotationConfig
+	return &config
+}
+
+// rotateBarrierKey installs a new barrier encryption key. Standbys are given
+// an upgrade path to the new term.
+func (c *Core) rotateBarrierKey() (uint32, error) {
+	c.keyRotationLock.Lock()
+	defer c.keyRotationLock.Unlock()
+
+	// Rotate to the new term
+	newTerm, err := c.barrier.Rotate()
+	if err != nil {
+		c.logger.Error("core: failed to create new encryption key", "error", err)
+		return 0, err
+	}
+	c.logger.Info("core: installed new encryption key", "term", newTerm)
+
+	// In HA mode, we need to an upgrade path for the standby instances
+	if c.ha != nil {
+		// Create the upgrade path to the new term
+		if err := c.barrier.CreateUpgrade(newTerm); err != nil {
+			c.logger.Error("core: failed to create new upgrade", "term", newTerm, "error", err)
+		}
+
+		// Schedule the destroy of the upgrade path
+		time.AfterFunc(keyRotateGracePeriod, func() {
+			if err := c.barrier.DestroyUpgrade(newTerm); err != nil {
+				c.logger.Error("core: failed to destroy upgrade", "term", newTerm, "error", err)
+			}
+		})
+	}
+
+	// Write to the canary path, which will force a synchronous truing during
+	// replication
+	if err := c.barrier.Put(&Entry{
+		Key:   coreKeyringCanaryPath,
+		Value: []byte(fmt.Sprintf("new-rotation-term-%d", newTerm)),
+	}); err != nil {
+		c.logger.Error("core: error saving keyring canary", "error", err)
+		return 0, fmt.Errorf("failed to save keyring canary: %v", err)
+	}
+
+	return newTerm, nil
+}
+
+// autoRotateKeys periodically rotates the barrier encryption key once it has
+// been used for the configured number of encryptions
+func (c *Core) autoRotateKeys(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(keyRotationAutoCheckInterval):
+			c.checkKeyRotation()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// checkKeyRotation rotates the barrier encryption key if it has been used
+// for more than the configured number of encryptions
+func (c *Core) checkKeyRotation() {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed || c.standby {
+		return
+	}
+	if c.ReplicationState() == consts.ReplicationSecondary {
+		return
+	}
+
+	if err := c.persistKeyEncryptionCount(); err != nil {
+		c.logger.Error("core: failed to checkpoint encryption count", "error", err)
+	}
+
+	config := c.KeyRotationConfig()
+	if !config.Enabled {
+		return
+	}
+
+	info, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		c.logger.Error("core: failed to read encryption key status", "error", err)
+		return
+	}
+	if info.Encryptions < config.MaxOperations {
+		return
+	}
+
+	c.logger.Info("core: rotating encryption key after reaching the maximum number of operations", "term", info.Term, "encryptions", info.Encryptions)
+	if _, err := c.rotateBarrierKey(); err != nil {
+		c.logger.Error("core: failed to rotate encryption key", "error", err)
+	}
+}
@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"testing"
+)
+
+func TestCore_KeyRotation_Auto(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	// Lower the limit below what can be configured
+	c.keyRotationConfigLock.Lock()
+	c.keyRotationConfig = &KeyRotationConfig{
+		Enabled:       false,
+		MaxOperations: 1,
+	}
+	c.keyRotationConfigLock.Unlock()
+
+	if err := c.barrier.Put(&Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Nothing happens while disabled
+	c.checkKeyRotation()
+	info, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Term != 1 {
+		t.Fatalf("bad term: %d", info.Term)
+	}
+
+	c.keyRotationConfigLock.Lock()
+	c.keyRotationConfig.Enabled = true
+	c.keyRotationConfigLock.Unlock()
+
+	c.checkKeyRotation()
+	info, err = c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Term != 2 {
+		t.Fatalf("bad term: %d", info.Term)
+	}
+
+	// Data written under the previous key is still readable
+	out, err := c.barrier.Get("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || string(out.Value) != "bar" {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestCore_KeyRotation_Config(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	if err := c.saveKeyRotationConfig(&KeyRotationConfig{
+		Enabled:       true,
+		MaxOperations: defaultKeyRotationMaxOperations + 1,
+	}); err == nil {
+		t.Fatal("expected error")
+	}
+
+	if err := c.saveKeyRotationConfig(&KeyRotationConfig{
+		Enabled:       true,
+		MaxOperations: minKeyRotationMaxOperations,
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The configuration survives a reload
+	c.keyRotationConfigLock.Lock()
+	c.keyRotationConfig = nil
+	c.keyRotationConfigLock.Unlock()
+	if err := c.loadKeyRotationConfig(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config := c.KeyRotationConfig(); !config.Enabled || config.MaxOperations != minKeyRotationMaxOperations {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestCore_KeyRotation_EncryptionCount(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+
+	for i := 0; i < 10; i++ {
+		if err := c.barrier.Put(&Entry{Key: "foo", Value: []byte("bar")}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	info, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	before := info.Encryptions
+
+	// The count is checkpointed on seal, and carried over on unseal
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	info, err = c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Encryptions <= before {
+		t.Fatalf("expected more than %d encryptions, got %d", before, info.Encryptions)
+	}
+
+	// Checkpoints are only written when the count changed
+	if err := c.persistKeyEncryptionCount(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	info, err = c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.persistKeyEncryptionCount(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	after, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if after.Encryptions != info.Encryptions {
+		t.Fatalf("expected %d encryptions, got %d", info.Encryptions, after.Encryptions)
+	}
+
+	// Counts of a previous key are not carried over
+	if _, err := c.rotateBarrierKey(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c.barrier.AddEncryptions(uint32(info.Term), 1000); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	info, err = c.barrier.ActiveKeyInfo()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if info.Encryptions >= 1000 {
+		t.Fatalf("bad encryptions: %d", info.Encryptions)
+	}
+}
@@ -158,6 +158,18 @@ func (b *PassthroughBackend) handleWrite(
 		return logical.ErrorResponse("missing data fields"), nil
 	}
 
+	// Check if there is a ttl key; verify parseability if so, since an
+	// unparseable value would otherwise silently fall back to the default
+	ttlRaw, ok := req.Data["ttl"]
+	if !ok {
+		ttlRaw, ok = req.Data["lease"]
+	}
+	if ok {
+		if _, err := parseutil.ParseDurationSecond(ttlRaw); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to parse ttl: %v", err)), nil
+		}
+	}
+
 	// JSON encode the data
 	buf, err := json.Marshal(req.Data)
 	if err != nil {
@@ -50,6 +50,33 @@ func TestPassthroughBackend_Write(t *testing.T) {
 	test(b)
 }
 
+func TestPassthroughBackend_WriteInvalidTTL(t *testing.T) {
+	test := func(b logical.Backend, ttlType string) {
+		req := logical.TestRequest(t, logical.UpdateOperation, "foo")
+		req.Data["raw"] = "test"
+		req.Data[ttlType] = "not-a-duration"
+
+		resp, err := b.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp == nil || !resp.IsError() {
+			t.Fatalf("expected error response, got: %#v", resp)
+		}
+
+		out, err := req.Storage.Get("foo")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out != nil {
+			t.Fatalf("unexpected write: %#v", out)
+		}
+	}
+	test(testPassthroughBackend(), "ttl")
+	test(testPassthroughBackend(), "lease")
+	test(testPassthroughLeasedBackend(), "ttl")
+}
+
 func TestPassthroughBackend_Read(t *testing.T) {
 	test := func(b logical.Backend, ttlType string, ttl interface{}, leased bool) {
 		req := logical.TestRequest(t, logical.UpdateOperation, "foo")
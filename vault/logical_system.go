@@ -1,20 +1,29 @@
 package vault
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"runtime/pprof"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fatih/structs"
+	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/parseutil"
 	"github.com/hashicorp/vault/helper/wrapping"
 	"github.com/hashicorp/vault/logical"
 	"github.com/hashicorp/vault/logical/framework"
+	"github.com/hashicorp/vault/version"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -57,26 +66,35 @@ func NewSystemBackend(core *Core) *SystemBackend {
 			Root: []string{
 				"auth/*",
 				"remount",
+				"namespaces/*",
 				"audit",
 				"audit/*",
 				"raw/*",
 				"replication/primary/secondary-token",
 				"replication/reindex",
+				"replication/dr/primary/*",
+				"replication/dr/secondary/*",
 				"rotate",
+				"pprof/*",
+				"rotate/config",
 				"config/cors",
 				"config/auditing/*",
+				"config/state/sanitized",
+				"config/reload",
 				"plugins/catalog/*",
 				"revoke-prefix/*",
 				"revoke-force/*",
 				"leases/revoke-prefix/*",
 				"leases/revoke-force/*",
 				"leases/lookup/*",
+				"storage/raft/*",
 			},
 
 			Unauthenticated: []string{
 				"wrapping/lookup",
 				"wrapping/pubkey",
 				"replication/status",
+				"internal/ui/mounts",
 			},
 		},
 
@@ -131,6 +149,28 @@ func NewSystemBackend(core *Core) *SystemBackend {
 				HelpSynopsis:    strings.TrimSpace(sysHelp["config/cors"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "config/reload$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleConfigReload,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["config/reload"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["config/reload"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "config/state/sanitized$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleConfigStateSanitized,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["config/state"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["config/state"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "capabilities$",
 
@@ -230,6 +270,10 @@ func NewSystemBackend(core *Core) *SystemBackend {
 						Type:        framework.TypeString,
 						Description: strings.TrimSpace(sysHelp["tune_max_lease_ttl"][0]),
 					},
+					"description": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["auth_desc"][0]),
+					},
 				},
 				Callbacks: map[logical.Operation]framework.OperationFunc{
 					logical.ReadOperation:   b.handleAuthTuneRead,
@@ -255,6 +299,10 @@ func NewSystemBackend(core *Core) *SystemBackend {
 						Type:        framework.TypeString,
 						Description: strings.TrimSpace(sysHelp["tune_max_lease_ttl"][0]),
 					},
+					"description": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["mount_desc"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -291,6 +339,11 @@ func NewSystemBackend(core *Core) *SystemBackend {
 						Default:     false,
 						Description: strings.TrimSpace(sysHelp["mount_local"][0]),
 					},
+					"seal_wrap": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Default:     false,
+						Description: strings.TrimSpace(sysHelp["seal_wrap"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -502,6 +555,11 @@ func NewSystemBackend(core *Core) *SystemBackend {
 						Default:     false,
 						Description: strings.TrimSpace(sysHelp["mount_local"][0]),
 					},
+					"seal_wrap": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Default:     false,
+						Description: strings.TrimSpace(sysHelp["seal_wrap"][0]),
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -549,6 +607,37 @@ func NewSystemBackend(core *Core) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["policy"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "namespaces/?$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ListOperation: b.handleNamespacesList,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["namespaces-list"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["namespaces-list"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "namespaces/" + framework.GenericNameRegex("path"),
+
+				Fields: map[string]*framework.FieldSchema{
+					"path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["namespace-path"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleNamespaceRead,
+					logical.UpdateOperation: b.handleNamespaceCreate,
+					logical.DeleteOperation: b.handleNamespaceDelete,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["namespace"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["namespace"][1]),
+			},
+
 			&framework.Path{
 				Pattern:         "seal-status$",
 				HelpSynopsis:    strings.TrimSpace(sysHelp["seal-status"][0]),
@@ -666,6 +755,124 @@ func NewSystemBackend(core *Core) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["key-status"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "metrics$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"format": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["metrics_format"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleMetrics,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["metrics"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["metrics"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "internal/ui/mounts$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleInternalUIMounts,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-ui-mounts"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-ui-mounts"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "internal/specs/openapi$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handleInternalOpenAPI,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["internal-specs-openapi"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["internal-specs-openapi"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "pprof/(?P<name>heap|goroutine)$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"name": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["pprof_name"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation: b.handlePprofLookup,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["pprof"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["pprof"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "tools/random" + framework.OptionalParamRegex("urlbytes"),
+
+				Fields: map[string]*framework.FieldSchema{
+					"urlbytes": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tools_random_urlbytes"][0]),
+					},
+					"bytes": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Default:     32,
+						Description: strings.TrimSpace(sysHelp["tools_random_bytes"][0]),
+					},
+					"format": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     "base64",
+						Description: strings.TrimSpace(sysHelp["tools_random_format"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleToolsRandom,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["tools-random"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["tools-random"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "tools/hash" + framework.OptionalParamRegex("urlalgorithm"),
+
+				Fields: map[string]*framework.FieldSchema{
+					"input": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tools_hash_input"][0]),
+					},
+					"urlalgorithm": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["tools_hash_urlalgorithm"][0]),
+					},
+					"algorithm": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     "sha2-256",
+						Description: strings.TrimSpace(sysHelp["tools_hash_algorithm"][0]),
+					},
+					"format": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Default:     "hex",
+						Description: strings.TrimSpace(sysHelp["tools_hash_format"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleToolsHash,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["tools-hash"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["tools-hash"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "rotate$",
 
@@ -677,6 +884,29 @@ func NewSystemBackend(core *Core) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["rotate"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "rotate/config$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"enabled": &framework.FieldSchema{
+						Type:        framework.TypeBool,
+						Description: strings.TrimSpace(sysHelp["rotate_config_enabled"][0]),
+					},
+					"max_operations": &framework.FieldSchema{
+						Type:        framework.TypeInt,
+						Description: strings.TrimSpace(sysHelp["rotate_config_max_operations"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.ReadOperation:   b.handleRotateConfigRead,
+					logical.UpdateOperation: b.handleRotateConfigUpdate,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["rotate_config"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["rotate_config"][1]),
+			},
+
 			/*
 				// Disabled for the moment as we don't support this externally
 				&framework.Path{
@@ -754,6 +984,146 @@ func NewSystemBackend(core *Core) *SystemBackend {
 				HelpDescription: strings.TrimSpace(sysHelp["rewrap"][1]),
 			},
 
+			&framework.Path{
+				Pattern: "control-group/authorize$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"accessor": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["control-group-accessor"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleControlGroupAuthorize,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["control-group-authorize"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["control-group-authorize"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "control-group/request$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"accessor": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["control-group-accessor"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleControlGroupRequest,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["control-group-request"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["control-group-request"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/enable$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleDRPrimaryEnable,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-primary-enable"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-primary-enable"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/secondary-token$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["replication-dr-secondary-id"][0]),
+					},
+					"ttl": &framework.FieldSchema{
+						Type:        framework.TypeDurationSecond,
+						Default:     "30m",
+						Description: "The time the activation token must be used within.",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleDRSecondaryToken,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-secondary-token"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-secondary-token"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/revoke-secondary$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"id": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: strings.TrimSpace(sysHelp["replication-dr-secondary-id"][0]),
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleDRRevokeSecondary,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-revoke-secondary"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-revoke-secondary"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/demote$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleDRPrimaryDemote,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-primary-demote"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-primary-demote"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/primary/disable$",
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleDRPrimaryDisable,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-primary-disable"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-primary-disable"][1]),
+			},
+
+			&framework.Path{
+				Pattern: "replication/dr/secondary/enable$",
+
+				Fields: map[string]*framework.FieldSchema{
+					"token": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The activation token generated by the primary.",
+					},
+					"primary_api_addr": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The API address of the primary, overriding that of the activation token.",
+					},
+					"ca_file": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The path to a PEM encoded CA file to verify the TLS certificate of the primary with.",
+					},
+					"ca_path": &framework.FieldSchema{
+						Type:        framework.TypeString,
+						Description: "The path to a directory of PEM encoded CA files to verify the TLS certificate of the primary with.",
+					},
+				},
+
+				Callbacks: map[logical.Operation]framework.OperationFunc{
+					logical.UpdateOperation: b.handleDRSecondaryEnable,
+				},
+
+				HelpSynopsis:    strings.TrimSpace(sysHelp["replication-dr-secondary-enable"][0]),
+				HelpDescription: strings.TrimSpace(sysHelp["replication-dr-secondary-enable"][1]),
+			},
+
 			&framework.Path{
 				Pattern: "config/auditing/request-headers/(?P<header>.+)",
 
@@ -854,6 +1224,7 @@ func NewSystemBackend(core *Core) *SystemBackend {
 	}
 
 	b.Backend.Paths = append(b.Backend.Paths, replicationPaths(b)...)
+	b.Backend.Paths = append(b.Backend.Paths, b.raftStoragePaths()...)
 
 	b.Backend.Invalidate = b.invalidate
 
@@ -905,6 +1276,32 @@ func (b *SystemBackend) handleCORSDelete(req *logical.Request, d *framework.Fiel
 	return nil, b.Core.corsConfig.Disable()
 }
 
+// handleConfigReload reloads the server configuration of this node
+func (b *SystemBackend) handleConfigReload(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if b.Core.reloadConfigFunc == nil {
+		return logical.ErrorResponse("reloading the configuration is not supported by this server"), nil
+	}
+
+	if err := b.Core.reloadConfigFunc(); err != nil {
+		b.Backend.Logger().Error("sys: failed to reload the configuration", "error", err)
+		return handleError(err)
+	}
+
+	return nil, nil
+}
+
+// handleConfigStateSanitized returns the server configuration this node was
+// started with, without any of its secrets
+func (b *SystemBackend) handleConfigStateSanitized(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if b.Core.sanitizedConfig == nil {
+		return logical.ErrorResponse("the server configuration is not available"), nil
+	}
+
+	return &logical.Response{
+		Data: b.Core.sanitizedConfig,
+	}, nil
+}
+
 func (b *SystemBackend) handleTidyLeases(req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	err := b.Core.expiration.Tidy()
 	if err != nil {
@@ -1099,7 +1496,8 @@ func (b *SystemBackend) handleCapabilities(req *logical.Request, d *framework.Fi
 	if token == "" {
 		token = req.ClientToken
 	}
-	capabilities, err := b.Core.Capabilities(token, d.Get("path").(string))
+	path := b.Core.requestNamespace(req).Path + d.Get("path").(string)
+	capabilities, err := b.Core.Capabilities(token, path)
 	if err != nil {
 		return nil, err
 	}
@@ -1215,6 +1613,8 @@ func (b *SystemBackend) handleRekeyDeleteRecovery(
 // handleMountTable handles the "mounts" endpoint to provide the mount table
 func (b *SystemBackend) handleMountTable(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.Core.requestNamespace(req)
+
 	b.Core.mountsLock.RLock()
 	defer b.Core.mountsLock.RUnlock()
 
@@ -1223,6 +1623,11 @@ func (b *SystemBackend) handleMountTable(
 	}
 
 	for _, entry := range b.Core.mounts.Entries {
+		// Only list the mounts of the namespace
+		if b.Core.namespaceByPath(entry.Path) != ns {
+			continue
+		}
+
 		// Populate mount info
 		structConfig := structs.New(entry.Config).Map()
 		structConfig["default_lease_ttl"] = int64(structConfig["default_lease_ttl"].(time.Duration).Seconds())
@@ -1233,8 +1638,9 @@ func (b *SystemBackend) handleMountTable(
 			"accessor":    entry.Accessor,
 			"config":      structConfig,
 			"local":       entry.Local,
+			"seal_wrap":   entry.SealWrap,
 		}
-		resp.Data[entry.Path] = info
+		resp.Data[strings.TrimPrefix(entry.Path, ns.Path)] = info
 	}
 
 	return resp, nil
@@ -1259,6 +1665,11 @@ func (b *SystemBackend) handleMount(
 
 	path = sanitizeMountPath(path)
 
+	ns := b.Core.requestNamespace(req)
+	if err := b.Core.validateNamespaceMountPath(ns, path); err != nil {
+		return handleError(err)
+	}
+
 	var config MountConfig
 	var apiConfig APIMountConfig
 
@@ -1329,11 +1740,12 @@ func (b *SystemBackend) handleMount(
 	// Create the mount entry
 	me := &MountEntry{
 		Table:       mountTableType,
-		Path:        path,
+		Path:        ns.Path + path,
 		Type:        logicalType,
 		Description: description,
 		Config:      config,
 		Local:       local,
+		SealWrap:    data.Get("seal_wrap").(bool),
 	}
 
 	// Attempt mount
@@ -1362,6 +1774,12 @@ func (b *SystemBackend) handleUnmount(
 	path := data.Get("path").(string)
 	path = sanitizeMountPath(path)
 
+	ns := b.Core.requestNamespace(req)
+	if err := b.Core.validateNamespaceMountPath(ns, path); err != nil {
+		return handleError(err)
+	}
+	path = ns.Path + path
+
 	repState := b.Core.ReplicationState()
 	entry := b.Core.router.MatchingMountEntry(path)
 	if entry != nil && !entry.Local && repState == consts.ReplicationSecondary {
@@ -1403,6 +1821,15 @@ func (b *SystemBackend) handleRemount(
 	fromPath = sanitizeMountPath(fromPath)
 	toPath = sanitizeMountPath(toPath)
 
+	ns := b.Core.requestNamespace(req)
+	for _, path := range []string{fromPath, toPath} {
+		if err := b.Core.validateNamespaceMountPath(ns, path); err != nil {
+			return handleError(err)
+		}
+	}
+	fromPath = ns.Path + fromPath
+	toPath = ns.Path + toPath
+
 	entry := b.Core.router.MatchingMountEntry(fromPath)
 	if entry != nil && !entry.Local && repState == consts.ReplicationSecondary {
 		return logical.ErrorResponse("cannot remount a non-local mount on a replication secondary"), nil
@@ -1426,7 +1853,7 @@ func (b *SystemBackend) handleAuthTuneRead(
 				"path must be specified as a string"),
 			logical.ErrInvalidRequest
 	}
-	return b.handleTuneReadCommon("auth/" + path)
+	return b.handleTuneReadCommon(b.Core.namespaceRoutePath(b.Core.requestNamespace(req).Path + "auth/" + path))
 }
 
 // handleMountTuneRead is used to get config settings on a backend
@@ -1442,7 +1869,7 @@ func (b *SystemBackend) handleMountTuneRead(
 	// This call will read both logical backend's configuration as well as auth backends'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
-	return b.handleTuneReadCommon(path)
+	return b.handleTuneReadCommon(b.Core.namespaceRoutePath(b.Core.requestNamespace(req).Path + path))
 }
 
 // handleTuneReadCommon returns the config settings of a path
@@ -1463,6 +1890,7 @@ func (b *SystemBackend) handleTuneReadCommon(path string) (*logical.Response, er
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
+			"description":       mountEntry.Description,
 			"default_lease_ttl": int(sysView.DefaultLeaseTTL().Seconds()),
 			"max_lease_ttl":     int(sysView.MaxLeaseTTL().Seconds()),
 			"force_no_cache":    mountEntry.Config.ForceNoCache,
@@ -1480,7 +1908,7 @@ func (b *SystemBackend) handleAuthTuneWrite(
 		return logical.ErrorResponse("path must be specified as a string"),
 			logical.ErrInvalidRequest
 	}
-	return b.handleTuneWriteCommon("auth/"+path, data)
+	return b.handleTuneWriteCommon(b.Core.namespaceRoutePath(b.Core.requestNamespace(req).Path+"auth/"+path), data)
 }
 
 // handleMountTuneWrite is used to set config settings on a backend
@@ -1494,7 +1922,7 @@ func (b *SystemBackend) handleMountTuneWrite(
 	// This call will write both logical backend's configuration as well as auth backends'.
 	// Retaining this behavior for backward compatibility. If this behavior is not desired,
 	// an error can be returned if path has a prefix of "auth/".
-	return b.handleTuneWriteCommon(path, data)
+	return b.handleTuneWriteCommon(b.Core.namespaceRoutePath(b.Core.requestNamespace(req).Path+path), data)
 }
 
 // handleTuneWriteCommon is used to set config settings on a path
@@ -1506,13 +1934,20 @@ func (b *SystemBackend) handleTuneWriteCommon(
 
 	path = sanitizeMountPath(path)
 
-	// Prevent protected paths from being changed
+	// Prevent protected paths from being changed, including the system and
+	// token backends of namespaces
+	ns := b.Core.namespaceByPath(path)
+	relPath := strings.TrimPrefix(path, ns.Path)
+	untunable := ns != rootNamespace && strings.HasPrefix(relPath, credentialRoutePrefix+"token/")
 	for _, p := range untunableMounts {
-		if strings.HasPrefix(path, p) {
-			b.Backend.Logger().Error("sys: cannot tune this mount", "path", path)
-			return handleError(fmt.Errorf("sys: cannot tune '%s'", path))
+		if strings.HasPrefix(relPath, p) {
+			untunable = true
 		}
 	}
+	if untunable {
+		b.Backend.Logger().Error("sys: cannot tune this mount", "path", path)
+		return handleError(fmt.Errorf("sys: cannot tune '%s'", path))
+	}
 
 	mountEntry := b.Core.router.MatchingMountEntry(path)
 	if mountEntry == nil {
@@ -1564,15 +1999,25 @@ func (b *SystemBackend) handleTuneWriteCommon(
 
 		if newDefault != nil || newMax != nil {
 			lock.Lock()
-			defer lock.Unlock()
-
-			if err := b.tuneMountTTLs(path, mountEntry, newDefault, newMax); err != nil {
+			err := b.tuneMountTTLs(path, mountEntry, newDefault, newMax)
+			lock.Unlock()
+			if err != nil {
 				b.Backend.Logger().Error("sys: tuning failed", "path", path, "error", err)
 				return handleError(err)
 			}
 		}
 	}
 
+	if rawVal, ok := data.GetOk("description"); ok {
+		lock.Lock()
+		err := b.tuneMountDescription(path, mountEntry, rawVal.(string))
+		lock.Unlock()
+		if err != nil {
+			b.Backend.Logger().Error("sys: tuning failed", "path", path, "error", err)
+			return handleError(err)
+		}
+	}
+
 	return nil, nil
 }
 
@@ -1643,6 +2088,9 @@ func (b *SystemBackend) handleRenew(
 		return logical.ErrorResponse("lease_id must be specified"),
 			logical.ErrInvalidRequest
 	}
+	if resp, err := b.checkLeaseNamespace(req, leaseID); resp != nil || err != nil {
+		return resp, err
+	}
 	incrementRaw := data.Get("increment").(int)
 
 	// Convert the increment
@@ -1669,6 +2117,9 @@ func (b *SystemBackend) handleRevoke(
 		return logical.ErrorResponse("lease_id must be specified"),
 			logical.ErrInvalidRequest
 	}
+	if resp, err := b.checkLeaseNamespace(req, leaseID); resp != nil || err != nil {
+		return resp, err
+	}
 
 	// Invoke the expiration manager directly
 	if err := b.Core.expiration.Revoke(leaseID); err != nil {
@@ -1678,6 +2129,15 @@ func (b *SystemBackend) handleRevoke(
 	return nil, nil
 }
 
+// checkLeaseNamespace ensures that a lease managed through the system backend
+// of a namespace was issued within that namespace
+func (b *SystemBackend) checkLeaseNamespace(req *logical.Request, leaseID string) (*logical.Response, error) {
+	if !b.Core.leaseInNamespace(b.Core.requestNamespace(req), leaseID) {
+		return logical.ErrorResponse("lease is not within the namespace"), logical.ErrPermissionDenied
+	}
+	return nil, nil
+}
+
 // handleRevokePrefix is used to revoke a prefix with many LeaseIDs
 func (b *SystemBackend) handleRevokePrefix(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -1713,6 +2173,8 @@ func (b *SystemBackend) handleRevokePrefixCommon(
 // handleAuthTable handles the "auth" endpoint to provide the auth table
 func (b *SystemBackend) handleAuthTable(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.Core.requestNamespace(req)
+
 	b.Core.authLock.RLock()
 	defer b.Core.authLock.RUnlock()
 
@@ -1720,6 +2182,11 @@ func (b *SystemBackend) handleAuthTable(
 		Data: make(map[string]interface{}),
 	}
 	for _, entry := range b.Core.auth.Entries {
+		// Only list the auth mounts of the namespace
+		if b.Core.namespaceByPath(entry.Path) != ns {
+			continue
+		}
+
 		info := map[string]interface{}{
 			"type":        entry.Type,
 			"description": entry.Description,
@@ -1728,9 +2195,10 @@ func (b *SystemBackend) handleAuthTable(
 				"default_lease_ttl": int64(entry.Config.DefaultLeaseTTL.Seconds()),
 				"max_lease_ttl":     int64(entry.Config.MaxLeaseTTL.Seconds()),
 			},
-			"local": entry.Local,
+			"local":     entry.Local,
+			"seal_wrap": entry.SealWrap,
 		}
-		resp.Data[entry.Path] = info
+		resp.Data[strings.TrimPrefix(entry.Path, ns.Path)] = info
 	}
 	return resp, nil
 }
@@ -1768,14 +2236,23 @@ func (b *SystemBackend) handleEnableAuth(
 
 	path = sanitizeMountPath(path)
 
+	ns := b.Core.requestNamespace(req)
+	if err := b.Core.validateNamespaceMountPath(ns, path); err != nil {
+		return handleError(err)
+	}
+	if ns != rootNamespace && path == "token/" {
+		return handleError(logical.CodedError(403, fmt.Sprintf("cannot mount '%s'", path)))
+	}
+
 	// Create the mount entry
 	me := &MountEntry{
 		Table:       credentialTableType,
-		Path:        path,
+		Path:        ns.Path + path,
 		Type:        logicalType,
 		Description: description,
 		Config:      config,
 		Local:       local,
+		SealWrap:    data.Get("seal_wrap").(bool),
 	}
 
 	// Attempt enabling
@@ -1791,6 +2268,12 @@ func (b *SystemBackend) handleDisableAuth(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	path := data.Get("path").(string)
 	path = sanitizeMountPath(path)
+
+	ns := b.Core.requestNamespace(req)
+	if err := b.Core.validateNamespaceMountPath(ns, path); err != nil {
+		return handleError(err)
+	}
+	path = ns.Path + path
 	fullPath := credentialRoutePrefix + path
 
 	repState := b.Core.ReplicationState()
@@ -1818,7 +2301,7 @@ func (b *SystemBackend) handleDisableAuth(
 func (b *SystemBackend) handlePolicyList(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	// Get all the configured policies
-	policies, err := b.Core.policyStore.ListPolicies()
+	policies, err := b.Core.namespacePolicyStore(b.Core.requestNamespace(req)).ListPolicies()
 
 	// Add the special "root" policy
 	policies = append(policies, "root")
@@ -1835,7 +2318,7 @@ func (b *SystemBackend) handlePolicyRead(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
-	policy, err := b.Core.policyStore.GetPolicy(name)
+	policy, err := b.Core.namespacePolicyStore(b.Core.requestNamespace(req)).GetPolicy(name)
 	if err != nil {
 		return handleError(err)
 	}
@@ -1878,7 +2361,7 @@ func (b *SystemBackend) handlePolicySet(
 	}
 
 	// Update the policy
-	if err := b.Core.policyStore.SetPolicy(parse); err != nil {
+	if err := b.Core.namespacePolicyStore(b.Core.requestNamespace(req)).SetPolicy(parse); err != nil {
 		return handleError(err)
 	}
 	return nil, nil
@@ -1889,7 +2372,73 @@ func (b *SystemBackend) handlePolicyDelete(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	name := data.Get("name").(string)
 
-	if err := b.Core.policyStore.DeletePolicy(name); err != nil {
+	if err := b.Core.namespacePolicyStore(b.Core.requestNamespace(req)).DeletePolicy(name); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleNamespacesList handles the "namespaces" endpoint to list the child
+// namespaces of the namespace of the request
+func (b *SystemBackend) handleNamespacesList(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return logical.ListResponse(b.Core.childNamespaces(b.Core.requestNamespace(req))), nil
+}
+
+// childNamespace returns the child namespace with the given name of the
+// namespace of the request, or nil if it does not exist
+func (b *SystemBackend) childNamespace(req *logical.Request, name string) *namespace {
+	path := b.Core.requestNamespace(req).Path + name + "/"
+	if ns := b.Core.namespaceByPath(path); ns.Path == path {
+		return ns
+	}
+	return nil
+}
+
+// handleNamespaceRead handles the "namespaces/<path>" endpoint to read a
+// child namespace
+func (b *SystemBackend) handleNamespaceRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.childNamespace(req, data.Get("path").(string))
+	if ns == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   ns.ID,
+			"path": ns.Path,
+		},
+	}, nil
+}
+
+// handleNamespaceCreate handles the "namespaces/<path>" endpoint to create a
+// child namespace
+func (b *SystemBackend) handleNamespaceCreate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := b.Core.createNamespace(b.Core.requestNamespace(req), data.Get("path").(string))
+	if err != nil {
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"id":   ns.ID,
+			"path": ns.Path,
+		},
+	}, nil
+}
+
+// handleNamespaceDelete handles the "namespaces/<path>" endpoint to delete a
+// child namespace
+func (b *SystemBackend) handleNamespaceDelete(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns := b.childNamespace(req, data.Get("path").(string))
+	if ns == nil {
+		return nil, nil
+	}
+
+	if err := b.Core.deleteNamespace(ns); err != nil {
 		return handleError(err)
 	}
 	return nil, nil
@@ -2084,11 +2633,330 @@ func (b *SystemBackend) handleKeyStatus(
 		Data: map[string]interface{}{
 			"term":         info.Term,
 			"install_time": info.InstallTime.Format(time.RFC3339Nano),
+			"encryptions":  info.Encryptions,
 		},
 	}
 	return resp, nil
 }
 
+// handleMetrics returns the telemetry of this node, either as JSON or in
+// the Prometheus text format
+func (b *SystemBackend) handleMetrics(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if b.Core.metricSink == nil {
+		return logical.ErrorResponse("metrics are not enabled on this node"), nil
+	}
+
+	switch format := data.Get("format").(string); format {
+	case "":
+		snap := b.Core.metricSink.Snapshot()
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"gauges":   snap.Gauges,
+				"counters": snap.Counters,
+				"samples":  snap.Samples,
+			},
+		}, nil
+
+	case metricsutil.PrometheusFormat:
+		return &logical.Response{
+			Data: map[string]interface{}{
+				logical.HTTPContentType: metricsutil.PrometheusContentType,
+				logical.HTTPRawBody:     b.Core.metricSink.Prometheus(),
+				logical.HTTPStatusCode:  200,
+			},
+		}, nil
+
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unsupported metrics format %q", format)), nil
+	}
+}
+
+// handleInternalUIMounts lists the secret and auth mounts of the namespace
+// of the request that the token of the request has any capability within.
+// The path is unauthenticated, so that the mounts can be listed without read
+// on sys/mounts, but the token is still required to filter them, and the
+// request counts as a use of it.
+func (b *SystemBackend) handleInternalUIMounts(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if req.ClientToken == "" {
+		return nil, logical.ErrPermissionDenied
+	}
+	acl, te, err := b.Core.fetchACLandTokenEntry(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Core.useToken(te); err != nil {
+		return nil, err
+	}
+
+	secretMounts := make(map[string]interface{})
+	authMounts := make(map[string]interface{})
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"secret": secretMounts,
+			"auth":   authMounts,
+		},
+	}
+
+	// Policies are written relative to the namespace of the token, which has
+	// no capabilities outside of it
+	ns := b.Core.requestNamespace(req)
+	tokenNS := b.Core.namespaceByID(te.NamespaceID)
+	if tokenNS == nil || !strings.HasPrefix(ns.Path, tokenNS.Path) {
+		return resp, nil
+	}
+	aclPrefix := strings.TrimPrefix(ns.Path, tokenNS.Path)
+
+	mountInfo := func(entry *MountEntry) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        entry.Type,
+			"description": entry.Description,
+			"options":     entry.Options,
+			"local":       entry.Local,
+			"seal_wrap":   entry.SealWrap,
+		}
+	}
+
+	b.Core.mountsLock.RLock()
+	for _, entry := range b.Core.mounts.Entries {
+		if b.Core.namespaceByPath(entry.Path) != ns {
+			continue
+		}
+		path := strings.TrimPrefix(entry.Path, ns.Path)
+		if acl.hasMountAccess(aclPrefix + path) {
+			secretMounts[path] = mountInfo(entry)
+		}
+	}
+	b.Core.mountsLock.RUnlock()
+
+	b.Core.authLock.RLock()
+	for _, entry := range b.Core.auth.Entries {
+		if b.Core.namespaceByPath(entry.Path) != ns {
+			continue
+		}
+		path := strings.TrimPrefix(entry.Path, ns.Path)
+		if acl.hasMountAccess(aclPrefix + credentialRoutePrefix + path) {
+			authMounts[path] = mountInfo(entry)
+		}
+	}
+	b.Core.authLock.RUnlock()
+
+	return resp, nil
+}
+
+// handleInternalOpenAPI returns an OpenAPI document of the paths of the mounts
+// the token of the request can access, generated from the path definitions
+// returned in the root help of each backend
+func (b *SystemBackend) handleInternalOpenAPI(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	acl, te, err := b.Core.fetchACLandTokenEntry(req)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := framework.NewOASDocument(version.GetVersion().Version)
+	doc.Servers = []framework.OASServer{{URL: "/v1"}}
+
+	// The prefix of each mount in the document, by the path it is routed at
+	prefixes := make(map[string]string)
+
+	ns := b.Core.requestNamespace(req)
+	tokenNS := b.Core.namespaceByID(te.NamespaceID)
+	if tokenNS != nil && strings.HasPrefix(ns.Path, tokenNS.Path) {
+		aclPrefix := strings.TrimPrefix(ns.Path, tokenNS.Path)
+
+		b.Core.mountsLock.RLock()
+		for _, entry := range b.Core.mounts.Entries {
+			if b.Core.namespaceByPath(entry.Path) != ns {
+				continue
+			}
+			path := strings.TrimPrefix(entry.Path, ns.Path)
+			if acl.hasMountAccess(aclPrefix + path) {
+				prefixes[entry.Path] = path
+			}
+		}
+		b.Core.mountsLock.RUnlock()
+
+		b.Core.authLock.RLock()
+		for _, entry := range b.Core.auth.Entries {
+			if b.Core.namespaceByPath(entry.Path) != ns {
+				continue
+			}
+			path := credentialRoutePrefix + strings.TrimPrefix(entry.Path, ns.Path)
+			if acl.hasMountAccess(aclPrefix + path) {
+				prefixes[credentialRoutePrefix+entry.Path] = path
+			}
+		}
+		b.Core.authLock.RUnlock()
+	}
+
+	for routePath, prefix := range prefixes {
+		backend := b.Core.router.MatchingBackend(routePath)
+		if backend == nil {
+			continue
+		}
+
+		resp, err := backend.HandleRequest(&logical.Request{
+			Operation: logical.HelpOperation,
+			Storage:   b.Core.router.MatchingStorageView(routePath),
+		})
+		if err != nil {
+			b.Core.logger.Warn("sys: failed to get the help of mount", "path", prefix, "error", err)
+			continue
+		}
+		if resp == nil || resp.Data["openapi"] == nil {
+			continue
+		}
+
+		// Backends return the document as generic data, which may have
+		// come from a plugin
+		docJSON, err := json.Marshal(resp.Data["openapi"])
+		if err != nil {
+			return nil, err
+		}
+		var mountDoc framework.OASDocument
+		if err := json.Unmarshal(docJSON, &mountDoc); err != nil {
+			b.Core.logger.Warn("sys: failed to parse the OpenAPI document of mount", "path", prefix, "error", err)
+			continue
+		}
+		doc.AddPaths(prefix, &mountDoc)
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/json",
+			logical.HTTPRawBody:     body,
+			logical.HTTPStatusCode:  200,
+		},
+	}, nil
+}
+
+// handlePprofLookup returns a runtime profile of this node, such as its heap
+// or goroutines, in the format read by "go tool pprof"
+func (b *SystemBackend) handlePprofLookup(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	name := data.Get("name").(string)
+	profile := pprof.Lookup(name)
+	if profile == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown profile %q", name)), nil
+	}
+
+	var buf bytes.Buffer
+	if err := profile.WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	return pprofResponse(buf.Bytes()), nil
+}
+
+// pprofResponse returns the profile as the raw body of the response
+func pprofResponse(profile []byte) *logical.Response {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/octet-stream",
+			logical.HTTPRawBody:     profile,
+			logical.HTTPStatusCode:  200,
+		},
+	}
+}
+
+// toolsRandomMaxBytes bounds the random bytes returned by sys/tools/random
+const toolsRandomMaxBytes = 128 * 1024
+
+// handleToolsRandom returns random bytes from the CSPRNG of the server
+func (b *SystemBackend) handleToolsRandom(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	numBytes := data.Get("bytes").(int)
+	if urlBytes := data.Get("urlbytes").(string); urlBytes != "" {
+		var err error
+		numBytes, err = strconv.Atoi(urlBytes)
+		if err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error parsing url-set byte count: %s", err)), nil
+		}
+	}
+	format := data.Get("format").(string)
+
+	if numBytes < 1 {
+		return logical.ErrorResponse(`"bytes" cannot be less than 1`), nil
+	}
+	if numBytes > toolsRandomMaxBytes {
+		return logical.ErrorResponse(fmt.Sprintf(`"bytes" cannot be greater than %d`, toolsRandomMaxBytes)), nil
+	}
+
+	switch format {
+	case "hex", "base64":
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unsupported encoding format %s; must be \"hex\" or \"base64\"", format)), nil
+	}
+
+	randBytes, err := uuid.GenerateRandomBytes(numBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"random_bytes": encodeToolsOutput(randBytes, format),
+		},
+	}, nil
+}
+
+// handleToolsHash returns the hash sum of the given input
+func (b *SystemBackend) handleToolsHash(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	format := data.Get("format").(string)
+	algorithm := data.Get("urlalgorithm").(string)
+	if algorithm == "" {
+		algorithm = data.Get("algorithm").(string)
+	}
+
+	input, err := base64.StdEncoding.DecodeString(data.Get("input").(string))
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("unable to decode input as base64: %s", err)), logical.ErrInvalidRequest
+	}
+
+	switch format {
+	case "hex", "base64":
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unsupported encoding format %s; must be \"hex\" or \"base64\"", format)), nil
+	}
+
+	var hf hash.Hash
+	switch algorithm {
+	case "sha2-224":
+		hf = sha256.New224()
+	case "sha2-256":
+		hf = sha256.New()
+	case "sha2-384":
+		hf = sha512.New384()
+	case "sha2-512":
+		hf = sha512.New()
+	default:
+		return logical.ErrorResponse(fmt.Sprintf("unsupported algorithm %s", algorithm)), nil
+	}
+	hf.Write(input)
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"sum": encodeToolsOutput(hf.Sum(nil), format),
+		},
+	}, nil
+}
+
+// encodeToolsOutput encodes the output of the tools endpoints in the given
+// format, which has already been validated
+func encodeToolsOutput(output []byte, format string) string {
+	if format == "hex" {
+		return hex.EncodeToString(output)
+	}
+	return base64.StdEncoding.EncodeToString(output)
+}
+
 // handleRotate is used to trigger a key rotation
 func (b *SystemBackend) handleRotate(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -2099,39 +2967,44 @@ func (b *SystemBackend) handleRotate(
 		return logical.ErrorResponse("cannot rotate on a replication secondary"), nil
 	}
 
-	// Rotate to the new term
-	newTerm, err := b.Core.barrier.Rotate()
-	if err != nil {
-		b.Backend.Logger().Error("sys: failed to create new encryption key", "error", err)
+	if _, err := b.Core.rotateBarrierKey(); err != nil {
 		return handleError(err)
 	}
-	b.Backend.Logger().Info("sys: installed new encryption key")
+	return nil, nil
+}
 
-	// In HA mode, we need to an upgrade path for the standby instances
-	if b.Core.ha != nil {
-		// Create the upgrade path to the new term
-		if err := b.Core.barrier.CreateUpgrade(newTerm); err != nil {
-			b.Backend.Logger().Error("sys: failed to create new upgrade", "term", newTerm, "error", err)
-		}
+// handleRotateConfigRead returns the automatic key rotation configuration
+func (b *SystemBackend) handleRotateConfigRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config := b.Core.KeyRotationConfig()
 
-		// Schedule the destroy of the upgrade path
-		time.AfterFunc(keyRotateGracePeriod, func() {
-			if err := b.Core.barrier.DestroyUpgrade(newTerm); err != nil {
-				b.Backend.Logger().Error("sys: failed to destroy upgrade", "term", newTerm, "error", err)
-			}
-		})
-	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"enabled":        config.Enabled,
+			"max_operations": config.MaxOperations,
+		},
+	}, nil
+}
 
-	// Write to the canary path, which will force a synchronous truing during
-	// replication
-	if err := b.Core.barrier.Put(&Entry{
-		Key:   coreKeyringCanaryPath,
-		Value: []byte(fmt.Sprintf("new-rotation-term-%d", newTerm)),
-	}); err != nil {
-		b.Core.logger.Error("core: error saving keyring canary", "error", err)
-		return nil, fmt.Errorf("failed to save keyring canary: %v", err)
+// handleRotateConfigUpdate sets the automatic key rotation configuration
+func (b *SystemBackend) handleRotateConfigUpdate(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	config := b.Core.KeyRotationConfig()
+
+	if enabledRaw, ok := data.GetOk("enabled"); ok {
+		config.Enabled = enabledRaw.(bool)
+	}
+	if maxOperationsRaw, ok := data.GetOk("max_operations"); ok {
+		maxOperations := maxOperationsRaw.(int)
+		if maxOperations < 0 {
+			return logical.ErrorResponse("max_operations cannot be negative"), logical.ErrInvalidRequest
+		}
+		config.MaxOperations = uint64(maxOperations)
 	}
 
+	if err := b.Core.saveKeyRotationConfig(config); err != nil {
+		return handleError(err)
+	}
 	return nil, nil
 }
 
@@ -2148,6 +3021,24 @@ func (b *SystemBackend) handleWrappingPubkey(
 	}, nil
 }
 
+// checkWrappingTokenNamespace ensures that a wrapping token used through the
+// system backend of a namespace was issued within that namespace
+func (b *SystemBackend) checkWrappingTokenNamespace(req *logical.Request, token string) (*logical.Response, error) {
+	ns := b.Core.requestNamespace(req)
+	if ns.ID == rootNamespaceID {
+		return nil, nil
+	}
+
+	te, err := b.Core.tokenStore.Lookup(token)
+	if err != nil {
+		return nil, err
+	}
+	if te != nil && !b.Core.tokenInNamespace(ns, te) {
+		return logical.ErrorResponse("wrapping token is not within the namespace"), logical.ErrPermissionDenied
+	}
+	return nil, nil
+}
+
 func (b *SystemBackend) handleWrappingWrap(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	if req.WrapInfo == nil || req.WrapInfo.TTL == 0 {
@@ -2180,6 +3071,10 @@ func (b *SystemBackend) handleWrappingUnwrap(
 		token = req.ClientToken
 	}
 
+	if resp, err := b.checkWrappingTokenNamespace(req, token); resp != nil || err != nil {
+		return resp, err
+	}
+
 	if thirdParty {
 		// Use the token to decrement the use count to avoid a second operation on the token.
 		_, err := b.Core.tokenStore.UseTokenByID(token)
@@ -2232,6 +3127,155 @@ func (b *SystemBackend) handleWrappingUnwrap(
 	return resp, nil
 }
 
+func (b *SystemBackend) handleControlGroupAuthorize(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	accessor := data.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing \"accessor\" value in input"), logical.ErrInvalidRequest
+	}
+
+	te, err := b.Core.tokenStore.Lookup(req.ClientToken)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil || te.EntityID == "" {
+		return logical.ErrorResponse("the token is not tied to an entity"), logical.ErrInvalidRequest
+	}
+
+	approved, err := b.Core.authorizeControlGroupRequest(accessor, te.EntityID)
+	if err != nil {
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"approved": approved,
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleControlGroupRequest(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	accessor := data.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing \"accessor\" value in input"), logical.ErrInvalidRequest
+	}
+
+	_, cgReq, err := b.Core.controlGroupRequestByAccessor(accessor)
+	if err != nil {
+		return nil, err
+	}
+	if cgReq == nil {
+		return logical.ErrorResponse("no control group request found for the accessor"), logical.ErrInvalidRequest
+	}
+
+	authorizations := make([]map[string]interface{}, 0, len(cgReq.AuthorizationEntityIDs))
+	for _, entityID := range cgReq.AuthorizationEntityIDs {
+		authorizations = append(authorizations, map[string]interface{}{
+			"entity_id":   entityID,
+			"entity_name": b.Core.identityStore.entityName(entityID),
+		})
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"approved":       b.Core.controlGroupApproved(cgReq),
+			"request_path":   cgReq.RequestPath,
+			"authorizations": authorizations,
+		},
+	}
+	if cgReq.RequestEntityID != "" {
+		resp.Data["request_entity"] = map[string]interface{}{
+			"id":   cgReq.RequestEntityID,
+			"name": b.Core.identityStore.entityName(cgReq.RequestEntityID),
+		}
+	}
+	return resp, nil
+}
+
+// handleDRPrimaryEnable makes the cluster a disaster recovery primary
+func (b *SystemBackend) handleDRPrimaryEnable(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.enableDRPrimary(); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleDRSecondaryToken generates the activation token of a secondary
+func (b *SystemBackend) handleDRSecondaryToken(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	id := data.Get("id").(string)
+	if id == "" {
+		return logical.ErrorResponse("missing \"id\" value in input"), logical.ErrInvalidRequest
+	}
+
+	ttl := time.Duration(data.Get("ttl").(int)) * time.Second
+	if ttl <= 0 {
+		return logical.ErrorResponse("\"ttl\" must be positive"), logical.ErrInvalidRequest
+	}
+
+	token, err := b.Core.generateDRSecondaryToken(id, ttl)
+	if err != nil {
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"token": token,
+		},
+	}, nil
+}
+
+// handleDRRevokeSecondary stops a secondary from streaming from the primary
+func (b *SystemBackend) handleDRRevokeSecondary(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	id := data.Get("id").(string)
+	if id == "" {
+		return logical.ErrorResponse("missing \"id\" value in input"), logical.ErrInvalidRequest
+	}
+
+	if err := b.Core.revokeDRSecondary(id); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleDRPrimaryDemote turns the primary into a secondary and seals it
+func (b *SystemBackend) handleDRPrimaryDemote(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.demoteDRPrimary(); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleDRPrimaryDisable disables disaster recovery replication on the
+// primary
+func (b *SystemBackend) handleDRPrimaryDisable(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := b.Core.disableDRPrimary(); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleDRSecondaryEnable makes the cluster a disaster recovery secondary
+// and seals it
+func (b *SystemBackend) handleDRSecondaryEnable(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	token := data.Get("token").(string)
+	if token == "" {
+		return logical.ErrorResponse("missing \"token\" value in input"), logical.ErrInvalidRequest
+	}
+
+	if err := b.Core.enableDRSecondary(token, data.Get("primary_api_addr").(string),
+		data.Get("ca_file").(string), data.Get("ca_path").(string)); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
 func (b *SystemBackend) handleWrappingLookup(
 	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	// This ordering of lookups has been validated already in the wrapping
@@ -2244,6 +3288,10 @@ func (b *SystemBackend) handleWrappingLookup(
 		}
 	}
 
+	if resp, err := b.checkWrappingTokenNamespace(req, token); resp != nil || err != nil {
+		return resp, err
+	}
+
 	cubbyReq := &logical.Request{
 		Operation:   logical.ReadOperation,
 		Path:        "cubbyhole/wrapinfo",
@@ -2304,6 +3352,10 @@ func (b *SystemBackend) handleWrappingRewrap(
 		token = req.ClientToken
 	}
 
+	if resp, err := b.checkWrappingTokenNamespace(req, token); resp != nil || err != nil {
+		return resp, err
+	}
+
 	if thirdParty {
 		// Use the token to decrement the use count to avoid a second operation on the token.
 		_, err := b.Core.tokenStore.UseTokenByID(token)
@@ -2423,6 +3475,28 @@ This path responds to the following HTTP methods.
         Clears the CORS configuration and disables acceptance of CORS requests.
 		`,
 	},
+	"config/reload": {
+		"Reloads the server configuration of this node.",
+		`
+This path responds to the following HTTP methods.
+
+    PUT /
+        Reloads the configuration files of this node, the same way as when it
+        receives SIGHUP: TLS certificates of listeners, the log level, and
+        file audit devices are reloaded.
+		`,
+	},
+	"config/state": {
+		"Returns the server configuration of this node.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /sanitized
+        Returns the configuration this node was started with. Values that may
+        be secrets, such as seal credentials, TLS keys and storage tokens, are
+        removed.
+		`,
+	},
 	"init": {
 		"Initializes or returns the initialization status of the Vault.",
 		`
@@ -2541,6 +3615,11 @@ and max_lease_ttl.`,
 and is unaffected by replication.`,
 	},
 
+	"seal_wrap": {
+		`Encrypt the storage of the mount with the seal in addition to the
+barrier. Requires a seal supporting seal wrapping.`,
+	},
+
 	"tune_default_lease_ttl": {
 		`The default lease TTL for this mount.`,
 	},
@@ -2719,6 +3798,28 @@ or delete a policy.
 		"",
 	},
 
+	"namespaces-list": {
+		"List the child namespaces of the namespace.",
+		`
+List the names of the direct child namespaces of the namespace of the request.
+		`,
+	},
+
+	"namespace": {
+		"Create, read, or delete a child namespace.",
+		`
+Namespaces isolate the mounts, policies and tokens of separate tenants of
+Vault. Requests are made within a namespace by setting the X-Vault-Namespace
+header to its path. A namespace can only be deleted once its mounts and
+child namespaces are removed.
+		`,
+	},
+
+	"namespace-path": {
+		`The name of the child namespace. Example: "team1"`,
+		"",
+	},
+
 	"audit-hash": {
 		"The hash of the given string via the given audit backend",
 		"",
@@ -2770,10 +3871,114 @@ Enable a new audit backend or disable an existing backend.
 	"key-status": {
 		"Provides information about the backend encryption key.",
 		`
-		Provides the current backend encryption key term and installation time.
+		Provides the current backend encryption key term, installation time and
+		the number of encryptions performed with it.
 		`,
 	},
 
+	"metrics": {
+		"Export the telemetry of this node.",
+		`
+		Returns the cumulative value of the counters, gauges and samples that
+		this node has emitted since it started. By default they are returned
+		as JSON; set the format to "prometheus" to get them in the Prometheus
+		text exposition format for scraping.
+		`,
+	},
+
+	"metrics_format": {
+		`The format of the response. Either empty for JSON, or "prometheus".`,
+		"",
+	},
+
+	"internal-ui-mounts": {
+		"List the mounts the token of the request can access.",
+		`
+		Returns the secret and auth mounts that the token of the request has
+		any capability within, with their type, description and options. It
+		does not require read on sys/mounts, so that user interfaces can show
+		every user the mounts they can use.
+		`,
+	},
+
+	"internal-specs-openapi": {
+		"Generate an OpenAPI document of the API.",
+		`
+		Returns an OpenAPI document describing the paths of the secret and auth
+		mounts that the token of the request has any capability within. The
+		document is generated from the path definitions of the backends, and
+		leaves out paths that are not framework based or that cannot be
+		expressed as OpenAPI paths.
+		`,
+	},
+
+	"pprof": {
+		"Export a runtime profile of this node.",
+		`
+		Returns the heap or goroutine profile of this node in the format read
+		by "go tool pprof". These paths are root-protected, since profiles can
+		reveal sensitive information held in memory.
+		`,
+	},
+
+	"pprof_name": {
+		`The name of the profile, either "heap" or "goroutine".`,
+		"",
+	},
+
+	"tools-random": {
+		"Generate random bytes.",
+		`
+		Returns high-entropy random bytes from the CSPRNG of the server,
+		encoded as base64 or hex. The number of bytes can be given in the path
+		or in the body, and is at most 131072.
+		`,
+	},
+
+	"tools_random_urlbytes": {
+		"The number of bytes to generate, given in the path.",
+		"",
+	},
+
+	"tools_random_bytes": {
+		"The number of bytes to generate. Defaults to 32 (256 bits).",
+		"",
+	},
+
+	"tools_random_format": {
+		`The encoding of the output, either "hex" or "base64". Defaults to "base64".`,
+		"",
+	},
+
+	"tools-hash": {
+		"Generate a hash sum for input data.",
+		`
+		Returns the hash sum of the given base64-encoded input, using the
+		sha2-224, sha2-256, sha2-384 or sha2-512 algorithm. No key is involved;
+		for a keyed hash use the hmac endpoint of the transit backend.
+		`,
+	},
+
+	"tools_hash_input": {
+		"The base64-encoded input data.",
+		"",
+	},
+
+	"tools_hash_urlalgorithm": {
+		"The algorithm to use, given in the path.",
+		"",
+	},
+
+	"tools_hash_algorithm": {
+		`The algorithm to use: "sha2-224", "sha2-256", "sha2-384" or "sha2-512". Defaults to "sha2-256".`,
+		"",
+	},
+
+	"tools_hash_format": {
+		`The encoding of the output, either "hex" or "base64". Defaults to "hex".`,
+		"",
+	},
+
 	"rotate": {
 		"Rotates the backend encryption key used to persist data.",
 		`
@@ -2783,6 +3988,63 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"raft-configuration": {
+		"Returns the servers of the Raft cluster.",
+		`
+		Lists the node ID and cluster address of every server in the Raft
+		cluster, along with whether it is the leader and whether it votes.
+		This is only available when Vault uses the raft storage backend.
+		`,
+	},
+
+	"raft-join": {
+		"Adds a server to the Raft cluster.",
+		`
+		Adds the server with the given node ID and cluster address to the Raft
+		cluster as a voter. The server must be started with the raft storage
+		backend and without 'bootstrap' set. This must be called on the active
+		node.
+		`,
+	},
+
+	"raft-remove-peer": {
+		"Removes a server from the Raft cluster.",
+		`
+		Removes the server with the given node ID from the Raft cluster. This
+		must be called on the active node.
+		`,
+	},
+
+	"raft-node-id": {
+		"The node ID of the server.",
+		"",
+	},
+
+	"raft-address": {
+		"The cluster address of the server, as host:port.",
+		"",
+	},
+
+	"rotate_config": {
+		"Configures the automatic rotation of the backend encryption key.",
+		`
+		The backend encryption key is rotated automatically once the active node
+		has performed the configured number of encryptions with it. The number
+		of encryptions is checkpointed to storage periodically and when the node
+		is sealed or steps down, and is carried over by the next active node.
+		`,
+	},
+
+	"rotate_config_enabled": {
+		"Whether the key is rotated automatically. Defaults to true.",
+		"",
+	},
+
+	"rotate_config_max_operations": {
+		"The number of encryptions after which the key is rotated. Defaults to 3865470566, which is also the maximum.",
+		"",
+	},
+
 	"rekey_backup": {
 		"Allows fetching or deleting the backup of the rotated unseal keys.",
 		"",
@@ -2842,6 +4104,72 @@ it.`,
 		`Rotates a response-wrapped token; the output is a new token with the same
 		response wrapped inside and the same creation TTL. The original token is revoked.`,
 	},
+
+	"control-group-authorize": {
+		"Authorizes a request subject to a control group.",
+		`Authorizes the request whose response is wrapped by the token with the given
+		accessor, on behalf of the entity of the calling token. The response can be
+		unwrapped once each factor of the control group is authorized by enough members
+		of its groups.`,
+	},
+
+	"control-group-request": {
+		"Looks up the status of a request subject to a control group.",
+		`Returns whether the request whose response is wrapped by the token with the given
+		accessor is approved, along with the requesting entity and the entities that
+		authorized it.`,
+	},
+
+	"replication-dr-primary-enable": {
+		"Makes the cluster a disaster recovery primary.",
+		`Starts logging the writes to the storage of the cluster, from which its
+		disaster recovery secondaries are kept up to date. Secondaries are added with
+		activation tokens generated by the primary.`,
+	},
+
+	"replication-dr-secondary-token": {
+		"Generates the activation token of a disaster recovery secondary.",
+		`Generates the token a cluster is made a disaster recovery secondary of this
+		primary with. The token must be used before its TTL elapses. Generating a token
+		for an existing secondary replaces its credentials.`,
+	},
+
+	"replication-dr-secondary-id": {
+		"The identifier of the secondary.",
+		"",
+	},
+
+	"replication-dr-revoke-secondary": {
+		"Revokes a disaster recovery secondary.",
+		`Revokes the credentials of the secondary, so that it can no longer stream the
+		storage of the primary.`,
+	},
+
+	"replication-dr-primary-demote": {
+		"Demotes the disaster recovery primary.",
+		`Turns the primary into a secondary without a primary, and seals it. It keeps
+		its storage, and can be pointed to a new primary with the update-primary
+		endpoint, or promoted again.`,
+	},
+
+	"replication-dr-primary-disable": {
+		"Disables disaster recovery replication on the primary.",
+		`Stops logging the writes to the storage of the cluster. Its secondaries can no
+		longer stream from it.`,
+	},
+
+	"replication-dr-secondary-enable": {
+		"Makes the cluster a disaster recovery secondary.",
+		`Makes the cluster a disaster recovery secondary of the primary that generated
+		the activation token, and seals it. The storage of the cluster is replaced by
+		that of the primary, including its tokens and leases. The cluster cannot be
+		unsealed; it is promoted with the key shares of the primary instead.`,
+	},
+
+	"control-group-accessor": {
+		"The accessor of the wrapping token of the request.",
+		"",
+	},
 	"audited-headers-name": {
 		"Configures the headers sent to the audit logs.",
 		`
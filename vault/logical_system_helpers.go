@@ -83,3 +83,32 @@ func (b *SystemBackend) tuneMountTTLs(path string, me *MountEntry, newDefault, n
 
 	return nil
 }
+
+// tuneMountDescription is used to update the description of a mount point
+func (b *SystemBackend) tuneMountDescription(path string, me *MountEntry, newDesc string) error {
+	if newDesc == me.Description {
+		return nil
+	}
+
+	origDesc := me.Description
+	me.Description = newDesc
+
+	// Update the mount table
+	var err error
+	switch {
+	case strings.HasPrefix(path, "auth/"):
+		err = b.Core.persistAuth(b.Core.auth, me.Local)
+	default:
+		err = b.Core.persistMounts(b.Core.mounts, me.Local)
+	}
+	if err != nil {
+		me.Description = origDesc
+		return fmt.Errorf("failed to update mount table, rolling back description change")
+	}
+
+	if b.Core.logger.IsInfo() {
+		b.Core.logger.Info("core: mount tuning of description successful", "path", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package vault
+
+import (
+	"strings"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+// raftStoragePaths returns the paths managing the servers of the Raft
+// cluster when Vault uses Raft storage. Snapshots are served by the HTTP
+// layer, since they are streamed.
+func (b *SystemBackend) raftStoragePaths() []*framework.Path {
+	return []*framework.Path{
+		&framework.Path{
+			Pattern: "storage/raft/configuration$",
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: b.handleRaftConfigurationRead,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raft-configuration"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raft-configuration"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "storage/raft/join$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"node_id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["raft-node-id"][0]),
+				},
+				"address": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["raft-address"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleRaftJoin,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raft-join"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raft-join"][1]),
+		},
+
+		&framework.Path{
+			Pattern: "storage/raft/remove-peer$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"node_id": &framework.FieldSchema{
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["raft-node-id"][0]),
+				},
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: b.handleRaftRemovePeer,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["raft-remove-peer"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["raft-remove-peer"][1]),
+		},
+	}
+}
+
+// handleRaftConfigurationRead lists the servers of the Raft cluster
+func (b *SystemBackend) handleRaftConfigurationRead(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raftBackend, err := b.Core.raftStorage()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	peers, err := raftBackend.Peers()
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]map[string]interface{}, 0, len(peers))
+	for _, peer := range peers {
+		servers = append(servers, map[string]interface{}{
+			"node_id": peer.NodeID,
+			"address": peer.Address,
+			"leader":  peer.Leader,
+			"voter":   peer.Voter,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"servers": servers,
+		},
+	}, nil
+}
+
+// handleRaftJoin adds a node to the Raft cluster
+func (b *SystemBackend) handleRaftJoin(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raftBackend, err := b.Core.raftStorage()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	nodeID := data.Get("node_id").(string)
+	if nodeID == "" {
+		return logical.ErrorResponse("'node_id' must be set"), logical.ErrInvalidRequest
+	}
+	address := data.Get("address").(string)
+	if address == "" {
+		return logical.ErrorResponse("'address' must be set"), logical.ErrInvalidRequest
+	}
+
+	if err := raftBackend.AddPeer(nodeID, address); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleRaftRemovePeer removes a node from the Raft cluster
+func (b *SystemBackend) handleRaftRemovePeer(
+	req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	raftBackend, err := b.Core.raftStorage()
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	nodeID := data.Get("node_id").(string)
+	if nodeID == "" {
+		return logical.ErrorResponse("'node_id' must be set"), logical.ErrInvalidRequest
+	}
+
+	if err := raftBackend.RemovePeer(nodeID); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
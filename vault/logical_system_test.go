@@ -2,7 +2,9 @@ package vault
 
 import (
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -13,11 +15,14 @@ import (
 	"time"
 
 	"github.com/fatih/structs"
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/vault/audit"
 	"github.com/hashicorp/vault/helper/builtinplugins"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/pluginutil"
 	"github.com/hashicorp/vault/helper/salt"
 	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -25,20 +30,28 @@ func TestSystemBackend_RootPaths(t *testing.T) {
 	expected := []string{
 		"auth/*",
 		"remount",
+		"namespaces/*",
 		"audit",
 		"audit/*",
 		"raw/*",
 		"replication/primary/secondary-token",
 		"replication/reindex",
+		"replication/dr/primary/*",
+		"replication/dr/secondary/*",
 		"rotate",
+		"pprof/*",
+		"rotate/config",
 		"config/cors",
 		"config/auditing/*",
+		"config/state/sanitized",
+		"config/reload",
 		"plugins/catalog/*",
 		"revoke-prefix/*",
 		"revoke-force/*",
 		"leases/revoke-prefix/*",
 		"leases/revoke-force/*",
 		"leases/lookup/*",
+		"storage/raft/*",
 	}
 
 	b := testSystemBackend(t)
@@ -124,7 +137,8 @@ func TestSystemBackend_mounts(t *testing.T) {
 				"max_lease_ttl":     resp.Data["secret/"].(map[string]interface{})["config"].(map[string]interface{})["max_lease_ttl"].(int64),
 				"force_no_cache":    false,
 			},
-			"local": false,
+			"local":     false,
+			"seal_wrap": false,
 		},
 		"sys/": map[string]interface{}{
 			"type":        "system",
@@ -135,7 +149,8 @@ func TestSystemBackend_mounts(t *testing.T) {
 				"max_lease_ttl":     resp.Data["sys/"].(map[string]interface{})["config"].(map[string]interface{})["max_lease_ttl"].(int64),
 				"force_no_cache":    false,
 			},
-			"local": false,
+			"local":     false,
+			"seal_wrap": false,
 		},
 		"cubbyhole/": map[string]interface{}{
 			"description": "per-token private secret storage",
@@ -146,7 +161,20 @@ func TestSystemBackend_mounts(t *testing.T) {
 				"max_lease_ttl":     resp.Data["cubbyhole/"].(map[string]interface{})["config"].(map[string]interface{})["max_lease_ttl"].(int64),
 				"force_no_cache":    false,
 			},
-			"local": true,
+			"local":     true,
+			"seal_wrap": false,
+		},
+		"identity/": map[string]interface{}{
+			"description": "identity store",
+			"type":        "identity",
+			"accessor":    resp.Data["identity/"].(map[string]interface{})["accessor"],
+			"config": map[string]interface{}{
+				"default_lease_ttl": resp.Data["identity/"].(map[string]interface{})["config"].(map[string]interface{})["default_lease_ttl"].(int64),
+				"max_lease_ttl":     resp.Data["identity/"].(map[string]interface{})["config"].(map[string]interface{})["max_lease_ttl"].(int64),
+				"force_no_cache":    false,
+			},
+			"local":     false,
+			"seal_wrap": false,
 		},
 	}
 	if !reflect.DeepEqual(resp.Data, exp) {
@@ -1124,7 +1152,8 @@ func TestSystemBackend_authTable(t *testing.T) {
 				"default_lease_ttl": int64(0),
 				"max_lease_ttl":     int64(0),
 			},
-			"local": false,
+			"local":     false,
+			"seal_wrap": false,
 		},
 	}
 	if !reflect.DeepEqual(resp.Data, exp) {
@@ -1554,12 +1583,337 @@ func TestSystemBackend_keyStatus(t *testing.T) {
 	exp := map[string]interface{}{
 		"term": 1,
 	}
+	if _, ok := resp.Data["encryptions"].(uint64); !ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	delete(resp.Data, "encryptions")
 	delete(resp.Data, "install_time")
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
 	}
 }
 
+func TestSystemBackend_configReload(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "config/reload")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected an error without a reload function: %#v", resp)
+	}
+
+	var reloaded bool
+	c.reloadConfigFunc = func() error {
+		reloaded = true
+		return nil
+	}
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if !reloaded {
+		t.Fatal("configuration was not reloaded")
+	}
+}
+
+func TestSystemBackend_configStateSanitized(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "config/state/sanitized")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected an error without a configuration: %#v", resp)
+	}
+
+	c.sanitizedConfig = map[string]interface{}{
+		"cluster_name": "testcluster",
+	}
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(resp.Data, c.sanitizedConfig) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, c.sanitizedConfig)
+	}
+}
+
+func TestSystemBackend_metrics(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "metrics")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected an error without a metric sink: %#v", resp)
+	}
+
+	c.metricSink = metricsutil.NewPrometheusSink()
+	c.metricSink.IncrCounter([]string{"vault", "test"}, 1)
+
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := map[string]float64{"vault_test": 1}
+	if !reflect.DeepEqual(resp.Data["counters"], exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data["counters"], exp)
+	}
+
+	req.Data["format"] = metricsutil.PrometheusFormat
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data[logical.HTTPContentType] != metricsutil.PrometheusContentType {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	body := "# TYPE vault_test counter\nvault_test 1\n"
+	if string(resp.Data[logical.HTTPRawBody].([]byte)) != body {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req.Data["format"] = "bogus"
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected an error for an unknown format: %#v", resp)
+	}
+}
+
+var internalUIMountsPolicy = `
+name = "ui-test"
+path "secret/foo/*" {
+	capabilities = ["read"]
+}
+path "other/*" {
+	capabilities = ["deny"]
+}
+`
+
+func TestSystemBackend_internalUIMounts(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/mounts/other")
+	req.ClientToken = root
+	req.Data["type"] = "generic"
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	policy, _ := Parse(internalUIMountsPolicy)
+	if err := c.policyStore.SetPolicy(policy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	testCoreMakeToken(t, c, root, "tokenid", "", []string{"ui-test"})
+
+	mounts := func(token string) (map[string]interface{}, map[string]interface{}) {
+		req := logical.TestRequest(t, logical.ReadOperation, "sys/internal/ui/mounts")
+		req.ClientToken = token
+		resp, err := c.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return resp.Data["secret"].(map[string]interface{}), resp.Data["auth"].(map[string]interface{})
+	}
+
+	// The root token sees every mount
+	secret, auth := mounts(root)
+	for _, path := range []string{"secret/", "other/", "sys/", "cubbyhole/"} {
+		if _, ok := secret[path]; !ok {
+			t.Fatalf("missing %s: %#v", path, secret)
+		}
+	}
+	if _, ok := auth["token/"]; !ok {
+		t.Fatalf("missing token/: %#v", auth)
+	}
+
+	// The token only sees the mounts it has capabilities within
+	secret, auth = mounts("tokenid")
+	if _, ok := secret["secret/"]; !ok {
+		t.Fatalf("missing secret/: %#v", secret)
+	}
+	if _, ok := secret["other/"]; ok {
+		t.Fatalf("unexpected other/: %#v", secret)
+	}
+	if secret["secret/"].(map[string]interface{})["type"] != "generic" {
+		t.Fatalf("bad: %#v", secret["secret/"])
+	}
+
+	// A token is still required
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/internal/ui/mounts")
+	if _, err := c.HandleRequest(req); err != logical.ErrPermissionDenied {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Listing the mounts counts as a use of the token
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+	req.ClientToken = root
+	req.Data["policies"] = []string{"ui-test"}
+	req.Data["num_uses"] = 2
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	limited := resp.Auth.ClientToken
+	mounts(limited)
+	mounts(limited)
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/internal/ui/mounts")
+	req.ClientToken = limited
+	if _, err := c.HandleRequest(req); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v", err)
+	}
+	if te, err := c.tokenStore.Lookup(limited); err != nil || te != nil {
+		t.Fatalf("expected the token to be revoked: %#v %v", te, err)
+	}
+}
+
+func TestSystemBackend_internalOpenAPI(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/internal/specs/openapi")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data[logical.HTTPContentType] != "application/json" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	var doc framework.OASDocument
+	if err := json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &doc); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if doc.Version != framework.OASVersion || doc.Info.Version == "" {
+		t.Fatalf("bad: %#v", doc)
+	}
+
+	// The paths of the system backend and the token store are documented
+	// under their mounts
+	for _, path := range []string{"/sys/mounts/{path}", "/sys/internal/specs/openapi", "/auth/token/lookup-self"} {
+		if _, ok := doc.Paths[path]; !ok {
+			t.Fatalf("missing %s", path)
+		}
+	}
+	if doc.Paths["/sys/mounts/{path}"].Post == nil {
+		t.Fatalf("bad: %#v", doc.Paths["/sys/mounts/{path}"])
+	}
+}
+
+func TestSystemBackend_pprof(t *testing.T) {
+	b := testSystemBackend(t)
+
+	for _, path := range []string{"pprof/heap", "pprof/goroutine"} {
+		req := logical.TestRequest(t, logical.ReadOperation, path)
+		resp, err := b.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("%s: err: %v", path, err)
+		}
+		if resp.Data[logical.HTTPContentType] != "application/octet-stream" {
+			t.Fatalf("%s: bad: %#v", path, resp.Data)
+		}
+		if len(resp.Data[logical.HTTPRawBody].([]byte)) == 0 {
+			t.Fatalf("%s: empty profile", path)
+		}
+	}
+}
+
+func TestSystemBackend_toolsRandom(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "tools/random")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	random, err := base64.StdEncoding.DecodeString(resp.Data["random_bytes"].(string))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(random) != 32 {
+		t.Fatalf("bad: %d", len(random))
+	}
+
+	// The number of bytes can be given in the path
+	req.Path = "tools/random/16"
+	req.Data["format"] = "hex"
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Data["random_bytes"].(string)) != 32 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	for _, path := range []string{"tools/random/0", "tools/random/1000000"} {
+		req.Path = path
+		resp, err = b.HandleRequest(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !resp.IsError() {
+			t.Fatalf("%s: expected an error: %#v", path, resp)
+		}
+	}
+}
+
+func TestSystemBackend_toolsHash(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "tools/hash")
+	req.Data["input"] = "dGhlIHF1aWNrIGJyb3duIGZveA=="
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["sum"] != "9ecb36561341d18eb65484e833efea61edc74b84cf5e6ae1b81c63533e25fc8f" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The algorithm can be given in the path
+	req.Path = "tools/hash/sha2-224"
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["sum"] != "ea074a96cabc5a61f8298a2c470f019074642631a49e1c5e2f560865" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req.Path = "tools/hash"
+	req.Data["algorithm"] = "sha2-512"
+	req.Data["format"] = "base64"
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Data["sum"] != "2dOA8puXrWodkumH2D+loCZTMB4QBt0rzVGvpZqRR+nK7a+JUhq8DwtoKtzUf7USuDQ8g0oy8yb+m+8AVCzohw==" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	req.Data["algorithm"] = "md5"
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp.IsError() {
+		t.Fatalf("expected an error for an unsupported algorithm: %#v", resp)
+	}
+}
+
 func TestSystemBackend_rotate(t *testing.T) {
 	b := testSystemBackend(t)
 
@@ -1581,12 +1935,64 @@ func TestSystemBackend_rotate(t *testing.T) {
 	exp := map[string]interface{}{
 		"term": 2,
 	}
+	if _, ok := resp.Data["encryptions"].(uint64); !ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	delete(resp.Data, "encryptions")
 	delete(resp.Data, "install_time")
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
 	}
 }
 
+func TestSystemBackend_rotateConfig(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "rotate/config")
+	resp, err := b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp := map[string]interface{}{
+		"enabled":        true,
+		"max_operations": defaultKeyRotationMaxOperations,
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "rotate/config")
+	req.Data["max_operations"] = 1000
+	resp, err = b.HandleRequest(req)
+	if err == nil {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "rotate/config")
+	req.Data["enabled"] = false
+	req.Data["max_operations"] = 2000000
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp != nil {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "rotate/config")
+	resp, err = b.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	exp = map[string]interface{}{
+		"enabled":        false,
+		"max_operations": uint64(2000000),
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
+	}
+}
+
 func testSystemBackend(t *testing.T) logical.Backend {
 	c, _, _ := TestCoreUnsealed(t)
 	bc := &logical.BackendConfig{
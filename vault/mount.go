@@ -49,12 +49,14 @@ var (
 		"auth/",
 		"sys/",
 		"cubbyhole/",
+		"identity/",
 	}
 
 	untunableMounts = []string{
 		"cubbyhole/",
 		"sys/",
 		"audit/",
+		"identity/",
 	}
 
 	// singletonMounts can only exist in one location and are
@@ -63,6 +65,7 @@ var (
 		"cubbyhole",
 		"system",
 		"token",
+		"identity",
 	}
 )
 
@@ -159,6 +162,7 @@ type MountEntry struct {
 	Config      MountConfig       `json:"config"`            // Configuration related to this mount (but not backend-derived)
 	Options     map[string]string `json:"options"`           // Backend options
 	Local       bool              `json:"local"`             // Local mounts are not replicated or affected by replication
+	SealWrap    bool              `json:"seal_wrap"`         // Whether the storage of the mount is also encrypted by the seal
 	Tainted     bool              `json:"tainted,omitempty"` // Set as a Write-Ahead flag for unmount/remount
 }
 
@@ -222,8 +226,12 @@ func (c *Core) mount(entry *MountEntry) error {
 		}
 		entry.Accessor = accessor
 	}
+	barrier, err := c.mountBarrier(entry)
+	if err != nil {
+		return logical.CodedError(400, err.Error())
+	}
 	viewPath := backendBarrierPrefix + entry.UUID + "/"
-	view := NewBarrierView(c.barrier, viewPath)
+	view := NewBarrierView(barrier, viewPath)
 	sysView := c.mountEntrySysView(entry)
 	conf := make(map[string]string)
 	if entry.Config.PluginName != "" {
@@ -313,13 +321,7 @@ func (c *Core) unmount(path string) error {
 		return err
 	}
 
-	// Call cleanup function if it exists
-	backend := c.router.MatchingBackend(path)
-	if backend != nil {
-		backend.Cleanup()
-	}
-
-	// Unmount the backend entirely
+	// Unmount the backend entirely, which calls its cleanup function
 	if err := c.router.Unmount(path); err != nil {
 		return err
 	}
@@ -664,6 +666,7 @@ func (c *Core) setupMounts() error {
 	defer c.mountsLock.Unlock()
 
 	var backend logical.Backend
+	var barrier BarrierStorage
 	var view *BarrierView
 	var err error
 
@@ -675,7 +678,12 @@ func (c *Core) setupMounts() error {
 		}
 
 		// Create a barrier view using the UUID
-		view = NewBarrierView(c.barrier, barrierPath)
+		barrier, err = c.mountBarrier(entry)
+		if err != nil {
+			c.logger.Error("core: failed to create mount entry", "path", entry.Path, "error", err)
+			return errLoadMountsFailed
+		}
+		view = NewBarrierView(barrier, barrierPath)
 		sysView := c.mountEntrySysView(entry)
 		// Set up conf to pass in plugin_name
 		conf := make(map[string]string)
@@ -705,6 +713,8 @@ func (c *Core) setupMounts() error {
 		switch entry.Type {
 		case "system":
 			c.systemBarrierView = view
+		case "identity":
+			c.identityStore = backend.(*IdentityStore)
 		case "cubbyhole":
 			ch := backend.(*CubbyholeBackend)
 			ch.saltUUID = entry.UUID
@@ -748,6 +758,7 @@ func (c *Core) unloadMounts() error {
 	c.mounts = nil
 	c.router = NewRouter()
 	c.systemBarrierView = nil
+	c.identityStore = nil
 	return nil
 }
 
@@ -851,8 +862,26 @@ func (c *Core) requiredMountTable() *MountTable {
 		UUID:        sysUUID,
 		Accessor:    sysAccessor,
 	}
+	identityUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		panic(fmt.Sprintf("could not create identity UUID: %v", err))
+	}
+	identityAccessor, err := c.generateMountAccessor("identity")
+	if err != nil {
+		panic(fmt.Sprintf("could not generate identity accessor: %v", err))
+	}
+	identityMount := &MountEntry{
+		Table:       mountTableType,
+		Path:        "identity/",
+		Type:        "identity",
+		Description: "identity store",
+		UUID:        identityUUID,
+		Accessor:    identityAccessor,
+	}
+
 	table.Entries = append(table.Entries, cubbyholeMount)
 	table.Entries = append(table.Entries, sysMount)
+	table.Entries = append(table.Entries, identityMount)
 	return table
 }
 
@@ -884,3 +913,17 @@ func (c *Core) singletonMountTables() (mounts, auth *MountTable) {
 
 	return
 }
+
+// MatchingMount returns the mount prefix and the backend type of the mount
+// that would serve the given path. Both are empty if no mount matches.
+func (c *Core) MatchingMount(path string) (string, string) {
+	mount := c.router.MatchingMount(path)
+	if mount == "" {
+		return "", ""
+	}
+	entry := c.router.MatchingMountEntry(path)
+	if entry == nil {
+		return mount, ""
+	}
+	return mount, entry.Type
+}
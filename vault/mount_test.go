@@ -276,6 +276,11 @@ func TestCore_Unmount_Cleanup(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
+	// The backend cleanup should be called once
+	if noop.Cleanups != 1 {
+		t.Fatalf("bad: %d", noop.Cleanups)
+	}
+
 	// Rollback should be invoked
 	if noop.Requests[1].Operation != logical.RollbackOperation {
 		t.Fatalf("bad: %#v", noop.Requests)
@@ -299,6 +304,31 @@ func TestCore_Unmount_Cleanup(t *testing.T) {
 	}
 }
 
+func TestCore_Seal_Cleanup(t *testing.T) {
+	noop := &NoopBackend{}
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(*logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+
+	me := &MountEntry{
+		Table: mountTableType,
+		Path:  "test/",
+		Type:  "noop",
+	}
+	if err := c.mount(me); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Sealing tears down the mounts, which should cleanup
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if noop.Cleanups != 1 {
+		t.Fatalf("bad: %d", noop.Cleanups)
+	}
+}
+
 func TestCore_Remount(t *testing.T) {
 	c, keys, _ := TestCoreUnsealed(t)
 	err := c.remount("secret", "foo")
@@ -592,7 +622,7 @@ func testCore_MountTable_UpgradeToTyped_Common(
 }
 
 func verifyDefaultTable(t *testing.T, table *MountTable) {
-	if len(table.Entries) != 3 {
+	if len(table.Entries) != 4 {
 		t.Fatalf("bad: %v", table.Entries)
 	}
 	table.sortEntriesByPath()
@@ -606,13 +636,20 @@ func verifyDefaultTable(t *testing.T, table *MountTable) {
 				t.Fatalf("bad: %v", entry)
 			}
 		case 1:
+			if entry.Path != "identity/" {
+				t.Fatalf("bad: %v", entry)
+			}
+			if entry.Type != "identity" {
+				t.Fatalf("bad: %v", entry)
+			}
+		case 2:
 			if entry.Path != "secret/" {
 				t.Fatalf("bad: %v", entry)
 			}
 			if entry.Type != "generic" {
 				t.Fatalf("bad: %v", entry)
 			}
-		case 2:
+		case 3:
 			if entry.Path != "sys/" {
 				t.Fatalf("bad: %v", entry)
 			}
@@ -637,12 +674,13 @@ func TestSingletonMountTableFunc(t *testing.T) {
 
 	mounts, auth := c.singletonMountTables()
 
-	if len(mounts.Entries) != 1 {
+	if len(mounts.Entries) != 2 {
 		t.Fatal("length of mounts is wrong")
 	}
 	for _, entry := range mounts.Entries {
 		switch entry.Type {
 		case "system":
+		case "identity":
 		default:
 			t.Fatalf("unknown type %s", entry.Type)
 		}
@@ -0,0 +1,565 @@
+package vault
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/strutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/logical/framework"
+)
+
+const (
+	// namespaceSubPath is the sub-path used for the namespace store view.
+	// This is nested under the system view.
+	namespaceSubPath = "namespace/"
+
+	// namespacePolicySubPath is the sub-path under which the policies of
+	// each namespace are stored, keyed by the namespace ID. This is nested
+	// under the system view.
+	namespacePolicySubPath = "namespace-policy/"
+
+	// namespaceMountSubPath is the sub-path used for the storage views of
+	// the system and token mounts of each namespace. Neither backend stores
+	// data through its view; it only keeps the router entries distinct.
+	namespaceMountSubPath = "namespace-mount/"
+
+	// rootNamespaceID is the identifier of the root namespace
+	rootNamespaceID = "root"
+)
+
+var (
+	// rootNamespace is the namespace of requests made outside of any other
+	// namespace
+	rootNamespace = &namespace{
+		ID: rootNamespaceID,
+	}
+
+	// reservedNamespaceNames cannot be used as namespace names, as they
+	// would shadow the paths of the mounts within their parent
+	reservedNamespaceNames = []string{
+		"auth",
+		"cubbyhole",
+		"identity",
+		"sys",
+	}
+
+	// namespaceSystemPaths are the patterns of the system backend paths
+	// available within a namespace other than the root namespace
+	namespaceSystemPaths = []string{
+		"capabilities$",
+		"capabilities-self$",
+		"auth/(?P<path>.+?)/tune$",
+		"mounts/(?P<path>.+?)/tune$",
+		"mounts/(?P<path>.+?)",
+		"mounts$",
+		"remount",
+		"auth$",
+		"auth/(?P<path>.+)",
+		"policy$",
+		"policy/(?P<name>.+)",
+		"namespaces/?$",
+		"namespaces/" + framework.GenericNameRegex("path"),
+		"(leases/)?renew" + framework.OptionalParamRegex("url_lease_id"),
+		"(leases/)?revoke" + framework.OptionalParamRegex("url_lease_id"),
+		"wrapping/wrap$",
+		"wrapping/unwrap$",
+		"wrapping/lookup$",
+		"wrapping/rewrap$",
+	}
+
+	// namespaceTokenPaths are the patterns of the token store paths
+	// available within a namespace other than the root namespace
+	namespaceTokenPaths = []string{
+		"create-orphan$",
+		"create$",
+		"lookup-self$",
+		"revoke-self$",
+		"renew-self$",
+	}
+)
+
+// namespace is an isolated tenant of Vault. Each namespace has its own
+// mounts, policies and tokens, and can have child namespaces. Requests
+// within a namespace are made under its path.
+type namespace struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+
+	policyStore *PolicyStore
+}
+
+// setupNamespaces is invoked after the credential backends are set up to
+// load the namespaces and mount their system and token backends
+func (c *Core) setupNamespaces() error {
+	c.namespacesLock.Lock()
+	defer c.namespacesLock.Unlock()
+
+	c.namespaces = make(map[string]*namespace)
+
+	view := c.systemBarrierView.SubView(namespaceSubPath)
+	ids, err := logical.CollectKeys(view)
+	if err != nil {
+		return errwrap.Wrapf("failed to list namespaces: {{err}}", err)
+	}
+
+	for _, id := range ids {
+		entry, err := view.Get(id)
+		if err != nil {
+			return errwrap.Wrapf("failed to read namespace: {{err}}", err)
+		}
+		if entry == nil {
+			continue
+		}
+
+		ns := new(namespace)
+		if err := entry.DecodeJSON(ns); err != nil {
+			return errwrap.Wrapf("failed to decode namespace: {{err}}", err)
+		}
+
+		if err := c.mountNamespace(ns); err != nil {
+			return err
+		}
+		c.namespaces[ns.Path] = ns
+	}
+
+	return nil
+}
+
+// teardownNamespaces is used to reverse setupNamespaces when the vault is
+// being sealed. The router entries are dropped along with the router.
+func (c *Core) teardownNamespaces() error {
+	c.namespacesLock.Lock()
+	defer c.namespacesLock.Unlock()
+
+	c.namespaces = nil
+	return nil
+}
+
+// mountNamespace sets up the policy store of the namespace and mounts its
+// system and token backends in the router
+func (c *Core) mountNamespace(ns *namespace) error {
+	sysView := &dynamicSystemView{core: c}
+	ns.policyStore = NewPolicyStore(c.systemBarrierView.SubView(namespacePolicySubPath+ns.ID+"/"), sysView)
+
+	// Wrapping tokens are issued within the namespace of the request, so
+	// the namespace needs its own cubbyhole response wrapping policy
+	policy, err := ns.policyStore.GetPolicy(responseWrappingPolicyName)
+	if err != nil {
+		return errwrap.Wrapf("error fetching response-wrapping policy from store: {{err}}", err)
+	}
+	if policy == nil || policy.Raw != responseWrappingPolicy {
+		if err := ns.policyStore.createResponseWrappingPolicy(); err != nil {
+			return err
+		}
+	}
+
+	sys, ok := c.router.MatchingBackend("sys/").(*SystemBackend)
+	if !ok {
+		return fmt.Errorf("system backend not mounted")
+	}
+	if err := c.mountNamespaceBackend(ns, sys.Backend, namespaceSystemPaths, mountTableType, "system", "sys/"); err != nil {
+		return err
+	}
+
+	if c.tokenStore == nil {
+		return fmt.Errorf("token store not mounted")
+	}
+	return c.mountNamespaceBackend(ns, c.tokenStore.Backend, namespaceTokenPaths, credentialTableType, "token", credentialRoutePrefix+"token/")
+}
+
+// mountNamespaceBackend mounts the given paths of a builtin backend at the
+// given path within the namespace
+func (c *Core) mountNamespaceBackend(ns *namespace, b *framework.Backend, patterns []string, table, backendType, path string) error {
+	backend := &framework.Backend{
+		Help:        b.Help,
+		BackendType: b.BackendType,
+	}
+	if b.PathsSpecial != nil {
+		backend.PathsSpecial = &logical.Paths{
+			Root:            b.PathsSpecial.Root,
+			Unauthenticated: b.PathsSpecial.Unauthenticated,
+		}
+	}
+	// The patterns of a backend are anchored in place once it has handled
+	// its first request
+	anchored := make([]string, len(patterns))
+	for i, p := range patterns {
+		anchored[i] = anchorPattern(p)
+	}
+	for _, p := range b.Paths {
+		if strutil.StrListContains(anchored, anchorPattern(p.Pattern)) {
+			backend.Paths = append(backend.Paths, p)
+		}
+	}
+
+	mountUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	accessor, err := c.generateMountAccessor(backendType)
+	if err != nil {
+		return err
+	}
+	entry := &MountEntry{
+		Table:       table,
+		Path:        ns.Path + path,
+		Type:        backendType,
+		Description: fmt.Sprintf("%s backend of namespace %s", backendType, ns.Path),
+		UUID:        mountUUID,
+		Accessor:    accessor,
+	}
+
+	if err := backend.Setup(&logical.BackendConfig{
+		Logger: c.logger,
+		System: c.mountEntrySysView(entry),
+	}); err != nil {
+		return err
+	}
+
+	view := c.systemBarrierView.SubView(namespaceMountSubPath + ns.ID + "/" + backendType + "/")
+	return c.router.Mount(backend, entry.Path, entry, view)
+}
+
+// anchorPattern anchors a path pattern the way the framework does
+func anchorPattern(pattern string) string {
+	if !strings.HasPrefix(pattern, "^") {
+		pattern = "^" + pattern
+	}
+	if !strings.HasSuffix(pattern, "$") {
+		pattern = pattern + "$"
+	}
+	return pattern
+}
+
+// unmountNamespace removes the system and token backends of the namespace
+// from the router
+func (c *Core) unmountNamespace(ns *namespace) error {
+	if err := c.router.Unmount(ns.Path + "sys/"); err != nil {
+		return err
+	}
+	return c.router.Unmount(ns.Path + credentialRoutePrefix + "token/")
+}
+
+// createNamespace creates a child namespace with the given name in the
+// parent namespace
+func (c *Core) createNamespace(parent *namespace, name string) (*namespace, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid namespace name %q", name)
+	}
+	if strutil.StrListContains(reservedNamespaceNames, name) {
+		return nil, fmt.Errorf("namespace name %q is reserved", name)
+	}
+	path := parent.Path + name + "/"
+
+	// Namespaces cannot overlap with any mount, as their requests would
+	// otherwise be ambiguous
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if strings.HasPrefix(entry.Path, path) {
+			c.mountsLock.RUnlock()
+			return nil, fmt.Errorf("path %q is already in use by mount %q", path, entry.Path)
+		}
+	}
+	c.mountsLock.RUnlock()
+	c.authLock.RLock()
+	for _, entry := range c.auth.Entries {
+		if strings.HasPrefix(entry.Path, path) {
+			c.authLock.RUnlock()
+			return nil, fmt.Errorf("path %q is already in use by auth mount %q", path, entry.Path)
+		}
+	}
+	c.authLock.RUnlock()
+	if match := c.router.MatchingMount(path); match != "" {
+		return nil, fmt.Errorf("path %q is already in use by mount %q", path, match)
+	}
+
+	c.namespacesLock.Lock()
+	defer c.namespacesLock.Unlock()
+
+	if _, ok := c.namespaces[path]; ok {
+		return nil, fmt.Errorf("namespace %q already exists", path)
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	ns := &namespace{
+		ID:   id,
+		Path: path,
+	}
+
+	if err := c.mountNamespace(ns); err != nil {
+		return nil, err
+	}
+	if err := ns.policyStore.createDefaultPolicy(); err != nil {
+		c.unmountNamespace(ns)
+		return nil, err
+	}
+
+	entry, err := logical.StorageEntryJSON(ns.ID, ns)
+	if err != nil {
+		c.unmountNamespace(ns)
+		return nil, err
+	}
+	if err := c.systemBarrierView.SubView(namespaceSubPath).Put(entry); err != nil {
+		c.unmountNamespace(ns)
+		return nil, errwrap.Wrapf("failed to persist namespace: {{err}}", err)
+	}
+
+	c.namespaces[ns.Path] = ns
+	if c.logger.IsInfo() {
+		c.logger.Info("core: created namespace", "path", ns.Path)
+	}
+	return ns, nil
+}
+
+// deleteNamespace deletes a namespace along with its policies. The namespace
+// must not have any child namespaces or mounts left; disabling its auth
+// mounts has already revoked the tokens issued through them.
+func (c *Core) deleteNamespace(ns *namespace) error {
+	c.mountsLock.RLock()
+	for _, entry := range c.mounts.Entries {
+		if strings.HasPrefix(entry.Path, ns.Path) {
+			c.mountsLock.RUnlock()
+			return fmt.Errorf("namespace %q still has mount %q", ns.Path, strings.TrimPrefix(entry.Path, ns.Path))
+		}
+	}
+	c.mountsLock.RUnlock()
+	c.authLock.RLock()
+	for _, entry := range c.auth.Entries {
+		if strings.HasPrefix(entry.Path, ns.Path) {
+			c.authLock.RUnlock()
+			return fmt.Errorf("namespace %q still has auth mount %q", ns.Path, strings.TrimPrefix(entry.Path, ns.Path))
+		}
+	}
+	c.authLock.RUnlock()
+
+	c.namespacesLock.Lock()
+	defer c.namespacesLock.Unlock()
+
+	for path := range c.namespaces {
+		if path != ns.Path && strings.HasPrefix(path, ns.Path) {
+			return fmt.Errorf("namespace %q still has child namespace %q", ns.Path, strings.TrimPrefix(path, ns.Path))
+		}
+	}
+
+	// Tokens of the namespace left to expire are rejected once it is gone,
+	// as their namespace can no longer be found
+	if err := c.systemBarrierView.SubView(namespaceSubPath).Delete(ns.ID); err != nil {
+		return errwrap.Wrapf("failed to delete namespace: {{err}}", err)
+	}
+	delete(c.namespaces, ns.Path)
+
+	if err := c.unmountNamespace(ns); err != nil {
+		return err
+	}
+	if err := logical.ClearView(c.systemBarrierView.SubView(namespacePolicySubPath + ns.ID + "/")); err != nil {
+		return err
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("core: deleted namespace", "path", ns.Path)
+	}
+	return nil
+}
+
+// namespaceByPath returns the namespace the given path is in, which is the
+// root namespace if it is not within any other namespace
+func (c *Core) namespaceByPath(path string) *namespace {
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+
+	result := rootNamespace
+	for nsPath, ns := range c.namespaces {
+		if strings.HasPrefix(path, nsPath) && len(nsPath) > len(result.Path) {
+			result = ns
+		}
+	}
+	return result
+}
+
+// requestNamespace returns the namespace of a request to the system backend
+// or the token store, whose paths are mounted within each namespace
+func (c *Core) requestNamespace(req *logical.Request) *namespace {
+	return c.namespaceByPath(req.MountPoint)
+}
+
+// namespaceByID returns the namespace with the given ID, or nil if it does
+// not exist. An empty ID refers to the root namespace.
+func (c *Core) namespaceByID(id string) *namespace {
+	if id == "" || id == rootNamespaceID {
+		return rootNamespace
+	}
+
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+
+	for _, ns := range c.namespaces {
+		if ns.ID == id {
+			return ns
+		}
+	}
+	return nil
+}
+
+// childNamespaces returns the paths of the direct children of the namespace,
+// relative to it
+func (c *Core) childNamespaces(parent *namespace) []string {
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+
+	var children []string
+	for path := range c.namespaces {
+		if !strings.HasPrefix(path, parent.Path) {
+			continue
+		}
+		rel := strings.TrimPrefix(path, parent.Path)
+		if strings.Count(rel, "/") == 1 {
+			children = append(children, rel)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// namespacePolicyStore returns the store of the policies of the namespace
+func (c *Core) namespacePolicyStore(ns *namespace) *PolicyStore {
+	if ns.ID == rootNamespaceID {
+		return c.policyStore
+	}
+	return ns.policyStore
+}
+
+// namespaceRoutePath returns the path a request for the given path is routed
+// to. Auth mounts within a namespace live under auth/ like all others, with
+// the namespace path as part of their own path, so that
+// <namespace>/auth/<mount>/... is routed to auth/<namespace>/<mount>/...
+func (c *Core) namespaceRoutePath(path string) string {
+	ns := c.namespaceByPath(path)
+	if ns.ID == rootNamespaceID {
+		return path
+	}
+
+	rel := strings.TrimPrefix(path, ns.Path)
+	if !strings.HasPrefix(rel, credentialRoutePrefix) ||
+		strings.HasPrefix(rel, credentialRoutePrefix+"token/") || rel == credentialRoutePrefix+"token" {
+		return path
+	}
+	return credentialRoutePrefix + ns.Path + strings.TrimPrefix(rel, credentialRoutePrefix)
+}
+
+// namespaceRequestPath is the inverse of namespaceRoutePath, returning the
+// path a request was made for given the path it is routed to
+func (c *Core) namespaceRequestPath(path string) string {
+	if !strings.HasPrefix(path, credentialRoutePrefix) {
+		return path
+	}
+
+	rel := strings.TrimPrefix(path, credentialRoutePrefix)
+	ns := c.namespaceByPath(rel)
+	if ns.ID == rootNamespaceID {
+		return path
+	}
+	return ns.Path + credentialRoutePrefix + strings.TrimPrefix(rel, ns.Path)
+}
+
+// validateNamespaceMountPath ensures that a mount at the given path, relative
+// to the namespace, stays within the namespace and does not shadow any of
+// its child namespaces
+func (c *Core) validateNamespaceMountPath(ns *namespace, path string) error {
+	if ns.ID != rootNamespaceID {
+		for _, p := range protectedMounts {
+			if strings.HasPrefix(path, p) {
+				return logical.CodedError(403, fmt.Sprintf("cannot mount '%s'", path))
+			}
+		}
+	}
+
+	fullPath := ns.Path + path
+	if c.namespaceByPath(fullPath) != ns {
+		return logical.CodedError(400, fmt.Sprintf("path '%s' is within a child namespace", path))
+	}
+
+	c.namespacesLock.RLock()
+	defer c.namespacesLock.RUnlock()
+	for nsPath := range c.namespaces {
+		if strings.HasPrefix(nsPath, fullPath) {
+			return logical.CodedError(400, fmt.Sprintf("path '%s' is in use by a child namespace", path))
+		}
+	}
+	return nil
+}
+
+// tokenACL returns the ACL of the token, built from the policies of its
+// namespace, along with the namespace. A nil ACL is returned if the
+// namespace of the token no longer exists.
+func (c *Core) tokenACL(te *TokenEntry) (*ACL, *namespace, error) {
+	ns := c.namespaceByID(te.NamespaceID)
+	if ns == nil {
+		return nil, nil, nil
+	}
+
+	acl, err := c.namespacePolicyStore(ns).ACL(c.tokenEntity(te), c.tokenPolicies(te)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return acl, ns, nil
+}
+
+// namespaceRelativePath returns the path relative to the namespace it is in,
+// so that the paths of the system backend mounted within each namespace can be
+// recognized
+func (c *Core) namespaceRelativePath(path string) string {
+	return strings.TrimPrefix(path, c.namespaceByPath(path).Path)
+}
+
+// leaseInNamespace reports whether the lease was issued within the namespace
+// or one of its child namespaces
+func (c *Core) leaseInNamespace(ns *namespace, leaseID string) bool {
+	return strings.HasPrefix(c.namespaceRequestPath(leaseID), ns.Path)
+}
+
+// tokenInNamespace reports whether the token belongs to the namespace or one
+// of its child namespaces
+func (c *Core) tokenInNamespace(ns *namespace, te *TokenEntry) bool {
+	tokenNS := c.namespaceByID(te.NamespaceID)
+	return tokenNS != nil && strings.HasPrefix(tokenNS.Path, ns.Path)
+}
+
+// namespaceACLPath returns the path of the request relative to the namespace
+// of the token, which its policies are written against. False is returned if
+// the request is outside of the namespace of the token.
+func (c *Core) namespaceACLPath(te *TokenEntry, path string) (string, bool) {
+	ns := c.namespaceByID(te.NamespaceID)
+	if ns == nil {
+		return "", false
+	}
+
+	path = c.namespaceRequestPath(path)
+	if !strings.HasPrefix(path, ns.Path) {
+		return "", false
+	}
+	return strings.TrimPrefix(path, ns.Path), true
+}
+
+// allowOperation checks the request against the ACL of the token. Policies
+// are written relative to the namespace of the token, which can only be
+// used within it and its child namespaces.
+func (c *Core) allowOperation(acl *ACL, te *TokenEntry, req *logical.Request) (allowed bool, rootPrivs bool) {
+	path, ok := c.namespaceACLPath(te, req.Path)
+	if !ok {
+		return false, false
+	}
+
+	originalPath := req.Path
+	req.Path = path
+	defer func() {
+		req.Path = originalPath
+	}()
+	return acl.AllowOperation(req)
+}
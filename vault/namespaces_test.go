@@ -0,0 +1,373 @@
+package vault
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/vault/logical"
+)
+
+// testNamespaceRequest makes a request with the given token and fails the
+// test on any error
+func testNamespaceRequest(t *testing.T, c *Core, token string, op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+	req := logical.TestRequest(t, op, path)
+	req.ClientToken = token
+	if data != nil {
+		req.Data = data
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("%s %s: err: %v, resp: %#v", op, path, err, resp)
+	}
+	return resp
+}
+
+// testNamespaceRequestDenied makes a request with the given token and fails
+// the test unless it is denied
+func testNamespaceRequestDenied(t *testing.T, c *Core, token string, op logical.Operation, path string) {
+	req := logical.TestRequest(t, op, path)
+	req.ClientToken = token
+	if _, err := c.HandleRequest(req); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("%s %s: expected permission denied, got: %v", op, path, err)
+	}
+}
+
+func TestNamespaces_CreateListDelete(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	resp := testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil)
+	if resp.Data["path"] != "team1/" || resp.Data["id"] == "" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/namespaces/child", nil)
+
+	resp = testNamespaceRequest(t, c, root, logical.ListOperation, "sys/namespaces", nil)
+	if !reflect.DeepEqual(resp.Data["keys"], []string{"team1/"}) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = testNamespaceRequest(t, c, root, logical.ReadOperation, "team1/sys/namespaces/child", nil)
+	if resp.Data["path"] != "team1/child/" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Reserved names and existing mounts cannot be used
+	for _, name := range []string{"sys", "secret", "team1"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "sys/namespaces/"+name)
+		req.ClientToken = root
+		if resp, _ := c.HandleRequest(req); resp == nil || !resp.IsError() {
+			t.Fatalf("%s: expected an error response, got: %#v", name, resp)
+		}
+	}
+
+	// A namespace with children cannot be deleted
+	req := logical.TestRequest(t, logical.DeleteOperation, "sys/namespaces/team1")
+	req.ClientToken = root
+	if resp, _ := c.HandleRequest(req); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got: %#v", resp)
+	}
+
+	testNamespaceRequest(t, c, root, logical.DeleteOperation, "team1/sys/namespaces/child", nil)
+	testNamespaceRequest(t, c, root, logical.DeleteOperation, "sys/namespaces/team1", nil)
+	if c.router.MatchingMount("team1/sys/mounts") != "" {
+		t.Fatal("expected the namespace system backend to be unmounted")
+	}
+}
+
+func TestNamespaces_Mounts(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil)
+
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/mounts/secret", map[string]interface{}{
+		"type": "generic",
+	})
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+
+	// The mount table of the namespace only holds its own mounts
+	resp := testNamespaceRequest(t, c, root, logical.ReadOperation, "team1/sys/mounts", nil)
+	if _, ok := resp.Data["secret/"]; !ok || len(resp.Data) != 1 {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = testNamespaceRequest(t, c, root, logical.ReadOperation, "sys/mounts", nil)
+	if _, ok := resp.Data["team1/secret/"]; ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// The system mounts cannot be shadowed within the namespace
+	req := logical.TestRequest(t, logical.UpdateOperation, "team1/sys/mounts/sys")
+	req.ClientToken = root
+	req.Data["type"] = "generic"
+	if resp, _ := c.HandleRequest(req); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got: %#v", resp)
+	}
+
+	// The namespace cannot be deleted while it has mounts
+	req = logical.TestRequest(t, logical.DeleteOperation, "sys/namespaces/team1")
+	req.ClientToken = root
+	if resp, _ := c.HandleRequest(req); resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error response, got: %#v", resp)
+	}
+}
+
+func TestNamespaces_Tokens(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil)
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/mounts/secret", map[string]interface{}{
+		"type": "generic",
+	})
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/mounts/team2", map[string]interface{}{
+		"type": "generic",
+	})
+
+	// Policies are written relative to the namespace and only exist within it
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/policy/admin", map[string]interface{}{
+		"rules": `path "*" { capabilities = ["create", "read", "update", "delete", "list"] }`,
+	})
+	if p, _ := c.policyStore.GetPolicy("admin"); p != nil {
+		t.Fatal("expected the policy to only exist within the namespace")
+	}
+
+	resp := testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/auth/token/create", map[string]interface{}{
+		"policies": "admin",
+	})
+	token := resp.Auth.ClientToken
+
+	te, err := c.tokenStore.Lookup(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te.NamespaceID != c.namespaceByPath("team1/").ID {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	// The token has access to the namespace, and nothing outside of it
+	testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+	testNamespaceRequest(t, c, token, logical.ReadOperation, "team1/sys/mounts", nil)
+	testNamespaceRequestDenied(t, c, token, logical.ReadOperation, "sys/mounts")
+	testNamespaceRequestDenied(t, c, token, logical.UpdateOperation, "team2/foo")
+
+	// Tokens of the namespace only create tokens within it
+	resp = testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/auth/token/create", nil)
+	te, err = c.tokenStore.Lookup(resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te.NamespaceID != c.namespaceByPath("team1/").ID {
+		t.Fatalf("bad: %#v", te)
+	}
+	testNamespaceRequestDenied(t, c, token, logical.UpdateOperation, "auth/token/create")
+}
+
+func TestNamespaces_Login(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["noop"] = func(conf *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{
+			Login: []string{"login"},
+			Response: &logical.Response{
+				Auth: &logical.Auth{
+					Policies: []string{"foo"},
+				},
+			},
+		}, nil
+	}
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/team1", nil)
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "team1/sys/auth/foo", map[string]interface{}{
+		"type": "noop",
+	})
+
+	// Auth mounts of the namespace are routed under auth/
+	if c.router.MatchingMount("auth/team1/foo/login") != "auth/team1/foo/" {
+		t.Fatalf("bad: %q", c.router.MatchingMount("auth/team1/foo/login"))
+	}
+	resp := testNamespaceRequest(t, c, root, logical.ReadOperation, "team1/sys/auth", nil)
+	if _, ok := resp.Data["foo/"]; !ok {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	resp, err := c.HandleRequest(&logical.Request{
+		Path: "team1/auth/foo/login",
+	})
+	if err != nil || resp == nil || resp.Auth == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	te, err := c.tokenStore.Lookup(resp.Auth.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te.NamespaceID != c.namespaceByPath("team1/").ID {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	// Tokens of the namespace are rejected once it is gone
+	testNamespaceRequest(t, c, root, logical.DeleteOperation, "team1/sys/auth/foo", nil)
+	testNamespaceRequest(t, c, root, logical.DeleteOperation, "sys/namespaces/team1", nil)
+	if acl, _, err := c.tokenACL(te); err != nil || acl != nil {
+		t.Fatalf("expected no ACL, got: %#v, err: %v", acl, err)
+	}
+}
+
+// testNamespaceAdminToken creates a namespace with a generic mount, and
+// returns a token of the namespace with full access to it
+func testNamespaceAdminToken(t *testing.T, c *Core, root, name string) string {
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/namespaces/"+name, nil)
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, name+"/sys/mounts/secret", map[string]interface{}{
+		"type": "generic",
+	})
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, name+"/sys/policy/admin", map[string]interface{}{
+		"rules": `path "*" { capabilities = ["create", "read", "update", "delete", "list"] }`,
+	})
+	resp := testNamespaceRequest(t, c, root, logical.UpdateOperation, name+"/auth/token/create", map[string]interface{}{
+		"policies": "admin",
+	})
+	return resp.Auth.ClientToken
+}
+
+func TestNamespaces_Leases(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	token := testNamespaceAdminToken(t, c, root, "team1")
+
+	testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/secret/foo", map[string]interface{}{
+		"value": "bar",
+		"ttl":   "1h",
+	})
+	resp := testNamespaceRequest(t, c, token, logical.ReadOperation, "team1/secret/foo", nil)
+	if resp.Secret == nil || !strings.HasPrefix(resp.Secret.LeaseID, "team1/secret/foo/") {
+		t.Fatalf("bad: %#v", resp)
+	}
+	leaseID := resp.Secret.LeaseID
+
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "bar",
+		"ttl":   "1h",
+	})
+	resp = testNamespaceRequest(t, c, root, logical.ReadOperation, "secret/foo", nil)
+	rootLeaseID := resp.Secret.LeaseID
+
+	// Leases of the namespace are renewed with its token
+	resp = testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/sys/leases/renew", map[string]interface{}{
+		"lease_id": leaseID,
+	})
+	if resp.Secret == nil || resp.Secret.LeaseID != leaseID {
+		t.Fatalf("bad: %#v", resp)
+	}
+	testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/sys/renew/"+leaseID, nil)
+
+	// Leases outside of the namespace cannot be managed from within it
+	for _, path := range []string{"team1/sys/leases/renew", "team1/sys/leases/revoke", "team1/sys/revoke"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = token
+		req.Data["lease_id"] = rootLeaseID
+		if _, err := c.HandleRequest(req); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+			t.Fatalf("%s: expected permission denied, got: %v", path, err)
+		}
+	}
+	testNamespaceRequestDenied(t, c, token, logical.UpdateOperation, "sys/leases/renew")
+
+	// Leases of the namespace are revoked with its token
+	testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/sys/leases/revoke", map[string]interface{}{
+		"lease_id": leaseID,
+	})
+	if le, err := c.expiration.loadEntry(leaseID); err != nil || le != nil {
+		t.Fatalf("expected the lease to be revoked, got: %#v, %v", le, err)
+	}
+	if le, err := c.expiration.loadEntry(rootLeaseID); err != nil || le == nil {
+		t.Fatalf("expected the lease to remain, got: %#v, %v", le, err)
+	}
+
+	// The root namespace still manages the leases of all namespaces
+	testNamespaceRequest(t, c, token, logical.UpdateOperation, "team1/secret/foo", map[string]interface{}{
+		"value": "bar",
+		"ttl":   "1h",
+	})
+	resp = testNamespaceRequest(t, c, token, logical.ReadOperation, "team1/secret/foo", nil)
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/leases/revoke", map[string]interface{}{
+		"lease_id": resp.Secret.LeaseID,
+	})
+}
+
+func TestNamespaces_Wrapping(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	token := testNamespaceAdminToken(t, c, root, "team1")
+
+	wrap := func(token, path string) string {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = token
+		req.Data["foo"] = "bar"
+		req.WrapInfo = &logical.RequestWrapInfo{
+			TTL: time.Minute,
+		}
+		resp, err := c.HandleRequest(req)
+		if err != nil || resp == nil || resp.WrapInfo == nil {
+			t.Fatalf("err: %v, resp: %#v", err, resp)
+		}
+		return resp.WrapInfo.Token
+	}
+
+	// Wrapping tokens belong to the namespace of the request
+	wrappingToken := wrap(token, "team1/sys/wrapping/wrap")
+	te, err := c.tokenStore.Lookup(wrappingToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te == nil || te.NamespaceID != c.namespaceByPath("team1/").ID {
+		t.Fatalf("bad: %#v", te)
+	}
+
+	resp := testNamespaceRequest(t, c, "", logical.UpdateOperation, "team1/sys/wrapping/lookup", map[string]interface{}{
+		"token": wrappingToken,
+	})
+	if resp.Data["creation_path"] != "team1/sys/wrapping/wrap" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// They can only be unwrapped within the namespace
+	testNamespaceRequestDenied(t, c, wrap(token, "team1/sys/wrapping/wrap"), logical.UpdateOperation, "sys/wrapping/unwrap")
+	resp = testNamespaceRequest(t, c, wrappingToken, logical.UpdateOperation, "team1/sys/wrapping/unwrap", nil)
+	if !strings.Contains(string(resp.Data[logical.HTTPRawBody].([]byte)), `"foo":"bar"`) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Wrapping tokens from outside of the namespace cannot be used within it,
+	// and are left untouched
+	rootWrappingToken := wrap(root, "sys/wrapping/wrap")
+	for _, path := range []string{"team1/sys/wrapping/lookup", "team1/sys/wrapping/rewrap", "team1/sys/wrapping/unwrap"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = token
+		req.Data["token"] = rootWrappingToken
+		if _, err := c.HandleRequest(req); err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+			t.Fatalf("%s: expected permission denied, got: %v", path, err)
+		}
+	}
+	testNamespaceRequest(t, c, root, logical.UpdateOperation, "sys/wrapping/unwrap", map[string]interface{}{
+		"token": rootWrappingToken,
+	})
+
+	// Rewrapping keeps the wrapping token within the namespace
+	wrappingToken = wrap(token, "team1/sys/wrapping/wrap")
+	req := logical.TestRequest(t, logical.UpdateOperation, "team1/sys/wrapping/rewrap")
+	req.ClientToken = token
+	req.Data["token"] = wrappingToken
+	req.WrapInfo = &logical.RequestWrapInfo{
+		TTL: time.Minute,
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil || resp == nil || resp.WrapInfo == nil {
+		t.Fatalf("err: %v, resp: %#v", err, resp)
+	}
+	if resp.WrapInfo.CreationPath != "team1/sys/wrapping/wrap" {
+		t.Fatalf("bad: %#v", resp.WrapInfo)
+	}
+	te, err = c.tokenStore.Lookup(resp.WrapInfo.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te == nil || te.NamespaceID != c.namespaceByPath("team1/").ID {
+		t.Fatalf("bad: %#v", te)
+	}
+}
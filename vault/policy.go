@@ -3,6 +3,8 @@ package vault
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -69,12 +71,50 @@ type PathCapabilities struct {
 	Glob         bool
 	Capabilities []string
 
+	// Templated is set if the path contains identity parameters, such as
+	// {{identity.entity.name}}. These are resolved against the entity of the
+	// token when its ACL is built.
+	Templated bool
+
 	// These keys are used at the top level to make the HCL nicer; we store in
 	// the Permissions object though
-	MinWrappingTTLHCL    interface{}              `hcl:"min_wrapping_ttl"`
-	MaxWrappingTTLHCL    interface{}              `hcl:"max_wrapping_ttl"`
-	AllowedParametersHCL map[string][]interface{} `hcl:"allowed_parameters"`
-	DeniedParametersHCL  map[string][]interface{} `hcl:"denied_parameters"`
+	MinWrappingTTLHCL     interface{}              `hcl:"min_wrapping_ttl"`
+	MaxWrappingTTLHCL     interface{}              `hcl:"max_wrapping_ttl"`
+	AllowedParametersHCL  map[string][]interface{} `hcl:"allowed_parameters"`
+	DeniedParametersHCL   map[string][]interface{} `hcl:"denied_parameters"`
+	RequiredParametersHCL []string                 `hcl:"required_parameters"`
+	ControlGroupHCL       *ControlGroupHCL         `hcl:"control_group"`
+}
+
+// ControlGroupHCL is the HCL representation of a control group
+type ControlGroupHCL struct {
+	TTL     interface{}                       `hcl:"ttl"`
+	Factors map[string]*ControlGroupFactorHCL `hcl:"factor"`
+}
+
+// ControlGroupFactorHCL is the HCL representation of a control group factor
+type ControlGroupFactorHCL struct {
+	Identity *struct {
+		GroupNames []string `hcl:"group_names"`
+		Approvals  int      `hcl:"approvals"`
+	} `hcl:"identity"`
+}
+
+// ControlGroup requires read and list requests to a path to be authorized by
+// members of identity groups before their response, which is always
+// wrapped, can be unwrapped
+type ControlGroup struct {
+	TTL     time.Duration
+	Factors []*ControlGroupFactor
+}
+
+// ControlGroupFactor is a set of identity groups of which a number of
+// members must authorize a request. All the factors of a control group must
+// be satisfied.
+type ControlGroupFactor struct {
+	Name       string   `json:"name"`
+	GroupNames []string `json:"group_names"`
+	Approvals  int      `json:"approvals"`
 }
 
 type Permissions struct {
@@ -83,6 +123,8 @@ type Permissions struct {
 	MaxWrappingTTL     time.Duration
 	AllowedParameters  map[string][]interface{}
 	DeniedParameters   map[string][]interface{}
+	RequiredParameters []string
+	ControlGroup       *ControlGroup
 }
 
 func (p *Permissions) Clone() (*Permissions, error) {
@@ -90,6 +132,9 @@ func (p *Permissions) Clone() (*Permissions, error) {
 		CapabilitiesBitmap: p.CapabilitiesBitmap,
 		MinWrappingTTL:     p.MinWrappingTTL,
 		MaxWrappingTTL:     p.MaxWrappingTTL,
+
+		// Control groups are never modified once parsed
+		ControlGroup: p.ControlGroup,
 	}
 
 	switch {
@@ -116,6 +161,11 @@ func (p *Permissions) Clone() (*Permissions, error) {
 		ret.DeniedParameters = clonedDenied.(map[string][]interface{})
 	}
 
+	if p.RequiredParameters != nil {
+		ret.RequiredParameters = make([]string, len(p.RequiredParameters))
+		copy(ret.RequiredParameters, p.RequiredParameters)
+	}
+
 	return ret, nil
 }
 
@@ -172,8 +222,10 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 			"capabilities",
 			"allowed_parameters",
 			"denied_parameters",
+			"required_parameters",
 			"min_wrapping_ttl",
 			"max_wrapping_ttl",
+			"control_group",
 		}
 		if err := checkHCLKeys(item.Val, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("path %q:", key))
@@ -200,6 +252,13 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 			pc.Glob = true
 		}
 
+		if strings.Contains(pc.Prefix, "{{") || strings.Contains(pc.Prefix, "}}") {
+			if err := validatePathTemplate(pc.Prefix); err != nil {
+				return fmt.Errorf("path %q: %v", key, err)
+			}
+			pc.Templated = true
+		}
+
 		// Map old-style policies into capabilities
 		if len(pc.Policy) > 0 {
 			switch pc.Policy {
@@ -245,6 +304,12 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 				pc.Permissions.DeniedParameters[strings.ToLower(key)] = val
 			}
 		}
+		if pc.RequiredParametersHCL != nil {
+			pc.Permissions.RequiredParameters = make([]string, 0, len(pc.RequiredParametersHCL))
+			for _, key := range pc.RequiredParametersHCL {
+				pc.Permissions.RequiredParameters = append(pc.Permissions.RequiredParameters, strings.ToLower(key))
+			}
+		}
 		if pc.MinWrappingTTLHCL != nil {
 			dur, err := parseutil.ParseDurationSecond(pc.MinWrappingTTLHCL)
 			if err != nil {
@@ -264,6 +329,13 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 			pc.Permissions.MaxWrappingTTL < pc.Permissions.MinWrappingTTL {
 			return errors.New("max_wrapping_ttl cannot be less than min_wrapping_ttl")
 		}
+		if pc.ControlGroupHCL != nil {
+			controlGroup, err := parseControlGroup(pc.ControlGroupHCL)
+			if err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("path %q: control_group:", key))
+			}
+			pc.Permissions.ControlGroup = controlGroup
+		}
 
 	PathFinished:
 		paths = append(paths, &pc)
@@ -273,6 +345,169 @@ func parsePaths(result *Policy, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseControlGroup(cgHCL *ControlGroupHCL) (*ControlGroup, error) {
+	controlGroup := &ControlGroup{
+		TTL: defaultControlGroupTTL,
+	}
+	if cgHCL.TTL != nil {
+		dur, err := parseutil.ParseDurationSecond(cgHCL.TTL)
+		if err != nil {
+			return nil, errwrap.Wrapf("error parsing ttl: {{err}}", err)
+		}
+		controlGroup.TTL = dur
+	}
+
+	if len(cgHCL.Factors) == 0 {
+		return nil, errors.New("at least one factor is required")
+	}
+	names := make([]string, 0, len(cgHCL.Factors))
+	for name := range cgHCL.Factors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		factorHCL := cgHCL.Factors[name]
+		if factorHCL == nil || factorHCL.Identity == nil || len(factorHCL.Identity.GroupNames) == 0 {
+			return nil, fmt.Errorf("factor %q: identity group_names are required", name)
+		}
+		if factorHCL.Identity.Approvals < 0 {
+			return nil, fmt.Errorf("factor %q: approvals cannot be negative", name)
+		}
+
+		factor := &ControlGroupFactor{
+			Name:       name,
+			GroupNames: factorHCL.Identity.GroupNames,
+			Approvals:  factorHCL.Identity.Approvals,
+		}
+		if factor.Approvals == 0 {
+			factor.Approvals = 1
+		}
+		controlGroup.Factors = append(controlGroup.Factors, factor)
+	}
+
+	return controlGroup, nil
+}
+
+// templateParamRegex matches the parameters of a templated policy path
+var templateParamRegex = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+
+// validatePathTemplate checks that every parameter in the path is one that
+// can be resolved against an entity
+func validatePathTemplate(path string) error {
+	for _, match := range templateParamRegex.FindAllStringSubmatch(path, -1) {
+		if !isTemplateParam(match[1]) {
+			return fmt.Errorf("unknown template parameter %q", match[1])
+		}
+	}
+	rest := templateParamRegex.ReplaceAllString(path, "")
+	if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+		return errors.New("unbalanced template braces")
+	}
+	return nil
+}
+
+// isTemplateParam returns whether the parameter is one that templated paths
+// support
+func isTemplateParam(param string) bool {
+	param = strings.TrimSpace(param)
+	switch {
+	case param == "identity.entity.id", param == "identity.entity.name":
+		return true
+	case strings.HasPrefix(param, "identity.entity.metadata."):
+		return len(param) > len("identity.entity.metadata.")
+	case strings.HasPrefix(param, "identity.entity.aliases.") && strings.HasSuffix(param, ".name"):
+		return len(param) > len("identity.entity.aliases.")+len(".name")
+	}
+	return false
+}
+
+// templateParamValue returns the value of the parameter for the entity. False
+// is returned if the entity has no value for it.
+func templateParamValue(param string, entity *Entity) (string, bool) {
+	param = strings.TrimSpace(param)
+	if !isTemplateParam(param) {
+		return "", false
+	}
+
+	var value string
+	switch {
+	case param == "identity.entity.id":
+		value = entity.ID
+	case param == "identity.entity.name":
+		value = entity.Name
+	case strings.HasPrefix(param, "identity.entity.metadata."):
+		value = entity.Metadata[strings.TrimPrefix(param, "identity.entity.metadata.")]
+	default:
+		mountAccessor := strings.TrimSuffix(strings.TrimPrefix(param, "identity.entity.aliases."), ".name")
+		for _, alias := range entity.Aliases {
+			if alias.MountAccessor == mountAccessor {
+				value = alias.Name
+				break
+			}
+		}
+	}
+
+	return value, value != ""
+}
+
+// resolveTemplatedPolicies returns the policies with the identity parameters
+// of templated paths replaced by the values of the entity. Templated paths
+// that cannot be resolved, because there is no entity or it has no value for
+// a parameter, are dropped, so that they never grant access.
+func resolveTemplatedPolicies(policies []*Policy, entity *Entity) []*Policy {
+	ret := make([]*Policy, 0, len(policies))
+	for _, policy := range policies {
+		if policy == nil || !policy.hasTemplatedPaths() {
+			ret = append(ret, policy)
+			continue
+		}
+
+		resolved := &Policy{
+			Name:  policy.Name,
+			Raw:   policy.Raw,
+			Paths: make([]*PathCapabilities, 0, len(policy.Paths)),
+		}
+		for _, pc := range policy.Paths {
+			if !pc.Templated {
+				resolved.Paths = append(resolved.Paths, pc)
+				continue
+			}
+			if entity == nil {
+				continue
+			}
+
+			ok := true
+			prefix := templateParamRegex.ReplaceAllStringFunc(pc.Prefix, func(match string) string {
+				value, found := templateParamValue(match[2:len(match)-2], entity)
+				if !found || strings.ContainsAny(value, "{}") {
+					ok = false
+				}
+				return value
+			})
+			if !ok {
+				continue
+			}
+
+			resolvedPC := *pc
+			resolvedPC.Prefix = prefix
+			resolvedPC.Templated = false
+			resolved.Paths = append(resolved.Paths, &resolvedPC)
+		}
+		ret = append(ret, resolved)
+	}
+	return ret
+}
+
+func (p *Policy) hasTemplatedPaths() bool {
+	for _, pc := range p.Paths {
+		if pc.Templated {
+			return true
+		}
+	}
+	return false
+}
+
 func checkHCLKeys(node ast.Node, valid []string) error {
 	var list *ast.ObjectList
 	switch n := node.(type) {
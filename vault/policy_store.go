@@ -95,6 +95,12 @@ path "sys/wrapping/lookup" {
 path "sys/wrapping/unwrap" {
     capabilities = ["update"]
 }
+
+# Allow a token to look up the status of a request subject to a control group
+# by the accessor of its wrapping token
+path "sys/control-group/request" {
+    capabilities = ["update"]
+}
 `
 )
 
@@ -347,8 +353,9 @@ func (ps *PolicyStore) DeletePolicy(name string) error {
 }
 
 // ACL is used to return an ACL which is built using the
-// named policies.
-func (ps *PolicyStore) ACL(names ...string) (*ACL, error) {
+// named policies. Templated paths are resolved against the
+// entity, which may be nil.
+func (ps *PolicyStore) ACL(entity *Entity, names ...string) (*ACL, error) {
 	// Fetch the policies
 	var policy []*Policy
 	for _, name := range names {
@@ -359,8 +366,8 @@ func (ps *PolicyStore) ACL(names ...string) (*ACL, error) {
 		policy = append(policy, p)
 	}
 
-	// Construct the ACL
-	acl, err := NewACL(policy)
+	// Construct the ACL, with templated paths resolved against the entity
+	acl, err := NewACL(resolveTemplatedPolicies(policy, entity))
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct ACL: %v", err)
 	}
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
@@ -85,6 +86,10 @@ path "test/types" {
 		"bool" = [false]
 	}
 }
+path "test/required" {
+	capabilities = ["create", "update"]
+	required_parameters = ["Name", "ttl"]
+}
 `)
 
 func TestPolicy_Parse(t *testing.T) {
@@ -225,6 +230,20 @@ func TestPolicy_Parse(t *testing.T) {
 			},
 			Glob: false,
 		},
+		&PathCapabilities{
+			Prefix: "test/required",
+			Policy: "",
+			Capabilities: []string{
+				"create",
+				"update",
+			},
+			RequiredParametersHCL: []string{"Name", "ttl"},
+			Permissions: &Permissions{
+				CapabilitiesBitmap: (CreateCapabilityInt | UpdateCapabilityInt),
+				RequiredParameters: []string{"name", "ttl"},
+			},
+			Glob: false,
+		},
 	}
 	if !reflect.DeepEqual(p.Paths, expect) {
 		t.Errorf("expected \n\n%#v\n\n to be \n\n%#v\n\n", p.Paths, expect)
@@ -312,3 +331,120 @@ path "/" {
 		t.Errorf("bad error: %s", err)
 	}
 }
+
+func TestPolicy_ParseControlGroup(t *testing.T) {
+	p, err := Parse(strings.TrimSpace(`
+path "secret/prod/*" {
+	capabilities = ["read"]
+	control_group = {
+		ttl = "4h"
+		factor "security" {
+			identity {
+				group_names = ["security"]
+			}
+		}
+		factor "admins" {
+			identity {
+				group_names = ["admins", "ops"]
+				approvals = 2
+			}
+		}
+	}
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := &ControlGroup{
+		TTL: 4 * time.Hour,
+		Factors: []*ControlGroupFactor{
+			{
+				Name:       "admins",
+				GroupNames: []string{"admins", "ops"},
+				Approvals:  2,
+			},
+			{
+				Name:       "security",
+				GroupNames: []string{"security"},
+				Approvals:  1,
+			},
+		},
+	}
+	if !reflect.DeepEqual(p.Paths[0].Permissions.ControlGroup, expected) {
+		t.Fatalf("bad: %#v", p.Paths[0].Permissions.ControlGroup)
+	}
+}
+
+func TestPolicy_ParseBadControlGroup(t *testing.T) {
+	cases := map[string]string{
+		`control_group = {}`: "at least one factor is required",
+		`control_group = {
+		factor "admins" {
+			identity {
+				approvals = 1
+			}
+		}
+	}`: `factor "admins": identity group_names are required`,
+		`control_group = {
+		factor "admins" {
+			identity {
+				group_names = ["admins"]
+				approvals = -1
+			}
+		}
+	}`: `factor "admins": approvals cannot be negative`,
+	}
+
+	for cg, expected := range cases {
+		_, err := Parse(fmt.Sprintf("path \"/\" {\n\tcapabilities = [\"read\"]\n\t%s\n}", cg))
+		if err == nil {
+			t.Fatalf("expected error for %q", cg)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("bad error: %s", err)
+		}
+	}
+}
+
+func TestPolicy_ParseTemplated(t *testing.T) {
+	p, err := Parse(`
+path "secret/{{identity.entity.name}}/*" {
+	capabilities = ["read"]
+}
+path "secret/{{ identity.entity.metadata.team }}/{{identity.entity.aliases.auth_userpass_1234.name}}" {
+	capabilities = ["read"]
+}
+path "secret/static" {
+	capabilities = ["read"]
+}
+`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	templated := []bool{}
+	for _, pc := range p.Paths {
+		templated = append(templated, pc.Templated)
+	}
+	if !reflect.DeepEqual(templated, []bool{true, true, false}) {
+		t.Fatalf("bad: %#v", templated)
+	}
+
+	cases := map[string]string{
+		`secret/{{identity.entity.foo}}`:            `unknown template parameter "identity.entity.foo"`,
+		`secret/{{identity.entity.metadata.}}`:      `unknown template parameter "identity.entity.metadata."`,
+		`secret/{{identity.entity.aliases.foo.id}}`: `unknown template parameter "identity.entity.aliases.foo.id"`,
+		`secret/{{identity.entity.name}`:            `unbalanced template braces`,
+		`secret/identity.entity.name}}`:             `unbalanced template braces`,
+		`secret/{{identity.entity.{{name}}}}`:       `unknown template parameter "name"`,
+	}
+	for path, expected := range cases {
+		_, err := Parse(fmt.Sprintf("path %q {\n\tcapabilities = [\"read\"]\n}", path))
+		if err == nil {
+			t.Fatalf("expected error for %q", path)
+		}
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("bad error: %s", err)
+		}
+	}
+}
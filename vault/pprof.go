@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+)
+
+// PprofMaxSeconds bounds how long a CPU profile or an execution trace can be
+// collected for
+const PprofMaxSeconds = 60
+
+// ErrPprofCanceled is returned when a CPU profile or an execution trace is
+// stopped before the end of its duration
+var ErrPprofCanceled = errors.New("the capture was canceled")
+
+// PprofProfile profiles the CPU usage of this node for the given number of
+// seconds and returns the profile in the format read by "go tool pprof". The
+// request must be made with root privileges. Closing stopCh stops the profile
+// early, in which case ErrPprofCanceled is returned.
+func (c *Core) PprofProfile(req *logical.Request, seconds int, stopCh <-chan struct{}) ([]byte, error) {
+	defer metrics.MeasureSince([]string{"core", "pprof_profile"}, time.Now())
+
+	// Only one CPU profile can run at a time
+	return c.pprofCapture(req, seconds, stopCh, pprof.StartCPUProfile, pprof.StopCPUProfile)
+}
+
+// PprofTrace traces the execution of this node for the given number of
+// seconds and returns the trace in the format read by "go tool trace". The
+// request must be made with root privileges. Closing stopCh stops the trace
+// early, in which case ErrPprofCanceled is returned.
+func (c *Core) PprofTrace(req *logical.Request, seconds int, stopCh <-chan struct{}) ([]byte, error) {
+	defer metrics.MeasureSince([]string{"core", "pprof_trace"}, time.Now())
+
+	// Only one trace can run at a time
+	return c.pprofCapture(req, seconds, stopCh, trace.Start, trace.Stop)
+}
+
+// pprofCapture checks the request and collects the output of start until the
+// duration expires or stopCh is closed. The state lock is only held while
+// checking the request, so that sealing or stepping down is not blocked for
+// the whole duration.
+func (c *Core) pprofCapture(req *logical.Request, seconds int, stopCh <-chan struct{},
+	start func(io.Writer) error, stop func()) ([]byte, error) {
+	if seconds <= 0 || seconds > PprofMaxSeconds {
+		return nil, logical.CodedError(http.StatusBadRequest,
+			fmt.Sprintf("seconds must be between 1 and %d", PprofMaxSeconds))
+	}
+	if err := c.checkPprofRequest(req); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := start(&buf); err != nil {
+		return nil, logical.CodedError(http.StatusBadRequest,
+			fmt.Sprintf("failed to start the capture: %v", err))
+	}
+
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-stopCh:
+		stop()
+		return nil, ErrPprofCanceled
+	}
+	stop()
+
+	return buf.Bytes(), nil
+}
+
+// checkPprofRequest checks that the request may profile this node
+func (c *Core) checkPprofRequest(req *logical.Request) error {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return consts.ErrSealed
+	}
+	if c.standby {
+		return consts.ErrStandby
+	}
+
+	return c.checkRootRequest(req)
+}
@@ -0,0 +1,96 @@
+package vault
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/consts"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+	physRaft "github.com/hashicorp/vault/physical/raft"
+)
+
+// ErrRaftStorageNotInUse is returned by the Raft storage operations when
+// Vault is configured with another storage backend
+var ErrRaftStorageNotInUse = errors.New("raft storage is not in use")
+
+// raftStorage returns the Raft storage backend Vault is configured with
+func (c *Core) raftStorage() (*physRaft.RaftBackend, error) {
+	raftBackend, ok := c.underlyingPhysical.(*physRaft.RaftBackend)
+	if !ok {
+		return nil, ErrRaftStorageNotInUse
+	}
+	return raftBackend, nil
+}
+
+// checkRaftSnapshotRequest checks that the request may take or restore a
+// snapshot of the Raft storage on this node
+func (c *Core) checkRaftSnapshotRequest(req *logical.Request) (*physRaft.RaftBackend, error) {
+	raftBackend, err := c.raftStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return nil, consts.ErrSealed
+	}
+	if c.standby {
+		return nil, consts.ErrStandby
+	}
+
+	if err := c.checkRootRequest(req); err != nil {
+		return nil, err
+	}
+	return raftBackend, nil
+}
+
+// RaftSnapshot writes a snapshot of the data of the Raft cluster to w. The
+// request must be made with root privileges.
+func (c *Core) RaftSnapshot(req *logical.Request, w io.Writer) error {
+	defer metrics.MeasureSince([]string{"core", "raft_snapshot"}, time.Now())
+
+	raftBackend, err := c.checkRaftSnapshotRequest(req)
+	if err != nil {
+		return err
+	}
+	return raftBackend.Snapshot(w)
+}
+
+// RaftSnapshotRestore replaces the data of the Raft cluster with a snapshot
+// taken by RaftSnapshot. The request must be made with root privileges.
+//
+// The snapshot may hold a different keyring and mount table, so once it is
+// restored this node steps down; the node becoming active then reloads them
+// from storage. The snapshot must have been taken with the same master key,
+// or the node becoming active seals itself.
+func (c *Core) RaftSnapshotRestore(req *logical.Request, r io.Reader) error {
+	defer metrics.MeasureSince([]string{"core", "raft_snapshot_restore"}, time.Now())
+
+	raftBackend, err := c.checkRaftSnapshotRequest(req)
+	if err != nil {
+		return err
+	}
+	if err := raftBackend.Restore(r); err != nil {
+		return err
+	}
+
+	if purgable, ok := c.physical.(physical.Purgable); ok {
+		purgable.Purge()
+	}
+
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed || c.standby || c.ha == nil {
+		return nil
+	}
+	select {
+	case c.manualStepDownCh <- struct{}{}:
+	default:
+		c.logger.Warn("core: manual step-down operation already queued")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"bytes"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -29,10 +30,18 @@ const (
 // RekeyResult is used to provide the key parts back after
 // they are generated as part of the rekey.
 type RekeyResult struct {
-	SecretShares    [][]byte
-	PGPFingerprints []string
-	Backup          bool
-	RecoveryKey     bool
+	SecretShares         [][]byte
+	PGPFingerprints      []string
+	Backup               bool
+	RecoveryKey          bool
+	VerificationRequired bool
+	VerificationNonce    string
+}
+
+// RekeyVerifyResult is used to provide the nonce of the verification once
+// the new key shares are verified and the rekey is committed
+type RekeyVerifyResult struct {
+	Nonce string
 }
 
 // RekeyBackup stores the backup copy of PGP-encrypted keys
@@ -136,6 +145,9 @@ func (c *Core) BarrierRekeyInit(config *SealConfig) error {
 		if config.Backup {
 			return fmt.Errorf("key backup not supported when using stored keys")
 		}
+		if config.VerificationRequired {
+			return fmt.Errorf("requiring verification not supported when using stored keys")
+		}
 	}
 
 	// Check if the seal configuration is valid
@@ -280,6 +292,10 @@ func (c *Core) BarrierRekeyUpdate(key []byte, nonce string) (*RekeyResult, error
 		return nil, fmt.Errorf("incorrect nonce supplied; nonce for this rekey operation is %s", c.barrierRekeyConfig.Nonce)
 	}
 
+	if len(c.barrierRekeyConfig.VerificationKey) > 0 {
+		return nil, fmt.Errorf("rekey verification in progress; the new keys must be provided to verify the rekey")
+	}
+
 	// Check if we already have this piece
 	for _, existing := range c.barrierRekeyProgress {
 		if bytes.Equal(existing, key) {
@@ -398,17 +414,43 @@ func (c *Core) BarrierRekeyUpdate(key []byte, nonce string) (*RekeyResult, error
 		}
 	}
 
+	// Hold on to the new key until the new key shares are verified
+	if c.barrierRekeyConfig.VerificationRequired {
+		nonce, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		c.barrierRekeyConfig.VerificationKey = newMasterKey
+		c.barrierRekeyConfig.VerificationNonce = nonce
+		results.VerificationRequired = true
+		results.VerificationNonce = nonce
+		return results, nil
+	}
+
+	if err := c.performBarrierRekey(newMasterKey); err != nil {
+		return nil, err
+	}
+
+	// Done!
+	c.barrierRekeyProgress = nil
+	c.barrierRekeyConfig = nil
+	return results, nil
+}
+
+// performBarrierRekey installs the new master key and seal configuration.
+// The rekey lock must be held.
+func (c *Core) performBarrierRekey(newMasterKey []byte) error {
 	// Rekey the barrier
 	if err := c.barrier.Rekey(newMasterKey); err != nil {
 		c.logger.Error("core: failed to rekey barrier", "error", err)
-		return nil, fmt.Errorf("failed to rekey barrier: %v", err)
+		return fmt.Errorf("failed to rekey barrier: %v", err)
 	}
 	if c.logger.IsInfo() {
 		c.logger.Info("core: security barrier rekeyed", "shares", c.barrierRekeyConfig.SecretShares, "threshold", c.barrierRekeyConfig.SecretThreshold)
 	}
-	if err := c.seal.SetBarrierConfig(c.barrierRekeyConfig); err != nil {
+	if err := c.seal.SetBarrierConfig(rekeyedSealConfig(c.barrierRekeyConfig)); err != nil {
 		c.logger.Error("core: error saving rekey seal configuration", "error", err)
-		return nil, fmt.Errorf("failed to save rekey seal configuration: %v", err)
+		return fmt.Errorf("failed to save rekey seal configuration: %v", err)
 	}
 
 	// Write to the canary path, which will force a synchronous truing during
@@ -418,13 +460,10 @@ func (c *Core) BarrierRekeyUpdate(key []byte, nonce string) (*RekeyResult, error
 		Value: []byte(c.barrierRekeyConfig.Nonce),
 	}); err != nil {
 		c.logger.Error("core: error saving keyring canary", "error", err)
-		return nil, fmt.Errorf("failed to save keyring canary: %v", err)
+		return fmt.Errorf("failed to save keyring canary: %v", err)
 	}
 
-	// Done!
-	c.barrierRekeyProgress = nil
-	c.barrierRekeyConfig = nil
-	return results, nil
+	return nil
 }
 
 // RecoveryRekeyUpdate is used to provide a new key part
@@ -477,6 +516,10 @@ func (c *Core) RecoveryRekeyUpdate(key []byte, nonce string) (*RekeyResult, erro
 		return nil, fmt.Errorf("incorrect nonce supplied; nonce for this rekey operation is %s", c.recoveryRekeyConfig.Nonce)
 	}
 
+	if len(c.recoveryRekeyConfig.VerificationKey) > 0 {
+		return nil, fmt.Errorf("rekey verification in progress; the new keys must be provided to verify the rekey")
+	}
+
 	// Check if we already have this piece
 	for _, existing := range c.recoveryRekeyProgress {
 		if bytes.Equal(existing, key) {
@@ -579,14 +622,40 @@ func (c *Core) RecoveryRekeyUpdate(key []byte, nonce string) (*RekeyResult, erro
 		}
 	}
 
+	// Hold on to the new key until the new key shares are verified
+	if c.recoveryRekeyConfig.VerificationRequired {
+		nonce, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, err
+		}
+		c.recoveryRekeyConfig.VerificationKey = newMasterKey
+		c.recoveryRekeyConfig.VerificationNonce = nonce
+		results.VerificationRequired = true
+		results.VerificationNonce = nonce
+		return results, nil
+	}
+
+	if err := c.performRecoveryRekey(newMasterKey); err != nil {
+		return nil, err
+	}
+
+	// Done!
+	c.recoveryRekeyProgress = nil
+	c.recoveryRekeyConfig = nil
+	return results, nil
+}
+
+// performRecoveryRekey installs the new recovery key and recovery
+// configuration. The rekey lock must be held.
+func (c *Core) performRecoveryRekey(newMasterKey []byte) error {
 	if err := c.seal.SetRecoveryKey(newMasterKey); err != nil {
 		c.logger.Error("core: failed to set recovery key", "error", err)
-		return nil, fmt.Errorf("failed to set recovery key: %v", err)
+		return fmt.Errorf("failed to set recovery key: %v", err)
 	}
 
-	if err := c.seal.SetRecoveryConfig(c.recoveryRekeyConfig); err != nil {
+	if err := c.seal.SetRecoveryConfig(rekeyedSealConfig(c.recoveryRekeyConfig)); err != nil {
 		c.logger.Error("core: error saving rekey seal configuration", "error", err)
-		return nil, fmt.Errorf("failed to save rekey seal configuration: %v", err)
+		return fmt.Errorf("failed to save rekey seal configuration: %v", err)
 	}
 
 	// Write to the canary path, which will force a synchronous truing during
@@ -596,13 +665,177 @@ func (c *Core) RecoveryRekeyUpdate(key []byte, nonce string) (*RekeyResult, erro
 		Value: []byte(c.recoveryRekeyConfig.Nonce),
 	}); err != nil {
 		c.logger.Error("core: error saving keyring canary", "error", err)
-		return nil, fmt.Errorf("failed to save keyring canary: %v", err)
+		return fmt.Errorf("failed to save keyring canary: %v", err)
+	}
+
+	return nil
+}
+
+// rekeyedSealConfig returns the seal configuration to install after a
+// rekey, without the state of its verification
+func rekeyedSealConfig(config *SealConfig) *SealConfig {
+	ret := config.Clone()
+	ret.VerificationRequired = false
+	ret.VerificationNonce = ""
+	return ret
+}
+
+// RekeyVerify is used to provide a share of the new key. Once enough shares
+// are provided and they match the new key, the rekey is committed.
+func (c *Core) RekeyVerify(key []byte, nonce string, recovery bool) (*RekeyVerifyResult, error) {
+	// Ensure we are already unsealed
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return nil, consts.ErrSealed
+	}
+	if c.standby {
+		return nil, consts.ErrStandby
+	}
+
+	// Verify the key length
+	min, max := c.barrier.KeyLength()
+	max += shamir.ShareOverhead
+	if len(key) < min {
+		return nil, &ErrInvalidKey{fmt.Sprintf("key is shorter than minimum %d bytes", min)}
+	}
+	if len(key) > max {
+		return nil, &ErrInvalidKey{fmt.Sprintf("key is longer than maximum %d bytes", max)}
+	}
+
+	c.rekeyLock.Lock()
+	defer c.rekeyLock.Unlock()
+
+	config := c.barrierRekeyConfig
+	if recovery {
+		config = c.recoveryRekeyConfig
+	}
+
+	// Ensure a verification is in progress
+	if config == nil || len(config.VerificationKey) == 0 {
+		return nil, fmt.Errorf("no rekey verification in progress")
+	}
+
+	if nonce != config.VerificationNonce {
+		return nil, fmt.Errorf("incorrect nonce supplied; nonce for this verify operation is %s", config.VerificationNonce)
+	}
+
+	// Check if we already have this piece
+	for _, existing := range config.VerificationProgress {
+		if bytes.Equal(existing, key) {
+			return nil, fmt.Errorf("given key has already been provided during this verify operation")
+		}
+	}
+
+	// Store this key
+	config.VerificationProgress = append(config.VerificationProgress, key)
+
+	// Check if we don't have enough keys to verify
+	if len(config.VerificationProgress) < config.SecretThreshold {
+		if c.logger.IsDebug() {
+			c.logger.Debug("core: cannot verify rekey yet, not enough keys", "keys", len(config.VerificationProgress), "threshold", config.SecretThreshold)
+		}
+		return nil, nil
+	}
+
+	// Recover the new key
+	var newMasterKey []byte
+	var err error
+	if config.SecretThreshold == 1 {
+		newMasterKey = config.VerificationProgress[0]
+		config.VerificationProgress = nil
+	} else {
+		newMasterKey, err = shamir.Combine(config.VerificationProgress)
+		config.VerificationProgress = nil
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute new key: %v", err)
+		}
+	}
+
+	if subtle.ConstantTimeCompare(newMasterKey, config.VerificationKey) != 1 {
+		c.logger.Error("core: rekey verification failed, the provided keys do not match the new key")
+		return nil, fmt.Errorf("rekey verification failed; the provided keys do not match the new key, and the verification must be restarted")
+	}
+
+	if recovery {
+		err = c.performRecoveryRekey(newMasterKey)
+	} else {
+		err = c.performBarrierRekey(newMasterKey)
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	// Done!
-	c.recoveryRekeyProgress = nil
-	c.recoveryRekeyConfig = nil
-	return results, nil
+	result := &RekeyVerifyResult{
+		Nonce: config.VerificationNonce,
+	}
+	memzero(config.VerificationKey)
+	if recovery {
+		c.recoveryRekeyProgress = nil
+		c.recoveryRekeyConfig = nil
+	} else {
+		c.barrierRekeyProgress = nil
+		c.barrierRekeyConfig = nil
+	}
+	return result, nil
+}
+
+// RekeyVerifyProgress is used to return the number of new key shares
+// provided so far to verify the rekey
+func (c *Core) RekeyVerifyProgress(recovery bool) (int, error) {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return 0, consts.ErrSealed
+	}
+	if c.standby {
+		return 0, consts.ErrStandby
+	}
+
+	c.rekeyLock.RLock()
+	defer c.rekeyLock.RUnlock()
+
+	config := c.barrierRekeyConfig
+	if recovery {
+		config = c.recoveryRekeyConfig
+	}
+	if config == nil {
+		return 0, nil
+	}
+	return len(config.VerificationProgress), nil
+}
+
+// RekeyVerifyRestart is used to discard the new key shares provided so far
+// and restart the verification with a new nonce. The new key is kept.
+func (c *Core) RekeyVerifyRestart(recovery bool) error {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+	if c.sealed {
+		return consts.ErrSealed
+	}
+	if c.standby {
+		return consts.ErrStandby
+	}
+
+	c.rekeyLock.Lock()
+	defer c.rekeyLock.Unlock()
+
+	config := c.barrierRekeyConfig
+	if recovery {
+		config = c.recoveryRekeyConfig
+	}
+	if config == nil || len(config.VerificationKey) == 0 {
+		return fmt.Errorf("no rekey verification in progress")
+	}
+
+	nonce, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	config.VerificationNonce = nonce
+	config.VerificationProgress = nil
+	return nil
 }
 
 // RekeyCancel is used to cancel an inprogress rekey
@@ -621,9 +854,15 @@ func (c *Core) RekeyCancel(recovery bool) error {
 
 	// Clear any progress or config
 	if recovery {
+		if c.recoveryRekeyConfig != nil {
+			memzero(c.recoveryRekeyConfig.VerificationKey)
+		}
 		c.recoveryRekeyConfig = nil
 		c.recoveryRekeyProgress = nil
 	} else {
+		if c.barrierRekeyConfig != nil {
+			memzero(c.barrierRekeyConfig.VerificationKey)
+		}
 		c.barrierRekeyConfig = nil
 		c.barrierRekeyProgress = nil
 	}
@@ -320,6 +320,149 @@ func testCore_Rekey_Update_Common(t *testing.T, c *Core, keys [][]byte, root str
 	}
 }
 
+func TestCore_Rekey_Verify(t *testing.T) {
+	bc, rc := TestSealDefConfigs()
+	bc.StoredShares = 0
+	c, masterKeys, recoveryKeys, root := TestCoreUnsealedWithConfigs(t, bc, rc)
+	testCore_Rekey_Verify_Common(t, c, masterKeys, root, false)
+	testCore_Rekey_Verify_Common(t, c, recoveryKeys, root, true)
+}
+
+func testCore_Rekey_Verify_Common(t *testing.T, c *Core, keys [][]byte, root string, recovery bool) {
+	var expType string
+	if recovery {
+		expType = c.seal.RecoveryType()
+	} else {
+		expType = c.seal.BarrierType()
+	}
+
+	newConf := &SealConfig{
+		Type:                 expType,
+		SecretThreshold:      3,
+		SecretShares:         5,
+		VerificationRequired: true,
+	}
+	if err := c.RekeyInit(newConf, recovery); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	rkconf, err := c.RekeyConfig(recovery)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var result *RekeyResult
+	for _, key := range keys {
+		result, err = c.RekeyUpdate(TestKeyCopy(key), rkconf.Nonce, recovery)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if result != nil {
+			break
+		}
+	}
+	if result == nil || len(result.SecretShares) != 5 || !result.VerificationRequired || result.VerificationNonce == "" {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// The new keys are not installed until verified
+	var sealConf *SealConfig
+	if recovery {
+		sealConf, err = c.seal.RecoveryConfig()
+	} else {
+		sealConf, err = c.seal.BarrierConfig()
+	}
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sealConf.Nonce == rkconf.Nonce {
+		t.Fatalf("bad: %#v", sealConf)
+	}
+	if _, err := c.RekeyUpdate(TestKeyCopy(keys[0]), rkconf.Nonce, recovery); err == nil {
+		t.Fatal("expected an error while verifying")
+	}
+	if _, err := c.RekeyVerify(TestKeyCopy(result.SecretShares[0]), "bad", recovery); err == nil {
+		t.Fatal("expected an error with a bad nonce")
+	}
+
+	// Shares of the old key do not verify, and restart the verification
+	verifyNonce := result.VerificationNonce
+	for i := 0; i < 3; i++ {
+		_, err := c.RekeyVerify(TestKeyCopy(keys[i]), verifyNonce, recovery)
+		if i < 2 && err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if i == 2 && err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if num, err := c.RekeyVerifyProgress(recovery); err != nil || num != 0 {
+		t.Fatalf("bad: %d %v", num, err)
+	}
+
+	// Restarting the verification changes its nonce
+	if err := c.RekeyVerifyRestart(recovery); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	rkconf, err = c.RekeyConfig(recovery)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if rkconf.VerificationNonce == "" || rkconf.VerificationNonce == verifyNonce {
+		t.Fatalf("bad: %#v", rkconf)
+	}
+	verifyNonce = rkconf.VerificationNonce
+
+	var verifyResult *RekeyVerifyResult
+	for i := 0; i < 3; i++ {
+		verifyResult, err = c.RekeyVerify(TestKeyCopy(result.SecretShares[i]), verifyNonce, recovery)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if (i < 2) != (verifyResult == nil) {
+			t.Fatalf("bad: %d %#v", i, verifyResult)
+		}
+	}
+	if verifyResult.Nonce != verifyNonce {
+		t.Fatalf("bad: %#v", verifyResult)
+	}
+
+	// The rekey is committed
+	if conf, err := c.RekeyConfig(recovery); err != nil || conf != nil {
+		t.Fatalf("bad: %#v %v", conf, err)
+	}
+	if recovery {
+		sealConf, err = c.seal.RecoveryConfig()
+	} else {
+		sealConf, err = c.seal.BarrierConfig()
+	}
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &SealConfig{
+		Type:            expType,
+		SecretThreshold: 3,
+		SecretShares:    5,
+		Nonce:           rkconf.Nonce,
+	}
+	if !reflect.DeepEqual(sealConf, expected) {
+		t.Fatalf("\nexpected: %#v\nactual: %#v", expected, sealConf)
+	}
+
+	if !recovery {
+		if err := c.Seal(root); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			if _, err := TestCoreUnseal(c, TestKeyCopy(result.SecretShares[i])); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+		}
+		if sealed, _ := c.Sealed(); sealed {
+			t.Fatal("should be unsealed")
+		}
+	}
+}
+
 func TestCore_Rekey_Invalid(t *testing.T) {
 	bc, rc := TestSealDefConfigs()
 	bc.StoredShares = 0
@@ -0,0 +1,988 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-rootcerts"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// drReplicationConfigPath is the path of the disaster recovery
+	// replication configuration. It is stored outside of the barrier, as it
+	// is needed while sealed, and it is never replicated.
+	drReplicationConfigPath = drReplicationLocalPrefix + "dr"
+
+	// drStreamPath is the API path secondaries stream the storage of their
+	// primary from
+	drStreamPath = "/v1/sys/replication/dr/primary/stream"
+
+	drModePrimary   = "primary"
+	drModeSecondary = "secondary"
+
+	// drStreamBatchSize is the maximum number of log entries, or of keys of
+	// a full copy of the storage, sent to a secondary at once
+	drStreamBatchSize = 1000
+
+	// drPollInterval is how often secondaries poll their primary once they
+	// are up to date, or after an error
+	drPollInterval = time.Second
+
+	// drStreamTimeout is the timeout of the requests of secondaries to their
+	// primary
+	drStreamTimeout = 60 * time.Second
+)
+
+var (
+	// ErrDRSecondary is returned when unsealing a disaster recovery
+	// secondary, which only holds a copy of the storage of its primary
+	ErrDRSecondary = errors.New("cannot unseal a disaster recovery secondary, it must be promoted first")
+)
+
+// drReplicationConfig is the disaster recovery replication configuration of
+// the cluster
+type drReplicationConfig struct {
+	// Mode is either primary, secondary or empty if disabled
+	Mode string `json:"mode"`
+
+	// Secondaries holds the credentials of the secondaries of a primary
+	Secondaries map[string]*drKnownSecondary `json:"secondaries,omitempty"`
+
+	// PrimaryAPIAddr, SecondaryID, CAFile and CAPath are how a secondary
+	// reaches its primary
+	PrimaryAPIAddr string `json:"primary_api_addr,omitempty"`
+	SecondaryID    string `json:"secondary_id,omitempty"`
+	CAFile         string `json:"ca_file,omitempty"`
+	CAPath         string `json:"ca_path,omitempty"`
+
+	// Secret authenticates a secondary to its primary
+	Secret string `json:"secret,omitempty"`
+
+	// Epoch and Index are how far a secondary is in the write-ahead log of
+	// its primary
+	Epoch string `json:"epoch,omitempty"`
+	Index uint64 `json:"index,omitempty"`
+
+	// Cursor is the last key of the full copy of the storage of the primary
+	// a secondary has received, while it is being streamed
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// drKnownSecondary holds the credentials of a secondary of a primary
+type drKnownSecondary struct {
+	// SecretHash is the SHA-256 hash of the secret of the secondary
+	SecretHash string `json:"secret_hash"`
+
+	// ExpirationTime is when the activation token of the secondary expires
+	// if it has not streamed from the primary yet. It is cleared once it
+	// has.
+	ExpirationTime time.Time `json:"expiration_time,omitempty"`
+}
+
+// drActivationToken is handed out by a primary to activate a secondary
+type drActivationToken struct {
+	PrimaryAPIAddr string `json:"primary_api_addr"`
+	ID             string `json:"id"`
+	Secret         string `json:"secret"`
+}
+
+// DRStreamRequest is the request of a secondary for the writes of its
+// primary after the given position in its write-ahead log. If Cursor is set,
+// the secondary is receiving a full copy of the storage as of that position,
+// and requests the keys after the cursor.
+type DRStreamRequest struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+	Epoch  string `json:"epoch"`
+	Index  uint64 `json:"index"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// DRStreamResponse holds the writes of a primary. If Full is set, the
+// entries are a page of a copy of its whole storage, holding the keys after
+// Start up to Cursor, or up to the last key if there are no more pages. Any
+// other key in that range is deleted.
+type DRStreamResponse struct {
+	Epoch   string        `json:"epoch"`
+	Index   uint64        `json:"index"`
+	Full    bool          `json:"full"`
+	Start   string        `json:"start,omitempty"`
+	Cursor  string        `json:"cursor,omitempty"`
+	More    bool          `json:"more"`
+	Entries []*drLogEntry `json:"entries"`
+}
+
+// drReplication holds the disaster recovery replication state of the core
+type drReplication struct {
+	// opLock serializes changes of mode
+	opLock sync.Mutex
+
+	// loaded is set once the configuration has been read from storage. It
+	// is protected by the operation lock.
+	loaded bool
+
+	// l protects the fields below. It is held by secondaries while they
+	// write to their storage. The configuration is replaced on every change.
+	l         sync.RWMutex
+	config    *drReplicationConfig
+	lastError string
+	lastSync  time.Time
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	// pendingUpdateToken is the activation token key shares are being
+	// provided for to update the primary of the secondary. It is protected
+	// by the operation lock.
+	pendingUpdateToken string
+
+	// streamFunc requests the writes of the primary
+	streamFunc func(*drReplicationConfig, *DRStreamRequest) (*DRStreamResponse, error)
+}
+
+// setupDRReplication loads the disaster recovery replication configuration.
+// Primaries start logging writes, and secondaries start replicating. As the
+// storage may not be reachable yet, a failure to read the configuration is
+// only logged, and loading is retried before unsealing.
+func (c *Core) setupDRReplication() {
+	c.drReplication = &drReplication{
+		config:     &drReplicationConfig{},
+		streamFunc: c.drStreamHTTP,
+	}
+
+	if err := c.ensureDRReplicationLoaded(); err != nil {
+		c.logger.Warn("core: failed to load disaster recovery replication configuration, retrying on unseal", "error", err)
+	}
+}
+
+// ensureDRReplicationLoaded loads the disaster recovery replication
+// configuration if it has not been loaded yet
+func (c *Core) ensureDRReplicationLoaded() error {
+	c.drReplication.opLock.Lock()
+	defer c.drReplication.opLock.Unlock()
+
+	return c.loadDRReplication()
+}
+
+// loadDRReplication loads the disaster recovery replication configuration
+// if it has not been loaded yet. The operation lock must be held.
+func (c *Core) loadDRReplication() error {
+	d := c.drReplication
+	if d.loaded {
+		return nil
+	}
+
+	entry, err := c.physical.Get(drReplicationConfigPath)
+	if err != nil {
+		return errwrap.Wrapf("failed to read disaster recovery replication configuration: {{err}}", err)
+	}
+	d.loaded = true
+	if entry == nil {
+		return nil
+	}
+	config := new(drReplicationConfig)
+	if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+		return errwrap.Wrapf("failed to decode disaster recovery replication configuration: {{err}}", err)
+	}
+	d.l.Lock()
+	d.config = config
+	d.l.Unlock()
+
+	switch config.Mode {
+	case drModePrimary:
+		return c.enableDRLog()
+	case drModeSecondary:
+		c.startDRSecondary()
+	}
+	return nil
+}
+
+// persistDRConfig stores the configuration and makes it current. The lock
+// must be held.
+func (c *Core) persistDRConfig(config *drReplicationConfig) error {
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := c.physical.Put(&physical.Entry{
+		Key:   drReplicationConfigPath,
+		Value: buf,
+	}); err != nil {
+		return errwrap.Wrapf("failed to persist disaster recovery replication configuration: {{err}}", err)
+	}
+	c.drReplication.config = config
+	return nil
+}
+
+// enableDRLog starts logging writes under a new epoch
+func (c *Core) enableDRLog() error {
+	epoch, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	c.drLog.enable(epoch)
+	return nil
+}
+
+// drSecondary returns whether the core is a disaster recovery secondary
+func (c *Core) drSecondary() bool {
+	c.drReplication.l.RLock()
+	defer c.drReplication.l.RUnlock()
+
+	return c.drReplication.config.Mode == drModeSecondary
+}
+
+// enableDRPrimary makes the core a disaster recovery primary
+func (c *Core) enableDRPrimary() error {
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.config.Mode != "" {
+		return logical.CodedError(400, fmt.Sprintf("disaster recovery replication is already enabled as %s", d.config.Mode))
+	}
+
+	if err := c.persistDRConfig(&drReplicationConfig{
+		Mode:        drModePrimary,
+		Secondaries: map[string]*drKnownSecondary{},
+	}); err != nil {
+		return err
+	}
+	return c.enableDRLog()
+}
+
+// generateDRSecondaryToken returns the activation token of the secondary
+// with the given ID, which must be used before the TTL elapses. Generating a
+// token for an existing secondary replaces its secret.
+func (c *Core) generateDRSecondaryToken(id string, ttl time.Duration) (string, error) {
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.config.Mode != drModePrimary {
+		return "", logical.CodedError(400, "not a disaster recovery primary")
+	}
+
+	secret, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	config := *d.config
+	config.Secondaries = make(map[string]*drKnownSecondary, len(d.config.Secondaries)+1)
+	for k, v := range d.config.Secondaries {
+		config.Secondaries[k] = v
+	}
+	config.Secondaries[id] = &drKnownSecondary{
+		SecretHash:     drHashSecret(secret),
+		ExpirationTime: time.Now().Add(ttl),
+	}
+	if err := c.persistDRConfig(&config); err != nil {
+		return "", err
+	}
+
+	return encodeDRActivationToken(&drActivationToken{
+		PrimaryAPIAddr: c.redirectAddr,
+		ID:             id,
+		Secret:         secret,
+	})
+}
+
+// revokeDRSecondary stops the secondary with the given ID from streaming
+// from the primary
+func (c *Core) revokeDRSecondary(id string) error {
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.config.Mode != drModePrimary {
+		return logical.CodedError(400, "not a disaster recovery primary")
+	}
+	if _, ok := d.config.Secondaries[id]; !ok {
+		return logical.CodedError(400, fmt.Sprintf("unknown secondary %q", id))
+	}
+
+	config := *d.config
+	config.Secondaries = make(map[string]*drKnownSecondary, len(d.config.Secondaries))
+	for k, v := range d.config.Secondaries {
+		if k != id {
+			config.Secondaries[k] = v
+		}
+	}
+	return c.persistDRConfig(&config)
+}
+
+// demoteDRPrimary turns the primary into a secondary without a primary, and
+// seals it. It keeps its storage, and is pointed to a new primary with
+// UpdateDRPrimary.
+func (c *Core) demoteDRPrimary() error {
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.config.Mode != drModePrimary {
+		return logical.CodedError(400, "not a disaster recovery primary")
+	}
+
+	if err := c.persistDRConfig(&drReplicationConfig{
+		Mode: drModeSecondary,
+	}); err != nil {
+		return err
+	}
+	c.drLog.disable()
+
+	c.sealForDRSecondary()
+	return nil
+}
+
+// disableDRPrimary disables disaster recovery replication on the primary.
+// Its secondaries can no longer stream from it.
+func (c *Core) disableDRPrimary() error {
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.config.Mode != drModePrimary {
+		return logical.CodedError(400, "not a disaster recovery primary")
+	}
+
+	if err := c.physical.Delete(drReplicationConfigPath); err != nil {
+		return errwrap.Wrapf("failed to delete disaster recovery replication configuration: {{err}}", err)
+	}
+	d.config = &drReplicationConfig{}
+	c.drLog.disable()
+	return nil
+}
+
+// enableDRSecondary makes the core a disaster recovery secondary of the
+// primary that issued the activation token, and seals it. Its storage is
+// replaced by that of the primary.
+func (c *Core) enableDRSecondary(token, primaryAPIAddr, caFile, caPath string) error {
+	config, err := newDRSecondaryConfig(token, primaryAPIAddr, caFile, caPath)
+	if err != nil {
+		return err
+	}
+
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	if d.config.Mode != "" {
+		return logical.CodedError(400, fmt.Sprintf("disaster recovery replication is already enabled as %s", d.config.Mode))
+	}
+
+	if err := c.persistDRConfig(config); err != nil {
+		return err
+	}
+
+	c.sealForDRSecondary()
+	return nil
+}
+
+// PromoteDRSecondary provides one of the key shares of the primary to
+// promote the secondary. Once enough shares are provided, replication stops
+// and the secondary is made a primary and unsealed with them. It returns
+// whether the promotion is complete.
+//
+// The key given as a parameter will automatically be zerod after this
+// method is done with it.
+func (c *Core) PromoteDRSecondary(key []byte) (bool, error) {
+	if c.seal.StoredKeysSupported() {
+		return false, logical.CodedError(400, "promoting disaster recovery secondaries with stored keys is not supported")
+	}
+	if err := c.checkUnsealKeyLength(key); err != nil {
+		return false, logical.CodedError(400, err.Error())
+	}
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+
+	if err := c.loadDRReplication(); err != nil {
+		return false, err
+	}
+	if !c.drSecondary() {
+		return false, logical.CodedError(400, "not a disaster recovery secondary")
+	}
+	d.l.RLock()
+	copying := d.config.Cursor != ""
+	d.l.RUnlock()
+	if copying {
+		return false, logical.CodedError(400, "the secondary has not received the full copy of the storage of its primary yet")
+	}
+	d.pendingUpdateToken = ""
+
+	masterKey, err := c.drUnsealPart(key)
+	if err != nil || masterKey == nil {
+		return false, err
+	}
+	defer memzero(masterKey)
+
+	c.stopDRSecondary()
+	if err := c.barrier.Unseal(masterKey); err != nil {
+		c.startDRSecondary()
+		return false, logical.CodedError(400, err.Error())
+	}
+
+	d.l.Lock()
+	err = c.persistDRConfig(&drReplicationConfig{
+		Mode:        drModePrimary,
+		Secondaries: map[string]*drKnownSecondary{},
+	})
+	if err == nil {
+		d.lastError = ""
+		err = c.enableDRLog()
+	}
+	d.l.Unlock()
+	if err != nil {
+		c.barrier.Seal()
+		c.startDRSecondary()
+		return false, err
+	}
+
+	// The seal configuration is now that of the former primary
+	c.seal.SetBarrierConfig(nil)
+	if c.seal.RecoveryKeySupported() {
+		c.seal.SetRecoveryConfig(nil)
+	}
+
+	return c.unsealInternal(masterKey)
+}
+
+// UpdateDRPrimary provides one of the key shares of the secondary to point
+// it to the primary that issued the activation token. Once enough shares are
+// provided, the whole storage of the new primary is streamed. It returns
+// whether the update is complete.
+//
+// The key given as a parameter will automatically be zerod after this
+// method is done with it.
+func (c *Core) UpdateDRPrimary(token, primaryAPIAddr, caFile, caPath string, key []byte) (bool, error) {
+	config, err := newDRSecondaryConfig(token, primaryAPIAddr, caFile, caPath)
+	if err != nil {
+		return false, err
+	}
+	if c.seal.StoredKeysSupported() {
+		return false, logical.CodedError(400, "updating the primary of disaster recovery secondaries with stored keys is not supported")
+	}
+	if err := c.checkUnsealKeyLength(key); err != nil {
+		return false, logical.CodedError(400, err.Error())
+	}
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+	d := c.drReplication
+	d.opLock.Lock()
+	defer d.opLock.Unlock()
+
+	if err := c.loadDRReplication(); err != nil {
+		return false, err
+	}
+	if !c.drSecondary() {
+		return false, logical.CodedError(400, "not a disaster recovery secondary")
+	}
+
+	// Key shares provided for another token do not count
+	if d.pendingUpdateToken != token {
+		c.unlockInfo = nil
+		d.pendingUpdateToken = token
+	}
+
+	masterKey, err := c.drUnsealPart(key)
+	if err != nil || masterKey == nil {
+		return false, err
+	}
+	defer memzero(masterKey)
+	d.pendingUpdateToken = ""
+
+	c.stopDRSecondary()
+	defer c.startDRSecondary()
+
+	// Verify the master key without unsealing the secondary
+	if err := c.barrier.Unseal(masterKey); err != nil {
+		return false, logical.CodedError(400, err.Error())
+	}
+	if err := c.barrier.Seal(); err != nil {
+		return false, err
+	}
+
+	d.l.Lock()
+	defer d.l.Unlock()
+	if err := c.persistDRConfig(config); err != nil {
+		return false, err
+	}
+	d.lastError = ""
+	d.lastSync = time.Time{}
+	return true, nil
+}
+
+// DRKeyProgress returns the number of key shares provided so far to promote
+// the secondary or update its primary, and the number required
+func (c *Core) DRKeyProgress() (int, int, error) {
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+
+	config, err := c.drBarrierConfig()
+	if err != nil {
+		return 0, 0, err
+	}
+	required := 0
+	if config != nil {
+		required = config.SecretThreshold
+	}
+	progress := 0
+	if c.unlockInfo != nil {
+		progress = len(c.unlockInfo.Parts)
+	}
+	return progress, required, nil
+}
+
+// drUnsealPart adds the key share to those provided so far, and returns the
+// master key once there are enough. The state lock must be held.
+func (c *Core) drUnsealPart(key []byte) ([]byte, error) {
+	config, err := c.drBarrierConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, logical.CodedError(400, "the storage of the primary has not been replicated yet")
+	}
+	return c.unsealPart(config, key)
+}
+
+// drBarrierConfig returns the seal configuration in the replicated storage,
+// which is that of the primary, bypassing the cached configuration of the
+// seal
+func (c *Core) drBarrierConfig() (*SealConfig, error) {
+	entry, err := c.physical.Get(barrierSealConfigPath)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read seal configuration: {{err}}", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	config := new(SealConfig)
+	if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+		return nil, errwrap.Wrapf("failed to decode seal configuration: {{err}}", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, errwrap.Wrapf("invalid seal configuration: {{err}}", err)
+	}
+	return config, nil
+}
+
+// DRReplicationStatus returns the disaster recovery replication status of
+// the core
+func (c *Core) DRReplicationStatus() map[string]interface{} {
+	d := c.drReplication
+	d.l.RLock()
+	defer d.l.RUnlock()
+
+	status := map[string]interface{}{
+		"mode": "disabled",
+	}
+	switch d.config.Mode {
+	case drModePrimary:
+		secondaries := make([]string, 0, len(d.config.Secondaries))
+		for id := range d.config.Secondaries {
+			secondaries = append(secondaries, id)
+		}
+		sort.Strings(secondaries)
+
+		epoch, index := c.drLog.current()
+		status["mode"] = drModePrimary
+		status["known_secondaries"] = secondaries
+		status["epoch"] = epoch
+		status["last_wal"] = index
+	case drModeSecondary:
+		status["mode"] = drModeSecondary
+		status["primary_api_addr"] = d.config.PrimaryAPIAddr
+		status["secondary_id"] = d.config.SecondaryID
+		status["epoch"] = d.config.Epoch
+		status["last_wal"] = d.config.Index
+		status["last_error"] = d.lastError
+		status["last_sync"] = ""
+		if !d.lastSync.IsZero() {
+			status["last_sync"] = d.lastSync.Format(time.RFC3339Nano)
+		}
+	}
+	return status
+}
+
+// DRStream returns the writes of the primary after the position of the
+// secondary in its write-ahead log, or its whole storage if the log no
+// longer goes back that far
+func (c *Core) DRStream(req *DRStreamRequest) (*DRStreamResponse, error) {
+	d := c.drReplication
+	d.l.RLock()
+	config := d.config
+	d.l.RUnlock()
+
+	if config.Mode != drModePrimary {
+		return nil, logical.CodedError(400, "not a disaster recovery primary")
+	}
+	secondary, ok := config.Secondaries[req.ID]
+	if !ok || subtle.ConstantTimeCompare([]byte(secondary.SecretHash), []byte(drHashSecret(req.Secret))) != 1 {
+		return nil, logical.CodedError(403, "unknown secondary or invalid secret")
+	}
+	if !secondary.ExpirationTime.IsZero() {
+		if time.Now().After(secondary.ExpirationTime) {
+			return nil, logical.CodedError(403, "the activation token of the secondary has expired")
+		}
+		if err := c.activateDRSecondary(req.ID, secondary); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, ok := c.drLog.since(req.Epoch, req.Index, drStreamBatchSize)
+	if ok && req.Cursor == "" {
+		index := req.Index
+		if len(entries) > 0 {
+			index = entries[len(entries)-1].Index
+		}
+		return &DRStreamResponse{
+			Epoch:   req.Epoch,
+			Index:   index,
+			More:    len(entries) == drStreamBatchSize,
+			Entries: entries,
+		}, nil
+	}
+
+	// The full copy of the storage is sent in pages. The writes made while
+	// it is being sent are replayed on top of it from the log, so a copy in
+	// progress is continued as long as the log still goes back to where it
+	// started, and is started over otherwise.
+	epoch, index, start := req.Epoch, req.Index, req.Cursor
+	if !ok || start == "" {
+		epoch, index = c.drLog.current()
+		start = ""
+	}
+	entries, cursor, more, err := c.drLog.snapshot(start, drStreamBatchSize)
+	if err != nil {
+		return nil, errwrap.Wrapf("failed to read storage: {{err}}", err)
+	}
+	resp := &DRStreamResponse{
+		Epoch:   epoch,
+		Index:   index,
+		Full:    true,
+		Start:   start,
+		More:    more,
+		Entries: entries,
+	}
+	if more {
+		resp.Cursor = cursor
+	}
+	return resp, nil
+}
+
+// activateDRSecondary clears the expiration of the activation token of the
+// secondary, now that it has streamed from the primary
+func (c *Core) activateDRSecondary(id string, secondary *drKnownSecondary) error {
+	d := c.drReplication
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	// The secondary was revoked or given a new token in the meantime
+	if d.config.Secondaries[id] != secondary {
+		return logical.CodedError(403, "unknown secondary or invalid secret")
+	}
+
+	config := *d.config
+	config.Secondaries = make(map[string]*drKnownSecondary, len(d.config.Secondaries))
+	for k, v := range d.config.Secondaries {
+		config.Secondaries[k] = v
+	}
+	config.Secondaries[id] = &drKnownSecondary{
+		SecretHash: secondary.SecretHash,
+	}
+	return c.persistDRConfig(&config)
+}
+
+// sealForDRSecondary seals the core and starts replicating in the
+// background, as the request that triggered it holds the state lock
+func (c *Core) sealForDRSecondary() {
+	go func() {
+		c.stateLock.Lock()
+		if err := c.sealInternal(); err != nil {
+			c.logger.Error("core: failed to seal disaster recovery secondary", "error", err)
+		}
+		c.stateLock.Unlock()
+
+		c.drReplication.opLock.Lock()
+		c.startDRSecondary()
+		c.drReplication.opLock.Unlock()
+	}()
+}
+
+// startDRSecondary starts replicating from the primary. The operation lock
+// must be held.
+func (c *Core) startDRSecondary() {
+	d := c.drReplication
+	if d.stopCh != nil || !c.drSecondary() {
+		return
+	}
+
+	d.stopCh = make(chan struct{})
+	d.doneCh = make(chan struct{})
+	go c.runDRSecondary(d.stopCh, d.doneCh)
+}
+
+// stopDRSecondary stops replicating and waits for the last batch to be
+// written. The operation lock must be held.
+func (c *Core) stopDRSecondary() {
+	d := c.drReplication
+	if d.stopCh == nil {
+		return
+	}
+
+	close(d.stopCh)
+	<-d.doneCh
+	d.stopCh = nil
+	d.doneCh = nil
+}
+
+// runDRSecondary replicates from the primary until stopped
+func (c *Core) runDRSecondary(stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	for {
+		var wait time.Duration
+		more, err := c.drSync()
+		if err != nil {
+			c.logger.Error("core: disaster recovery replication failed", "error", err)
+		}
+		if err != nil || !more {
+			wait = drPollInterval
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// drSync requests and writes the next batch of writes of the primary. It
+// returns whether the primary has more.
+func (c *Core) drSync() (bool, error) {
+	d := c.drReplication
+	d.l.RLock()
+	config := d.config
+	d.l.RUnlock()
+
+	if config.Mode != drModeSecondary || config.PrimaryAPIAddr == "" {
+		return false, nil
+	}
+
+	resp, err := d.streamFunc(config, &DRStreamRequest{
+		ID:     config.SecondaryID,
+		Secret: config.Secret,
+		Epoch:  config.Epoch,
+		Index:  config.Index,
+		Cursor: config.Cursor,
+	})
+
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	// The configuration changed in the meantime
+	if d.config != config {
+		return false, nil
+	}
+
+	if err == nil {
+		err = c.applyDRStream(resp)
+	}
+	if err == nil {
+		updated := *config
+		updated.Epoch = resp.Epoch
+		updated.Index = resp.Index
+		updated.Cursor = resp.Cursor
+		err = c.persistDRConfig(&updated)
+	}
+	if err != nil {
+		d.lastError = err.Error()
+		return false, err
+	}
+
+	d.lastError = ""
+	d.lastSync = time.Now()
+	return resp.More, nil
+}
+
+// applyDRStream writes the writes of the primary to the storage. The lock
+// must be held.
+func (c *Core) applyDRStream(resp *DRStreamResponse) error {
+	if resp.Full {
+		keys, err := drCollectKeys(c.physical, "")
+		if err != nil {
+			return err
+		}
+		replicated := make(map[string]struct{}, len(resp.Entries))
+		for _, entry := range resp.Entries {
+			replicated[entry.Key] = struct{}{}
+		}
+		for _, key := range keys {
+			// Only the keys in the range of the page are deleted
+			if key <= resp.Start || (resp.More && key > resp.Cursor) {
+				continue
+			}
+			if _, ok := replicated[key]; ok {
+				continue
+			}
+			if err := c.physical.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entry := range resp.Entries {
+		if strings.HasPrefix(entry.Key, drReplicationLocalPrefix) {
+			continue
+		}
+
+		var err error
+		switch entry.Operation {
+		case physical.PutOperation:
+			err = c.physical.Put(&physical.Entry{
+				Key:   entry.Key,
+				Value: entry.Value,
+			})
+		case string(physical.DeleteOperation):
+			err = c.physical.Delete(entry.Key)
+		default:
+			err = fmt.Errorf("unknown operation %q", entry.Operation)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drStreamHTTP requests the writes of the primary over its API
+func (c *Core) drStreamHTTP(config *drReplicationConfig, req *DRStreamRequest) (*DRStreamResponse, error) {
+	client := cleanhttp.DefaultClient()
+	client.Timeout = drStreamTimeout
+	tlsConfig := &tls.Config{}
+	if err := rootcerts.ConfigureTLS(tlsConfig, &rootcerts.Config{
+		CAFile: config.CAFile,
+		CAPath: config.CAPath,
+	}); err != nil {
+		return nil, err
+	}
+	transport := cleanhttp.DefaultTransport()
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+
+	buf, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := client.Post(strings.TrimSuffix(config.PrimaryAPIAddr, "/")+drStreamPath, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		jsonutil.DecodeJSONFromReader(httpResp.Body, &errResp)
+		return nil, fmt.Errorf("primary returned status %d: %s", httpResp.StatusCode, strings.Join(errResp.Errors, ", "))
+	}
+
+	resp := new(DRStreamResponse)
+	if err := jsonutil.DecodeJSONFromReader(httpResp.Body, resp); err != nil {
+		return nil, errwrap.Wrapf("failed to decode response of primary: {{err}}", err)
+	}
+	return resp, nil
+}
+
+func drHashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func encodeDRActivationToken(token *drActivationToken) (string, error) {
+	buf, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+func decodeDRActivationToken(token string) (*drActivationToken, error) {
+	buf, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, logical.CodedError(400, "invalid token")
+	}
+	out := new(drActivationToken)
+	if err := jsonutil.DecodeJSON(buf, out); err != nil || out.Secret == "" {
+		return nil, logical.CodedError(400, "invalid token")
+	}
+	return out, nil
+}
+
+// newDRSecondaryConfig returns the configuration of a secondary of the
+// primary that issued the activation token
+func newDRSecondaryConfig(token, primaryAPIAddr, caFile, caPath string) (*drReplicationConfig, error) {
+	activation, err := decodeDRActivationToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if primaryAPIAddr == "" {
+		primaryAPIAddr = activation.PrimaryAPIAddr
+	}
+	if primaryAPIAddr == "" {
+		return nil, logical.CodedError(400, "the activation token does not include the API address of the primary, which must be provided")
+	}
+	if _, err := rootcerts.LoadCACerts(&rootcerts.Config{
+		CAFile: caFile,
+		CAPath: caPath,
+	}); err != nil {
+		return nil, logical.CodedError(400, err.Error())
+	}
+
+	return &drReplicationConfig{
+		Mode:           drModeSecondary,
+		PrimaryAPIAddr: primaryAPIAddr,
+		SecondaryID:    activation.ID,
+		CAFile:         caFile,
+		CAPath:         caPath,
+		Secret:         activation.Secret,
+	}, nil
+}
@@ -0,0 +1,239 @@
+package vault
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/helper/locksutil"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// drLogSize is the number of writes kept in the write-ahead log of a
+	// disaster recovery primary. Secondaries that fall further behind are
+	// sent a full copy of the storage instead.
+	drLogSize = 16384
+
+	// drReplicationLocalPrefix is the storage prefix of the replication state
+	// of the cluster itself, which is never replicated
+	drReplicationLocalPrefix = "core/replication/"
+)
+
+// drLogEntry is a single write to the physical storage of a disaster
+// recovery primary
+type drLogEntry struct {
+	Index     uint64 `json:"index"`
+	Operation string `json:"operation"`
+	Key       string `json:"key"`
+	Value     []byte `json:"value,omitempty"`
+}
+
+// drLogBackend wraps the physical backend of the core and, while enabled,
+// keeps a bounded write-ahead log of the writes made to it, from which
+// disaster recovery secondaries are brought up to date. Writes to the same
+// key are serialized so that the log holds them in the order they were
+// applied to the storage.
+type drLogBackend struct {
+	physical.Backend
+
+	locks []*locksutil.LockEntry
+
+	l       sync.RWMutex
+	enabled bool
+	epoch   string
+	index   uint64
+	entries []*drLogEntry
+}
+
+// newDRLogBackend returns a disabled write-ahead log wrapping the backend
+func newDRLogBackend(b physical.Backend) *drLogBackend {
+	return &drLogBackend{
+		Backend: b,
+		locks:   locksutil.CreateLocks(),
+	}
+}
+
+// Put writes the entry and logs it
+func (b *drLogBackend) Put(entry *physical.Entry) error {
+	lock := locksutil.LockForKey(b.locks, entry.Key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.Backend.Put(entry); err != nil {
+		return err
+	}
+	b.append(physical.PutOperation, entry.Key, entry.Value)
+	return nil
+}
+
+// Delete deletes the key and logs it
+func (b *drLogBackend) Delete(key string) error {
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.Backend.Delete(key); err != nil {
+		return err
+	}
+	b.append(string(physical.DeleteOperation), key, nil)
+	return nil
+}
+
+// Purge purges the wrapped backend if it supports it
+func (b *drLogBackend) Purge() {
+	if purgable, ok := b.Backend.(physical.Purgable); ok {
+		purgable.Purge()
+	}
+}
+
+func (b *drLogBackend) append(op, key string, value []byte) {
+	if strings.HasPrefix(key, drReplicationLocalPrefix) {
+		return
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if !b.enabled {
+		return
+	}
+
+	b.index++
+	b.entries = append(b.entries, &drLogEntry{
+		Index:     b.index,
+		Operation: op,
+		Key:       key,
+		Value:     value,
+	})
+	if len(b.entries) > drLogSize {
+		b.entries = b.entries[len(b.entries)-drLogSize:]
+	}
+}
+
+// enable starts logging writes under a new epoch. Logs of different epochs
+// have unrelated indexes.
+func (b *drLogBackend) enable(epoch string) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.enabled = true
+	b.epoch = epoch
+	b.index = 0
+	b.entries = nil
+}
+
+// disable stops logging writes and drops the log
+func (b *drLogBackend) disable() {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.enabled = false
+	b.epoch = ""
+	b.index = 0
+	b.entries = nil
+}
+
+// current returns the epoch and index of the log
+func (b *drLogBackend) current() (string, uint64) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	return b.epoch, b.index
+}
+
+// since returns up to max of the entries logged after the index. It returns
+// false if the log no longer holds all of them, or if the epoch does not
+// match.
+func (b *drLogBackend) since(epoch string, index uint64, max int) ([]*drLogEntry, bool) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if !b.enabled || epoch != b.epoch || index > b.index {
+		return nil, false
+	}
+	if index == b.index {
+		return nil, true
+	}
+	if len(b.entries) == 0 || b.entries[0].Index > index+1 {
+		return nil, false
+	}
+
+	start := int(index + 1 - b.entries[0].Index)
+	end := len(b.entries)
+	if end-start > max {
+		end = start + max
+	}
+
+	entries := make([]*drLogEntry, end-start)
+	copy(entries, b.entries[start:end])
+	return entries, true
+}
+
+// snapshot returns up to max of the replicated entries of the storage, in
+// order of their keys, starting after the given key. It also returns the
+// last key of the page, and whether there are more. Writes made while the
+// snapshot is taken are logged after the index that was current when it
+// started, so they are replayed on top of it.
+func (b *drLogBackend) snapshot(after string, max int) ([]*drLogEntry, string, bool, error) {
+	keys, err := drCollectKeys(b.Backend, "")
+	if err != nil {
+		return nil, "", false, err
+	}
+	sort.Strings(keys)
+
+	keys = keys[sort.Search(len(keys), func(i int) bool { return keys[i] > after }):]
+	more := len(keys) > max
+	if more {
+		keys = keys[:max]
+	}
+	cursor := after
+	if len(keys) > 0 {
+		cursor = keys[len(keys)-1]
+	}
+
+	entries := make([]*drLogEntry, 0, len(keys))
+	for _, key := range keys {
+		entry, err := b.Backend.Get(key)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if entry == nil {
+			continue
+		}
+		entries = append(entries, &drLogEntry{
+			Operation: physical.PutOperation,
+			Key:       key,
+			Value:     entry.Value,
+		})
+	}
+
+	return entries, cursor, more, nil
+}
+
+// drCollectKeys returns every key under the prefix, leaving out the local
+// replication state
+func drCollectKeys(b physical.Backend, prefix string) ([]string, error) {
+	keys, err := b.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, key := range keys {
+		key = prefix + key
+		if strings.HasPrefix(key, drReplicationLocalPrefix) {
+			continue
+		}
+		if strings.HasSuffix(key, "/") {
+			subKeys, err := drCollectKeys(b, key)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, subKeys...)
+			continue
+		}
+		out = append(out, key)
+	}
+	return out, nil
+}
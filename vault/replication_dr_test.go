@@ -0,0 +1,462 @@
+package vault
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/logical"
+	"github.com/hashicorp/vault/physical"
+	"github.com/hashicorp/vault/physical/inmem"
+	log "github.com/mgutz/logxi/v1"
+)
+
+// testDRWaitSealed waits for the core to be sealed after being made a
+// secondary
+func testDRWaitSealed(t *testing.T, c *Core) {
+	for i := 0; i < 50; i++ {
+		if sealed, _ := c.Sealed(); sealed {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("core was not sealed")
+}
+
+// testDRSync syncs the secondary until it is up to date
+func testDRSync(t *testing.T, c *Core) {
+	for {
+		more, err := c.drSync()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// testDRPromote promotes the secondary with the keys of the primary
+func testDRPromote(t *testing.T, c *Core, keys [][]byte) {
+	for i, key := range keys {
+		complete, err := c.PromoteDRSecondary(TestKeyCopy(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if complete != (i == len(keys)-1) {
+			t.Fatalf("bad: %d %v", i, complete)
+		}
+	}
+}
+
+// testDRSecondary makes the core a secondary of the primary, streaming from
+// it directly, and waits for it to be sealed
+func testDRSecondary(t *testing.T, primary, secondary *Core, primaryRoot, secondaryRoot, id string) string {
+	resp := testNamespaceRequest(t, primary, primaryRoot, logical.UpdateOperation, "sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": id,
+	})
+	token := resp.Data["token"].(string)
+
+	secondary.drReplication.streamFunc = func(config *drReplicationConfig, req *DRStreamRequest) (*DRStreamResponse, error) {
+		if config.PrimaryAPIAddr != "https://primary:8200" {
+			return nil, fmt.Errorf("bad primary address: %q", config.PrimaryAPIAddr)
+		}
+		return primary.DRStream(req)
+	}
+	testNamespaceRequest(t, secondary, secondaryRoot, logical.UpdateOperation, "sys/replication/dr/secondary/enable", map[string]interface{}{
+		"token":            token,
+		"primary_api_addr": "https://primary:8200",
+	})
+	testDRWaitSealed(t, secondary)
+	return token
+}
+
+func TestDRLogBackend(t *testing.T) {
+	inm, err := inmem.NewInmem(nil, log.New("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := newDRLogBackend(inm)
+
+	// Writes are not logged until enabled
+	b.Put(&physical.Entry{Key: "foo", Value: []byte("bar")})
+	b.enable("epoch1")
+	b.Put(&physical.Entry{Key: "foo", Value: []byte("baz")})
+	b.Put(&physical.Entry{Key: "core/replication/dr", Value: []byte("local")})
+	b.Put(&physical.Entry{Key: "sub/key", Value: []byte("value")})
+	b.Delete("foo")
+
+	entries, ok := b.since("epoch1", 0, drStreamBatchSize)
+	expected := []*drLogEntry{
+		{Index: 1, Operation: physical.PutOperation, Key: "foo", Value: []byte("baz")},
+		{Index: 2, Operation: physical.PutOperation, Key: "sub/key", Value: []byte("value")},
+		{Index: 3, Operation: string(physical.DeleteOperation), Key: "foo"},
+	}
+	if !ok || !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+	if entries, ok := b.since("epoch1", 2, 1); !ok || !reflect.DeepEqual(entries, expected[2:]) {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+	if entries, ok := b.since("epoch1", 3, 1); !ok || len(entries) != 0 {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+	if _, ok := b.since("epoch2", 0, 1); ok {
+		t.Fatal("expected a mismatching epoch to require a snapshot")
+	}
+	if _, ok := b.since("epoch1", 4, 1); ok {
+		t.Fatal("expected an index ahead of the log to require a snapshot")
+	}
+
+	// Old entries are dropped
+	for i := 0; i < drLogSize; i++ {
+		b.Put(&physical.Entry{Key: "foo", Value: []byte("bar")})
+	}
+	if _, ok := b.since("epoch1", 2, 1); ok {
+		t.Fatal("expected a dropped index to require a snapshot")
+	}
+	if entries, ok := b.since("epoch1", 3, 1); !ok || len(entries) != 1 || entries[0].Index != 4 {
+		t.Fatalf("bad: %v %#v", ok, entries)
+	}
+
+	// Snapshots leave out the local replication state
+	entries, cursor, more, err := b.snapshot("", drStreamBatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []*drLogEntry{
+		{Operation: physical.PutOperation, Key: "foo", Value: []byte("bar")},
+		{Operation: physical.PutOperation, Key: "sub/key", Value: []byte("value")},
+	}
+	if cursor != "sub/key" || more || !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("bad: %s %v %#v", cursor, more, entries)
+	}
+
+	// Snapshots are paged in order of the keys
+	entries, cursor, more, err = b.snapshot("", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "foo" || !more || !reflect.DeepEqual(entries, expected[:1]) {
+		t.Fatalf("bad: %s %v %#v", cursor, more, entries)
+	}
+	entries, cursor, more, err = b.snapshot(cursor, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor != "sub/key" || more || !reflect.DeepEqual(entries, expected[1:]) {
+		t.Fatalf("bad: %s %v %#v", cursor, more, entries)
+	}
+}
+
+func TestDRReplication_PromoteSecondary(t *testing.T) {
+	primary, keys, root := TestCoreUnsealed(t)
+	secondary, secondaryKeys, secondaryRoot := TestCoreUnsealed(t)
+
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/enable", nil)
+	testDRSecondary(t, primary, secondary, root, secondaryRoot, "dr1")
+
+	// Secondaries cannot be unsealed, with either set of keys
+	if _, err := TestCoreUnseal(secondary, TestKeyCopy(keys[0])); err != ErrDRSecondary {
+		t.Fatalf("expected ErrDRSecondary, got: %v", err)
+	}
+
+	testDRSync(t, secondary)
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "baz",
+	})
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "secret/other", map[string]interface{}{
+		"value": "other",
+	})
+	testDRSync(t, secondary)
+
+	status := secondary.DRReplicationStatus()
+	if status["mode"] != "secondary" || status["secondary_id"] != "dr1" || status["last_wal"].(uint64) == 0 ||
+		status["last_wal"] != primary.DRReplicationStatus()["last_wal"] {
+		t.Fatalf("bad: %#v", status)
+	}
+	status = primary.DRReplicationStatus()
+	if status["mode"] != "primary" || !reflect.DeepEqual(status["known_secondaries"], []string{"dr1"}) {
+		t.Fatalf("bad: %#v", status)
+	}
+
+	// Promotion requires the keys of the primary
+	for i, key := range secondaryKeys {
+		complete, err := secondary.PromoteDRSecondary(TestKeyCopy(key))
+		if i < len(secondaryKeys)-1 && (err != nil || complete) {
+			t.Fatalf("bad: %v %v", complete, err)
+		}
+		if i == len(secondaryKeys)-1 && err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if progress, required, err := secondary.DRKeyProgress(); err != nil || progress != 0 || required != 3 {
+		t.Fatalf("bad: %d %d %v", progress, required, err)
+	}
+	if sealed, _ := secondary.Sealed(); !sealed || !secondary.drSecondary() {
+		t.Fatal("expected the secondary to still be a sealed secondary")
+	}
+	testDRPromote(t, secondary, keys)
+
+	// The promoted secondary is unsealed and holds the data and tokens of
+	// the primary
+	if sealed, _ := secondary.Sealed(); sealed {
+		t.Fatal("expected the promoted secondary to be unsealed")
+	}
+	resp := testNamespaceRequest(t, secondary, root, logical.ReadOperation, "secret/foo", nil)
+	if resp.Data["value"] != "baz" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	resp = testNamespaceRequest(t, secondary, root, logical.ReadOperation, "secret/other", nil)
+	if resp.Data["value"] != "other" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+	if status := secondary.DRReplicationStatus(); status["mode"] != "primary" {
+		t.Fatalf("bad: %#v", status)
+	}
+}
+
+func TestDRReplication_SecondaryToken(t *testing.T) {
+	primary, _, root := TestCoreUnsealed(t)
+	secondary, _, secondaryRoot := TestCoreUnsealed(t)
+
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/enable", nil)
+	resp := testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id":  "dr1",
+		"ttl": "1s",
+	})
+	token := resp.Data["token"].(string)
+
+	// The address of the primary is required, as that of the core is unknown
+	resp, err := secondary.HandleRequest(&logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/replication/dr/secondary/enable",
+		ClientToken: secondaryRoot,
+		Data: map[string]interface{}{
+			"token": token,
+		},
+	})
+	if err == nil || !resp.IsError() {
+		t.Fatalf("expected an error, got: %#v", resp)
+	}
+
+	// Expired activation tokens can no longer be used
+	activation, err := decodeDRActivationToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := primary.DRStream(&DRStreamRequest{ID: activation.ID, Secret: activation.Secret}); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// Once used, activation tokens no longer expire
+	resp = testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": "dr1",
+	})
+	activation, err = decodeDRActivationToken(resp.Data["token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := primary.DRStream(&DRStreamRequest{ID: activation.ID, Secret: activation.Secret}); err != nil {
+		t.Fatal(err)
+	}
+	if !primary.drReplication.config.Secondaries["dr1"].ExpirationTime.IsZero() {
+		t.Fatalf("bad: %#v", primary.drReplication.config.Secondaries["dr1"])
+	}
+
+	// Disabling replication revokes every secondary
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/disable", nil)
+	if _, err := primary.DRStream(&DRStreamRequest{ID: activation.ID, Secret: activation.Secret}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if status := primary.DRReplicationStatus(); status["mode"] != "disabled" {
+		t.Fatalf("bad: %#v", status)
+	}
+}
+
+func TestDRReplication_DemotePrimary(t *testing.T) {
+	primary, keys, root := TestCoreUnsealed(t)
+	secondary, _, secondaryRoot := TestCoreUnsealed(t)
+
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/enable", nil)
+	testDRSecondary(t, primary, secondary, root, secondaryRoot, "dr1")
+	testDRSync(t, secondary)
+
+	// Revoked secondaries can no longer stream
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/revoke-secondary", map[string]interface{}{
+		"id": "dr1",
+	})
+	if _, err := secondary.drSync(); err == nil {
+		t.Fatal("expected an error")
+	}
+	if status := secondary.DRReplicationStatus(); status["last_error"] == "" {
+		t.Fatalf("bad: %#v", status)
+	}
+
+	testDRPromote(t, secondary, keys)
+	testNamespaceRequest(t, secondary, root, logical.UpdateOperation, "secret/foo", map[string]interface{}{
+		"value": "bar",
+	})
+
+	// The former primary is demoted and becomes a secondary of the promoted
+	// one
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/demote", nil)
+	testDRWaitSealed(t, primary)
+
+	resp := testNamespaceRequest(t, secondary, root, logical.UpdateOperation, "sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": "dr2",
+	})
+	token := resp.Data["token"].(string)
+	primary.drReplication.streamFunc = func(config *drReplicationConfig, req *DRStreamRequest) (*DRStreamResponse, error) {
+		if config.PrimaryAPIAddr != "https://secondary:8200" {
+			return nil, fmt.Errorf("bad primary address: %q", config.PrimaryAPIAddr)
+		}
+		return secondary.DRStream(req)
+	}
+
+	// Key shares provided for another token do not count
+	resp = testNamespaceRequest(t, secondary, root, logical.UpdateOperation, "sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": "dr3",
+	})
+	if _, err := primary.UpdateDRPrimary(resp.Data["token"].(string), "https://secondary:8200", "", "", TestKeyCopy(keys[1])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := primary.UpdateDRPrimary(token, "https://secondary:8200", "", "", TestKeyCopy(keys[0])); err != nil {
+		t.Fatal(err)
+	}
+	if progress, _, _ := primary.DRKeyProgress(); progress != 1 {
+		t.Fatalf("bad: %d", progress)
+	}
+	for i, key := range keys[1:] {
+		complete, err := primary.UpdateDRPrimary(token, "https://secondary:8200", "", "", TestKeyCopy(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if complete != (i == len(keys)-2) {
+			t.Fatalf("bad: %d %v", i, complete)
+		}
+	}
+	if sealed, _ := primary.Sealed(); !sealed {
+		t.Fatal("expected the updated secondary to be sealed")
+	}
+	testDRSync(t, primary)
+
+	testDRPromote(t, primary, keys)
+	resp = testNamespaceRequest(t, primary, root, logical.ReadOperation, "secret/foo", nil)
+	if resp.Data["value"] != "bar" {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+}
+
+func TestDRReplication_FullCopyPaged(t *testing.T) {
+	primary, keys, root := TestCoreUnsealed(t)
+	secondary, _, secondaryRoot := TestCoreUnsealed(t)
+
+	testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/enable", nil)
+	for i := 0; i < 2*drStreamBatchSize+1; i++ {
+		if err := primary.physical.Put(&physical.Entry{Key: fmt.Sprintf("test/%04d", i), Value: []byte("value")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Keys the primary does not have are deleted from the secondary
+	if err := secondary.physical.Put(&physical.Entry{Key: "test/stale", Value: []byte("stale")}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := testNamespaceRequest(t, primary, root, logical.UpdateOperation, "sys/replication/dr/primary/secondary-token", map[string]interface{}{
+		"id": "dr1",
+	})
+	token := resp.Data["token"].(string)
+
+	// Writes made between the pages of the full copy are replayed on top of
+	// it
+	var l sync.Mutex
+	var pages int
+	secondary.drReplication.streamFunc = func(config *drReplicationConfig, req *DRStreamRequest) (*DRStreamResponse, error) {
+		resp, err := primary.DRStream(req)
+		if err != nil || !resp.Full {
+			return resp, err
+		}
+		if len(resp.Entries) > drStreamBatchSize {
+			return nil, fmt.Errorf("page of %d entries", len(resp.Entries))
+		}
+
+		l.Lock()
+		defer l.Unlock()
+		pages++
+		if pages == 1 {
+			if err := primary.physical.Put(&physical.Entry{Key: "test/0000", Value: []byte("late")}); err != nil {
+				return nil, err
+			}
+			if err := primary.physical.Put(&physical.Entry{Key: "test/late", Value: []byte("late")}); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+	testNamespaceRequest(t, secondary, secondaryRoot, logical.UpdateOperation, "sys/replication/dr/secondary/enable", map[string]interface{}{
+		"token":            token,
+		"primary_api_addr": "https://primary:8200",
+	})
+	testDRWaitSealed(t, secondary)
+
+	// The secondary also syncs in the background, so sync until it is
+	// current with the primary
+	for i := 0; ; i++ {
+		testDRSync(t, secondary)
+		status := secondary.DRReplicationStatus()
+		secondary.drReplication.l.RLock()
+		cursor := secondary.drReplication.config.Cursor
+		secondary.drReplication.l.RUnlock()
+		if cursor == "" && status["last_wal"] == primary.DRReplicationStatus()["last_wal"] {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("secondary did not catch up: %#v", status)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// A secondary cannot be promoted halfway through the full copy
+	secondary.drReplication.opLock.Lock()
+	secondary.stopDRSecondary()
+	secondary.drReplication.opLock.Unlock()
+	secondary.drReplication.l.Lock()
+	config := *secondary.drReplication.config
+	config.Cursor = "test/0001"
+	secondary.drReplication.config = &config
+	secondary.drReplication.l.Unlock()
+	if _, err := secondary.PromoteDRSecondary(TestKeyCopy(keys[0])); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	l.Lock()
+	if pages < 3 {
+		t.Fatalf("expected the full copy to be paged, got %d pages", pages)
+	}
+	l.Unlock()
+
+	for key, expected := range map[string]string{
+		"test/0000": "late",
+		"test/late": "late",
+		fmt.Sprintf("test/%04d", 2*drStreamBatchSize): "value",
+	} {
+		entry, err := secondary.physical.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry == nil || string(entry.Value) != expected {
+			t.Fatalf("bad: %s: %#v", key, entry)
+		}
+	}
+	if entry, err := secondary.physical.Get("test/stale"); err != nil || entry != nil {
+		t.Fatalf("bad: %#v %v", entry, err)
+	}
+}
@@ -38,11 +38,15 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 		return logical.ErrorResponse("cannot write to a path ending in '/'"), nil
 	}
 
+	// Auth mounts within namespaces are routed under auth/
+	req.Path = c.namespaceRoutePath(req.Path)
+
 	var auth *logical.Auth
+	var cgReq *controlGroupRequest
 	if c.router.LoginPath(req.Path) {
 		resp, auth, err = c.handleLoginRequest(req)
 	} else {
-		resp, auth, err = c.handleRequest(req)
+		resp, auth, cgReq, err = c.handleRequest(req)
 	}
 
 	// Ensure we don't leak internal data
@@ -66,7 +70,7 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 		resp.WrapInfo.TTL != 0
 
 	if wrapping {
-		cubbyResp, cubbyErr := c.wrapInCubbyhole(req, resp)
+		cubbyResp, cubbyErr := c.wrapInCubbyhole(req, resp, cgReq)
 		// If not successful, returns either an error response from the
 		// cubbyhole backend or an error; if either is set, set resp and err to
 		// those and continue so that that's what we audit log. Otherwise
@@ -87,7 +91,7 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 	// When unwrapping we want to log the actual response that will be written
 	// out. We still want to return the raw value to avoid automatic updating
 	// to any of it.
-	if req.Path == "sys/wrapping/unwrap" &&
+	if c.namespaceRelativePath(req.Path) == "sys/wrapping/unwrap" &&
 		resp != nil &&
 		resp.Data != nil &&
 		resp.Data[logical.HTTPRawBody] != nil {
@@ -112,11 +116,27 @@ func (c *Core) HandleRequest(req *logical.Request) (resp *logical.Response, err
 	return
 }
 
-func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retErr error) {
+func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, retAuth *logical.Auth, retControlGroup *controlGroupRequest, retErr error) {
 	defer metrics.MeasureSince([]string{"core", "handle_request"}, time.Now())
 
 	// Validate the token
-	auth, te, ctErr := c.checkToken(req)
+	auth, te, controlGroup, ctErr := c.checkToken(req)
+
+	// A response wrapped under a control group cannot be unwrapped until the
+	// request is approved. This is checked before the wrapping token is used
+	// so that it stays valid meanwhile.
+	if ctErr == nil {
+		if cgErr := c.checkControlGroupUnwrap(req, te); cgErr != nil {
+			if err := c.auditBroker.LogRequest(auth, req, c.auditedHeaders, cgErr); err != nil {
+				c.logger.Error("core: failed to audit request", "path", req.Path, "error", err)
+			}
+			if cgErr == ErrInternalError {
+				return nil, auth, nil, multierror.Append(retErr, cgErr)
+			}
+			return logical.ErrorResponse(cgErr.Error()), auth, nil, multierror.Append(retErr, logical.ErrInvalidRequest)
+		}
+	}
+
 	// We run this logic first because we want to decrement the use count even in the case of an error
 	if te != nil {
 		// Attempt to use the token (decrement NumUses)
@@ -125,12 +145,12 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if err != nil {
 			c.logger.Error("core: failed to use token", "error", err)
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, nil, retErr
+			return nil, nil, nil, retErr
 		}
 		if te == nil {
 			// Token has been revoked by this point
 			retErr = multierror.Append(retErr, logical.ErrPermissionDenied)
-			return nil, nil, retErr
+			return nil, nil, nil, retErr
 		}
 		if te.NumUses == -1 {
 			// We defer a revocation until after logic has run, since this is a
@@ -172,9 +192,9 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 			retErr = multierror.Append(retErr, errType)
 		}
 		if ctErr == ErrInternalError {
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
-		return logical.ErrorResponse(ctErr.Error()), auth, retErr
+		return logical.ErrorResponse(ctErr.Error()), auth, nil, retErr
 	}
 
 	// Attach the display name
@@ -184,10 +204,11 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 	if err := c.auditBroker.LogRequest(auth, req, c.auditedHeaders, nil); err != nil {
 		c.logger.Error("core: failed to audit request", "path", req.Path, "error", err)
 		retErr = multierror.Append(retErr, ErrInternalError)
-		return nil, auth, retErr
+		return nil, auth, nil, retErr
 	}
 
 	// Route the request
+	var cgReq *controlGroupRequest
 	resp, routeErr := c.router.Route(req)
 	if resp != nil {
 		// If wrapping is used, use the shortest between the request and response
@@ -220,6 +241,19 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 			}
 		}
 
+		// Responses subject to a control group are always wrapped, for no
+		// longer than the TTL of the control group
+		if controlGroup != nil {
+			if wrapTTL == 0 || wrapTTL > controlGroup.TTL {
+				wrapTTL = controlGroup.TTL
+			}
+			cgReq = &controlGroupRequest{
+				RequestPath:     req.Path,
+				RequestEntityID: te.EntityID,
+				Factors:         controlGroup.Factors,
+			}
+		}
+
 		if wrapTTL > 0 {
 			resp.WrapInfo = &wrapping.ResponseWrapInfo{
 				TTL:          wrapTTL,
@@ -231,14 +265,15 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 
 	// If there is a secret, we must register it with the expiration manager.
 	// We exclude renewal of a lease, since it does not need to be re-registered
-	if resp != nil && resp.Secret != nil && !strings.HasPrefix(req.Path, "sys/renew") &&
-		!strings.HasPrefix(req.Path, "sys/leases/renew") {
+	sysPath := c.namespaceRelativePath(req.Path)
+	if resp != nil && resp.Secret != nil && !strings.HasPrefix(sysPath, "sys/renew") &&
+		!strings.HasPrefix(sysPath, "sys/leases/renew") {
 		// Get the SystemView for the mount
 		sysView := c.router.MatchingSystemView(req.Path)
 		if sysView == nil {
 			c.logger.Error("core: unable to retrieve system view from router")
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 
 		// Apply the default lease if none given
@@ -259,7 +294,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if matchingBackend == nil {
 			c.logger.Error("core: unable to retrieve generic backend from router")
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 		if ptbe, ok := matchingBackend.(*PassthroughBackend); ok {
 			if !ptbe.GeneratesLeases() {
@@ -273,7 +308,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 			if err != nil {
 				c.logger.Error("core: failed to register lease", "request_path", req.Path, "error", err)
 				retErr = multierror.Append(retErr, ErrInternalError)
-				return nil, auth, retErr
+				return nil, auth, nil, retErr
 			}
 			resp.Secret.LeaseID = leaseID
 		}
@@ -282,11 +317,12 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 	// Only the token store is allowed to return an auth block, for any
 	// other request this is an internal error. We exclude renewal of a token,
 	// since it does not need to be re-registered
-	if resp != nil && resp.Auth != nil && !strings.HasPrefix(req.Path, "auth/token/renew") {
-		if !strings.HasPrefix(req.Path, "auth/token/") {
+	tokenStorePath := strings.TrimPrefix(req.Path, c.namespaceByPath(req.Path).Path)
+	if resp != nil && resp.Auth != nil && !strings.HasPrefix(tokenStorePath, "auth/token/renew") {
+		if !strings.HasPrefix(tokenStorePath, "auth/token/") {
 			c.logger.Error("core: unexpected Auth response for non-token backend", "request_path", req.Path)
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 
 		// Register with the expiration manager. We use the token's actual path
@@ -295,14 +331,14 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 		if err != nil {
 			c.logger.Error("core: failed to look up token", "error", err)
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 
 		if err := c.expiration.RegisterAuth(te.Path, resp.Auth); err != nil {
 			c.tokenStore.Revoke(te.ID)
 			c.logger.Error("core: failed to register token lease", "request_path", req.Path, "error", err)
 			retErr = multierror.Append(retErr, ErrInternalError)
-			return nil, auth, retErr
+			return nil, auth, nil, retErr
 		}
 	}
 
@@ -317,7 +353,7 @@ func (c *Core) handleRequest(req *logical.Request) (retResp *logical.Response, r
 	if routeErr != nil {
 		retErr = multierror.Append(retErr, routeErr)
 	}
-	return resp, auth, retErr
+	return resp, auth, cgReq, retErr
 }
 
 // handleLoginRequest is used to handle a login request, which is an
@@ -426,6 +462,15 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 			CreationTime: time.Now().Unix(),
 			TTL:          auth.TTL,
 			NumUses:      auth.NumUses,
+			BoundCIDRs:   auth.BoundCIDRs,
+		}
+
+		// Tokens belong to the namespace of the auth mount they are issued
+		// through
+		if mountEntry := c.router.MatchingMountEntry(req.Path); mountEntry != nil {
+			if ns := c.namespaceByPath(mountEntry.Path); ns.ID != rootNamespaceID {
+				te.NamespaceID = ns.ID
+			}
 		}
 
 		te.Policies = policyutil.SanitizePolicies(te.Policies, true)
@@ -437,6 +482,30 @@ func (c *Core) handleLoginRequest(req *logical.Request) (*logical.Response, *log
 			}
 		}
 
+		// Tie the token to the entity of the client, creating one if this is
+		// its first login, and refresh its external group memberships
+		if auth.Persona != nil && c.identityStore != nil {
+			mountEntry := c.router.MatchingMountEntry(req.Path)
+			if mountEntry == nil {
+				c.logger.Error("core: unable to look up mount entry for login path", "request_path", req.Path)
+				return nil, nil, ErrInternalError
+			}
+			auth.Persona.MountAccessor = mountEntry.Accessor
+			auth.Persona.MountType = mountEntry.Type
+
+			entity, err := c.identityStore.CreateOrFetchEntity(auth.Persona)
+			if err != nil {
+				c.logger.Error("core: failed to fetch entity for login", "request_path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
+			te.EntityID = entity.ID
+
+			if err := c.identityStore.refreshExternalGroupMemberships(mountEntry.Accessor, entity.ID, auth.GroupPersonas); err != nil {
+				c.logger.Error("core: failed to refresh external group memberships", "request_path", req.Path, "error", err)
+				return nil, nil, ErrInternalError
+			}
+		}
+
 		if err := c.tokenStore.create(&te); err != nil {
 			c.logger.Error("core: failed to create token", "error", err)
 			return nil, auth, ErrInternalError
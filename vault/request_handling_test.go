@@ -62,6 +62,43 @@ func TestRequestHandling_Wrapping(t *testing.T) {
 	}
 }
 
+func TestRequestHandling_WrappingMaxTTL(t *testing.T) {
+	core, _, root := TestCoreUnsealed(t)
+
+	req := &logical.Request{
+		Path:        "sys/wrapping/wrap",
+		ClientToken: root,
+		Operation:   logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"zip": "zap",
+		},
+		WrapInfo: &logical.RequestWrapInfo{
+			TTL: core.maxLeaseTTL + time.Hour,
+		},
+	}
+	resp, err := core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.WrapInfo == nil {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if resp.WrapInfo.TTL != core.maxLeaseTTL {
+		t.Fatalf("expected wrap TTL to be capped to %s, got %s", core.maxLeaseTTL, resp.WrapInfo.TTL)
+	}
+	if len(resp.Warnings) != 1 {
+		t.Fatalf("expected a warning, got: %#v", resp.Warnings)
+	}
+
+	te, err := core.tokenStore.Lookup(resp.WrapInfo.Token)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if te == nil || te.TTL != core.maxLeaseTTL {
+		t.Fatalf("bad: %#v", te)
+	}
+}
+
 func TestRequestHandling_LoginWrapping(t *testing.T) {
 	core, _, root := TestCoreUnsealed(t)
 
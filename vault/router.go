@@ -299,8 +299,8 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 	if !ok {
 		return logical.ErrorResponse(fmt.Sprintf("no handler for route '%s'", req.Path)), false, false, logical.ErrUnsupportedPath
 	}
-	defer metrics.MeasureSince([]string{"route", string(req.Operation),
-		strings.Replace(mount, "/", "-", -1)}, time.Now())
+	mountName := strings.Replace(mount, "/", "-", -1)
+	defer metrics.MeasureSince([]string{"route", string(req.Operation), mountName}, time.Now())
 	re := raw.(*routeEntry)
 
 	// If the path is tainted, we reject any operation except for
@@ -330,6 +330,8 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 	switch {
 	case strings.HasPrefix(originalPath, "auth/token/"):
 	case strings.HasPrefix(originalPath, "sys/"):
+	case re.mountEntry.Type == "system" || re.mountEntry.Type == "token":
+		// The system and token backends mounted within namespaces
 	case strings.HasPrefix(originalPath, "cubbyhole/"):
 		// In order for the token store to revoke later, we need to have the same
 		// salted ID, so we double-salt what's going to the cubbyhole backend
@@ -388,6 +390,12 @@ func (r *Router) routeCommon(req *logical.Request, existenceCheck bool) (*logica
 		return nil, ok, exists, err
 	} else {
 		resp, err := re.backend.HandleRequest(req)
+
+		// Count the requests to each mount, and those that failed
+		metrics.IncrCounter([]string{"route", "requests", mountName}, 1)
+		if err != nil || resp.IsError() {
+			metrics.IncrCounter([]string{"route", "errors", mountName}, 1)
+		}
 		return resp, false, false, err
 	}
 }
@@ -9,8 +9,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/logformat"
+	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/logical"
 	log "github.com/mgutz/logxi/v1"
 )
@@ -24,6 +26,7 @@ type NoopBackend struct {
 	Requests      []*logical.Request
 	Response      *logical.Response
 	Invalidations []string
+	Cleanups      int
 }
 
 func (n *NoopBackend) HandleRequest(req *logical.Request) (*logical.Response, error) {
@@ -59,7 +62,10 @@ func (n *NoopBackend) System() logical.SystemView {
 }
 
 func (n *NoopBackend) Cleanup() {
-	// noop
+	n.Lock()
+	defer n.Unlock()
+
+	n.Cleanups++
 }
 
 func (n *NoopBackend) InvalidateKey(k string) {
@@ -458,6 +464,65 @@ func TestRouter_Taint(t *testing.T) {
 	}
 }
 
+func TestRouter_Metrics(t *testing.T) {
+	sink := metricsutil.NewPrometheusSink()
+	conf := metrics.DefaultConfig("vault")
+	conf.EnableHostname = false
+	conf.EnableRuntimeMetrics = false
+	metrics.NewGlobal(conf, sink)
+	defer metrics.NewGlobal(conf, &metrics.BlackholeSink{})
+
+	r := NewRouter()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &NoopBackend{}
+	if err := r.Mount(n, "prod/aws/", &MountEntry{UUID: meUUID, Accessor: "awsaccessor"}, view); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "prod/aws/foo",
+	}
+	if _, err := r.Route(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	n.Response = logical.ErrorResponse("failed")
+	req = &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "prod/aws/foo",
+	}
+	if _, err := r.Route(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Existence checks are not counted as requests
+	req = &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "prod/aws/foo",
+	}
+	if _, _, err := r.RouteExistenceCheck(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	snapshot := sink.Snapshot()
+	expected := map[string]float64{
+		"vault_route_requests_prod_aws_": 2,
+		"vault_route_errors_prod_aws_":   1,
+	}
+	if !reflect.DeepEqual(snapshot.Counters, expected) {
+		t.Fatalf("bad: %#v", snapshot.Counters)
+	}
+	if sample := snapshot.Samples["vault_route_read_prod_aws_"]; sample == nil || sample.Count != 2 {
+		t.Fatalf("bad: %#v", snapshot.Samples)
+	}
+}
+
 func TestRouter_Untaint(t *testing.T) {
 	r := NewRouter()
 	_, barrier, _ := mockBarrier(t)
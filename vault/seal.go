@@ -241,6 +241,23 @@ type SealConfig struct {
 
 	// How many keys to store, for seals that support storage.
 	StoredShares int `json:"stored_shares"`
+
+	// VerificationRequired indicates that after a rekey the new key shares
+	// must be provided back before the new key is actually installed. This
+	// and the fields below are omitted from JSON, as they are only kept in
+	// memory during a rekey.
+	VerificationRequired bool `json:"-"`
+
+	// VerificationKey is the new key that will be installed once the new
+	// key shares are verified
+	VerificationKey []byte `json:"-"`
+
+	// VerificationNonce is the nonce of the verification of the new key
+	// shares
+	VerificationNonce string `json:"-"`
+
+	// VerificationProgress holds the new key shares provided so far
+	VerificationProgress [][]byte `json:"-"`
 }
 
 // Validate is used to sanity check the seal configuration
@@ -292,6 +309,9 @@ func (s *SealConfig) Clone() *SealConfig {
 		Nonce:           s.Nonce,
 		Backup:          s.Backup,
 		StoredShares:    s.StoredShares,
+
+		VerificationRequired: s.VerificationRequired,
+		VerificationNonce:    s.VerificationNonce,
 	}
 	if len(s.PGPKeys) > 0 {
 		ret.PGPKeys = make([]string, len(s.PGPKeys))
@@ -0,0 +1,254 @@
+package vault
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/hashicorp/vault/physical"
+)
+
+const (
+	// storedBarrierKeysPath is the path used to store the barrier keys
+	// encrypted by the seal. This value is stored outside of the barrier,
+	// since we must be able to read it with the Vault sealed.
+	storedBarrierKeysPath = "core/stored-barrier-keys"
+)
+
+// sealEncrypter encrypts and decrypts values with a key that is held outside
+// of Vault, such as in a cloud KMS or an HSM
+type sealEncrypter interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// autoSeal implements the parts shared by the seals that store the barrier key
+// encrypted by a sealEncrypter, allowing Vault to unseal itself on start.
+// Recovery keys are used in place of unseal keys for operations requiring a
+// quorum of key holders.
+type autoSeal struct {
+	defseal     *DefaultSeal
+	encrypter   sealEncrypter
+	barrierType string
+
+	recoveryConfig *SealConfig
+}
+
+func newAutoSeal(barrierType string, encrypter sealEncrypter) *autoSeal {
+	return &autoSeal{
+		encrypter:   encrypter,
+		barrierType: barrierType,
+	}
+}
+
+func (s *autoSeal) checkCore() error {
+	if s.defseal == nil || s.defseal.core == nil {
+		return fmt.Errorf("seal does not have a core set")
+	}
+	return nil
+}
+
+func (s *autoSeal) SetCore(core *Core) {
+	s.defseal = &DefaultSeal{}
+	s.defseal.core = core
+}
+
+func (s *autoSeal) Init() error {
+	// Ensure the key can be used before anything is written with it
+	if _, err := s.encrypter.Encrypt([]byte("vault-seal-check")); err != nil {
+		return fmt.Errorf("error using %s key: %v", s.barrierType, err)
+	}
+	return s.defseal.Init()
+}
+
+func (s *autoSeal) Finalize() error {
+	if s.defseal == nil {
+		return nil
+	}
+	return s.defseal.Finalize()
+}
+
+func (s *autoSeal) BarrierType() string {
+	return s.barrierType
+}
+
+func (s *autoSeal) BarrierConfig() (*SealConfig, error) {
+	return s.defseal.BarrierConfig()
+}
+
+func (s *autoSeal) SetBarrierConfig(config *SealConfig) error {
+	return s.defseal.SetBarrierConfig(config)
+}
+
+func (s *autoSeal) StoredKeysSupported() bool {
+	return true
+}
+
+func (s *autoSeal) SetStoredKeys(keys [][]byte) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to encode stored keys: %v", err)
+	}
+
+	ciphertext, err := s.encrypter.Encrypt(buf)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt stored keys: %v", err)
+	}
+
+	pe := &physical.Entry{
+		Key:   storedBarrierKeysPath,
+		Value: ciphertext,
+	}
+	if err := s.defseal.core.physical.Put(pe); err != nil {
+		return fmt.Errorf("failed to write stored keys: %v", err)
+	}
+
+	return nil
+}
+
+func (s *autoSeal) GetStoredKeys() ([][]byte, error) {
+	if err := s.checkCore(); err != nil {
+		return nil, err
+	}
+
+	pe, err := s.defseal.core.physical.Get(storedBarrierKeysPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored keys: %v", err)
+	}
+	if pe == nil {
+		return nil, nil
+	}
+
+	buf, err := s.encrypter.Decrypt(pe.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt stored keys: %v", err)
+	}
+
+	var keys [][]byte
+	if err := jsonutil.DecodeJSON(buf, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode stored keys: %v", err)
+	}
+
+	return keys, nil
+}
+
+func (s *autoSeal) RecoveryKeySupported() bool {
+	return true
+}
+
+func (s *autoSeal) RecoveryType() string {
+	return "shamir"
+}
+
+func (s *autoSeal) RecoveryConfig() (*SealConfig, error) {
+	if s.recoveryConfig != nil {
+		return s.recoveryConfig.Clone(), nil
+	}
+
+	if err := s.checkCore(); err != nil {
+		return nil, err
+	}
+
+	// The recovery configuration is stored inside the barrier
+	entry, err := s.defseal.core.barrier.Get(recoverySealConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recovery configuration: %v", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var conf SealConfig
+	if err := jsonutil.DecodeJSON(entry.Value, &conf); err != nil {
+		return nil, fmt.Errorf("failed to decode recovery configuration: %v", err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("recovery configuration validation failed: %v", err)
+	}
+
+	s.recoveryConfig = &conf
+	return s.recoveryConfig.Clone(), nil
+}
+
+func (s *autoSeal) SetRecoveryConfig(config *SealConfig) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	// Provide a way to wipe out the cached value (also prevents actually
+	// saving a nil config)
+	if config == nil {
+		s.recoveryConfig = nil
+		return nil
+	}
+
+	config.Type = s.RecoveryType()
+
+	buf, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode recovery configuration: %v", err)
+	}
+
+	if err := s.defseal.core.barrier.Put(&Entry{
+		Key:   recoverySealConfigPath,
+		Value: buf,
+	}); err != nil {
+		return fmt.Errorf("failed to write recovery configuration: %v", err)
+	}
+
+	s.recoveryConfig = config.Clone()
+
+	return nil
+}
+
+func (s *autoSeal) SetRecoveryKey(key []byte) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	if err := s.defseal.core.barrier.Put(&Entry{
+		Key:   recoveryKeyPath,
+		Value: key,
+	}); err != nil {
+		return fmt.Errorf("failed to write recovery key: %v", err)
+	}
+
+	return nil
+}
+
+func (s *autoSeal) VerifyRecoveryKey(key []byte) error {
+	if err := s.checkCore(); err != nil {
+		return err
+	}
+
+	entry, err := s.defseal.core.barrier.Get(recoveryKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read recovery key: %v", err)
+	}
+	if entry == nil {
+		return fmt.Errorf("no recovery key found")
+	}
+
+	if subtle.ConstantTimeCompare(entry.Value, key) != 1 {
+		return fmt.Errorf("recovery key verification failed")
+	}
+
+	return nil
+}
+
+// SealWrapEncrypt encrypts the plaintext with a data key encrypted by the
+// seal's key
+func (s *autoSeal) SealWrapEncrypt(plaintext []byte) ([]byte, error) {
+	return sealWrapEnvelope(s.encrypter.Encrypt, plaintext)
+}
+
+// SealWrapDecrypt decrypts a value encrypted by SealWrapEncrypt
+func (s *autoSeal) SealWrapDecrypt(ciphertext []byte) ([]byte, error) {
+	return sealUnwrapEnvelope(s.encrypter.Decrypt, ciphertext)
+}
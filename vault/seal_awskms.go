@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/awsutil"
+)
+
+// awsKMSSealType is the type of the AWS KMS seal
+const awsKMSSealType = "awskms"
+
+// AWSKMSSeal is a seal that stores the barrier key encrypted with an AWS KMS
+// customer master key, allowing Vault to unseal itself on start. Recovery
+// keys are used in place of unseal keys for operations requiring a quorum of
+// key holders.
+type AWSKMSSeal struct {
+	*autoSeal
+}
+
+// NewAWSKMSSeal creates an AWS KMS seal from the given configuration
+func NewAWSKMSSeal(conf map[string]string) (*AWSKMSSeal, error) {
+	keyID := os.Getenv("VAULT_AWSKMS_SEAL_KEY_ID")
+	if keyID == "" {
+		keyID = conf["kms_key_id"]
+	}
+	if keyID == "" {
+		return nil, fmt.Errorf("'kms_key_id' must be set")
+	}
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+		if region == "" {
+			region = conf["region"]
+			if region == "" {
+				region = "us-east-1"
+			}
+		}
+	}
+
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    conf["access_key"],
+		SecretKey:    conf["secret_key"],
+		SessionToken: conf["session_token"],
+		Region:       region,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{
+		Credentials: creds,
+		HTTPClient:  cleanhttp.DefaultClient(),
+		Region:      aws.String(region),
+	}
+	if endpoint := conf["endpoint"]; endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+
+	return &AWSKMSSeal{
+		autoSeal: newAutoSeal(awsKMSSealType, newAWSKMSClient(session.New(awsConfig), keyID)),
+	}, nil
+}
+
+// awsKMSClient is a minimal AWS KMS client performing encryption and
+// decryption with a single customer master key
+type awsKMSClient struct {
+	*client.Client
+	keyID string
+}
+
+type awsKMSEncryptInput struct {
+	KeyId     *string `type:"string"`
+	Plaintext []byte  `type:"blob"`
+}
+
+type awsKMSEncryptOutput struct {
+	CiphertextBlob []byte  `type:"blob"`
+	KeyId          *string `type:"string"`
+}
+
+type awsKMSDecryptInput struct {
+	CiphertextBlob []byte `type:"blob"`
+}
+
+type awsKMSDecryptOutput struct {
+	KeyId     *string `type:"string"`
+	Plaintext []byte  `type:"blob"`
+}
+
+func newAWSKMSClient(p client.ConfigProvider, keyID string) *awsKMSClient {
+	c := p.ClientConfig("kms")
+	svc := &awsKMSClient{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   "kms",
+				SigningName:   c.SigningName,
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    "2014-11-01",
+				JSONVersion:   "1.1",
+				TargetPrefix:  "TrentService",
+			},
+			c.Handlers,
+		),
+		keyID: keyID,
+	}
+
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return svc
+}
+
+func (c *awsKMSClient) do(name string, input, output interface{}) error {
+	op := &request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return c.NewRequest(op, input, output).Send()
+}
+
+func (c *awsKMSClient) Encrypt(plaintext []byte) ([]byte, error) {
+	output := &awsKMSEncryptOutput{}
+	if err := c.do("Encrypt", &awsKMSEncryptInput{
+		KeyId:     aws.String(c.keyID),
+		Plaintext: plaintext,
+	}, output); err != nil {
+		return nil, err
+	}
+	return output.CiphertextBlob, nil
+}
+
+func (c *awsKMSClient) Decrypt(ciphertext []byte) ([]byte, error) {
+	output := &awsKMSDecryptOutput{}
+	if err := c.do("Decrypt", &awsKMSDecryptInput{
+		CiphertextBlob: ciphertext,
+	}, output); err != nil {
+		return nil, err
+	}
+	return output.Plaintext, nil
+}
@@ -0,0 +1,196 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// testKMSEncrypter reverses and prefixes data in place of real encryption
+type testKMSEncrypter struct{}
+
+var testKMSPrefix = []byte("kms:")
+
+func (testKMSEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	out := append([]byte{}, testKMSPrefix...)
+	for i := len(plaintext) - 1; i >= 0; i-- {
+		out = append(out, plaintext[i])
+	}
+	return out, nil
+}
+
+func (testKMSEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, testKMSPrefix) {
+		return nil, fmt.Errorf("invalid ciphertext")
+	}
+	ciphertext = ciphertext[len(testKMSPrefix):]
+	out := make([]byte, 0, len(ciphertext))
+	for i := len(ciphertext) - 1; i >= 0; i-- {
+		out = append(out, ciphertext[i])
+	}
+	return out, nil
+}
+
+func TestAWSKMSSeal_AutoUnseal(t *testing.T) {
+	seal := &AWSKMSSeal{newAutoSeal(awsKMSSealType, testKMSEncrypter{})}
+	core := TestCoreWithSeal(t, seal)
+
+	result, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &SealConfig{
+			SecretShares:    5,
+			SecretThreshold: 3,
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(result.SecretShares) != 0 {
+		t.Fatalf("expected no unseal keys to be returned, got %d", len(result.SecretShares))
+	}
+	if len(result.RecoveryShares) != 5 {
+		t.Fatalf("expected 5 recovery keys, got %d", len(result.RecoveryShares))
+	}
+
+	// The stored keys must not be persisted in plaintext
+	pe, err := core.physical.Get(storedBarrierKeysPath)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pe == nil || !bytes.HasPrefix(pe.Value, testKMSPrefix) {
+		t.Fatalf("bad: %#v", pe)
+	}
+
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+
+	recoveryConfig, err := seal.RecoveryConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if recoveryConfig == nil || recoveryConfig.SecretShares != 5 || recoveryConfig.SecretThreshold != 3 {
+		t.Fatalf("bad: %#v", recoveryConfig)
+	}
+
+	// Seal and ensure the stored keys unseal again, as on a restart
+	if err := core.Seal(result.RootToken); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+}
+
+func TestAWSKMSSeal_RecoveryKey(t *testing.T) {
+	seal := &AWSKMSSeal{newAutoSeal(awsKMSSealType, testKMSEncrypter{})}
+	core := TestCoreWithSeal(t, seal)
+
+	if _, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+		},
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	key := []byte("recovery-key")
+	if err := seal.SetRecoveryKey(key); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := seal.VerifyRecoveryKey(key); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := seal.VerifyRecoveryKey([]byte("wrong-key")); err == nil {
+		t.Fatal("expected verification to fail")
+	}
+}
+
+func TestAWSKMSClient(t *testing.T) {
+	var lastTarget string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastTarget = r.Header.Get("X-Amz-Target")
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch lastTarget {
+		case "TrentService.Encrypt":
+			if body["KeyId"] != "test-key" {
+				http.Error(w, "bad key id", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{
+				"KeyId":          body["KeyId"],
+				"CiphertextBlob": body["Plaintext"],
+			})
+		case "TrentService.Decrypt":
+			json.NewEncoder(w).Encode(map[string]string{
+				"KeyId":     "test-key",
+				"Plaintext": body["CiphertextBlob"],
+			})
+		default:
+			http.Error(w, "unknown target", http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	c := newAWSKMSClient(session.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("access", "secret", ""),
+		Endpoint:    aws.String(ts.URL),
+		Region:      aws.String("us-east-1"),
+	}), "test-key")
+
+	ciphertext, err := c.Encrypt([]byte("plaintext"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if lastTarget != "TrentService.Encrypt" {
+		t.Fatalf("bad target: %s", lastTarget)
+	}
+	if string(ciphertext) != "plaintext" {
+		t.Fatalf("bad: %s", base64.StdEncoding.EncodeToString(ciphertext))
+	}
+
+	plaintext, err := c.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if lastTarget != "TrentService.Decrypt" {
+		t.Fatalf("bad target: %s", lastTarget)
+	}
+	if string(plaintext) != "plaintext" {
+		t.Fatalf("bad: %s", plaintext)
+	}
+}
@@ -0,0 +1,201 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+// ErrNoSealMigration is returned when a seal migration is requested but no
+// migration seal is configured
+var ErrNoSealMigration = errors.New("no seal migration is configured")
+
+// UnsealMigrate is used to provide one of the key parts required to migrate
+// from the configured migration seal to the current seal. When migrating to
+// a seal with stored keys, unseal keys are provided and become the recovery
+// keys of the new seal. When migrating away from a seal with stored keys,
+// recovery keys are provided and become the unseal keys.
+//
+// Once enough key parts have been provided, the seal configuration is
+// migrated and the Vault is unsealed.
+func (c *Core) UnsealMigrate(key []byte) (bool, error) {
+	defer metrics.MeasureSince([]string{"core", "unseal-migrate"}, time.Now())
+
+	if c.migrationSeal == nil {
+		return false, ErrNoSealMigration
+	}
+
+	if err := c.checkUnsealKeyLength(key); err != nil {
+		return false, err
+	}
+
+	// Get the seal configuration
+	config, err := c.seal.BarrierConfig()
+	if err != nil {
+		return false, err
+	}
+
+	// Ensure the barrier is initialized
+	if config == nil {
+		return false, ErrNotInit
+	}
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if !c.sealed {
+		return false, fmt.Errorf("vault must be sealed to migrate seals")
+	}
+
+	if err := c.ensureDRReplicationLoaded(); err != nil {
+		return false, err
+	}
+
+	switch {
+	case !c.migrationSeal.StoredKeysSupported() && c.seal.StoredKeysSupported():
+		if config.StoredShares > 0 {
+			return false, fmt.Errorf("seal migration has already been performed")
+		}
+		return c.migrateToStoredKeys(config, key)
+
+	case c.migrationSeal.StoredKeysSupported() && !c.seal.StoredKeysSupported():
+		if config.StoredShares == 0 {
+			return false, fmt.Errorf("seal migration has already been performed")
+		}
+		return c.migrateFromStoredKeys(key)
+
+	default:
+		return false, fmt.Errorf("migration from seal type %s to %s is not supported", c.migrationSeal.BarrierType(), c.seal.BarrierType())
+	}
+}
+
+// migrateToStoredKeys moves from a seal using unseal keys to one storing the
+// master key. The master key is stored through the new seal and the existing
+// unseal keys become its recovery keys.
+//
+// This must be called with the state write lock held
+func (c *Core) migrateToStoredKeys(config *SealConfig, key []byte) (bool, error) {
+	masterKey, err := c.unsealPart(config, key)
+	if err != nil || masterKey == nil {
+		return false, err
+	}
+
+	if err := c.barrier.Unseal(masterKey); err != nil {
+		memzero(masterKey)
+		return false, err
+	}
+
+	// The recovery configuration and key are stored in the barrier
+	recoveryConfig := config.Clone()
+	recoveryConfig.StoredShares = 0
+	recoveryConfig.PGPKeys = nil
+	recoveryConfig.Nonce = ""
+	recoveryConfig.Backup = false
+	if err := c.seal.SetRecoveryConfig(recoveryConfig); err != nil {
+		return c.abortSealMigration(masterKey, fmt.Errorf("failed to save recovery configuration: %v", err))
+	}
+	if err := c.seal.SetRecoveryKey(masterKey); err != nil {
+		return c.abortSealMigration(masterKey, fmt.Errorf("failed to save recovery key: %v", err))
+	}
+	if err := c.seal.SetStoredKeys([][]byte{masterKey}); err != nil {
+		return c.abortSealMigration(masterKey, fmt.Errorf("failed to store keys: %v", err))
+	}
+
+	// Writing the barrier configuration completes the migration
+	if err := c.seal.SetBarrierConfig(&SealConfig{
+		SecretShares:    1,
+		SecretThreshold: 1,
+		StoredShares:    1,
+	}); err != nil {
+		return c.abortSealMigration(masterKey, fmt.Errorf("failed to save barrier configuration: %v", err))
+	}
+	c.migrationSeal.SetBarrierConfig(nil)
+
+	c.logger.Info("core: seal migration complete", "from", c.migrationSeal.BarrierType(), "to", c.seal.BarrierType())
+
+	return c.unsealInternal(masterKey)
+}
+
+// migrateFromStoredKeys moves from a seal storing the master key to one using
+// unseal keys. The barrier is rekeyed to the recovery key so that the
+// existing recovery keys become the unseal keys.
+//
+// This must be called with the state write lock held
+func (c *Core) migrateFromStoredKeys(key []byte) (bool, error) {
+	keys, err := c.migrationSeal.GetStoredKeys()
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch stored keys: %v", err)
+	}
+	if len(keys) == 0 {
+		return false, fmt.Errorf("no stored keys found")
+	}
+
+	// The recovery configuration is stored in the barrier, so it must be
+	// unsealed to determine the number of required recovery keys
+	if err := c.barrier.Unseal(keys[0]); err != nil {
+		return false, err
+	}
+
+	recoveryConfig, err := c.migrationSeal.RecoveryConfig()
+	if err != nil {
+		c.barrier.Seal()
+		return false, err
+	}
+	if recoveryConfig == nil {
+		c.barrier.Seal()
+		return false, fmt.Errorf("recovery configuration not found")
+	}
+
+	recoveryKey, err := c.unsealPart(recoveryConfig, key)
+	if err != nil || recoveryKey == nil {
+		c.barrier.Seal()
+		return false, err
+	}
+
+	if err := c.migrationSeal.VerifyRecoveryKey(recoveryKey); err != nil {
+		return c.abortSealMigration(recoveryKey, err)
+	}
+
+	if err := c.barrier.Rekey(recoveryKey); err != nil {
+		return c.abortSealMigration(recoveryKey, fmt.Errorf("failed to rekey barrier: %v", err))
+	}
+
+	barrierConfig := recoveryConfig.Clone()
+	barrierConfig.StoredShares = 0
+	barrierConfig.Nonce = ""
+	if err := c.seal.SetBarrierConfig(barrierConfig); err != nil {
+		// Restore the stored key as the master key so that the previous
+		// seal can still unseal the Vault
+		if rekeyErr := c.barrier.Rekey(keys[0]); rekeyErr != nil {
+			c.logger.Error("core: failed to restore master key after failed seal migration", "error", rekeyErr)
+		}
+		return c.abortSealMigration(recoveryKey, fmt.Errorf("failed to save barrier configuration: %v", err))
+	}
+
+	// Clean up the state of the previous seal, which is no longer valid
+	if err := c.barrier.Delete(recoverySealConfigPath); err != nil {
+		c.logger.Warn("core: failed to remove recovery configuration", "error", err)
+	}
+	if err := c.barrier.Delete(recoveryKeyPath); err != nil {
+		c.logger.Warn("core: failed to remove recovery key", "error", err)
+	}
+	if err := c.physical.Delete(storedBarrierKeysPath); err != nil {
+		c.logger.Warn("core: failed to remove stored keys", "error", err)
+	}
+	c.migrationSeal.SetRecoveryConfig(nil)
+	c.migrationSeal.SetBarrierConfig(nil)
+
+	c.logger.Info("core: seal migration complete", "from", c.migrationSeal.BarrierType(), "to", c.seal.BarrierType())
+
+	return c.unsealInternal(recoveryKey)
+}
+
+// abortSealMigration reseals the barrier after a failed migration
+func (c *Core) abortSealMigration(key []byte, err error) (bool, error) {
+	memzero(key)
+	c.barrier.Seal()
+	c.logger.Error("core: seal migration failed", "error", err)
+	return false, err
+}
@@ -0,0 +1,133 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/shamir"
+)
+
+func testUnsealMigrate(t *testing.T, core *Core, keys [][]byte) {
+	for i, key := range keys {
+		unsealed, err := core.UnsealMigrate(TestKeyCopy(key))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if unsealed != (i == len(keys)-1) {
+			t.Fatalf("bad unseal state after %d keys: %v", i+1, unsealed)
+		}
+	}
+}
+
+func TestCore_UnsealMigrate_ShamirToStoredKeys(t *testing.T) {
+	core := TestCoreWithSeal(t, nil)
+	keys, root := TestCoreInit(t, core)
+
+	// Restart with the AWS KMS seal, migrating from the default seal
+	kmsSeal := &AWSKMSSeal{newAutoSeal(awsKMSSealType, testKMSEncrypter{})}
+	kmsSeal.SetCore(core)
+	core.migrationSeal = core.seal
+	core.seal = kmsSeal
+
+	testUnsealMigrate(t, core, keys)
+
+	conf, err := kmsSeal.BarrierConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.SecretShares != 1 || conf.SecretThreshold != 1 || conf.StoredShares != 1 {
+		t.Fatalf("bad: %#v", conf)
+	}
+
+	// The previous unseal keys are now the recovery keys
+	recoveryConf, err := kmsSeal.RecoveryConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if recoveryConf.SecretShares != 3 || recoveryConf.SecretThreshold != 3 {
+		t.Fatalf("bad: %#v", recoveryConf)
+	}
+	recoveryKey, err := shamir.Combine(keys)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := kmsSeal.VerifyRecoveryKey(recoveryKey); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Migrating again must fail, and the stored keys must unseal on restart
+	if err := core.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := core.UnsealMigrate(TestKeyCopy(keys[0])); err == nil {
+		t.Fatal("expected error")
+	}
+	core.migrationSeal = nil
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+}
+
+func TestCore_UnsealMigrate_StoredKeysToShamir(t *testing.T) {
+	kmsSeal := &AWSKMSSeal{newAutoSeal(awsKMSSealType, testKMSEncrypter{})}
+	core := TestCoreWithSeal(t, kmsSeal)
+	result, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &SealConfig{
+			SecretShares:    3,
+			SecretThreshold: 2,
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Restart with the default seal, migrating from the AWS KMS seal
+	defSeal := &DefaultSeal{}
+	defSeal.SetCore(core)
+	core.migrationSeal = kmsSeal
+	core.seal = defSeal
+
+	testUnsealMigrate(t, core, result.RecoveryShares[:2])
+
+	conf, err := defSeal.BarrierConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.SecretShares != 3 || conf.SecretThreshold != 2 || conf.StoredShares != 0 {
+		t.Fatalf("bad: %#v", conf)
+	}
+	if pe, err := core.physical.Get(storedBarrierKeysPath); err != nil || pe != nil {
+		t.Fatalf("stored keys not removed: %#v, %v", pe, err)
+	}
+
+	// The previous recovery keys now unseal the Vault
+	if err := core.Seal(result.RootToken); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	core.migrationSeal = nil
+	for i, key := range result.RecoveryShares[1:] {
+		unsealed, err := core.Unseal(TestKeyCopy(key))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if unsealed != (i == 1) {
+			t.Fatalf("bad unseal state after %d keys: %v", i+1, unsealed)
+		}
+	}
+}
+
+func TestCore_UnsealMigrate_NotConfigured(t *testing.T) {
+	core := TestCoreWithSeal(t, nil)
+	keys, _ := TestCoreInit(t, core)
+
+	if _, err := core.UnsealMigrate(TestKeyCopy(keys[0])); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,144 @@
+package vault
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hashicorp/vault/helper/parseutil"
+)
+
+// pkcs11SealType is the type of the PKCS#11 seal
+const pkcs11SealType = "pkcs11"
+
+const (
+	// pkcs11MechanismAESGCM and pkcs11MechanismAESCBCPad are the values of
+	// CKM_AES_GCM and CKM_AES_CBC_PAD, the mechanisms the seal can encrypt
+	// with
+	pkcs11MechanismAESGCM    = 0x1087
+	pkcs11MechanismAESCBCPad = 0x1085
+)
+
+// pkcs11Config holds the settings of an hsm "pkcs11" stanza
+type pkcs11Config struct {
+	lib         string
+	slot        uint
+	pin         string
+	keyLabel    string
+	mechanism   uint
+	generateKey bool
+}
+
+// pkcs11Encrypter is a sealEncrypter holding a PKCS#11 session that must be
+// closed when the seal is finalized
+type pkcs11Encrypter interface {
+	sealEncrypter
+	Close() error
+}
+
+// PKCS11Seal is a seal that stores the barrier key encrypted with an AES key
+// held in an HSM, accessed through a PKCS#11 library, allowing Vault to
+// unseal itself on start. Recovery keys are used in place of unseal keys for
+// operations requiring a quorum of key holders.
+type PKCS11Seal struct {
+	*autoSeal
+	hsm pkcs11Encrypter
+}
+
+// NewPKCS11Seal creates a PKCS#11 seal from the given configuration, opening
+// a session with the HSM and looking up the key to use
+func NewPKCS11Seal(conf map[string]string) (*PKCS11Seal, error) {
+	config, err := parsePKCS11Config(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	hsm, err := newPKCS11Client(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PKCS11Seal{
+		autoSeal: newAutoSeal(pkcs11SealType, hsm),
+		hsm:      hsm,
+	}, nil
+}
+
+// Finalize closes the session with the HSM
+func (s *PKCS11Seal) Finalize() error {
+	err := s.autoSeal.Finalize()
+	if closeErr := s.hsm.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// pkcs11ConfigValue returns the value of a setting, giving precedence to its
+// environment variable
+func pkcs11ConfigValue(conf map[string]string, key, env string) string {
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	return conf[key]
+}
+
+func parsePKCS11Config(conf map[string]string) (*pkcs11Config, error) {
+	config := &pkcs11Config{
+		lib:       pkcs11ConfigValue(conf, "lib", "VAULT_HSM_LIB"),
+		pin:       pkcs11ConfigValue(conf, "pin", "VAULT_HSM_PIN"),
+		keyLabel:  pkcs11ConfigValue(conf, "key_label", "VAULT_HSM_KEY_LABEL"),
+		mechanism: pkcs11MechanismAESGCM,
+	}
+	if config.lib == "" {
+		return nil, fmt.Errorf("'lib' must be set")
+	}
+	if config.pin == "" {
+		return nil, fmt.Errorf("'pin' must be set")
+	}
+	if config.keyLabel == "" {
+		return nil, fmt.Errorf("'key_label' must be set")
+	}
+
+	slot := pkcs11ConfigValue(conf, "slot", "VAULT_HSM_SLOT")
+	if slot == "" {
+		return nil, fmt.Errorf("'slot' must be set")
+	}
+	slotID, err := strconv.ParseUint(slot, 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing 'slot': %v", err)
+	}
+	config.slot = uint(slotID)
+
+	if mechanism := pkcs11ConfigValue(conf, "mechanism", "VAULT_HSM_MECHANISM"); mechanism != "" {
+		m, err := strconv.ParseUint(mechanism, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'mechanism': %v", err)
+		}
+		switch m {
+		case pkcs11MechanismAESGCM, pkcs11MechanismAESCBCPad:
+		default:
+			return nil, fmt.Errorf("unsupported mechanism %s; only CKM_AES_GCM (0x1087) and CKM_AES_CBC_PAD (0x1085) are supported", mechanism)
+		}
+		config.mechanism = uint(m)
+	}
+
+	if generateKey := pkcs11ConfigValue(conf, "generate_key", "VAULT_HSM_GENERATE_KEY"); generateKey != "" {
+		config.generateKey, err = parseutil.ParseBool(generateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'generate_key': %v", err)
+		}
+	}
+
+	// Replacing the key would leave the stored barrier keys undecryptable
+	if regenerateKey := conf["regenerate_key"]; regenerateKey != "" {
+		regenerate, err := parseutil.ParseBool(regenerateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing 'regenerate_key': %v", err)
+		}
+		if regenerate {
+			return nil, fmt.Errorf("'regenerate_key' is not supported")
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,239 @@
+// +build cgo
+
+package vault
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+	"github.com/miekg/pkcs11"
+)
+
+// pkcs11Client encrypts and decrypts with a single AES key held in an HSM,
+// through a PKCS#11 session that stays open for the lifetime of the seal
+type pkcs11Client struct {
+	ctx       *pkcs11.Ctx
+	session   pkcs11.SessionHandle
+	key       pkcs11.ObjectHandle
+	mechanism uint
+
+	// l serializes operations, since a session can only run one at a time
+	l           sync.Mutex
+	initialized bool
+	sessionOpen bool
+}
+
+// pkcs11Ciphertext is the stored form of a value encrypted by pkcs11Client.
+// The mechanism is recorded so values stay decryptable if it is changed.
+type pkcs11Ciphertext struct {
+	Mechanism  uint   `json:"mechanism"`
+	IV         []byte `json:"iv"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func newPKCS11Client(conf *pkcs11Config) (pkcs11Encrypter, error) {
+	ctx := pkcs11.New(conf.lib)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 library %q", conf.lib)
+	}
+
+	c := &pkcs11Client{
+		ctx:       ctx,
+		mechanism: conf.mechanism,
+	}
+	if err := c.open(conf); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *pkcs11Client) open(conf *pkcs11Config) error {
+	if err := c.ctx.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize PKCS#11 library: %v", err)
+	}
+	c.initialized = true
+
+	session, err := c.ctx.OpenSession(conf.slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open session on slot %d: %v", conf.slot, err)
+	}
+	c.session = session
+	c.sessionOpen = true
+
+	if err := c.ctx.Login(session, pkcs11.CKU_USER, conf.pin); err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+		return fmt.Errorf("failed to log in to slot %d: %v", conf.slot, err)
+	}
+
+	key, found, err := c.findKey(conf.keyLabel)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if !conf.generateKey {
+			return fmt.Errorf("no key with label %q found and 'generate_key' is not set", conf.keyLabel)
+		}
+		if key, err = c.generateKey(conf.keyLabel); err != nil {
+			return err
+		}
+	}
+	c.key = key
+
+	return nil
+}
+
+func (c *pkcs11Client) findKey(label string) (pkcs11.ObjectHandle, bool, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := c.ctx.FindObjectsInit(c.session, template); err != nil {
+		return 0, false, fmt.Errorf("failed to search for key with label %q: %v", label, err)
+	}
+	objs, _, err := c.ctx.FindObjects(c.session, 2)
+	if finalErr := c.ctx.FindObjectsFinal(c.session); finalErr != nil && err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to search for key with label %q: %v", label, err)
+	}
+
+	switch len(objs) {
+	case 0:
+		return 0, false, nil
+	case 1:
+		return objs[0], true, nil
+	default:
+		return 0, false, fmt.Errorf("more than one key with label %q found", label)
+	}
+}
+
+// generateKey creates a persistent AES-256 key that never leaves the HSM
+func (c *pkcs11Client) generateKey(label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_SECRET_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_AES),
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE_LEN, 32),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+		pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+	}
+	mech := []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_KEY_GEN, nil)}
+
+	key, err := c.ctx.GenerateKey(c.session, mech, template)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate key with label %q: %v", label, err)
+	}
+	return key, nil
+}
+
+// newMechanism returns the mechanism to encrypt or decrypt with, and the
+// GCM parameters to free once the operation is complete, if any
+func newMechanism(mechanism uint, iv []byte) (*pkcs11.Mechanism, *pkcs11.GCMParams, error) {
+	switch mechanism {
+	case pkcs11.CKM_AES_GCM:
+		params := pkcs11.NewGCMParams(iv, nil, 128)
+		return pkcs11.NewMechanism(mechanism, params), params, nil
+	case pkcs11.CKM_AES_CBC_PAD:
+		return pkcs11.NewMechanism(mechanism, iv), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported mechanism 0x%x", mechanism)
+	}
+}
+
+func (c *pkcs11Client) Encrypt(plaintext []byte) ([]byte, error) {
+	ivSize := 16
+	if c.mechanism == pkcs11.CKM_AES_GCM {
+		ivSize = 12
+	}
+	iv := make([]byte, ivSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	mech, params, err := newMechanism(c.mechanism, iv)
+	if err != nil {
+		return nil, err
+	}
+	defer params.Free()
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if err := c.ctx.EncryptInit(c.session, []*pkcs11.Mechanism{mech}, c.key); err != nil {
+		return nil, fmt.Errorf("failed to initialize encryption: %v", err)
+	}
+	ciphertext, err := c.ctx.Encrypt(c.session, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt: %v", err)
+	}
+
+	// Some HSMs ignore the given IV and generate their own
+	if params != nil {
+		if actual := params.IV(); len(actual) > 0 {
+			iv = actual
+		}
+	}
+
+	return jsonutil.EncodeJSON(&pkcs11Ciphertext{
+		Mechanism:  c.mechanism,
+		IV:         iv,
+		Ciphertext: ciphertext,
+	})
+}
+
+func (c *pkcs11Client) Decrypt(ciphertext []byte) ([]byte, error) {
+	var ct pkcs11Ciphertext
+	if err := jsonutil.DecodeJSON(ciphertext, &ct); err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %v", err)
+	}
+
+	mech, params, err := newMechanism(ct.Mechanism, ct.IV)
+	if err != nil {
+		return nil, err
+	}
+	defer params.Free()
+
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	if err := c.ctx.DecryptInit(c.session, []*pkcs11.Mechanism{mech}, c.key); err != nil {
+		return nil, fmt.Errorf("failed to initialize decryption: %v", err)
+	}
+	plaintext, err := c.ctx.Decrypt(c.session, ct.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %v", err)
+	}
+	return plaintext, nil
+}
+
+// Close ends the session and unloads the PKCS#11 library
+func (c *pkcs11Client) Close() error {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	var err error
+	if c.sessionOpen {
+		// Logging out is best effort; closing the session ends the login
+		c.ctx.Logout(c.session)
+		err = c.ctx.CloseSession(c.session)
+		c.sessionOpen = false
+	}
+	if c.initialized {
+		if finalizeErr := c.ctx.Finalize(); finalizeErr != nil && err == nil {
+			err = finalizeErr
+		}
+		c.initialized = false
+	}
+	if c.ctx != nil {
+		c.ctx.Destroy()
+		c.ctx = nil
+	}
+	return err
+}
@@ -0,0 +1,9 @@
+// +build !cgo
+
+package vault
+
+import "fmt"
+
+func newPKCS11Client(conf *pkcs11Config) (pkcs11Encrypter, error) {
+	return nil, fmt.Errorf("the pkcs11 seal requires Vault to be built with cgo")
+}
@@ -0,0 +1,123 @@
+package vault
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPKCS11Seal_Config(t *testing.T) {
+	base := map[string]string{
+		"lib":       "/usr/lib/softhsm/libsofthsm2.so",
+		"slot":      "0x10",
+		"pin":       "1234",
+		"key_label": "vault",
+	}
+	with := func(k, v string) map[string]string {
+		conf := make(map[string]string, len(base)+1)
+		for bk, bv := range base {
+			conf[bk] = bv
+		}
+		if v == "" {
+			delete(conf, k)
+		} else {
+			conf[k] = v
+		}
+		return conf
+	}
+
+	config, err := parsePKCS11Config(base)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.slot != 16 || config.mechanism != pkcs11MechanismAESGCM || config.generateKey {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	config, err = parsePKCS11Config(with("mechanism", "0x1085"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.mechanism != pkcs11MechanismAESCBCPad {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	config, err = parsePKCS11Config(with("generate_key", "true"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !config.generateKey {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	cases := map[string]map[string]string{
+		"'lib' must be set":                 with("lib", ""),
+		"'slot' must be set":                with("slot", ""),
+		"error parsing 'slot'":              with("slot", "first"),
+		"'pin' must be set":                 with("pin", ""),
+		"'key_label' must be set":           with("key_label", ""),
+		"unsupported mechanism":             with("mechanism", "0x1081"),
+		"error parsing 'generate_key'":      with("generate_key", "maybe"),
+		"'regenerate_key' is not supported": with("regenerate_key", "true"),
+	}
+	for expected, conf := range cases {
+		_, err := parsePKCS11Config(conf)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+// TestPKCS11Seal_AutoUnseal runs against a real PKCS#11 library, such as
+// SoftHSM, configured through the VAULT_HSM_* environment variables
+func TestPKCS11Seal_AutoUnseal(t *testing.T) {
+	if os.Getenv("VAULT_HSM_LIB") == "" {
+		t.Skip("VAULT_HSM_LIB not set")
+	}
+
+	seal, err := NewPKCS11Seal(map[string]string{
+		"generate_key": "true",
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer seal.Finalize()
+
+	ciphertext, err := seal.hsm.Encrypt([]byte("foo"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bytes.Contains(ciphertext, []byte("foo")) {
+		t.Fatalf("bad: %q", ciphertext)
+	}
+	plaintext, err := seal.hsm.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(plaintext) != "foo" {
+		t.Fatalf("bad: %q", plaintext)
+	}
+
+	core := TestCoreWithSeal(t, seal)
+	if _, err := core.Initialize(&InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+			StoredShares:    1,
+		},
+		RecoveryConfig: &SealConfig{
+			SecretShares:    1,
+			SecretThreshold: 1,
+		},
+	}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := core.UnsealWithStoredKeys(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if sealed, _ := core.Sealed(); sealed {
+		t.Fatal("should not be sealed")
+	}
+}
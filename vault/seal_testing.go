@@ -2,6 +2,7 @@ package vault
 
 import (
 	"bytes"
+	"crypto/rand"
 	"fmt"
 	"testing"
 )
@@ -18,6 +19,9 @@ type TestSeal struct {
 	recoveryConfig       *SealConfig
 	storedKeysDisabled   bool
 	recoveryKeysDisabled bool
+
+	// sealWrapKey encrypts the data keys of seal wrapped values
+	sealWrapKey []byte
 }
 
 func newTestSeal(t *testing.T) Seal {
@@ -34,6 +38,10 @@ func (d *TestSeal) checkCore() error {
 func (d *TestSeal) SetCore(core *Core) {
 	d.defseal = &DefaultSeal{}
 	d.defseal.core = core
+	d.sealWrapKey = make([]byte, 32)
+	if _, err := rand.Read(d.sealWrapKey); err != nil {
+		panic(err)
+	}
 }
 
 func (d *TestSeal) Init() error {
@@ -105,6 +113,33 @@ func (d *TestSeal) SetRecoveryKey(key []byte) error {
 	return nil
 }
 
+func (d *TestSeal) SealWrapEncrypt(plaintext []byte) ([]byte, error) {
+	return sealWrapEnvelope(func(key []byte) ([]byte, error) {
+		gcm, err := sealWrapAEAD(d.sealWrapKey)
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		return gcm.Seal(nonce, nonce, key, nil), nil
+	}, plaintext)
+}
+
+func (d *TestSeal) SealWrapDecrypt(ciphertext []byte) ([]byte, error) {
+	return sealUnwrapEnvelope(func(key []byte) ([]byte, error) {
+		gcm, err := sealWrapAEAD(d.sealWrapKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(key) < gcm.NonceSize() {
+			return nil, fmt.Errorf("invalid data key")
+		}
+		return gcm.Open(nil, key[:gcm.NonceSize()], key[gcm.NonceSize():], nil)
+	}, ciphertext)
+}
+
 func testCoreUnsealedWithConfigs(t *testing.T, barrierConf, recoveryConf *SealConfig) (*Core, [][]byte, [][]byte, string) {
 	seal := &TestSeal{}
 	core := TestCoreWithSeal(t, seal)
@@ -0,0 +1,174 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/vault/helper/jsonutil"
+)
+
+const (
+	// sealWrapVersion is the version of the format of seal wrapped values
+	sealWrapVersion = 1
+)
+
+// SealWrapper is implemented by seals able to encrypt storage entries, which
+// allows mounts to be seal wrapped: their entries are encrypted by the seal
+// in addition to the barrier, so that the barrier key alone is not enough to
+// read them.
+type SealWrapper interface {
+	SealWrapEncrypt(plaintext []byte) ([]byte, error)
+	SealWrapDecrypt(ciphertext []byte) ([]byte, error)
+}
+
+// sealWrappedValue is a value encrypted with a data key, itself encrypted by
+// the seal, as seals like KMS can only encrypt small payloads
+type sealWrappedValue struct {
+	Version    int    `json:"version"`
+	Key        []byte `json:"key"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// sealWrapEnvelope encrypts the plaintext with a new data key, which is
+// encrypted using encryptKey
+func sealWrapEnvelope(encryptKey func([]byte) ([]byte, error), plaintext []byte) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %v", err)
+	}
+	defer memzero(key)
+
+	gcm, err := sealWrapAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	wrappedKey, err := encryptKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data key: %v", err)
+	}
+
+	return json.Marshal(&sealWrappedValue{
+		Version:    sealWrapVersion,
+		Key:        wrappedKey,
+		Ciphertext: gcm.Seal(nonce, nonce, plaintext, nil),
+	})
+}
+
+// sealUnwrapEnvelope decrypts a value encrypted by sealWrapEnvelope, using
+// decryptKey to decrypt its data key
+func sealUnwrapEnvelope(decryptKey func([]byte) ([]byte, error), wrapped []byte) ([]byte, error) {
+	value := new(sealWrappedValue)
+	if err := jsonutil.DecodeJSON(wrapped, value); err != nil {
+		return nil, fmt.Errorf("failed to decode seal wrapped value: %v", err)
+	}
+	if value.Version != sealWrapVersion {
+		return nil, fmt.Errorf("unsupported seal wrapped value version %d", value.Version)
+	}
+
+	key, err := decryptKey(value.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %v", err)
+	}
+	defer memzero(key)
+
+	gcm, err := sealWrapAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(value.Ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid seal wrapped value")
+	}
+	nonce := value.Ciphertext[:gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, value.Ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt seal wrapped value: %v", err)
+	}
+	return plaintext, nil
+}
+
+func sealWrapAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealWrapStorage encrypts entries with the seal before they are written
+// to the barrier
+type sealWrapStorage struct {
+	barrier BarrierStorage
+	wrapper SealWrapper
+}
+
+func (s *sealWrapStorage) Put(entry *Entry) error {
+	value, err := s.wrapper.SealWrapEncrypt(entry.Value)
+	if err != nil {
+		return fmt.Errorf("failed to seal wrap entry: %v", err)
+	}
+	return s.barrier.Put(&Entry{
+		Key:   entry.Key,
+		Value: value,
+	})
+}
+
+func (s *sealWrapStorage) Get(key string) (*Entry, error) {
+	entry, err := s.barrier.Get(key)
+	if err != nil || entry == nil {
+		return entry, err
+	}
+	value, err := s.wrapper.SealWrapDecrypt(entry.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap entry: %v", err)
+	}
+	return &Entry{
+		Key:   entry.Key,
+		Value: value,
+	}, nil
+}
+
+func (s *sealWrapStorage) Delete(key string) error {
+	return s.barrier.Delete(key)
+}
+
+func (s *sealWrapStorage) List(prefix string) ([]string, error) {
+	return s.barrier.List(prefix)
+}
+
+// sealWrapper returns the seal used to wrap entries, or nil if seal
+// wrapping is not supported. While migrating away from a seal able to wrap
+// entries, it keeps being used so that seal wrapped mounts stay readable.
+func (c *Core) sealWrapper() SealWrapper {
+	if wrapper, ok := c.seal.(SealWrapper); ok {
+		return wrapper
+	}
+	if wrapper, ok := c.migrationSeal.(SealWrapper); ok {
+		return wrapper
+	}
+	return nil
+}
+
+// mountBarrier returns the storage of a mount. Entries of seal wrapped
+// mounts are also encrypted by the seal.
+func (c *Core) mountBarrier(entry *MountEntry) (BarrierStorage, error) {
+	if !entry.SealWrap {
+		return c.barrier, nil
+	}
+
+	wrapper := c.sealWrapper()
+	if wrapper == nil {
+		return nil, fmt.Errorf("seal wrapping is not supported by the %s seal", c.seal.BarrierType())
+	}
+	return &sealWrapStorage{
+		barrier: c.barrier,
+		wrapper: wrapper,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/logical"
+)
+
+func TestSealWrapEnvelope(t *testing.T) {
+	kms := testKMSEncrypter{}
+	plaintext := []byte("the quick brown fox")
+
+	wrapped, err := sealWrapEnvelope(kms.Encrypt, plaintext)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bytes.Contains(wrapped, plaintext) {
+		t.Fatalf("plaintext found in wrapped value: %s", wrapped)
+	}
+
+	out, err := sealUnwrapEnvelope(kms.Decrypt, wrapped)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(out, plaintext) {
+		t.Fatalf("bad: %s", out)
+	}
+
+	// Tampering with the ciphertext is detected
+	value := wrapped[:len(wrapped)-10]
+	value = append(value, []byte("AAAAAAA\"}")...)
+	if _, err := sealUnwrapEnvelope(kms.Decrypt, value); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestCore_SealWrapMount(t *testing.T) {
+	bc, rc := TestSealDefConfigs()
+	c, keys, _, root := TestCoreUnsealedWithConfigs(t, bc, rc)
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/mounts/wrapped",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"type":      "generic",
+			"seal_wrap": true,
+		},
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req = &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "wrapped/foo",
+		ClientToken: root,
+		Data: map[string]interface{}{
+			"secret": "sauce",
+		},
+	}
+	if _, err := c.HandleRequest(req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The entry is only readable through the seal
+	me := c.router.MatchingMountEntry("wrapped/")
+	if me == nil || !me.SealWrap {
+		t.Fatalf("bad: %#v", me)
+	}
+	raw, err := c.barrier.Get(backendBarrierPrefix + me.UUID + "/foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if raw == nil || strings.Contains(string(raw.Value), "sauce") {
+		t.Fatalf("bad: %#v", raw)
+	}
+	plaintext, err := c.sealWrapper().SealWrapDecrypt(raw.Value)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(string(plaintext), "sauce") {
+		t.Fatalf("bad: %s", plaintext)
+	}
+
+	req = &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "wrapped/foo",
+		ClientToken: root,
+	}
+	resp, err := c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["secret"] != "sauce" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The mount is set up again after unsealing
+	if err := c.Seal(root); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, TestKeyCopy(key)); err != nil {
+			t.Fatalf("unseal err: %v", err)
+		}
+	}
+	resp, err = c.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp == nil || resp.Data["secret"] != "sauce" {
+		t.Fatalf("bad: %#v", resp)
+	}
+}
+
+func TestCore_SealWrapMount_Unsupported(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+
+	for _, path := range []string{"sys/mounts/wrapped", "sys/auth/wrapped"} {
+		req := &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        path,
+			ClientToken: root,
+			Data: map[string]interface{}{
+				"type":      "noop",
+				"seal_wrap": true,
+			},
+		}
+		if path == "sys/mounts/wrapped" {
+			req.Data["type"] = "generic"
+		}
+		_, err := c.HandleRequest(req)
+		if err == nil || !strings.Contains(err.Error(), "seal wrapping is not supported") {
+			t.Fatalf("%s: expected error, got: %v", path, err)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/cidrutil"
 	"github.com/hashicorp/vault/helper/consts"
 	"github.com/hashicorp/vault/helper/jsonutil"
 	"github.com/hashicorp/vault/helper/locksutil"
@@ -92,6 +93,8 @@ type TokenStore struct {
 
 	policyLookupFunc func(string) (*Policy, error)
 
+	namespaceLookupFunc func(string) *namespace
+
 	tokenLocks []*locksutil.LockEntry
 
 	cubbyholeDestroyer func(*TokenStore, string) error
@@ -123,6 +126,7 @@ func NewTokenStore(c *Core, config *logical.BackendConfig) (*TokenStore, error)
 	if c.policyStore != nil {
 		t.policyLookupFunc = c.policyStore.GetPolicy
 	}
+	t.namespaceLookupFunc = c.namespaceByPath
 
 	// Setup the framework endpoints
 	t.Backend = &framework.Backend{
@@ -216,6 +220,11 @@ func NewTokenStore(c *Core, config *logical.BackendConfig) (*TokenStore, error)
 						Default:     true,
 						Description: tokenRenewableHelp,
 					},
+
+					"bound_cidrs": &framework.FieldSchema{
+						Type:        framework.TypeCommaStringSlice,
+						Description: tokenBoundCIDRsHelp,
+					},
 				},
 
 				Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -576,6 +585,18 @@ type TokenEntry struct {
 	// backends are subject to those renewal rules.
 	Period time.Duration `json:"period" mapstructure:"period" structs:"period"`
 
+	// If set, the token can only be used from source addresses that fall
+	// within one of these CIDR blocks
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
+
+	// If set, the ID of the identity entity the token was issued to on
+	// login. The policies of the entity apply to the token.
+	EntityID string `json:"entity_id" mapstructure:"entity_id" structs:"entity_id"`
+
+	// If set, the ID of the namespace the token was created in. The token
+	// can only be used within that namespace and its child namespaces.
+	NamespaceID string `json:"namespace_id" mapstructure:"namespace_id" structs:"namespace_id"`
+
 	// These are the deprecated fields
 	DisplayNameDeprecated    string        `json:"DisplayName" mapstructure:"DisplayName" structs:"DisplayName"`
 	NumUsesDeprecated        int           `json:"NumUses" mapstructure:"NumUses" structs:"NumUses"`
@@ -612,6 +633,10 @@ type tsRoleEntry struct {
 	// If set, the token entry will have an explicit maximum TTL set, rather
 	// than deferring to role/mount values
 	ExplicitMaxTTL time.Duration `json:"explicit_max_ttl" mapstructure:"explicit_max_ttl" structs:"explicit_max_ttl"`
+
+	// If set, tokens created using this role will only be usable from the
+	// given CIDR blocks
+	BoundCIDRs []string `json:"bound_cidrs" mapstructure:"bound_cidrs" structs:"bound_cidrs"`
 }
 
 type accessorEntry struct {
@@ -1487,6 +1512,22 @@ func (ts *TokenStore) handleCreateCommon(
 	// Check if the client token has sudo/root privileges for the requested path
 	isSudo := ts.System().SudoPrivilege(req.MountPoint+req.Path, req.ClientToken)
 
+	// Tokens are created in the namespace of the request. The policies of a
+	// parent from another namespace have no meaning here, so only a sudo
+	// token can create tokens outside its own namespace.
+	ns := rootNamespace
+	if ts.namespaceLookupFunc != nil {
+		ns = ts.namespaceLookupFunc(req.MountPoint)
+	}
+	parentNamespaceID := parent.NamespaceID
+	if parentNamespaceID == "" {
+		parentNamespaceID = rootNamespaceID
+	}
+	if parentNamespaceID != ns.ID && !isSudo {
+		return logical.ErrorResponse("root or sudo privileges required to create tokens in another namespace"),
+			logical.ErrInvalidRequest
+	}
+
 	// Read and parse the fields
 	var data struct {
 		ID              string
@@ -1501,6 +1542,7 @@ func (ts *TokenStore) handleCreateCommon(
 		DisplayName     string `mapstructure:"display_name"`
 		NumUses         int    `mapstructure:"num_uses"`
 		Period          string
+		BoundCIDRs      []string `mapstructure:"bound_cidrs"`
 	}
 	if err := mapstructure.WeakDecode(req.Data, &data); err != nil {
 		return logical.ErrorResponse(fmt.Sprintf(
@@ -1527,6 +1569,9 @@ func (ts *TokenStore) handleCreateCommon(
 		NumUses:      data.NumUses,
 		CreationTime: time.Now().Unix(),
 	}
+	if ns.ID != rootNamespaceID {
+		te.NamespaceID = ns.ID
+	}
 
 	renewable := true
 	if data.Renewable != nil {
@@ -1728,6 +1773,28 @@ func (ts *TokenStore) handleCreateCommon(
 		te.ExplicitMaxTTL = dur
 	}
 
+	if len(data.BoundCIDRs) > 0 {
+		te.BoundCIDRs = strutil.ParseDedupAndSortStrings(strings.Join(data.BoundCIDRs, ","), ",")
+	}
+	if role != nil && len(role.BoundCIDRs) > 0 {
+		if len(te.BoundCIDRs) > 0 {
+			subset, err := cidrutil.SubsetBlocks(role.BoundCIDRs, te.BoundCIDRs)
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("failed to validate CIDR blocks: %v", err)), logical.ErrInvalidRequest
+			}
+			if !subset {
+				return logical.ErrorResponse("bound_cidrs must be a subset of the CIDR blocks set on the role"), logical.ErrInvalidRequest
+			}
+		} else {
+			te.BoundCIDRs = role.BoundCIDRs
+		}
+	}
+	for _, block := range te.BoundCIDRs {
+		if valid, err := cidrutil.ValidateCIDRListSlice([]string{block}); err != nil || !valid {
+			return logical.ErrorResponse(fmt.Sprintf("invalid CIDR block %q in bound_cidrs", block)), logical.ErrInvalidRequest
+		}
+	}
+
 	var periodToUse time.Duration
 	if data.Period != "" {
 		if !isSudo {
@@ -1859,11 +1926,16 @@ func (ts *TokenStore) handleCreateCommon(
 		},
 		ClientToken: te.ID,
 		Accessor:    te.Accessor,
+		BoundCIDRs:  te.BoundCIDRs,
 	}
 
-	if ts.policyLookupFunc != nil {
+	policyLookupFunc := ts.policyLookupFunc
+	if ns.ID != rootNamespaceID {
+		policyLookupFunc = ns.policyStore.GetPolicy
+	}
+	if policyLookupFunc != nil {
 		for _, p := range te.Policies {
-			policy, err := ts.policyLookupFunc(p)
+			policy, err := policyLookupFunc(p)
 			if err != nil {
 				return logical.ErrorResponse(fmt.Sprintf("could not look up policy %s", p)), nil
 			}
@@ -2037,6 +2109,15 @@ func (ts *TokenStore) handleLookup(
 	if out.Period != 0 {
 		resp.Data["period"] = int64(out.Period.Seconds())
 	}
+	if len(out.BoundCIDRs) > 0 {
+		resp.Data["bound_cidrs"] = out.BoundCIDRs
+	}
+	if out.EntityID != "" {
+		resp.Data["entity_id"] = out.EntityID
+	}
+	if out.NamespaceID != "" {
+		resp.Data["namespace_id"] = out.NamespaceID
+	}
 
 	// Fetch the last renewal time
 	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(out.Path, out.ID)
@@ -2260,6 +2341,10 @@ func (ts *TokenStore) tokenStoreRoleRead(
 		},
 	}
 
+	if len(role.BoundCIDRs) > 0 {
+		resp.Data["bound_cidrs"] = role.BoundCIDRs
+	}
+
 	return resp, nil
 }
 
@@ -2375,6 +2460,21 @@ func (ts *TokenStore) tokenStoreRoleCreateUpdate(
 		entry.DisallowedPolicies = strutil.ParseDedupLowercaseAndSortStrings(data.Get("disallowed_policies").(string), ",")
 	}
 
+	boundCIDRsRaw, ok := data.GetOk("bound_cidrs")
+	if ok {
+		boundCIDRs := boundCIDRsRaw.([]string)
+		if len(boundCIDRs) > 0 {
+			valid, err := cidrutil.ValidateCIDRListSlice(boundCIDRs)
+			if err != nil {
+				return logical.ErrorResponse(fmt.Sprintf("failed to validate CIDR blocks: %v", err)), nil
+			}
+			if !valid {
+				return logical.ErrorResponse("invalid CIDR blocks"), nil
+			}
+		}
+		entry.BoundCIDRs = boundCIDRs
+	}
+
 	// Store it
 	jsonEntry, err := logical.StorageEntryJSON(fmt.Sprintf("%s%s", rolesPrefix, name), entry)
 	if err != nil {
@@ -2443,6 +2543,10 @@ no effect on the token being renewed.`
 	tokenRenewableHelp = `Tokens created via this role will be
 renewable or not according to this value.
 Defaults to "true".`
+	tokenBoundCIDRsHelp = `Comma separated string or JSON list of CIDR
+blocks. If set, tokens created via this role
+can only be used from source addresses that
+fall within one of these blocks.`
 	tokenListAccessorsHelp = `List token accessors, which can then be
 be used to iterate and discover their properities
 or revoke them. Because this can be used to
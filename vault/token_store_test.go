@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/logical"
 )
@@ -2179,6 +2180,98 @@ func TestTokenStore_RolePathSuffix(t *testing.T) {
 	}
 }
 
+func TestTokenStore_RoleBoundCIDRs(t *testing.T) {
+	core, _, _, root := TestCoreWithTokenStore(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"bound_cidrs": "127.0.0.1/32,10.0.0.0/8",
+	}
+
+	resp, err := core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp != nil {
+		t.Fatalf("expected a nil response")
+	}
+
+	req.Operation = logical.ReadOperation
+	req.Data = nil
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["bound_cidrs"], []string{"127.0.0.1/32", "10.0.0.0/8"}) {
+		t.Fatalf("bad: %#v", resp.Data["bound_cidrs"])
+	}
+
+	req.Operation = logical.UpdateOperation
+	req.Path = "auth/token/create/test"
+	req.Data = map[string]interface{}{
+		"policies": []string{"default"},
+	}
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if resp.Auth.ClientToken == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	req.ClientToken = resp.Auth.ClientToken
+	req.Operation = logical.ReadOperation
+	req.Path = "auth/token/lookup-self"
+	req.Data = nil
+
+	req.Connection = &logical.Connection{RemoteAddr: "10.1.2.3"}
+	resp, err = core.HandleRequest(req)
+	if err != nil {
+		t.Fatalf("err: %v %v", err, resp)
+	}
+	if !reflect.DeepEqual(resp.Data["bound_cidrs"], []string{"127.0.0.1/32", "10.0.0.0/8"}) {
+		t.Fatalf("bad: %#v", resp.Data["bound_cidrs"])
+	}
+
+	req.Connection = &logical.Connection{RemoteAddr: "192.168.0.1"}
+	resp, err = core.HandleRequest(req)
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v %v", err, resp)
+	}
+
+	req.Connection = nil
+	resp, err = core.HandleRequest(req)
+	if err == nil || !errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
+		t.Fatalf("expected permission denied, got: %v %v", err, resp)
+	}
+
+	// Requesting CIDRs outside of the role's blocks should fail
+	req.ClientToken = root
+	req.Connection = &logical.Connection{RemoteAddr: "127.0.0.1"}
+	req.Operation = logical.UpdateOperation
+	req.Path = "auth/token/create/test"
+	req.Data = map[string]interface{}{
+		"policies":    []string{"default"},
+		"bound_cidrs": "192.168.0.0/16",
+	}
+	resp, err = core.HandleRequest(req)
+	if err == nil {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+
+	// Invalid CIDRs are reported by their value
+	req.Path = "auth/token/create"
+	req.Data = map[string]interface{}{
+		"policies":    []string{"default"},
+		"bound_cidrs": "127.0.0.1/32,foo",
+	}
+	resp, err = core.HandleRequest(req)
+	if err == nil || !strings.Contains(resp.Data["error"].(string), `"foo"`) {
+		t.Fatalf("expected error naming the invalid block, got: %v %#v", err, resp)
+	}
+}
+
 func TestTokenStore_RolePeriod(t *testing.T) {
 	core, _, _, root := TestCoreWithTokenStore(t)
 
@@ -72,7 +72,10 @@ func (c *Core) ensureWrappingKey() error {
 	return nil
 }
 
-func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*logical.Response, error) {
+// wrapInCubbyhole wraps the response in the cubbyhole of a new wrapping
+// token. If the response is subject to a control group, the control group
+// request is stored along with it.
+func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response, cgReq *controlGroupRequest) (*logical.Response, error) {
 	// Before wrapping, obey special rules for listing: if no entries are
 	// found, 404. This prevents unwrapping only to find empty data.
 	if req.Operation == logical.ListOperation {
@@ -94,6 +97,13 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 
 	var err error
 
+	// Wrapping tokens must not outlive the system maximum TTL, regardless of
+	// what was requested
+	if resp.WrapInfo.TTL > c.maxLeaseTTL {
+		resp.AddWarning(fmt.Sprintf("requested wrap TTL of %s exceeds the system max TTL; capping to %s", resp.WrapInfo.TTL, c.maxLeaseTTL))
+		resp.WrapInfo.TTL = c.maxLeaseTTL
+	}
+
 	// If we are wrapping, the first part (performed in this functions) happens
 	// before auditing so that resp.WrapInfo.Token can contain the HMAC'd
 	// wrapping token ID in the audit logs, so that it can be determined from
@@ -108,15 +118,23 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 		ExplicitMaxTTL: resp.WrapInfo.TTL,
 	}
 
+	// Wrapping tokens belong to the namespace of the request, and can only
+	// be unwrapped within it
+	if ns := c.namespaceByPath(c.namespaceRequestPath(req.Path)); ns.ID != rootNamespaceID {
+		te.NamespaceID = ns.ID
+	}
+	rewrap := c.namespaceRelativePath(req.Path) == "sys/wrapping/rewrap"
+
 	if err := c.tokenStore.create(&te); err != nil {
 		c.logger.Error("core: failed to create wrapping token", "error", err)
 		return nil, ErrInternalError
 	}
 
 	resp.WrapInfo.Token = te.ID
+	resp.WrapInfo.Accessor = te.Accessor
 	resp.WrapInfo.CreationTime = creationTime
 	// If this is not a rewrap, store the request path as creation_path
-	if req.Path != "sys/wrapping/rewrap" {
+	if !rewrap {
 		resp.WrapInfo.CreationPath = req.Path
 	}
 
@@ -157,7 +175,7 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 	}
 
 	// During a rewrap, store the original response, don't wrap it again.
-	if req.Path == "sys/wrapping/rewrap" {
+	if rewrap {
 		cubbyReq.Data = map[string]interface{}{
 			"response": resp.Data["response"],
 		}
@@ -205,7 +223,7 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 		"creation_time": creationTime,
 	}
 	// Store creation_path if not a rewrap
-	if req.Path != "sys/wrapping/rewrap" {
+	if !rewrap {
 		cubbyReq.Data["creation_path"] = req.Path
 	} else {
 		cubbyReq.Data["creation_path"] = resp.WrapInfo.CreationPath
@@ -223,6 +241,15 @@ func (c *Core) wrapInCubbyhole(req *logical.Request, resp *logical.Response) (*l
 		return cubbyResp, nil
 	}
 
+	if cgReq != nil {
+		cgReq.Accessor = te.Accessor
+		if err := c.storeControlGroupRequest(te.ID, cgReq); err != nil {
+			c.tokenStore.Revoke(te.ID)
+			c.logger.Error("core: failed to store control group request", "error", err)
+			return nil, ErrInternalError
+		}
+	}
+
 	auth := &logical.Auth{
 		ClientToken: te.ID,
 		Policies:    []string{"response-wrapping"},